@@ -19,23 +19,21 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"syscall"
 
 	"github.com/abcxyz/jvs-plugin-jira/pkg/cli"
 )
 
 func main() {
-	ctx, done := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM)
+	ctx, done := signal.NotifyContext(context.Background(), cli.ShutdownSignals()...)
 	defer done()
 
 	if err := realMain(ctx); err != nil {
 		done()
 		fmt.Fprintln(os.Stderr, err.Error())
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 }
 
 func realMain(ctx context.Context) error {
-	return new(cli.ServerCommand).Run(ctx, os.Args[1:]) //nolint:wrapcheck // Want passthrough
+	return cli.Run(ctx, os.Args[1:]) //nolint:wrapcheck // Want passthrough
 }
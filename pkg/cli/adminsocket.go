@@ -0,0 +1,90 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// adminSocketVar adds the -admin-socket flag to the section.
+func adminSocketVar(f *cli.FlagSection, target *string, usage string) {
+	f.StringVar(&cli.StringVar{
+		Name:    "admin-socket",
+		Target:  target,
+		EnvVar:  "JIRA_PLUGIN_ADMIN_SOCKET",
+		Example: "/run/jvs-plugin-jira/admin.sock",
+		Usage:   usage,
+	})
+}
+
+// serveAdminSocket serves the admin service of p on a unix socket at pth,
+// which only the user of the process can connect to, until the returned
+// function is called. A stale socket of a previous process is replaced.
+func serveAdminSocket(logger *slog.Logger, pth string, p *plugin.JiraPlugin) (func() error, error) {
+	if err := os.Remove(pth); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale admin socket %s: %w", pth, err)
+	}
+	lis, err := net.Listen("unix", pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on admin socket %s: %w", pth, err)
+	}
+	if err := os.Chmod(pth, 0o600); err != nil {
+		lis.Close()
+		return nil, fmt.Errorf("failed to restrict admin socket %s: %w", pth, err)
+	}
+
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(recoverPanics(logger), logRequests(logger)))
+	p.RegisterAdmin(s)
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		if err := s.Serve(lis); err != nil {
+			logger.Error("failed to serve admin socket", "path", pth, "error", err)
+		}
+	}()
+
+	return func() error {
+		s.Stop()
+		<-doneCh
+		if err := os.Remove(pth); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove admin socket %s: %w", pth, err)
+		}
+		return nil
+	}, nil
+}
+
+// dialAdminSocket connects to the admin socket of a running server.
+func dialAdminSocket(ctx context.Context, pth string) (*grpc.ClientConn, error) {
+	if pth == "" {
+		return nil, newConfigError(fmt.Errorf("missing -admin-socket, the admin socket of the running server"))
+	}
+	conn, err := grpc.DialContext(ctx, "unix:"+pth, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to admin socket %s: %w", pth, err)
+	}
+	return conn, nil
+}
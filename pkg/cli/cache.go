@@ -0,0 +1,275 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// adminSocketUsage is the usage of the -admin-socket flag of the cache
+// commands.
+const adminSocketUsage = "The admin socket of the running server, see the " +
+	"-admin-socket option of the server."
+
+// adminCommand has the flags of the commands talking to the admin socket of
+// a running server.
+type adminCommand struct {
+	cli.BaseCommand
+
+	flagAdminSocket string
+	flagFormat      string
+
+	// dial connects to the admin service, it is mockable for testing and
+	// defaults to [dialAdminSocket].
+	dial func(ctx context.Context, pth string) (grpc.ClientConnInterface, func() error, error)
+}
+
+func (c *adminCommand) adminFlags(section string) *cli.FlagSet {
+	set := c.NewFlagSet()
+	f := set.NewSection(section)
+	adminSocketVar(f, &c.flagAdminSocket, adminSocketUsage)
+	formatVar(f, &c.flagFormat)
+	return set
+}
+
+// connect connects to the admin service, the returned function closes the
+// connection.
+func (c *adminCommand) connect(ctx context.Context) (grpc.ClientConnInterface, func() error, error) {
+	if err := checkFormat(c.flagFormat); err != nil {
+		return nil, nil, err
+	}
+	if c.dial != nil {
+		return c.dial(ctx, c.flagAdminSocket)
+	}
+	conn, err := dialAdminSocket(ctx, c.flagAdminSocket)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, conn.Close, nil
+}
+
+// CacheStatsCommand prints the statistics of the decision cache of a
+// running server.
+type CacheStatsCommand struct {
+	adminCommand
+}
+
+func (c *CacheStatsCommand) Desc() string {
+	return `Show the decision cache statistics of a running Jira Plugin`
+}
+
+func (c *CacheStatsCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+  Print the backend, TTL, number of entries and lookup counters of the
+  decision cache of the server serving the admin socket.
+`
+}
+
+func (c *CacheStatsCommand) Flags() *cli.FlagSet {
+	return c.adminFlags("CACHE OPTIONS")
+}
+
+func (c *CacheStatsCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	if args := f.Args(); len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+
+	conn, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn() //nolint:errcheck // Nothing to do
+
+	stats, err := plugin.GetCacheStats(ctx, conn)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, stats)
+	}
+	c.Outf("%-24s %s", "backend", stats.Backend)
+	c.Outf("%-24s %s", "ttl", stats.TTL)
+	c.Outf("%-24s %d", "entries", stats.Entries)
+	c.Outf("%-24s %d", "total entries", stats.TotalEntries)
+	c.Outf("%-24s %d", "hits", stats.Hits)
+	c.Outf("%-24s %d", "stale hits", stats.StaleHits)
+	c.Outf("%-24s %d", "misses", stats.Misses)
+	c.Outf("%-24s %d", "revalidations", stats.Revalidations)
+	return nil
+}
+
+// CacheLookupCommand prints the cached decision for an issue of a running
+// server.
+type CacheLookupCommand struct {
+	adminCommand
+
+	flagRequestor string
+}
+
+func (c *CacheLookupCommand) Desc() string {
+	return `Show the cached decision for an issue of a running Jira Plugin`
+}
+
+func (c *CacheLookupCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] ISSUE_KEY
+
+  Print the cached match of the issue for the current configuration of the
+  server serving the admin socket, and when it expires. When the JQL
+  depends on the requestor, pass the requestor.
+`
+}
+
+func (c *CacheLookupCommand) Flags() *cli.FlagSet {
+	set := c.adminFlags("CACHE OPTIONS")
+	requestorVar(set.NewSection("REQUESTOR OPTIONS"), &c.flagRequestor)
+	return set
+}
+
+func (c *CacheLookupCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) != 1 {
+		return newConfigError(fmt.Errorf("expected exactly one issue key, got %q", args))
+	}
+
+	conn, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn() //nolint:errcheck // Nothing to do
+
+	d, err := plugin.LookupCachedDecision(ctx, conn, args[0], c.flagRequestor)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, d)
+	}
+	if d == nil {
+		c.Outf("no cached decision for %s", args[0])
+		return nil
+	}
+	c.Outf("%-24s %s", "issue key", d.IssueKey)
+	c.Outf("%-24s %s", "expires at", d.ExpiresAt.UTC().Format(time.RFC3339))
+	c.Outf("%-24s %t", "expired", d.Expired)
+	if d.Result != nil {
+		for _, m := range d.Result.Matches {
+			c.Outf("%-24s %v", "matched issues", m.MatchedIssues)
+		}
+	}
+	return nil
+}
+
+// CachePurgeCommand purges cached decisions of a running server, so the
+// next validation asks Jira again.
+type CachePurgeCommand struct {
+	adminCommand
+
+	flagRequestor string
+	flagAll       bool
+}
+
+func (c *CachePurgeCommand) Desc() string {
+	return `Purge cached decisions of a running Jira Plugin`
+}
+
+func (c *CachePurgeCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] ISSUE_KEY
+       {{ COMMAND }} [options] -all
+
+  Purge the cached decisions of the issue, or of every issue with -all, from
+  the server serving the admin socket, so that the next validation asks Jira
+  again instead of waiting for the entries to expire. With a shared Redis
+  cache every replica is affected.
+`
+}
+
+func (c *CachePurgeCommand) Flags() *cli.FlagSet {
+	set := c.adminFlags("CACHE OPTIONS")
+	f := set.NewSection("PURGE OPTIONS")
+	f.BoolVar(&cli.BoolVar{
+		Name:    "all",
+		Target:  &c.flagAll,
+		Default: false,
+		Usage:   "Purge the cached decisions of every issue and configuration.",
+	})
+	requestorVar(f, &c.flagRequestor)
+	return set
+}
+
+func (c *CachePurgeCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	var issueKey string
+	switch {
+	case c.flagAll && len(args) > 0:
+		return newConfigError(fmt.Errorf("unexpected arguments with -all: %q", args))
+	case !c.flagAll && len(args) != 1:
+		return newConfigError(fmt.Errorf("expected exactly one issue key or -all, got %q", args))
+	case !c.flagAll:
+		issueKey = args[0]
+	}
+
+	conn, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn() //nolint:errcheck // Nothing to do
+
+	n, err := plugin.PurgeCache(ctx, conn, issueKey, c.flagRequestor)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, map[string]int{"purged": n})
+	}
+	c.Outf("purged %d cached decisions", n)
+	return nil
+}
+
+// requestorVar adds the -requestor flag to the section.
+func requestorVar(f *cli.FlagSection, target *string) {
+	f.StringVar(&cli.StringVar{
+		Name:    "requestor",
+		Target:  target,
+		Example: "user@example.com",
+		Usage: "The identity of the requestor, only needed when the JQL depends " +
+			"on the requestor.",
+	})
+}
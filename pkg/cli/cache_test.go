@@ -0,0 +1,185 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestCacheCommands(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":"1234","key":%q}`, strings.TrimPrefix(r.URL.Path, "/issue/"))
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	p, err := plugin.NewJiraPluginWithToken(ctx, &plugin.PluginConfig{
+		JIRAEndpoint: srv.URL,
+		Jql:          "project = ABCD",
+		JIRAAccount:  "abc@xyz.com",
+		IssueBaseURL: srv.URL,
+		AllowHTTP:    true,
+		CachePath:    filepath.Join(t.TempDir(), "decisions.db"),
+	}, "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+	if _, err := p.ValidateValue(ctx, "ABCD-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unix socket paths are limited to around 100 bytes, the test temporary
+	// directory may be longer.
+	dir, err := os.MkdirTemp("", "jpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "admin.sock")
+	stop, err := serveAdminSocket(logging.TestLogger(t), socket, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode().Perm(), os.FileMode(0o600); got != want {
+		t.Errorf("admin socket mode got %s, want %s", got, want)
+	}
+
+	// The cases share the cache of the plugin and run in order.
+	cases := []struct {
+		name         string
+		cmd          cli.Command
+		args         []string
+		wantOut      []string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name:         "stats",
+			cmd:          &CacheStatsCommand{},
+			args:         []string{"-admin-socket", socket},
+			wantOut:      []string{"backend                  file", "entries                  1", "misses                   1"},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "lookup",
+			cmd:          &CacheLookupCommand{},
+			args:         []string{"-admin-socket", socket, "ABCD-1"},
+			wantOut:      []string{"issue key                ABCD-1", "expired                  false", "matched issues           [1234]"},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "lookup_json",
+			cmd:          &CacheLookupCommand{},
+			args:         []string{"-admin-socket", socket, "-format", "json", "ABCD-1"},
+			wantOut:      []string{`"issue_key": "ABCD-1"`},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "purge_issue",
+			cmd:          &CachePurgeCommand{},
+			args:         []string{"-admin-socket", socket, "ABCD-1"},
+			wantOut:      []string{"purged 1 cached decisions"},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "lookup_purged",
+			cmd:          &CacheLookupCommand{},
+			args:         []string{"-admin-socket", socket, "ABCD-1"},
+			wantOut:      []string{"no cached decision for ABCD-1"},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "purge_all",
+			cmd:          &CachePurgeCommand{},
+			args:         []string{"-admin-socket", socket, "-all"},
+			wantOut:      []string{"purged 0 cached decisions"},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "purge_all_and_issue",
+			cmd:          &CachePurgeCommand{},
+			args:         []string{"-admin-socket", socket, "-all", "ABCD-1"},
+			wantErr:      "unexpected arguments with -all",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "lookup_without_key",
+			cmd:          &CacheLookupCommand{},
+			args:         []string{"-admin-socket", socket},
+			wantErr:      "expected exactly one issue key",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "missing_socket",
+			cmd:          &CacheStatsCommand{},
+			wantErr:      "missing -admin-socket",
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			tc.cmd.SetStdout(&stdout)
+
+			err := tc.cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+
+			out := stdout.String()
+			for _, want := range tc.wantOut {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q does not contain %q", out, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/posener/complete/v2"
+	"github.com/posener/complete/v2/predict"
+
+	"github.com/abcxyz/jvs-plugin-jira/internal/version"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// completionShells are the shells a completion script can be generated for.
+var completionShells = []string{"bash", "fish", "zsh"}
+
+// CompletionCommand prints a shell completion script for all commands and
+// flags.
+type CompletionCommand struct {
+	cli.BaseCommand
+
+	// executable returns the path of the binary the completions call, it is
+	// mockable for testing.
+	executable func() (string, error)
+}
+
+func (c *CompletionCommand) Desc() string {
+	return `Generate shell completions`
+}
+
+func (c *CompletionCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} SHELL
+
+  Print the completion script for SHELL, one of bash, fish, zsh. The script
+  calls this binary to complete commands and flags. For example:
+
+      {{ COMMAND }} bash > /etc/bash_completion.d/jvs-plugin-jira
+      {{ COMMAND }} zsh >> ~/.zshrc
+      {{ COMMAND }} fish > ~/.config/fish/completions/jvs-plugin-jira.fish
+`
+}
+
+func (c *CompletionCommand) Flags() *cli.FlagSet {
+	return c.NewFlagSet()
+}
+
+// PredictArgs completes the shell names.
+func (c *CompletionCommand) PredictArgs() complete.Predictor {
+	return predict.Set(completionShells)
+}
+
+func (c *CompletionCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) != 1 {
+		return newConfigError(fmt.Errorf("expected exactly one shell, one of %s", strings.Join(completionShells, ", ")))
+	}
+
+	executable := c.executable
+	if executable == nil {
+		executable = os.Executable
+	}
+	bin, err := executable()
+	if err != nil {
+		return fmt.Errorf("failed to find executable: %w", err)
+	}
+
+	script, err := completionScript(args[0], version.Name, bin)
+	if err != nil {
+		return newConfigError(err)
+	}
+	c.Outf("%s", script)
+	return nil
+}
+
+// completionScript returns the completion script of the shell for the
+// command, completed by calling bin. The completion protocol is the one of
+// [complete.Complete], bin is called with COMP_LINE and COMP_POINT set.
+func completionScript(shell, name, bin string) (string, error) {
+	switch shell {
+	case "bash":
+		return fmt.Sprintf("complete -C %q %s", bin, name), nil
+	case "zsh":
+		return fmt.Sprintf("autoload -U +X bashcompinit && bashcompinit\n"+
+			"complete -o nospace -C %q %s", bin, name), nil
+	case "fish":
+		// Unlike bash, fish does not set COMP_LINE and COMP_POINT itself.
+		fn := "__complete_" + strings.ReplaceAll(name, "-", "_")
+		return fmt.Sprintf("function %s\n"+
+			"    set -lx COMP_LINE (commandline -cp)\n"+
+			"    test -z (commandline -ct)\n"+
+			"    and set COMP_LINE \"$COMP_LINE \"\n"+
+			"    set -lx COMP_POINT (string length -- \"$COMP_LINE\")\n"+
+			"    %q\n"+
+			"end\n"+
+			"complete -f -c %s -a \"(%s)\"", fn, bin, name, fn), nil
+	}
+	return "", fmt.Errorf("unsupported shell %q, must be one of %s", shell, strings.Join(completionShells, ", "))
+}
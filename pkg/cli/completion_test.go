@@ -0,0 +1,105 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestCompletionCommand(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		args         []string
+		wantOut      []string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name:         "bash",
+			args:         []string{"bash"},
+			wantOut:      []string{`complete -C "/usr/local/bin/jvs-plugin-jira" jvs-plugin-jira`},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name: "zsh",
+			args: []string{"zsh"},
+			wantOut: []string{
+				"bashcompinit",
+				`complete -o nospace -C "/usr/local/bin/jvs-plugin-jira" jvs-plugin-jira`,
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name: "fish",
+			args: []string{"fish"},
+			wantOut: []string{
+				"function __complete_jvs_plugin_jira",
+				"set -lx COMP_POINT",
+				`complete -f -c jvs-plugin-jira -a "(__complete_jvs_plugin_jira)"`,
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "unsupported_shell",
+			args:         []string{"tcsh"},
+			wantErr:      `unsupported shell "tcsh"`,
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "missing_shell",
+			wantErr:      "expected exactly one shell",
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &CompletionCommand{
+				executable: func() (string, error) {
+					return "/usr/local/bin/jvs-plugin-jira", nil
+				},
+			}
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+
+			out := stdout.String()
+			for _, want := range tc.wantOut {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q does not contain %q", out, want)
+				}
+			}
+		})
+	}
+}
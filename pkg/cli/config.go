@@ -0,0 +1,153 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// ConfigCheckCommand validates a plugin configuration written as an env
+// file, for preflight checks of deployment tooling.
+type ConfigCheckCommand struct {
+	cli.BaseCommand
+
+	flagFromEnvFile string
+	flagFormat      string
+}
+
+// configCheckOutput is the JSON output of [ConfigCheckCommand].
+type configCheckOutput struct {
+	Valid            bool     `json:"valid"`
+	Errors           []string `json:"errors,omitempty"`
+	UnknownVariables []string `json:"unknown_variables,omitempty"`
+}
+
+func (c *ConfigCheckCommand) Desc() string {
+	return `Validate a Jira Plugin configuration file`
+}
+
+func (c *ConfigCheckCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] --from-env-file PATH
+
+  Validate the JIRA_PLUGIN_* variables of a dotenv or tfvars style file, one
+  NAME=value per line, like the server would at startup. Variable names are
+  case-insensitive, so Terraform variables like jira_plugin_jql work too.
+  Only the file is read, not the environment. Variables with the
+  JIRA_PLUGIN_ prefix that the plugin does not know are errors. Secrets are
+  not fetched and Jira is not contacted.
+
+  The command exits with 2 when the configuration is invalid.
+`
+}
+
+func (c *ConfigCheckCommand) Flags() *cli.FlagSet {
+	set := c.NewFlagSet()
+
+	f := set.NewSection("CONFIG CHECK OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "from-env-file",
+		Target:  &c.flagFromEnvFile,
+		Example: "plugin.env",
+		Usage:   "The env file holding the configuration.",
+	})
+
+	formatVar(f, &c.flagFormat)
+
+	return set
+}
+
+func (c *ConfigCheckCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+	if c.flagFromEnvFile == "" {
+		return newConfigError(fmt.Errorf("missing -from-env-file"))
+	}
+	if err := checkFormat(c.flagFormat); err != nil {
+		return err
+	}
+
+	out, err := checkEnvFile(c.flagFromEnvFile)
+	if err != nil {
+		return err
+	}
+
+	if c.flagFormat == formatJSON {
+		if err := outJSON(&c.BaseCommand, out); err != nil {
+			return err
+		}
+	} else if out.Valid {
+		c.Outf("%s: configuration is valid", c.flagFromEnvFile)
+	} else {
+		for _, msg := range out.Errors {
+			c.Outf("%s: %s", c.flagFromEnvFile, msg)
+		}
+		for _, name := range out.UnknownVariables {
+			c.Outf("%s: unknown variable %s", c.flagFromEnvFile, name)
+		}
+	}
+
+	if !out.Valid {
+		return newConfigError(fmt.Errorf("invalid configuration in %s", c.flagFromEnvFile))
+	}
+	return nil
+}
+
+// checkEnvFile validates the configuration in the env file at pth. It only
+// fails when the file cannot be read or parsed.
+func checkEnvFile(pth string) (*configCheckOutput, error) {
+	file, err := os.Open(pth)
+	if err != nil {
+		return nil, newConfigError(fmt.Errorf("failed to open env file: %w", err))
+	}
+	defer file.Close()
+
+	vars, err := parseEnvFile(file)
+	if err != nil {
+		return nil, newConfigError(fmt.Errorf("failed to parse %s: %w", pth, err))
+	}
+
+	cfg := &plugin.PluginConfig{}
+	set := cfg.ToFlags(cli.NewFlagSet(cli.WithLookupEnv(cli.MapLookuper(vars))))
+
+	out := &configCheckOutput{}
+	if err := set.Parse(nil); err != nil {
+		out.Errors = append(out.Errors, err.Error())
+	} else if err := cfg.Validate(); err != nil {
+		out.Errors = append(out.Errors, strings.Split(err.Error(), "\n")...)
+	}
+
+	environ := make([]string, 0, len(vars))
+	for name := range vars {
+		environ = append(environ, name+"=")
+	}
+	out.UnknownVariables = unknownEnvVars(set, environ)
+
+	out.Valid = len(out.Errors) == 0 && len(out.UnknownVariables) == 0
+	return out, nil
+}
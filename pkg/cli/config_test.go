@@ -0,0 +1,137 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestConfigCheckCommand(t *testing.T) {
+	t.Parallel()
+
+	validFile := `JIRA_PLUGIN_ENDPOINT=https://example.atlassian.net/rest/api/3
+JIRA_PLUGIN_JQL="project = ABCD"
+JIRA_PLUGIN_ACCOUNT_SECRET_ID=projects/123/secrets/account/versions/1
+JIRA_PLUGIN_API_TOKEN_SECRET_ID=projects/123/secrets/api-token/versions/1
+JIRA_PLUGIN_HINT="Jira Issue Key under JVS project"
+JIRA_PLUGIN_ISSUE_BASE_URL=https://example.atlassian.net
+`
+
+	cases := []struct {
+		name     string
+		file     string
+		args     []string
+		want     *configCheckOutput
+		wantText string
+		wantErr  string
+	}{
+		{
+			name: "valid",
+			file: validFile,
+			want: &configCheckOutput{Valid: true},
+		},
+		{
+			name:     "valid_text",
+			file:     validFile,
+			args:     []string{"-format", "text"},
+			wantText: "configuration is valid",
+		},
+		{
+			name: "invalid",
+			file: validFile + "JIRA_PLUGIN_CACHE_TTL=-1m\nJIRA_PLUGIN_ENDPONT=typo\n",
+			want: &configCheckOutput{
+				Errors:           []string{"invalid JIRA_PLUGIN_CACHE_TTL -1m0s, must be positive"},
+				UnknownVariables: []string{"JIRA_PLUGIN_ENDPONT"},
+			},
+			wantErr: "invalid configuration",
+		},
+		{
+			name: "unparsable_value",
+			file: validFile + "JIRA_PLUGIN_CACHE_TTL=soon\n",
+			want: &configCheckOutput{
+				Errors: []string{`invalid JIRA_PLUGIN_CACHE_TTL "soon": time: invalid duration "soon"`},
+			},
+			wantErr: "invalid configuration",
+		},
+		{
+			name:    "unparsable_file",
+			file:    "JIRA_PLUGIN_JQL\n",
+			wantErr: "line 1: expected NAME=value",
+		},
+		{
+			name:    "missing_file_flag",
+			args:    []string{"-format", "json"},
+			wantErr: "missing -from-env-file",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			args := tc.args
+			if args == nil {
+				args = []string{"-format", "json"}
+			}
+			if tc.file != "" {
+				pth := filepath.Join(t.TempDir(), "plugin.env")
+				if err := os.WriteFile(pth, []byte(tc.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "-from-env-file", pth)
+			}
+
+			cmd := &ConfigCheckCommand{}
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if err != nil && ExitCode(err) != ExitCodeConfig {
+				t.Errorf("got exit code %d, want %d", ExitCode(err), ExitCodeConfig)
+			}
+
+			if tc.wantText != "" {
+				if got := stdout.String(); !strings.Contains(got, tc.wantText) {
+					t.Errorf("got output %q, want %q", got, tc.wantText)
+				}
+			}
+			if tc.want != nil {
+				var got configCheckOutput
+				if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+					t.Fatalf("output is not json: %v", err)
+				}
+				if diff := cmp.Diff(tc.want, &got); diff != "" {
+					t.Errorf("unexpected output (-want,+got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// diagnosticsDumper writes a diagnostic dump, it is implemented by
+// [plugin.JiraPlugin].
+type diagnosticsDumper interface {
+	Diagnostics(context.Context) *plugin.Diagnostics
+	DumpDiagnostics(context.Context, io.Writer) error
+}
+
+// dumpOnSignal calls dump each time one of sigs is received, until the
+// returned function is called.
+func dumpOnSignal(ctx context.Context, dump func(context.Context), sigs ...os.Signal) func() {
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, sigs...)
+
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-sigCh:
+				dump(ctx)
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(stopCh)
+		<-doneCh
+	}
+}
+
+// dumpDiagnosticsOrLog writes the diagnostics of p to a new file in dir. It
+// logs them instead when the file cannot be written, e.g. on a read-only
+// file system.
+func dumpDiagnosticsOrLog(ctx context.Context, p diagnosticsDumper, dir string) {
+	logger := logging.FromContext(ctx)
+	pth, err := dumpDiagnostics(ctx, p, dir, time.Now())
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to write diagnostics, logging them instead",
+			"error", err,
+			"diagnostics", p.Diagnostics(ctx))
+		return
+	}
+	logger.InfoContext(ctx, "wrote diagnostics", "path", pth)
+}
+
+// dumpDiagnostics writes the diagnostics of p to a new file in dir and
+// returns its path.
+func dumpDiagnostics(ctx context.Context, p diagnosticsDumper, dir string, now time.Time) (string, error) {
+	// The decisions hold issue keys and requestors, keep them private.
+	pth, f, err := createDumpFile(dir, "diagnostics", "json", now)
+	if err != nil {
+		return "", fmt.Errorf("failed to create diagnostics file: %w", err)
+	}
+	if err := p.DumpDiagnostics(ctx, f); err != nil {
+		f.Close()
+		return "", err //nolint:wrapcheck // Want passthrough
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to close diagnostics file %s: %w", pth, err)
+	}
+	return pth, nil
+}
+
+// createDumpFile creates a new file only the user can read for a dump of
+// kind in dir, or the temporary directory when dir is empty. The name holds
+// the process ID and the time, so dumps of several processes do not clash.
+func createDumpFile(dir, kind, ext string, now time.Time) (string, *os.File, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	pth := filepath.Join(dir, fmt.Sprintf("jvs-plugin-jira-%s-%d-%s.%s",
+		kind, os.Getpid(), now.UTC().Format("20060102T150405.000000000Z"), ext))
+
+	f, err := os.OpenFile(pth, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", nil, err //nolint:wrapcheck // Want passthrough
+	}
+	return pth, f, nil
+}
+
+// DiagnosticsCommand prints the diagnostics of a running server.
+type DiagnosticsCommand struct {
+	adminCommand
+}
+
+func (c *DiagnosticsCommand) Desc() string {
+	return `Show the diagnostics of a running Jira Plugin`
+}
+
+func (c *DiagnosticsCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+  Print the state of the server serving the admin socket: the validations in
+  flight, the health of the Jira endpoints, the rate limit and request
+  statistics, the cache statistics, the recent decisions and the stacks of
+  all goroutines. The server writes the same dump to a file on SIGUSR1.
+
+  The configuration is only part of the json output, with the passwords of
+  URLs redacted.
+`
+}
+
+func (c *DiagnosticsCommand) Flags() *cli.FlagSet {
+	return c.adminFlags("DIAGNOSTICS OPTIONS")
+}
+
+func (c *DiagnosticsCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	if args := f.Args(); len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+
+	conn, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn() //nolint:errcheck // Nothing to do
+
+	d, err := plugin.GetDiagnostics(ctx, conn)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, d)
+	}
+	c.Outf("%-24s %s", "time", d.Time.Format(time.RFC3339))
+	c.Outf("%-24s %s", "version", d.Version)
+	c.Outf("%-24s %d", "in flight", d.InFlight)
+	for _, e := range d.Endpoints {
+		c.Outf("%-24s %s healthy=%t selected=%t latency=%s", "endpoint", e.URL, e.Healthy, e.Selected, e.Latency)
+	}
+	if rl := d.RateLimit; rl != nil {
+		c.Outf("%-24s %d remaining, observed %s", "rate limit", rl.Remaining, rl.ObservedAt.Format(time.RFC3339))
+	}
+	names := make([]string, 0, len(d.APIStats))
+	for name := range d.APIStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := d.APIStats[name]
+		c.Outf("%-24s %d requests, %d failures, max latency %s", "api "+name, s.Requests, s.Failures, s.MaxLatency)
+	}
+	if s := d.Cache; s != nil {
+		c.Outf("%-24s %d entries, %d hits, %d misses", "cache", s.Entries, s.Hits, s.Misses)
+	}
+	if d.CacheError != "" {
+		c.Outf("%-24s %s", "cache error", d.CacheError)
+	}
+	if s := d.NotFoundCache; s != nil {
+		c.Outf("%-24s %d entries, %d hits, %d misses", "not found cache", s.Entries, s.Hits, s.Misses)
+	}
+	for _, flag := range d.FeatureFlags {
+		c.Outf("%-24s %s", "feature "+flag.Name, featureFlagState(flag))
+	}
+	for _, dec := range d.Decisions {
+		c.Outf("%-24s %v %v valid=%v errors=%v", "decision", dec["time"], dec["value"], dec["valid"], dec["errors"])
+	}
+	c.Outf("")
+	c.Outf("%s", d.Goroutines)
+	return nil
+}
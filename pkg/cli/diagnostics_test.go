@@ -0,0 +1,82 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+)
+
+type fakeDiagnosticsDumper struct {
+	d *plugin.Diagnostics
+}
+
+func (f *fakeDiagnosticsDumper) Diagnostics(context.Context) *plugin.Diagnostics {
+	return f.d
+}
+
+func (f *fakeDiagnosticsDumper) DumpDiagnostics(_ context.Context, w io.Writer) error {
+	return json.NewEncoder(w).Encode(f.d) //nolint:wrapcheck // Test
+}
+
+func TestDumpDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	d := &fakeDiagnosticsDumper{d: &plugin.Diagnostics{InFlight: 3, Goroutines: "goroutine 1 [running]:"}}
+
+	pth, err := dumpDiagnostics(ctx, d, dir, now)
+	if err != nil {
+		t.Fatalf("failed to dump diagnostics: %v", err)
+	}
+	if got, want := filepath.Dir(pth), dir; got != want {
+		t.Errorf("got diagnostics in %s, want %s", got, want)
+	}
+
+	fi, err := os.Stat(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fi.Mode().Perm(), os.FileMode(0o600); runtime.GOOS != "windows" && got != want {
+		t.Errorf("got diagnostics file mode %s, want %s", got, want)
+	}
+
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		t.Fatalf("failed to read diagnostics: %v", err)
+	}
+	var got plugin.Diagnostics
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("failed to decode diagnostics: %v", err)
+	}
+	if got.InFlight != 3 || got.Goroutines != d.d.Goroutines {
+		t.Errorf("got diagnostics %+v, want %+v", got, d.d)
+	}
+
+	// A second dump at the same time does not overwrite the first one.
+	if _, err := dumpDiagnostics(ctx, d, dir, now); err == nil {
+		t.Errorf("expected an error dumping to an existing file")
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// ANSI escape sequences used to colorize the doctor report.
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+// DoctorCommand runs a sequence of diagnostic checks against the plugin
+// configuration and prints a report.
+type DoctorCommand struct {
+	cli.BaseCommand
+
+	cfg *plugin.PluginConfig
+
+	flagIssue   string
+	flagFormat  string
+	flagJSON    bool
+	flagNoColor bool
+}
+
+func (c *DoctorCommand) Desc() string {
+	return `Check that the Jira Plugin is correctly configured`
+}
+
+func (c *DoctorCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+  Sequentially check the configuration, secret access, connectivity to the
+  Jira endpoint, authentication, JQL, a sample issue and clock skew.
+`
+}
+
+func (c *DoctorCommand) Flags() *cli.FlagSet {
+	c.cfg = &plugin.PluginConfig{}
+	set := c.cfg.ToFlags(c.NewFlagSet())
+
+	f := set.NewSection("DOCTOR OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "issue",
+		Target:  &c.flagIssue,
+		Example: "ABCD-123",
+		Usage:   "A sample issue key to fetch and match against the JQL.",
+	})
+
+	formatVar(f, &c.flagFormat)
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "json",
+		Target:  &c.flagJSON,
+		Default: false,
+		Usage:   "Print the report as JSON, same as --format json.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "no-color",
+		Target:  &c.flagNoColor,
+		Default: false,
+		Usage:   "Disable colorized output. Also disabled when NO_COLOR is set to any non-empty value.",
+	})
+
+	return set
+}
+
+func (c *DoctorCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+
+	if c.flagJSON {
+		c.flagFormat = formatJSON
+	}
+	if err := checkFormat(c.flagFormat); err != nil {
+		return err
+	}
+
+	report := plugin.NewDoctor(c.cfg, c.flagIssue).Run(ctx)
+
+	if c.flagFormat == formatJSON {
+		if err := outJSON(&c.BaseCommand, report); err != nil {
+			return err
+		}
+	} else {
+		c.printReport(report)
+	}
+
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("one or more checks failed: %w", err)
+	}
+	return nil
+}
+
+// printReport writes a human-readable report, colorized when stdout is a
+// terminal.
+func (c *DoctorCommand) printReport(report *plugin.DoctorReport) {
+	// See https://no-color.org, any non-empty value disables color.
+	color := !c.flagNoColor && c.GetEnv("NO_COLOR") == "" && isTerminal(c.Stdout())
+	for _, check := range report.Checks {
+		status := string(check.Status)
+		if color {
+			switch check.Status {
+			case plugin.CheckPass:
+				status = colorGreen + status + colorReset
+			case plugin.CheckFail:
+				status = colorRed + status + colorReset
+			case plugin.CheckSkip:
+				status = colorYellow + status + colorReset
+			}
+		}
+		c.Outf("[%s] %-12s %s", status, check.Name, check.Detail)
+	}
+}
+
+// isTerminal reports whether w is a character device.
+func isTerminal(w any) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		wantOut      []string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name: "json_report",
+			args: []string{"--json"},
+			wantOut: []string{
+				`"name": "config"`,
+				`"status": "fail"`,
+				`"status": "skip"`,
+			},
+			wantErr:      "one or more checks failed",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name: "text_report_no_color_env",
+			env:  map[string]string{"NO_COLOR": "yes"},
+			wantOut: []string{
+				"[fail] config",
+				"[skip] secret",
+			},
+			wantErr:      "one or more checks failed",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name: "format_json",
+			args: []string{"--format", "json"},
+			wantOut: []string{
+				`"name": "config"`,
+			},
+			wantErr:      "one or more checks failed",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "unexpected_args",
+			args:         []string{"extra"},
+			wantErr:      `unexpected arguments: ["extra"]`,
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &DoctorCommand{}
+			cmd.SetLookupEnv(cli.MapLookuper(tc.env))
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+
+			out := stdout.String()
+			for _, want := range tc.wantOut {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q does not contain %q", out, want)
+				}
+			}
+			if strings.Contains(out, "\033[") {
+				t.Errorf("output %q is colorized", out)
+			}
+
+			if tc.args != nil && (tc.args[0] == "--json" || tc.args[0] == "--format") {
+				var report plugin.DoctorReport
+				if err := json.Unmarshal([]byte(out), &report); err != nil {
+					t.Errorf("output is not a JSON report: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// envFileKeyRe matches the variable names of an env file.
+var envFileKeyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseEnvFile parses the variables of a dotenv or tfvars style file, one
+// NAME=value assignment per line. Names are upper-cased, so the snake case
+// names of Terraform variables map to the environment variables. Values may
+// be double quoted with Go escapes, single quoted verbatim, or bare, with an
+// optional " #" comment. Blank lines, "#" and "//" comments and an "export "
+// prefix are ignored. A name assigned twice is an error.
+func parseEnvFile(r io.Reader) (map[string]string, error) {
+	vars := make(map[string]string)
+	lines := make(map[string]int)
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected NAME=value", n)
+		}
+		name = strings.TrimSpace(name)
+		if !envFileKeyRe.MatchString(name) {
+			return nil, fmt.Errorf("line %d: invalid name %q", n, name)
+		}
+		name = strings.ToUpper(name)
+		if prev, ok := lines[name]; ok {
+			return nil, fmt.Errorf("line %d: %s already set on line %d", n, name, prev)
+		}
+
+		value, err := parseEnvFileValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid value of %s: %w", n, name, err)
+		}
+		vars[name] = value
+		lines[name] = n
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	return vars, nil
+}
+
+// parseEnvFileValue returns the value of an assignment in an env file.
+func parseEnvFileValue(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		s, err := strconv.Unquote(v)
+		if err != nil {
+			return "", fmt.Errorf("unterminated or invalid double quoted string")
+		}
+		return s, nil
+	case strings.HasPrefix(v, "'"):
+		if len(v) < 2 || !strings.HasSuffix(v, "'") {
+			return "", fmt.Errorf("unterminated single quoted string")
+		}
+		return v[1 : len(v)-1], nil
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	return v, nil
+}
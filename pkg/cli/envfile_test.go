@@ -0,0 +1,99 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		in      string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "dotenv",
+			in: `# Jira
+export JIRA_PLUGIN_ENDPOINT=https://example.atlassian.net/rest/api/3
+JIRA_PLUGIN_JQL="project = ABCD AND status = \"In Progress\""
+JIRA_PLUGIN_HINT='Jira issue key, e.g. ABCD-1'
+JIRA_PLUGIN_CACHE_TTL=10m # refreshed conditionally
+
+`,
+			want: map[string]string{
+				"JIRA_PLUGIN_ENDPOINT":  "https://example.atlassian.net/rest/api/3",
+				"JIRA_PLUGIN_JQL":       `project = ABCD AND status = "In Progress"`,
+				"JIRA_PLUGIN_HINT":      "Jira issue key, e.g. ABCD-1",
+				"JIRA_PLUGIN_CACHE_TTL": "10m",
+			},
+		},
+		{
+			name: "tfvars",
+			in: `// Jira
+jira_plugin_endpoint = "https://example.atlassian.net/rest/api/3"
+jira_plugin_debug_annotations = true
+`,
+			want: map[string]string{
+				"JIRA_PLUGIN_ENDPOINT":          "https://example.atlassian.net/rest/api/3",
+				"JIRA_PLUGIN_DEBUG_ANNOTATIONS": "true",
+			},
+		},
+		{
+			name:    "missing_equals",
+			in:      "JIRA_PLUGIN_JQL\n",
+			wantErr: "line 1: expected NAME=value",
+		},
+		{
+			name:    "invalid_name",
+			in:      "JIRA-PLUGIN-JQL=x\n",
+			wantErr: `line 1: invalid name "JIRA-PLUGIN-JQL"`,
+		},
+		{
+			name:    "duplicate",
+			in:      "JIRA_PLUGIN_JQL=a\n\njira_plugin_jql=b\n",
+			wantErr: "line 3: JIRA_PLUGIN_JQL already set on line 1",
+		},
+		{
+			name:    "unterminated_quote",
+			in:      `JIRA_PLUGIN_JQL="project = ABCD`,
+			wantErr: "line 1: invalid value of JIRA_PLUGIN_JQL",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseEnvFile(strings.NewReader(tc.in))
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected variables (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
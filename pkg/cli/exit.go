@@ -0,0 +1,72 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+)
+
+// Exit codes returned by the binary, see docs/cli.md. They let deployment
+// pipelines and health probes tell a misconfiguration, which will not fix
+// itself on restart, from Jira being down or rejecting the credentials.
+const (
+	ExitCodeOK              = 0
+	ExitCodeRuntime         = 1
+	ExitCodeConfig          = 2
+	ExitCodeJiraUnreachable = 3
+	ExitCodeJiraAuth        = 4
+)
+
+// configError marks an error as caused by invalid flags or configuration, it
+// wraps [plugin.ErrInvalidConfig] without adding it to the message.
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string {
+	return e.err.Error()
+}
+
+func (e *configError) Unwrap() []error {
+	return []error{e.err, plugin.ErrInvalidConfig}
+}
+
+// newConfigError wraps err as a configuration error.
+func newConfigError(err error) error {
+	return &configError{err: err}
+}
+
+// ExitCode returns the process exit code for the error returned by [Run].
+// Flag and validation errors as well as plugin construction errors caused by
+// the configuration map to [ExitCodeConfig], Jira failures to
+// [ExitCodeJiraUnreachable] or [ExitCodeJiraAuth]. A configuration error
+// takes precedence, as it is usually the cause of the others.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+
+	switch {
+	case errors.Is(err, plugin.ErrInvalidConfig):
+		return ExitCodeConfig
+	case errors.Is(err, plugin.ErrJiraUnreachable):
+		return ExitCodeJiraUnreachable
+	case errors.Is(err, plugin.ErrJiraAuth):
+		return ExitCodeJiraAuth
+	}
+	return ExitCodeRuntime
+}
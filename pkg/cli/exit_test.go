@@ -0,0 +1,85 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+)
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: ExitCodeOK,
+		},
+		{
+			name: "config_error",
+			err:  newConfigError(fmt.Errorf("invalid configuration")),
+			want: ExitCodeConfig,
+		},
+		{
+			name: "wrapped_config_error",
+			err:  fmt.Errorf("failed to instantiate jira plugin: %w", newConfigError(fmt.Errorf("bad flag"))),
+			want: ExitCodeConfig,
+		},
+		{
+			name: "plugin_invalid_config",
+			err:  fmt.Errorf("failed to instantiate audit sink: %w", plugin.ErrInvalidConfig),
+			want: ExitCodeConfig,
+		},
+		{
+			name: "jira_unreachable",
+			err:  fmt.Errorf("failed to connect to jira: %w", plugin.ErrJiraUnreachable),
+			want: ExitCodeJiraUnreachable,
+		},
+		{
+			name: "jira_auth",
+			err:  fmt.Errorf("failed to connect to jira: %w", plugin.ErrJiraAuth),
+			want: ExitCodeJiraAuth,
+		},
+		{
+			name: "config_before_jira",
+			err:  errors.Join(plugin.ErrJiraUnreachable, plugin.ErrInvalidConfig),
+			want: ExitCodeConfig,
+		},
+		{
+			name: "runtime_error",
+			err:  fmt.Errorf("failed to fetch API token"),
+			want: ExitCodeRuntime,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, want := ExitCode(tc.err), tc.want; got != want {
+				t.Errorf("ExitCode(%v) got %d, want %d", tc.err, got, want)
+			}
+		})
+	}
+}
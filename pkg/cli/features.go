@@ -0,0 +1,196 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// FeaturesListCommand prints the feature flags of a running server.
+type FeaturesListCommand struct {
+	adminCommand
+}
+
+func (c *FeaturesListCommand) Desc() string {
+	return `Show the feature flags of a running Jira Plugin`
+}
+
+func (c *FeaturesListCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+  Print whether each feature flag of the server serving the admin socket is
+  on, its configured value, and whether it is overridden.
+`
+}
+
+func (c *FeaturesListCommand) Flags() *cli.FlagSet {
+	return c.adminFlags("FEATURE OPTIONS")
+}
+
+func (c *FeaturesListCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	if args := f.Args(); len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+
+	conn, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn() //nolint:errcheck // Nothing to do
+
+	flags, err := plugin.GetFeatureFlags(ctx, conn)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+	return c.outFlags(flags)
+}
+
+// FeaturesSetCommand overrides a feature flag of a running server.
+type FeaturesSetCommand struct {
+	adminCommand
+}
+
+func (c *FeaturesSetCommand) Desc() string {
+	return `Override a feature flag of a running Jira Plugin`
+}
+
+func (c *FeaturesSetCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] NAME on|off
+
+  Turn the feature flag on or off on the server serving the admin socket,
+  regardless of its configuration, until the override is reset or the
+  server restarts. Validations starting afterwards see the new value.
+`
+}
+
+func (c *FeaturesSetCommand) Flags() *cli.FlagSet {
+	return c.adminFlags("FEATURE OPTIONS")
+}
+
+func (c *FeaturesSetCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) != 2 {
+		return newConfigError(fmt.Errorf("expected a feature flag name and on or off, got %q", args))
+	}
+	var enabled bool
+	switch strings.ToLower(args[1]) {
+	case "on":
+		enabled = true
+	case "off":
+	default:
+		return newConfigError(fmt.Errorf("invalid feature flag value %q, must be on or off", args[1]))
+	}
+
+	conn, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn() //nolint:errcheck // Nothing to do
+
+	flags, err := plugin.SetFeatureFlag(ctx, conn, args[0], enabled)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+	return c.outFlags(flags)
+}
+
+// FeaturesResetCommand removes the override of a feature flag of a running
+// server.
+type FeaturesResetCommand struct {
+	adminCommand
+}
+
+func (c *FeaturesResetCommand) Desc() string {
+	return `Remove the override of a feature flag of a running Jira Plugin`
+}
+
+func (c *FeaturesResetCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] NAME
+
+  Remove the override of the feature flag on the server serving the admin
+  socket, so that its configured value applies again.
+`
+}
+
+func (c *FeaturesResetCommand) Flags() *cli.FlagSet {
+	return c.adminFlags("FEATURE OPTIONS")
+}
+
+func (c *FeaturesResetCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) != 1 {
+		return newConfigError(fmt.Errorf("expected exactly one feature flag name, got %q", args))
+	}
+
+	conn, closeConn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeConn() //nolint:errcheck // Nothing to do
+
+	flags, err := plugin.ResetFeatureFlag(ctx, conn, args[0])
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+	return c.outFlags(flags)
+}
+
+// outFlags prints the feature flags in the format of the command.
+func (c *adminCommand) outFlags(flags []plugin.FeatureFlag) error {
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, flags)
+	}
+	for _, flag := range flags {
+		c.Outf("%-24s %s", flag.Name, featureFlagState(flag))
+	}
+	return nil
+}
+
+// featureFlagState describes the state of the flag, e.g. "off (overridden,
+// configured on)".
+func featureFlagState(flag plugin.FeatureFlag) string {
+	state := onOff(flag.Enabled)
+	if flag.Overridden {
+		state += " (overridden, configured " + onOff(flag.Configured) + ")"
+	}
+	return state
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
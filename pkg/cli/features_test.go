@@ -0,0 +1,161 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestFeaturesCommands(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":"1234","key":%q}`, strings.TrimPrefix(r.URL.Path, "/issue/"))
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	p, err := plugin.NewJiraPluginWithToken(ctx, &plugin.PluginConfig{
+		JIRAEndpoint: srv.URL,
+		Jql:          "project = ABCD",
+		JIRAAccount:  "abc@xyz.com",
+		IssueBaseURL: srv.URL,
+		AllowHTTP:    true,
+		FeatureFlags: []string{"decision_stream=off"},
+	}, "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	// Unix socket paths are limited to around 100 bytes, the test temporary
+	// directory may be longer.
+	dir, err := os.MkdirTemp("", "jpf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "admin.sock")
+	stop, err := serveAdminSocket(logging.TestLogger(t), socket, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// The cases share the flags of the plugin and run in order.
+	cases := []struct {
+		name         string
+		cmd          cli.Command
+		args         []string
+		wantOut      []string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name:         "list",
+			cmd:          &FeaturesListCommand{},
+			args:         []string{"-admin-socket", socket},
+			wantOut:      []string{"cache                    on", "decision_stream          off"},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "set",
+			cmd:          &FeaturesSetCommand{},
+			args:         []string{"-admin-socket", socket, "cache", "off"},
+			wantOut:      []string{"cache                    off (overridden, configured on)"},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "list_json",
+			cmd:          &FeaturesListCommand{},
+			args:         []string{"-admin-socket", socket, "-format", "json"},
+			wantOut:      []string{`"name": "cache"`, `"overridden": true`},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "reset",
+			cmd:          &FeaturesResetCommand{},
+			args:         []string{"-admin-socket", socket, "cache"},
+			wantOut:      []string{"cache                    on\n"},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "set_unknown",
+			cmd:          &FeaturesSetCommand{},
+			args:         []string{"-admin-socket", socket, "hedging", "on"},
+			wantErr:      `unknown feature flag "hedging"`,
+			wantExitCode: ExitCodeRuntime,
+		},
+		{
+			name:         "set_invalid_value",
+			cmd:          &FeaturesSetCommand{},
+			args:         []string{"-admin-socket", socket, "cache", "maybe"},
+			wantErr:      `invalid feature flag value "maybe", must be on or off`,
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "reset_without_name",
+			cmd:          &FeaturesResetCommand{},
+			args:         []string{"-admin-socket", socket},
+			wantErr:      "expected exactly one feature flag name",
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			tc.cmd.SetStdout(&stdout)
+
+			err := tc.cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+
+			out := stdout.String()
+			for _, want := range tc.wantOut {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q does not contain %q", out, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/posener/complete/v2/predict"
+
+	"github.com/abcxyz/pkg/cli"
+)
+
+// Output formats of the --format flag.
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// formatVar adds the --format flag to the section.
+func formatVar(f *cli.FlagSection, target *string) {
+	f.StringVar(&cli.StringVar{
+		Name:    "format",
+		Target:  target,
+		Default: formatText,
+		Example: formatJSON,
+		Predict: predict.Set{formatText, formatJSON},
+		Usage:   "The output format, one of text, json.",
+	})
+}
+
+// checkFormat returns a config error when format is not a known output
+// format.
+func checkFormat(format string) error {
+	switch format {
+	case formatText, formatJSON:
+		return nil
+	}
+	return newConfigError(fmt.Errorf("invalid format %q, must be one of text, json", format))
+}
+
+// outJSON writes v as indented JSON to the command output.
+func outJSON(c *cli.BaseCommand, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	c.Outf("%s", b)
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/abcxyz/pkg/cli"
+)
+
+// HealthcheckCommand checks the health file written by a running server. It
+// is meant as a container HEALTHCHECK or liveness probe that needs no open
+// port.
+type HealthcheckCommand struct {
+	cli.BaseCommand
+
+	flagHealthFile string
+	flagMaxAge     time.Duration
+
+	// now returns the current time, it is mockable for testing.
+	now func() time.Time
+}
+
+func (c *HealthcheckCommand) Desc() string {
+	return `Check that a running Jira Plugin is healthy`
+}
+
+func (c *HealthcheckCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+  Exit with 0 when the health file written by the server, see the server
+  -health-file option, was updated recently, and with 1 otherwise.
+`
+}
+
+func (c *HealthcheckCommand) Flags() *cli.FlagSet {
+	set := c.NewFlagSet()
+
+	f := set.NewSection("HEALTHCHECK OPTIONS")
+
+	healthFileVar(f, &c.flagHealthFile)
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "max-age",
+		Target:  &c.flagMaxAge,
+		Default: defaultHealthMaxAge,
+		Example: "1m",
+		Usage:   "How old the health file may get before the plugin is unhealthy.",
+	})
+
+	return set
+}
+
+// Run returns no config errors, unlike the other commands. Docker reserves
+// the exit code 2 of a HEALTHCHECK, so every failure exits with 1.
+func (c *HealthcheckCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+	args = f.Args()
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %q", args)
+	}
+	if c.flagHealthFile == "" {
+		return fmt.Errorf("no health file given, use -health-file or JIRA_PLUGIN_HEALTH_FILE")
+	}
+
+	now := c.now
+	if now == nil {
+		now = time.Now
+	}
+	age, err := checkHealthFile(c.flagHealthFile, c.flagMaxAge, now())
+	if err != nil {
+		return fmt.Errorf("unhealthy: %w", err)
+	}
+	c.Outf("healthy, updated %s ago", age.Round(time.Second))
+	return nil
+}
+
+// healthFileVar adds the -health-file flag to the section, it is shared by
+// the server writing the file and the healthcheck reading it.
+func healthFileVar(f *cli.FlagSection, target *string) {
+	f.StringVar(&cli.StringVar{
+		Name:    "health-file",
+		Target:  target,
+		EnvVar:  "JIRA_PLUGIN_HEALTH_FILE",
+		Example: "/tmp/jvs-plugin-jira.health",
+		Usage:   "If set, the server updates this file every 10s while serving, for the healthcheck command.",
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestHealthcheckCommand(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	pth := filepath.Join(t.TempDir(), "plugin.health")
+	if err := os.WriteFile(pth, []byte(`{"pid":1,"time":"2023-10-01T11:59:45Z"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		wantOut      string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name:         "healthy",
+			env:          map[string]string{"JIRA_PLUGIN_HEALTH_FILE": pth},
+			wantOut:      "healthy, updated 15s ago",
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "too_old",
+			args:         []string{"-health-file", pth, "-max-age", "10s"},
+			wantErr:      "unhealthy: health file",
+			wantExitCode: ExitCodeRuntime,
+		},
+		{
+			name:         "missing_file",
+			args:         []string{"-health-file", filepath.Join(t.TempDir(), "missing")},
+			wantErr:      "unhealthy: failed to read health file",
+			wantExitCode: ExitCodeRuntime,
+		},
+		{
+			// Docker reserves exit code 2, so even a broken probe exits with 1.
+			name:         "no_health_file",
+			wantErr:      "no health file given",
+			wantExitCode: ExitCodeRuntime,
+		},
+		{
+			name:         "unexpected_args",
+			args:         []string{"extra"},
+			wantErr:      `unexpected arguments: ["extra"]`,
+			wantExitCode: ExitCodeRuntime,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &HealthcheckCommand{
+				now: func() time.Time { return now },
+			}
+			cmd.SetLookupEnv(cli.MapLookuper(tc.env))
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+			if out := stdout.String(); !strings.Contains(out, tc.wantOut) {
+				t.Errorf("output %q does not contain %q", out, tc.wantOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+const (
+	// healthInterval is how often the server rewrites the health file.
+	healthInterval = 10 * time.Second
+
+	// defaultHealthMaxAge is how old the health file may get before the
+	// healthcheck fails, it allows for two missed updates.
+	defaultHealthMaxAge = 3 * healthInterval
+)
+
+// healthStatus is the content of the health file.
+type healthStatus struct {
+	PID  int       `json:"pid"`
+	Time time.Time `json:"time"`
+}
+
+// writeHealthFile writes the health file now and then every interval until
+// the returned function is called, which removes the file again. Failing to
+// update the file is logged, the healthcheck then fails once the file is too
+// old.
+func writeHealthFile(ctx context.Context, pth string, interval time.Duration) (func() error, error) {
+	if err := updateHealthFile(pth, time.Now()); err != nil {
+		return nil, err
+	}
+
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case now := <-ticker.C:
+				if err := updateHealthFile(pth, now); err != nil {
+					logging.FromContext(ctx).ErrorContext(ctx, "failed to update health file", "error", err)
+				}
+			}
+		}
+	}()
+
+	return func() error {
+		close(stopCh)
+		<-doneCh
+		if err := os.Remove(pth); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove health file %s: %w", pth, err)
+		}
+		return nil
+	}, nil
+}
+
+// updateHealthFile replaces the health file, so a concurrent healthcheck
+// never reads a partial write.
+func updateHealthFile(pth string, now time.Time) error {
+	b, err := json.Marshal(&healthStatus{PID: os.Getpid(), Time: now.UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal health status: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(pth), filepath.Base(pth)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write health file %s: %w", pth, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write health file %s: %w", pth, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write health file %s: %w", pth, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil { //nolint:gosec // health files are world-readable like PID files
+		return fmt.Errorf("failed to write health file %s: %w", pth, err)
+	}
+	if err := os.Rename(tmp.Name(), pth); err != nil {
+		return fmt.Errorf("failed to write health file %s: %w", pth, err)
+	}
+	return nil
+}
+
+// checkHealthFile returns the age of the health file, or an error when it is
+// missing, invalid or older than maxAge.
+func checkHealthFile(pth string, maxAge time.Duration, now time.Time) (time.Duration, error) {
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read health file: %w", err)
+	}
+
+	var status healthStatus
+	if err := json.Unmarshal(b, &status); err != nil {
+		return 0, fmt.Errorf("failed to parse health file %s: %w", pth, err)
+	}
+
+	age := now.Sub(status.Time)
+	if age > maxAge {
+		return age, fmt.Errorf("health file %s was last updated %s ago, more than %s", pth, age.Round(time.Second), maxAge)
+	}
+	return age, nil
+}
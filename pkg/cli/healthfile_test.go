@@ -0,0 +1,130 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestWriteHealthFile(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	pth := filepath.Join(t.TempDir(), "plugin.health")
+
+	remove, err := writeHealthFile(ctx, pth, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("writeHealthFile() got unexpected error: %v", err)
+	}
+	if _, err := checkHealthFile(pth, time.Minute, time.Now()); err != nil {
+		t.Errorf("health file is not healthy: %v", err)
+	}
+
+	// The file is rewritten while serving.
+	first, err := os.ReadFile(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b, err := os.ReadFile(pth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != string(first) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("health file was not updated")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := remove(); err != nil {
+		t.Errorf("failed to remove health file: %v", err)
+	}
+	if _, err := os.Stat(pth); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("health file still exists after removal: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(pth))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("got leftover files %v", entries)
+	}
+}
+
+func TestCheckHealthFile(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name    string
+		content string
+		missing bool
+		wantErr string
+	}{
+		{
+			name:    "fresh",
+			content: `{"pid":1,"time":"2023-10-01T11:59:50Z"}`,
+		},
+		{
+			name:    "stale",
+			content: `{"pid":1,"time":"2023-10-01T11:59:00Z"}`,
+			wantErr: "was last updated 1m0s ago, more than 30s",
+		},
+		{
+			name:    "invalid",
+			content: `ok`,
+			wantErr: "failed to parse health file",
+		},
+		{
+			name:    "missing",
+			missing: true,
+			wantErr: "failed to read health file",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pth := filepath.Join(t.TempDir(), "plugin.health")
+			if !tc.missing {
+				if err := os.WriteFile(pth, []byte(tc.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := checkHealthFile(pth, 30*time.Second, now)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
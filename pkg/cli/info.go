@@ -0,0 +1,92 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abcxyz/jvs-plugin-jira/internal/version"
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// InfoCommand prints the protocol versions and features of the plugin.
+type InfoCommand struct {
+	cli.BaseCommand
+
+	cfg *plugin.PluginConfig
+
+	flagFormat string
+}
+
+// infoOutput is the JSON output of [InfoCommand].
+type infoOutput struct {
+	Version string `json:"version"`
+	*plugin.Info
+}
+
+func (c *InfoCommand) Desc() string {
+	return `Print the protocol versions and features of the Jira Plugin`
+}
+
+func (c *InfoCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+  Print the JVS plugin protocol versions the plugin serves, the justification
+  categories it validates and the annotations it returns with the given
+  configuration. The configuration is not validated and Jira is not
+  contacted.
+`
+}
+
+func (c *InfoCommand) Flags() *cli.FlagSet {
+	c.cfg = &plugin.PluginConfig{}
+	set := c.cfg.ToFlags(c.NewFlagSet())
+
+	f := set.NewSection("INFO OPTIONS")
+
+	formatVar(f, &c.flagFormat)
+
+	return set
+}
+
+func (c *InfoCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+	if err := checkFormat(c.flagFormat); err != nil {
+		return err
+	}
+
+	info := plugin.NewInfo(c.cfg)
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, &infoOutput{Version: version.Version, Info: info})
+	}
+
+	c.Outf("%-24s %s", "version", version.Version)
+	c.Outf("%-24s %s", "protocol versions", joinInts(info.ProtocolVersions))
+	c.Outf("%-24s %s", "categories", strings.Join(info.Categories, ", "))
+	c.Outf("%-24s %s", "justification format", info.JustificationFormat)
+	c.Outf("%-24s %s", "annotations", strings.Join(info.Annotations, ", "))
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestInfoCommand(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		wantOut      []string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name: "text",
+			env:  map[string]string{"JIRA_PLUGIN_JUSTIFICATION_FORMAT": "composite"},
+			wantOut: []string{
+				"protocol versions        1",
+				"categories               jira",
+				"annotations              jira_issue_id, jira_issue_url, jira_related_issue_keys",
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name: "json",
+			args: []string{"--format", "json"},
+			wantOut: []string{
+				`"protocol_versions": [`,
+				`"justification_format": "key"`,
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "unexpected_args",
+			args:         []string{"extra"},
+			wantErr:      `unexpected arguments: ["extra"]`,
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &InfoCommand{}
+			cmd.SetLookupEnv(cli.MapLookuper(tc.env))
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+
+			out := stdout.String()
+			for _, want := range tc.wantOut {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q does not contain %q", out, want)
+				}
+			}
+			if tc.args != nil && tc.args[0] == "--format" {
+				var info plugin.Info
+				if err := json.Unmarshal([]byte(out), &info); err != nil {
+					t.Errorf("output is not plugin info: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionedPlugins(t *testing.T) {
+	t.Parallel()
+
+	sets := versionedPlugins(&plugin.JiraPlugin{})
+	for _, v := range plugin.ProtocolVersions {
+		set, ok := sets[v]
+		if !ok {
+			t.Errorf("no plugin for protocol version %d", v)
+			continue
+		}
+		if _, ok := set["jvs-plugin-jira"].(*jvspb.ValidatorPlugin); !ok {
+			t.Errorf("protocol version %d got plugin %T, want %T", v, set["jvs-plugin-jira"], &jvspb.ValidatorPlugin{})
+		}
+	}
+	if _, ok := sets[int(jvspb.Handshake.ProtocolVersion)]; !ok {
+		t.Errorf("protocol version %d of the jvs host is not served", jvspb.Handshake.ProtocolVersion)
+	}
+}
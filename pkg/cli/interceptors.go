@@ -0,0 +1,123 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultMaxRequestBytes is the largest request the server accepts by
+// default. A justification is a few hundred bytes.
+const defaultMaxRequestBytes = 64 << 10
+
+// grpcServer returns the go-plugin GRPCServer factory with the interceptors
+// of the server: panic recovery outermost so nothing escapes it, then the
+// request log, then the request size limit. A maxRequestBytes of 0 disables
+// the limit. The register functions add services to the server, e.g. the
+// decision stream of the plugin.
+func grpcServer(logger *slog.Logger, maxRequestBytes int, register ...func(*grpc.Server)) func([]grpc.ServerOption) *grpc.Server {
+	return func(opts []grpc.ServerOption) *grpc.Server {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(
+				recoverPanics(logger),
+				logRequests(logger),
+				limitRequestSize(maxRequestBytes),
+			),
+			grpc.ChainStreamInterceptor(recoverStreamPanics(logger)),
+		)
+		s := grpc.NewServer(opts...)
+		for _, fn := range register {
+			fn(s)
+		}
+		return s
+	}
+}
+
+// recoverPanics turns a panic in a handler into an Internal status, so a bug
+// fails the request instead of crashing the plugin process and every
+// validation of the host with it.
+func recoverPanics(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorContext(ctx, "recovered from panic in grpc handler",
+					"method", info.FullMethod,
+					"panic", fmt.Sprint(r),
+					"stack", string(debug.Stack()))
+				resp, err = nil, status.Errorf(codes.Internal, "internal error handling %s", info.FullMethod)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// recoverStreamPanics is [recoverPanics] for streaming RPCs.
+func recoverStreamPanics(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorContext(ss.Context(), "recovered from panic in grpc handler",
+					"method", info.FullMethod,
+					"panic", fmt.Sprint(r),
+					"stack", string(debug.Stack()))
+				err = status.Errorf(codes.Internal, "internal error handling %s", info.FullMethod)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// logRequests logs a summary of every request. Successful requests are
+// logged at debug level since the health checks of the host are frequent
+// and the decisions are already logged by the plugin.
+func logRequests(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		level := slog.LevelDebug
+		if err != nil {
+			level = slog.LevelWarn
+		}
+		logger.Log(ctx, level, "handled grpc request",
+			"method", info.FullMethod,
+			"code", status.Code(err).String(),
+			"latency", time.Since(start))
+		return resp, err
+	}
+}
+
+// limitRequestSize rejects requests larger than maxBytes before they reach
+// the handler.
+func limitRequestSize(maxBytes int) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if m, ok := req.(proto.Message); ok && maxBytes > 0 {
+			if size := proto.Size(m); size > maxBytes {
+				return nil, status.Errorf(codes.InvalidArgument,
+					"request of %d bytes exceeds the limit of %d bytes", size, maxBytes)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
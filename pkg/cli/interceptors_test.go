@@ -0,0 +1,102 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	t.Parallel()
+
+	interceptor := recoverPanics(logging.TestLogger(t))
+	info := &grpc.UnaryServerInfo{FullMethod: "/jvs.JVSPlugin/Validate"}
+
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		panic("boom")
+	})
+	if got, want := status.Code(err), codes.Internal; got != want {
+		t.Errorf("got code %s, want %s", got, want)
+	}
+
+	resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	})
+	if err != nil || resp != "ok" {
+		t.Errorf("got (%v, %v), want the handler result", resp, err)
+	}
+}
+
+func TestLimitRequestSize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		maxBytes int
+		value    string
+		wantCode codes.Code
+	}{
+		{
+			name:     "within_limit",
+			maxBytes: 64,
+			value:    "ABCD-1",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "too_large",
+			maxBytes: 64,
+			value:    strings.Repeat("A", 65),
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "disabled",
+			value:    strings.Repeat("A", 1<<20),
+			wantCode: codes.OK,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			interceptor := limitRequestSize(tc.maxBytes)
+			req := &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{Category: "jira", Value: tc.value},
+			}
+			var called bool
+			_, err := interceptor(context.Background(), req, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+				called = true
+				return nil, nil
+			})
+			if got := status.Code(err); got != tc.wantCode {
+				t.Errorf("got code %s, want %s", got, tc.wantCode)
+			}
+			if want := tc.wantCode == codes.OK; called != want {
+				t.Errorf("handler called %t, want %t", called, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// IssueShowCommand fetches an issue with the plugin credentials and prints
+// the fields the plugin uses.
+type IssueShowCommand struct {
+	cli.BaseCommand
+
+	cfg *plugin.PluginConfig
+
+	flagFormat string
+
+	// newValidator creates the validator for the config, it is mockable for
+	// testing.
+	newValidator func(context.Context, *plugin.PluginConfig) (*plugin.Validator, error)
+}
+
+func (c *IssueShowCommand) Desc() string {
+	return `Show an issue as seen by the Jira Plugin`
+}
+
+func (c *IssueShowCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] ISSUE_KEY
+
+  Fetch the issue with the plugin credentials and print its key, id, URL and
+  the annotation fields, as the plugin would see them. Use it to debug
+  permission or field visibility problems.
+`
+}
+
+func (c *IssueShowCommand) Flags() *cli.FlagSet {
+	c.cfg = &plugin.PluginConfig{}
+	set := c.cfg.ToFlags(c.NewFlagSet())
+
+	f := set.NewSection("ISSUE OPTIONS")
+
+	formatVar(f, &c.flagFormat)
+
+	return set
+}
+
+func (c *IssueShowCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) != 1 {
+		return newConfigError(fmt.Errorf("expected exactly one issue key, got %q", args))
+	}
+	issueKey := args[0]
+	if err := checkFormat(c.flagFormat); err != nil {
+		return err
+	}
+
+	if err := c.cfg.Validate(); err != nil {
+		return newConfigError(fmt.Errorf("invalid configuration: %w", err))
+	}
+
+	newValidator := c.newValidator
+	if newValidator == nil {
+		newValidator = plugin.NewValidatorFromConfig
+	}
+	v, err := newValidator(ctx, c.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create validator: %w", err)
+	}
+
+	issue, err := v.Issue(ctx, issueKey)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, issue)
+	}
+
+	// The URL is built the same way as the jira_issue_url annotation.
+	issueURL, err := c.cfg.IssueURL(issue.Key, issue.ID)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+	c.Outf("%-24s %s", "key", issue.Key)
+	c.Outf("%-24s %s", "id", issue.ID)
+	c.Outf("%-24s %s", "url", issueURL)
+
+	names := make([]string, 0, len(issue.Fields))
+	for name := range issue.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.Outf("%-24s %s", "field "+name, issue.Fields[name])
+	}
+	for _, name := range c.cfg.AnnotationFieldNames() {
+		if _, ok := issue.Fields[name]; !ok {
+			c.Outf("%-24s (empty, not visible or over the annotation size limit)", "field "+name)
+		}
+	}
+	return nil
+}
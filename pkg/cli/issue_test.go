@@ -0,0 +1,143 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestIssueShowCommand(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/issue/")
+		if key == "GONE-1" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"id":"1234","key":%q,"fields":{"summary":"Roll back","status":{"name":"Open"}}}`, key)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	env := map[string]string{
+		"JIRA_PLUGIN_ENDPOINT":            srv.URL,
+		"JIRA_PLUGIN_JQL":                 "project = JRA",
+		"JIRA_PLUGIN_ACCOUNT":             "abc@xyz.com",
+		"JIRA_PLUGIN_API_TOKEN_SECRET_ID": "projects/123456/secrets/api-token/versions/4",
+		"JIRA_PLUGIN_HINT":                "Jira Issue Key",
+		"JIRA_PLUGIN_ISSUE_BASE_URL":      srv.URL,
+		"JIRA_PLUGIN_ALLOW_HTTP":          "true",
+		"JIRA_PLUGIN_ANNOTATION_FIELDS":   "summary,status,priority",
+	}
+
+	cases := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		wantOut      []string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name: "text",
+			args: []string{"ABCD-123"},
+			env:  env,
+			wantOut: []string{
+				"key                      ABCD-123",
+				"id                       1234",
+				"url                      " + srv.URL + "/browse/ABCD-123",
+				"field status             Open",
+				"field summary            Roll back",
+				"field priority           (empty, not visible or over the annotation size limit)",
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name: "json",
+			args: []string{"--format", "json", "ABCD-123"},
+			env:  env,
+			wantOut: []string{
+				`"key": "ABCD-123"`,
+				`"summary": "Roll back"`,
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "not_found",
+			args:         []string{"GONE-1"},
+			env:          env,
+			wantErr:      `failed to get jira issue "GONE-1"`,
+			wantExitCode: ExitCodeRuntime,
+		},
+		{
+			name:         "missing_key",
+			env:          env,
+			wantErr:      "expected exactly one issue key",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "invalid_config",
+			args:         []string{"ABCD-123"},
+			wantErr:      "empty JIRA_PLUGIN_ENDPOINT",
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &IssueShowCommand{
+				newValidator: func(ctx context.Context, cfg *plugin.PluginConfig) (*plugin.Validator, error) {
+					return plugin.NewValidator(cfg.JIRAEndpoint, cfg.Jql, cfg.JIRAAccount, "secrets",
+						plugin.WithAnnotationFields(cfg.AnnotationFields, int(cfg.AnnotationFieldMaxBytes)))
+				},
+			}
+			cmd.SetLookupEnv(cli.MapLookuper(tc.env))
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+
+			out := stdout.String()
+			for _, want := range tc.wantOut {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q does not contain %q", out, want)
+				}
+			}
+		})
+	}
+}
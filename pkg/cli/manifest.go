@@ -0,0 +1,76 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abcxyz/jvs-plugin-jira/internal/version"
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// ManifestCommand prints the JSON manifest of the plugin.
+type ManifestCommand struct {
+	cli.BaseCommand
+
+	cfg *plugin.PluginConfig
+}
+
+// manifestOutput is the output of [ManifestCommand].
+type manifestOutput struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	*plugin.Manifest
+}
+
+func (c *ManifestCommand) Desc() string {
+	return `Print a machine-readable manifest of the Jira Plugin`
+}
+
+func (c *ManifestCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+  Print a JSON description of the plugin with the given configuration: the
+  category it serves, the configuration it requires, the optional features
+  enabled, its UI data and version, and whether the configuration is valid.
+  An invalid configuration is reported in the manifest rather than failing
+  the command. Jira is not contacted.
+`
+}
+
+func (c *ManifestCommand) Flags() *cli.FlagSet {
+	c.cfg = &plugin.PluginConfig{}
+	return c.cfg.ToFlags(c.NewFlagSet())
+}
+
+func (c *ManifestCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+
+	return outJSON(&c.BaseCommand, &manifestOutput{
+		Name:     version.Name,
+		Version:  version.Version,
+		Manifest: plugin.NewManifest(c.cfg),
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestManifestCommand(t *testing.T) {
+	t.Parallel()
+
+	validEnv := map[string]string{
+		"JIRA_PLUGIN_ENDPOINT":            "https://example.atlassian.net/rest/api/3",
+		"JIRA_PLUGIN_JQL":                 "project = ABCD",
+		"JIRA_PLUGIN_ACCOUNT_SECRET_ID":   "projects/123/secrets/account/versions/1",
+		"JIRA_PLUGIN_API_TOKEN_SECRET_ID": "projects/123/secrets/api-token/versions/1",
+		"JIRA_PLUGIN_DISPLAY_NAME":        "Jira Issue Key",
+		"JIRA_PLUGIN_HINT":                "Jira Issue Key under JVS project",
+		"JIRA_PLUGIN_ISSUE_BASE_URL":      "https://example.atlassian.net",
+		"JIRA_PLUGIN_CACHE_PATH":          "/var/cache/jira.db",
+		"JIRA_PLUGIN_DEBUG_ANNOTATIONS":   "true",
+	}
+
+	cases := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "valid",
+			env:  validEnv,
+			want: map[string]any{
+				"category": "jira",
+				"required_config": []any{
+					"JIRA_PLUGIN_ENDPOINT",
+					"JIRA_PLUGIN_JQL",
+					"JIRA_PLUGIN_ACCOUNT_SECRET_ID",
+					"JIRA_PLUGIN_API_TOKEN_SECRET_ID",
+					"JIRA_PLUGIN_HINT",
+					"JIRA_PLUGIN_ISSUE_BASE_URL",
+				},
+				"features": []any{"cache", "debug_annotations"},
+				"ui_data": map[string]any{
+					"display_name": "Jira Issue Key",
+					"hint":         "Jira Issue Key under JVS project",
+				},
+				"valid": true,
+			},
+		},
+		{
+			name: "invalid",
+			env:  map[string]string{"JIRA_PLUGIN_JQL": "project = ABCD"},
+			want: map[string]any{
+				"category": "jira",
+				"features": []any{},
+				"valid":    false,
+			},
+		},
+		{
+			name:    "unexpected_args",
+			args:    []string{"extra"},
+			wantErr: `unexpected arguments: ["extra"]`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &ManifestCommand{}
+			cmd.SetLookupEnv(cli.MapLookuper(tc.env))
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if err != nil {
+				return
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+				t.Fatalf("output is not json: %v", err)
+			}
+			for _, key := range []string{"name", "version", "protocol_versions", "annotations"} {
+				if _, ok := got[key]; !ok {
+					t.Errorf("manifest has no %q", key)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreMapEntries(func(k string, _ any) bool {
+				_, ok := tc.want[k]
+				return !ok
+			})); diff != "" {
+				t.Errorf("manifest (-want, +got):\n%s", diff)
+			}
+			if _, ok := got["errors"]; ok == got["valid"].(bool) { //nolint:forcetypeassert // Checked by the diff
+				t.Errorf("manifest got errors %v with valid %v", got["errors"], got["valid"])
+			}
+		})
+	}
+}
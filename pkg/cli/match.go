@@ -0,0 +1,144 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// MatchCommand matches issues against a JQL with the plugin credentials and
+// prints the raw match result.
+type MatchCommand struct {
+	cli.BaseCommand
+
+	cfg *plugin.PluginConfig
+
+	flagJQL    string
+	flagIssues []string
+	flagFormat string
+
+	// newValidator creates the validator for the config, it is mockable for
+	// testing.
+	newValidator func(context.Context, *plugin.PluginConfig) (*plugin.Validator, error)
+}
+
+func (c *MatchCommand) Desc() string {
+	return `Match issues against a JQL`
+}
+
+func (c *MatchCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] --issues ISSUE_KEY[,ISSUE_KEY...]
+
+  Match the issues against the JQL with a single Jira match request and print
+  the result, --format json prints the raw match result. Use it to design new
+  validation criteria or to reproduce a mismatch. The configured JQL is used
+  unless --jql is given. Matched issues are reported by id, "issue show"
+  prints the id of an issue.
+`
+}
+
+func (c *MatchCommand) Flags() *cli.FlagSet {
+	c.cfg = &plugin.PluginConfig{}
+	set := c.cfg.ToFlags(c.NewFlagSet())
+
+	f := set.NewSection("MATCH OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jql",
+		Target:  &c.flagJQL,
+		Example: "project = ABCD AND statusCategory != Done",
+		Usage:   "The JQL to match the issues against. Defaults to the configured JQL.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "issues",
+		Target:  &c.flagIssues,
+		Example: "ABCD-1,ABCD-2",
+		Usage:   "The keys of the issues to match.",
+	})
+
+	formatVar(f, &c.flagFormat)
+
+	return set
+}
+
+func (c *MatchCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	args = f.Args()
+	if len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+	if len(c.flagIssues) == 0 {
+		return newConfigError(fmt.Errorf("no issues given, use --issues"))
+	}
+	if err := checkFormat(c.flagFormat); err != nil {
+		return err
+	}
+
+	if c.flagJQL != "" {
+		c.cfg.Jql = c.flagJQL
+	}
+	if err := c.cfg.Validate(); err != nil {
+		return newConfigError(fmt.Errorf("invalid configuration: %w", err))
+	}
+
+	newValidator := c.newValidator
+	if newValidator == nil {
+		newValidator = plugin.NewValidatorFromConfig
+	}
+	v, err := newValidator(ctx, c.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create validator: %w", err)
+	}
+
+	result, err := v.MatchIssues(ctx, c.flagIssues)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, result)
+	}
+
+	for _, m := range result.Matches {
+		c.Outf("%-16s %s", "matched issues", joinInts(m.MatchedIssues))
+		for _, e := range m.Errors {
+			c.Outf("%-16s %s", "error", e)
+		}
+	}
+	return nil
+}
+
+// joinInts returns the numbers separated by commas, or "none".
+func joinInts(ns []int) string {
+	if len(ns) == 0 {
+		return "none"
+	}
+	s := make([]string, 0, len(ns))
+	for _, n := range ns {
+		s = append(s, strconv.Itoa(n))
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestMatchCommand(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/issue/")
+		fmt.Fprintf(w, `{"id":%q,"key":%q}`, strings.TrimPrefix(key, "ABCD-"), key)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		var data struct {
+			IssueIDs []string `json:"issueIds"`
+			Jqls     []string `json:"jqls"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			t.Errorf("failed to decode match request: %v", err)
+		}
+		// Echo the request in the errors, so the test can check it.
+		fmt.Fprintf(w, `{"matches":[{"matchedIssues":[1],"errors":[%q]}]}`,
+			strings.Join(data.IssueIDs, ",")+" "+strings.Join(data.Jqls, ","))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	env := map[string]string{
+		"JIRA_PLUGIN_ENDPOINT":            srv.URL,
+		"JIRA_PLUGIN_JQL":                 "project = JRA",
+		"JIRA_PLUGIN_ACCOUNT":             "abc@xyz.com",
+		"JIRA_PLUGIN_API_TOKEN_SECRET_ID": "projects/123456/secrets/api-token/versions/4",
+		"JIRA_PLUGIN_HINT":                "Jira Issue Key",
+		"JIRA_PLUGIN_ISSUE_BASE_URL":      srv.URL,
+		"JIRA_PLUGIN_ALLOW_HTTP":          "true",
+	}
+
+	cases := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		wantOut      string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name:         "configured_jql",
+			args:         []string{"--format", "json", "--issues", "ABCD-1,ABCD-2"},
+			env:          env,
+			wantOut:      `"1,2 project = JRA"`,
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "jql_flag",
+			args:         []string{"--format", "json", "--jql", "status = Open", "--issues", "ABCD-3"},
+			env:          env,
+			wantOut:      `"3 status = Open"`,
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "text",
+			args:         []string{"--issues", "ABCD-1"},
+			env:          env,
+			wantOut:      "matched issues   1\nerror            1 project = JRA\n",
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "invalid_format",
+			args:         []string{"--format", "yaml", "--issues", "ABCD-1"},
+			env:          env,
+			wantErr:      `invalid format "yaml"`,
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "no_issues",
+			env:          env,
+			wantErr:      "no issues given",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "unexpected_args",
+			args:         []string{"--issues", "ABCD-1", "extra"},
+			env:          env,
+			wantErr:      `unexpected arguments: ["extra"]`,
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &MatchCommand{
+				newValidator: func(ctx context.Context, cfg *plugin.PluginConfig) (*plugin.Validator, error) {
+					return plugin.NewValidator(cfg.JIRAEndpoint, cfg.Jql, cfg.JIRAAccount, "secrets")
+				},
+			}
+			cmd.SetLookupEnv(cli.MapLookuper(tc.env))
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+
+			out := stdout.String()
+			if !strings.Contains(out, tc.wantOut) {
+				t.Errorf("output %q does not contain %q", out, tc.wantOut)
+			}
+			if tc.wantErr == "" && tc.args[0] == "--format" {
+				var result plugin.MatchResult
+				if err := json.Unmarshal([]byte(out), &result); err != nil {
+					t.Errorf("output is not a match result: %v", err)
+				}
+			}
+		})
+	}
+}
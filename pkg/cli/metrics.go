@@ -0,0 +1,99 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// metricsShutdownTimeout bounds how long a scrape in flight delays the
+// shutdown.
+const metricsShutdownTimeout = 5 * time.Second
+
+// metricsAddrVar adds the -metrics-addr flag to the section.
+func metricsAddrVar(f *cli.FlagSection, target *string) {
+	f.StringVar(&cli.StringVar{
+		Name:    "metrics-addr",
+		Target:  target,
+		EnvVar:  "JIRA_PLUGIN_METRICS_ADDR",
+		Example: "127.0.0.1:9464",
+		Usage: "If set, the build info and config hash metrics are served in " +
+			"the Prometheus text format on this address at /metrics.",
+	})
+}
+
+// serveMetrics serves the metrics written by write at /metrics on addr until
+// the returned function is called.
+func serveMetrics(logger *slog.Logger, addr string, write func(io.Writer) error) (func() error, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on metrics address %s: %w", addr, err)
+	}
+
+	s := &http.Server{
+		Handler:           metricsHandler(logger, write),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		if err := s.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("failed to serve metrics", "addr", addr, "error", err)
+		}
+	}()
+
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		err := s.Shutdown(ctx)
+		<-doneCh
+		if err != nil {
+			return fmt.Errorf("failed to stop metrics server: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// metricsHandler serves the metrics written by write at /metrics.
+func metricsHandler(logger *slog.Logger, write func(io.Writer) error) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			logger.Error("failed to write metrics", "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", plugin.MetricsContentType)
+		w.Write(buf.Bytes())
+	})
+	return mux
+}
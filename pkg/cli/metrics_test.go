@@ -0,0 +1,115 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	t.Parallel()
+
+	const metrics = "jira_plugin_config_hash{category=\"jira\"} 42\n"
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		writeErr error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "get",
+			method:   http.MethodGet,
+			path:     "/metrics",
+			wantCode: http.StatusOK,
+			wantBody: metrics,
+		},
+		{
+			name:     "post",
+			method:   http.MethodPost,
+			path:     "/metrics",
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "not_found",
+			method:   http.MethodGet,
+			path:     "/",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "write_error",
+			method:   http.MethodGet,
+			path:     "/metrics",
+			writeErr: fmt.Errorf("broken"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := metricsHandler(logging.TestLogger(t), func(w io.Writer) error {
+				if tc.writeErr != nil {
+					return tc.writeErr
+				}
+				_, err := io.WriteString(w, metrics)
+				return err
+			})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if got, want := w.Code, tc.wantCode; got != want {
+				t.Errorf("got status %d, want %d", got, want)
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			if got, want := w.Header().Get("Content-Type"), plugin.MetricsContentType; got != want {
+				t.Errorf("got content type %q, want %q", got, want)
+			}
+			if got, want := w.Body.String(), tc.wantBody; got != want {
+				t.Errorf("got body %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestServeMetrics(t *testing.T) {
+	t.Parallel()
+
+	stop, err := serveMetrics(logging.TestLogger(t), "127.0.0.1:0", func(w io.Writer) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stop(); err != nil {
+		t.Errorf("stop() got unexpected error: %v", err)
+	}
+
+	if _, err := serveMetrics(logging.TestLogger(t), "not-an-address", nil); err == nil {
+		t.Errorf("serveMetrics() got no error for an invalid address")
+	}
+}
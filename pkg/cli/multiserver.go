@@ -0,0 +1,371 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	goplugin "github.com/hashicorp/go-plugin"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// MultiServerCommand serves several plugin configurations, each validating
+// its own justification category, in one process.
+type MultiServerCommand struct {
+	cli.BaseCommand
+
+	flagConfig          string
+	flagPIDFile         string
+	flagHealthFile      string
+	flagMetricsAddr     string
+	flagWarmup          bool
+	flagWarmupTimeout   time.Duration
+	flagShutdownTimeout time.Duration
+	flagMaxRequestBytes int
+}
+
+// multiServerConfig is the configuration file of [MultiServerCommand].
+type multiServerConfig struct {
+	Instances []*multiServerInstance `json:"instances"`
+}
+
+// multiServerInstance is a plugin configuration of a [multiServerConfig].
+type multiServerInstance struct {
+	// Category is the justification category of the instance, it takes
+	// precedence over JIRA_PLUGIN_CATEGORY.
+	Category string `json:"category"`
+
+	// EnvFile is an env file holding the JIRA_PLUGIN_* variables of the
+	// instance, relative to the configuration file.
+	EnvFile string `json:"env_file"`
+
+	// Env are JIRA_PLUGIN_* variables of the instance, they take precedence
+	// over those of the env file.
+	Env map[string]string `json:"env"`
+}
+
+func (c *MultiServerCommand) Desc() string {
+	return `Start several Jira Plugin configurations in one process`
+}
+
+func (c *MultiServerCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] -config PATH
+
+  Serve several plugin configurations, e.g. one per Jira site, through one
+  go-plugin server. Each instance validates the justifications of its own
+  category, validations of other categories fail with InvalidArgument. The
+  configuration file is JSON:
+
+      {
+        "instances": [
+          {"category": "jira", "env_file": "jira.env"},
+          {"category": "jira-ops", "env_file": "jira.env",
+           "env": {"JIRA_PLUGIN_JQL": "project = OPS"}}
+        ]
+      }
+
+  The JIRA_PLUGIN_* variables of an instance are read from its env file,
+  in the format of "config check", and its env, not from the environment.
+  The UI data served are those of the first instance, the plugin API does
+  not say which category they are asked for.
+`
+}
+
+func (c *MultiServerCommand) Flags() *cli.FlagSet {
+	set := c.NewFlagSet()
+
+	f := set.NewSection("SERVER OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "config",
+		Target:  &c.flagConfig,
+		EnvVar:  "JIRA_PLUGIN_MULTI_CONFIG",
+		Example: "/etc/jvs-plugin-jira/instances.json",
+		Usage:   "The JSON file listing the plugin configurations to serve.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "pid-file",
+		Target:  &c.flagPIDFile,
+		EnvVar:  "JIRA_PLUGIN_PID_FILE",
+		Example: "/run/jvs-plugin-jira.pid",
+		Usage:   "If set, the process ID is written to this file while serving.",
+	})
+
+	healthFileVar(f, &c.flagHealthFile)
+
+	metricsAddrVar(f, &c.flagMetricsAddr)
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "warmup",
+		Target:  &c.flagWarmup,
+		EnvVar:  "JIRA_PLUGIN_WARMUP",
+		Default: false,
+		Usage: "Check every instance against Jira before serving, and exit if " +
+			"any fails, like the server command.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "warmup-timeout",
+		Target:  &c.flagWarmupTimeout,
+		EnvVar:  "JIRA_PLUGIN_WARMUP_TIMEOUT",
+		Example: "1m",
+		Default: defaultWarmupTimeout,
+		Usage:   "How long -warmup may take in total. The instances warm up concurrently.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "shutdown-timeout",
+		Target:  &c.flagShutdownTimeout,
+		EnvVar:  "JIRA_PLUGIN_SHUTDOWN_TIMEOUT",
+		Example: "30s",
+		Default: defaultShutdownTimeout,
+		Usage: "How long to wait for validations in flight on shutdown before " +
+			"releasing the connections they use.",
+	})
+
+	f.IntVar(&cli.IntVar{
+		Name:    "max-request-bytes",
+		Target:  &c.flagMaxRequestBytes,
+		EnvVar:  "JIRA_PLUGIN_MAX_REQUEST_BYTES",
+		Example: "131072",
+		Default: defaultMaxRequestBytes,
+		Usage: "The largest request from the host the server accepts, larger " +
+			"requests fail with InvalidArgument. 0 disables the limit.",
+	})
+
+	return set
+}
+
+func (c *MultiServerCommand) Run(ctx context.Context, args []string) error {
+	m, err := c.RunUnstarted(ctx, args)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate jira plugins: %w", err)
+	}
+	defer func() {
+		// ctx is canceled by a shutdown signal, the plugins still get the
+		// shutdown timeout to finish the validations in flight.
+		closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.flagShutdownTimeout)
+		defer cancel()
+		if err := m.Close(closeCtx); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to close jira plugins", "error", err)
+		}
+	}()
+
+	if c.flagWarmup {
+		warmupCtx, cancel := context.WithTimeout(ctx, c.flagWarmupTimeout)
+		err := m.Warmup(warmupCtx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to warm up jira plugins: %w", err)
+		}
+	}
+
+	if c.flagPIDFile != "" {
+		removePIDFile, err := writePIDFile(c.flagPIDFile)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := removePIDFile(); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "failed to clean up", "error", err)
+			}
+		}()
+	}
+
+	if c.flagHealthFile != "" {
+		removeHealthFile, err := writeHealthFile(ctx, c.flagHealthFile, healthInterval)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := removeHealthFile(); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "failed to clean up", "error", err)
+			}
+		}()
+	}
+
+	if c.flagMetricsAddr != "" {
+		stopMetrics, err := serveMetrics(logging.FromContext(ctx), c.flagMetricsAddr, m.WriteMetrics)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := stopMetrics(); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "failed to clean up", "error", err)
+			}
+		}()
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		goplugin.Serve(c.serveConfig(ctx, m))
+	}()
+
+	// See [ServerCommand.RunWithServeConfig].
+	select {
+	case <-ctx.Done():
+		logging.FromContext(ctx).InfoContext(ctx, "received termination signal, shutting down")
+	case <-doneCh:
+	}
+	return nil
+}
+
+// serveConfig returns the go-plugin configuration serving m.
+func (c *MultiServerCommand) serveConfig(ctx context.Context, m *plugin.MultiPlugin) *goplugin.ServeConfig {
+	return &goplugin.ServeConfig{
+		HandshakeConfig:  jvspb.Handshake,
+		VersionedPlugins: versionedPlugins(m),
+
+		// A non-nil value here enables gRPC serving for this plugin.
+		GRPCServer: grpcServer(logging.FromContext(ctx), c.flagMaxRequestBytes),
+	}
+}
+
+// RunUnstarted loads the configuration file and creates the plugins of its
+// instances without serving them.
+func (c *MultiServerCommand) RunUnstarted(ctx context.Context, args []string) (*plugin.MultiPlugin, error) {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return nil, newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	if args := f.Args(); len(args) > 0 {
+		return nil, newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+	if c.flagConfig == "" {
+		return nil, newConfigError(fmt.Errorf("missing -config"))
+	}
+	if c.flagMaxRequestBytes < 0 {
+		return nil, newConfigError(fmt.Errorf("invalid -max-request-bytes %d, must not be negative", c.flagMaxRequestBytes))
+	}
+
+	cfgs, err := loadMultiServerConfig(c.flagConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := logging.FromContext(ctx)
+	plugins := make([]*plugin.JiraPlugin, 0, len(cfgs))
+	closeAll := func() {
+		for _, p := range plugins {
+			p.Close(ctx)
+		}
+	}
+	for _, cfg := range cfgs {
+		logger.DebugContext(ctx, "loaded configuration", "category", cfg.Category, "config", cfg)
+		p, err := plugin.NewJiraPlugin(ctx, cfg)
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("category %s: %w", cfg.Category, err)
+		}
+		plugins = append(plugins, p)
+	}
+	m, err := plugin.NewMultiPlugin(plugins...)
+	if err != nil {
+		closeAll()
+		return nil, newConfigError(err)
+	}
+	logger.InfoContext(ctx, "serving jira plugins", "categories", m.Categories())
+	return m, nil
+}
+
+// loadMultiServerConfig returns the validated plugin configurations of the
+// instances of the configuration file at pth.
+func loadMultiServerConfig(pth string) ([]*plugin.PluginConfig, error) {
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, newConfigError(fmt.Errorf("failed to read multi-server config: %w", err))
+	}
+	var mc multiServerConfig
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&mc); err != nil {
+		return nil, newConfigError(fmt.Errorf("failed to parse %s: %w", pth, err))
+	}
+	if len(mc.Instances) == 0 {
+		return nil, newConfigError(fmt.Errorf("no instances in %s", pth))
+	}
+
+	cfgs := make([]*plugin.PluginConfig, 0, len(mc.Instances))
+	seen := make(map[string]int, len(mc.Instances))
+	for i, inst := range mc.Instances {
+		cfg, err := inst.config(filepath.Dir(pth))
+		if err != nil {
+			return nil, newConfigError(fmt.Errorf("instance %d of %s: %w", i, pth, err))
+		}
+		if prev, ok := seen[cfg.Category]; ok {
+			return nil, newConfigError(fmt.Errorf("instance %d of %s: category %q already served by instance %d", i, pth, cfg.Category, prev))
+		}
+		seen[cfg.Category] = i
+		cfgs = append(cfgs, cfg)
+	}
+	return cfgs, nil
+}
+
+// config returns the validated plugin configuration of the instance. A
+// relative env file is looked up in dir.
+func (inst *multiServerInstance) config(dir string) (*plugin.PluginConfig, error) {
+	vars := make(map[string]string)
+	if inst.EnvFile != "" {
+		pth := inst.EnvFile
+		if !filepath.IsAbs(pth) {
+			pth = filepath.Join(dir, pth)
+		}
+		file, err := os.Open(pth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open env file: %w", err)
+		}
+		defer file.Close()
+		if vars, err = parseEnvFile(file); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", pth, err)
+		}
+	}
+	for name, value := range inst.Env {
+		vars[strings.ToUpper(name)] = value
+	}
+	if inst.Category != "" {
+		vars["JIRA_PLUGIN_CATEGORY"] = inst.Category
+	}
+
+	cfg := &plugin.PluginConfig{}
+	set := cfg.ToFlags(cli.NewFlagSet(cli.WithLookupEnv(cli.MapLookuper(vars))))
+	if err := set.Parse(nil); err != nil {
+		return nil, err //nolint:wrapcheck // Want passthrough
+	}
+	environ := make([]string, 0, len(vars))
+	for name := range vars {
+		environ = append(environ, name+"=")
+	}
+	if unknown := unknownEnvVars(set, environ); len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown variables: %s", strings.Join(unknown, ", "))
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
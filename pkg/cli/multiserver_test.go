@@ -0,0 +1,169 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestMultiServerCommand_RunUnstarted(t *testing.T) {
+	t.Parallel()
+
+	const envFile = `JIRA_PLUGIN_ENDPOINT=https://example.atlassian.net/rest/api/3
+JIRA_PLUGIN_JQL="project = JRA"
+JIRA_PLUGIN_ACCOUNT=abc@xyz.com
+JIRA_PLUGIN_API_TOKEN_SECRET_ID=projects/123456/secrets/api-token/versions/4
+JIRA_PLUGIN_HINT="Jira Issue Key"
+JIRA_PLUGIN_ISSUE_BASE_URL=https://example.atlassian.net
+`
+
+	cases := []struct {
+		name           string
+		config         string
+		noConfig       bool
+		args           []string
+		wantCategories []string
+		wantErr        string
+		wantExitCode   int
+	}{
+		{
+			name: "two_instances",
+			config: `{"instances": [
+				{"category": "jira", "env_file": "jira.env"},
+				{"category": "jira-ops", "env_file": "jira.env", "env": {"jira_plugin_jql": "project = OPS"}}
+			]}`,
+			wantCategories: []string{"jira", "jira-ops"},
+			wantExitCode:   ExitCodeOK,
+		},
+		{
+			name:           "category_from_env",
+			config:         `{"instances": [{"env_file": "jira.env", "env": {"JIRA_PLUGIN_CATEGORY": "ops"}}]}`,
+			wantCategories: []string{"ops"},
+			wantExitCode:   ExitCodeOK,
+		},
+		{
+			name:         "missing_config",
+			noConfig:     true,
+			wantErr:      "missing -config",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "unreadable_config",
+			noConfig:     true,
+			args:         []string{"-config", "/does/not/exist.json"},
+			wantErr:      "failed to read multi-server config",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "unknown_field",
+			config:       `{"instances": [{"category": "jira", "envfile": "jira.env"}]}`,
+			wantErr:      `unknown field "envfile"`,
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "no_instances",
+			config:       `{"instances": []}`,
+			wantErr:      "no instances",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name: "duplicate_category",
+			config: `{"instances": [
+				{"env_file": "jira.env"},
+				{"category": "jira", "env_file": "jira.env"}
+			]}`,
+			wantErr:      `category "jira" already served by instance 0`,
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "missing_env_file",
+			config:       `{"instances": [{"category": "jira", "env_file": "other.env"}]}`,
+			wantErr:      "failed to open env file",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "unknown_variable",
+			config:       `{"instances": [{"category": "jira", "env_file": "jira.env", "env": {"JIRA_PLUGIN_CACHE_TLL": "10m"}}]}`,
+			wantErr:      "unknown variables: JIRA_PLUGIN_CACHE_TLL",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "invalid_instance",
+			config:       `{"instances": [{"category": "jira", "env_file": "jira.env", "env": {"JIRA_PLUGIN_JQL": ""}}]}`,
+			wantErr:      "empty JIRA_PLUGIN_JQL",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "invalid_category",
+			config:       `{"instances": [{"category": "jira ops", "env_file": "jira.env"}]}`,
+			wantErr:      `invalid JIRA_PLUGIN_CATEGORY "jira ops"`,
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "jira.env"), []byte(envFile), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			args := tc.args
+			if !tc.noConfig {
+				pth := filepath.Join(dir, "instances.json")
+				if err := os.WriteFile(pth, []byte(tc.config), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append([]string{"-config", pth}, args...)
+			}
+
+			cmd := &MultiServerCommand{}
+			cmd.SetLookupEnv(func(string) (string, bool) { return "", false })
+			_, _, _ = cmd.Pipe()
+
+			m, err := cmd.RunUnstarted(ctx, args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+			if m == nil {
+				return
+			}
+			t.Cleanup(func() {
+				if err := m.Close(ctx); err != nil {
+					t.Errorf("Close() got unexpected error: %v", err)
+				}
+			})
+			if diff := cmp.Diff(tc.wantCategories, m.Categories()); diff != "" {
+				t.Errorf("Categories() (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
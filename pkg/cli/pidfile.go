@@ -0,0 +1,39 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+)
+
+// writePIDFile writes the current process ID to pth and returns a function
+// that removes the file again.
+func writePIDFile(pth string) (func() error, error) {
+	pid := strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(pth, []byte(pid+"\n"), 0o644); err != nil { //nolint:gosec // PID files are world-readable
+		return nil, fmt.Errorf("failed to write pid file %s: %w", pth, err)
+	}
+
+	return func() error {
+		if err := os.Remove(pth); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove pid file %s: %w", pth, err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cli
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePIDFile(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		dir       string
+		preRemove bool
+		wantErr   bool
+	}{
+		{
+			name: "written_and_removed",
+		},
+		{
+			name:      "already_removed",
+			preRemove: true,
+		},
+		{
+			name:    "missing_directory",
+			dir:     "does-not-exist",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pth := filepath.Join(t.TempDir(), tc.dir, "plugin.pid")
+
+			remove, err := writePIDFile(pth)
+			if got, want := err != nil, tc.wantErr; got != want {
+				t.Fatalf("writePIDFile() got err %v, want error %t", err, want)
+			}
+			if err != nil {
+				return
+			}
+
+			b, err := os.ReadFile(pth)
+			if err != nil {
+				t.Fatalf("failed to read pid file: %v", err)
+			}
+			if got, want := strings.TrimSpace(string(b)), strconv.Itoa(os.Getpid()); got != want {
+				t.Errorf("pid file got %q, want %q", got, want)
+			}
+
+			if tc.preRemove {
+				if err := os.Remove(pth); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := remove(); err != nil {
+				t.Errorf("failed to remove pid file: %v", err)
+			}
+			if _, err := os.Stat(pth); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("pid file still exists after shutdown: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// replayDumper writes a replay log, it is implemented by
+// [plugin.JiraPlugin].
+type replayDumper interface {
+	DumpReplay(io.Writer) (int, error)
+}
+
+// dumpReplay writes the replay log of p to a new file in dir and returns its
+// path and the number of records written.
+func dumpReplay(p replayDumper, dir string, now time.Time) (string, int, error) {
+	// The records hold issue data, keep them private.
+	pth, f, err := createDumpFile(dir, "replay", "jsonl", now)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create replay log: %w", err)
+	}
+	n, err := p.DumpReplay(f)
+	if err != nil {
+		f.Close()
+		return "", 0, err //nolint:wrapcheck // Want passthrough
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to close replay log %s: %w", pth, err)
+	}
+	return pth, n, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeReplayDumper struct {
+	records []string
+}
+
+func (d *fakeReplayDumper) DumpReplay(w io.Writer) (int, error) {
+	for _, r := range d.records {
+		fmt.Fprintln(w, r)
+	}
+	return len(d.records), nil
+}
+
+func TestDumpReplay(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+	d := &fakeReplayDumper{records: []string{`{"value":"ABCD-1"}`, `{"value":"ABCD-2"}`}}
+
+	pth, n, err := dumpReplay(d, dir, now)
+	if err != nil {
+		t.Fatalf("failed to dump replay log: %v", err)
+	}
+	if got, want := n, 2; got != want {
+		t.Errorf("got %d records, want %d", got, want)
+	}
+	if got, want := filepath.Dir(pth), dir; got != want {
+		t.Errorf("got replay log in %s, want %s", got, want)
+	}
+
+	b, err := os.ReadFile(pth)
+	if err != nil {
+		t.Fatalf("failed to read replay log: %v", err)
+	}
+	if got, want := string(b), "{\"value\":\"ABCD-1\"}\n{\"value\":\"ABCD-2\"}\n"; got != want {
+		t.Errorf("got replay log %q, want %q", got, want)
+	}
+
+	// A second dump at the same time does not overwrite the first one.
+	if _, _, err := dumpReplay(d, dir, now); err == nil {
+		t.Errorf("expected an error dumping to an existing file")
+	}
+}
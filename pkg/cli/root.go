@@ -0,0 +1,153 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/abcxyz/jvs-plugin-jira/internal/version"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// rootCmd defines the starting command structure.
+var rootCmd = func() cli.Command {
+	return &cli.RootCommand{
+		Name:    version.Name,
+		Version: version.HumanVersion,
+		Commands: map[string]cli.CommandFactory{
+			"cache": func() cli.Command {
+				return &cli.RootCommand{
+					Name:        "cache",
+					Description: "Administer the decision cache of a running server",
+					Commands: map[string]cli.CommandFactory{
+						"lookup": func() cli.Command {
+							return &CacheLookupCommand{}
+						},
+						"purge": func() cli.Command {
+							return &CachePurgeCommand{}
+						},
+						"stats": func() cli.Command {
+							return &CacheStatsCommand{}
+						},
+					},
+				}
+			},
+			"completion": func() cli.Command {
+				return &CompletionCommand{}
+			},
+			"config": func() cli.Command {
+				return &cli.RootCommand{
+					Name:        "config",
+					Description: "Work with Jira Plugin configurations",
+					Commands: map[string]cli.CommandFactory{
+						"check": func() cli.Command {
+							return &ConfigCheckCommand{}
+						},
+					},
+				}
+			},
+			"diagnostics": func() cli.Command {
+				return &DiagnosticsCommand{}
+			},
+			"doctor": func() cli.Command {
+				return &DoctorCommand{}
+			},
+			"features": func() cli.Command {
+				return &cli.RootCommand{
+					Name:        "features",
+					Description: "Show and override the feature flags of a running server",
+					Commands: map[string]cli.CommandFactory{
+						"list": func() cli.Command {
+							return &FeaturesListCommand{}
+						},
+						"reset": func() cli.Command {
+							return &FeaturesResetCommand{}
+						},
+						"set": func() cli.Command {
+							return &FeaturesSetCommand{}
+						},
+					},
+				}
+			},
+			"healthcheck": func() cli.Command {
+				return &HealthcheckCommand{}
+			},
+			"info": func() cli.Command {
+				return &InfoCommand{}
+			},
+			"issue": func() cli.Command {
+				return &cli.RootCommand{
+					Name:        "issue",
+					Description: "Inspect Jira issues with the plugin configuration",
+					Commands: map[string]cli.CommandFactory{
+						"show": func() cli.Command {
+							return &IssueShowCommand{}
+						},
+					},
+				}
+			},
+			"manifest": func() cli.Command {
+				return &ManifestCommand{}
+			},
+			"match": func() cli.Command {
+				return &MatchCommand{}
+			},
+			"multi-server": func() cli.Command {
+				return &MultiServerCommand{}
+			},
+			"server": func() cli.Command {
+				return &ServerCommand{}
+			},
+			"validate": func() cli.Command {
+				return &ValidateCommand{}
+			},
+			"whoami": func() cli.Command {
+				return &WhoAmICommand{}
+			},
+		},
+	}
+}
+
+// Run executes the CLI.
+func Run(ctx context.Context, args []string) error {
+	// Completion requests always go to the root command, fish calls the
+	// binary without arguments.
+	if runsServerByDefault(args) && os.Getenv("COMP_LINE") == "" {
+		return new(ServerCommand).Run(ctx, args) //nolint:wrapcheck // Want passthrough
+	}
+	return rootCmd().Run(ctx, args) //nolint:wrapcheck // Want passthrough
+}
+
+// runsServerByDefault reports whether args are for the plugin server rather
+// than a subcommand. The JVS host launches plugins without any arguments, and
+// flags without a subcommand, including -h, have always been server flags.
+// Only the version flags are handled by the root command.
+func runsServerByDefault(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	return strings.HasPrefix(args[0], "-") && !isVersionFlag(args[0])
+}
+
+// isVersionFlag reports whether arg asks the root command for the version.
+func isVersionFlag(arg string) bool {
+	switch arg {
+	case "-v", "-version", "--version":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/abcxyz/pkg/cli"
+)
+
+func TestRunsServerByDefault(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		args        []string
+		wantServer  bool
+		wantCommand string
+	}{
+		{
+			name:       "no_args",
+			args:       []string{},
+			wantServer: true,
+		},
+		{
+			name:       "server_flag",
+			args:       []string{"-jira-plugin-endpoint", "https://example.atlassian.net/rest/api/3"},
+			wantServer: true,
+		},
+		{
+			name:       "help",
+			args:       []string{"-h"},
+			wantServer: true,
+		},
+		{
+			name:       "version",
+			args:       []string{"-version"},
+			wantServer: false,
+		},
+		{
+			name:        "server",
+			args:        []string{"server"},
+			wantCommand: "server",
+		},
+		{
+			name:        "doctor_json",
+			args:        []string{"doctor", "--json"},
+			wantCommand: "doctor",
+		},
+		{
+			name:        "issue_show",
+			args:        []string{"issue", "show", "ABCD-123"},
+			wantCommand: "issue",
+		},
+		{
+			name:        "match",
+			args:        []string{"match", "--issues", "ABCD-1,ABCD-2"},
+			wantCommand: "match",
+		},
+		{
+			name:        "completion",
+			args:        []string{"completion", "bash"},
+			wantCommand: "completion",
+		},
+		{
+			name:        "info",
+			args:        []string{"info"},
+			wantCommand: "info",
+		},
+		{
+			name:        "manifest",
+			args:        []string{"manifest"},
+			wantCommand: "manifest",
+		},
+		{
+			name:        "healthcheck",
+			args:        []string{"healthcheck"},
+			wantCommand: "healthcheck",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, want := runsServerByDefault(tc.args), tc.wantServer; got != want {
+				t.Errorf("runsServerByDefault(%q) got %t, want %t", tc.args, got, want)
+			}
+
+			if tc.wantCommand != "" {
+				root := rootCmd().(*cli.RootCommand) //nolint:forcetypeassert // rootCmd always returns a RootCommand
+				if _, ok := root.Commands[tc.wantCommand]; !ok {
+					t.Errorf("root command has no subcommand %q", tc.wantCommand)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// ServeOption customizes the go-plugin configuration the server is started
+// with by [ServerCommand.RunWithServeConfig]. Options are applied in order
+// after the defaults.
+type ServeOption func(*goplugin.ServeConfig)
+
+// WithHandshakeConfig replaces the [jvspb.Handshake] the server is started
+// with, for hosts that launch the plugin with their own magic cookie.
+func WithHandshakeConfig(h goplugin.HandshakeConfig) ServeOption {
+	return func(cfg *goplugin.ServeConfig) {
+		cfg.HandshakeConfig = h
+	}
+}
+
+// WithTLSProvider secures the connection between the host and the plugin
+// with the TLS configuration returned by fn, instead of the automatic mTLS
+// negotiated by go-plugin.
+func WithTLSProvider(fn func() (*tls.Config, error)) ServeOption {
+	return func(cfg *goplugin.ServeConfig) {
+		cfg.TLSProvider = fn
+	}
+}
+
+// WithServeLogger sets the logger of go-plugin itself. The plugin logs to
+// the logger in the context regardless.
+func WithServeLogger(logger hclog.Logger) ServeOption {
+	return func(cfg *goplugin.ServeConfig) {
+		cfg.Logger = logger
+	}
+}
+
+// WithGRPCServerOptions adds options to the gRPC server, such as
+// [grpc.MaxRecvMsgSize] or [grpc.KeepaliveParams]. Interceptors added here
+// run before the interceptors of the server, so they also see requests the
+// server rejects.
+func WithGRPCServerOptions(opts ...grpc.ServerOption) ServeOption {
+	return func(cfg *goplugin.ServeConfig) {
+		next := cfg.GRPCServer
+		cfg.GRPCServer = func(base []grpc.ServerOption) *grpc.Server {
+			return next(append(base, opts...))
+		}
+	}
+}
+
+// grpcServerOptions returns the gRPC server options of the message size and
+// keepalive flags. Unset flags keep the gRPC defaults.
+func (c *ServerCommand) grpcServerOptions() []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if c.flagMaxRecvMsgBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(c.flagMaxRecvMsgBytes))
+	}
+	if c.flagMaxSendMsgBytes > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(c.flagMaxSendMsgBytes))
+	}
+	if c.flagKeepaliveTime > 0 || c.flagKeepaliveTimeout > 0 {
+		// Zero fields are replaced with the gRPC defaults.
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    c.flagKeepaliveTime,
+			Timeout: c.flagKeepaliveTimeout,
+		}))
+	}
+	if c.flagKeepaliveMinTime > 0 {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.flagKeepaliveMinTime,
+			PermitWithoutStream: true,
+		}))
+	}
+	return opts
+}
+
+// serveConfig returns the go-plugin configuration serving p with the gRPC
+// server options of the flags, with opts applied after them.
+func (c *ServerCommand) serveConfig(ctx context.Context, p *plugin.JiraPlugin, opts ...ServeOption) *goplugin.ServeConfig {
+	cfg := &goplugin.ServeConfig{
+		HandshakeConfig:  jvspb.Handshake,
+		VersionedPlugins: versionedPlugins(p),
+
+		// A non-nil value here enables gRPC serving for this plugin.
+		GRPCServer: grpcServer(logging.FromContext(ctx), c.flagMaxRequestBytes, p.RegisterDecisionStream, p.RegisterAdmin),
+	}
+	if grpcOpts := c.grpcServerOptions(); len(grpcOpts) > 0 {
+		WithGRPCServerOptions(grpcOpts...)(cfg)
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
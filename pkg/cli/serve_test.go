@@ -0,0 +1,156 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestServerCommand_serveConfig(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+	p, err := plugin.NewJiraPluginWithToken(ctx, &plugin.PluginConfig{
+		JIRAEndpoint: srv.URL,
+		Jql:          "project = ABCD",
+		JIRAAccount:  "abc@xyz.com",
+		IssueBaseURL: srv.URL,
+		AllowHTTP:    true,
+	}, "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := new(ServerCommand).serveConfig(ctx, p)
+		if diff := cmp.Diff(jvspb.Handshake, cfg.HandshakeConfig); diff != "" {
+			t.Errorf("handshake (-want, +got):\n%s", diff)
+		}
+		if cfg.TLSProvider != nil {
+			t.Errorf("got a TLS provider, want none")
+		}
+		if cfg.GRPCServer == nil {
+			t.Errorf("got no gRPC server")
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		t.Parallel()
+
+		handshake := goplugin.HandshakeConfig{
+			ProtocolVersion:  1,
+			MagicCookieKey:   "EMBEDDER_PLUGIN",
+			MagicCookieValue: "embedded",
+		}
+		var mu sync.Mutex
+		var methods []string
+		cfg := new(ServerCommand).serveConfig(ctx, p,
+			WithHandshakeConfig(handshake),
+			WithTLSProvider(func() (*tls.Config, error) { return &tls.Config{MinVersion: tls.VersionTLS13}, nil }),
+			WithGRPCServerOptions(grpc.ChainUnaryInterceptor(
+				func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+					mu.Lock()
+					methods = append(methods, info.FullMethod)
+					mu.Unlock()
+					return handler(ctx, req)
+				})),
+		)
+		if diff := cmp.Diff(handshake, cfg.HandshakeConfig); diff != "" {
+			t.Errorf("handshake (-want, +got):\n%s", diff)
+		}
+		if cfg.TLSProvider == nil {
+			t.Fatalf("got no TLS provider")
+		}
+		if _, err := cfg.TLSProvider(); err != nil {
+			t.Errorf("TLS provider: %v", err)
+		}
+
+		// The server keeps the services of the plugin and runs the added
+		// interceptor.
+		conn := serveBufconn(ctx, t, cfg)
+		if _, err := plugin.GetDiagnostics(ctx, conn); err != nil {
+			t.Fatalf("failed to get diagnostics: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if diff := cmp.Diff([]string{plugin.AdminDiagnosticsMethod}, methods); diff != "" {
+			t.Errorf("intercepted methods (-want, +got):\n%s", diff)
+		}
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		t.Parallel()
+
+		c := &ServerCommand{
+			flagMaxSendMsgBytes:  64,
+			flagKeepaliveTime:    time.Minute,
+			flagKeepaliveMinTime: 30 * time.Second,
+		}
+		if got, want := len(c.grpcServerOptions()), 3; got != want {
+			t.Errorf("got %d gRPC server options, want %d", got, want)
+		}
+
+		// The diagnostics are larger than the send limit.
+		conn := serveBufconn(ctx, t, c.serveConfig(ctx, p))
+		_, err := plugin.GetDiagnostics(ctx, conn)
+		if got, want := status.Code(err), codes.ResourceExhausted; got != want {
+			t.Errorf("got code %s, want %s: %v", got, want, err)
+		}
+	})
+}
+
+// serveBufconn serves the gRPC server of cfg in memory and returns a
+// connection to it.
+func serveBufconn(ctx context.Context, t *testing.T, cfg *goplugin.ServeConfig) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	s := cfg.GRPCServer(nil)
+	go s.Serve(lis) //nolint:errcheck // Stopped in cleanup
+	t.Cleanup(s.Stop)
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
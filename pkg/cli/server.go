@@ -18,6 +18,9 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	goplugin "github.com/hashicorp/go-plugin"
 
@@ -27,10 +30,48 @@ import (
 	"github.com/abcxyz/pkg/logging"
 )
 
+// defaultShutdownTimeout is how long the server waits for validations in
+// flight on shutdown.
+const defaultShutdownTimeout = 10 * time.Second
+
+// defaultWarmupTimeout is how long the server waits for the startup checks
+// of -warmup.
+const defaultWarmupTimeout = 30 * time.Second
+
 type ServerCommand struct {
 	cli.BaseCommand
 
 	cfg *plugin.PluginConfig
+
+	flagPIDFile     string
+	flagHealthFile  string
+	flagAdminSocket string
+	flagMetricsAddr string
+	flagReplayDir   string
+	flagWarmup      bool
+	flagStrictEnv   string
+
+	flagDiagnosticsDir string
+
+	flagWarmupTimeout   time.Duration
+	flagShutdownTimeout time.Duration
+
+	flagMaxRequestBytes int
+	flagMaxRecvMsgBytes int
+	flagMaxSendMsgBytes int
+
+	flagKeepaliveTime    time.Duration
+	flagKeepaliveTimeout time.Duration
+	flagKeepaliveMinTime time.Duration
+
+	flagWatchdogInterval      time.Duration
+	flagWatchdogMaxGoroutines int
+	flagWatchdogMaxHeapBytes  uint64
+	flagWatchdogRestart       bool
+
+	// environ returns the environment checked by -strict-env, it is
+	// mockable for testing and defaults to [os.Environ].
+	environ func() []string
 }
 
 func (c *ServerCommand) Desc() string {
@@ -47,45 +88,392 @@ Usage: {{ COMMAND }} [options]
 
 func (c *ServerCommand) Flags() *cli.FlagSet {
 	c.cfg = &plugin.PluginConfig{}
-	set := c.NewFlagSet()
-	return c.cfg.ToFlags(set)
+	set := c.cfg.ToFlags(c.NewFlagSet())
+
+	f := set.NewSection("SERVER OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "pid-file",
+		Target:  &c.flagPIDFile,
+		EnvVar:  "JIRA_PLUGIN_PID_FILE",
+		Example: "/run/jvs-plugin-jira.pid",
+		Usage:   "If set, the process ID is written to this file while serving.",
+	})
+
+	healthFileVar(f, &c.flagHealthFile)
+
+	adminSocketVar(f, &c.flagAdminSocket, "If set, the admin service, e.g. the cache "+
+		"administration of the cache command, is served on this unix socket, "+
+		"which only the user of the process can connect to.")
+
+	metricsAddrVar(f, &c.flagMetricsAddr)
+
+	f.StringVar(&cli.StringVar{
+		Name:    "diagnostics-dir",
+		Target:  &c.flagDiagnosticsDir,
+		EnvVar:  "JIRA_PLUGIN_DIAGNOSTICS_DIR",
+		Example: "/var/log/jvs-plugin-jira",
+		Usage: "The directory the diagnostics, e.g. the goroutine stacks and " +
+			"recent decisions, are written to on SIGUSR1. They are logged when " +
+			"the file cannot be written. Defaults to the temporary directory.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "replay-dir",
+		Target:  &c.flagReplayDir,
+		EnvVar:  "JIRA_PLUGIN_REPLAY_DIR",
+		Example: "/var/log/jvs-plugin-jira",
+		Usage: "The directory the replay log is written to on SIGUSR1 when " +
+			"-jira-plugin-replay-buffer-size is set. Defaults to the temporary directory.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "warmup",
+		Target:  &c.flagWarmup,
+		EnvVar:  "JIRA_PLUGIN_WARMUP",
+		Default: false,
+		Usage: "Fetch the API token, check the credentials, the JQL and the " +
+			"warmup projects against Jira before serving, and exit if that " +
+			"fails. By default the token is fetched on the first validation, " +
+			"which keeps cold starts fast at the cost of a slower first " +
+			"validation.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "warmup-timeout",
+		Target:  &c.flagWarmupTimeout,
+		EnvVar:  "JIRA_PLUGIN_WARMUP_TIMEOUT",
+		Example: "1m",
+		Default: defaultWarmupTimeout,
+		Usage: "How long -warmup may take in total. The checks against Jira " +
+			"run concurrently.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "shutdown-timeout",
+		Target:  &c.flagShutdownTimeout,
+		EnvVar:  "JIRA_PLUGIN_SHUTDOWN_TIMEOUT",
+		Example: "30s",
+		Default: defaultShutdownTimeout,
+		Usage: "How long to wait for validations in flight on shutdown before " +
+			"releasing the connections they use.",
+	})
+
+	f.IntVar(&cli.IntVar{
+		Name:    "max-request-bytes",
+		Target:  &c.flagMaxRequestBytes,
+		EnvVar:  "JIRA_PLUGIN_MAX_REQUEST_BYTES",
+		Example: "131072",
+		Default: defaultMaxRequestBytes,
+		Usage: "The largest request from the host the server accepts, larger " +
+			"requests fail with InvalidArgument. 0 disables the limit.",
+	})
+
+	f.IntVar(&cli.IntVar{
+		Name:    "max-recv-msg-bytes",
+		Target:  &c.flagMaxRecvMsgBytes,
+		EnvVar:  "JIRA_PLUGIN_MAX_RECV_MSG_BYTES",
+		Example: "1048576",
+		Usage: "The largest gRPC message the server receives, larger messages " +
+			"fail with ResourceExhausted before they are decoded. Defaults to " +
+			"the gRPC default of 4 MiB.",
+	})
+
+	f.IntVar(&cli.IntVar{
+		Name:    "max-send-msg-bytes",
+		Target:  &c.flagMaxSendMsgBytes,
+		EnvVar:  "JIRA_PLUGIN_MAX_SEND_MSG_BYTES",
+		Example: "16777216",
+		Usage: "The largest gRPC message the server sends, e.g. a response " +
+			"with large annotations or a diagnostics dump. Defaults to the gRPC " +
+			"default, which is unlimited.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "keepalive-time",
+		Target:  &c.flagKeepaliveTime,
+		EnvVar:  "JIRA_PLUGIN_KEEPALIVE_TIME",
+		Example: "1m",
+		Usage: "How long the connection to the host may be idle before the " +
+			"server pings it, so that middleboxes do not drop it. Defaults to " +
+			"the gRPC default of 2h.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "keepalive-timeout",
+		Target:  &c.flagKeepaliveTimeout,
+		EnvVar:  "JIRA_PLUGIN_KEEPALIVE_TIMEOUT",
+		Example: "10s",
+		Usage: "How long the server waits for the answer to a keepalive ping " +
+			"before closing the connection. Defaults to the gRPC default of 20s.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "keepalive-min-time",
+		Target:  &c.flagKeepaliveMinTime,
+		EnvVar:  "JIRA_PLUGIN_KEEPALIVE_MIN_TIME",
+		Example: "30s",
+		Usage: "If set, the host may send keepalive pings this often, also " +
+			"while no request is in flight. By default the server closes " +
+			"connections that ping more often than every 5m.",
+	})
+
+	f.DurationVar(&cli.DurationVar{
+		Name:    "watchdog-interval",
+		Target:  &c.flagWatchdogInterval,
+		EnvVar:  "JIRA_PLUGIN_WATCHDOG_INTERVAL",
+		Example: "30s",
+		Default: defaultWatchdogInterval,
+		Usage: "How often the server samples its goroutines, heap, open file " +
+			"descriptors and GC pauses and logs them at debug level. 0 disables " +
+			"the sampling.",
+	})
+
+	f.IntVar(&cli.IntVar{
+		Name:    "watchdog-max-goroutines",
+		Target:  &c.flagWatchdogMaxGoroutines,
+		EnvVar:  "JIRA_PLUGIN_WATCHDOG_MAX_GOROUTINES",
+		Example: "10000",
+		Usage: "If set, a warning is logged when the server runs more " +
+			"goroutines, see -watchdog-restart.",
+	})
+
+	f.Uint64Var(&cli.Uint64Var{
+		Name:    "watchdog-max-heap-bytes",
+		Target:  &c.flagWatchdogMaxHeapBytes,
+		EnvVar:  "JIRA_PLUGIN_WATCHDOG_MAX_HEAP_BYTES",
+		Example: "536870912",
+		Usage: "If set, a warning is logged when the heap of the server " +
+			"grows larger, see -watchdog-restart.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "watchdog-restart",
+		Target:  &c.flagWatchdogRestart,
+		EnvVar:  "JIRA_PLUGIN_WATCHDOG_RESTART",
+		Default: false,
+		Usage: "Shut down cleanly and exit with 1 when a watchdog threshold is " +
+			"exceeded, so that the supervisor of the process restarts it.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "strict-env",
+		Target:  &c.flagStrictEnv,
+		EnvVar:  "JIRA_PLUGIN_STRICT_ENV",
+		Example: "error",
+		Usage: "How to report JIRA_PLUGIN_ environment variables that are not " +
+			"the variable of any option, e.g. typos: warn logs them, error exits. " +
+			"They are ignored by default.",
+	})
+
+	return set
 }
 
 func (c *ServerCommand) Run(ctx context.Context, args []string) error {
+	return c.RunWithServeConfig(ctx, args)
+}
+
+// RunWithServeConfig is [ServerCommand.Run] with the go-plugin configuration
+// customized by opts, for programs that embed the plugin server in their own
+// binary and need another handshake, TLS between the host and the plugin, or
+// gRPC server options.
+func (c *ServerCommand) RunWithServeConfig(ctx context.Context, args []string, opts ...ServeOption) error {
 	p, err := c.RunUnstarted(ctx, args)
 	if err != nil {
 		return fmt.Errorf("failed to instantiate jira plugin: %w", err)
 	}
+	defer func() {
+		// ctx is canceled by a shutdown signal, the plugin still gets the
+		// shutdown timeout to finish the validations in flight.
+		closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.flagShutdownTimeout)
+		defer cancel()
+		if err := p.Close(closeCtx); err != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "failed to close jira plugin", "error", err)
+		}
+	}()
 
-	goplugin.Serve(&goplugin.ServeConfig{
-		HandshakeConfig: jvspb.Handshake,
-		Plugins: map[string]goplugin.Plugin{
-			"jvs-plugin-jira": &jvspb.ValidatorPlugin{Impl: p},
-		},
+	if c.flagWarmup {
+		warmupCtx, cancel := context.WithTimeout(ctx, c.flagWarmupTimeout)
+		err := p.Warmup(warmupCtx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to warm up jira plugin: %w", err)
+		}
+	}
 
-		// A non-nil value here enables gRPC serving for this plugin.
-		GRPCServer: goplugin.DefaultGRPCServer,
-	})
+	if c.flagPIDFile != "" {
+		removePIDFile, err := writePIDFile(c.flagPIDFile)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := removePIDFile(); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "failed to clean up", "error", err)
+			}
+		}()
+	}
+
+	if c.flagHealthFile != "" {
+		removeHealthFile, err := writeHealthFile(ctx, c.flagHealthFile, healthInterval)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := removeHealthFile(); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "failed to clean up", "error", err)
+			}
+		}()
+	}
+
+	if c.flagAdminSocket != "" {
+		stopAdmin, err := serveAdminSocket(logging.FromContext(ctx), c.flagAdminSocket, p)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := stopAdmin(); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "failed to clean up", "error", err)
+			}
+		}()
+	}
+
+	if c.flagMetricsAddr != "" {
+		stopMetrics, err := serveMetrics(logging.FromContext(ctx), c.flagMetricsAddr, p.WriteMetrics)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := stopMetrics(); err != nil {
+				logging.FromContext(ctx).ErrorContext(ctx, "failed to clean up", "error", err)
+			}
+		}()
+	}
+
+	stopDumps := dumpOnSignal(ctx, func(ctx context.Context) {
+		dumpDiagnosticsOrLog(ctx, p, c.flagDiagnosticsDir)
+		if c.cfg.ReplayBufferSize == 0 {
+			return
+		}
+		logger := logging.FromContext(ctx)
+		pth, n, err := dumpReplay(p, c.flagReplayDir, time.Now())
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to write replay log", "error", err)
+			return
+		}
+		logger.InfoContext(ctx, "wrote replay log", "path", pth, "records", n)
+	}, dumpSignals()...)
+	defer stopDumps()
+
+	var trippedCh <-chan string
+	if c.flagWatchdogInterval > 0 {
+		w := &watchdog{
+			interval:      c.flagWatchdogInterval,
+			maxGoroutines: c.flagWatchdogMaxGoroutines,
+			maxHeapBytes:  c.flagWatchdogMaxHeapBytes,
+		}
+		var stopWatchdog func()
+		trippedCh, stopWatchdog = w.start(ctx)
+		defer stopWatchdog()
+	}
+	if !c.flagWatchdogRestart {
+		// Receiving from a nil channel blocks forever.
+		trippedCh = nil
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		goplugin.Serve(c.serveConfig(ctx, p, opts...))
+	}()
+
+	// Serve returns once the host disconnects. A signal from
+	// [ShutdownSignals], which never includes os.Interrupt for a plugin
+	// launched by the host, cancels ctx instead, in which case we return so
+	// deferred cleanup runs before the process exits.
+	select {
+	case <-ctx.Done():
+		logging.FromContext(ctx).InfoContext(ctx, "received termination signal, shutting down")
+	case <-doneCh:
+	case reason := <-trippedCh:
+		return fmt.Errorf("shutting down for a restart, resource watchdog tripped: %s", reason)
+	}
 
 	return nil
 }
 
+// versionedPlugins returns the plugin for every protocol version in
+// [plugin.ProtocolVersions]. go-plugin serves the newest version the host
+// also speaks, and the host reports an incompatible API version otherwise.
+func versionedPlugins(p jvspb.Validator) map[int]goplugin.PluginSet {
+	sets := make(map[int]goplugin.PluginSet, len(plugin.ProtocolVersions))
+	for _, v := range plugin.ProtocolVersions {
+		sets[v] = goplugin.PluginSet{
+			"jvs-plugin-jira": &jvspb.ValidatorPlugin{Impl: p},
+		}
+	}
+	return sets
+}
+
 func (c *ServerCommand) RunUnstarted(ctx context.Context, args []string) (*plugin.JiraPlugin, error) {
 	f := c.Flags()
 	if err := f.Parse(args); err != nil {
-		return nil, fmt.Errorf("failed to parse flags: %w", err)
+		return nil, newConfigError(fmt.Errorf("failed to parse flags: %w", err))
 	}
 	args = f.Args()
 	if len(args) > 0 {
-		return nil, fmt.Errorf("unexpected arguments: %q", args)
+		return nil, newConfigError(fmt.Errorf("unexpected arguments: %q", args))
 	}
 
 	logger := logging.FromContext(ctx)
 
+	if err := checkStrictEnv(c.flagStrictEnv); err != nil {
+		return nil, err
+	}
+	if c.flagWatchdogInterval < 0 {
+		return nil, newConfigError(fmt.Errorf("invalid -watchdog-interval %s, must not be negative", c.flagWatchdogInterval))
+	}
+	if c.flagWatchdogMaxGoroutines < 0 {
+		return nil, newConfigError(fmt.Errorf("invalid -watchdog-max-goroutines %d, must not be negative", c.flagWatchdogMaxGoroutines))
+	}
+	if c.flagWatchdogRestart && c.flagWatchdogInterval == 0 {
+		return nil, newConfigError(fmt.Errorf("-watchdog-restart needs a -watchdog-interval"))
+	}
+	if c.flagMaxRequestBytes < 0 {
+		return nil, newConfigError(fmt.Errorf("invalid -max-request-bytes %d, must not be negative", c.flagMaxRequestBytes))
+	}
+	if c.flagMaxRecvMsgBytes < 0 {
+		return nil, newConfigError(fmt.Errorf("invalid -max-recv-msg-bytes %d, must not be negative", c.flagMaxRecvMsgBytes))
+	}
+	if c.flagMaxSendMsgBytes < 0 {
+		return nil, newConfigError(fmt.Errorf("invalid -max-send-msg-bytes %d, must not be negative", c.flagMaxSendMsgBytes))
+	}
+	if c.flagKeepaliveTime < 0 {
+		return nil, newConfigError(fmt.Errorf("invalid -keepalive-time %s, must not be negative", c.flagKeepaliveTime))
+	}
+	if c.flagKeepaliveTimeout < 0 {
+		return nil, newConfigError(fmt.Errorf("invalid -keepalive-timeout %s, must not be negative", c.flagKeepaliveTimeout))
+	}
+	if c.flagKeepaliveMinTime < 0 {
+		return nil, newConfigError(fmt.Errorf("invalid -keepalive-min-time %s, must not be negative", c.flagKeepaliveMinTime))
+	}
+	if c.flagStrictEnv != "" {
+		environ := c.environ
+		if environ == nil {
+			environ = os.Environ
+		}
+		if unknown := unknownEnvVars(f, environ()); len(unknown) > 0 {
+			if c.flagStrictEnv == strictEnvError {
+				return nil, newConfigError(fmt.Errorf("unknown environment variables: %s", strings.Join(unknown, ", ")))
+			}
+			logger.WarnContext(ctx, "ignoring unknown environment variables", "names", unknown)
+		}
+	}
+
 	if err := c.cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+		return nil, newConfigError(fmt.Errorf("invalid configuration: %w", err))
 	}
 	logger.DebugContext(ctx, "loaded configuration", "config", c.cfg)
+	logger.DebugContext(ctx, "plugin info", "info", plugin.NewInfo(c.cfg))
 
 	p, err := plugin.NewJiraPlugin(ctx, c.cfg)
 	if err != nil {
@@ -0,0 +1,173 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestServerCommand_RunUnstarted(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name:         "unknown_flag",
+			args:         []string{"-not-a-flag"},
+			wantErr:      "failed to parse flags",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "unexpected_args",
+			args:         []string{"extra"},
+			wantErr:      "unexpected arguments",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "watchdog_restart_without_interval",
+			args:         []string{"-watchdog-restart", "-watchdog-interval", "0"},
+			wantErr:      "-watchdog-restart needs a -watchdog-interval",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "negative_max_request_bytes",
+			args:         []string{"-max-request-bytes", "-1"},
+			wantErr:      "invalid -max-request-bytes -1",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "negative_max_recv_msg_bytes",
+			args:         []string{"-max-recv-msg-bytes", "-1"},
+			wantErr:      "invalid -max-recv-msg-bytes -1",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "negative_keepalive_time",
+			args:         []string{"-keepalive-time", "-1s"},
+			wantErr:      "invalid -keepalive-time -1s",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "invalid_config",
+			env:          map[string]string{"JIRA_PLUGIN_ENDPOINT": "https://example.atlassian.net/rest/api/3"},
+			wantErr:      "empty JIRA_PLUGIN_JQL",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name: "invalid_justification_format",
+			env: map[string]string{
+				"JIRA_PLUGIN_ENDPOINT":             "https://example.atlassian.net/rest/api/3",
+				"JIRA_PLUGIN_JQL":                  "project = JRA",
+				"JIRA_PLUGIN_ACCOUNT":              "abc@xyz.com",
+				"JIRA_PLUGIN_API_TOKEN_SECRET_ID":  "projects/123456/secrets/api-token/versions/4",
+				"JIRA_PLUGIN_HINT":                 "Jira Issue Key",
+				"JIRA_PLUGIN_ISSUE_BASE_URL":       "https://example.atlassian.net",
+				"JIRA_PLUGIN_JUSTIFICATION_FORMAT": "xml",
+			},
+			wantErr:      "invalid JIRA_PLUGIN_JUSTIFICATION_FORMAT",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			// The API token is only fetched on first use, so Secret Manager is
+			// not needed to start.
+			name: "lazy_secret",
+			env: map[string]string{
+				"JIRA_PLUGIN_ENDPOINT":            "https://example.atlassian.net/rest/api/3",
+				"JIRA_PLUGIN_JQL":                 "project = JRA",
+				"JIRA_PLUGIN_ACCOUNT":             "abc@xyz.com",
+				"JIRA_PLUGIN_API_TOKEN_SECRET_ID": "projects/123456/secrets/api-token/versions/4",
+				"JIRA_PLUGIN_HINT":                "Jira Issue Key",
+				"JIRA_PLUGIN_ISSUE_BASE_URL":      "https://example.atlassian.net",
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name: "strict_env_error",
+			env: map[string]string{
+				"JIRA_PLUGIN_ENDPOINT":            "https://example.atlassian.net/rest/api/3",
+				"JIRA_PLUGIN_JQL":                 "project = JRA",
+				"JIRA_PLUGIN_ACCOUNT":             "abc@xyz.com",
+				"JIRA_PLUGIN_API_TOKEN_SECRET_ID": "projects/123456/secrets/api-token/versions/4",
+				"JIRA_PLUGIN_HINT":                "Jira Issue Key",
+				"JIRA_PLUGIN_ISSUE_BASE_URL":      "https://example.atlassian.net",
+				"JIRA_PLUGIN_STRICT_ENV":          "error",
+				"JIRA_PLUGIN_CACHE_TLL":           "10m",
+				"JIRA_PLUGIN_PID_FILE":            "",
+			},
+			wantErr:      "unknown environment variables: JIRA_PLUGIN_CACHE_TLL",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name: "strict_env_warn",
+			env: map[string]string{
+				"JIRA_PLUGIN_ENDPOINT":            "https://example.atlassian.net/rest/api/3",
+				"JIRA_PLUGIN_JQL":                 "project = JRA",
+				"JIRA_PLUGIN_ACCOUNT":             "abc@xyz.com",
+				"JIRA_PLUGIN_API_TOKEN_SECRET_ID": "projects/123456/secrets/api-token/versions/4",
+				"JIRA_PLUGIN_HINT":                "Jira Issue Key",
+				"JIRA_PLUGIN_ISSUE_BASE_URL":      "https://example.atlassian.net",
+				"JIRA_PLUGIN_STRICT_ENV":          "warn",
+				"JIRA_PLUGIN_CACHE_TLL":           "10m",
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "invalid_strict_env",
+			args:         []string{"-strict-env", "fail"},
+			wantErr:      `invalid -strict-env "fail"`,
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &ServerCommand{
+				environ: func() []string {
+					environ := make([]string, 0, len(tc.env))
+					for k, v := range tc.env {
+						environ = append(environ, k+"="+v)
+					}
+					return environ
+				},
+			}
+			cmd.SetLookupEnv(cli.MapLookuper(tc.env))
+			_, _, _ = cmd.Pipe()
+
+			_, err := cmd.RunUnstarted(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+		})
+	}
+}
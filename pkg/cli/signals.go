@@ -0,0 +1,43 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os"
+	"syscall"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+// ShutdownSignals returns the signals that should stop the binary.
+//
+// SIGTERM is what container orchestrators and systemd send before killing the
+// process. os.Interrupt is SIGINT on unix and CTRL_C_EVENT or CTRL_BREAK_EVENT
+// on Windows. It only stops the binary when it runs standalone: a plugin
+// launched by the JVS host shares the host's process group and receives the
+// host's Ctrl-C, but the host decides when its plugins stop, which is why
+// go-plugin ignores interrupts while serving.
+func ShutdownSignals() []os.Signal {
+	return shutdownSignals(os.LookupEnv)
+}
+
+func shutdownSignals(lookupEnv func(string) (string, bool)) []os.Signal {
+	// The host sets the handshake cookie in the environment of the plugins it
+	// launches.
+	if _, ok := lookupEnv(jvspb.Handshake.MagicCookieKey); ok {
+		return []os.Signal{syscall.SIGTERM}
+	}
+	return []os.Signal{os.Interrupt, syscall.SIGTERM}
+}
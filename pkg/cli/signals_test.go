@@ -0,0 +1,58 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cli
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/cli"
+)
+
+func TestShutdownSignals(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		env  map[string]string
+		want []os.Signal
+	}{
+		{
+			name: "standalone",
+			want: []os.Signal{os.Interrupt, syscall.SIGTERM},
+		},
+		{
+			name: "launched_by_host",
+			env:  map[string]string{jvspb.Handshake.MagicCookieKey: jvspb.Handshake.MagicCookieValue},
+			want: []os.Signal{syscall.SIGTERM},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := shutdownSignals(cli.MapLookuper(tc.env))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("shutdownSignals() unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
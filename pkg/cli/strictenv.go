@@ -0,0 +1,74 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/abcxyz/pkg/cli"
+)
+
+// envPrefix is the prefix of the environment variables of the plugin.
+const envPrefix = "JIRA_PLUGIN_"
+
+// Strict env modes, see the -strict-env flag.
+const (
+	strictEnvWarn  = "warn"
+	strictEnvError = "error"
+)
+
+// flagEnvRe extracts the environment variable from the usage of a flag, the
+// flag library appends it to the usage of every flag with one.
+var flagEnvRe = regexp.MustCompile(`specified with the (\S+) environment variable`)
+
+// checkStrictEnv returns an error when mode is not a strict env mode.
+func checkStrictEnv(mode string) error {
+	switch mode {
+	case "", strictEnvWarn, strictEnvError:
+		return nil
+	default:
+		return newConfigError(fmt.Errorf("invalid -strict-env %q, must be one of %s, %s", mode, strictEnvWarn, strictEnvError))
+	}
+}
+
+// unknownEnvVars returns the sorted names of the variables in environ, in
+// os.Environ format, that have the plugin prefix but are not the
+// environment variable of a flag of set, e.g. typos like
+// JIRA_PLUGIN_ENDPONT that would otherwise leave a setting empty.
+func unknownEnvVars(set *cli.FlagSet, environ []string) []string {
+	known := make(map[string]struct{})
+	set.VisitAll(func(f *flag.Flag) {
+		if m := flagEnvRe.FindStringSubmatch(f.Usage); m != nil {
+			known[m[1]] = struct{}{}
+		}
+	})
+
+	var unknown []string
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, envPrefix) {
+			continue
+		}
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
@@ -0,0 +1,204 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// ValidateCommand validates a justification value like the server and prints
+// the response, and the decision tree with --explain.
+type ValidateCommand struct {
+	cli.BaseCommand
+
+	cfg *plugin.PluginConfig
+
+	flagValue     string
+	flagRequestor string
+	flagExplain   bool
+	flagFormat    string
+
+	// newPlugin creates the plugin for the config, it is mockable for
+	// testing.
+	newPlugin func(context.Context, *plugin.PluginConfig) (*plugin.JiraPlugin, error)
+}
+
+// validateOutput is the JSON output of [ValidateCommand].
+type validateOutput struct {
+	Valid       bool                `json:"valid"`
+	Errors      []string            `json:"errors,omitempty"`
+	Warnings    []string            `json:"warnings,omitempty"`
+	Annotations map[string]string   `json:"annotations,omitempty"`
+	Explanation *plugin.Explanation `json:"explanation,omitempty"`
+}
+
+func (c *ValidateCommand) Desc() string {
+	return `Validate a justification like the server`
+}
+
+func (c *ValidateCommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options] --value JUSTIFICATION
+
+  Validate the justification value with the plugin configuration and print
+  the response the JVS server would get. With --explain, also print every
+  check the validation ran with its input and outcome, to find out why a
+  justification is rejected. The decision is not audited.
+`
+}
+
+func (c *ValidateCommand) Flags() *cli.FlagSet {
+	c.cfg = &plugin.PluginConfig{}
+	set := c.cfg.ToFlags(c.NewFlagSet())
+
+	f := set.NewSection("VALIDATE OPTIONS")
+
+	f.StringVar(&cli.StringVar{
+		Name:    "value",
+		Target:  &c.flagValue,
+		Example: "ABCD-1",
+		Usage:   "The justification value to validate.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "requestor",
+		Target:  &c.flagRequestor,
+		Example: "user@example.com",
+		Usage:   "The identity of the requestor, for the bypass list, the quotas and personalized JQLs.",
+	})
+
+	f.BoolVar(&cli.BoolVar{
+		Name:    "explain",
+		Target:  &c.flagExplain,
+		Default: false,
+		Usage:   "Print the checks the validation ran, their inputs and outcomes.",
+	})
+
+	formatVar(f, &c.flagFormat)
+
+	return set
+}
+
+func (c *ValidateCommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	if args := f.Args(); len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+	if c.flagValue == "" {
+		return newConfigError(fmt.Errorf("no justification given, use --value"))
+	}
+	if err := checkFormat(c.flagFormat); err != nil {
+		return err
+	}
+
+	if err := c.cfg.Validate(); err != nil {
+		return newConfigError(fmt.Errorf("invalid configuration: %w", err))
+	}
+
+	newPlugin := c.newPlugin
+	if newPlugin == nil {
+		newPlugin = func(ctx context.Context, cfg *plugin.PluginConfig) (*plugin.JiraPlugin, error) {
+			return plugin.NewJiraPlugin(ctx, cfg)
+		}
+	}
+	p, err := newPlugin(ctx, c.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create plugin: %w", err)
+	}
+	defer func() {
+		if err := p.Close(ctx); err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "failed to close plugin", "error", err)
+		}
+	}()
+
+	if c.flagRequestor != "" {
+		ctx = plugin.WithRequestor(ctx, &plugin.Requestor{Subject: c.flagRequestor})
+	}
+	var resp *jvspb.ValidateJustificationResponse
+	var explanation *plugin.Explanation
+	if c.flagExplain {
+		resp, explanation, err = p.Explain(ctx, c.flagValue)
+	} else {
+		resp, err = p.ValidateValue(ctx, c.flagValue)
+	}
+	if err != nil {
+		if explanation != nil && c.flagFormat == formatText {
+			c.outExplanation(explanation)
+		}
+		return err //nolint:wrapcheck // Want passthrough
+	}
+
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, &validateOutput{
+			Valid:       resp.GetValid(),
+			Errors:      resp.GetError(),
+			Warnings:    resp.GetWarning(),
+			Annotations: resp.GetAnnotation(),
+			Explanation: explanation,
+		})
+	}
+
+	c.Outf("%-16s %t", "valid", resp.GetValid())
+	for _, e := range resp.GetError() {
+		c.Outf("%-16s %s", "error", e)
+	}
+	for _, w := range resp.GetWarning() {
+		c.Outf("%-16s %s", "warning", w)
+	}
+	keys := make([]string, 0, len(resp.GetAnnotation()))
+	for k := range resp.GetAnnotation() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		c.Outf("%-16s %s=%s", "annotation", k, resp.GetAnnotation()[k])
+	}
+	if explanation != nil {
+		c.outExplanation(explanation)
+	}
+	return nil
+}
+
+// outExplanation prints the decision tree, a check per line indented by its
+// depth.
+func (c *ValidateCommand) outExplanation(e *plugin.Explanation) {
+	c.Outf("explanation")
+	var out func(steps []*plugin.ExplainStep, depth int)
+	out = func(steps []*plugin.ExplainStep, depth int) {
+		for _, s := range steps {
+			line := fmt.Sprintf("%s%-8s %s", strings.Repeat("  ", depth+1), s.Outcome, s.Check)
+			if s.Input != "" {
+				line += " " + s.Input
+			}
+			if s.Detail != "" {
+				line += ": " + s.Detail
+			}
+			c.Outf("%s", line)
+			out(s.Steps, depth+1)
+		}
+	}
+	out(e.Steps, 0)
+}
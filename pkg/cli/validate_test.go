@@ -0,0 +1,146 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestValidateCommand(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/issue/")
+		fmt.Fprintf(w, `{"id":"1","key":%q}`, key)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	env := map[string]string{
+		"JIRA_PLUGIN_ENDPOINT":            srv.URL,
+		"JIRA_PLUGIN_JQL":                 "project = JRA",
+		"JIRA_PLUGIN_ACCOUNT":             "abc@xyz.com",
+		"JIRA_PLUGIN_API_TOKEN_SECRET_ID": "projects/123456/secrets/api-token/versions/4",
+		"JIRA_PLUGIN_HINT":                "Jira Issue Key",
+		"JIRA_PLUGIN_ISSUE_BASE_URL":      srv.URL,
+		"JIRA_PLUGIN_ALLOW_HTTP":          "true",
+	}
+
+	cases := []struct {
+		name         string
+		args         []string
+		wantOut      string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name: "text",
+			args: []string{"--value", "ABCD-1"},
+			wantOut: "valid            true\n" +
+				"annotation       jira_issue_id=1\n" +
+				"annotation       jira_issue_url=" + srv.URL + "/browse/ABCD-1\n",
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name: "explain",
+			args: []string{"--value", "ABCD-1", "--explain"},
+			wantOut: "explanation\n" +
+				"  pass     category jira\n" +
+				"  pass     parse ABCD-1: issue ABCD-1\n" +
+				"  pass     issue ABCD-1\n" +
+				"    pass     fetch ABCD-1\n" +
+				"    pass     jql project = JRA\n",
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "explain_invalid",
+			args:         []string{"--value", "not a key", "--explain"},
+			wantOut:      `  fail     issue_keys: "not a key" is not a jira issue key`,
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "json",
+			args:         []string{"--format", "json", "--value", "ABCD-1", "--explain"},
+			wantOut:      `"check": "fetch"`,
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "no_value",
+			wantErr:      "no justification given",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "unexpected_args",
+			args:         []string{"--value", "ABCD-1", "extra"},
+			wantErr:      `unexpected arguments: ["extra"]`,
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &ValidateCommand{
+				newPlugin: func(ctx context.Context, cfg *plugin.PluginConfig) (*plugin.JiraPlugin, error) {
+					return plugin.NewJiraPluginWithToken(ctx, cfg, "secrets")
+				},
+			}
+			cmd.SetLookupEnv(cli.MapLookuper(env))
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+
+			out := stdout.String()
+			if !strings.Contains(out, tc.wantOut) {
+				t.Errorf("output %q does not contain %q", out, tc.wantOut)
+			}
+			if tc.wantErr == "" && tc.args[0] == "--format" {
+				var got validateOutput
+				if err := json.Unmarshal([]byte(out), &got); err != nil {
+					t.Errorf("output is not a validation result: %v", err)
+				}
+				if !got.Valid || got.Explanation == nil {
+					t.Errorf("got %+v, want a valid explained result", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+// defaultWatchdogInterval is how often the server samples its resource
+// usage by default.
+const defaultWatchdogInterval = time.Minute
+
+// resourceStats is the resource usage of the plugin process.
+type resourceStats struct {
+	Goroutines int
+	HeapBytes  uint64
+
+	// OpenFDs is the number of open file descriptors, or -1 where they
+	// cannot be counted, i.e. outside of Linux.
+	OpenFDs int
+
+	NumGC        uint32
+	GCPauseTotal time.Duration
+	LastGCPause  time.Duration
+}
+
+// readResourceStats samples the resource usage of the process.
+func readResourceStats() resourceStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	s := resourceStats{
+		Goroutines:   runtime.NumGoroutine(),
+		HeapBytes:    ms.HeapAlloc,
+		OpenFDs:      -1,
+		NumGC:        ms.NumGC,
+		GCPauseTotal: time.Duration(ms.PauseTotalNs), //nolint:gosec // Pauses do not overflow an int64
+	}
+	if ms.NumGC > 0 {
+		s.LastGCPause = time.Duration(ms.PauseNs[(ms.NumGC+255)%256]) //nolint:gosec // Pauses do not overflow an int64
+	}
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		s.OpenFDs = len(entries)
+	}
+	return s
+}
+
+// LogValue implements [slog.LogValuer].
+func (s resourceStats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("goroutines", s.Goroutines),
+		slog.Uint64("heap_bytes", s.HeapBytes),
+		slog.Int("open_fds", s.OpenFDs),
+		slog.Uint64("num_gc", uint64(s.NumGC)),
+		slog.Duration("gc_pause_total", s.GCPauseTotal),
+		slog.Duration("last_gc_pause", s.LastGCPause),
+	)
+}
+
+// watchdog checks the resource usage of the process against thresholds, to
+// catch leaks, e.g. of goroutines of a background subsystem. A threshold of
+// 0 is not checked.
+type watchdog struct {
+	interval      time.Duration
+	maxGoroutines int
+	maxHeapBytes  uint64
+
+	// read samples the resource usage, it is mockable for testing and
+	// defaults to [readResourceStats].
+	read func() resourceStats
+}
+
+// exceeded returns the thresholds s exceeds.
+func (w *watchdog) exceeded(s resourceStats) []string {
+	var out []string
+	if w.maxGoroutines > 0 && s.Goroutines > w.maxGoroutines {
+		out = append(out, fmt.Sprintf("%d goroutines exceed %d", s.Goroutines, w.maxGoroutines))
+	}
+	if w.maxHeapBytes > 0 && s.HeapBytes > w.maxHeapBytes {
+		out = append(out, fmt.Sprintf("%d heap bytes exceed %d", s.HeapBytes, w.maxHeapBytes))
+	}
+	return out
+}
+
+// start samples the resource usage every interval until the returned
+// function is called. Every sample is logged at debug level, a sample
+// exceeding a threshold is logged as a warning and sent on the returned
+// channel, which is never closed and drops samples nobody receives.
+func (w *watchdog) start(ctx context.Context) (<-chan string, func()) {
+	read := w.read
+	if read == nil {
+		read = readResourceStats
+	}
+
+	trippedCh := make(chan string, 1)
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		logger := logging.FromContext(ctx)
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				s := read()
+				reasons := w.exceeded(s)
+				if len(reasons) == 0 {
+					logger.DebugContext(ctx, "plugin resource usage", "resources", s)
+					continue
+				}
+				reason := strings.Join(reasons, ", ")
+				logger.WarnContext(ctx, "plugin resource usage exceeds the watchdog thresholds",
+					"reason", reason,
+					"resources", s)
+				select {
+				case trippedCh <- reason:
+				default:
+				}
+			}
+		}
+	}()
+
+	return trippedCh, func() {
+		close(stopCh)
+		<-doneCh
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestWatchdog_Exceeded(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		w     *watchdog
+		stats resourceStats
+		want  []string
+	}{
+		{
+			name:  "no_thresholds",
+			w:     &watchdog{},
+			stats: resourceStats{Goroutines: 1 << 20, HeapBytes: 1 << 40},
+		},
+		{
+			name:  "within",
+			w:     &watchdog{maxGoroutines: 100, maxHeapBytes: 1 << 20},
+			stats: resourceStats{Goroutines: 100, HeapBytes: 1 << 20},
+		},
+		{
+			name:  "both",
+			w:     &watchdog{maxGoroutines: 100, maxHeapBytes: 1 << 20},
+			stats: resourceStats{Goroutines: 101, HeapBytes: 1<<20 + 1},
+			want:  []string{"101 goroutines exceed 100", "1048577 heap bytes exceed 1048576"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.want, tc.w.exceeded(tc.stats)); diff != "" {
+				t.Errorf("exceeded (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWatchdog_Start(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	w := &watchdog{
+		interval:      time.Millisecond,
+		maxGoroutines: 10,
+		read: func() resourceStats {
+			return resourceStats{Goroutines: 11}
+		},
+	}
+	trippedCh, stop := w.start(ctx)
+	defer stop()
+
+	select {
+	case reason := <-trippedCh:
+		if want := "11 goroutines exceed 10"; reason != want {
+			t.Errorf("got reason %q, want %q", reason, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchdog did not trip")
+	}
+}
+
+func TestReadResourceStats(t *testing.T) {
+	t.Parallel()
+
+	s := readResourceStats()
+	if s.Goroutines < 1 {
+		t.Errorf("got %d goroutines, want at least 1", s.Goroutines)
+	}
+	if s.HeapBytes == 0 {
+		t.Errorf("got no heap bytes")
+	}
+	if s.OpenFDs == 0 {
+		t.Errorf("got 0 open file descriptors, want -1 or more")
+	}
+}
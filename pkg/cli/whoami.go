@@ -0,0 +1,105 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// WhoAmICommand prints the Jira account the plugin authenticates as.
+type WhoAmICommand struct {
+	cli.BaseCommand
+
+	cfg *plugin.PluginConfig
+
+	flagFormat string
+
+	// newValidator creates the validator for the config, it is mockable for
+	// testing.
+	newValidator func(context.Context, *plugin.PluginConfig) (*plugin.Validator, error)
+}
+
+func (c *WhoAmICommand) Desc() string {
+	return `Show the Jira account the Jira Plugin authenticates as`
+}
+
+func (c *WhoAmICommand) Help() string {
+	return `
+Usage: {{ COMMAND }} [options]
+
+  Fetch the account of the plugin credentials from Jira and print its
+  account ID, email address, display name and groups. Include the output in
+  permission requests to Jira admins.
+`
+}
+
+func (c *WhoAmICommand) Flags() *cli.FlagSet {
+	c.cfg = &plugin.PluginConfig{}
+	set := c.cfg.ToFlags(c.NewFlagSet())
+
+	f := set.NewSection("WHOAMI OPTIONS")
+
+	formatVar(f, &c.flagFormat)
+
+	return set
+}
+
+func (c *WhoAmICommand) Run(ctx context.Context, args []string) error {
+	f := c.Flags()
+	if err := f.Parse(args); err != nil {
+		return newConfigError(fmt.Errorf("failed to parse flags: %w", err))
+	}
+	if args := f.Args(); len(args) > 0 {
+		return newConfigError(fmt.Errorf("unexpected arguments: %q", args))
+	}
+	if err := checkFormat(c.flagFormat); err != nil {
+		return err
+	}
+
+	if err := c.cfg.Validate(); err != nil {
+		return newConfigError(fmt.Errorf("invalid configuration: %w", err))
+	}
+
+	newValidator := c.newValidator
+	if newValidator == nil {
+		newValidator = plugin.NewValidatorFromConfig
+	}
+	v, err := newValidator(ctx, c.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create validator: %w", err)
+	}
+
+	id, err := v.WhoAmI(ctx)
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+
+	if c.flagFormat == formatJSON {
+		return outJSON(&c.BaseCommand, id)
+	}
+
+	c.Outf("%-24s %s", "endpoint", id.Endpoint)
+	c.Outf("%-24s %s", "account id", id.AccountID)
+	c.Outf("%-24s %s", "email address", id.EmailAddress)
+	c.Outf("%-24s %s", "display name", id.DisplayName)
+	c.Outf("%-24s %t", "active", id.Active)
+	c.Outf("%-24s %s", "groups", strings.Join(id.Groups, ", "))
+	return nil
+}
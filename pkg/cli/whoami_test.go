@@ -0,0 +1,132 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestWhoAmICommand(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/myself", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("expand"); got != "groups" {
+			http.Error(w, fmt.Sprintf("unexpected expand %q", got), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"accountId":"5b10a2844c20165700ede21g","emailAddress":"jvs@xyz.com","displayName":"JVS Bot","active":true,`+
+			`"groups":{"size":1,"items":[{"name":"jira-software-users"}]}}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	env := map[string]string{
+		"JIRA_PLUGIN_ENDPOINT":            srv.URL,
+		"JIRA_PLUGIN_JQL":                 "project = JRA",
+		"JIRA_PLUGIN_ACCOUNT":             "abc@xyz.com",
+		"JIRA_PLUGIN_API_TOKEN_SECRET_ID": "projects/123456/secrets/api-token/versions/4",
+		"JIRA_PLUGIN_HINT":                "Jira Issue Key",
+		"JIRA_PLUGIN_ISSUE_BASE_URL":      srv.URL,
+		"JIRA_PLUGIN_ALLOW_HTTP":          "true",
+	}
+
+	cases := []struct {
+		name         string
+		args         []string
+		env          map[string]string
+		wantOut      []string
+		wantErr      string
+		wantExitCode int
+	}{
+		{
+			name: "text",
+			env:  env,
+			wantOut: []string{
+				"account id               5b10a2844c20165700ede21g",
+				"email address            jvs@xyz.com",
+				"display name             JVS Bot",
+				"active                   true",
+				"groups                   jira-software-users",
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name: "json",
+			args: []string{"--format", "json"},
+			env:  env,
+			wantOut: []string{
+				`"account_id": "5b10a2844c20165700ede21g"`,
+				`"jira-software-users"`,
+			},
+			wantExitCode: ExitCodeOK,
+		},
+		{
+			name:         "unexpected_args",
+			args:         []string{"ABCD-123"},
+			env:          env,
+			wantErr:      "unexpected arguments",
+			wantExitCode: ExitCodeConfig,
+		},
+		{
+			name:         "invalid_config",
+			wantErr:      "empty JIRA_PLUGIN_ENDPOINT",
+			wantExitCode: ExitCodeConfig,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			cmd := &WhoAmICommand{
+				newValidator: func(ctx context.Context, cfg *plugin.PluginConfig) (*plugin.Validator, error) {
+					return plugin.NewValidator(cfg.JIRAEndpoint, cfg.Jql, cfg.JIRAAccount, "secrets")
+				},
+			}
+			cmd.SetLookupEnv(cli.MapLookuper(tc.env))
+			_, stdout, _ := cmd.Pipe()
+
+			err := cmd.Run(ctx, tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := ExitCode(err), tc.wantExitCode; got != want {
+				t.Errorf("ExitCode() got %d, want %d", got, want)
+			}
+
+			out := stdout.String()
+			for _, want := range tc.wantOut {
+				if !strings.Contains(out, want) {
+					t.Errorf("output %q does not contain %q", out, want)
+				}
+			}
+		})
+	}
+}
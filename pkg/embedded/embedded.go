@@ -0,0 +1,85 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package embedded runs the Jira plugin inside the process of the JVS
+// server, for deployments that compile their plugins into a single binary.
+// It skips the go-plugin subprocess and its gRPC round trip, and is
+// configured with the same [plugin.PluginConfig] and JIRA_PLUGIN_
+// environment variables as the plugin binary.
+//
+// The validator is registered with the JVS server for [Category] like the
+// built-in validators:
+//
+//	v, err := embedded.NewFromEnv(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer v.Close(ctx)
+//	validators[embedded.Category] = v
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/cli"
+)
+
+// Category is the justification category the validator validates.
+const Category = plugin.Category
+
+// Validator is the Jira plugin as a [jvspb.Validator]. Close waits for the
+// validations in flight until ctx is done and releases the connections to
+// Jira.
+type Validator interface {
+	jvspb.Validator
+	Close(ctx context.Context) error
+}
+
+var (
+	_ Validator                  = (*plugin.JiraPlugin)(nil)
+	_ plugin.TokenClaimsProvider = (*plugin.JiraPlugin)(nil)
+)
+
+// New validates the configuration and creates the validator. The API token
+// is fetched from Secret Manager on the first validation, like in the
+// plugin binary started without -warmup.
+func New(ctx context.Context, cfg *plugin.PluginConfig, opts ...plugin.Option) (Validator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w: %w", err, plugin.ErrInvalidConfig)
+	}
+	p, err := plugin.NewJiraPlugin(ctx, cfg, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedded jira plugin: %w", err)
+	}
+	return p, nil
+}
+
+// NewFromEnv is [New] with the configuration read from the JIRA_PLUGIN_
+// environment variables of the plugin binary.
+func NewFromEnv(ctx context.Context, opts ...plugin.Option) (Validator, error) {
+	return newFromLookuper(ctx, os.LookupEnv, opts...)
+}
+
+func newFromLookuper(ctx context.Context, lookupEnv cli.LookupEnvFunc, opts ...plugin.Option) (Validator, error) {
+	cfg := &plugin.PluginConfig{}
+	set := cfg.ToFlags(cli.NewFlagSet(cli.WithLookupEnv(lookupEnv)))
+	if err := set.Parse(nil); err != nil {
+		return nil, fmt.Errorf("failed to read configuration: %w: %w", err, plugin.ErrInvalidConfig)
+	}
+	return New(ctx, cfg, opts...)
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package embedded
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/cli"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		env      map[string]string
+		wantHint string
+		wantErr  string
+	}{
+		{
+			name: "valid",
+			env: map[string]string{
+				"JIRA_PLUGIN_ENDPOINT":            "https://example.atlassian.net/rest/api/3",
+				"JIRA_PLUGIN_JQL":                 "project = JRA",
+				"JIRA_PLUGIN_ACCOUNT":             "abc@xyz.com",
+				"JIRA_PLUGIN_API_TOKEN_SECRET_ID": "projects/123456/secrets/api-token/versions/4",
+				"JIRA_PLUGIN_HINT":                "Jira Issue Key",
+				"JIRA_PLUGIN_ISSUE_BASE_URL":      "https://example.atlassian.net",
+			},
+			wantHint: "Jira Issue Key",
+		},
+		{
+			name:    "invalid_config",
+			env:     map[string]string{"JIRA_PLUGIN_ENDPOINT": "https://example.atlassian.net/rest/api/3"},
+			wantErr: "empty JIRA_PLUGIN_JQL",
+		},
+		{
+			name:    "invalid_value",
+			env:     map[string]string{"JIRA_PLUGIN_HTTP_RETRIES": "many"},
+			wantErr: "failed to read configuration",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			v, err := newFromLookuper(ctx, cli.MapLookuper(tc.env))
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				if !errors.Is(err, plugin.ErrInvalidConfig) {
+					t.Errorf("got err %v, want it to wrap %v", err, plugin.ErrInvalidConfig)
+				}
+				return
+			}
+			t.Cleanup(func() { v.Close(ctx) })
+
+			uiData, err := v.GetUIData(ctx, &jvspb.GetUIDataRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := uiData.GetHint(); got != tc.wantHint {
+				t.Errorf("got hint %q, want %q", got, tc.wantHint)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jiraplugin exposes the Jira issue validation of the plugin as a
+// library, for Go services that want the same validation without running it
+// as a JVS plugin over go-plugin.
+//
+// The exported API of this package follows semantic versioning: it will not
+// change in a backwards incompatible way within a major version. It does not
+// depend on the JVS protobuf types.
+package jiraplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+)
+
+// The errors returned by a [Client], for callers to check with [errors.Is].
+var (
+	// ErrJiraUnreachable is wrapped by errors caused by Jira not being
+	// reachable or failing, i.e. network failures and 5xx responses.
+	ErrJiraUnreachable = plugin.ErrJiraUnreachable
+
+	// ErrJiraMaintenance is wrapped, along with ErrJiraUnreachable, by
+	// errors caused by Jira answering with a maintenance page.
+	ErrJiraMaintenance = plugin.ErrJiraMaintenance
+
+	// ErrJiraAuth is wrapped by errors caused by Jira rejecting the
+	// credentials.
+	ErrJiraAuth = plugin.ErrJiraAuth
+
+	// ErrClosed is returned by [Client.ValidateIssueKey] after
+	// [Client.Close].
+	ErrClosed = plugin.ErrClosed
+)
+
+// JiraStatus returns the status code of the Jira response that caused err,
+// and false when err was not caused by a Jira response.
+func JiraStatus(err error) (int, bool) {
+	return plugin.JiraStatus(err)
+}
+
+// RetryAfter returns how long Jira asked to wait before retrying the
+// validation that failed with err, and false when it did not say.
+func RetryAfter(err error) (time.Duration, bool) {
+	return plugin.RetryAfter(err)
+}
+
+// Parser extracts the issue keys from the value passed to
+// [Client.ValidateIssueKey].
+type Parser = plugin.JustificationParser
+
+// ParsedJustification is the result of a [Parser].
+type ParsedJustification = plugin.ParsedJustification
+
+// Config is the configuration of a [Client].
+type Config struct {
+	// Endpoint is the JIRA REST API base url, e.g.
+	// https://your-domain.atlassian.net/rest/api/3.
+	Endpoint string
+
+	// JQL is the query an issue must match to be valid.
+	JQL string
+
+	// Account is the user name used in JIRA Basic Auth.
+	Account string
+
+	// APIToken is the API token used in JIRA Basic Auth.
+	APIToken string
+
+	// IssueBaseURL is used to construct a URL to the issue that can be
+	// clicked, e.g. https://your-domain.atlassian.net.
+	IssueBaseURL string
+
+	// Parser, when set, extracts the issue keys to validate from the value
+	// passed to [Client.ValidateIssueKey]. By default the value is the issue
+	// key.
+	Parser Parser
+}
+
+// Result is the outcome of validating an issue key.
+type Result struct {
+	// Valid reports whether the issue exists and matches the JQL.
+	Valid bool
+
+	// IssueID is the numeric id of the matched issue.
+	IssueID string
+
+	// IssueURL is the browsable URL of the matched issue.
+	IssueURL string
+
+	// Reasons explains why the issue is not valid.
+	Reasons []string
+
+	// Warnings are non-fatal messages returned by Jira.
+	Warnings []string
+}
+
+// Client validates Jira issue keys.
+type Client struct {
+	p *plugin.JiraPlugin
+}
+
+// New creates a new Client.
+func New(cfg *Config) (*Client, error) {
+	var merr error
+	if cfg.Endpoint == "" {
+		merr = errors.Join(merr, fmt.Errorf("empty Endpoint"))
+	}
+	if cfg.JQL == "" {
+		merr = errors.Join(merr, fmt.Errorf("empty JQL"))
+	}
+	if cfg.Account == "" {
+		merr = errors.Join(merr, fmt.Errorf("empty Account"))
+	}
+	if cfg.APIToken == "" {
+		merr = errors.Join(merr, fmt.Errorf("empty APIToken"))
+	}
+	if cfg.IssueBaseURL == "" {
+		merr = errors.Join(merr, fmt.Errorf("empty IssueBaseURL"))
+	}
+	if merr != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", merr)
+	}
+
+	var opts []plugin.Option
+	if cfg.Parser != nil {
+		opts = append(opts, plugin.WithJustificationParser(cfg.Parser))
+	}
+
+	p, err := plugin.NewJiraPluginWithToken(context.Background(), &plugin.PluginConfig{
+		JIRAEndpoint: cfg.Endpoint,
+		Jql:          cfg.JQL,
+		JIRAAccount:  cfg.Account,
+		IssueBaseURL: cfg.IssueBaseURL,
+	}, cfg.APIToken, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	return &Client{p: p}, nil
+}
+
+// ValidateIssueKey checks that the issue exists and matches the JQL. An
+// invalid issue is reported in the result, an error is only returned when
+// the validation could not be performed, e.g. Jira is unavailable.
+func (c *Client) ValidateIssueKey(ctx context.Context, key string) (*Result, error) {
+	resp, err := c.p.ValidateValue(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate issue %q: %w", key, err)
+	}
+
+	return &Result{
+		Valid:    resp.GetValid(),
+		IssueID:  resp.GetAnnotation()[plugin.AnnotationIssueID],
+		IssueURL: resp.GetAnnotation()[plugin.AnnotationIssueURL],
+		Reasons:  resp.GetError(),
+		Warnings: resp.GetWarning(),
+	}, nil
+}
+
+// Close waits for the validations in flight until ctx is done and releases
+// the connections to Jira. ValidateIssueKey fails after Close.
+func (c *Client) Close(ctx context.Context) error {
+	if err := c.p.Close(ctx); err != nil {
+		return fmt.Errorf("failed to close client: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jiraplugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/status"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+// prefixParser accepts values like "ticket:ABCD-1".
+type prefixParser struct{}
+
+func (p *prefixParser) Parse(value string) (*ParsedJustification, error) {
+	key, ok := strings.CutPrefix(value, "ticket:")
+	if !ok {
+		return nil, fmt.Errorf("missing ticket: prefix")
+	}
+	return &ParsedJustification{IssueKey: key}, nil
+}
+
+func TestClient_ValidateIssueKey(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		parser        Parser
+		value         string
+		issuesHandler http.HandlerFunc
+		matchHandler  http.HandlerFunc
+		want          *Result
+		wantErr       string
+		wantIs        error
+		wantStatus    int
+	}{
+		{
+			name: "valid",
+			issuesHandler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+			},
+			matchHandler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+			},
+			want: &Result{
+				Valid:    true,
+				IssueID:  "1234",
+				IssueURL: "https://example.atlassian.net/browse/ABCD-1",
+				Warnings: []string{},
+			},
+		},
+		{
+			name: "not_matching",
+			issuesHandler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+			},
+			matchHandler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"matches":[{"matchedIssues":[],"errors":[]}]}`)
+			},
+			want: &Result{
+				Reasons: []string{`no matched jira issue for justification "ABCD-1": invalid justification`},
+			},
+		},
+		{
+			name:   "custom_parser",
+			parser: &prefixParser{},
+			value:  "ticket:ABCD-1",
+			issuesHandler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+			},
+			matchHandler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+			},
+			want: &Result{
+				Valid:    true,
+				IssueID:  "1234",
+				IssueURL: "https://example.atlassian.net/browse/ABCD-1",
+				Warnings: []string{},
+			},
+		},
+		{
+			name:   "custom_parser_rejects",
+			parser: &prefixParser{},
+			value:  "ABCD-1",
+			want: &Result{
+				Reasons: []string{"failed to parse justification: missing ticket: prefix"},
+			},
+		},
+		{
+			name: "jira_unavailable",
+			issuesHandler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			wantErr:    "got response code 503",
+			wantIs:     ErrJiraUnreachable,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mux := http.NewServeMux()
+			if tc.issuesHandler != nil {
+				mux.Handle("/issue/", tc.issuesHandler)
+			}
+			if tc.matchHandler != nil {
+				mux.Handle("/jql/match", tc.matchHandler)
+			}
+
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			c, err := New(&Config{
+				Endpoint:     srv.URL,
+				JQL:          "status NOT IN (Done)",
+				Account:      "test@test.com",
+				APIToken:     "secrets",
+				IssueBaseURL: "https://example.atlassian.net",
+				Parser:       tc.parser,
+			})
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			value := tc.value
+			if value == "" {
+				value = "ABCD-1"
+			}
+			got, err := c.ValidateIssueKey(ctx, value)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if tc.wantIs != nil && !errors.Is(err, tc.wantIs) {
+				t.Errorf("ValidateIssueKey() got err %v, want it to wrap %v", err, tc.wantIs)
+			}
+			if code, _ := JiraStatus(err); code != tc.wantStatus {
+				t.Errorf("ValidateIssueKey() got Jira status %d, want %d", code, tc.wantStatus)
+			}
+			if _, ok := status.FromError(err); err != nil && ok {
+				t.Errorf("ValidateIssueKey() got gRPC status error %v, want a plain error", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ValidateIssueKey() unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(&Config{})
+	if diff := testutil.DiffErrString(err, "empty Endpoint"); diff != "" {
+		t.Errorf(diff)
+	}
+}
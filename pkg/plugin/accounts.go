@@ -0,0 +1,194 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+const (
+	// defaultAccountCacheTTL is how long a resolved account id is cached when
+	// no TTL is configured.
+	defaultAccountCacheTTL = time.Hour
+
+	// maxAccountCacheEntries bounds the cache of account ids, expired entries
+	// are dropped once it is full.
+	maxAccountCacheEntries = 10000
+
+	// userSearchMaxResults is the number of users asked for, more than one
+	// to tell an ambiguous email from a unique one.
+	userSearchMaxResults = 10
+)
+
+// accountCache caches the Jira account ids of requestors by email.
+type accountCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*accountEntry
+}
+
+// accountEntry is a cached account id.
+type accountEntry struct {
+	accountID string
+	expiresAt time.Time
+}
+
+// WithAccountIDs makes the validator replace the requestor placeholder of
+// the JQL with the Jira account id of the requestor instead of the email.
+// Jira Cloud no longer accepts usernames, and GDPR strict sites do not match
+// emails in JQL, e.g. in "assignee = {{.Requestor}}". The account id is
+// resolved with the user search API and cached for ttl, one hour when zero.
+//
+// When the privacy settings of the site hide the email of the user, the
+// single user Jira finds for the email is used. When the account lacks the
+// Browse users and groups permission, the email is used as is.
+func WithAccountIDs(ttl time.Duration) ValidatorOption {
+	return func(v *Validator) error {
+		if ttl < 0 {
+			return fmt.Errorf("account id cache ttl must be positive, got %s", ttl)
+		}
+		if ttl == 0 {
+			ttl = defaultAccountCacheTTL
+		}
+		v.accounts = &accountCache{
+			ttl:     ttl,
+			now:     time.Now,
+			entries: make(map[string]*accountEntry),
+		}
+		return nil
+	}
+}
+
+// get returns the cached account id of the email.
+func (c *accountCache) get(email string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[strings.ToLower(email)]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return "", false
+	}
+	return entry.accountID, true
+}
+
+// put caches the account id of the email.
+func (c *accountCache) put(email, accountID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if len(c.entries) >= maxAccountCacheEntries {
+		for k, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) < maxAccountCacheEntries {
+		c.entries[strings.ToLower(email)] = &accountEntry{accountID: accountID, expiresAt: now.Add(c.ttl)}
+	}
+}
+
+// accountID returns the Jira account id of the requestor with the email, see
+// [WithAccountIDs]. A subject that is not an email is taken as an account id.
+// The error wraps ErrInvalidJustification when no single user matches.
+func (v *Validator) accountID(ctx context.Context, email string) (string, error) {
+	if !strings.Contains(email, "@") {
+		return email, nil
+	}
+	if id, ok := v.accounts.get(email); ok {
+		return id, nil
+	}
+
+	users, err := v.SearchUsers(ctx, email)
+	if errors.Is(err, ErrJiraAuth) {
+		logging.FromContext(ctx).WarnContext(ctx, "cannot search jira users, using the email of the requestor",
+			"error", err)
+		return email, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the jira account of the requestor: %w", err)
+	}
+	id, err := pickAccount(users, email)
+	if err != nil {
+		return "", err
+	}
+	v.accounts.put(email, id)
+	return id, nil
+}
+
+// pickAccount returns the account id of the only user with the email. Users
+// whose email the privacy settings hide are only considered when no user
+// shows it, Jira found them by their email all the same.
+func pickAccount(users []*JiraUser, email string) (string, error) {
+	var shown, hidden []string
+	for _, u := range users {
+		switch {
+		case strings.EqualFold(u.EmailAddress, email):
+			shown = append(shown, u.AccountID)
+		case u.EmailAddress == "":
+			hidden = append(hidden, u.AccountID)
+		}
+	}
+	candidates := shown
+	if len(candidates) == 0 {
+		candidates = hidden
+	}
+	switch len(candidates) {
+	case 1:
+		return candidates[0], nil
+	case 0:
+		return "", WithReason(fmt.Errorf("no jira user has the email of the requestor %q: %w", email, ErrInvalidJustification), ErrorCodeRequestorNotInJira)
+	default:
+		return "", WithReason(fmt.Errorf("%d jira users match the email of the requestor %q: %w", len(candidates), email, ErrInvalidJustification), ErrorCodeRequestorNotInJira)
+	}
+}
+
+// SearchUsers returns the jira users matching the query, e.g. an email
+// address.
+func (v *Validator) SearchUsers(ctx context.Context, query string) ([]*JiraUser, error) {
+	// Construct [Find Users API].
+	//
+	// [Find Users API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-user-search/#api-rest-api-3-user-search-get
+	u := v.endpointURL("user", "search")
+	q := u.Query()
+	q.Set("query", query)
+	q.Set("maxResults", strconv.Itoa(userSearchMaxResults))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct user search request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	var users []*JiraUser
+	if err := v.makeRequest(req, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,222 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestPickAccount(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		users   []*JiraUser
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "email",
+			users: []*JiraUser{{AccountID: "5b10a", EmailAddress: "Jane@Example.com"}},
+			want:  "5b10a",
+		},
+		{
+			name: "email_over_hidden",
+			users: []*JiraUser{
+				{AccountID: "5b10a", EmailAddress: "jane@example.com"},
+				{AccountID: "5b10b"},
+			},
+			want: "5b10a",
+		},
+		{
+			name:  "hidden_email",
+			users: []*JiraUser{{AccountID: "5b10b"}},
+			want:  "5b10b",
+		},
+		{
+			name:    "none",
+			users:   []*JiraUser{{AccountID: "5b10c", EmailAddress: "janet@example.com"}},
+			wantErr: `no jira user has the email of the requestor "jane@example.com"`,
+		},
+		{
+			name:    "ambiguous_hidden",
+			users:   []*JiraUser{{AccountID: "5b10b"}, {AccountID: "5b10c"}},
+			wantErr: `2 jira users match the email of the requestor "jane@example.com"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := pickAccount(tc.users, "jane@example.com")
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got != tc.want {
+				t.Errorf("got account %q, want %q", got, tc.want)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidJustification) {
+					t.Errorf("got error %v, want ErrInvalidJustification", err)
+				}
+				if got, want := Reason(err), ErrorCodeRequestorNotInJira; got != want {
+					t.Errorf("got reason %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidator_MatchIssue_AccountIDs(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		users      string
+		searchCode int
+		subject    string
+		wantJQL    string
+		wantSearch int64
+		wantErr    string
+	}{
+		{
+			name:       "resolved_and_cached",
+			users:      `[{"accountId":"5b10a","emailAddress":"jane@example.com","active":true}]`,
+			subject:    "jane@example.com",
+			wantJQL:    `assignee = "5b10a"`,
+			wantSearch: 1,
+		},
+		{
+			name:       "hidden_email",
+			users:      `[{"accountId":"5b10b","displayName":"Jane","active":true}]`,
+			subject:    "jane@example.com",
+			wantJQL:    `assignee = "5b10b"`,
+			wantSearch: 1,
+		},
+		{
+			name:       "account_id_subject",
+			subject:    "5b10c",
+			wantJQL:    `assignee = "5b10c"`,
+			wantSearch: 0,
+		},
+		{
+			name:       "no_permission",
+			searchCode: http.StatusForbidden,
+			subject:    "jane@example.com",
+			wantJQL:    `assignee = "jane@example.com"`,
+			wantSearch: 2,
+		},
+		{
+			name:       "unknown_user",
+			users:      `[]`,
+			subject:    "jane@example.com",
+			wantSearch: 2,
+			wantErr:    `no jira user has the email of the requestor "jane@example.com"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var searches atomic.Int64
+			var gotJQL atomic.Value
+			mux := http.NewServeMux()
+			mux.HandleFunc("/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":"1","key":"ABCD-1"}`)
+			})
+			mux.HandleFunc("/user/search", func(w http.ResponseWriter, r *http.Request) {
+				searches.Add(1)
+				if got, want := r.URL.Query().Get("query"), tc.subject; got != want {
+					t.Errorf("got user query %q, want %q", got, want)
+				}
+				if tc.searchCode != 0 {
+					w.WriteHeader(tc.searchCode)
+					return
+				}
+				fmt.Fprint(w, tc.users)
+			})
+			mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+				var req matchData
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Error(err)
+				}
+				gotJQL.Store(req.Jqls[0])
+				fmt.Fprint(w, `{"matches":[{"matchedIssues":[1],"errors":[]}]}`)
+			})
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			v, err := NewValidator(srv.URL, "assignee = {{.Requestor}}", "test@test.com", "token",
+				WithAccountIDs(time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			ctx = WithRequestor(ctx, &Requestor{Subject: tc.subject})
+			for i := 0; i < 2; i++ {
+				_, err := v.MatchIssue(ctx, "ABCD-1")
+				if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+					t.Fatal(diff)
+				}
+			}
+			if tc.wantJQL != "" {
+				if got := gotJQL.Load(); got != tc.wantJQL {
+					t.Errorf("got JQL %q, want %q", got, tc.wantJQL)
+				}
+			}
+			if got := searches.Load(); got != tc.wantSearch {
+				t.Errorf("got %d user searches, want %d", got, tc.wantSearch)
+			}
+		})
+	}
+}
+
+func TestAccountCache_Expiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	v := &Validator{}
+	if err := WithAccountIDs(time.Minute)(v); err != nil {
+		t.Fatal(err)
+	}
+	v.accounts.now = func() time.Time { return now }
+
+	v.accounts.put("Jane@example.com", "5b10a")
+	if got, ok := v.accounts.get("jane@EXAMPLE.com"); !ok || got != "5b10a" {
+		t.Errorf("got (%q, %t), want (%q, true)", got, ok, "5b10a")
+	}
+	now = now.Add(time.Minute)
+	if got, ok := v.accounts.get("jane@example.com"); ok {
+		t.Errorf("got expired account %q", got)
+	}
+}
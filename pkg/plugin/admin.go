@@ -0,0 +1,438 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// AdminWhoAmIMethod is the full name of the unary RPC returning the
+	// [JiraIdentity] of the plugin, see [JiraPlugin.RegisterAdmin]. The
+	// request is a google.protobuf.Empty and the response a
+	// google.protobuf.Struct.
+	AdminWhoAmIMethod = "/jvs_plugin_jira.Admin/WhoAmI"
+
+	// AdminCacheStatsMethod is the full name of the unary RPC returning the
+	// [CacheStats] of the plugin. The request is a google.protobuf.Empty and
+	// the response a google.protobuf.Struct.
+	AdminCacheStatsMethod = "/jvs_plugin_jira.Admin/CacheStats"
+
+	// AdminCacheLookupMethod is the full name of the unary RPC returning the
+	// [CachedDecision] of an issue. The request and the response are
+	// google.protobuf.Structs, the request has an issue_key and, when the
+	// JQL depends on the requestor, a subject.
+	AdminCacheLookupMethod = "/jvs_plugin_jira.Admin/CacheLookup"
+
+	// AdminCachePurgeMethod is the full name of the unary RPC purging the
+	// cached decisions of an issue, or of every issue when the issue_key is
+	// empty. The request is like the one of [AdminCacheLookupMethod], the
+	// response a google.protobuf.Struct with the number of entries purged.
+	AdminCachePurgeMethod = "/jvs_plugin_jira.Admin/CachePurge"
+
+	// AdminDiagnosticsMethod is the full name of the unary RPC returning the
+	// [Diagnostics] of the plugin. The request is a google.protobuf.Empty and
+	// the response a google.protobuf.Struct with the diagnostics as JSON.
+	AdminDiagnosticsMethod = "/jvs_plugin_jira.Admin/Diagnostics"
+
+	// AdminFeatureFlagsMethod is the full name of the unary RPC returning the
+	// [FeatureFlag]s of the plugin. The request is a google.protobuf.Empty
+	// and the response a google.protobuf.Struct with the list of flags.
+	AdminFeatureFlagsMethod = "/jvs_plugin_jira.Admin/FeatureFlags"
+
+	// AdminSetFeatureFlagMethod is the full name of the unary RPC overriding
+	// a feature flag. The request is a google.protobuf.Struct with the name
+	// and either enabled, or reset to remove the override, and the response
+	// is like the one of [AdminFeatureFlagsMethod].
+	AdminSetFeatureFlagMethod = "/jvs_plugin_jira.Admin/SetFeatureFlag"
+)
+
+// RegisterAdmin registers the administration service of
+// [AdminWhoAmIMethod], [AdminDiagnosticsMethod], the cache methods and the
+// feature flag methods on a gRPC server, e.g. the one go-plugin serves,
+// which only the JVS server can reach, or one on a local socket for
+// operators.
+func (j *JiraPlugin) RegisterAdmin(s *grpc.Server) {
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "jvs_plugin_jira.Admin",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{
+			{
+				MethodName: "WhoAmI",
+				Handler:    j.whoAmIHandler,
+			},
+			{
+				MethodName: "CacheStats",
+				Handler:    j.structHandler(AdminCacheStatsMethod, j.cacheStatsRPC),
+			},
+			{
+				MethodName: "CacheLookup",
+				Handler:    j.structHandler(AdminCacheLookupMethod, j.cacheLookupRPC),
+			},
+			{
+				MethodName: "CachePurge",
+				Handler:    j.structHandler(AdminCachePurgeMethod, j.cachePurgeRPC),
+			},
+			{
+				MethodName: "Diagnostics",
+				Handler:    j.structHandler(AdminDiagnosticsMethod, j.diagnosticsRPC),
+			},
+			{
+				MethodName: "FeatureFlags",
+				Handler:    j.structHandler(AdminFeatureFlagsMethod, j.featureFlagsRPC),
+			},
+			{
+				MethodName: "SetFeatureFlag",
+				Handler:    j.structHandler(AdminSetFeatureFlagMethod, j.setFeatureFlagRPC),
+			},
+		},
+	}, j)
+}
+
+// whoAmIHandler serves [AdminWhoAmIMethod] through the interceptors of the
+// server.
+func (j *JiraPlugin) whoAmIHandler(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := &emptypb.Empty{}
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, _ any) (any, error) {
+		id, err := j.WhoAmI(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to get jira identity: %s", err)
+		}
+		return identityToStruct(id)
+	}
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: j, FullMethod: AdminWhoAmIMethod}, handler)
+}
+
+// WhoAmI asks the plugin served on cc for its [JiraIdentity].
+func WhoAmI(ctx context.Context, cc grpc.ClientConnInterface) (*JiraIdentity, error) {
+	out := &structpb.Struct{}
+	if err := cc.Invoke(ctx, AdminWhoAmIMethod, &emptypb.Empty{}, out); err != nil {
+		return nil, fmt.Errorf("failed to get jira identity: %w", err)
+	}
+	return identityFromStruct(out), nil
+}
+
+// identityToStruct encodes the identity for the admin service.
+func identityToStruct(id *JiraIdentity) (*structpb.Struct, error) {
+	groups := make([]any, 0, len(id.Groups))
+	for _, g := range id.Groups {
+		groups = append(groups, g)
+	}
+	msg, err := structpb.NewStruct(map[string]any{
+		"endpoint":      id.Endpoint,
+		"account_id":    id.AccountID,
+		"email_address": id.EmailAddress,
+		"display_name":  id.DisplayName,
+		"active":        id.Active,
+		"groups":        groups,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode identity: %w", err)
+	}
+	return msg, nil
+}
+
+// identityFromStruct decodes an identity of the admin service, ignoring
+// fields it does not know.
+func identityFromStruct(msg *structpb.Struct) *JiraIdentity {
+	f := msg.GetFields()
+	id := &JiraIdentity{
+		Endpoint:     f["endpoint"].GetStringValue(),
+		AccountID:    f["account_id"].GetStringValue(),
+		EmailAddress: f["email_address"].GetStringValue(),
+		DisplayName:  f["display_name"].GetStringValue(),
+		Active:       f["active"].GetBoolValue(),
+		Groups:       []string{},
+	}
+	for _, v := range f["groups"].GetListValue().GetValues() {
+		id.Groups = append(id.Groups, v.GetStringValue())
+	}
+	return id
+}
+
+// structHandler serves an administration method through the interceptors
+// of the server. The request of every method decodes as a
+// google.protobuf.Struct, an empty message included.
+func (j *JiraPlugin) structHandler(method string, call func(context.Context, *structpb.Struct) (*structpb.Struct, error)) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	return func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := &structpb.Struct{}
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req any) (any, error) {
+			out, err := call(ctx, req.(*structpb.Struct)) //nolint:forcetypeassert // The request decoded above
+			if err != nil {
+				return nil, adminStatus(err)
+			}
+			return out, nil
+		}
+		if interceptor == nil {
+			return handler(ctx, in)
+		}
+		return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: j, FullMethod: method}, handler)
+	}
+}
+
+// adminStatus converts an error of an administration method into a
+// gRPC status.
+func adminStatus(err error) error {
+	switch {
+	case errors.Is(err, errInvalidAdminRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrCacheDisabled):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func (j *JiraPlugin) cacheStatsRPC(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	stats, err := j.CacheStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(map[string]any{ //nolint:wrapcheck // Only fails for invalid values
+		"backend":       stats.Backend,
+		"ttl":           stats.TTL.String(),
+		"entries":       stats.Entries,
+		"total_entries": stats.TotalEntries,
+		"hits":          stats.Hits,
+		"stale_hits":    stats.StaleHits,
+		"misses":        stats.Misses,
+		"revalidations": stats.Revalidations,
+	})
+}
+
+func (j *JiraPlugin) cacheLookupRPC(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	f := in.GetFields()
+	d, err := j.LookupCachedDecision(ctx, f["issue_key"].GetStringValue(), f["subject"].GetStringValue())
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return structpb.NewStruct(map[string]any{"found": false}) //nolint:wrapcheck // Only fails for invalid values
+	}
+	result, err := json.Marshal(d.Result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode cached match: %w", err)
+	}
+	return structpb.NewStruct(map[string]any{ //nolint:wrapcheck // Only fails for invalid values
+		"found":      true,
+		"issue_key":  d.IssueKey,
+		"expires_at": d.ExpiresAt.UTC().Format(time.RFC3339Nano),
+		"expired":    d.Expired,
+		"result":     string(result),
+	})
+}
+
+func (j *JiraPlugin) cachePurgeRPC(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	f := in.GetFields()
+	n, err := j.PurgeCache(ctx, f["issue_key"].GetStringValue(), f["subject"].GetStringValue())
+	if err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(map[string]any{"purged": n}) //nolint:wrapcheck // Only fails for invalid values
+}
+
+func (j *JiraPlugin) diagnosticsRPC(ctx context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	b, err := json.Marshal(j.Diagnostics(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode diagnostics: %w", err)
+	}
+	return structpb.NewStruct(map[string]any{"diagnostics": string(b)}) //nolint:wrapcheck // Only fails for invalid values
+}
+
+func (j *JiraPlugin) featureFlagsRPC(_ context.Context, _ *structpb.Struct) (*structpb.Struct, error) {
+	return featureFlagsToStruct(j.FeatureFlags())
+}
+
+func (j *JiraPlugin) setFeatureFlagRPC(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	f := in.GetFields()
+	name := f["name"].GetStringValue()
+	var err error
+	if f["reset"].GetBoolValue() {
+		err = j.ResetFeatureFlag(ctx, name)
+	} else {
+		err = j.SetFeatureFlag(ctx, name, f["enabled"].GetBoolValue())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return featureFlagsToStruct(j.FeatureFlags())
+}
+
+// featureFlagsToStruct encodes the feature flags for the admin service.
+func featureFlagsToStruct(flags []FeatureFlag) (*structpb.Struct, error) {
+	list := make([]any, 0, len(flags))
+	for _, flag := range flags {
+		list = append(list, map[string]any{
+			"name":       flag.Name,
+			"enabled":    flag.Enabled,
+			"configured": flag.Configured,
+			"overridden": flag.Overridden,
+		})
+	}
+	return structpb.NewStruct(map[string]any{"flags": list}) //nolint:wrapcheck // Only fails for invalid values
+}
+
+// featureFlagsFromStruct decodes the feature flags of the admin service.
+func featureFlagsFromStruct(msg *structpb.Struct) []FeatureFlag {
+	values := msg.GetFields()["flags"].GetListValue().GetValues()
+	flags := make([]FeatureFlag, 0, len(values))
+	for _, v := range values {
+		f := v.GetStructValue().GetFields()
+		flags = append(flags, FeatureFlag{
+			Name:       f["name"].GetStringValue(),
+			Enabled:    f["enabled"].GetBoolValue(),
+			Configured: f["configured"].GetBoolValue(),
+			Overridden: f["overridden"].GetBoolValue(),
+		})
+	}
+	return flags
+}
+
+// GetCacheStats asks the plugin served on cc for its [CacheStats].
+func GetCacheStats(ctx context.Context, cc grpc.ClientConnInterface) (*CacheStats, error) {
+	out := &structpb.Struct{}
+	if err := cc.Invoke(ctx, AdminCacheStatsMethod, &structpb.Struct{}, out); err != nil {
+		return nil, fmt.Errorf("failed to get cache stats: %w", err)
+	}
+	f := out.GetFields()
+	ttl, _ := time.ParseDuration(f["ttl"].GetStringValue()) //nolint:errcheck // Zero when unknown
+	return &CacheStats{
+		Backend:       f["backend"].GetStringValue(),
+		TTL:           ttl,
+		Entries:       int(f["entries"].GetNumberValue()),
+		TotalEntries:  int(f["total_entries"].GetNumberValue()),
+		Hits:          int64(f["hits"].GetNumberValue()),
+		StaleHits:     int64(f["stale_hits"].GetNumberValue()),
+		Misses:        int64(f["misses"].GetNumberValue()),
+		Revalidations: int64(f["revalidations"].GetNumberValue()),
+	}, nil
+}
+
+// LookupCachedDecision asks the plugin served on cc for the
+// [CachedDecision] of the issue, it returns nil when there is none. The
+// subject of the requestor is only needed when the JQL depends on it.
+func LookupCachedDecision(ctx context.Context, cc grpc.ClientConnInterface, issueKey, subject string) (*CachedDecision, error) {
+	out := &structpb.Struct{}
+	if err := cc.Invoke(ctx, AdminCacheLookupMethod, cacheRequest(issueKey, subject), out); err != nil {
+		return nil, fmt.Errorf("failed to look up cached decision: %w", err)
+	}
+	f := out.GetFields()
+	if !f["found"].GetBoolValue() {
+		return nil, nil
+	}
+	d := &CachedDecision{
+		IssueKey: f["issue_key"].GetStringValue(),
+		Expired:  f["expired"].GetBoolValue(),
+	}
+	var err error
+	if d.ExpiresAt, err = time.Parse(time.RFC3339Nano, f["expires_at"].GetStringValue()); err != nil {
+		return nil, fmt.Errorf("failed to decode cached decision: %w", err)
+	}
+	if err := json.Unmarshal([]byte(f["result"].GetStringValue()), &d.Result); err != nil {
+		return nil, fmt.Errorf("failed to decode cached decision: %w", err)
+	}
+	return d, nil
+}
+
+// PurgeCache asks the plugin served on cc to purge the cached decisions of
+// the issue, or of every issue when issueKey is empty, and returns the
+// number of entries purged. See [JiraPlugin.PurgeCache].
+func PurgeCache(ctx context.Context, cc grpc.ClientConnInterface, issueKey, subject string) (int, error) {
+	out := &structpb.Struct{}
+	if err := cc.Invoke(ctx, AdminCachePurgeMethod, cacheRequest(issueKey, subject), out); err != nil {
+		return 0, fmt.Errorf("failed to purge cache: %w", err)
+	}
+	return int(out.GetFields()["purged"].GetNumberValue()), nil
+}
+
+// GetDiagnostics asks the plugin served on cc for its [Diagnostics].
+func GetDiagnostics(ctx context.Context, cc grpc.ClientConnInterface) (*Diagnostics, error) {
+	out := &structpb.Struct{}
+	if err := cc.Invoke(ctx, AdminDiagnosticsMethod, &structpb.Struct{}, out); err != nil {
+		return nil, fmt.Errorf("failed to get diagnostics: %w", err)
+	}
+	d := &Diagnostics{}
+	if err := json.Unmarshal([]byte(out.GetFields()["diagnostics"].GetStringValue()), d); err != nil {
+		return nil, fmt.Errorf("failed to decode diagnostics: %w", err)
+	}
+	return d, nil
+}
+
+// GetFeatureFlags asks the plugin served on cc for its [FeatureFlag]s.
+func GetFeatureFlags(ctx context.Context, cc grpc.ClientConnInterface) ([]FeatureFlag, error) {
+	out := &structpb.Struct{}
+	if err := cc.Invoke(ctx, AdminFeatureFlagsMethod, &structpb.Struct{}, out); err != nil {
+		return nil, fmt.Errorf("failed to get feature flags: %w", err)
+	}
+	return featureFlagsFromStruct(out), nil
+}
+
+// SetFeatureFlag asks the plugin served on cc to override the feature flag,
+// see [JiraPlugin.SetFeatureFlag], and returns the resulting flags.
+func SetFeatureFlag(ctx context.Context, cc grpc.ClientConnInterface, name string, enabled bool) ([]FeatureFlag, error) {
+	in := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"name":    structpb.NewStringValue(name),
+		"enabled": structpb.NewBoolValue(enabled),
+	}}
+	out := &structpb.Struct{}
+	if err := cc.Invoke(ctx, AdminSetFeatureFlagMethod, in, out); err != nil {
+		return nil, fmt.Errorf("failed to set feature flag: %w", err)
+	}
+	return featureFlagsFromStruct(out), nil
+}
+
+// ResetFeatureFlag asks the plugin served on cc to remove the override of
+// the feature flag, see [JiraPlugin.ResetFeatureFlag], and returns the
+// resulting flags.
+func ResetFeatureFlag(ctx context.Context, cc grpc.ClientConnInterface, name string) ([]FeatureFlag, error) {
+	in := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"name":  structpb.NewStringValue(name),
+		"reset": structpb.NewBoolValue(true),
+	}}
+	out := &structpb.Struct{}
+	if err := cc.Invoke(ctx, AdminSetFeatureFlagMethod, in, out); err != nil {
+		return nil, fmt.Errorf("failed to reset feature flag: %w", err)
+	}
+	return featureFlagsFromStruct(out), nil
+}
+
+// cacheRequest returns the request of the cache methods for the issue.
+func cacheRequest(issueKey, subject string) *structpb.Struct {
+	return &structpb.Struct{Fields: map[string]*structpb.Value{
+		"issue_key": structpb.NewStringValue(issueKey),
+		"subject":   structpb.NewStringValue(subject),
+	}}
+}
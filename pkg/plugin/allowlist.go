@@ -0,0 +1,106 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+// ErrHostNotAllowed is wrapped by the error of a request to a host that is
+// not allowed, e.g. a redirect to another site, see [WithAllowedHosts]. The
+// request is not sent.
+var ErrHostNotAllowed = fmt.Errorf("jira host not allowed")
+
+// WithAllowedHosts restricts the hosts the validator sends requests to, so
+// that neither a redirect nor a tampered endpoint makes it send the API
+// token elsewhere. A host without a port is allowed on any port, and a
+// host with a port on that port only. Without this option only the hosts of
+// the endpoint and of the regional endpoints are allowed.
+func WithAllowedHosts(hosts []string) ValidatorOption {
+	return func(v *Validator) error {
+		allowed := make([]string, 0, len(hosts))
+		for _, host := range hosts {
+			h, err := parseAllowedHost(host)
+			if err != nil {
+				return err
+			}
+			allowed = append(allowed, h)
+		}
+		v.allowedHosts = allowed
+		return nil
+	}
+}
+
+// parseAllowedHost checks that host is a host name or IP address with an
+// optional port, and returns it in lower case.
+func parseAllowedHost(host string) (string, error) {
+	u, err := url.Parse("//" + host)
+	if err != nil || host == "" || u.Host != host || u.Hostname() == "" {
+		return "", fmt.Errorf("invalid allowed host %q, must be a host with an optional port", host)
+	}
+	return strings.ToLower(host), nil
+}
+
+// defaultAllowedHosts returns the hosts of the endpoint and of the regional
+// endpoints.
+func (v *Validator) defaultAllowedHosts() []string {
+	hosts := []string{strings.ToLower(v.baseURL.Host)}
+	if v.regions != nil {
+		for _, r := range v.regions.regions {
+			hosts = append(hosts, strings.ToLower(r.base.Host))
+		}
+	}
+	return hosts
+}
+
+// hostAllowed reports whether u is on one of the allowed hosts.
+func hostAllowed(allowed []string, u *url.URL) bool {
+	host := strings.ToLower(u.Host)
+	hostname := strings.ToLower(u.Hostname())
+	for _, a := range allowed {
+		if _, _, err := net.SplitHostPort(a); err == nil {
+			if a == host {
+				return true
+			}
+			continue
+		}
+		if strings.Trim(a, "[]") == hostname {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictHosts fails the requests to hosts that are not allowed without
+// sending them, and logs them.
+func (v *Validator) restrictHosts(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if !hostAllowed(v.allowedHosts, req.URL) {
+			ctx := req.Context()
+			logging.FromContext(ctx).WarnContext(ctx, "blocked jira request to a host that is not allowed",
+				"method", req.Method,
+				"host", req.URL.Host,
+				"path", req.URL.Path)
+			return nil, fmt.Errorf("request to %s blocked: %w", req.URL.Host, ErrHostNotAllowed)
+		}
+		return next.RoundTrip(req) //nolint:wrapcheck // Want passthrough
+	})
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestHostAllowed(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		allowed []string
+		url     string
+		want    bool
+	}{
+		{
+			name:    "same_host",
+			allowed: []string{"jira.example.com"},
+			url:     "https://jira.example.com/rest/api/2/issue/ABCD-1",
+			want:    true,
+		},
+		{
+			name:    "case_insensitive",
+			allowed: []string{"jira.example.com"},
+			url:     "https://JIRA.example.com/rest/api/2",
+			want:    true,
+		},
+		{
+			name:    "any_port",
+			allowed: []string{"jira.example.com"},
+			url:     "https://jira.example.com:8443/rest/api/2",
+			want:    true,
+		},
+		{
+			name:    "same_port",
+			allowed: []string{"jira.example.com:8443"},
+			url:     "https://jira.example.com:8443/rest/api/2",
+			want:    true,
+		},
+		{
+			name:    "other_port",
+			allowed: []string{"jira.example.com:8443"},
+			url:     "https://jira.example.com/rest/api/2",
+			want:    false,
+		},
+		{
+			name:    "other_host",
+			allowed: []string{"jira.example.com"},
+			url:     "https://attacker.example.com/rest/api/2",
+			want:    false,
+		},
+		{
+			name:    "suffix_not_allowed",
+			allowed: []string{"example.com"},
+			url:     "https://jira.example.com/rest/api/2",
+			want:    false,
+		},
+		{
+			name:    "ipv6",
+			allowed: []string{"[::1]"},
+			url:     "http://[::1]:8080/rest/api/2",
+			want:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hostAllowed(tc.allowed, u); got != tc.want {
+				t.Errorf("hostAllowed(%q, %s) got %t, want %t", tc.allowed, tc.url, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithAllowedHosts(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		hosts   []string
+		wantErr string
+	}{
+		{
+			name:  "valid",
+			hosts: []string{"jira.example.com", "jira-eu.example.com:8443", "[::1]:8080"},
+		},
+		{
+			name:    "url",
+			hosts:   []string{"https://jira.example.com"},
+			wantErr: `invalid allowed host "https://jira.example.com"`,
+		},
+		{
+			name:    "path",
+			hosts:   []string{"jira.example.com/rest"},
+			wantErr: `invalid allowed host "jira.example.com/rest"`,
+		},
+		{
+			name:    "port_only",
+			hosts:   []string{":8443"},
+			wantErr: `invalid allowed host ":8443"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewValidator("https://jira.example.com/rest/api/2", "project = ABCD", "test@test.com", "secrets",
+				WithAllowedHosts(tc.hosts))
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestRestrictHosts(t *testing.T) {
+	t.Parallel()
+
+	var elsewhereCalls atomic.Int32
+	elsewhere := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		elsewhereCalls.Add(1)
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	}))
+	t.Cleanup(elsewhere.Close)
+
+	var jiraCalls atomic.Int32
+	jira := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jiraCalls.Add(1)
+		http.Redirect(w, r, elsewhere.URL+r.URL.Path, http.StatusFound)
+	}))
+	t.Cleanup(jira.Close)
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	// The redirect to another port is not followed and not retried.
+	v, err := NewValidator(jira.URL+"/rest/api/2", "project = ABCD", "test@test.com", "secrets", WithRetries(2))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+	_, err = v.MatchIssue(ctx, "ABCD-1")
+	if !errors.Is(err, ErrHostNotAllowed) {
+		t.Errorf("got error %v, want %v", err, ErrHostNotAllowed)
+	}
+	if errors.Is(err, ErrJiraUnreachable) {
+		t.Errorf("got error %v, want no %v", err, ErrJiraUnreachable)
+	}
+	if got, want := jiraCalls.Load(), int32(1); got != want {
+		t.Errorf("got %d requests to jira, want %d", got, want)
+	}
+	if got := elsewhereCalls.Load(); got != 0 {
+		t.Errorf("got %d requests to the redirect target, want none", got)
+	}
+
+	// A tampered endpoint is not in the explicit allowlist.
+	jiraHost := strings.TrimPrefix(jira.URL, "http://")
+	v, err = NewValidator(elsewhere.URL+"/rest/api/2", "project = ABCD", "test@test.com", "secrets",
+		WithAllowedHosts([]string{jiraHost}))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+	if _, err := v.jiraIssue(ctx, "ABCD-1"); !errors.Is(err, ErrHostNotAllowed) {
+		t.Errorf("got error %v, want %v", err, ErrHostNotAllowed)
+	}
+	if got := elsewhereCalls.Load(); got != 0 {
+		t.Errorf("got %d requests to the tampered endpoint, want none", got)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointStats reports the requests made to a Jira endpoint, e.g. "issue"
+// or "jql/match", so that operators can tell which feature drives the load
+// on Jira.
+type EndpointStats struct {
+	// Requests is the number of requests made, including retries with the
+	// secondary API token.
+	Requests uint64 `json:"requests"`
+
+	// Failures is the number of requests that failed or got a 4xx or 5xx
+	// response.
+	Failures uint64 `json:"failures"`
+
+	// TotalLatency is the sum of the latencies of the requests, until the
+	// response header was received.
+	TotalLatency time.Duration `json:"total_latency"`
+
+	// MaxLatency is the highest latency of a request.
+	MaxLatency time.Duration `json:"max_latency"`
+}
+
+// add adds the stats of o.
+func (s *EndpointStats) add(o EndpointStats) {
+	s.Requests += o.Requests
+	s.Failures += o.Failures
+	s.TotalLatency += o.TotalLatency
+	s.MaxLatency = max(s.MaxLatency, o.MaxLatency)
+}
+
+// endpointCounters counts the requests by endpoint.
+type endpointCounters struct {
+	mu    sync.Mutex
+	stats map[string]*EndpointStats
+}
+
+// observe counts a request to the endpoint.
+func (c *endpointCounters) observe(endpoint string, latency time.Duration, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stats == nil {
+		c.stats = make(map[string]*EndpointStats)
+	}
+	s, ok := c.stats[endpoint]
+	if !ok {
+		s = &EndpointStats{}
+		c.stats[endpoint] = s
+	}
+	var failures uint64
+	if failed {
+		failures = 1
+	}
+	s.add(EndpointStats{Requests: 1, Failures: failures, TotalLatency: latency, MaxLatency: latency})
+}
+
+// load returns a copy of the stats by endpoint.
+func (c *endpointCounters) load() map[string]EndpointStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make(map[string]EndpointStats, len(c.stats))
+	for endpoint, s := range c.stats {
+		out[endpoint] = *s
+	}
+	return out
+}
+
+// endpointName returns the name of the endpoint of the request URL: its path
+// under the API base with the issue key left out, e.g. "issue" or
+// "jql/match".
+func (v *Validator) endpointName(u *url.URL) string {
+	rel := strings.Trim(strings.TrimPrefix(u.Path, v.baseURL.Path), "/")
+	elems := strings.Split(rel, "/")
+	if len(elems) > 1 && elems[0] == "issue" {
+		elems = append(elems[:1], elems[2:]...)
+	}
+	return path.Join(elems...)
+}
+
+// APIStats returns the requests made to Jira by endpoint since the validator
+// was created.
+func (v *Validator) APIStats() map[string]EndpointStats {
+	return v.apiStats.load()
+}
+
+// APIStats returns the requests made to Jira by endpoint since the last
+// reload, summed over the Jira validators of the plugin.
+func (j *JiraPlugin) APIStats() map[string]EndpointStats {
+	out := make(map[string]EndpointStats)
+	for _, lv := range j.current.Load().validators() {
+		v := lv.peek()
+		if v == nil {
+			continue
+		}
+		for endpoint, stats := range v.APIStats() {
+			sum := out[endpoint]
+			sum.add(stats)
+			out[endpoint] = sum
+		}
+	}
+	return out
+}
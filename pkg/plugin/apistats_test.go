@@ -0,0 +1,102 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestValidator_EndpointName(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator("https://example.com/jira/rest/api/2", "project = JRA", "test@test.com", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{path: "/jira/rest/api/2/issue/ABCD-1", want: "issue"},
+		{path: "/jira/rest/api/2/issue/ABCD-1/comment", want: "issue/comment"},
+		{path: "/jira/rest/api/2/jql/match", want: "jql/match"},
+		{path: "/jira/rest/api/2/search/jql", want: "search/jql"},
+		{path: "/jira/rest/api/2/myself", want: "myself"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.want, func(t *testing.T) {
+			t.Parallel()
+
+			if got := v.endpointName(&url.URL{Path: tc.path}); got != tc.want {
+				t.Errorf("endpointName(%q) got %q, want %q", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidator_APIStats(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/issue/ABCD-2", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL, "project = ABCD", "test@test.com", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err := v.MatchIssue(ctx, "ABCD-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.MatchIssue(ctx, "ABCD-2"); err == nil {
+		t.Fatal("got no error for a missing issue")
+	}
+
+	stats := v.APIStats()
+	if got, want := len(stats), 2; got != want {
+		t.Fatalf("got stats for %d endpoints, want %d: %v", got, want, stats)
+	}
+	if got := stats["issue"]; got.Requests != 2 || got.Failures != 1 {
+		t.Errorf("got issue stats %+v, want 2 requests and 1 failure", got)
+	}
+	if got := stats["jql/match"]; got.Requests != 1 || got.Failures != 0 {
+		t.Errorf("got jql/match stats %+v, want 1 request and no failure", got)
+	}
+	for endpoint, s := range stats {
+		if s.MaxLatency < 0 || s.TotalLatency < s.MaxLatency {
+			t.Errorf("got inconsistent latencies for %s: %+v", endpoint, s)
+		}
+	}
+}
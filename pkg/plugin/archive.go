@@ -0,0 +1,115 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// archivedDateField is the Jira Data Center field holding the time the
+	// issue was archived, empty while it is not archived. Archived issues are
+	// read-only, but may still match a JQL on some versions.
+	archivedDateField = "archiveddate"
+
+	// jiraIssueArchived is the key for the archival time of an accepted
+	// archived issue in the annotation map of the justification.
+	jiraIssueArchived = "jira_issue_archived"
+)
+
+const (
+	// ArchivedIssuesAllow accepts archived issues and annotates their
+	// archival time, see [WithArchivedIssues].
+	ArchivedIssuesAllow = "allow"
+
+	// ArchivedIssuesReject rejects archived issues, see [WithArchivedIssues].
+	ArchivedIssuesReject = "reject"
+)
+
+// WithArchivedIssues makes the validator detect archived issues from the
+// archival time Jira Data Center returns with the issue. With
+// [ArchivedIssuesReject] they are rejected, with [ArchivedIssuesAllow] they
+// are accepted and [MatchResult.ArchivedAt] is set. It only applies to
+// [Validator.MatchIssue], and cannot be used in search mode, which does not
+// fetch the issue.
+func WithArchivedIssues(policy string) ValidatorOption {
+	return func(v *Validator) error {
+		if v.searchJQLPrefix != "" {
+			return fmt.Errorf("archived issue policy cannot be used in search mode")
+		}
+		switch policy {
+		case ArchivedIssuesAllow:
+			v.annotateArchived = true
+		case ArchivedIssuesReject:
+			v.issueChecks = append(v.issueChecks, &archivedCheck{clock: v.clock})
+		default:
+			return fmt.Errorf("invalid archived issue policy %q, must be one of %s, %s", policy, ArchivedIssuesAllow, ArchivedIssuesReject)
+		}
+		v.issueFieldsQuery = v.fieldsQuery()
+		return nil
+	}
+}
+
+// archivedCheck rejects archived issues.
+type archivedCheck struct {
+	clock *jiraClock
+}
+
+func (c *archivedCheck) field() string {
+	return archivedDateField
+}
+
+func (c *archivedCheck) reason() string {
+	return ErrorCodeIssueArchived
+}
+
+func (c *archivedCheck) check(fields map[string]json.RawMessage) error {
+	archivedAt, ok := archivalTime(c.clock, fields)
+	if !ok {
+		return nil
+	}
+	return fmt.Errorf("issue was archived at %s", archivedAt)
+}
+
+// archivedAt returns the archival time of the issue for
+// [MatchResult.ArchivedAt], empty when the issue is not archived or archived
+// issues are not annotated.
+func (v *Validator) archivedAt(fields map[string]json.RawMessage) string {
+	if !v.annotateArchived {
+		return ""
+	}
+	archivedAt, _ := archivalTime(v.clock, fields)
+	return archivedAt
+}
+
+// archivalTime returns the archival time of the issue in RFC 3339 format in
+// UTC, and whether it is archived. The time is returned as Jira formatted it
+// when it cannot be parsed, it still marks the issue archived.
+func archivalTime(clock *jiraClock, fields map[string]json.RawMessage) (string, bool) {
+	value, ok := renderField(fields[archivedDateField])
+	if !ok {
+		return "", false
+	}
+	if clock == nil {
+		clock = defaultJiraClock
+	}
+	t, err := clock.parse(value)
+	if err != nil {
+		return value, true
+	}
+	return t.UTC().Format(time.RFC3339), true
+}
@@ -0,0 +1,180 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestArchivedCheck(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		fields  map[string]json.RawMessage
+		wantErr string
+	}{
+		{
+			name:   "not_archived",
+			fields: map[string]json.RawMessage{"archiveddate": json.RawMessage(`null`)},
+		},
+		{
+			name:   "field_missing",
+			fields: map[string]json.RawMessage{},
+		},
+		{
+			name:    "archived",
+			fields:  map[string]json.RawMessage{"archiveddate": json.RawMessage(`"2026-01-05T10:00:00.000+0200"`)},
+			wantErr: "issue was archived at 2026-01-05T08:00:00Z",
+		},
+		{
+			name:    "archived_unparsable_time",
+			fields:  map[string]json.RawMessage{"archiveddate": json.RawMessage(`"last week"`)},
+			wantErr: "issue was archived at last week",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &archivedCheck{}
+			if diff := testutil.DiffErrString(c.check(tc.fields), tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestWithArchivedIssues(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		opts       []ValidatorOption
+		wantFields string
+		wantErr    string
+	}{
+		{
+			name:       "allow",
+			opts:       []ValidatorOption{WithArchivedIssues(ArchivedIssuesAllow)},
+			wantFields: "fields=key%2Cid%2Carchiveddate",
+		},
+		{
+			name:       "reject",
+			opts:       []ValidatorOption{WithArchivedIssues(ArchivedIssuesReject)},
+			wantFields: "fields=key%2Cid%2Carchiveddate",
+		},
+		{
+			name:    "invalid",
+			opts:    []ValidatorOption{WithArchivedIssues("hide")},
+			wantErr: `invalid archived issue policy "hide", must be one of allow, reject`,
+		},
+		{
+			name:    "search_mode",
+			opts:    []ValidatorOption{WithSearchMode(), WithArchivedIssues(ArchivedIssuesReject)},
+			wantErr: "archived issue policy cannot be used in search mode",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = ABCD", "test@test.com", "token", tc.opts...)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			if got := v.issueFieldsQuery; got != tc.wantFields {
+				t.Errorf("got query %q, want %q", got, tc.wantFields)
+			}
+		})
+	}
+}
+
+func TestMatchIssue_Archived(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/3/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1","fields":{"archiveddate":"2026-01-05T10:00:00.000+0000"}}`)
+	})
+	mux.HandleFunc("/rest/api/3/issue/ABCD-2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1235","key":"ABCD-2","fields":{"archiveddate":null}}`)
+	})
+	mux.HandleFunc("/rest/api/3/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			IssueIDs []string `json:"issueIds"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"matches":[{"matchedIssues":[%s],"errors":[]}]}`, req.IssueIDs[0])
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	allow, err := NewValidator(srv.URL+"/rest/api/3", "project = ABCD", "test@test.com", "token", WithArchivedIssues(ArchivedIssuesAllow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"ABCD-1": "2026-01-05T10:00:00Z", "ABCD-2": ""} {
+		result, err := allow.MatchIssue(ctx, key)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", key, err)
+		}
+		if got := result.ArchivedAt; got != want {
+			t.Errorf("%s: got ArchivedAt %q, want %q", key, got, want)
+		}
+	}
+
+	reject, err := NewValidator(srv.URL+"/rest/api/3", "project = ABCD", "test@test.com", "token", WithArchivedIssues(ArchivedIssuesReject))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = reject.MatchIssue(ctx, "ABCD-1")
+	if !errors.Is(err, ErrInvalidJustification) {
+		t.Fatalf("got error %v, want ErrInvalidJustification", err)
+	}
+	if got, want := Reason(err), ErrorCodeIssueArchived; got != want {
+		t.Errorf("got reason %q, want %q", got, want)
+	}
+	result, err := reject.MatchIssue(ctx, "ABCD-2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ArchivedAt != "" {
+		t.Errorf("got ArchivedAt %q, want none", result.ArchivedAt)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"time"
+)
+
+// Decision is the outcome of a single justification validation.
+type Decision struct {
+	// Time is when the decision was made.
+	Time time.Time
+
+	// Category is the justification category of the request.
+	Category string
+
+	// Value is the justification value of the request, e.g. the issue key.
+	Value string
+
+	// Valid reports whether the justification was accepted.
+	Valid bool
+
+	// Requestor is the identity of the requestor, it is empty when unknown.
+	// See [Requestor].
+	Requestor string
+
+	// Bypassed reports whether the justification was accepted without asking
+	// Jira because the requestor is on the bypass list.
+	Bypassed bool
+
+	// BypassToken reports whether the justification was accepted without
+	// asking Jira because it holds the emergency bypass token. Bypassed is
+	// set too.
+	BypassToken bool
+
+	// Errors holds the rejection reasons, or the internal error when the
+	// validation could not be completed.
+	Errors []string
+
+	// Annotation is the annotation returned to JVS for a valid justification.
+	Annotation map[string]string
+
+	// InsecureTransport reports whether the TLS certificate of Jira was not
+	// verified, see [PluginConfig.InsecureSkipVerify].
+	InsecureTransport bool
+}
+
+// AuditSink receives every validation decision, e.g. to forward them to a
+// SIEM.
+type AuditSink interface {
+	Emit(ctx context.Context, d *Decision) error
+	Close() error
+}
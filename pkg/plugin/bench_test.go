@@ -0,0 +1,166 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// See docs/performance.md for the latency budget these benchmarks are
+// evaluated against.
+
+var benchRequest = &jvspb.ValidateJustificationRequest{
+	Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+}
+
+func benchContext() context.Context {
+	return logging.WithLogger(context.Background(),
+		logging.New(io.Discard, slog.LevelError, logging.FormatJSON, false))
+}
+
+// BenchmarkValidate_Cold creates a new plugin for every validation, so every
+// iteration pays for client construction and new connections.
+func BenchmarkValidate_Cold(b *testing.B) {
+	f := newFakeJira(b)
+	ctx := benchContext()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p, err := NewJiraPluginWithToken(context.Background(), f.config(), "secrets")
+		if err != nil {
+			b.Fatal(err)
+		}
+		resp, err := p.Validate(ctx, benchRequest)
+		if err != nil || !resp.GetValid() {
+			b.Fatalf("unexpected result %v: %v", resp, err)
+		}
+		p.current.Load().jira.v.httpClient.CloseIdleConnections()
+	}
+	b.StopTimer()
+
+	f.assertCalls(b, b.N)
+}
+
+// BenchmarkValidate_KeepAlive reuses one plugin, so connections are kept
+// alive across validations. Every validation still makes both Jira requests.
+func BenchmarkValidate_KeepAlive(b *testing.B) {
+	f := newFakeJira(b)
+	ctx := benchContext()
+
+	p, err := NewJiraPluginWithToken(context.Background(), f.config(), "secrets")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := p.Validate(ctx, benchRequest)
+		if err != nil || !resp.GetValid() {
+			b.Fatalf("unexpected result %v: %v", resp, err)
+		}
+	}
+	b.StopTimer()
+
+	f.assertCalls(b, b.N)
+}
+
+// BenchmarkValidate_Cached reuses one plugin with a decision cache, so only
+// the first validation asks Jira.
+func BenchmarkValidate_Cached(b *testing.B) {
+	f := newFakeJira(b)
+	ctx := benchContext()
+
+	cfg := f.config()
+	cfg.CachePath = filepath.Join(b.TempDir(), "decisions.db")
+	p, err := NewJiraPluginWithToken(context.Background(), cfg, "secrets")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { p.Close(context.Background()) })
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := p.Validate(ctx, benchRequest)
+		if err != nil || !resp.GetValid() {
+			b.Fatalf("unexpected result %v: %v", resp, err)
+		}
+	}
+	b.StopTimer()
+
+	f.assertCalls(b, 1)
+}
+
+// BenchmarkValidate_Invalid validates a value that is not an issue key, which
+// must be rejected without asking Jira.
+func BenchmarkValidate_Invalid(b *testing.B) {
+	f := newFakeJira(b)
+	ctx := benchContext()
+
+	p, err := NewJiraPluginWithToken(context.Background(), f.config(), "secrets")
+	if err != nil {
+		b.Fatal(err)
+	}
+	req := &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "not a key"},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := p.Validate(ctx, req)
+		if err != nil || resp.GetValid() {
+			b.Fatalf("unexpected result %v: %v", resp, err)
+		}
+	}
+	b.StopTimer()
+
+	f.assertCalls(b, 0)
+}
+
+// BenchmarkValidate_Parallel validates concurrently through one plugin.
+func BenchmarkValidate_Parallel(b *testing.B) {
+	f := newFakeJira(b)
+	ctx := benchContext()
+
+	p, err := NewJiraPluginWithToken(context.Background(), f.config(), "secrets")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			resp, err := p.Validate(ctx, benchRequest)
+			if err != nil || !resp.GetValid() {
+				b.Errorf("unexpected result %v: %v", resp, err)
+				return
+			}
+		}
+	})
+	b.StopTimer()
+
+	f.assertCalls(b, b.N)
+}
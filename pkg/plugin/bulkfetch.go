@@ -0,0 +1,162 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// bulkFetchMaxIssues is the most issues a single [Bulk Fetch Issues API]
+	// request may ask for.
+	//
+	// [Bulk Fetch Issues API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-bulkfetch-post
+	bulkFetchMaxIssues = 100
+
+	// matchMaxIssueIDs is the most issue ids a single [match request] may
+	// ask for.
+	//
+	// [match request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-search/#api-rest-api-3-jql-match-post
+	matchMaxIssueIDs = 1000
+)
+
+// bulkFetchData is the request body of the [Bulk Fetch Issues API].
+//
+// [Bulk Fetch Issues API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-bulkfetch-post
+type bulkFetchData struct {
+	IssueIDsOrKeys []string `json:"issueIdsOrKeys"`
+	Fields         []string `json:"fields"`
+}
+
+// bulkFetchResult is the response of the [Bulk Fetch Issues API]. Issues
+// that do not exist or are not visible are left out of Issues.
+//
+// [Bulk Fetch Issues API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-bulkfetch-post
+type bulkFetchResult struct {
+	Issues []*jiraIssue `json:"issues"`
+}
+
+// fetchIssues returns the jira issues of the keys, in the same order, with
+// one bulk fetch request per [bulkFetchMaxIssues] keys. Keys missing from
+// the bulk result, e.g. the old key of a moved issue, are fetched one by one
+// so that they resolve or fail like with [Validator.Issue]. When jira does
+// not support bulk fetches, as Jira Data Center, all issues are fetched one
+// by one from then on.
+func (v *Validator) fetchIssues(ctx context.Context, issueKeys []string) ([]*jiraIssue, error) {
+	issues := make([]*jiraIssue, len(issueKeys))
+	if !v.bulkFetchUnsupported.Load() {
+		for start := 0; start < len(issueKeys); start += bulkFetchMaxIssues {
+			end := min(start+bulkFetchMaxIssues, len(issueKeys))
+			fetched, err := v.bulkFetch(ctx, issueKeys[start:end])
+			if code, ok := JiraStatus(err); ok && (code == http.StatusNotFound || code == http.StatusMethodNotAllowed) {
+				v.bulkFetchUnsupported.Store(true)
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get jira issues %q: %w", issueKeys[start:end], err)
+			}
+			copy(issues[start:end], fetched)
+		}
+	}
+
+	for i, key := range issueKeys {
+		if issues[i] != nil {
+			continue
+		}
+		issue, err := v.jiraIssue(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get jira issue %q: %w", key, err)
+		}
+		issues[i] = issue
+	}
+	return issues, nil
+}
+
+// bulkFetch fetches the issues of at most [bulkFetchMaxIssues] keys with a
+// single request. The result has an issue per key in the same order, nil
+// where jira did not return the issue.
+func (v *Validator) bulkFetch(ctx context.Context, issueKeys []string) ([]*jiraIssue, error) {
+	u := v.endpointURL("issue", "bulkfetch")
+
+	body, err := json.Marshal(&bulkFetchData{
+		IssueIDsOrKeys: issueKeys,
+		Fields:         v.requestedFields(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	var result bulkFetchResult
+	if err := v.makeRequest(req, &result); err != nil {
+		return nil, err
+	}
+
+	// Issue keys are case insensitive, and a key may have been given as an
+	// issue id.
+	byKey := make(map[string]*jiraIssue, 2*len(result.Issues))
+	for _, issue := range result.Issues {
+		byKey[strings.ToUpper(issue.Key)] = issue
+		byKey[issue.ID] = issue
+	}
+	issues := make([]*jiraIssue, len(issueKeys))
+	for i, key := range issueKeys {
+		issues[i] = byKey[strings.ToUpper(key)]
+	}
+	return issues, nil
+}
+
+// mergeMatchResults appends the matches of r to the matches of the same JQL
+// in result, for a match split into several requests. A nil result is
+// replaced by r.
+func mergeMatchResults(result, r *MatchResult) *MatchResult {
+	if result == nil {
+		return r
+	}
+	for i, m := range r.Matches {
+		if i >= len(result.Matches) {
+			result.Matches = append(result.Matches, m)
+			continue
+		}
+		result.Matches[i].MatchedIssues = append(result.Matches[i].MatchedIssues, m.MatchedIssues...)
+		result.Matches[i].Errors = append(result.Matches[i].Errors, m.Errors...)
+	}
+	return result
+}
+
+// chunkStrings splits s into slices of at most n elements. An empty s is a
+// single empty chunk, so that callers still make one request.
+func chunkStrings(s []string, n int) [][]string {
+	if len(s) == 0 {
+		return [][]string{s}
+	}
+	chunks := make([][]string, 0, (len(s)+n-1)/n)
+	for start := 0; start < len(s); start += n {
+		chunks = append(chunks, s[start:min(start+n, len(s))])
+	}
+	return chunks
+}
@@ -0,0 +1,209 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+// bulkJira is a fake jira serving issues ABCD-<n> with id n, the bulk fetch
+// and the match APIs, and counting the requests.
+type bulkJira struct {
+	bulkCalls, issueCalls, matchCalls atomic.Int32
+}
+
+func newBulkJira(tb testing.TB, bulkSupported bool) (*bulkJira, *httptest.Server) {
+	tb.Helper()
+
+	f := &bulkJira{}
+	idOf := func(key string) (string, bool) {
+		n, ok := strings.CutPrefix(strings.ToUpper(key), "ABCD-")
+		return n, ok && n != "0"
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/bulkfetch", func(w http.ResponseWriter, r *http.Request) {
+		f.bulkCalls.Add(1)
+		if !bulkSupported {
+			http.NotFound(w, r)
+			return
+		}
+		var data bulkFetchData
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil || len(data.IssueIDsOrKeys) > bulkFetchMaxIssues {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var result bulkFetchResult
+		for _, key := range data.IssueIDsOrKeys {
+			// OLD-1 was moved to ABCD-1, bulk fetches do not follow moves.
+			if id, ok := idOf(key); ok {
+				result.Issues = append(result.Issues, &jiraIssue{Key: "ABCD-" + id, ID: id})
+			}
+		}
+		json.NewEncoder(w).Encode(&result) //nolint:errcheck // Test server
+	})
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		f.issueCalls.Add(1)
+		key := strings.TrimPrefix(r.URL.Path, "/issue/")
+		if key == "OLD-1" {
+			key = "ABCD-1"
+		}
+		id, ok := idOf(key)
+		if !ok {
+			http.Error(w, `{"errorMessages":["Issue does not exist or you do not have permission to see it."]}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"id":%q,"key":%q}`, id, key)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		f.matchCalls.Add(1)
+		var data matchData
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil || len(data.IssueIDs) > matchMaxIssueIDs {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		m := &Match{MatchedIssues: []int{}, Errors: []string{}}
+		for _, id := range data.IssueIDs {
+			n, _ := strconv.Atoi(id)
+			m.MatchedIssues = append(m.MatchedIssues, n)
+		}
+		json.NewEncoder(w).Encode(&MatchResult{Matches: []*Match{m}}) //nolint:errcheck // Test server
+	})
+	srv := httptest.NewServer(mux)
+	tb.Cleanup(srv.Close)
+	return f, srv
+}
+
+func issueKeys(n int) []string {
+	keys := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		keys = append(keys, fmt.Sprintf("ABCD-%d", i))
+	}
+	return keys
+}
+
+func TestValidator_MatchIssues_Bulk(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name           string
+		bulkSupported  bool
+		keys           []string
+		wantMatched    int
+		wantBulkCalls  int32
+		wantIssueCalls int32
+		wantMatchCalls int32
+		wantErr        string
+	}{
+		{
+			name:           "chunked",
+			bulkSupported:  true,
+			keys:           issueKeys(1001),
+			wantMatched:    1001,
+			wantBulkCalls:  11,
+			wantMatchCalls: 2,
+		},
+		{
+			name:           "moved_issue",
+			bulkSupported:  true,
+			keys:           []string{"abcd-2", "OLD-1"},
+			wantMatched:    2,
+			wantBulkCalls:  1,
+			wantIssueCalls: 1,
+			wantMatchCalls: 1,
+		},
+		{
+			name:           "missing_issue",
+			bulkSupported:  true,
+			keys:           []string{"ABCD-1", "ABCD-0"},
+			wantBulkCalls:  1,
+			wantIssueCalls: 1,
+			wantErr:        `failed to get jira issue "ABCD-0"`,
+		},
+		{
+			name:           "unsupported",
+			keys:           issueKeys(3),
+			wantMatched:    3,
+			wantBulkCalls:  1,
+			wantIssueCalls: 3,
+			wantMatchCalls: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			f, srv := newBulkJira(t, tc.bulkSupported)
+			v, err := NewValidator(srv.URL, "project = ABCD", "test@test.com", "secrets")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := v.MatchIssues(ctx, tc.keys)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if err == nil {
+				if got := len(result.Matches[0].MatchedIssues); got != tc.wantMatched {
+					t.Errorf("got %d matched issues, want %d", got, tc.wantMatched)
+				}
+			}
+
+			got := []int32{f.bulkCalls.Load(), f.issueCalls.Load(), f.matchCalls.Load()}
+			want := []int32{tc.wantBulkCalls, tc.wantIssueCalls, tc.wantMatchCalls}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("bulk, issue and match calls (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidator_FetchIssues_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f, srv := newBulkJira(t, false)
+	v, err := NewValidator(srv.URL, "project = ABCD", "test@test.com", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Jira is only asked for a bulk fetch once.
+	for i := 0; i < 2; i++ {
+		if _, err := v.fetchIssues(ctx, issueKeys(2)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := f.bulkCalls.Load(); got != 1 {
+		t.Errorf("got %d bulk calls, want 1", got)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+const (
+	// jiraValidationBypassed is the key in the annotation map of a
+	// justification accepted without asking Jira.
+	jiraValidationBypassed = "jira_validation_bypassed"
+
+	// jiraBypassRequestor is the key for the requestor whose justification
+	// bypassed Jira in the annotation map.
+	jiraBypassRequestor = "jira_bypass_requestor"
+
+	// jiraBypassToken is the key in the annotation map of a justification
+	// accepted with the emergency bypass token.
+	jiraBypassToken = "jira_bypass_token"
+
+	// bypassTokenPrefix prefixes the emergency bypass token in justification
+	// values, e.g. "bypass:<token>".
+	bypassTokenPrefix = "bypass:"
+
+	// minBypassTokenLength is the minimum length of the emergency bypass
+	// token, so that it cannot be guessed.
+	minBypassTokenLength = 16
+)
+
+// bypassList holds the requestors, by identity or group, whose
+// justifications are accepted without asking Jira, e.g. break-glass service
+// accounts that cannot hold Jira issues.
+type bypassList struct {
+	entries map[string]struct{}
+}
+
+// newBypassList creates the bypass list, it returns nil when it is empty.
+// Entries are matched case insensitively.
+func newBypassList(entries []string) *bypassList {
+	if len(entries) == 0 {
+		return nil
+	}
+	b := &bypassList{entries: make(map[string]struct{}, len(entries))}
+	for _, e := range entries {
+		b.entries[strings.ToLower(e)] = struct{}{}
+	}
+	return b
+}
+
+// match returns the entry the requestor matches, by identity first, and
+// true, or false when the requestor is unknown or not on the list.
+func (b *bypassList) match(r *Requestor) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	for _, id := range append([]string{r.Subject}, r.Groups...) {
+		if _, ok := b.entries[strings.ToLower(id)]; ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// checkBypassToken returns the emergency bypass token, trimmed of the
+// whitespace a secret often ends with, or an error when it is too short.
+func checkBypassToken(token string) (string, error) {
+	token = strings.TrimSpace(token)
+	if len(token) < minBypassTokenLength {
+		return "", fmt.Errorf("bypass token must be at least %d characters long", minBypassTokenLength)
+	}
+	return token, nil
+}
+
+// bypassTokenValue reports whether the justification value is an emergency
+// bypass, i.e. "bypass:<token>".
+func bypassTokenValue(value string) bool {
+	return strings.HasPrefix(value, bypassTokenPrefix)
+}
+
+// matchBypassToken reports whether the bypass value holds the token, in
+// constant time.
+func matchBypassToken(value, token string) bool {
+	got := strings.TrimPrefix(value, bypassTokenPrefix)
+	return token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// redactBypassToken hides the token of a bypass value, so that it never ends
+// up in logs, audit events or the replay log.
+func redactBypassToken(value string) string {
+	if !bypassTokenValue(value) {
+		return value
+	}
+	return bypassTokenPrefix + redactionMarker
+}
+
+// bypassTokenResponse returns the response for a justification accepted with
+// the emergency bypass token. Like [bypassResponse] it is flagged in the
+// annotation and warnings, with a distinct annotation.
+func bypassTokenResponse(r *Requestor) *jvspb.ValidateJustificationResponse {
+	resp := &jvspb.ValidateJustificationResponse{
+		Valid:   true,
+		Warning: []string{"jira validation bypassed with the emergency bypass token"},
+		Annotation: map[string]string{
+			jiraValidationBypassed: "true",
+			jiraBypassToken:        "true",
+		},
+	}
+	if r != nil {
+		resp.Annotation[jiraBypassRequestor] = r.Subject
+	}
+	return resp
+}
+
+// bypassResponse returns the response for a justification accepted without
+// asking Jira. It is flagged in the annotation and warnings, so neither the
+// requestor nor JVS can mistake it for a validated justification.
+func bypassResponse(r *Requestor, entry string) *jvspb.ValidateJustificationResponse {
+	return &jvspb.ValidateJustificationResponse{
+		Valid:   true,
+		Warning: []string{fmt.Sprintf("jira validation bypassed for requestor %q via %q", r.Subject, entry)},
+		Annotation: map[string]string{
+			jiraValidationBypassed: "true",
+			jiraBypassRequestor:    r.Subject,
+		},
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/grpc/metadata"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestRequestorFromContext(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		ctx  context.Context //nolint:containedctx // Test input
+		want *Requestor
+	}{
+		{
+			name: "none",
+			ctx:  context.Background(),
+		},
+		{
+			name: "with_requestor",
+			ctx:  WithRequestor(context.Background(), &Requestor{Subject: "a@example.com"}),
+			want: &Requestor{Subject: "a@example.com"},
+		},
+		{
+			name: "metadata",
+			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+				RequestorMetadataKey, "a@example.com",
+				RequestorGroupsMetadataKey, "sre, oncall",
+				RequestorGroupsMetadataKey, "breakglass",
+			)),
+			want: &Requestor{Subject: "a@example.com", Groups: []string{"sre", "oncall", "breakglass"}},
+		},
+		{
+			name: "metadata_without_subject",
+			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+				RequestorGroupsMetadataKey, "breakglass",
+			)),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.want, requestorFromContext(tc.ctx)); diff != "" {
+				t.Errorf("requestorFromContext() unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBypassList_Match(t *testing.T) {
+	t.Parallel()
+
+	b := newBypassList([]string{"BreakGlass@example.com", "sre-oncall"})
+
+	cases := []struct {
+		name      string
+		requestor *Requestor
+		wantEntry string
+		wantOK    bool
+	}{
+		{
+			name: "unknown_requestor",
+		},
+		{
+			name:      "subject",
+			requestor: &Requestor{Subject: "breakglass@example.com"},
+			wantEntry: "breakglass@example.com",
+			wantOK:    true,
+		},
+		{
+			name:      "group",
+			requestor: &Requestor{Subject: "a@example.com", Groups: []string{"dev", "SRE-Oncall"}},
+			wantEntry: "SRE-Oncall",
+			wantOK:    true,
+		},
+		{
+			name:      "not_listed",
+			requestor: &Requestor{Subject: "a@example.com", Groups: []string{"dev"}},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			entry, ok := b.match(tc.requestor)
+			if entry != tc.wantEntry || ok != tc.wantOK {
+				t.Errorf("match() got (%q, %t), want (%q, %t)", entry, ok, tc.wantEntry, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestPlugin_Validate_Bypass(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &PluginConfig{
+		JIRAEndpoint:     srv.URL,
+		Jql:              "status NOT IN (Done)",
+		JIRAAccount:      "test@test.com",
+		IssueBaseURL:     "https://example.atlassian.net",
+		BypassRequestors: []string{"breakglass"},
+	}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	ctx = WithRequestor(ctx, &Requestor{Subject: "robot@example.com", Groups: []string{"breakglass"}})
+	got, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: jiraCategory, Value: "ABCD-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := &jvspb.ValidateJustificationResponse{
+		Valid:   true,
+		Warning: []string{`jira validation bypassed for requestor "robot@example.com" via "breakglass"`},
+		Annotation: map[string]string{
+			jiraValidationBypassed: "true",
+			jiraBypassRequestor:    "robot@example.com",
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(jvspb.ValidateJustificationResponse{})); diff != "" {
+		t.Errorf("Validate() unexpected diff (-want,+got):\n%s", diff)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("expected no jira requests, got %d", n)
+	}
+}
+
+func TestPlugin_Validate_BypassToken(t *testing.T) {
+	t.Parallel()
+
+	const token = "0123456789abcdef-emergency"
+
+	cases := []struct {
+		name      string
+		token     string
+		value     string
+		requestor *Requestor
+		want      *jvspb.ValidateJustificationResponse
+		wantAudit *Decision
+	}{
+		{
+			name:      "accepted",
+			token:     token,
+			value:     "bypass:" + token,
+			requestor: &Requestor{Subject: "oncall@example.com"},
+			want: &jvspb.ValidateJustificationResponse{
+				Valid:   true,
+				Warning: []string{"jira validation bypassed with the emergency bypass token"},
+				Annotation: map[string]string{
+					jiraValidationBypassed: "true",
+					jiraBypassToken:        "true",
+					jiraBypassRequestor:    "oncall@example.com",
+				},
+			},
+			wantAudit: &Decision{
+				Category:    jiraCategory,
+				Value:       "bypass:[redacted]",
+				Requestor:   "oncall@example.com",
+				Valid:       true,
+				Bypassed:    true,
+				BypassToken: true,
+			},
+		},
+		{
+			name:  "accepted_without_requestor",
+			token: token,
+			value: "bypass:" + token,
+			want: &jvspb.ValidateJustificationResponse{
+				Valid:   true,
+				Warning: []string{"jira validation bypassed with the emergency bypass token"},
+				Annotation: map[string]string{
+					jiraValidationBypassed: "true",
+					jiraBypassToken:        "true",
+				},
+			},
+			wantAudit: &Decision{
+				Category:    jiraCategory,
+				Value:       "bypass:[redacted]",
+				Valid:       true,
+				Bypassed:    true,
+				BypassToken: true,
+			},
+		},
+		{
+			name:  "wrong_token",
+			token: token,
+			value: "bypass:" + token[:len(token)-1],
+			want: &jvspb.ValidateJustificationResponse{
+				Valid: false,
+				Error: []string{"emergency bypass rejected"},
+			},
+			wantAudit: &Decision{
+				Category: jiraCategory,
+				Value:    "bypass:[redacted]",
+				Errors:   []string{"emergency bypass rejected"},
+			},
+		},
+		{
+			name:  "not_configured",
+			value: "bypass:",
+			want: &jvspb.ValidateJustificationResponse{
+				Valid: false,
+				Error: []string{"emergency bypass rejected"},
+			},
+			wantAudit: &Decision{
+				Category: jiraCategory,
+				Value:    "bypass:[redacted]",
+				Errors:   []string{"emergency bypass rejected"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			sink := &fakeAuditSink{}
+			validator := &countingMatcher{mockValidator: mockValidator{result: testMatch(1234)}}
+			p := newTestPlugin(&snapshot{
+				validator:    validator,
+				issueBaseURL: "https://example.atlassian.net",
+				bypassToken:  tc.token,
+			})
+			p.auditSink = sink
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			if tc.requestor != nil {
+				ctx = WithRequestor(ctx, tc.requestor)
+			}
+			got, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{Category: jiraCategory, Value: tc.value},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(jvspb.ValidateJustificationResponse{})); diff != "" {
+				t.Errorf("Validate() unexpected diff (-want,+got):\n%s", diff)
+			}
+			if validator.calls != 0 {
+				t.Errorf("expected no jira requests, got %d", validator.calls)
+			}
+			if len(sink.decisions) != 1 {
+				t.Fatalf("got %d audit events, want 1", len(sink.decisions))
+			}
+			if diff := cmp.Diff(tc.wantAudit, sink.decisions[0], cmpopts.IgnoreFields(Decision{}, "Time", "Annotation")); diff != "" {
+				t.Errorf("audit event unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckBypassToken(t *testing.T) {
+	t.Parallel()
+
+	if got, err := checkBypassToken(" 0123456789abcdef\n"); err != nil || got != "0123456789abcdef" {
+		t.Errorf("checkBypassToken() got (%q, %v), want the trimmed token", got, err)
+	}
+	if _, err := checkBypassToken("short"); err == nil {
+		t.Error("checkBypassToken() expected an error for a short token")
+	}
+}
@@ -0,0 +1,686 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+const (
+	// defaultCacheTTL is how long a decision is cached when no TTL is
+	// configured.
+	defaultCacheTTL = 5 * time.Minute
+
+	// cacheBucketPrefix prefixes the bucket names of the configurations.
+	cacheBucketPrefix = "matches/"
+
+	// cacheOpenTimeout bounds how long opening the cache waits for the file
+	// lock held by another process.
+	cacheOpenTimeout = time.Second
+
+	// cacheRevalidationWindow is how long an expired entry with an issue
+	// version is kept, so the issue can be requested conditionally instead of
+	// matched again.
+	cacheRevalidationWindow = 24 * time.Hour
+
+	// cacheRefreshTimeout bounds the background refresh of a stale entry.
+	cacheRefreshTimeout = 30 * time.Second
+)
+
+// DecisionCache is an on-disk cache of successful issue matches, so a plugin
+// that restarts frequently does not have to ask Jira again for issues it
+// validated recently.
+//
+// Only issues that matched the JQL are cached. A rejected issue may become
+// valid at any time, e.g. when it is reopened, and is always checked again.
+// Entries are scoped to the Jira endpoint, account, JQL and annotation fields
+// they were matched with, so changing the configuration never serves stale
+// entries.
+//
+// When a JQL depends on the requestor through the {{.Requestor}}
+// placeholder, entries are keyed by the issue and the requestor, so a
+// decision is never served to another requestor. Validations without a
+// requestor bypass the cache.
+//
+// An expired entry is revalidated with a conditional Get Issue request using
+// the ETag and Last-Modified of the cached response. When Jira reports the
+// issue unchanged the entry is refreshed without matching the JQL again.
+type DecisionCache struct {
+	store  cacheStore
+	bucket []byte
+	ttl    time.Duration
+	now    func() time.Time
+
+	// personalized is set when decisions depend on the requestor.
+	personalized bool
+
+	// cipher encrypts the entries, they are stored in clear when it is nil.
+	cipher *cacheCipher
+
+	// counters count the lookups of every configuration, they are shared
+	// with the views of [DecisionCache.forConfig].
+	counters *cacheCounters
+}
+
+// CacheOption customizes a [DecisionCache].
+type CacheOption func(*DecisionCache) error
+
+// WithCacheEncryptionKeys encrypts the cached entries with AES-GCM using keys
+// derived from the keys, which must be at least 32 bytes. The first key
+// encrypts, every key decrypts. An entry none of the keys decrypts is a
+// cache miss.
+func WithCacheEncryptionKeys(keys ...[]byte) CacheOption {
+	return func(c *DecisionCache) error {
+		cipher, err := newCacheCipher(keys)
+		if err != nil {
+			return err
+		}
+		c.cipher = cipher
+		return nil
+	}
+}
+
+// cacheEntry is the stored form of a cached match.
+type cacheEntry struct {
+	ExpiresAt time.Time    `json:"expires_at"`
+	Result    *MatchResult `json:"result"`
+}
+
+// cacheStore stores the encoded entries of a [DecisionCache] by bucket and
+// name.
+type cacheStore interface {
+	// get returns the entry, or nil when there is none.
+	get(bucket, name []byte) ([]byte, error)
+
+	// put stores the entry. The store may drop it after keep.
+	put(bucket, name, v []byte, keep time.Duration) error
+
+	delete(bucket, name []byte) error
+
+	// count returns the number of entries in the bucket, and in every
+	// bucket.
+	count(bucket []byte) (int, int, error)
+
+	// purge removes the entries of every bucket and returns how many it
+	// removed.
+	purge() (int, error)
+
+	close() error
+}
+
+// cacheLocker is a [cacheStore] shared by several plugins, which can lock an
+// entry so only one of them refreshes it.
+type cacheLocker interface {
+	// tryLock locks the entry for at most ttl. It returns false when the
+	// entry is already locked, and otherwise the function unlocking it.
+	tryLock(ctx context.Context, bucket, name []byte, ttl time.Duration) (func(), bool, error)
+}
+
+// OpenDecisionCache opens or creates the cache file at pth. Entries expire
+// after ttl, a zero ttl uses the default of 5 minutes. Expired entries are
+// removed when the cache is opened, unless they can still be revalidated.
+// Entries that cannot be decrypted are removed too.
+func OpenDecisionCache(pth string, ttl time.Duration, cfg *PluginConfig, opts ...CacheOption) (*DecisionCache, error) {
+	c, err := newDecisionCache(ttl, cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(pth, 0o600, &bolt.Options{Timeout: cacheOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache %s: %w", pth, err)
+	}
+
+	c.store = &boltCacheStore{db: db}
+	if err := c.prune(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// newDecisionCache creates a cache without its store.
+func newDecisionCache(ttl time.Duration, cfg *PluginConfig, opts ...CacheOption) (*DecisionCache, error) {
+	if ttl == 0 {
+		ttl = defaultCacheTTL
+	}
+	if ttl < 0 {
+		return nil, fmt.Errorf("cache ttl must be positive, got %s", ttl)
+	}
+
+	c := &DecisionCache{
+		bucket:       cacheBucket(cfg),
+		ttl:          ttl,
+		now:          time.Now,
+		personalized: cfg.personalized(),
+		counters:     new(cacheCounters),
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, fmt.Errorf("failed to apply cache option: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// cacheBucket returns the bucket name for the configuration. It covers every
+// setting that changes the cached result.
+func cacheBucket(cfg *PluginConfig) []byte {
+	h := sha256.New()
+	for _, s := range []string{
+		cfg.JIRAEndpoint,
+		cfg.JIRAAccount,
+		cfg.Jql,
+		strings.Join(cfg.AnnotationFields, ","),
+		strconv.Itoa(int(cfg.AnnotationFieldMaxBytes)),
+	} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	// The account may be a secret instead, like the candidate it is only
+	// hashed when set.
+	if cfg.AccountSecretID != "" {
+		h.Write([]byte(cfg.AccountSecretID))
+		h.Write([]byte{0})
+	}
+	// Cached matches hold the candidate match too. Only hashed when set, so
+	// adding the setting did not invalidate existing caches.
+	if cfg.CandidateJql != "" {
+		h.Write([]byte(cfg.CandidateJql))
+		h.Write([]byte{0})
+	}
+	if cfg.IssueTypeJql != "" {
+		h.Write([]byte(cfg.IssueTypeJql))
+		h.Write([]byte{0})
+	}
+	if cfg.FieldConstraints != "" {
+		h.Write([]byte(cfg.FieldConstraints))
+		h.Write([]byte{0})
+	}
+	if cfg.LinkRules != "" {
+		h.Write([]byte(cfg.LinkRules))
+		h.Write([]byte{0})
+	}
+	if cfg.FieldRedactions != "" {
+		h.Write([]byte(cfg.FieldRedactions))
+		h.Write([]byte{0})
+	}
+	if cfg.MaxIssueAge > 0 || cfg.MaxResolvedAge > 0 {
+		h.Write([]byte(cfg.MaxIssueAge.String() + "/" + cfg.MaxResolvedAge.String()))
+		h.Write([]byte{0})
+	}
+	if cfg.ArchivedIssues != "" {
+		h.Write([]byte("archived=" + cfg.ArchivedIssues))
+		h.Write([]byte{0})
+	}
+	if cfg.IssueSnapshotHash {
+		h.Write([]byte("snapshot_hash"))
+		h.Write([]byte{0})
+	}
+	if cfg.MinPriority != "" {
+		h.Write([]byte(cfg.MinPriority + "<" + strings.Join(cfg.PriorityOrder, ",")))
+		h.Write([]byte{0})
+	}
+	// The mapping changes the requestor of personalized JQL. Like the account
+	// secret, the file and secret are hashed by name.
+	if cfg.identityMapped() {
+		h.Write([]byte("identity=" + strings.Join(cfg.IdentityDomainRewrites, ",") + "|" + cfg.IdentityMappingFile + "|" + cfg.IdentityMappingSecretID))
+		h.Write([]byte{0})
+	}
+	return []byte(cacheBucketPrefix + hex.EncodeToString(h.Sum(nil)))
+}
+
+// forConfig returns a view of the cache scoped to another configuration. It
+// shares the file with c and must not be closed.
+func (c *DecisionCache) forConfig(cfg *PluginConfig) *DecisionCache {
+	scoped := *c
+	scoped.bucket = cacheBucket(cfg)
+	scoped.personalized = cfg.personalized()
+	return &scoped
+}
+
+// key returns the key of the entry for the issue key and the requestor of
+// the context. It returns false when decisions depend on the requestor and
+// the requestor is unknown, such validations must not be cached.
+func (c *DecisionCache) key(ctx context.Context, issueKey string) (string, bool) {
+	if !c.personalized {
+		return issueKey, true
+	}
+	r := requestorFromContext(ctx)
+	if r == nil {
+		return "", false
+	}
+	// Issue keys cannot contain a slash.
+	return issueKey + "/" + r.Subject, true
+}
+
+// Get returns the cached match for the issue key, or nil when there is no
+// unexpired entry.
+func (c *DecisionCache) Get(issueKey string) (*MatchResult, error) {
+	entry, err := c.get(issueKey)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil || !c.fresh(entry) {
+		return nil, nil
+	}
+	return entry.Result, nil
+}
+
+// fresh reports whether the entry has not expired.
+func (c *DecisionCache) fresh(entry *cacheEntry) bool {
+	return c.now().Before(entry.ExpiresAt)
+}
+
+// usable reports whether the entry expired less than maxStale ago.
+func (c *DecisionCache) usable(entry *cacheEntry, maxStale time.Duration) bool {
+	return entry.Result != nil && c.now().Before(entry.ExpiresAt.Add(maxStale))
+}
+
+// get returns the entry for the issue key whether it expired or not, or nil
+// when there is none.
+func (c *DecisionCache) get(issueKey string) (*cacheEntry, error) {
+	name := c.name(issueKey)
+	v, err := c.store.get(c.bucket, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	entry, err := c.decode(name, v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: failed to decode cache entry for %q: %w", issueKey, err)
+	}
+	return entry, nil
+}
+
+// Put caches the match for the issue key.
+func (c *DecisionCache) Put(issueKey string, result *MatchResult) error {
+	name := c.name(issueKey)
+	v, err := c.encode(name, &cacheEntry{
+		ExpiresAt: c.now().Add(c.ttl),
+		Result:    result,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry for %q: %w", issueKey, err)
+	}
+
+	// Like prune, entries are kept while they can be revalidated.
+	if err := c.store.put(c.bucket, name, v, c.ttl+cacheRevalidationWindow); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+// delete removes the entry for the issue key.
+func (c *DecisionCache) delete(issueKey string) error {
+	if err := c.store.delete(c.bucket, c.name(issueKey)); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return nil
+}
+
+// tryLock locks the entry for the issue key when the store is shared, see
+// [cacheLocker]. Entries of a store that is not shared are always unlocked.
+func (c *DecisionCache) tryLock(ctx context.Context, issueKey string, ttl time.Duration) (func(), bool, error) {
+	l, ok := c.store.(cacheLocker)
+	if !ok {
+		return func() {}, true, nil
+	}
+	return l.tryLock(ctx, c.bucket, c.name(issueKey), ttl) //nolint:wrapcheck // Want passthrough
+}
+
+// name returns the name the entry for the key is stored under.
+func (c *DecisionCache) name(key string) []byte {
+	if c.cipher == nil {
+		return []byte(key)
+	}
+	return []byte(c.cipher.name(key))
+}
+
+// encode returns the stored form of the entry stored under name.
+func (c *DecisionCache) encode(name []byte, entry *cacheEntry) ([]byte, error) {
+	v, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Want passthrough
+	}
+	if c.cipher == nil {
+		return v, nil
+	}
+	return c.cipher.seal(string(name), v)
+}
+
+// decode returns the entry stored under name from its stored form.
+func (c *DecisionCache) decode(name, v []byte) (*cacheEntry, error) {
+	if c.cipher != nil {
+		var err error
+		if v, err = c.cipher.open(string(name), v); err != nil {
+			return nil, err
+		}
+	}
+	entry := new(cacheEntry)
+	if err := json.Unmarshal(v, entry); err != nil {
+		return nil, err //nolint:wrapcheck // Want passthrough
+	}
+	return entry, nil
+}
+
+// Close closes the cache file, or the connections to a shared cache.
+func (c *DecisionCache) Close() error {
+	if err := c.store.close(); err != nil {
+		return fmt.Errorf("failed to close cache: %w", err)
+	}
+	return nil
+}
+
+// prune removes expired entries of every configuration from the cache file.
+// Entries with an issue version are kept for another
+// cacheRevalidationWindow.
+func (c *DecisionCache) prune(db *bolt.DB) error {
+	now := c.now()
+	if err := db.Update(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(_ []byte, b *bolt.Bucket) error { //nolint:wrapcheck // Want passthrough
+			var expired [][]byte
+			if err := b.ForEach(func(k, v []byte) error {
+				entry, err := c.decode(k, v)
+				if err != nil {
+					expired = append(expired, k)
+					return nil
+				}
+				expiresAt := entry.ExpiresAt
+				if entry.Result != nil && entry.Result.IssueVersion != nil {
+					expiresAt = expiresAt.Add(cacheRevalidationWindow)
+				}
+				if !now.Before(expiresAt) {
+					expired = append(expired, k)
+				}
+				return nil
+			}); err != nil {
+				return err //nolint:wrapcheck // Want passthrough
+			}
+			for _, k := range expired {
+				if err := b.Delete(k); err != nil {
+					return err //nolint:wrapcheck // Want passthrough
+				}
+			}
+			return nil
+		})
+	}); err != nil {
+		return fmt.Errorf("failed to prune cache: %w", err)
+	}
+	return nil
+}
+
+// boltCacheStore is a [cacheStore] in a file, expired entries are removed by
+// [DecisionCache.prune].
+type boltCacheStore struct {
+	db *bolt.DB
+}
+
+func (s *boltCacheStore) get(bucket, name []byte) ([]byte, error) {
+	var v []byte
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(bucket); b != nil {
+			// The value is only valid during the transaction.
+			if got := b.Get(name); got != nil {
+				v = append([]byte(nil), got...)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err //nolint:wrapcheck // Want passthrough
+	}
+	return v, nil
+}
+
+func (s *boltCacheStore) put(bucket, name, v []byte, _ time.Duration) error {
+	return s.db.Update(func(tx *bolt.Tx) error { //nolint:wrapcheck // Want passthrough
+		b, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+		return b.Put(name, v) //nolint:wrapcheck // Want passthrough
+	})
+}
+
+func (s *boltCacheStore) delete(bucket, name []byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error { //nolint:wrapcheck // Want passthrough
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return nil
+		}
+		return b.Delete(name) //nolint:wrapcheck // Want passthrough
+	})
+}
+
+func (s *boltCacheStore) count(bucket []byte) (int, int, error) {
+	var n, total int
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error { //nolint:wrapcheck // Want passthrough
+			keys := b.Stats().KeyN
+			if bytes.Equal(name, bucket) {
+				n = keys
+			}
+			total += keys
+			return nil
+		})
+	}); err != nil {
+		return 0, 0, err //nolint:wrapcheck // Want passthrough
+	}
+	return n, total, nil
+}
+
+func (s *boltCacheStore) purge() (int, error) {
+	var n int
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		var names [][]byte
+		if err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			names = append(names, append([]byte(nil), name...))
+			n += b.Stats().KeyN
+			return nil
+		}); err != nil {
+			return err //nolint:wrapcheck // Want passthrough
+		}
+		for _, name := range names {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err //nolint:wrapcheck // Want passthrough
+			}
+		}
+		return nil
+	}); err != nil {
+		return 0, err //nolint:wrapcheck // Want passthrough
+	}
+	return n, nil
+}
+
+func (s *boltCacheStore) close() error {
+	return s.db.Close() //nolint:wrapcheck // Want passthrough
+}
+
+// conditionalMatcher is an [IssueMatcher] that can skip matching an issue
+// that did not change since a previous match.
+type conditionalMatcher interface {
+	IssueMatcher
+	matchIssueSince(context.Context, string, *IssueVersion) (*MatchResult, error)
+}
+
+// cachingMatcher serves issue matches from a [DecisionCache] and falls back
+// to the wrapped matcher. Cache failures are logged, they never fail a
+// validation.
+type cachingMatcher struct {
+	next  IssueMatcher
+	cache *DecisionCache
+
+	// maxStale is how long after expiring a match is still served while it
+	// is refreshed in the background, see [PluginConfig.CacheMaxStaleness].
+	maxStale time.Duration
+
+	// life keeps the plugin from closing the cache during a background
+	// refresh, refreshes are not tracked when it is nil.
+	life *lifecycle
+
+	// features turn the cache and serving stale matches off at runtime,
+	// see [FeatureCache] and [FeatureStaleWhileRevalidate].
+	features *featureFlags
+
+	// refreshing holds the keys refreshed in the background.
+	refreshing sync.Map
+}
+
+// MatchIssue returns the cached match for the issue key, or matches it with
+// the wrapped matcher and caches the result when exactly one issue matched.
+// An expired match is revalidated conditionally when the wrapped matcher
+// supports it, a 304 Not Modified response refreshes the cached match.
+//
+// A match that expired less than maxStale ago is returned flagged as stale
+// instead, while a single background refresh per key updates the cache.
+func (m *cachingMatcher) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
+	logger := logging.FromContext(ctx)
+
+	if !m.features.enabled(FeatureCache) {
+		explainCheck(ctx, "cache", "", ExplainSkipped, "decision cache turned off by feature flag")
+		return m.next.MatchIssue(ctx, issueKey) //nolint:wrapcheck // Want passthrough
+	}
+	key, ok := m.cache.key(ctx, issueKey)
+	if !ok {
+		return m.next.MatchIssue(ctx, issueKey) //nolint:wrapcheck // Want passthrough
+	}
+
+	stop := timeStage(ctx, stageCacheLookup)
+	entry, err := m.cache.get(key)
+	stop()
+	if err != nil {
+		logger.WarnContext(ctx, "failed to read decision cache", "error", err)
+	}
+	if entry != nil && m.cache.fresh(entry) {
+		m.cache.counters.hits.Add(1)
+		countCacheHit(ctx)
+		explainCheck(ctx, "cache", "", ExplainPass, "served from the decision cache")
+		return entry.Result, nil
+	}
+	if entry != nil && m.maxStale > 0 && m.features.enabled(FeatureStaleWhileRevalidate) && m.cache.usable(entry, m.maxStale) {
+		m.refreshInBackground(ctx, key, issueKey, entry)
+		m.cache.counters.staleHits.Add(1)
+		countCacheHit(ctx)
+		explainCheck(ctx, "cache", "", ExplainPass, "served stale from the decision cache, refreshing in the background")
+		stale := *entry.Result
+		stale.Stale = true
+		return &stale, nil
+	}
+	m.cache.counters.misses.Add(1)
+	if entry != nil {
+		explainCheck(ctx, "cache", "", ExplainSkipped, "cached match expired")
+	} else {
+		explainCheck(ctx, "cache", "", ExplainSkipped, "no cached match")
+	}
+	return m.refresh(ctx, key, issueKey, entry)
+}
+
+// refreshInBackground refreshes the expired entry for the cache key unless a
+// refresh is already running, in this plugin or in another one sharing the
+// cache, or the plugin is closing. An issue that no longer matches is removed
+// from the cache, so it is not served stale again.
+func (m *cachingMatcher) refreshInBackground(ctx context.Context, key, issueKey string, entry *cacheEntry) {
+	if _, running := m.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	if m.life != nil && !m.life.acquire() {
+		m.refreshing.Delete(key)
+		return
+	}
+
+	// The refresh outlives the validation, it keeps the logger and the
+	// requestor of ctx but not its deadline.
+	ctx, cancel := context.WithTimeout(withoutExplanation(context.WithoutCancel(ctx)), cacheRefreshTimeout)
+	go func() {
+		defer cancel()
+		defer m.refreshing.Delete(key)
+		if m.life != nil {
+			defer m.life.release()
+		}
+
+		logger := logging.FromContext(ctx)
+		unlock, ok, err := m.cache.tryLock(ctx, key, cacheRefreshTimeout)
+		if err != nil {
+			// Refreshing twice is better than not refreshing.
+			logger.WarnContext(ctx, "failed to lock cached match for refresh", "issue_key", issueKey, "error", err)
+			unlock, ok = func() {}, true
+		}
+		if !ok {
+			return
+		}
+		defer unlock()
+
+		if _, err := m.refresh(ctx, key, issueKey, entry); err != nil {
+			logger.WarnContext(ctx, "failed to refresh stale cached match", "issue_key", issueKey, "error", err)
+			if errors.Is(err, ErrInvalidJustification) {
+				if err := m.cache.delete(key); err != nil {
+					logger.WarnContext(ctx, "failed to write decision cache", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// refresh matches the issue again, conditionally when entry has an issue
+// version, and caches the result.
+func (m *cachingMatcher) refresh(ctx context.Context, key, issueKey string, entry *cacheEntry) (*MatchResult, error) {
+	logger := logging.FromContext(ctx)
+
+	var since *IssueVersion
+	if entry != nil && entry.Result != nil {
+		since = entry.Result.IssueVersion
+	}
+
+	var result *MatchResult
+	var err error
+	if cm, ok := m.next.(conditionalMatcher); ok && since != nil {
+		result, err = cm.matchIssueSince(ctx, issueKey, since)
+		if errors.Is(err, errNotModified) {
+			logger.DebugContext(ctx, "issue not modified, refreshing cached match", "issue_key", issueKey)
+			result, err = entry.Result, nil
+			m.cache.counters.revalidations.Add(1)
+			countCacheHit(ctx)
+			explainCheck(ctx, "revalidate", issueKey, ExplainPass, "issue not modified since the cached match")
+		}
+	} else {
+		result, err = m.next.MatchIssue(ctx, issueKey)
+	}
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Want passthrough
+	}
+
+	if len(result.Matches) == 1 && len(result.Matches[0].MatchedIssues) == 1 {
+		if err := m.cache.Put(key, result); err != nil {
+			logger.WarnContext(ctx, "failed to write decision cache", "error", err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,429 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+var testCacheConfig = &PluginConfig{
+	JIRAEndpoint: "https://example.atlassian.net/rest/api/3",
+	JIRAAccount:  "test@test.com",
+	Jql:          "status NOT IN (Done)",
+}
+
+func testMatch(id int) *MatchResult {
+	return &MatchResult{Matches: []*Match{{MatchedIssues: []int{id}, Errors: []string{}}}}
+}
+
+func openTestCache(t *testing.T, pth string, cfg *PluginConfig) *DecisionCache {
+	t.Helper()
+
+	c, err := OpenDecisionCache(pth, time.Minute, cfg)
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	return c
+}
+
+func TestDecisionCache(t *testing.T) {
+	t.Parallel()
+
+	pth := filepath.Join(t.TempDir(), "decisions.db")
+	now := time.Now()
+
+	c := openTestCache(t, pth, testCacheConfig)
+	c.now = func() time.Time { return now }
+
+	if err := c.Put("ABCD-1", testMatch(1234)); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	// Entries survive a restart.
+	c = openTestCache(t, pth, testCacheConfig)
+	t.Cleanup(func() { c.Close() })
+	c.now = func() time.Time { return now.Add(30 * time.Second) }
+
+	got, err := c.Get("ABCD-1")
+	if err != nil {
+		t.Fatalf("failed to get: %v", err)
+	}
+	if diff := cmp.Diff(testMatch(1234), got); diff != "" {
+		t.Errorf("Get() unexpected diff (-want,+got):\n%s", diff)
+	}
+
+	if got, err := c.Get("ABCD-2"); err != nil || got != nil {
+		t.Errorf("Get() for unknown key got (%v, %v), want (nil, nil)", got, err)
+	}
+
+	c.now = func() time.Time { return now.Add(time.Minute) }
+	if got, err := c.Get("ABCD-1"); err != nil || got != nil {
+		t.Errorf("Get() for expired key got (%v, %v), want (nil, nil)", got, err)
+	}
+}
+
+func TestDecisionCache_ScopedToConfig(t *testing.T) {
+	t.Parallel()
+
+	pth := filepath.Join(t.TempDir(), "decisions.db")
+
+	c := openTestCache(t, pth, testCacheConfig)
+	if err := c.Put("ABCD-1", testMatch(1234)); err != nil {
+		t.Fatalf("failed to put: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	other := *testCacheConfig
+	other.Jql = "project = OPS"
+	c = openTestCache(t, pth, &other)
+	t.Cleanup(func() { c.Close() })
+
+	if got, err := c.Get("ABCD-1"); err != nil || got != nil {
+		t.Errorf("Get() with a different JQL got (%v, %v), want (nil, nil)", got, err)
+	}
+}
+
+func TestOpenDecisionCache_NegativeTTL(t *testing.T) {
+	t.Parallel()
+
+	_, err := OpenDecisionCache(filepath.Join(t.TempDir(), "decisions.db"), -time.Second, testCacheConfig)
+	if diff := testutil.DiffErrString(err, "cache ttl must be positive"); diff != "" {
+		t.Errorf(diff)
+	}
+}
+
+// countingMatcher counts the calls to the wrapped matcher.
+type countingMatcher struct {
+	mockValidator
+	calls int
+}
+
+func (m *countingMatcher) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
+	m.calls++
+	return m.mockValidator.MatchIssue(ctx, issueKey)
+}
+
+func TestCachingMatcher(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		validator mockValidator
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "match_is_cached",
+			validator: mockValidator{result: testMatch(1234)},
+			wantCalls: 1,
+		},
+		{
+			name:      "no_match_is_not_cached",
+			validator: mockValidator{result: &MatchResult{Matches: []*Match{{MatchedIssues: []int{}}}}},
+			wantCalls: 2,
+		},
+		{
+			name:      "error_is_not_cached",
+			validator: mockValidator{err: fmt.Errorf("jira unavailable")},
+			wantCalls: 2,
+			wantErr:   "jira unavailable",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := openTestCache(t, filepath.Join(t.TempDir(), "decisions.db"), testCacheConfig)
+			t.Cleanup(func() { c.Close() })
+
+			next := &countingMatcher{mockValidator: tc.validator}
+			m := &cachingMatcher{next: next, cache: c}
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			for i := 0; i < 2; i++ {
+				_, err := m.MatchIssue(ctx, "ABCD-1")
+				if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+					t.Errorf(diff)
+				}
+			}
+			if got, want := next.calls, tc.wantCalls; got != want {
+				t.Errorf("got %d calls to Jira, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestCachingMatcher_Revalidate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		modified    bool
+		wantMatches int32
+	}{
+		{
+			name:        "not_modified_refreshes",
+			wantMatches: 1,
+		},
+		{
+			name:        "modified_matches_again",
+			modified:    true,
+			wantMatches: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var issueGets, matches atomic.Int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+				n := issueGets.Add(1)
+				etag := `"v1"`
+				if tc.modified && n > 1 {
+					etag = `"v2"`
+				}
+				w.Header().Set("ETag", etag)
+				if r.Header.Get("If-None-Match") == etag {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+			})
+			mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+				matches.Add(1)
+				fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+			})
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			v, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "secrets")
+			if err != nil {
+				t.Fatalf("failed to create validator: %v", err)
+			}
+
+			now := time.Now()
+			c := openTestCache(t, filepath.Join(t.TempDir(), "decisions.db"), testCacheConfig)
+			t.Cleanup(func() { c.Close() })
+			c.now = func() time.Time { return now }
+
+			m := &cachingMatcher{next: &lazyValidator{v: v}, cache: c}
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			// The second validation happens after the entry expired and the
+			// third one within the refreshed TTL.
+			for _, d := range []time.Duration{0, 2 * time.Minute, 30 * time.Second} {
+				now = now.Add(d)
+				got, err := m.MatchIssue(ctx, "ABCD-1")
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got, want := got.Matches[0].MatchedIssues, []int{1234}; !cmp.Equal(got, want) {
+					t.Errorf("got matched issues %v, want %v", got, want)
+				}
+			}
+
+			if got, want := issueGets.Load(), int32(2); got != want {
+				t.Errorf("got %d issue requests, want %d", got, want)
+			}
+			if got, want := matches.Load(), tc.wantMatches; got != want {
+				t.Errorf("got %d match requests, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestCachingMatcher_RevalidateIssueAge(t *testing.T) {
+	t.Parallel()
+
+	var conditional atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1","fields":{"created":"2026-10-16T12:00:00.000+0000"}}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "secrets", WithMaxIssueAge(24*time.Hour, 0))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+	now := time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)
+	v.clock.now = func() time.Time { return now }
+
+	c := openTestCache(t, filepath.Join(t.TempDir(), "decisions.db"), testCacheConfig)
+	t.Cleanup(func() { c.Close() })
+	c.now = func() time.Time { return now }
+
+	m := &cachingMatcher{next: &lazyValidator{v: v}, cache: c}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	if _, err := m.MatchIssue(ctx, "ABCD-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The cached match expires after the issue got too old, even though
+	// jira would report it unchanged.
+	now = now.Add(24 * time.Hour)
+	_, err = m.MatchIssue(ctx, "ABCD-1")
+	if diff := testutil.DiffErrString(err, "issue was created at 2026-10-16T12:00:00Z, more than 1d ago"); diff != "" {
+		t.Error(diff)
+	}
+	if got := conditional.Load(); got != 0 {
+		t.Errorf("got %d conditional issue requests, want 0", got)
+	}
+}
+
+// gatedMatcher is a mockValidator counting its calls that waits for the gate
+// to be closed before answering.
+type gatedMatcher struct {
+	mockValidator
+	gate  chan struct{}
+	calls atomic.Int32
+}
+
+func (m *gatedMatcher) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
+	m.calls.Add(1)
+	<-m.gate
+	return m.mockValidator.MatchIssue(ctx, issueKey)
+}
+
+func TestCachingMatcher_StaleWhileRevalidate(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		validator mockValidator
+		wantCalls int32
+		wantErr   string
+	}{
+		{
+			name:      "refreshed",
+			validator: mockValidator{result: testMatch(1234)},
+			wantCalls: 1,
+		},
+		{
+			name:      "no_longer_matching_is_removed",
+			validator: mockValidator{err: fmt.Errorf("issue closed: %w", ErrInvalidJustification)},
+			wantCalls: 2,
+			wantErr:   "issue closed",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Now()
+			c := openTestCache(t, filepath.Join(t.TempDir(), "decisions.db"), testCacheConfig)
+			t.Cleanup(func() { c.Close() })
+			c.now = func() time.Time { return now }
+			if err := c.Put("ABCD-1", testMatch(1234)); err != nil {
+				t.Fatalf("failed to write cache: %v", err)
+			}
+
+			next := &gatedMatcher{mockValidator: tc.validator, gate: make(chan struct{})}
+			life := &lifecycle{}
+			m := &cachingMatcher{next: next, cache: c, maxStale: time.Minute, life: life}
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			// Every validation is served the stale match while the single
+			// refresh waits for Jira.
+			now = now.Add(90 * time.Second)
+			for i := 0; i < 5; i++ {
+				got, err := m.MatchIssue(ctx, "ABCD-1")
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !got.Stale {
+					t.Errorf("got fresh match %v, want stale", got)
+				}
+			}
+			close(next.gate)
+			if _, err := life.shutdown(ctx); err != nil {
+				t.Fatalf("failed to wait for the refresh: %v", err)
+			}
+
+			got, err := m.MatchIssue(ctx, "ABCD-1")
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got != nil && got.Stale {
+				t.Errorf("got stale match after the refresh")
+			}
+			if got, want := next.calls.Load(), tc.wantCalls; got != want {
+				t.Errorf("got %d calls to Jira, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestCachingMatcher_TooStale(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	c := openTestCache(t, filepath.Join(t.TempDir(), "decisions.db"), testCacheConfig)
+	t.Cleanup(func() { c.Close() })
+	c.now = func() time.Time { return now }
+	if err := c.Put("ABCD-1", testMatch(1234)); err != nil {
+		t.Fatalf("failed to write cache: %v", err)
+	}
+
+	next := &countingMatcher{mockValidator: mockValidator{err: fmt.Errorf("jira unavailable")}}
+	m := &cachingMatcher{next: next, cache: c, maxStale: time.Minute}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	now = now.Add(3 * time.Minute)
+	if _, err := m.MatchIssue(ctx, "ABCD-1"); err == nil {
+		t.Errorf("got no error for a match older than the max staleness")
+	}
+	if next.calls != 1 {
+		t.Errorf("got %d calls to Jira, want 1", next.calls)
+	}
+}
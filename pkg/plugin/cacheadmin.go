@@ -0,0 +1,211 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCacheDisabled is returned by the cache administration methods of
+// [JiraPlugin] when the decision cache is disabled.
+var ErrCacheDisabled = errors.New("decision cache is disabled")
+
+// errInvalidAdminRequest is wrapped by the errors of administration
+// requests with invalid arguments.
+var errInvalidAdminRequest = errors.New("invalid request")
+
+// cacheCounters count the lookups of a [DecisionCache].
+type cacheCounters struct {
+	hits          atomic.Int64
+	staleHits     atomic.Int64
+	misses        atomic.Int64
+	revalidations atomic.Int64
+}
+
+// CacheStats describes the decision cache, see [JiraPlugin.CacheStats].
+type CacheStats struct {
+	// Backend is where the entries are stored, "file" or "redis".
+	Backend string `json:"backend"`
+
+	// TTL is how long a decision is served from the cache.
+	TTL time.Duration `json:"ttl"`
+
+	// Entries is the number of entries of the current configuration,
+	// including expired ones kept for revalidation, and TotalEntries the
+	// number of entries of every configuration.
+	Entries      int `json:"entries"`
+	TotalEntries int `json:"total_entries"`
+
+	// Hits, StaleHits and Misses count the lookups since the plugin started,
+	// Revalidations the misses Jira reported unchanged.
+	Hits          int64 `json:"hits"`
+	StaleHits     int64 `json:"stale_hits"`
+	Misses        int64 `json:"misses"`
+	Revalidations int64 `json:"revalidations"`
+}
+
+// CachedDecision is the cached match of an issue, see
+// [JiraPlugin.LookupCachedDecision].
+type CachedDecision struct {
+	IssueKey  string    `json:"issue_key"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Expired is set when the entry is only kept to revalidate it.
+	Expired bool `json:"expired"`
+
+	Result *MatchResult `json:"result"`
+}
+
+// CacheStats returns the statistics of the decision cache, or an error
+// wrapping [ErrCacheDisabled].
+func (j *JiraPlugin) CacheStats(ctx context.Context) (*CacheStats, error) {
+	if !j.life.acquire() {
+		return nil, ErrClosed
+	}
+	defer j.life.release()
+
+	c, err := j.currentCache()
+	if err != nil {
+		return nil, err
+	}
+	n, total, err := c.store.count(c.bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count cache entries: %w", err)
+	}
+	backend := "file"
+	if _, ok := c.store.(*redisCacheStore); ok {
+		backend = "redis"
+	}
+	return &CacheStats{
+		Backend:       backend,
+		TTL:           c.ttl,
+		Entries:       n,
+		TotalEntries:  total,
+		Hits:          c.counters.hits.Load(),
+		StaleHits:     c.counters.staleHits.Load(),
+		Misses:        c.counters.misses.Load(),
+		Revalidations: c.counters.revalidations.Load(),
+	}, nil
+}
+
+// LookupCachedDecision returns the cached match of the issue for the
+// current configuration, or nil when there is none. When the JQL depends on
+// the requestor, the subject of the requestor is required.
+func (j *JiraPlugin) LookupCachedDecision(ctx context.Context, issueKey, subject string) (*CachedDecision, error) {
+	if !j.life.acquire() {
+		return nil, ErrClosed
+	}
+	defer j.life.release()
+
+	c, err := j.currentCache()
+	if err != nil {
+		return nil, err
+	}
+	key, err := c.adminKey(issueKey, subject)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.get(key)
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	return &CachedDecision{
+		IssueKey:  issueKey,
+		ExpiresAt: entry.ExpiresAt,
+		Expired:   !c.fresh(entry),
+		Result:    entry.Result,
+	}, nil
+}
+
+// PurgeCache removes the cached matches of the issue, for the JQL, change
+// JQL and emergency JQL of the current configuration, so that the next
+// validation asks Jira again. It forgets that Jira reported the issue
+// missing, too. An empty issue key purges the whole cache, of every
+// configuration, and returns the number of entries removed. When the JQL
+// depends on the requestor, purging an issue requires the subject of the
+// requestor.
+func (j *JiraPlugin) PurgeCache(ctx context.Context, issueKey, subject string) (int, error) {
+	if !j.life.acquire() {
+		return 0, ErrClosed
+	}
+	defer j.life.release()
+
+	s := j.current.Load()
+	if issueKey == "" {
+		if s.notFound != nil {
+			s.notFound.purge()
+		}
+		if j.cache == nil {
+			return 0, nil
+		}
+		n, err := j.cache.store.purge()
+		if err != nil {
+			return 0, fmt.Errorf("failed to purge cache: %w", err)
+		}
+		return n, nil
+	}
+
+	if s.notFound != nil {
+		s.notFound.forget(issueKey)
+	}
+	var n int
+	for _, c := range s.decisionCaches {
+		key, err := c.adminKey(issueKey, subject)
+		if err != nil {
+			return n, err
+		}
+		entry, err := c.get(key)
+		if err != nil {
+			return n, err
+		}
+		if entry == nil {
+			continue
+		}
+		if err := c.delete(key); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// currentCache returns the view of the decision cache for the current
+// configuration.
+func (j *JiraPlugin) currentCache() (*DecisionCache, error) {
+	s := j.current.Load()
+	if len(s.decisionCaches) == 0 {
+		return nil, ErrCacheDisabled
+	}
+	return s.decisionCaches[0], nil
+}
+
+// adminKey returns the key of the entry for the issue and the requestor
+// subject, like [DecisionCache.key].
+func (c *DecisionCache) adminKey(issueKey, subject string) (string, error) {
+	if _, err := issuePathSegment(issueKey); err != nil {
+		return "", fmt.Errorf("%w: invalid issue key %q", errInvalidAdminRequest, issueKey)
+	}
+	if !c.personalized {
+		return issueKey, nil
+	}
+	if subject == "" {
+		return "", fmt.Errorf("%w: decisions depend on the requestor, a requestor subject is required", errInvalidAdminRequest)
+	}
+	return issueKey + "/" + subject, nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+// newAdminConn serves the admin service of p and returns a connection to
+// it.
+func newAdminConn(ctx context.Context, t *testing.T, p *JiraPlugin) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	p.RegisterAdmin(srv)
+	go srv.Serve(lis) //nolint:errcheck // Stopped in cleanup
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestCacheAdmin(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.CachePath = filepath.Join(t.TempDir(), "decisions.db")
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+	conn := newAdminConn(ctx, t, p)
+
+	for _, key := range []string{"ABCD-1", "ABCD-2", "ABCD-1"} {
+		if resp, err := p.ValidateValue(ctx, key); err != nil || !resp.GetValid() {
+			t.Fatalf("ValidateValue(%q) got %v, %v, want a valid response", key, resp, err)
+		}
+	}
+	f.assertCalls(t, 2)
+
+	stats, err := GetCacheStats(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := *stats, (CacheStats{Backend: "file", TTL: defaultCacheTTL, Entries: 2, TotalEntries: 2, Hits: 1, Misses: 2}); got != want {
+		t.Errorf("GetCacheStats() got %+v, want %+v", got, want)
+	}
+
+	d, err := LookupCachedDecision(ctx, conn, "ABCD-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil || d.IssueKey != "ABCD-1" || d.Expired || d.Result.Matches[0].MatchedIssues[0] != 1234 {
+		t.Errorf("LookupCachedDecision() got %+v, want the cached match of issue 1234", d)
+	}
+	if d, err := LookupCachedDecision(ctx, conn, "ABCD-9", ""); err != nil || d != nil {
+		t.Errorf("LookupCachedDecision() of an uncached issue got %+v, %v, want nil", d, err)
+	}
+	if _, err := LookupCachedDecision(ctx, conn, "ABCD/../1", ""); status.Code(errors.Unwrap(err)) != codes.InvalidArgument {
+		t.Errorf("LookupCachedDecision() of an invalid key got %v, want InvalidArgument", err)
+	}
+
+	if n, err := PurgeCache(ctx, conn, "ABCD-1", ""); err != nil || n != 1 {
+		t.Errorf("PurgeCache(ABCD-1) got %d, %v, want 1", n, err)
+	}
+	if d, err := LookupCachedDecision(ctx, conn, "ABCD-1", ""); err != nil || d != nil {
+		t.Errorf("LookupCachedDecision() after purge got %+v, %v, want nil", d, err)
+	}
+	if _, err := p.ValidateValue(ctx, "ABCD-1"); err != nil {
+		t.Fatal(err)
+	}
+	f.assertCalls(t, 3)
+
+	if n, err := PurgeCache(ctx, conn, "", ""); err != nil || n != 2 {
+		t.Errorf("PurgeCache() got %d, %v, want 2", n, err)
+	}
+	stats, err = p.CacheStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 0 || stats.TotalEntries != 0 {
+		t.Errorf("CacheStats() after purge got %+v, want no entries", stats)
+	}
+}
+
+func TestCacheAdmin_Disabled(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := NewJiraPluginWithToken(ctx, newFakeJira(t).config(), "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	if _, err := p.CacheStats(ctx); !errors.Is(err, ErrCacheDisabled) {
+		t.Errorf("CacheStats() got err %v, want %v", err, ErrCacheDisabled)
+	}
+	_, err = GetCacheStats(ctx, newAdminConn(ctx, t, p))
+	if got, want := status.Code(errors.Unwrap(err)), codes.FailedPrecondition; got != want {
+		t.Errorf("GetCacheStats() got code %s, want %s", got, want)
+	}
+}
+
+func TestDecisionCache_AdminKey(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		personalized bool
+		issueKey     string
+		subject      string
+		want         string
+		wantErr      string
+	}{
+		{name: "issue", issueKey: "ABCD-1", want: "ABCD-1"},
+		{name: "subject_ignored", issueKey: "ABCD-1", subject: "user@example.com", want: "ABCD-1"},
+		{name: "personalized", personalized: true, issueKey: "ABCD-1", subject: "user@example.com", want: "ABCD-1/user@example.com"},
+		{name: "personalized_no_subject", personalized: true, issueKey: "ABCD-1", wantErr: "a requestor subject is required"},
+		{name: "invalid_key", issueKey: "ABCD 1", wantErr: `invalid issue key "ABCD 1"`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &DecisionCache{personalized: tc.personalized}
+			got, err := c.adminKey(tc.issueKey, tc.subject)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if got != tc.want {
+				t.Errorf("adminKey() got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
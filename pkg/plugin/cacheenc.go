@@ -0,0 +1,139 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	// minCacheKeyBytes is the minimum length of a cache encryption key.
+	minCacheKeyBytes = 32
+
+	// cacheKeyIDBytes is the length of the key id prefixing an encrypted
+	// entry.
+	cacheKeyIDBytes = 4
+)
+
+// errCacheDecrypt is returned for a cache entry that none of the keys
+// decrypts, e.g. after its key was removed.
+var errCacheDecrypt = errors.New("failed to decrypt cache entry")
+
+// cacheCipher encrypts the entries of a [DecisionCache] with AES-GCM, since
+// they hold issue metadata. The first key encrypts, every key decrypts, so a
+// key can be rotated by prepending the new key and removing the old one
+// once its entries expired.
+//
+// The issue keys and requestors the entries are stored under are replaced
+// with an HMAC of the first key, so rotating it makes the existing entries
+// unreachable and they are matched again.
+type cacheCipher struct {
+	// aeads are the ciphers of the keys by key id.
+	aeads map[string]cipher.AEAD
+
+	// primary is the id of the key encrypting new entries.
+	primary string
+
+	// nameKey keys the HMAC of the entry names.
+	nameKey []byte
+}
+
+// newCacheCipher creates the cipher for the keys, the first one encrypting.
+// Every key must be at least 32 bytes, the AES-256 keys are derived from
+// them.
+func newCacheCipher(keys [][]byte) (*cacheCipher, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no cache encryption key")
+	}
+
+	c := &cacheCipher{aeads: make(map[string]cipher.AEAD, len(keys))}
+	for i, key := range keys {
+		if len(key) < minCacheKeyBytes {
+			return nil, fmt.Errorf("cache encryption key %d has %d bytes, want at least %d", i, len(key), minCacheKeyBytes)
+		}
+		encKey := deriveCacheKey(key, "encryption")
+		block, err := aes.NewCipher(encKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cipher: %w", err)
+		}
+		id := cacheKeyID(encKey)
+		c.aeads[id] = aead
+		if i == 0 {
+			c.primary = id
+			c.nameKey = deriveCacheKey(key, "names")
+		}
+	}
+	return c, nil
+}
+
+// deriveCacheKey derives the 32 bytes key for the purpose from key.
+func deriveCacheKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("jvs-plugin-jira/cache/" + purpose))
+	return mac.Sum(nil)
+}
+
+// cacheKeyID returns the id identifying the key in encrypted entries.
+func cacheKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return string(sum[:cacheKeyIDBytes])
+}
+
+// name returns the name an entry is stored under.
+func (c *cacheCipher) name(key string) string {
+	mac := hmac.New(sha256.New, c.nameKey)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// seal encrypts the entry stored under name with the first key. The name is
+// authenticated, so entries cannot be swapped.
+func (c *cacheCipher) seal(name string, plaintext []byte) ([]byte, error) {
+	aead := c.aeads[c.primary]
+	out := make([]byte, cacheKeyIDBytes+aead.NonceSize(), cacheKeyIDBytes+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	copy(out, c.primary)
+	if _, err := rand.Read(out[cacheKeyIDBytes:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(out, out[cacheKeyIDBytes:], plaintext, []byte(name)), nil
+}
+
+// open decrypts the entry stored under name with the key that encrypted it.
+func (c *cacheCipher) open(name string, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < cacheKeyIDBytes {
+		return nil, errCacheDecrypt
+	}
+	aead, ok := c.aeads[string(ciphertext[:cacheKeyIDBytes])]
+	if !ok || len(ciphertext) < cacheKeyIDBytes+aead.NonceSize() {
+		return nil, errCacheDecrypt
+	}
+	nonce := ciphertext[cacheKeyIDBytes : cacheKeyIDBytes+aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, ciphertext[cacheKeyIDBytes+aead.NonceSize():], []byte(name))
+	if err != nil {
+		return nil, errCacheDecrypt
+	}
+	return plaintext, nil
+}
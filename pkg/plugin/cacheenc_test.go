@@ -0,0 +1,134 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+var (
+	testCacheKey1 = bytes.Repeat([]byte("1"), 32)
+	testCacheKey2 = bytes.Repeat([]byte("2"), 32)
+)
+
+func TestCacheCipher(t *testing.T) {
+	t.Parallel()
+
+	old, err := newCacheCipher([][]byte{testCacheKey1})
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+	rotated, err := newCacheCipher([][]byte{testCacheKey2, testCacheKey1})
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+	removed, err := newCacheCipher([][]byte{testCacheKey2})
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+
+	sealed, err := old.seal("ABCD-1", []byte("entry"))
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("entry")) {
+		t.Errorf("got the plaintext in the encrypted entry %q", sealed)
+	}
+
+	// An entry of the old key is readable until the key is removed.
+	if got, err := rotated.open("ABCD-1", sealed); err != nil || string(got) != "entry" {
+		t.Errorf("got %q, %v after rotating, want the entry", got, err)
+	}
+	if _, err := removed.open("ABCD-1", sealed); !errors.Is(err, errCacheDecrypt) {
+		t.Errorf("got error %v after removing the key, want %v", err, errCacheDecrypt)
+	}
+	// Entries cannot be moved to another name.
+	if _, err := old.open("ABCD-2", sealed); !errors.Is(err, errCacheDecrypt) {
+		t.Errorf("got error %v for another name, want %v", err, errCacheDecrypt)
+	}
+
+	if old.name("ABCD-1") == rotated.name("ABCD-1") {
+		t.Errorf("got the same entry name after rotating the key")
+	}
+
+	if _, err := newCacheCipher([][]byte{[]byte("short")}); err == nil {
+		t.Errorf("got no error for a short key")
+	}
+}
+
+func TestDecisionCache_Encrypted(t *testing.T) {
+	t.Parallel()
+
+	pth := filepath.Join(t.TempDir(), "decisions.db")
+	c, err := OpenDecisionCache(pth, 0, testCacheConfig, WithCacheEncryptionKeys(testCacheKey1))
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	result := testMatch(1234)
+	result.IssueFields = map[string]string{"summary": "secret summary"}
+	if err := c.Put("ABCD-1", result); err != nil {
+		t.Fatalf("failed to write cache: %v", err)
+	}
+	if got, err := c.Get("ABCD-1"); err != nil || got == nil {
+		t.Fatalf("got %v, %v, want the cached match", got, err)
+	}
+	c.Close()
+
+	db, err := bolt.Open(pth, 0o600, nil)
+	if err != nil {
+		t.Fatalf("failed to open cache file: %v", err)
+	}
+	if err := db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(_ []byte, b *bolt.Bucket) error {
+			return b.ForEach(func(k, v []byte) error {
+				if strings.Contains(string(k), "ABCD-1") || bytes.Contains(v, []byte("secret summary")) {
+					t.Errorf("got cleartext entry %q: %q", k, v)
+				}
+				return nil
+			})
+		})
+	}); err != nil {
+		t.Fatalf("failed to read cache file: %v", err)
+	}
+	db.Close()
+
+	// After rotating the key, the entry is matched again and re-encrypted.
+	c, err = OpenDecisionCache(pth, 0, testCacheConfig, WithCacheEncryptionKeys(testCacheKey2, testCacheKey1))
+	if err != nil {
+		t.Fatalf("failed to open cache: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	next := &countingMatcher{mockValidator: mockValidator{result: testMatch(1234)}}
+	m := &cachingMatcher{next: next, cache: c}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	for i := 0; i < 2; i++ {
+		if _, err := m.MatchIssue(ctx, "ABCD-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if next.calls != 1 {
+		t.Errorf("got %d calls to Jira, want 1", next.calls)
+	}
+}
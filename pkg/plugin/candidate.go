@@ -0,0 +1,116 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+// WithCandidateJQL makes the validator evaluate a candidate JQL in shadow of
+// the configured one and report it in [MatchResult.Candidate]. It never
+// changes the result of the configured JQL: in match mode both JQLs share a
+// single match request, which is retried with the configured JQL alone when
+// Jira rejects it, and in search mode the candidate takes an extra search
+// request whose failures are only logged.
+func WithCandidateJQL(jql string) ValidatorOption {
+	return func(v *Validator) error {
+		v.candidateJQL = jql
+		return nil
+	}
+}
+
+// matchWithCandidate matches the issue against the JQL, and the candidate JQL
+// if any.
+func (v *Validator) matchWithCandidate(ctx context.Context, jql, issueID string) (*MatchResult, error) {
+	if v.candidateJQL == "" {
+		return v.match(ctx, []string{jql}, issueID)
+	}
+
+	result, err := v.match(ctx, []string{jql, v.candidateJQL}, issueID)
+	if err == nil && len(result.Matches) == 2 {
+		result.Candidate = result.Matches[1]
+		result.Matches = result.Matches[:1]
+		return result, nil
+	}
+	// A 4xx may be caused by an invalid candidate JQL, anything else fails
+	// the same way without it.
+	if err != nil && (!errors.Is(err, ErrInvalidJustification) || errors.Is(err, ErrJiraAuth)) {
+		return nil, err
+	}
+	logging.FromContext(ctx).WarnContext(ctx, "failed to match candidate jql, matching the active jql alone",
+		"error", err)
+	return v.match(ctx, []string{jql}, issueID)
+}
+
+// searchCandidate matches the issue against the candidate JQL with a search
+// request, it returns nil when the request fails.
+func (v *Validator) searchCandidate(ctx context.Context, issueKey string) *Match {
+	result, err := v.searchIssue(ctx, searchJQLPrefix(v.candidateJQL), issueKey)
+	if err != nil {
+		logging.FromContext(ctx).WarnContext(ctx, "failed to search candidate jql", "error", err)
+		return nil
+	}
+	return result.Matches[0]
+}
+
+// CandidateStats reports how the candidate JQL compared with the active JQL
+// since the plugin started.
+type CandidateStats struct {
+	// Evaluated is the number of issues matched against both JQLs.
+	Evaluated uint64 `json:"evaluated"`
+
+	// Diverged is the number of issues only one of the JQLs accepted.
+	Diverged uint64 `json:"diverged"`
+}
+
+// candidateCounter counts the evaluations of the candidate JQL.
+type candidateCounter struct {
+	evaluated atomic.Uint64
+	diverged  atomic.Uint64
+}
+
+// compare logs and counts whether the candidate JQL accepted the issue like
+// the active JQL did. An issue is accepted when exactly one issue matched.
+func (c *candidateCounter) compare(ctx context.Context, issueKey string, result *MatchResult) {
+	if result.Candidate == nil {
+		return
+	}
+	c.evaluated.Add(1)
+
+	active := len(result.Matches) > 0 && len(result.Matches[0].MatchedIssues) == 1
+	candidate := len(result.Candidate.MatchedIssues) == 1
+	if active == candidate {
+		return
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "candidate jql diverged from the active jql",
+		"issue_key", issueKey,
+		"active_accepted", active,
+		"candidate_accepted", candidate,
+		"candidate_errors", result.Candidate.Errors,
+		"diverged_total", c.diverged.Add(1))
+}
+
+// CandidateStats reports how the candidate JQL compared with the active JQL,
+// see [PluginConfig.CandidateJql].
+func (j *JiraPlugin) CandidateStats() CandidateStats {
+	return CandidateStats{
+		Evaluated: j.candidate.evaluated.Load(),
+		Diverged:  j.candidate.diverged.Load(),
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+const (
+	testActiveJQL    = "status NOT IN (Done)"
+	testCandidateJQL = "status NOT IN (Done) AND priority = High"
+)
+
+// newCandidateJira starts a Jira where the issue matches the active JQL but
+// not the candidate JQL. A match request with the candidate is rejected when
+// rejectCandidate is set.
+func newCandidateJira(tb testing.TB, rejectCandidate bool) *httptest.Server {
+	tb.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		var data matchData
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			tb.Errorf("failed to decode match request: %v", err)
+		}
+		matches := make([]string, 0, len(data.Jqls))
+		for _, jql := range data.Jqls {
+			if jql == testCandidateJQL {
+				if rejectCandidate {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				matches = append(matches, `{"matchedIssues":[],"errors":[]}`)
+				continue
+			}
+			matches = append(matches, `{"matchedIssues":[1234],"errors":[]}`)
+		}
+		fmt.Fprintf(w, `{"matches":[%s]}`, strings.Join(matches, ","))
+	})
+	mux.HandleFunc("/search/jql", func(w http.ResponseWriter, r *http.Request) {
+		var data searchData
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			tb.Errorf("failed to decode search request: %v", err)
+		}
+		if strings.HasPrefix(data.JQL, searchJQLPrefix(testCandidateJQL)) {
+			fmt.Fprint(w, `{"issues":[]}`)
+			return
+		}
+		fmt.Fprint(w, `{"issues":[{"id":"1234","key":"ABCD-1"}]}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	tb.Cleanup(srv.Close)
+	return srv
+}
+
+func TestValidator_CandidateJQL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name            string
+		opts            []ValidatorOption
+		rejectCandidate bool
+		want            *MatchResult
+	}{
+		{
+			name: "no_candidate",
+			want: &MatchResult{
+				Matches: []*Match{{MatchedIssues: []int{1234}, Errors: []string{}}},
+			},
+		},
+		{
+			name: "match_mode",
+			opts: []ValidatorOption{WithCandidateJQL(testCandidateJQL)},
+			want: &MatchResult{
+				Matches:   []*Match{{MatchedIssues: []int{1234}, Errors: []string{}}},
+				Candidate: &Match{MatchedIssues: []int{}, Errors: []string{}},
+			},
+		},
+		{
+			name:            "match_mode_candidate_rejected",
+			opts:            []ValidatorOption{WithCandidateJQL(testCandidateJQL)},
+			rejectCandidate: true,
+			want: &MatchResult{
+				Matches: []*Match{{MatchedIssues: []int{1234}, Errors: []string{}}},
+			},
+		},
+		{
+			name: "search_mode",
+			opts: []ValidatorOption{WithSearchMode(), WithCandidateJQL(testCandidateJQL)},
+			want: &MatchResult{
+				Matches:   []*Match{{MatchedIssues: []int{1234}, Errors: []string{}}},
+				Candidate: &Match{MatchedIssues: []int{}, Errors: []string{}},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newCandidateJira(t, tc.rejectCandidate)
+			v, err := NewValidator(srv.URL, testActiveJQL, "test@test.com", "secrets", tc.opts...)
+			if err != nil {
+				t.Fatalf("failed to create validator: %v", err)
+			}
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			got, err := v.MatchIssue(ctx, "ABCD-1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("MatchIssue() unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlugin_CandidateStats(t *testing.T) {
+	t.Parallel()
+
+	srv := newCandidateJira(t, false)
+	cfg := &PluginConfig{
+		JIRAEndpoint: srv.URL,
+		Jql:          testActiveJQL,
+		CandidateJql: testCandidateJQL,
+		JIRAAccount:  "test@test.com",
+		IssueBaseURL: "https://example.atlassian.net",
+	}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: jiraCategory, Value: "ABCD-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.GetValid() {
+		t.Errorf("got invalid response %v, decisions must follow the active JQL", resp)
+	}
+
+	if diff := cmp.Diff(CandidateStats{Evaluated: 1, Diverged: 1}, p.CandidateStats()); diff != "" {
+		t.Errorf("CandidateStats() unexpected diff (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+const (
+	// TokenClaimsKey is the key of the claims the plugin contributes to a
+	// justification token, see [TokenClaimsProvider].
+	TokenClaimsKey = "jira"
+
+	// issueSnapshotPrefix prefixes the issue snapshot claim with its hash
+	// algorithm.
+	issueSnapshotPrefix = "sha256:"
+)
+
+// TokenClaimsProvider is implemented by validators that contribute claims
+// to the signed justification token, rather than only to the annotations of
+// the validation response.
+//
+// The JVS API has no token post-processing hook yet. The interface is the
+// surface this plugin offers to one: a JVS server that links the plugin,
+// see the embedded package, can type-assert its validator for it and merge
+// the claims into the token it signs.
+type TokenClaimsProvider interface {
+	// TokenClaims returns the claims for the token issued for a valid
+	// response of the validator, or nil when the response is invalid.
+	TokenClaims(ctx context.Context, req *jvspb.ValidateJustificationRequest, resp *jvspb.ValidateJustificationResponse) (map[string]any, error)
+}
+
+var _ TokenClaimsProvider = (*JiraPlugin)(nil)
+
+// TokenClaims returns the Jira metadata of a valid response under
+// [TokenClaimsKey]: the issue id and URL, the annotation fields, e.g. an
+// approver field rendered as a user, and an issue_snapshot hash over the
+// annotations the decision was made with. Issue ids and URLs are only
+// present when Jira was asked, e.g. not for bypassed requestors.
+func (j *JiraPlugin) TokenClaims(ctx context.Context, req *jvspb.ValidateJustificationRequest, resp *jvspb.ValidateJustificationResponse) (map[string]any, error) {
+	if !resp.GetValid() {
+		return nil, nil
+	}
+
+	annotations := resp.GetAnnotation()
+	claims := map[string]any{
+		"issue_snapshot": issueSnapshot(annotations),
+	}
+	if id, ok := annotations[jiraIssueID]; ok {
+		claims["issue_id"] = id
+	}
+	if url, ok := annotations[jiraIssueURL]; ok {
+		claims["issue_url"] = url
+	}
+	fields := make(map[string]string)
+	for k, v := range annotations {
+		if name, ok := strings.CutPrefix(k, annotationFieldPrefix); ok {
+			fields[name] = v
+		}
+	}
+	if len(fields) > 0 {
+		claims["fields"] = fields
+	}
+	return map[string]any{TokenClaimsKey: claims}, nil
+}
+
+// issueSnapshot returns the hash of the annotations of a decision, leaving
+// out the signature and the diagnostic annotations that differ between
+// validations of the same issue.
+func issueSnapshot(annotations map[string]string) string {
+	stable := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if k == jiraSignedAt || strings.HasPrefix(k, debugAnnotationPrefix) {
+			continue
+		}
+		stable[k] = v
+	}
+	// signaturePayload leaves out the signature.
+	sum := sha256.Sum256(signaturePayload(stable))
+	return issueSnapshotPrefix + hex.EncodeToString(sum[:])
+}
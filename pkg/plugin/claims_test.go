@@ -0,0 +1,82 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+func TestPlugin_TokenClaims(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPlugin(&snapshot{})
+	ctx := context.Background()
+	req := &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	}
+	resp := &jvspb.ValidateJustificationResponse{
+		Valid: true,
+		Annotation: map[string]string{
+			"jira_issue_id":        "1234",
+			"jira_issue_url":       "https://example.atlassian.net/browse/ABCD-1",
+			"jira_field_approver":  "lead@example.com",
+			"jira_signed_at":       "2024-01-01T00:00:00Z",
+			"debug.cache_hit":      "false",
+			"jira_signature":       "v1:abc",
+			"jira_field_component": "payments",
+		},
+	}
+
+	got, err := p.TokenClaims(ctx, req, resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claims, ok := got[TokenClaimsKey].(map[string]any)
+	if !ok {
+		t.Fatalf("got no %q claims in %v", TokenClaimsKey, got)
+	}
+	snapshot, _ := claims["issue_snapshot"].(string)
+	want := map[string]any{
+		"issue_id":       "1234",
+		"issue_url":      "https://example.atlassian.net/browse/ABCD-1",
+		"issue_snapshot": snapshot,
+		"fields":         map[string]string{"approver": "lead@example.com", "component": "payments"},
+	}
+	if diff := cmp.Diff(want, claims); diff != "" {
+		t.Errorf("unexpected claims (-want,+got):\n%s", diff)
+	}
+
+	// The snapshot only changes with the issue metadata.
+	resp.Annotation["jira_signed_at"] = "2024-01-02T00:00:00Z"
+	resp.Annotation["debug.cache_hit"] = "true"
+	resp.Annotation["jira_signature"] = "v1:def"
+	if got := issueSnapshot(resp.Annotation); got != snapshot {
+		t.Errorf("got snapshot %q after signing again, want %q", got, snapshot)
+	}
+	resp.Annotation["jira_field_approver"] = "other@example.com"
+	if got := issueSnapshot(resp.Annotation); got == snapshot {
+		t.Errorf("got the same snapshot after the approver changed")
+	}
+
+	invalid := &jvspb.ValidateJustificationResponse{Valid: false, Error: []string{"closed"}}
+	if got, err := p.TokenClaims(ctx, req, invalid); err != nil || got != nil {
+		t.Errorf("got claims %v, %v for an invalid response, want none", got, err)
+	}
+}
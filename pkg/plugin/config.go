@@ -16,12 +16,23 @@
 package plugin
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/abcxyz/pkg/cli"
 )
 
+// categoryPattern matches a justification category, see
+// [PluginConfig.Category].
+var categoryPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
 // PluginConfig defines the set over environment variables required
 // for running the plugin.
 type PluginConfig struct {
@@ -29,32 +40,463 @@ type PluginConfig struct {
 	// format of:
 	//     https://host:port/context/rest/api-name/api-version
 	//
+	// It is the bare site URL, e.g. https://host:port/context, when
+	// EndpointDiscovery is set.
+	//
 	// [JIRA REST API url]: https://developer.atlassian.com/server/jira/platform/rest-apis/#uri-structure
 	JIRAEndpoint string
 
+	// EndpointDiscovery makes JIRAEndpoint the bare site URL, the REST API
+	// endpoint is discovered from the server info of the site, see
+	// [DiscoverEndpoint]. Jira Cloud sites use the version 3 API, others the
+	// version 2 API.
+	EndpointDiscovery bool
+
+	// RegionalEndpoints are REST API endpoints of other regions of the same
+	// Jira Data Center cluster as JIRAEndpoint. Requests go to the healthy
+	// endpoint with the lowest latency, see [WithRegionalEndpoints].
+	RegionalEndpoints []string
+
+	// RegionProbeInterval is how often the latency of the endpoints is
+	// measured with RegionalEndpoints. Defaults to 30 seconds.
+	RegionProbeInterval time.Duration
+
+	// AllowedHosts are the only hosts, with an optional port, requests are
+	// sent to, see [WithAllowedHosts]. They must include the hosts of
+	// JIRAEndpoint and RegionalEndpoints. Defaults to those hosts.
+	AllowedHosts []string
+
 	// Jql is the [JQL] query specifying validation criteria.
 	//
+	// The placeholder {{.Requestor}} is replaced by the identity of the
+	// requestor as a quoted string, e.g. "assignee = {{.Requestor}}", such
+	// justifications are rejected when the requestor is unknown.
+	//
 	// [JQL]: https://support.atlassian.com/jira-service-management-cloud/docs/use-advanced-search-with-jira-query-language-jql/
 	Jql string
 
+	// CandidateJql is a JQL evaluated in shadow of Jql, so a change of the
+	// validation criteria can be previewed against live traffic. Decisions
+	// always follow Jql, issues only one of them accepts are logged and
+	// counted. Disabled when empty.
+	CandidateJql string
+
+	// IssueTypeJql routes issues of some types to their own JQL instead of
+	// Jql, e.g. "Change=status = Approved; Incident=statusCategory != Done".
+	// Routes are separated by semicolons, issue types are compared
+	// case-insensitively. Disabled when empty, not supported in search mode.
+	IssueTypeJql string
+
+	// ChangeJql is the JQL the change ticket of a dual justification is
+	// validated against, the incident ticket is validated against Jql. It is
+	// required with the dual justification format, see
+	// [JustificationFormatDual].
+	ChangeJql string
+
 	// JIRAAccount is the user name used in [JIRA Basic Auth].
 	//
 	// [JIRA Basic Auth]: https://developer.atlassian.com/cloud/jira/platform/basic-auth-for-rest-apis/
 	JIRAAccount string
 
+	// AccountSecretID is the resource name of the
+	// [SecretVersion][google.cloud.secretmanager.v1.SecretVersion] holding
+	// the JIRAAccount, for orgs treating the account as sensitive. It is
+	// mutually exclusive with JIRAAccount.
+	AccountSecretID string
+
 	// APITokenSecretID is the resource name of the
 	// [SecretVersion][google.cloud.secretmanager.v1.SecretVersion] for the API
 	// token in the format `projects/*/secrets/*/versions/*`.
 	APITokenSecretID string
 
+	// SecretManagerCredentialsFile is the path of the credentials Secret
+	// Manager is accessed with instead of Application Default Credentials,
+	// e.g. a Workload Identity Federation credential configuration, which
+	// holds the audience, when running outside Google Cloud.
+	SecretManagerCredentialsFile string
+
+	// SecretManagerImpersonateServiceAccount is the email of a service
+	// account impersonated to access Secret Manager, with the credentials
+	// file or Application Default Credentials as source credentials.
+	SecretManagerImpersonateServiceAccount string
+
+	// SecondaryAPITokenSecretID is the resource name of the
+	// [SecretVersion][google.cloud.secretmanager.v1.SecretVersion] of a
+	// second API token, tried when Jira rejects the token in use. It allows
+	// rotating the token without downtime, see [WithSecondaryAPIToken].
+	SecondaryAPITokenSecretID string
+
+	// AnnotationSigningKeySecretID is the resource name of the
+	// [SecretVersion][google.cloud.secretmanager.v1.SecretVersion] for the key
+	// signing the annotations of valid responses with an HMAC-SHA256, in the
+	// format `projects/*/secrets/*/versions/*`. Signing is disabled when
+	// empty, see [VerifyAnnotations].
+	AnnotationSigningKeySecretID string
+
+	// BypassTokenSecretID is the resource name of the
+	// [SecretVersion][google.cloud.secretmanager.v1.SecretVersion] of the
+	// emergency bypass token, in the format
+	// `projects/*/secrets/*/versions/*`. A justification "bypass:<token>" is
+	// then accepted without asking Jira, e.g. during a Jira outage, with
+	// loud audit events and the jira_bypass_token annotation. Disabled when
+	// empty.
+	BypassTokenSecretID string
+
+	// Category is the justification category the plugin validates, so that
+	// several plugins, e.g. for several Jira sites, can be installed side by
+	// side. Defaults to "jira", see [Category].
+	Category string
+
 	// DisplaNname is for display, e.g. for the web UI.
 	DisplayName string
 
 	// Hint is for what value to put as the justification.
 	Hint string
 
-	// IssueBaseURL is used to construct a URL that can be clicked.
+	// IssueBaseURL is used to construct a URL that can be clicked. It must be
+	// on the host of JIRAEndpoint.
 	IssueBaseURL string
+
+	// IssueURLTemplate is the [text/template] of the jira_issue_url
+	// annotation, e.g. "{{.BaseURL}}/browse/{{.Key}}?src=jvs". It is given
+	// the IssueBaseURL without trailing slash as .BaseURL, the issue key as
+	// .Key and the issue id as .ID. The URL is "<IssueBaseURL>/browse/<key>"
+	// when empty.
+	IssueURLTemplate string
+
+	// ProjectBaseURLs are the issue base URLs of projects on other Jira
+	// sites, each "<project key>=<base URL>", e.g.
+	// "OPS=https://ops.atlassian.net". The issue URL annotations of the
+	// other projects use IssueBaseURL.
+	ProjectBaseURLs []string
+
+	// AllowHTTP allows JIRAEndpoint and IssueBaseURL to use http rather than
+	// https, e.g. for a Jira only reachable in a private network.
+	AllowHTTP bool
+
+	// JustificationFormat selects how the justification value is parsed into
+	// an issue key, one of "key", "composite" or "json". Defaults to "key".
+	JustificationFormat string
+
+	// MaxValueLength is the maximum number of characters of a justification
+	// value. Longer values are rejected before they are parsed, logged or
+	// sent to Jira. Defaults to 512.
+	MaxValueLength int
+
+	// ValueCharset is the characters a justification value may hold, one of
+	// "printable", valid UTF-8 without control characters, or "ascii",
+	// printable ASCII characters only. Defaults to "printable".
+	ValueCharset string
+
+	// AuditSyslogAddress is the host:port of a syslog collector that receives
+	// every validation decision. Auditing is disabled when empty.
+	AuditSyslogAddress string
+
+	// AuditSyslogNetwork is the transport to the syslog collector, one of
+	// "udp", "tcp" or "tls". Defaults to "udp".
+	AuditSyslogNetwork string
+
+	// AuditFormat is the message format sent to the syslog collector, one of
+	// "cef" or "rfc5424". Defaults to "cef".
+	AuditFormat string
+
+	// AuditSyslogSDID is the SD-ID of the structured data element holding the
+	// decision in rfc5424 messages, e.g. "jvsDecision@12345" with the private
+	// enterprise number of the operator. The decision is written to the
+	// message text when empty.
+	AuditSyslogSDID string
+
+	// CachePath is the file of an on-disk cache of issues that matched the
+	// JQL, so restarts do not cause a burst of Jira requests. Caching is
+	// disabled when empty.
+	CachePath string
+
+	// CacheTTL is how long a cached match is used. Defaults to 5 minutes.
+	CacheTTL time.Duration
+
+	// CacheEncryptionKeySecretIDs are the resource names of the
+	// [SecretVersion][google.cloud.secretmanager.v1.SecretVersion]s of the
+	// keys encrypting the cached matches, at least 32 random bytes each. The
+	// first key encrypts, every key decrypts, so a key is rotated by putting
+	// the new one first. The cache is stored in clear when empty.
+	CacheEncryptionKeySecretIDs []string
+
+	// CacheMaxStaleness is how long after expiring a cached match is still
+	// used, annotated with [AnnotationCacheStale], while a single background
+	// request refreshes it. Disabled when zero, an expired match is then
+	// refreshed before answering.
+	CacheMaxStaleness time.Duration
+
+	// StateBackend is where the decision cache, its refresh locks and the
+	// requestor quota counters are kept, one of "memory" or "redis". With
+	// "redis" they are kept in RedisURL and shared by all the plugin
+	// replicas, and the decision cache is enabled without CachePath.
+	// Defaults to "memory", i.e. the plugin process and the CachePath file.
+	StateBackend string
+
+	// RedisURL is the Redis or Memorystore instance of the redis
+	// StateBackend, e.g. redis://10.0.0.3:6379/0, or rediss:// for TLS.
+	RedisURL string
+
+	// RedisPasswordSecretID is the resource name of the
+	// [SecretVersion][google.cloud.secretmanager.v1.SecretVersion] of the
+	// password, or Memorystore AUTH string, of RedisURL.
+	RedisPasswordSecretID string
+
+	// NotFoundCacheTTL is how long an issue Jira reported missing is
+	// rejected without asking Jira again. Keep it short, an issue created
+	// meanwhile is rejected until it expires. Disabled when zero.
+	NotFoundCacheTTL time.Duration
+
+	// NotFoundCacheSize is the number of missing issues remembered, the
+	// least recently used is forgotten first. Defaults to 1000.
+	NotFoundCacheSize int
+
+	// MatchMode selects how an issue is matched against the JQL, one of
+	// "match" or "search". Defaults to "match".
+	MatchMode string
+
+	// MinPriority rejects issues whose priority is below it, e.g. "High".
+	// Disabled when empty, not supported in search mode.
+	MinPriority string
+
+	// PriorityOrder are the priority names highest first, MinPriority is
+	// compared with. Defaults to the default Jira priority scheme, i.e.
+	// Highest, High, Medium, Low and Lowest.
+	PriorityOrder []string
+
+	// MaxIssueAge rejects issues created longer ago, e.g. 2160h for 90 days.
+	// Disabled when zero, not supported in search mode.
+	MaxIssueAge time.Duration
+
+	// MaxResolvedAge rejects issues resolved longer ago, e.g. 48h.
+	// Unresolved issues are accepted. Disabled when zero, not supported in
+	// search mode.
+	MaxResolvedAge time.Duration
+
+	// ArchivedIssues detects archived issues on Jira Data Center, one of
+	// "allow", accepting them with their archival time annotated, or
+	// "reject". Disabled when empty, not supported in search mode.
+	ArchivedIssues string
+
+	// IssueSnapshotHash annotates valid responses with a hash of the key,
+	// status, assignee and resolution of the issue, see
+	// [IssueSnapshotHash]. Not supported in search mode.
+	IssueSnapshotHash bool
+
+	// FieldConstraints are simple checks of issue fields separated by
+	// semicolons, e.g. "status in [Open, In Progress]; labels contains
+	// approved", a friendlier alternative to Jql for common policies. See
+	// [WithFieldConstraints] for the syntax. Not supported in search mode.
+	FieldConstraints string
+
+	// LinkRules are checks of the issue links separated by semicolons, e.g.
+	// `reject "is blocked by" unresolved`. See [WithLinkRules] for the
+	// syntax. Not supported in search mode.
+	LinkRules string
+
+	// Expression is a Jira expression an issue must evaluate to true for,
+	// e.g. "issue.dueDate != null", see [WithExpression]. Not supported in
+	// search mode.
+	Expression string
+
+	// AccountIDs replaces the requestor placeholder of the JQL with the Jira
+	// account id of the requestor instead of the email, for Jira Cloud sites
+	// that do not match emails in JQL, see [WithAccountIDs].
+	AccountIDs bool
+
+	// AccountCacheTTL is how long the account id of a requestor is cached
+	// with AccountIDs. Defaults to 1h.
+	AccountCacheTTL time.Duration
+
+	// IdentityDomainRewrites map requestors to Jira users by the domain of
+	// their email, e.g. "example.com=corp.example.com", see
+	// [NewDomainRewriteMapper].
+	IdentityDomainRewrites []string
+
+	// IdentityMappingFile is a CSV file of requestors and their Jira users,
+	// see [NewStaticIdentityMapper]. Requestors missing from it are mapped
+	// with IdentityDomainRewrites.
+	IdentityMappingFile string
+
+	// IdentityMappingSecretID is the resource name of the
+	// [SecretVersion][google.cloud.secretmanager.v1.SecretVersion] holding
+	// the CSV of IdentityMappingFile instead. It is ignored by
+	// [NewJiraPluginWithToken].
+	IdentityMappingSecretID string
+
+	// AnnotationFields are jira issue fields, e.g. "summary" or
+	// "customfield_10010", copied into the annotation map of the
+	// justification as "jira_field_<name>". Only these fields are requested
+	// from jira. A field may name its renderer after a colon, e.g.
+	// "customfield_10010:user", see [WithAnnotationFieldRenderers].
+	AnnotationFields []string
+
+	// AnnotationFieldMaxBytes limits the size of each annotation field value,
+	// longer values are truncated. Defaults to 256.
+	AnnotationFieldMaxBytes ByteSize
+
+	// FieldRedactions are redaction rules of AnnotationFields separated by
+	// semicolons, e.g. "summary max 80; summary projects [OPS]", keeping
+	// sensitive data out of signed tokens and audit logs. See
+	// [WithFieldRedactions] for the syntax.
+	FieldRedactions string
+
+	// QuotaRate limits the validations per second, so a noisy client such as
+	// CI automation cannot starve everyone else. Validations over the quota
+	// are rejected. Unlimited when zero.
+	QuotaRate float64
+
+	// QuotaBurst is the number of validations allowed at once on top of
+	// QuotaRate. Defaults to QuotaRate rounded up.
+	QuotaBurst int
+
+	// QuotaMaxConcurrent limits the validations in flight. Unlimited when
+	// zero.
+	QuotaMaxConcurrent int
+
+	// RequestorQuota is the number of successful validations a requestor may
+	// perform per RequestorQuotaWindow, to catch abuse such as scripted
+	// access with a single evergreen ticket. The requestor must be sent by
+	// the JVS server, see [Requestor]. Unlimited when zero.
+	RequestorQuota int
+
+	// RequestorQuotaWindow is the rolling window of RequestorQuota. Defaults
+	// to 24 hours.
+	RequestorQuotaWindow time.Duration
+
+	// RequestorQuotaMode is what happens to validations of a requestor over
+	// RequestorQuota, one of "reject" or "warn". Defaults to "reject".
+	RequestorQuotaMode string
+
+	// FreezeWindows are the recurring freeze windows, separated by
+	// semicolons. Each is a cron expression for its start, with the minute,
+	// hour, day of month, month and day of week, followed by its duration,
+	// e.g. "0 18 * * FRI 62h" for weekends from Friday 18:00 to Monday 8:00.
+	// During a freeze only issues matching FreezeJql are accepted. Disabled
+	// when empty.
+	FreezeWindows string
+
+	// FreezeTimezone is the IANA time zone of FreezeWindows. Defaults to
+	// SiteTimezone.
+	FreezeTimezone string
+
+	// SiteTimezone is the IANA time zone of the Jira site, the time zone of
+	// date fields and of date-time values without one. Defaults to UTC.
+	SiteTimezone string
+
+	// ClockSkew is the skew between the Jira and plugin clocks tolerated by
+	// the time-based checks, in favor of the issue, see [WithSiteClock].
+	ClockSkew time.Duration
+
+	// FreezeJql is the emergency JQL an issue must match in addition to Jql
+	// during a freeze. No issue is accepted during a freeze when empty.
+	FreezeJql string
+
+	// BypassRequestors are the identities and groups of requestors whose
+	// justifications are accepted without asking Jira, e.g. break-glass
+	// service accounts. Bypassed justifications are flagged in the annotation
+	// map and audited as such. The requestor must be sent by the JVS server,
+	// see [Requestor].
+	BypassRequestors []string
+
+	// DebugAnnotations adds diagnostic annotations prefixed with "debug." to
+	// the responses: the validation latency, the number of Jira requests and
+	// whether the match was served from the decision cache.
+	DebugAnnotations bool
+
+	// ExplainAnnotation adds the decision tree of the validation as JSON to
+	// the "jira_explanation" annotation of the responses to requests whose
+	// justification annotation "jira_explain" is "true", see [Explanation].
+	ExplainAnnotation bool
+
+	// WrongCategoryError fails requests for a justification category other
+	// than [Category] with an InvalidArgument status instead of an invalid
+	// response annotated with [ErrorCodeWrongCategory].
+	WrongCategoryError bool
+
+	// MessageLocale is the locale of the user-facing rejection messages for
+	// requests without a locale, see [RequestorLocaleMetadataKey]. When set,
+	// invalid responses hold a message from the catalog instead of the
+	// internal details, which are logged, and are annotated with the
+	// rejection code. Empty keeps the internal details.
+	MessageLocale string
+
+	// Messages override the messages of the catalog, separated by
+	// semicolons, e.g. "de:no_match=Das Ticket {issue} ist nicht
+	// freigegeben". {issue} is replaced with the issue key and {until} with
+	// the end of the deploy freeze. Requires MessageLocale.
+	Messages string
+
+	// ReplayBufferSize is the number of failed validations kept in memory
+	// with the Jira requests and responses they involved, credentials
+	// removed, see [JiraPlugin.DumpReplay]. Disabled when zero.
+	ReplayBufferSize int
+
+	// DiagnosticDecisions is the number of recent decisions kept in memory
+	// for the diagnostic dump, see [JiraPlugin.Diagnostics]. Disabled when
+	// zero.
+	DiagnosticDecisions int
+
+	// WarmupProjects are the keys of projects the account must be able to
+	// browse, checked by [JiraPlugin.Warmup].
+	WarmupProjects []string
+
+	// HTTPRetries is the number of retries of a Jira request that failed in
+	// transit or got a 5xx response, see [WithRetries].
+	HTTPRetries int
+
+	// MaxRedirects is how many same-host redirects a Jira request follows,
+	// see [WithMaxRedirects]. Defaults to 3.
+	MaxRedirects int
+
+	// FaultInjectionRate is the fraction of Jira requests failed on purpose
+	// with a 503 response, to test how a deployment copes with Jira failing,
+	// see [WithFaultInjection]. Disabled when zero.
+	FaultInjectionRate float64
+
+	// InsecureSkipVerify disables the verification of the TLS certificate of
+	// Jira, for test instances with a self-signed certificate, see
+	// [WithInsecureSkipVerify]. The decisions are annotated with
+	// jira_insecure_transport.
+	InsecureSkipVerify bool
+
+	// DecisionStream serves every decision to the subscribers of
+	// [DecisionStreamMethod] on the gRPC server of the plugin.
+	DecisionStream bool
+
+	// FeatureFlags turn gated subsystems on or off, as name=on or name=off,
+	// e.g. "cache=off". Every flag is on by default. They can be overridden
+	// at runtime through the admin service, see [JiraPlugin.SetFeatureFlag].
+	FeatureFlags []string
+
+	// SampleRate is the fraction of the validation decisions captured with
+	// their issue snapshot and policy evaluation to SampleDestination, for
+	// a periodic human review of the criteria. Disabled when zero.
+	SampleRate float64
+
+	// SampleDestination is where the decision samples are written, a
+	// gs://bucket/prefix URL or a local directory, see [DecisionSample].
+	SampleDestination string
+
+	// RevocationDestination receives a [Revocation] for every accepted
+	// decision whose issue no longer matches when it is validated again, an
+	// https URL of a JVS revocation or denylist API, or a Pub/Sub topic
+	// "projects/<project>/topics/<topic>". Disabled when empty.
+	RevocationDestination string
+
+	// RevocationAudience is the audience of the Google ID token sent to a
+	// RevocationDestination URL. Requests are not authenticated when empty.
+	RevocationAudience string
+
+	// RevocationInterval is how often the accepted decisions are validated
+	// again. Defaults to 5 minutes.
+	RevocationInterval time.Duration
+
+	// RevocationWindow is how long an accepted decision is validated again,
+	// it should be the longest lifetime of a JVS token. Defaults to 24
+	// hours.
+	RevocationWindow time.Duration
 }
 
 // Validate checks if the config is valid.
@@ -63,42 +505,635 @@ func (cfg *PluginConfig) Validate() error {
 
 	if cfg.JIRAEndpoint == "" {
 		merr = errors.Join(merr, fmt.Errorf("empty JIRA_PLUGIN_ENDPOINT"))
+	} else if _, err := parseEndpoint(cfg.JIRAEndpoint); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ENDPOINT: %w", err))
+	} else if cfg.EndpointDiscovery && !isSiteURL(cfg.JIRAEndpoint) {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ENDPOINT %s, must be the site url with JIRA_PLUGIN_ENDPOINT_DISCOVERY", cfg.JIRAEndpoint))
+	}
+
+	for _, endpoint := range cfg.RegionalEndpoints {
+		u, err := parseEndpoint(endpoint)
+		if err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REGIONAL_ENDPOINTS: %w", err))
+		} else if u.Scheme != "https" && !cfg.AllowHTTP {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REGIONAL_ENDPOINTS %s, must use https unless JIRA_PLUGIN_ALLOW_HTTP is set", endpoint))
+		}
+	}
+	if len(cfg.RegionalEndpoints) > 0 && cfg.EndpointDiscovery {
+		merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_REGIONAL_ENDPOINTS cannot be used with JIRA_PLUGIN_ENDPOINT_DISCOVERY"))
+	}
+	if cfg.RegionProbeInterval < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REGION_PROBE_INTERVAL %s, must be positive", cfg.RegionProbeInterval))
+	}
+	if len(cfg.AllowedHosts) > 0 {
+		merr = errors.Join(merr, cfg.validateAllowedHosts())
 	}
 
 	if cfg.Jql == "" {
 		merr = errors.Join(merr, fmt.Errorf("empty JIRA_PLUGIN_JQL"))
 	}
 
-	if cfg.JIRAAccount == "" {
-		merr = errors.Join(merr, fmt.Errorf("empty JIRA_PLUGIN_ACCOUNT"))
+	if cfg.JIRAAccount == "" && cfg.AccountSecretID == "" {
+		merr = errors.Join(merr, fmt.Errorf("empty JIRA_PLUGIN_ACCOUNT and JIRA_PLUGIN_ACCOUNT_SECRET_ID"))
+	} else if cfg.JIRAAccount != "" && cfg.AccountSecretID != "" {
+		merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_ACCOUNT and JIRA_PLUGIN_ACCOUNT_SECRET_ID are mutually exclusive"))
 	}
 
 	if cfg.APITokenSecretID == "" {
 		merr = errors.Join(merr, fmt.Errorf("empty JIRA_PLUGIN_API_TOKEN_SECRET_ID"))
 	}
 
+	if cfg.SecretManagerCredentialsFile != "" {
+		if _, err := os.Stat(cfg.SecretManagerCredentialsFile); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_SECRET_MANAGER_CREDENTIALS_FILE: %w", err))
+		}
+	}
+
+	if cfg.Category != "" && !categoryPattern.MatchString(cfg.Category) {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_CATEGORY %q, must be letters, digits, ., _ and -", cfg.Category))
+	}
+
 	if cfg.Hint == "" {
 		merr = errors.Join(merr, fmt.Errorf("empty JIRA_PLUGIN_HINT"))
 	}
 
 	if cfg.IssueBaseURL == "" {
 		merr = errors.Join(merr, fmt.Errorf("empty JIRA_PLUGIN_ISSUE_BASE_URL"))
+	} else if u, err := url.Parse(cfg.IssueBaseURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ISSUE_BASE_URL %s, must be an http or https url", cfg.IssueBaseURL))
+	}
+
+	if _, err := parseIssueURLTemplate(cfg.IssueURLTemplate); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ISSUE_URL_TEMPLATE: %w", err))
+	}
+
+	if _, err := parseProjectBaseURLs(cfg.ProjectBaseURLs); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_PROJECT_BASE_URLS: %w", err))
+	}
+
+	if err := cfg.validateURLs(); err != nil {
+		merr = errors.Join(merr, err)
+	}
+
+	if cfg.JustificationFormat == JustificationFormatDual && cfg.ChangeJql == "" {
+		merr = errors.Join(merr, fmt.Errorf("empty JIRA_PLUGIN_CHANGE_JQL, required with JIRA_PLUGIN_JUSTIFICATION_FORMAT=dual"))
+	}
+	if cfg.JustificationFormat != JustificationFormatDual && cfg.ChangeJql != "" {
+		merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_CHANGE_JQL requires JIRA_PLUGIN_JUSTIFICATION_FORMAT=dual"))
+	}
+	if _, err := NewJustificationParser(cfg.JustificationFormat); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_JUSTIFICATION_FORMAT: %w", err))
+	}
+	if cfg.MaxValueLength < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_MAX_VALUE_LENGTH %d, must be positive", cfg.MaxValueLength))
+	}
+	switch cfg.ValueCharset {
+	case "", ValueCharsetPrintable, ValueCharsetASCII:
+	default:
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_VALUE_CHARSET %q, must be one of printable, ascii", cfg.ValueCharset))
+	}
+
+	if cfg.AuditSyslogAddress != "" {
+		switch cfg.AuditSyslogNetwork {
+		case "", "udp", "tcp", "tls":
+		default:
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_AUDIT_SYSLOG_NETWORK %q, must be one of udp, tcp, tls", cfg.AuditSyslogNetwork))
+		}
+
+		switch cfg.AuditFormat {
+		case "", AuditFormatCEF, AuditFormatRFC5424:
+		default:
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_AUDIT_FORMAT %q, must be one of cef, rfc5424", cfg.AuditFormat))
+		}
+
+		if cfg.AuditSyslogSDID != "" && !sdIDPattern.MatchString(cfg.AuditSyslogSDID) {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_AUDIT_SYSLOG_SD_ID %q, must be name@<private enterprise number>", cfg.AuditSyslogSDID))
+		}
+	}
+
+	if _, err := parseIssueTypeJQL(cfg.IssueTypeJql); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ISSUE_TYPE_JQL: %w", err))
+	}
+
+	switch cfg.MatchMode {
+	case "", MatchModeMatch:
+	case MatchModeSearch:
+		if cfg.IssueTypeJql != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_ISSUE_TYPE_JQL cannot be used with JIRA_PLUGIN_MATCH_MODE=search"))
+		}
+		if cfg.MinPriority != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_MIN_PRIORITY cannot be used with JIRA_PLUGIN_MATCH_MODE=search"))
+		}
+		if cfg.MaxIssueAge > 0 || cfg.MaxResolvedAge > 0 {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_MAX_ISSUE_AGE and JIRA_PLUGIN_MAX_RESOLVED_AGE cannot be used with JIRA_PLUGIN_MATCH_MODE=search"))
+		}
+		if cfg.ArchivedIssues != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_ARCHIVED_ISSUES cannot be used with JIRA_PLUGIN_MATCH_MODE=search"))
+		}
+		if cfg.IssueSnapshotHash {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_ISSUE_SNAPSHOT_HASH cannot be used with JIRA_PLUGIN_MATCH_MODE=search"))
+		}
+		if cfg.FieldConstraints != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_FIELD_CONSTRAINTS cannot be used with JIRA_PLUGIN_MATCH_MODE=search"))
+		}
+		if cfg.LinkRules != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_LINK_RULES cannot be used with JIRA_PLUGIN_MATCH_MODE=search"))
+		}
+		if cfg.Expression != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_EXPRESSION cannot be used with JIRA_PLUGIN_MATCH_MODE=search"))
+		}
+		if err := checkComposableJQL(cfg.Jql); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_JQL cannot be used with JIRA_PLUGIN_MATCH_MODE=search: %w", err))
+		}
+		if cfg.ChangeJql != "" {
+			if err := checkComposableJQL(cfg.ChangeJql); err != nil {
+				merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_CHANGE_JQL cannot be used with JIRA_PLUGIN_MATCH_MODE=search: %w", err))
+			}
+		}
+		if cfg.CandidateJql != "" {
+			if err := checkComposableJQL(cfg.CandidateJql); err != nil {
+				merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_CANDIDATE_JQL cannot be used with JIRA_PLUGIN_MATCH_MODE=search: %w", err))
+			}
+		}
+	default:
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_MATCH_MODE %q, must be one of match, search", cfg.MatchMode))
+	}
+
+	if cfg.MaxIssueAge < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_MAX_ISSUE_AGE %s, must be positive", cfg.MaxIssueAge))
+	}
+	if cfg.MaxResolvedAge < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_MAX_RESOLVED_AGE %s, must be positive", cfg.MaxResolvedAge))
+	}
+	switch cfg.ArchivedIssues {
+	case "", ArchivedIssuesAllow, ArchivedIssuesReject:
+	default:
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ARCHIVED_ISSUES %q, must be one of allow, reject", cfg.ArchivedIssues))
+	}
+
+	if cfg.MinPriority != "" {
+		if _, err := newMinPriorityCheck(cfg.MinPriority, cfg.PriorityOrder); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_MIN_PRIORITY: %w", err))
+		}
+	}
+
+	for _, s := range splitFieldConstraints(cfg.FieldConstraints) {
+		if _, err := parseFieldConstraint(s); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_FIELD_CONSTRAINTS: %w", err))
+		}
+	}
+
+	for _, s := range splitFieldConstraints(cfg.LinkRules) {
+		if _, err := parseLinkRule(s); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_LINK_RULES: %w", err))
+		}
+	}
+
+	if cfg.MessageLocale != "" {
+		if _, err := newMessageCatalog(cfg.MessageLocale, splitFieldConstraints(cfg.Messages)); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_MESSAGE_LOCALE or JIRA_PLUGIN_MESSAGES: %w", err))
+		}
+	} else if cfg.Messages != "" {
+		merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_MESSAGES requires JIRA_PLUGIN_MESSAGE_LOCALE"))
+	}
+
+	for _, field := range cfg.AnnotationFields {
+		name, renderer := splitAnnotationField(field)
+		if !fieldNamePattern.MatchString(name) {
+			merr = errors.Join(merr, fmt.Errorf("invalid jira field name %q in JIRA_PLUGIN_ANNOTATION_FIELDS", name))
+		}
+		if _, ok := fieldRenderers[renderer]; renderer != "" && !ok {
+			merr = errors.Join(merr, fmt.Errorf("invalid renderer %q of field %q in JIRA_PLUGIN_ANNOTATION_FIELDS, must be one of text, user, option, date", renderer, name))
+		}
+	}
+
+	if cfg.FieldRedactions != "" {
+		redactions, err := parseFieldRedactions(splitFieldConstraints(cfg.FieldRedactions))
+		if err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_FIELD_REDACTIONS: %w", err))
+		}
+		names := cfg.AnnotationFieldNames()
+		for name := range redactions {
+			if !slices.Contains(names, name) {
+				merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_FIELD_REDACTIONS, field %q is not in JIRA_PLUGIN_ANNOTATION_FIELDS", name))
+			}
+		}
+	}
+
+	if cfg.AnnotationFieldMaxBytes < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ANNOTATION_FIELD_MAX_BYTES %d, must be positive", cfg.AnnotationFieldMaxBytes))
+	}
+
+	if cfg.QuotaRate < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_QUOTA_RATE %v, must be positive", cfg.QuotaRate))
+	}
+
+	if cfg.QuotaBurst < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_QUOTA_BURST %d, must be positive", cfg.QuotaBurst))
+	}
+
+	if cfg.QuotaMaxConcurrent < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_QUOTA_MAX_CONCURRENT %d, must be positive", cfg.QuotaMaxConcurrent))
+	}
+
+	if cfg.RequestorQuota < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REQUESTOR_QUOTA %d, must be positive", cfg.RequestorQuota))
+	}
+
+	if cfg.RequestorQuotaWindow < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REQUESTOR_QUOTA_WINDOW %s, must be positive", cfg.RequestorQuotaWindow))
+	}
+
+	switch cfg.RequestorQuotaMode {
+	case "", RequestorQuotaModeReject, RequestorQuotaModeWarn:
+	default:
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REQUESTOR_QUOTA_MODE %q, must be one of reject, warn", cfg.RequestorQuotaMode))
+	}
+
+	if _, err := newJiraClock(cfg.SiteTimezone, cfg.ClockSkew); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_SITE_TIMEZONE or JIRA_PLUGIN_CLOCK_SKEW: %w", err))
+	}
+	if _, err := newFreezePolicy(cfg); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_FREEZE_WINDOWS or JIRA_PLUGIN_FREEZE_TIMEZONE: %w", err))
+	}
+
+	if cfg.FreezeJql != "" {
+		// Both are combined into the JQL used during a freeze.
+		if err := checkComposableJQL(cfg.Jql); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_JQL cannot be combined with JIRA_PLUGIN_FREEZE_JQL: %w", err))
+		}
+		if err := checkComposableJQL(cfg.FreezeJql); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_FREEZE_JQL: %w", err))
+		}
+	}
+
+	if cfg.ReplayBufferSize < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REPLAY_BUFFER_SIZE %d, must be positive", cfg.ReplayBufferSize))
+	}
+
+	if cfg.DiagnosticDecisions < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_DIAGNOSTIC_DECISIONS %d, must be positive", cfg.DiagnosticDecisions))
+	}
+
+	if cfg.CacheTTL < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_CACHE_TTL %s, must be positive", cfg.CacheTTL))
+	}
+
+	if len(cfg.CacheEncryptionKeySecretIDs) > 0 && !cfg.cacheEnabled() {
+		merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_CACHE_ENCRYPTION_KEY_SECRET_IDS requires JIRA_PLUGIN_CACHE_PATH or JIRA_PLUGIN_STATE_BACKEND=redis"))
+	}
+
+	switch cfg.StateBackend {
+	case "", StateBackendMemory:
+		if cfg.RedisURL != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_REDIS_URL requires JIRA_PLUGIN_STATE_BACKEND=redis"))
+		}
+	case StateBackendRedis:
+		if cfg.RedisURL == "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_STATE_BACKEND=redis requires JIRA_PLUGIN_REDIS_URL"))
+		} else if _, err := parseRedisURL(cfg.RedisURL); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REDIS_URL: %w", err))
+		}
+		if cfg.CachePath != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_CACHE_PATH cannot be used with JIRA_PLUGIN_STATE_BACKEND=redis"))
+		}
+	default:
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_STATE_BACKEND %q, must be one of memory, redis", cfg.StateBackend))
+	}
+	if cfg.RedisPasswordSecretID != "" && cfg.RedisURL == "" {
+		merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_REDIS_PASSWORD_SECRET_ID requires JIRA_PLUGIN_REDIS_URL"))
+	}
+
+	if cfg.CacheMaxStaleness < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_CACHE_MAX_STALENESS %s, must be positive", cfg.CacheMaxStaleness))
+	}
+
+	if cfg.IdentityMappingFile != "" && cfg.IdentityMappingSecretID != "" {
+		merr = errors.Join(merr, fmt.Errorf("only one of JIRA_PLUGIN_IDENTITY_MAPPING_FILE and JIRA_PLUGIN_IDENTITY_MAPPING_SECRET_ID may be set"))
+	}
+	if len(cfg.IdentityDomainRewrites) > 0 || cfg.IdentityMappingFile != "" {
+		if _, err := cfg.identityMapper(""); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_IDENTITY_DOMAIN_REWRITES or JIRA_PLUGIN_IDENTITY_MAPPING_FILE: %w", err))
+		}
+	}
+
+	if cfg.AccountCacheTTL < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ACCOUNT_CACHE_TTL %s, must be positive", cfg.AccountCacheTTL))
+	}
+
+	if cfg.NotFoundCacheTTL < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_NOT_FOUND_CACHE_TTL %s, must be positive", cfg.NotFoundCacheTTL))
+	}
+	if cfg.NotFoundCacheSize < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_NOT_FOUND_CACHE_SIZE %d, must be positive", cfg.NotFoundCacheSize))
+	}
+
+	for _, key := range cfg.WarmupProjects {
+		if !projectKeyPattern.MatchString(key) {
+			merr = errors.Join(merr, fmt.Errorf("invalid jira project key %q in JIRA_PLUGIN_WARMUP_PROJECTS", key))
+		}
+	}
+
+	if cfg.HTTPRetries < 0 || cfg.HTTPRetries > maxRetries {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_HTTP_RETRIES %d, must be between 0 and %d", cfg.HTTPRetries, maxRetries))
+	}
+
+	if cfg.MaxRedirects < 0 || cfg.MaxRedirects > maxMaxRedirects {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_MAX_REDIRECTS %d, must be between 0 and %d", cfg.MaxRedirects, maxMaxRedirects))
+	}
+
+	if cfg.FaultInjectionRate < 0 || cfg.FaultInjectionRate > 1 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_FAULT_INJECTION_RATE %v, must be between 0 and 1", cfg.FaultInjectionRate))
+	}
+
+	if _, err := parseFeatureFlags(cfg.FeatureFlags); err != nil {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_FEATURE_FLAGS: %w", err))
+	}
+
+	if cfg.SampleRate < 0 || cfg.SampleRate > 1 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_SAMPLE_RATE %v, must be between 0 and 1", cfg.SampleRate))
+	}
+	if cfg.SampleRate > 0 && cfg.SampleDestination == "" {
+		merr = errors.Join(merr, fmt.Errorf("missing JIRA_PLUGIN_SAMPLE_DESTINATION, required with JIRA_PLUGIN_SAMPLE_RATE"))
+	}
+	if cfg.SampleDestination == gcsScheme || strings.HasPrefix(cfg.SampleDestination, gcsScheme+"/") {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_SAMPLE_DESTINATION %q, missing bucket", cfg.SampleDestination))
+	}
+
+	if err := cfg.validateRevocation(); err != nil {
+		merr = errors.Join(merr, err)
 	}
 
 	return merr
 }
 
+// validateRevocation checks the RevocationDestination and its settings.
+func (cfg *PluginConfig) validateRevocation() error {
+	var merr error
+	if cfg.RevocationInterval < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REVOCATION_INTERVAL %s, must not be negative", cfg.RevocationInterval))
+	}
+	if cfg.RevocationWindow < 0 {
+		merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REVOCATION_WINDOW %s, must not be negative", cfg.RevocationWindow))
+	}
+
+	dest := cfg.RevocationDestination
+	switch {
+	case dest == "":
+		if cfg.RevocationAudience != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_REVOCATION_AUDIENCE requires a JIRA_PLUGIN_REVOCATION_DESTINATION url"))
+		}
+	case pubsubTopicPattern.MatchString(dest):
+		if cfg.RevocationAudience != "" {
+			merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_REVOCATION_AUDIENCE cannot be used with a pub/sub topic"))
+		}
+	default:
+		u, err := url.Parse(dest)
+		switch {
+		case err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http"):
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REVOCATION_DESTINATION %q, must be an https url or a projects/<project>/topics/<topic> pub/sub topic", redactURL(dest)))
+		case u.Scheme == "http" && !cfg.AllowHTTP:
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_REVOCATION_DESTINATION %s, must use https unless JIRA_PLUGIN_ALLOW_HTTP is set", redactURL(dest)))
+		}
+	}
+	return merr
+}
+
+// account returns the JIRAAccount, or fetches it with accessSecret when
+// AccountSecretID is set. Surrounding whitespace of the secret, like a
+// trailing newline, is removed.
+func (cfg *PluginConfig) account(ctx context.Context, accessSecret func(context.Context, string) (string, error)) (string, error) {
+	if cfg.AccountSecretID == "" {
+		return cfg.JIRAAccount, nil
+	}
+	account, err := accessSecret(ctx, cfg.AccountSecretID)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch account: %w", err)
+	}
+	return strings.TrimSpace(account), nil
+}
+
+// atlassianAPIHost is the host of the Atlassian API gateway, whose Jira
+// endpoints are served for another site host.
+const atlassianAPIHost = "api.atlassian.com"
+
+// validateURLs checks that the endpoint and the issue base URL use https,
+// unless AllowHTTP is set, and point at the same Jira site, so annotations
+// do not link to another site. It only reports what the other checks of
+// [PluginConfig.Validate] did not, i.e. nothing for malformed URLs.
+func (cfg *PluginConfig) validateURLs() error {
+	endpoint, err := parseEndpoint(cfg.JIRAEndpoint)
+	if err != nil {
+		return nil //nolint:nilerr // Reported by Validate
+	}
+	base, err := url.Parse(cfg.IssueBaseURL)
+	if err != nil || base.Host == "" {
+		return nil //nolint:nilerr // Reported by Validate
+	}
+
+	var merr error
+	if !cfg.AllowHTTP {
+		if endpoint.Scheme != "https" {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ENDPOINT %s, must use https unless JIRA_PLUGIN_ALLOW_HTTP is set", cfg.JIRAEndpoint))
+		}
+		if base.Scheme != "https" {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ISSUE_BASE_URL %s, must use https unless JIRA_PLUGIN_ALLOW_HTTP is set", cfg.IssueBaseURL))
+		}
+	}
+	if !cfg.AllowHTTP {
+		for _, rule := range cfg.ProjectBaseURLs {
+			project, baseURL, _ := strings.Cut(rule, "=")
+			if u, err := url.Parse(strings.TrimSpace(baseURL)); err == nil && u.Scheme == "http" {
+				merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_PROJECT_BASE_URLS base url %s of project %s, must use https unless JIRA_PLUGIN_ALLOW_HTTP is set",
+					strings.TrimSpace(baseURL), strings.ToUpper(strings.TrimSpace(project))))
+			}
+		}
+	}
+	if !strings.EqualFold(endpoint.Hostname(), atlassianAPIHost) && !strings.EqualFold(endpoint.Hostname(), base.Hostname()) {
+		merr = errors.Join(merr, fmt.Errorf("JIRA_PLUGIN_ENDPOINT host %q and JIRA_PLUGIN_ISSUE_BASE_URL host %q differ, annotations would link to another jira site",
+			endpoint.Hostname(), base.Hostname()))
+	}
+	return merr
+}
+
+// validateAllowedHosts checks that the allowed hosts are hosts with an
+// optional port and include the hosts of the endpoints, so that a tampered
+// endpoint is reported before any request. Malformed endpoints are reported
+// by [PluginConfig.Validate].
+func (cfg *PluginConfig) validateAllowedHosts() error {
+	var merr error
+	allowed := make([]string, 0, len(cfg.AllowedHosts))
+	for _, host := range cfg.AllowedHosts {
+		h, err := parseAllowedHost(host)
+		if err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid JIRA_PLUGIN_ALLOWED_HOSTS: %w", err))
+			continue
+		}
+		allowed = append(allowed, h)
+	}
+	for _, endpoint := range append([]string{cfg.JIRAEndpoint}, cfg.RegionalEndpoints...) {
+		u, err := parseEndpoint(endpoint)
+		if err != nil {
+			continue
+		}
+		if !hostAllowed(allowed, u) {
+			merr = errors.Join(merr, fmt.Errorf("endpoint host %q is not in JIRA_PLUGIN_ALLOWED_HOSTS", u.Host))
+		}
+	}
+	return merr
+}
+
+// AnnotationFieldNames returns the names of the annotation fields, without
+// their renderers.
+func (cfg *PluginConfig) AnnotationFieldNames() []string {
+	names := make([]string, 0, len(cfg.AnnotationFields))
+	for _, field := range cfg.AnnotationFields {
+		name, _ := splitAnnotationField(field)
+		names = append(names, name)
+	}
+	return names
+}
+
+// annotationFieldRenderers returns the renderers of the annotation fields
+// that have one.
+func (cfg *PluginConfig) annotationFieldRenderers() map[string]string {
+	renderers := make(map[string]string)
+	for _, field := range cfg.AnnotationFields {
+		if name, renderer := splitAnnotationField(field); renderer != "" {
+			renderers[name] = renderer
+		}
+	}
+	return renderers
+}
+
+// validatorOptions returns the options for the [Validator] of the config.
+func (cfg *PluginConfig) validatorOptions() []ValidatorOption {
+	var opts []ValidatorOption
+	if cfg.MatchMode == MatchModeSearch {
+		opts = append(opts, WithSearchMode())
+	}
+	if cfg.HTTPRetries > 0 {
+		opts = append(opts, WithRetries(cfg.HTTPRetries))
+	}
+	if cfg.MaxRedirects > 0 {
+		opts = append(opts, WithMaxRedirects(cfg.MaxRedirects))
+	}
+	if cfg.FaultInjectionRate > 0 {
+		opts = append(opts, WithFaultInjection(cfg.FaultInjectionRate))
+	}
+	if cfg.InsecureSkipVerify {
+		opts = append(opts, WithInsecureSkipVerify())
+	}
+	if len(cfg.RegionalEndpoints) > 0 {
+		opts = append(opts, WithRegionalEndpoints(cfg.RegionalEndpoints, cfg.RegionProbeInterval))
+	}
+	if len(cfg.AllowedHosts) > 0 {
+		opts = append(opts, WithAllowedHosts(cfg.AllowedHosts))
+	}
+	if len(cfg.AnnotationFields) > 0 {
+		opts = append(opts, WithAnnotationFields(cfg.AnnotationFieldNames(), int(cfg.AnnotationFieldMaxBytes)))
+		if renderers := cfg.annotationFieldRenderers(); len(renderers) > 0 {
+			opts = append(opts, WithAnnotationFieldRenderers(renderers))
+		}
+		if cfg.FieldRedactions != "" {
+			opts = append(opts, WithFieldRedactions(splitFieldConstraints(cfg.FieldRedactions)))
+		}
+	}
+	if cfg.CandidateJql != "" {
+		opts = append(opts, WithCandidateJQL(cfg.CandidateJql))
+	}
+	if cfg.IssueTypeJql != "" {
+		opts = append(opts, WithIssueTypeJQL(cfg.issueTypeJQLs()))
+	}
+	if cfg.SiteTimezone != "" || cfg.ClockSkew > 0 {
+		opts = append(opts, WithSiteClock(cfg.SiteTimezone, cfg.ClockSkew))
+	}
+	if cfg.MinPriority != "" {
+		opts = append(opts, WithMinPriority(cfg.MinPriority, cfg.PriorityOrder))
+	}
+	if cfg.MaxIssueAge > 0 || cfg.MaxResolvedAge > 0 {
+		opts = append(opts, WithMaxIssueAge(cfg.MaxIssueAge, cfg.MaxResolvedAge))
+	}
+	if cfg.ArchivedIssues != "" {
+		opts = append(opts, WithArchivedIssues(cfg.ArchivedIssues))
+	}
+	if cfg.IssueSnapshotHash {
+		opts = append(opts, WithIssueSnapshotHash())
+	}
+	if cfg.FieldConstraints != "" {
+		opts = append(opts, WithFieldConstraints(splitFieldConstraints(cfg.FieldConstraints)))
+	}
+	if cfg.LinkRules != "" {
+		opts = append(opts, WithLinkRules(splitFieldConstraints(cfg.LinkRules)))
+	}
+	if cfg.Expression != "" {
+		opts = append(opts, WithExpression(cfg.Expression))
+	}
+	if cfg.AccountIDs {
+		opts = append(opts, WithAccountIDs(cfg.AccountCacheTTL))
+	}
+	if len(cfg.IdentityDomainRewrites) > 0 || cfg.IdentityMappingFile != "" {
+		// A mapping secret is read by newValidatorFromSecrets.
+		opts = append(opts, withConfigIdentityMapper(cfg, ""))
+	}
+	if cfg.ReplayBufferSize > 0 {
+		opts = append(opts, withReplay())
+	}
+	return opts
+}
+
 // ToFlags binds the config to the give [cli.FlagSet] and returns it.
 func (cfg *PluginConfig) ToFlags(set *cli.FlagSet) *cli.FlagSet {
 	// Command options
 	f := set.NewSection("JIRA PLUGIN OPTIONS")
+	typed := newTypedFlags(set, f)
 
 	f.StringVar(&cli.StringVar{
 		Name:    "jira-plugin-endpoint",
 		Target:  &cfg.JIRAEndpoint,
 		EnvVar:  "JIRA_PLUGIN_ENDPOINT",
 		Example: "https://your-domain.atlassian.net/rest/api/3",
-		Usage:   "The base uri to form JIRA REST API uri.",
+		Usage: "The base uri to form JIRA REST API uri, or the bare site url " +
+			"with endpoint discovery.",
+	})
+
+	typed.BoolVar(&cli.BoolVar{
+		Name:    "jira-plugin-endpoint-discovery",
+		Target:  &cfg.EndpointDiscovery,
+		EnvVar:  "JIRA_PLUGIN_ENDPOINT_DISCOVERY",
+		Default: false,
+		Usage: "Treat the endpoint as the bare site url, e.g. " +
+			"https://your-domain.atlassian.net, and discover the REST API " +
+			"version and path from the server info of the site.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-regional-endpoints",
+		Target:  &cfg.RegionalEndpoints,
+		EnvVar:  "JIRA_PLUGIN_REGIONAL_ENDPOINTS",
+		Example: "https://jira-eu.example.com/rest/api/2,https://jira-asia.example.com/rest/api/2",
+		Usage: "REST API endpoints of other regions of the same Jira Data Center " +
+			"cluster. Requests go to the healthy endpoint with the lowest latency.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-region-probe-interval",
+		Target:  &cfg.RegionProbeInterval,
+		EnvVar:  "JIRA_PLUGIN_REGION_PROBE_INTERVAL",
+		Example: "1m",
+		Usage: "How often the latency of the regional endpoints is measured " +
+			"while the plugin is in use. Defaults to 30s.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-allowed-hosts",
+		Target:  &cfg.AllowedHosts,
+		EnvVar:  "JIRA_PLUGIN_ALLOWED_HOSTS",
+		Example: "jira.example.com,jira-eu.example.com:8443",
+		Usage: "The only hosts, with an optional port, requests with the API " +
+			"token are sent to. They must include the hosts of the endpoints. " +
+			"Requests elsewhere, e.g. redirects, are blocked and logged. " +
+			"Defaults to the hosts of the endpoints.",
 	})
 
 	f.StringVar(&cli.StringVar{
@@ -106,7 +1141,39 @@ func (cfg *PluginConfig) ToFlags(set *cli.FlagSet) *cli.FlagSet {
 		Target:  &cfg.Jql,
 		EnvVar:  "JIRA_PLUGIN_JQL",
 		Example: "project = JRA and assignee != jsmith",
-		Usage:   "The JQL query specifying validation criteria for a JIRA issue.",
+		Usage: "The JQL query specifying validation criteria for a JIRA issue. " +
+			"{{.Requestor}} is replaced by the requestor as a quoted string, " +
+			"e.g. assignee = {{.Requestor}}.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-candidate-jql",
+		Target:  &cfg.CandidateJql,
+		EnvVar:  "JIRA_PLUGIN_CANDIDATE_JQL",
+		Example: "project = JRA and assignee != jsmith and priority = High",
+		Usage: "A JQL evaluated in shadow of the JQL to preview new validation " +
+			"criteria. Decisions follow the JQL, issues only one of them accepts " +
+			"are logged.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-issue-type-jql",
+		Target:  &cfg.IssueTypeJql,
+		EnvVar:  "JIRA_PLUGIN_ISSUE_TYPE_JQL",
+		Example: "Change=status = Approved; Incident=statusCategory != Done",
+		Usage: "Issue types validated with their own JQL instead of the JQL, " +
+			"separated by semicolons, each an issue type name, an equals sign and " +
+			"a JQL. Issues of other types are validated with the JQL.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-change-jql",
+		Target:  &cfg.ChangeJql,
+		EnvVar:  "JIRA_PLUGIN_CHANGE_JQL",
+		Example: "project = CHG and status = Approved",
+		Usage: "The JQL the change ticket of a dual justification is validated " +
+			"against, the incident ticket is validated against the JQL. Required " +
+			"with the dual justification format.",
 	})
 
 	f.StringVar(&cli.StringVar{
@@ -117,6 +1184,16 @@ func (cfg *PluginConfig) ToFlags(set *cli.FlagSet) *cli.FlagSet {
 		Usage:   "The user name used in JIRA Basic Auth.",
 	})
 
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-account-secret-id",
+		Target:  &cfg.AccountSecretID,
+		EnvVar:  "JIRA_PLUGIN_ACCOUNT_SECRET_ID",
+		Example: "projects/*/secrets/*/versions/*",
+		Usage: "The resource name of the [google.cloud.secretmanager.v1.SecretVersion] " +
+			"holding the user name used in JIRA Basic Auth, instead of " +
+			"-jira-plugin-account.",
+	})
+
 	f.StringVar(&cli.StringVar{
 		Name:    "jira-plugin-api-token-secret-id",
 		Target:  &cfg.APITokenSecretID,
@@ -125,6 +1202,62 @@ func (cfg *PluginConfig) ToFlags(set *cli.FlagSet) *cli.FlagSet {
 		Usage:   "The resource name of [google.cloud.secretmanager.v1.SecretVersion].",
 	})
 
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-secret-manager-credentials-file",
+		Target:  &cfg.SecretManagerCredentialsFile,
+		EnvVar:  "JIRA_PLUGIN_SECRET_MANAGER_CREDENTIALS_FILE",
+		Example: "/etc/jvs/wif-credentials.json",
+		Usage: "The credentials file Secret Manager is accessed with, e.g. a " +
+			"Workload Identity Federation credential configuration. Defaults to " +
+			"Application Default Credentials.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-secret-manager-impersonate-service-account",
+		Target:  &cfg.SecretManagerImpersonateServiceAccount,
+		EnvVar:  "JIRA_PLUGIN_SECRET_MANAGER_IMPERSONATE_SERVICE_ACCOUNT",
+		Example: "jvs-plugin-jira@my-project.iam.gserviceaccount.com",
+		Usage:   "The service account impersonated to access Secret Manager.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-secondary-api-token-secret-id",
+		Target:  &cfg.SecondaryAPITokenSecretID,
+		EnvVar:  "JIRA_PLUGIN_SECONDARY_API_TOKEN_SECRET_ID",
+		Example: "projects/*/secrets/*/versions/*",
+		Usage: "The resource name of the [google.cloud.secretmanager.v1.SecretVersion] " +
+			"of a second API token, used when Jira rejects the token in use. Set " +
+			"it to the new token while rotating.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-annotation-signing-key-secret-id",
+		Target:  &cfg.AnnotationSigningKeySecretID,
+		EnvVar:  "JIRA_PLUGIN_ANNOTATION_SIGNING_KEY_SECRET_ID",
+		Example: "projects/*/secrets/*/versions/*",
+		Usage: "The resource name of the [google.cloud.secretmanager.v1.SecretVersion] " +
+			"of the key signing the annotations with an HMAC-SHA256. Disabled when empty.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-bypass-token-secret-id",
+		Target:  &cfg.BypassTokenSecretID,
+		EnvVar:  "JIRA_PLUGIN_BYPASS_TOKEN_SECRET_ID",
+		Example: "projects/*/secrets/*/versions/*",
+		Usage: "The resource name of the [google.cloud.secretmanager.v1.SecretVersion] " +
+			"of the emergency bypass token. A justification bypass:<token> is then " +
+			"accepted without Jira, and audited as such. Disabled when empty.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-category",
+		Target:  &cfg.Category,
+		EnvVar:  "JIRA_PLUGIN_CATEGORY",
+		Default: jiraCategory,
+		Usage: "The justification category the plugin validates, to install " +
+			"several plugins side by side.",
+	})
+
 	f.StringVar(&cli.StringVar{
 		Name:    "jira-plugin-display-name",
 		Target:  &cfg.DisplayName,
@@ -149,5 +1282,659 @@ func (cfg *PluginConfig) ToFlags(set *cli.FlagSet) *cli.FlagSet {
 		Usage:   "IssueBaseURL is used to construct a URL that can be clicked.",
 	})
 
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-issue-url-template",
+		Target:  &cfg.IssueURLTemplate,
+		EnvVar:  "JIRA_PLUGIN_ISSUE_URL_TEMPLATE",
+		Example: "{{.BaseURL}}/browse/{{.Key}}?src=jvs",
+		Usage: "The template of the jira_issue_url annotation, given .BaseURL, " +
+			".Key and .ID. Defaults to the issue base url followed by /browse/ " +
+			"and the issue key.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-project-base-urls",
+		Target:  &cfg.ProjectBaseURLs,
+		EnvVar:  "JIRA_PLUGIN_PROJECT_BASE_URLS",
+		Example: "OPS=https://ops.atlassian.net,SEC=https://security.example.com",
+		Usage: "The issue base urls of projects on other Jira sites, as " +
+			"<project key>=<base url>. The issue urls of other projects use " +
+			"the issue base url.",
+	})
+
+	typed.BoolVar(&cli.BoolVar{
+		Name:    "jira-plugin-allow-http",
+		Target:  &cfg.AllowHTTP,
+		EnvVar:  "JIRA_PLUGIN_ALLOW_HTTP",
+		Default: false,
+		Usage:   "Allow the endpoint and the issue base url to use http rather than https.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-justification-format",
+		Target:  &cfg.JustificationFormat,
+		EnvVar:  "JIRA_PLUGIN_JUSTIFICATION_FORMAT",
+		Example: "composite",
+		Usage:   "How the justification value is parsed, one of key, composite, json, dual. Defaults to key.",
+	})
+
+	typed.IntVar(&cli.IntVar{
+		Name:    "jira-plugin-max-value-length",
+		Target:  &cfg.MaxValueLength,
+		EnvVar:  "JIRA_PLUGIN_MAX_VALUE_LENGTH",
+		Example: "256",
+		Usage: "The maximum number of characters of a justification value, longer " +
+			"values are rejected before they are parsed. Defaults to 512.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-value-charset",
+		Target:  &cfg.ValueCharset,
+		EnvVar:  "JIRA_PLUGIN_VALUE_CHARSET",
+		Example: "ascii",
+		Usage: "The characters a justification value may hold, one of printable " +
+			"(UTF-8 without control characters) or ascii (printable ASCII). " +
+			"Defaults to printable.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-audit-syslog-address",
+		Target:  &cfg.AuditSyslogAddress,
+		EnvVar:  "JIRA_PLUGIN_AUDIT_SYSLOG_ADDRESS",
+		Example: "siem.example.com:6514",
+		Usage:   "The host:port of a syslog collector receiving validation decisions.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-audit-syslog-network",
+		Target:  &cfg.AuditSyslogNetwork,
+		EnvVar:  "JIRA_PLUGIN_AUDIT_SYSLOG_NETWORK",
+		Example: "tls",
+		Usage:   "The transport to the syslog collector, one of udp, tcp, tls. Defaults to udp.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-audit-format",
+		Target:  &cfg.AuditFormat,
+		EnvVar:  "JIRA_PLUGIN_AUDIT_FORMAT",
+		Example: "rfc5424",
+		Usage:   "The format of audit messages, one of cef, rfc5424. Defaults to cef.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-audit-syslog-sd-id",
+		Target:  &cfg.AuditSyslogSDID,
+		EnvVar:  "JIRA_PLUGIN_AUDIT_SYSLOG_SD_ID",
+		Example: "jvsDecision@12345",
+		Usage: "The SD-ID, with your private enterprise number, of the structured data " +
+			"element holding the decision in rfc5424 messages. The decision is " +
+			"written to the message text when unset.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-state-backend",
+		Target:  &cfg.StateBackend,
+		EnvVar:  "JIRA_PLUGIN_STATE_BACKEND",
+		Example: "redis",
+		Usage: "Where the decision cache and the requestor quota counters are " +
+			"kept, memory or redis. With redis all the plugin replicas share " +
+			"them and the decision cache is enabled. Defaults to memory.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-redis-url",
+		Target:  &cfg.RedisURL,
+		EnvVar:  "JIRA_PLUGIN_REDIS_URL",
+		Example: "rediss://10.0.0.3:6378/0",
+		Usage:   "The Redis or Memorystore instance of the redis state backend, rediss:// for TLS.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-redis-password-secret-id",
+		Target:  &cfg.RedisPasswordSecretID,
+		EnvVar:  "JIRA_PLUGIN_REDIS_PASSWORD_SECRET_ID",
+		Example: "projects/[PROJECT_ID]/secrets/[SECRET_ID]/versions/[VERSION]",
+		Usage: "The resource name of the [google.cloud.secretmanager.v1.SecretVersion] " +
+			"of the Redis password or Memorystore AUTH string.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-cache-path",
+		Target:  &cfg.CachePath,
+		EnvVar:  "JIRA_PLUGIN_CACHE_PATH",
+		Example: "/var/cache/jvs-plugin-jira/decisions.db",
+		Usage: "If set, issues that matched the JQL are cached in this file, so " +
+			"restarts do not cause a burst of Jira requests.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-cache-ttl",
+		Target:  &cfg.CacheTTL,
+		EnvVar:  "JIRA_PLUGIN_CACHE_TTL",
+		Example: "10m",
+		Usage: "How long a cached match is used. Defaults to 5m. An expired " +
+			"match is revalidated with a conditional request and reused when " +
+			"the issue did not change.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-cache-max-staleness",
+		Target:  &cfg.CacheMaxStaleness,
+		EnvVar:  "JIRA_PLUGIN_CACHE_MAX_STALENESS",
+		Example: "2m",
+		Usage: "How long after expiring a cached match is still used, with the " +
+			"jira_cache_stale annotation, while one background request " +
+			"refreshes it. Disabled when 0.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-not-found-cache-ttl",
+		Target:  &cfg.NotFoundCacheTTL,
+		EnvVar:  "JIRA_PLUGIN_NOT_FOUND_CACHE_TTL",
+		Example: "30s",
+		Usage: "How long an issue Jira reported missing is rejected without " +
+			"asking Jira again. An issue created meanwhile is rejected until " +
+			"it expires. Disabled when 0.",
+	})
+
+	typed.IntVar(&cli.IntVar{
+		Name:    "jira-plugin-not-found-cache-size",
+		Target:  &cfg.NotFoundCacheSize,
+		EnvVar:  "JIRA_PLUGIN_NOT_FOUND_CACHE_SIZE",
+		Example: "1000",
+		Usage: "The number of missing issues remembered, the least recently " +
+			"used is forgotten first. Defaults to 1000.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-cache-encryption-key-secret-ids",
+		Target:  &cfg.CacheEncryptionKeySecretIDs,
+		EnvVar:  "JIRA_PLUGIN_CACHE_ENCRYPTION_KEY_SECRET_IDS",
+		Example: "projects/*/secrets/*/versions/2,projects/*/secrets/*/versions/1",
+		Usage: "The resource names of the [google.cloud.secretmanager.v1.SecretVersion]s " +
+			"of the keys encrypting the decision cache, at least 32 random bytes " +
+			"each. The first key encrypts, every key decrypts. Put a new key first " +
+			"to rotate.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-match-mode",
+		Target:  &cfg.MatchMode,
+		EnvVar:  "JIRA_PLUGIN_MATCH_MODE",
+		Example: "search",
+		Usage: "How an issue is matched against the JQL, one of match (two requests " +
+			"per validation) or search (one request). Defaults to match.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-min-priority",
+		Target:  &cfg.MinPriority,
+		EnvVar:  "JIRA_PLUGIN_MIN_PRIORITY",
+		Example: "High",
+		Usage: "Reject issues whose priority is below this one, in the order of " +
+			"-jira-plugin-priority-order.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-priority-order",
+		Target:  &cfg.PriorityOrder,
+		EnvVar:  "JIRA_PLUGIN_PRIORITY_ORDER",
+		Example: "Blocker,Critical,Major,Minor,Trivial",
+		Usage: "The priority names highest first, for -jira-plugin-min-priority. " +
+			"Defaults to Highest,High,Medium,Low,Lowest.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-max-issue-age",
+		Target:  &cfg.MaxIssueAge,
+		EnvVar:  "JIRA_PLUGIN_MAX_ISSUE_AGE",
+		Example: "2160h",
+		Usage: "Reject issues created longer ago, so that ancient issues cannot " +
+			"be recycled as justifications. Disabled when 0.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-max-resolved-age",
+		Target:  &cfg.MaxResolvedAge,
+		EnvVar:  "JIRA_PLUGIN_MAX_RESOLVED_AGE",
+		Example: "48h",
+		Usage:   "Reject issues resolved longer ago, unresolved issues are accepted. Disabled when 0.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-archived-issues",
+		Target:  &cfg.ArchivedIssues,
+		EnvVar:  "JIRA_PLUGIN_ARCHIVED_ISSUES",
+		Example: "reject",
+		Usage: "What to do with archived issues on Jira Data Center, one of allow " +
+			"(annotate the archival time) or reject. Disabled when empty.",
+	})
+
+	typed.BoolVar(&cli.BoolVar{
+		Name:    "jira-plugin-issue-snapshot-hash",
+		Target:  &cfg.IssueSnapshotHash,
+		EnvVar:  "JIRA_PLUGIN_ISSUE_SNAPSHOT_HASH",
+		Default: false,
+		Usage: "Annotate valid responses with a hash of the key, status, assignee " +
+			"and resolution of the issue, so that a revocation system can tell " +
+			"whether the issue changed since.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-field-constraints",
+		Target:  &cfg.FieldConstraints,
+		EnvVar:  "JIRA_PLUGIN_FIELD_CONSTRAINTS",
+		Example: "status in [Open, In Progress]; labels contains approved",
+		Usage: "Checks of issue fields separated by semicolons, each a field id, " +
+			"an operator (==, !=, in, contains, before, after) and a value, as a simpler " +
+			"alternative to JQL. Values are compared case-insensitively.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-link-rules",
+		Target:  &cfg.LinkRules,
+		EnvVar:  "JIRA_PLUGIN_LINK_RULES",
+		Example: `reject "is blocked by" unresolved; require "is reviewed by" status Approved`,
+		Usage: "Checks of the issue links separated by semicolons, each reject or " +
+			"require, a link description or type, and conditions on the linked " +
+			"issue: resolved, unresolved, issuetype <name> or status <name>.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-expression",
+		Target:  &cfg.Expression,
+		EnvVar:  "JIRA_PLUGIN_EXPRESSION",
+		Example: "issue.dueDate != null && issue.dueDate > issue.created",
+		Usage: "A Jira expression the issue must evaluate to true for, for " +
+			"checks JQL cannot express, e.g. comparing two fields. It costs " +
+			"an extra Jira request per validation.",
+	})
+
+	typed.BoolVar(&cli.BoolVar{
+		Name:    "jira-plugin-account-ids",
+		Target:  &cfg.AccountIDs,
+		EnvVar:  "JIRA_PLUGIN_ACCOUNT_IDS",
+		Default: false,
+		Usage: "Replace {{.Requestor}} in the JQL with the Jira account id of " +
+			"the requestor, resolved from the email with the user search API, " +
+			"for sites that do not match emails in JQL.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-account-cache-ttl",
+		Target:  &cfg.AccountCacheTTL,
+		EnvVar:  "JIRA_PLUGIN_ACCOUNT_CACHE_TTL",
+		Example: "1h",
+		Usage:   "How long the account id of a requestor is cached. Defaults to 1h.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-identity-domain-rewrites",
+		Target:  &cfg.IdentityDomainRewrites,
+		EnvVar:  "JIRA_PLUGIN_IDENTITY_DOMAIN_REWRITES",
+		Example: "example.com=corp.example.com",
+		Usage: "Rewrites of the email domain of requestors to the one of their " +
+			"Jira users, for {{.Requestor}} in the JQL.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-identity-mapping-file",
+		Target:  &cfg.IdentityMappingFile,
+		EnvVar:  "JIRA_PLUGIN_IDENTITY_MAPPING_FILE",
+		Example: "/etc/jvs/identities.csv",
+		Usage: "A CSV file of requestors and their Jira users, e.g. " +
+			"jane@example.com,jane.doe@corp.example.com, for {{.Requestor}} in the JQL.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-identity-mapping-secret-id",
+		Target:  &cfg.IdentityMappingSecretID,
+		EnvVar:  "JIRA_PLUGIN_IDENTITY_MAPPING_SECRET_ID",
+		Example: "projects/*/secrets/*/versions/*",
+		Usage:   "The secret version holding the CSV of -jira-plugin-identity-mapping-file instead.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-annotation-fields",
+		Target:  &cfg.AnnotationFields,
+		EnvVar:  "JIRA_PLUGIN_ANNOTATION_FIELDS",
+		Example: "summary,priority,customfield_10010:user,duedate:date",
+		Usage: "Jira issue fields copied into the justification annotations as " +
+			"jira_field_<name>. Only these fields are requested from Jira. A " +
+			"field may be followed by a renderer: text (default), user for the " +
+			"email of user pickers, option for the value of select lists, or " +
+			"date for RFC 3339 dates.",
+	})
+
+	typed.ByteSizeVar(&cli.Var[ByteSize]{
+		Name:    "jira-plugin-annotation-field-max-bytes",
+		Target:  &cfg.AnnotationFieldMaxBytes,
+		EnvVar:  "JIRA_PLUGIN_ANNOTATION_FIELD_MAX_BYTES",
+		Example: "1KiB",
+		Usage: "The maximum size of each annotation field value, longer values " +
+			"are truncated, e.g. 512 or 1KiB. Defaults to 256.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-field-redactions",
+		Target:  &cfg.FieldRedactions,
+		EnvVar:  "JIRA_PLUGIN_FIELD_REDACTIONS",
+		Example: "summary max 80; summary projects [OPS, INFRA]",
+		Usage: "Redaction rules of annotation fields separated by semicolons, " +
+			"each a field id, a rule (max, scrub, projects) and a value, " +
+			"applied before the fields are returned.",
+	})
+
+	typed.Float64Var(&cli.Float64Var{
+		Name:    "jira-plugin-quota-rate",
+		Target:  &cfg.QuotaRate,
+		EnvVar:  "JIRA_PLUGIN_QUOTA_RATE",
+		Example: "10",
+		Usage:   "The maximum validations per second, validations over it are rejected. Unlimited when 0.",
+	})
+
+	typed.IntVar(&cli.IntVar{
+		Name:    "jira-plugin-quota-burst",
+		Target:  &cfg.QuotaBurst,
+		EnvVar:  "JIRA_PLUGIN_QUOTA_BURST",
+		Example: "20",
+		Usage:   "The validations allowed at once on top of the quota rate. Defaults to the rate.",
+	})
+
+	typed.IntVar(&cli.IntVar{
+		Name:    "jira-plugin-quota-max-concurrent",
+		Target:  &cfg.QuotaMaxConcurrent,
+		EnvVar:  "JIRA_PLUGIN_QUOTA_MAX_CONCURRENT",
+		Example: "8",
+		Usage:   "The maximum validations in flight, validations over it are rejected. Unlimited when 0.",
+	})
+
+	typed.IntVar(&cli.IntVar{
+		Name:    "jira-plugin-requestor-quota",
+		Target:  &cfg.RequestorQuota,
+		EnvVar:  "JIRA_PLUGIN_REQUESTOR_QUOTA",
+		Example: "50",
+		Usage: "The maximum successful validations per requestor in the requestor " +
+			"quota window. Unlimited when 0.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-requestor-quota-window",
+		Target:  &cfg.RequestorQuotaWindow,
+		EnvVar:  "JIRA_PLUGIN_REQUESTOR_QUOTA_WINDOW",
+		Example: "1h",
+		Usage:   "The rolling window of the requestor quota. Defaults to 24h.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-requestor-quota-mode",
+		Target:  &cfg.RequestorQuotaMode,
+		EnvVar:  "JIRA_PLUGIN_REQUESTOR_QUOTA_MODE",
+		Example: "warn",
+		Usage: "What happens to validations of a requestor over the quota, reject " +
+			"or warn. Defaults to reject.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-freeze-windows",
+		Target:  &cfg.FreezeWindows,
+		EnvVar:  "JIRA_PLUGIN_FREEZE_WINDOWS",
+		Example: "0 18 * * FRI 62h; 0 0 20 12 * 336h",
+		Usage: "Freeze windows separated by semicolons, each a cron expression " +
+			"for its start followed by its duration. During a freeze only issues " +
+			"matching the freeze JQL are accepted.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-freeze-timezone",
+		Target:  &cfg.FreezeTimezone,
+		EnvVar:  "JIRA_PLUGIN_FREEZE_TIMEZONE",
+		Example: "America/New_York",
+		Usage:   "The time zone of the freeze windows. Defaults to the site time zone.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-site-timezone",
+		Target:  &cfg.SiteTimezone,
+		EnvVar:  "JIRA_PLUGIN_SITE_TIMEZONE",
+		Example: "Europe/Berlin",
+		Usage: "The time zone of the Jira site, used for date fields and " +
+			"date-times without a time zone. Defaults to UTC.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-clock-skew",
+		Target:  &cfg.ClockSkew,
+		EnvVar:  "JIRA_PLUGIN_CLOCK_SKEW",
+		Example: "30s",
+		Usage: "The skew between the Jira and plugin clocks tolerated by " +
+			"time-based checks, in favor of the issue.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-freeze-jql",
+		Target:  &cfg.FreezeJql,
+		EnvVar:  "JIRA_PLUGIN_FREEZE_JQL",
+		Example: "priority = Highest AND labels = emergency",
+		Usage: "The emergency JQL an issue must match in addition to the JQL " +
+			"during a freeze. No issue is accepted during a freeze when empty.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-bypass-requestors",
+		Target:  &cfg.BypassRequestors,
+		EnvVar:  "JIRA_PLUGIN_BYPASS_REQUESTORS",
+		Example: "breakglass@example.iam.gserviceaccount.com,sre-oncall@example.com",
+		Usage: "Identities and groups of requestors whose justifications are " +
+			"accepted without asking Jira. They are flagged in the annotations " +
+			"and audited as bypassed.",
+	})
+
+	typed.BoolVar(&cli.BoolVar{
+		Name:    "jira-plugin-debug-annotations",
+		Target:  &cfg.DebugAnnotations,
+		EnvVar:  "JIRA_PLUGIN_DEBUG_ANNOTATIONS",
+		Default: false,
+		Usage: "Add the validation latency, the number of Jira requests and " +
+			"whether the decision cache was hit to the response annotations, " +
+			"prefixed with debug.",
+	})
+
+	typed.BoolVar(&cli.BoolVar{
+		Name:    "jira-plugin-explain-annotation",
+		Target:  &cfg.ExplainAnnotation,
+		EnvVar:  "JIRA_PLUGIN_EXPLAIN_ANNOTATION",
+		Default: false,
+		Usage: "Add the decision tree of the validation to the jira_explanation " +
+			"response annotation when the justification annotation " +
+			"jira_explain is true.",
+	})
+
+	typed.BoolVar(&cli.BoolVar{
+		Name:    "jira-plugin-wrong-category-error",
+		Target:  &cfg.WrongCategoryError,
+		EnvVar:  "JIRA_PLUGIN_WRONG_CATEGORY_ERROR",
+		Default: false,
+		Usage: "Fail requests for a justification category other than jira " +
+			"with an InvalidArgument status. By default they get an invalid " +
+			"response with the jira_error_code annotation wrong_category.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-message-locale",
+		Target:  &cfg.MessageLocale,
+		EnvVar:  "JIRA_PLUGIN_MESSAGE_LOCALE",
+		Example: "en",
+		Usage: "The locale of the user-facing rejection messages for requests " +
+			"without a locale. When set, rejections get a message from the " +
+			"catalog and the jira_error_code annotation, the internal details " +
+			"are logged.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-messages",
+		Target:  &cfg.Messages,
+		EnvVar:  "JIRA_PLUGIN_MESSAGES",
+		Example: "de:no_match=Das Ticket {issue} ist nicht freigegeben",
+		Usage: "Overrides of the rejection messages by locale and code, " +
+			"separated by semicolons.",
+	})
+
+	typed.IntVar(&cli.IntVar{
+		Name:    "jira-plugin-replay-buffer-size",
+		Target:  &cfg.ReplayBufferSize,
+		EnvVar:  "JIRA_PLUGIN_REPLAY_BUFFER_SIZE",
+		Example: "50",
+		Usage: "The number of failed validations kept in memory with their Jira " +
+			"requests and responses for debugging, credentials removed. The " +
+			"server writes them to a file on SIGUSR1. Disabled when 0.",
+	})
+
+	typed.IntVar(&cli.IntVar{
+		Name:    "jira-plugin-diagnostic-decisions",
+		Target:  &cfg.DiagnosticDecisions,
+		EnvVar:  "JIRA_PLUGIN_DIAGNOSTIC_DECISIONS",
+		Example: "20",
+		Usage: "The number of recent decisions kept in memory for the diagnostic " +
+			"dump the server writes on SIGUSR1. Disabled when 0.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-warmup-projects",
+		Target:  &cfg.WarmupProjects,
+		EnvVar:  "JIRA_PLUGIN_WARMUP_PROJECTS",
+		Example: "INC,CHG",
+		Usage: "Keys of projects the account must be able to browse, checked " +
+			"with the credentials and the JQL when the server warms up.",
+	})
+
+	typed.IntVar(&cli.IntVar{
+		Name:    "jira-plugin-http-retries",
+		Target:  &cfg.HTTPRetries,
+		EnvVar:  "JIRA_PLUGIN_HTTP_RETRIES",
+		Example: "2",
+		Usage: "How often a Jira request that failed in transit or got a 5xx " +
+			"response is retried, with exponential backoff within the request " +
+			"timeout. Only read-only requests are retried. At most 5.",
+	})
+
+	typed.IntVar(&cli.IntVar{
+		Name:    "jira-plugin-max-redirects",
+		Target:  &cfg.MaxRedirects,
+		EnvVar:  "JIRA_PLUGIN_MAX_REDIRECTS",
+		Example: "1",
+		Usage: "How many redirects a Jira request follows. Redirects are only " +
+			"followed on the same host and never from https to http, since " +
+			"the API token is sent again. Defaults to 3, at most 10.",
+	})
+
+	typed.Float64Var(&cli.Float64Var{
+		Name:    "jira-plugin-fault-injection-rate",
+		Target:  &cfg.FaultInjectionRate,
+		EnvVar:  "JIRA_PLUGIN_FAULT_INJECTION_RATE",
+		Example: "0.1",
+		Usage: "The fraction of Jira requests failed on purpose with a 503 " +
+			"response, for testing. Disabled when 0, never set it in production.",
+	})
+
+	typed.BoolVar(&cli.BoolVar{
+		Name:    "jira-plugin-insecure-skip-verify",
+		Target:  &cfg.InsecureSkipVerify,
+		EnvVar:  "JIRA_PLUGIN_INSECURE_SKIP_VERIFY",
+		Default: false,
+		Usage: "Do not verify the TLS certificate of Jira, for test instances " +
+			"with a self-signed certificate. Every decision is annotated with " +
+			"jira_insecure_transport, never set it in production.",
+	})
+
+	typed.BoolVar(&cli.BoolVar{
+		Name:    "jira-plugin-decision-stream",
+		Target:  &cfg.DecisionStream,
+		EnvVar:  "JIRA_PLUGIN_DECISION_STREAM",
+		Default: false,
+		Usage: "Serve the validation decisions as they are made with the " +
+			"server streaming RPC " + DecisionStreamMethod + ", so the JVS server " +
+			"can collect them centrally. Slow subscribers miss decisions.",
+	})
+
+	f.StringSliceVar(&cli.StringSliceVar{
+		Name:    "jira-plugin-feature-flags",
+		Target:  &cfg.FeatureFlags,
+		EnvVar:  "JIRA_PLUGIN_FEATURE_FLAGS",
+		Example: "cache=off,stale_while_revalidate=off",
+		Usage: "Turn subsystems off or on per deployment, as name=off or " +
+			"name=on. The flags are " + strings.Join(featureNames, ", ") + ", " +
+			"all on by default. The features command overrides them at runtime.",
+	})
+
+	typed.Float64Var(&cli.Float64Var{
+		Name:    "jira-plugin-sample-rate",
+		Target:  &cfg.SampleRate,
+		EnvVar:  "JIRA_PLUGIN_SAMPLE_RATE",
+		Example: "0.05",
+		Usage: "The fraction of validation decisions captured with their issue " +
+			"snapshot and policy evaluation for review. Disabled when 0.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-sample-destination",
+		Target:  &cfg.SampleDestination,
+		EnvVar:  "JIRA_PLUGIN_SAMPLE_DESTINATION",
+		Example: "gs://my-bucket/decision-samples",
+		Usage: "Where the decision samples are written, a gs://bucket/prefix " +
+			"URL or a local directory. Required with the sample rate.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-revocation-destination",
+		Target:  &cfg.RevocationDestination,
+		EnvVar:  "JIRA_PLUGIN_REVOCATION_DESTINATION",
+		Example: "projects/my-project/topics/jvs-revocations",
+		Usage: "Where accepted decisions whose issue no longer matches are " +
+			"reported with their annotations, an https URL receiving a JSON " +
+			"POST or a Pub/Sub topic. Disabled when empty.",
+	})
+
+	f.StringVar(&cli.StringVar{
+		Name:    "jira-plugin-revocation-audience",
+		Target:  &cfg.RevocationAudience,
+		EnvVar:  "JIRA_PLUGIN_REVOCATION_AUDIENCE",
+		Example: "https://jvs.example.com",
+		Usage: "The audience of the Google ID token authenticating the " +
+			"revocation requests to a URL. Unauthenticated when empty.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-revocation-interval",
+		Target:  &cfg.RevocationInterval,
+		EnvVar:  "JIRA_PLUGIN_REVOCATION_INTERVAL",
+		Example: "1m",
+		Usage:   "How often the accepted decisions are validated again. Defaults to 5m.",
+	})
+
+	typed.DurationVar(&cli.DurationVar{
+		Name:    "jira-plugin-revocation-window",
+		Target:  &cfg.RevocationWindow,
+		EnvVar:  "JIRA_PLUGIN_REVOCATION_WINDOW",
+		Example: "12h",
+		Usage: "How long an accepted decision is validated again, the longest " +
+			"lifetime of a JVS token. Defaults to 24h.",
+	})
+
 	return set
 }
+
+// category returns the justification category validated, [Category] unless
+// configured otherwise.
+func (cfg *PluginConfig) category() string {
+	if cfg.Category == "" {
+		return jiraCategory
+	}
+	return cfg.Category
+}
+
+// cacheEnabled reports whether the decision cache is enabled, in a file or in
+// Redis.
+func (cfg *PluginConfig) cacheEnabled() bool {
+	return cfg.CachePath != "" || cfg.StateBackend == StateBackendRedis
+}
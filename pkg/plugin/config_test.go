@@ -16,7 +16,10 @@
 package plugin
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -32,6 +35,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 		args       []string
 		envs       map[string]string
 		wantConfig *PluginConfig
+		wantErr    string
 	}{
 		{
 			name: "all_envs_specified",
@@ -49,6 +53,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 				Jql:              "project = JRA and assignee != jsmith",
 				JIRAAccount:      "abc@xyz.com",
 				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Category:         "jira",
 				DisplayName:      "Jira Issue Key",
 				Hint:             "Jira Issue Key under JVS project",
 				IssueBaseURL:     "https://example.atlassian.net",
@@ -71,6 +76,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 				Jql:              "project = JRA and assignee != jsmith",
 				JIRAAccount:      "abc@xyz.com",
 				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Category:         "jira",
 				DisplayName:      "Jira Issue Key",
 				Hint:             "Jira Issue Key under specific project",
 				IssueBaseURL:     "https://example.atlassian.net",
@@ -83,6 +89,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 			},
 			wantConfig: &PluginConfig{
 				JIRAEndpoint: "https://example.atlassian.net/rest/api/3",
+				Category:     "jira",
 				DisplayName:  "Jira Issue Key",
 			},
 		},
@@ -91,6 +98,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 			args: []string{"-jira-plugin-endpoint", "https://example.atlassian.net/rest/api/3"},
 			wantConfig: &PluginConfig{
 				JIRAEndpoint: "https://example.atlassian.net/rest/api/3",
+				Category:     "jira",
 				DisplayName:  "Jira Issue Key",
 			},
 		},
@@ -101,6 +109,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 			},
 			wantConfig: &PluginConfig{
 				Jql:         "project = JRA and assignee != jsmith",
+				Category:    "jira",
 				DisplayName: "Jira Issue Key",
 			},
 		},
@@ -109,6 +118,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 			args: []string{"-jira-plugin-jql", "project = JRA and assignee != jsmith"},
 			wantConfig: &PluginConfig{
 				Jql:         "project = JRA and assignee != jsmith",
+				Category:    "jira",
 				DisplayName: "Jira Issue Key",
 			},
 		},
@@ -119,6 +129,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 			},
 			wantConfig: &PluginConfig{
 				JIRAAccount: "abc@xyz.com",
+				Category:    "jira",
 				DisplayName: "Jira Issue Key",
 			},
 		},
@@ -127,6 +138,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 			args: []string{"-jira-plugin-account", "abc@xyz.com"},
 			wantConfig: &PluginConfig{
 				JIRAAccount: "abc@xyz.com",
+				Category:    "jira",
 				DisplayName: "Jira Issue Key",
 			},
 		},
@@ -137,6 +149,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 			},
 			wantConfig: &PluginConfig{
 				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Category:         "jira",
 				DisplayName:      "Jira Issue Key",
 			},
 		},
@@ -148,6 +161,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 			},
 			wantConfig: &PluginConfig{
 				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Category:         "jira",
 				DisplayName:      "Jira Issue Key",
 			},
 		},
@@ -157,6 +171,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 				"JIRA_PLUGIN_DISPLAY_NAME": "jira display name",
 			},
 			wantConfig: &PluginConfig{
+				Category:    "jira",
 				DisplayName: "jira display name",
 			},
 		},
@@ -166,6 +181,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 				"-jira-plugin-display-name", "jira display name",
 			},
 			wantConfig: &PluginConfig{
+				Category:    "jira",
 				DisplayName: "jira display name",
 			},
 		},
@@ -175,6 +191,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 				"JIRA_PLUGIN_HINT": "jira hint",
 			},
 			wantConfig: &PluginConfig{
+				Category:    "jira",
 				DisplayName: "Jira Issue Key",
 				Hint:        "jira hint",
 			},
@@ -185,6 +202,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 				"-jira-plugin-hint", "jira hint",
 			},
 			wantConfig: &PluginConfig{
+				Category:    "jira",
 				DisplayName: "Jira Issue Key",
 				Hint:        "jira hint",
 			},
@@ -195,6 +213,7 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 				"JIRA_PLUGIN_ISSUE_BASE_URL": "https://example.atlassian.net",
 			},
 			wantConfig: &PluginConfig{
+				Category:     "jira",
 				DisplayName:  "Jira Issue Key",
 				IssueBaseURL: "https://example.atlassian.net",
 			},
@@ -205,10 +224,40 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 				"-jira-plugin-issue-base-url", "https://example.atlassian.net",
 			},
 			wantConfig: &PluginConfig{
+				Category:     "jira",
 				DisplayName:  "Jira Issue Key",
 				IssueBaseURL: "https://example.atlassian.net",
 			},
 		},
+		{
+			name: "typed_envs",
+			envs: map[string]string{
+				"JIRA_PLUGIN_CACHE_TTL":                  "10m",
+				"JIRA_PLUGIN_ANNOTATION_FIELD_MAX_BYTES": "1KiB",
+				"JIRA_PLUGIN_DEBUG_ANNOTATIONS":          "true",
+				"JIRA_PLUGIN_QUOTA_RATE":                 "2.5",
+			},
+			wantConfig: &PluginConfig{
+				Category:                "jira",
+				DisplayName:             "Jira Issue Key",
+				CacheTTL:                10 * time.Minute,
+				AnnotationFieldMaxBytes: 1024,
+				DebugAnnotations:        true,
+				QuotaRate:               2.5,
+			},
+		},
+		{
+			name: "invalid_typed_envs",
+			envs: map[string]string{
+				"JIRA_PLUGIN_CACHE_TTL":                  "10",
+				"JIRA_PLUGIN_ANNOTATION_FIELD_MAX_BYTES": "1 kilobyte",
+			},
+			wantConfig: &PluginConfig{
+				Category:    "jira",
+				DisplayName: "Jira Issue Key",
+			},
+			wantErr: `invalid JIRA_PLUGIN_ANNOTATION_FIELD_MAX_BYTES "1 kilobyte": invalid byte size`,
+		},
 	}
 
 	for _, tc := range cases {
@@ -220,8 +269,9 @@ func TestPluginConfig_ToFlags(t *testing.T) {
 			gotConfig := &PluginConfig{}
 			set := cli.NewFlagSet(cli.WithLookupEnv(cli.MapLookuper(tc.envs)))
 			set = gotConfig.ToFlags(set)
-			if err := set.Parse(tc.args); err != nil {
-				t.Errorf("unexpected flag set parse error: %v", err)
+			err := set.Parse(tc.args)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
 			}
 			if diff := cmp.Diff(tc.wantConfig, gotConfig); diff != "" {
 				t.Errorf("Config unexpected diff (-want,+got):\n%s", diff)
@@ -270,6 +320,747 @@ func TestPluginConfig_Validate(t *testing.T) {
 			},
 			wantErr: "empty JIRA_PLUGIN_ENDPOINT",
 		},
+		{
+			name: "invalid_jira_endpoint",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+			wantErr: "invalid JIRA_PLUGIN_ENDPOINT: invalid endpoint example.atlassian.net/rest/api/3, must be an http or https url",
+		},
+		{
+			name: "endpoint_discovery_with_rest_path",
+			cfg: &PluginConfig{
+				JIRAEndpoint:      "https://example.atlassian.net/rest/api/3",
+				EndpointDiscovery: true,
+				Jql:               "project = JRA and assignee != jsmith",
+				JIRAAccount:       "abc@xyz.com",
+				APITokenSecretID:  "projects/123456/secrets/api-token/versions/4",
+				Hint:              "Jira Issue Key under JVS project",
+				IssueBaseURL:      "https://example.atlassian.net",
+			},
+			wantErr: "must be the site url with JIRA_PLUGIN_ENDPOINT_DISCOVERY",
+		},
+		{
+			name: "http_endpoint",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "http://jira.corp.example/rest/api/2",
+				Jql:              "project = JRA and assignee != jsmith",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://jira.corp.example",
+			},
+			wantErr: "invalid JIRA_PLUGIN_ENDPOINT http://jira.corp.example/rest/api/2, must use https unless JIRA_PLUGIN_ALLOW_HTTP is set",
+		},
+		{
+			name: "http_allowed",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "http://jira.corp.example:8080/jira/rest/api/2",
+				Jql:              "project = JRA and assignee != jsmith",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "http://jira.corp.example:8080/jira",
+				AllowHTTP:        true,
+			},
+		},
+		{
+			name: "host_mismatch",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://other.atlassian.net",
+			},
+			wantErr: "JIRA_PLUGIN_ENDPOINT host \"example.atlassian.net\" and JIRA_PLUGIN_ISSUE_BASE_URL host \"other.atlassian.net\" differ",
+		},
+		{
+			name: "atlassian_api_gateway",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://api.atlassian.com/ex/jira/1234/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+		},
+		{
+			name: "invalid_issue_base_url",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "example.atlassian.net",
+			},
+			wantErr: "invalid JIRA_PLUGIN_ISSUE_BASE_URL example.atlassian.net, must be an http or https url",
+		},
+		{
+			name: "invalid_issue_url_template",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				IssueURLTemplate: "{{.BaseURL}}/browse/{{.Summary}}",
+			},
+			wantErr: "invalid JIRA_PLUGIN_ISSUE_URL_TEMPLATE: failed to execute issue url template",
+		},
+		{
+			name: "account_secret",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				AccountSecretID:  "projects/123456/secrets/account/versions/1",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+		},
+		{
+			name: "account_and_account_secret",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				JIRAAccount:      "abc@xyz.com",
+				AccountSecretID:  "projects/123456/secrets/account/versions/1",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+			wantErr: "JIRA_PLUGIN_ACCOUNT and JIRA_PLUGIN_ACCOUNT_SECRET_ID are mutually exclusive",
+		},
+		{
+			name: "missing_secret_manager_credentials_file",
+			cfg: &PluginConfig{
+				JIRAEndpoint:                 "https://example.atlassian.net/rest/api/3",
+				Jql:                          "project = JRA and assignee != jsmith",
+				JIRAAccount:                  "abc@xyz.com",
+				APITokenSecretID:             "projects/123456/secrets/api-token/versions/4",
+				Hint:                         "Jira Issue Key under JVS project",
+				IssueBaseURL:                 "https://example.atlassian.net",
+				SecretManagerCredentialsFile: "/does/not/exist.json",
+			},
+			wantErr: "invalid JIRA_PLUGIN_SECRET_MANAGER_CREDENTIALS_FILE",
+		},
+		{
+			name: "invalid_issue_type_jql",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				IssueTypeJql:     "Change",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+			wantErr: `invalid JIRA_PLUGIN_ISSUE_TYPE_JQL: invalid issue type route "Change"`,
+		},
+		{
+			name: "issue_type_jql_with_search_mode",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				IssueTypeJql:     "Change=status = Approved",
+				MatchMode:        MatchModeSearch,
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+			wantErr: "JIRA_PLUGIN_ISSUE_TYPE_JQL cannot be used with JIRA_PLUGIN_MATCH_MODE=search",
+		},
+		{
+			name: "unknown_min_priority",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				MinPriority:      "Major",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+			wantErr: `invalid JIRA_PLUGIN_MIN_PRIORITY: unknown minimum priority "Major"`,
+		},
+		{
+			name: "invalid_field_constraints",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				FieldConstraints: "status in [Open]; labels has approved",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+			wantErr: `invalid JIRA_PLUGIN_FIELD_CONSTRAINTS: invalid field constraint "labels has approved", unknown operator "has"`,
+		},
+		{
+			name: "invalid_link_rules",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				LinkRules:        `reject "is blocked by" unresolved; forbid blocks`,
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+			wantErr: `invalid JIRA_PLUGIN_LINK_RULES: invalid link rule "forbid blocks", unknown action "forbid"`,
+		},
+		{
+			name: "dual_without_change_jql",
+			cfg: &PluginConfig{
+				JIRAEndpoint:        "https://example.atlassian.net/rest/api/3",
+				Jql:                 "project = INC",
+				JustificationFormat: JustificationFormatDual,
+				JIRAAccount:         "abc@xyz.com",
+				APITokenSecretID:    "projects/123456/secrets/api-token/versions/4",
+				Hint:                "Jira Issue Key under JVS project",
+				IssueBaseURL:        "https://example.atlassian.net",
+			},
+			wantErr: "empty JIRA_PLUGIN_CHANGE_JQL, required with JIRA_PLUGIN_JUSTIFICATION_FORMAT=dual",
+		},
+		{
+			name: "change_jql_without_dual",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = INC",
+				ChangeJql:        "project = CHG",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+			},
+			wantErr: "JIRA_PLUGIN_CHANGE_JQL requires JIRA_PLUGIN_JUSTIFICATION_FORMAT=dual",
+		},
+		{
+			name: "unknown_annotation_field_renderer",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				AnnotationFields: []string{"summary", "customfield_10010:user", "duedate:time"},
+			},
+			wantErr: `invalid renderer "time" of field "duedate"`,
+		},
+		{
+			name: "invalid_warmup_project",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				WarmupProjects:   []string{"INC", "CHG-1"},
+			},
+			wantErr: `invalid jira project key "CHG-1" in JIRA_PLUGIN_WARMUP_PROJECTS`,
+		},
+		{
+			name: "invalid_http_retries",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				HTTPRetries:      6,
+			},
+			wantErr: "invalid JIRA_PLUGIN_HTTP_RETRIES 6, must be between 0 and 5",
+		},
+		{
+			name: "invalid_max_redirects",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				MaxRedirects:     11,
+			},
+			wantErr: "invalid JIRA_PLUGIN_MAX_REDIRECTS 11, must be between 0 and 10",
+		},
+		{
+			name: "invalid_feature_flags",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				FeatureFlags:     []string{"cache=off", "hedging=on"},
+			},
+			wantErr: `invalid JIRA_PLUGIN_FEATURE_FLAGS: unknown feature flag "hedging"`,
+		},
+		{
+			name: "revocation_topic",
+			cfg: &PluginConfig{
+				JIRAEndpoint:          "https://example.atlassian.net/rest/api/3",
+				Jql:                   "project = JRA",
+				JIRAAccount:           "abc@xyz.com",
+				APITokenSecretID:      "projects/123456/secrets/api-token/versions/4",
+				Hint:                  "Jira Issue Key under JVS project",
+				IssueBaseURL:          "https://example.atlassian.net",
+				RevocationDestination: "projects/my-project/topics/jvs-revocations",
+			},
+		},
+		{
+			name: "invalid_revocation",
+			cfg: &PluginConfig{
+				JIRAEndpoint:          "https://example.atlassian.net/rest/api/3",
+				Jql:                   "project = JRA",
+				JIRAAccount:           "abc@xyz.com",
+				APITokenSecretID:      "projects/123456/secrets/api-token/versions/4",
+				Hint:                  "Jira Issue Key under JVS project",
+				IssueBaseURL:          "https://example.atlassian.net",
+				RevocationDestination: "my-project/jvs-revocations",
+				RevocationInterval:    -time.Minute,
+			},
+			wantErr: `invalid JIRA_PLUGIN_REVOCATION_INTERVAL -1m0s, must not be negative
+invalid JIRA_PLUGIN_REVOCATION_DESTINATION "my-project/jvs-revocations", must be an https url or a projects/<project>/topics/<topic> pub/sub topic`,
+		},
+		{
+			name: "revocation_http",
+			cfg: &PluginConfig{
+				JIRAEndpoint:          "https://example.atlassian.net/rest/api/3",
+				Jql:                   "project = JRA",
+				JIRAAccount:           "abc@xyz.com",
+				APITokenSecretID:      "projects/123456/secrets/api-token/versions/4",
+				Hint:                  "Jira Issue Key under JVS project",
+				IssueBaseURL:          "https://example.atlassian.net",
+				RevocationDestination: "http://jvs.internal/revocations",
+			},
+			wantErr: "invalid JIRA_PLUGIN_REVOCATION_DESTINATION http://jvs.internal/revocations, must use https",
+		},
+		{
+			name: "revocation_audience_with_topic",
+			cfg: &PluginConfig{
+				JIRAEndpoint:          "https://example.atlassian.net/rest/api/3",
+				Jql:                   "project = JRA",
+				JIRAAccount:           "abc@xyz.com",
+				APITokenSecretID:      "projects/123456/secrets/api-token/versions/4",
+				Hint:                  "Jira Issue Key under JVS project",
+				IssueBaseURL:          "https://example.atlassian.net",
+				RevocationDestination: "projects/my-project/topics/jvs-revocations",
+				RevocationAudience:    "https://jvs.example.com",
+			},
+			wantErr: "JIRA_PLUGIN_REVOCATION_AUDIENCE cannot be used with a pub/sub topic",
+		},
+		{
+			name: "invalid_category",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				Category:         "jira/ops",
+			},
+			wantErr: `invalid JIRA_PLUGIN_CATEGORY "jira/ops"`,
+		},
+		{
+			name: "project_base_url_http",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				ProjectBaseURLs:  []string{"OPS=https://ops.atlassian.net", "sec=http://jira.internal"},
+			},
+			wantErr: "invalid JIRA_PLUGIN_PROJECT_BASE_URLS base url http://jira.internal of project SEC, must use https unless JIRA_PLUGIN_ALLOW_HTTP is set",
+		},
+		{
+			name: "invalid_sample_rate",
+			cfg: &PluginConfig{
+				JIRAEndpoint:      "https://example.atlassian.net/rest/api/3",
+				Jql:               "project = JRA",
+				JIRAAccount:       "abc@xyz.com",
+				APITokenSecretID:  "projects/123456/secrets/api-token/versions/4",
+				Hint:              "Jira Issue Key under JVS project",
+				IssueBaseURL:      "https://example.atlassian.net",
+				SampleRate:        5,
+				SampleDestination: "gs://my-bucket/samples",
+			},
+			wantErr: "invalid JIRA_PLUGIN_SAMPLE_RATE 5, must be between 0 and 1",
+		},
+		{
+			name: "missing_sample_destination",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				SampleRate:       0.05,
+			},
+			wantErr: "missing JIRA_PLUGIN_SAMPLE_DESTINATION, required with JIRA_PLUGIN_SAMPLE_RATE",
+		},
+		{
+			name: "sample_destination_missing_bucket",
+			cfg: &PluginConfig{
+				JIRAEndpoint:      "https://example.atlassian.net/rest/api/3",
+				Jql:               "project = JRA",
+				JIRAAccount:       "abc@xyz.com",
+				APITokenSecretID:  "projects/123456/secrets/api-token/versions/4",
+				Hint:              "Jira Issue Key under JVS project",
+				IssueBaseURL:      "https://example.atlassian.net",
+				SampleRate:        0.05,
+				SampleDestination: "gs://",
+			},
+			wantErr: `invalid JIRA_PLUGIN_SAMPLE_DESTINATION "gs://", missing bucket`,
+		},
+		{
+			name: "invalid_fault_injection_rate",
+			cfg: &PluginConfig{
+				JIRAEndpoint:       "https://example.atlassian.net/rest/api/3",
+				Jql:                "project = JRA",
+				JIRAAccount:        "abc@xyz.com",
+				APITokenSecretID:   "projects/123456/secrets/api-token/versions/4",
+				Hint:               "Jira Issue Key under JVS project",
+				IssueBaseURL:       "https://example.atlassian.net",
+				FaultInjectionRate: -0.1,
+			},
+			wantErr: "invalid JIRA_PLUGIN_FAULT_INJECTION_RATE -0.1, must be between 0 and 1",
+		},
+		{
+			name: "expression_in_search_mode",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				MatchMode:        MatchModeSearch,
+				Expression:       "issue.dueDate != null",
+			},
+			wantErr: "JIRA_PLUGIN_EXPRESSION cannot be used with JIRA_PLUGIN_MATCH_MODE=search",
+		},
+		{
+			name: "invalid_not_found_cache",
+			cfg: &PluginConfig{
+				JIRAEndpoint:      "https://example.atlassian.net/rest/api/3",
+				Jql:               "project = JRA",
+				JIRAAccount:       "abc@xyz.com",
+				APITokenSecretID:  "projects/123456/secrets/api-token/versions/4",
+				Hint:              "Jira Issue Key under JVS project",
+				IssueBaseURL:      "https://example.atlassian.net",
+				NotFoundCacheTTL:  -time.Second,
+				NotFoundCacheSize: -1,
+			},
+			wantErr: "invalid JIRA_PLUGIN_NOT_FOUND_CACHE_TTL -1s, must be positive\ninvalid JIRA_PLUGIN_NOT_FOUND_CACHE_SIZE -1, must be positive",
+		},
+		{
+			name: "invalid_cache_max_staleness",
+			cfg: &PluginConfig{
+				JIRAEndpoint:      "https://example.atlassian.net/rest/api/3",
+				Jql:               "project = JRA",
+				JIRAAccount:       "abc@xyz.com",
+				APITokenSecretID:  "projects/123456/secrets/api-token/versions/4",
+				Hint:              "Jira Issue Key under JVS project",
+				IssueBaseURL:      "https://example.atlassian.net",
+				CacheMaxStaleness: -time.Minute,
+			},
+			wantErr: "invalid JIRA_PLUGIN_CACHE_MAX_STALENESS -1m0s, must be positive",
+		},
+		{
+			name: "cache_encryption_without_cache",
+			cfg: &PluginConfig{
+				JIRAEndpoint:                "https://example.atlassian.net/rest/api/3",
+				Jql:                         "project = JRA",
+				JIRAAccount:                 "abc@xyz.com",
+				APITokenSecretID:            "projects/123456/secrets/api-token/versions/4",
+				Hint:                        "Jira Issue Key under JVS project",
+				IssueBaseURL:                "https://example.atlassian.net",
+				CacheEncryptionKeySecretIDs: []string{"projects/123456/secrets/cache-key/versions/1"},
+			},
+			wantErr: "JIRA_PLUGIN_CACHE_ENCRYPTION_KEY_SECRET_IDS requires JIRA_PLUGIN_CACHE_PATH",
+		},
+		{
+			name: "invalid_regional_endpoints",
+			cfg: &PluginConfig{
+				JIRAEndpoint:        "https://example.atlassian.net/rest/api/3",
+				Jql:                 "project = JRA",
+				JIRAAccount:         "abc@xyz.com",
+				APITokenSecretID:    "projects/123456/secrets/api-token/versions/4",
+				Hint:                "Jira Issue Key under JVS project",
+				IssueBaseURL:        "https://example.atlassian.net",
+				RegionalEndpoints:   []string{"http://jira-eu.example.com/rest/api/3", "jira-asia"},
+				RegionProbeInterval: -time.Second,
+			},
+			wantErr: "invalid JIRA_PLUGIN_REGIONAL_ENDPOINTS http://jira-eu.example.com/rest/api/3, must use https unless JIRA_PLUGIN_ALLOW_HTTP is set\n" +
+				"invalid JIRA_PLUGIN_REGIONAL_ENDPOINTS: invalid endpoint jira-asia, must be an http or https url\n" +
+				"invalid JIRA_PLUGIN_REGION_PROBE_INTERVAL -1s, must be positive",
+		},
+		{
+			name: "redis_without_url",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				StateBackend:     StateBackendRedis,
+				CachePath:        "/var/cache/jvs-plugin-jira.db",
+			},
+			wantErr: "JIRA_PLUGIN_STATE_BACKEND=redis requires JIRA_PLUGIN_REDIS_URL\n" +
+				"JIRA_PLUGIN_CACHE_PATH cannot be used with JIRA_PLUGIN_STATE_BACKEND=redis",
+		},
+		{
+			name: "invalid_redis",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				StateBackend:     StateBackendRedis,
+				RedisURL:         "http://10.0.0.3:6379",
+			},
+			wantErr: "invalid JIRA_PLUGIN_REDIS_URL: invalid redis url http://10.0.0.3:6379, must be redis://host:port or rediss://host:port",
+		},
+		{
+			name: "invalid_state_backend",
+			cfg: &PluginConfig{
+				JIRAEndpoint:          "https://example.atlassian.net/rest/api/3",
+				Jql:                   "project = JRA",
+				JIRAAccount:           "abc@xyz.com",
+				APITokenSecretID:      "projects/123456/secrets/api-token/versions/4",
+				Hint:                  "Jira Issue Key under JVS project",
+				IssueBaseURL:          "https://example.atlassian.net",
+				StateBackend:          "memcached",
+				RedisPasswordSecretID: "projects/123456/secrets/redis-auth/versions/1",
+			},
+			wantErr: `invalid JIRA_PLUGIN_STATE_BACKEND "memcached", must be one of memory, redis` + "\n" +
+				"JIRA_PLUGIN_REDIS_PASSWORD_SECRET_ID requires JIRA_PLUGIN_REDIS_URL",
+		},
+		{
+			name: "invalid_messages",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				MessageLocale:    "en",
+				Messages:         "de:no_match=Nicht freigegeben; de:nomatch=Nicht freigegeben",
+			},
+			wantErr: `invalid JIRA_PLUGIN_MESSAGE_LOCALE or JIRA_PLUGIN_MESSAGES: invalid message "de:nomatch=Nicht freigegeben", unknown code "nomatch"`,
+		},
+		{
+			name: "messages_without_locale",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				Messages:         "de:no_match=Nicht freigegeben",
+			},
+			wantErr: "JIRA_PLUGIN_MESSAGES requires JIRA_PLUGIN_MESSAGE_LOCALE",
+		},
+		{
+			name: "invalid_max_issue_age",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				MaxIssueAge:      -time.Hour,
+				MaxResolvedAge:   -time.Minute,
+			},
+			wantErr: "invalid JIRA_PLUGIN_MAX_ISSUE_AGE -1h0m0s, must be positive\n" +
+				"invalid JIRA_PLUGIN_MAX_RESOLVED_AGE -1m0s, must be positive",
+		},
+		{
+			name: "invalid_value_limits",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				MaxValueLength:   -1,
+				ValueCharset:     "latin1",
+			},
+			wantErr: "invalid JIRA_PLUGIN_MAX_VALUE_LENGTH -1, must be positive\n" +
+				`invalid JIRA_PLUGIN_VALUE_CHARSET "latin1", must be one of printable, ascii`,
+		},
+		{
+			name: "allowed_hosts",
+			cfg: &PluginConfig{
+				JIRAEndpoint:      "https://example.atlassian.net/rest/api/3",
+				RegionalEndpoints: []string{"https://jira-eu.example.com:8443/rest/api/3"},
+				AllowedHosts:      []string{"example.atlassian.net", "jira-eu.example.com:443", "https://example.com"},
+				Jql:               "project = JRA",
+				JIRAAccount:       "abc@xyz.com",
+				APITokenSecretID:  "projects/123456/secrets/api-token/versions/4",
+				Hint:              "Jira Issue Key under JVS project",
+				IssueBaseURL:      "https://example.atlassian.net",
+			},
+			wantErr: `invalid JIRA_PLUGIN_ALLOWED_HOSTS: invalid allowed host "https://example.com", must be a host with an optional port` + "\n" +
+				`endpoint host "jira-eu.example.com:8443" is not in JIRA_PLUGIN_ALLOWED_HOSTS`,
+		},
+		{
+			name: "issue_snapshot_hash_in_search_mode",
+			cfg: &PluginConfig{
+				JIRAEndpoint:      "https://example.atlassian.net/rest/api/3",
+				Jql:               "project = JRA",
+				JIRAAccount:       "abc@xyz.com",
+				APITokenSecretID:  "projects/123456/secrets/api-token/versions/4",
+				Hint:              "Jira Issue Key under JVS project",
+				IssueBaseURL:      "https://example.atlassian.net",
+				MatchMode:         MatchModeSearch,
+				IssueSnapshotHash: true,
+			},
+			wantErr: "JIRA_PLUGIN_ISSUE_SNAPSHOT_HASH cannot be used with JIRA_PLUGIN_MATCH_MODE=search",
+		},
+		{
+			name: "archived_issues_in_search_mode",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				MatchMode:        MatchModeSearch,
+				ArchivedIssues:   "hide",
+			},
+			wantErr: "JIRA_PLUGIN_ARCHIVED_ISSUES cannot be used with JIRA_PLUGIN_MATCH_MODE=search\n" +
+				`invalid JIRA_PLUGIN_ARCHIVED_ISSUES "hide", must be one of allow, reject`,
+		},
+		{
+			name: "invalid_identity_mapping",
+			cfg: &PluginConfig{
+				JIRAEndpoint:            "https://example.atlassian.net/rest/api/3",
+				Jql:                     "assignee = {{.Requestor}}",
+				JIRAAccount:             "abc@xyz.com",
+				APITokenSecretID:        "projects/123456/secrets/api-token/versions/4",
+				Hint:                    "Jira Issue Key under JVS project",
+				IssueBaseURL:            "https://example.atlassian.net",
+				IdentityDomainRewrites:  []string{"example.com"},
+				IdentityMappingFile:     "/etc/jvs/identities.csv",
+				IdentityMappingSecretID: "projects/123456/secrets/identities/versions/1",
+			},
+			wantErr: "only one of JIRA_PLUGIN_IDENTITY_MAPPING_FILE and JIRA_PLUGIN_IDENTITY_MAPPING_SECRET_ID may be set\n" +
+				`invalid JIRA_PLUGIN_IDENTITY_DOMAIN_REWRITES or JIRA_PLUGIN_IDENTITY_MAPPING_FILE: invalid domain rewrite "example.com", must be <domain>=<domain>`,
+		},
+		{
+			name: "invalid_account_cache_ttl",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "assignee = {{.Requestor}}",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				AccountIDs:       true,
+				AccountCacheTTL:  -time.Minute,
+			},
+			wantErr: "invalid JIRA_PLUGIN_ACCOUNT_CACHE_TTL -1m0s, must be positive",
+		},
+		{
+			name: "invalid_field_redactions",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				AnnotationFields: []string{"summary"},
+				FieldRedactions:  "summary max many",
+			},
+			wantErr: `invalid JIRA_PLUGIN_FIELD_REDACTIONS: invalid field redaction "summary max many", the value of max must be a positive number of bytes`,
+		},
+		{
+			name: "field_redactions_without_annotation_field",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				AnnotationFields: []string{"summary:text"},
+				FieldRedactions:  "summary max 80; description max 80",
+			},
+			wantErr: `invalid JIRA_PLUGIN_FIELD_REDACTIONS, field "description" is not in JIRA_PLUGIN_ANNOTATION_FIELDS`,
+		},
+		{
+			name: "invalid_site_timezone",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				SiteTimezone:     "Mars/Olympus",
+			},
+			wantErr: "invalid JIRA_PLUGIN_SITE_TIMEZONE or JIRA_PLUGIN_CLOCK_SKEW: failed to load time zone: unknown time zone Mars/Olympus",
+		},
+		{
+			name: "invalid_clock_skew",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				ClockSkew:        2 * time.Hour,
+			},
+			wantErr: "invalid JIRA_PLUGIN_SITE_TIMEZONE or JIRA_PLUGIN_CLOCK_SKEW: invalid clock skew 2h0m0s, must be between 0 and 1h0m0s",
+		},
+		{
+			name: "invalid_requestor_quota",
+			cfg: &PluginConfig{
+				JIRAEndpoint:         "https://example.atlassian.net/rest/api/3",
+				Jql:                  "project = JRA",
+				JIRAAccount:          "abc@xyz.com",
+				APITokenSecretID:     "projects/123456/secrets/api-token/versions/4",
+				Hint:                 "Jira Issue Key under JVS project",
+				IssueBaseURL:         "https://example.atlassian.net",
+				RequestorQuota:       -1,
+				RequestorQuotaWindow: -time.Hour,
+				RequestorQuotaMode:   "block",
+			},
+			wantErr: "invalid JIRA_PLUGIN_REQUESTOR_QUOTA -1, must be positive\n" +
+				"invalid JIRA_PLUGIN_REQUESTOR_QUOTA_WINDOW -1h0m0s, must be positive\n" +
+				`invalid JIRA_PLUGIN_REQUESTOR_QUOTA_MODE "block", must be one of reject, warn`,
+		},
 		{
 			name: "empty_jql",
 			cfg: &PluginConfig{
@@ -318,6 +1109,90 @@ func TestPluginConfig_Validate(t *testing.T) {
 			},
 			wantErr: "empty JIRA_PLUGIN_ISSUE_BASE_URL",
 		},
+		{
+			name: "invalid_audit_syslog",
+			cfg: &PluginConfig{
+				JIRAEndpoint:       "https://example.atlassian.net/rest/api/3",
+				Jql:                "project = JRA and assignee != jsmith",
+				JIRAAccount:        "abc@xyz.com",
+				APITokenSecretID:   "projects/123456/secrets/api-token/versions/4",
+				Hint:               "Jira Issue Key under JVS project",
+				IssueBaseURL:       "https://example.atlassian.net",
+				AuditSyslogAddress: "siem.example.com:514",
+				AuditSyslogNetwork: "unix",
+				AuditFormat:        "leef",
+			},
+			wantErr: `invalid JIRA_PLUGIN_AUDIT_SYSLOG_NETWORK "unix"`,
+		},
+		{
+			name: "negative_cache_ttl",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA and assignee != jsmith",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				CachePath:        "/tmp/decisions.db",
+				CacheTTL:         -time.Minute,
+			},
+			wantErr: "invalid JIRA_PLUGIN_CACHE_TTL -1m0s, must be positive",
+		},
+		{
+			name: "negative_quota",
+			cfg: &PluginConfig{
+				JIRAEndpoint:       "https://example.atlassian.net/rest/api/3",
+				Jql:                "project = JRA and assignee != jsmith",
+				JIRAAccount:        "abc@xyz.com",
+				APITokenSecretID:   "projects/123456/secrets/api-token/versions/4",
+				Hint:               "Jira Issue Key under JVS project",
+				IssueBaseURL:       "https://example.atlassian.net",
+				QuotaRate:          -1,
+				QuotaMaxConcurrent: -1,
+			},
+			wantErr: "invalid JIRA_PLUGIN_QUOTA_RATE -1, must be positive\n" +
+				"invalid JIRA_PLUGIN_QUOTA_MAX_CONCURRENT -1, must be positive",
+		},
+		{
+			name: "search_mode_with_order_by",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA ORDER BY created",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				MatchMode:        MatchModeSearch,
+			},
+			wantErr: "JIRA_PLUGIN_JQL cannot be used with JIRA_PLUGIN_MATCH_MODE=search: ORDER BY is not supported",
+		},
+		{
+			name: "search_mode_with_candidate_order_by",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				CandidateJql:     "project = JRA ORDER BY created",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				MatchMode:        MatchModeSearch,
+			},
+			wantErr: "JIRA_PLUGIN_CANDIDATE_JQL cannot be used with JIRA_PLUGIN_MATCH_MODE=search: ORDER BY is not supported",
+		},
+		{
+			name: "invalid_match_mode",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "https://example.atlassian.net/rest/api/3",
+				Jql:              "project = JRA",
+				JIRAAccount:      "abc@xyz.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "https://example.atlassian.net",
+				MatchMode:        "scan",
+			},
+			wantErr: `invalid JIRA_PLUGIN_MATCH_MODE "scan"`,
+		},
 	}
 
 	for _, tc := range cases {
@@ -333,3 +1208,53 @@ func TestPluginConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestPluginConfig_Account(t *testing.T) {
+	t.Parallel()
+
+	accessSecret := func(_ context.Context, name string) (string, error) {
+		if name == "projects/123456/secrets/missing/versions/1" {
+			return "", fmt.Errorf("secret not found")
+		}
+		return "bot@example.com\n", nil
+	}
+
+	cases := []struct {
+		name    string
+		cfg     *PluginConfig
+		want    string
+		wantErr string
+	}{
+		{
+			name: "plain",
+			cfg:  &PluginConfig{JIRAAccount: "abc@xyz.com"},
+			want: "abc@xyz.com",
+		},
+		{
+			name: "secret",
+			cfg:  &PluginConfig{AccountSecretID: "projects/123456/secrets/account/versions/1"},
+			want: "bot@example.com",
+		},
+		{
+			name:    "secret_error",
+			cfg:     &PluginConfig{AccountSecretID: "projects/123456/secrets/missing/versions/1"},
+			wantErr: "failed to fetch account: secret not found",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tc.cfg.account(context.Background(), accessSecret)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if got != tc.want {
+				t.Errorf("account() got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
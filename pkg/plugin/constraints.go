@@ -0,0 +1,218 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Field constraint operators, see [WithFieldConstraints].
+const (
+	constraintEquals    = "=="
+	constraintNotEquals = "!="
+	constraintIn        = "in"
+	constraintContains  = "contains"
+	constraintBefore    = "before"
+	constraintAfter     = "after"
+)
+
+// fieldConstraint is a declarative check of an issue field, e.g.
+// "status in [Open, In Progress]".
+type fieldConstraint struct {
+	name   string
+	op     string
+	values []string
+
+	// ref is the time the field is compared with by before and after, read
+	// with clock.
+	ref   *timeReference
+	clock *jiraClock
+}
+
+// WithFieldConstraints makes the validator reject issues whose fields do
+// not satisfy every constraint, a simple alternative to JQL for common
+// policies. A constraint is a field id, an operator and a value:
+//
+//	status in [Open, In Progress]
+//	labels contains approved
+//	customfield_10010 == Yes
+//	resolution != Won't Do
+//	customfield_10020 before now
+//	duedate after now-7d
+//
+// Values are compared case-insensitively with the field rendered like an
+// annotation field, i.e. by the name or value of objects. contains matches
+// an element of a list field, or a substring of a text field. before and
+// after compare a date or date-time field with a time, or with now plus or
+// minus a duration like 2h or 7d, see [WithSiteClock] for the time zone and
+// the skew tolerance. A missing field only satisfies !=. It only applies to
+// [Validator.MatchIssue], and cannot be used in search mode, which does not
+// fetch the issue.
+func WithFieldConstraints(constraints []string) ValidatorOption {
+	return func(v *Validator) error {
+		if v.searchJQLPrefix != "" {
+			return fmt.Errorf("field constraints cannot be used in search mode")
+		}
+		for _, s := range constraints {
+			c, err := parseFieldConstraint(s)
+			if err != nil {
+				return err
+			}
+			c.clock = v.clock
+			v.issueChecks = append(v.issueChecks, c)
+		}
+		v.issueFieldsQuery = v.fieldsQuery()
+		return nil
+	}
+}
+
+// splitFieldConstraints splits constraints separated by semicolons.
+func splitFieldConstraints(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// parseFieldConstraint parses a constraint, see [WithFieldConstraints].
+func parseFieldConstraint(s string) (*fieldConstraint, error) {
+	name, rest, _ := strings.Cut(strings.TrimSpace(s), " ")
+	if !fieldNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid field constraint %q, invalid jira field name %q", s, name)
+	}
+	op, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("invalid field constraint %q, must be <field> <operator> <value>", s)
+	}
+
+	c := &fieldConstraint{name: name, op: op}
+	switch op {
+	case constraintEquals, constraintNotEquals, constraintContains:
+		c.values = []string{unquote(value)}
+	case constraintIn:
+		if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+			return nil, fmt.Errorf("invalid field constraint %q, the value of in must be a list like [a, b]", s)
+		}
+		for _, v := range strings.Split(value[1:len(value)-1], ",") {
+			if v = unquote(strings.TrimSpace(v)); v != "" {
+				c.values = append(c.values, v)
+			}
+		}
+		if len(c.values) == 0 {
+			return nil, fmt.Errorf("invalid field constraint %q, empty list", s)
+		}
+	case constraintBefore, constraintAfter:
+		ref, err := parseTimeReference(unquote(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid field constraint %q: %w", s, err)
+		}
+		c.values, c.ref = []string{unquote(value)}, ref
+	default:
+		return nil, fmt.Errorf("invalid field constraint %q, unknown operator %q, must be one of ==, !=, in, contains, before, after", s, op)
+	}
+	return c, nil
+}
+
+// unquote removes the double quotes around s, if any.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func (c *fieldConstraint) field() string {
+	return c.name
+}
+
+func (c *fieldConstraint) reason() string {
+	return ErrorCodeFieldConstraint
+}
+
+func (c *fieldConstraint) check(fields map[string]json.RawMessage) error {
+	value, ok := renderField(fields[c.name])
+	equal := func(v string) bool { return strings.EqualFold(value, v) }
+
+	switch c.op {
+	case constraintEquals:
+		if !ok || !equal(c.values[0]) {
+			return fmt.Errorf("issue field %s is %q, must be %q", c.name, value, c.values[0])
+		}
+	case constraintNotEquals:
+		if ok && equal(c.values[0]) {
+			return fmt.Errorf("issue field %s must not be %q", c.name, c.values[0])
+		}
+	case constraintIn:
+		if !ok || !slices.ContainsFunc(c.values, equal) {
+			return fmt.Errorf("issue field %s is %q, must be one of [%s]", c.name, value, strings.Join(c.values, ", "))
+		}
+	case constraintContains:
+		if !ok || !containsFold(fields[c.name], value, c.values[0]) {
+			return fmt.Errorf("issue field %s does not contain %q", c.name, c.values[0])
+		}
+	case constraintBefore, constraintAfter:
+		return c.checkTime(value, ok)
+	}
+	return nil
+}
+
+// checkTime compares the rendered time value of the field with the
+// reference time of the constraint.
+func (c *fieldConstraint) checkTime(value string, ok bool) error {
+	clock := c.clock
+	if clock == nil {
+		clock = defaultJiraClock
+	}
+	if !ok {
+		return fmt.Errorf("issue field %s is empty, must be %s %s", c.name, c.op, c.values[0])
+	}
+	t, err := clock.parse(value)
+	if err != nil {
+		return fmt.Errorf("issue field %s: %w", c.name, err)
+	}
+	ref := c.ref.at(clock)
+	if c.op == constraintBefore && !clock.before(t, ref) || c.op == constraintAfter && !clock.after(t, ref) {
+		return fmt.Errorf("issue field %s is %s, must be %s %s", c.name, t.UTC().Format(time.RFC3339), c.op, c.values[0])
+	}
+	return nil
+}
+
+// containsFold reports whether a list field has an element equal to want,
+// or a text field has want as a substring, ignoring case.
+func containsFold(raw json.RawMessage, rendered, want string) bool {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return false
+		}
+		for _, e := range elems {
+			if s, ok := renderField(e); ok && strings.EqualFold(s, want) {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.Contains(strings.ToLower(rendered), strings.ToLower(want))
+}
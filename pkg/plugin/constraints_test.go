@@ -0,0 +1,185 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestParseFieldConstraint(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{name: "equals", in: "customfield_10010 == Yes"},
+		{name: "not_equals_quoted", in: `resolution != "Won't Do"`},
+		{name: "in", in: "status in [Open, In Progress]"},
+		{name: "contains", in: "labels contains approved"},
+		{name: "before", in: "duedate before 2026-11-01"},
+		{name: "after_relative", in: "updated after now-7d"},
+		{
+			name:    "before_invalid_time",
+			in:      "duedate before tomorrow",
+			wantErr: `invalid time "tomorrow"`,
+		},
+		{
+			name:    "after_invalid_duration",
+			in:      "updated after now-7w",
+			wantErr: "must be now, now+<duration> or now-<duration>",
+		},
+		{
+			name:    "invalid_field",
+			in:      "custom-field == Yes",
+			wantErr: `invalid jira field name "custom-field"`,
+		},
+		{
+			name:    "unknown_operator",
+			in:      "status like Open",
+			wantErr: `unknown operator "like"`,
+		},
+		{
+			name:    "missing_value",
+			in:      "status ==",
+			wantErr: "must be <field> <operator> <value>",
+		},
+		{
+			name:    "in_without_list",
+			in:      "status in Open",
+			wantErr: "the value of in must be a list like [a, b]",
+		},
+		{
+			name:    "in_empty_list",
+			in:      "status in [ ]",
+			wantErr: "empty list",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseFieldConstraint(tc.in)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestFieldConstraint_Check(t *testing.T) {
+	t.Parallel()
+
+	fields := map[string]json.RawMessage{
+		"status":            json.RawMessage(`{"name":"In Progress"}`),
+		"labels":            json.RawMessage(`["change-approved","prod"]`),
+		"summary":           json.RawMessage(`"Rotate the production database credentials"`),
+		"customfield_10010": json.RawMessage(`{"value":"Yes"}`),
+		"resolution":        json.RawMessage(`null`),
+		"duedate":           json.RawMessage(`"2026-10-16"`),
+		"updated":           json.RawMessage(`"2026-10-16T09:30:00.000+0200"`),
+	}
+
+	cases := []struct {
+		name       string
+		constraint string
+		wantErr    string
+	}{
+		{name: "in", constraint: "status in [Open, in progress]"},
+		{
+			name:       "not_in",
+			constraint: "status in [Open]",
+			wantErr:    `issue field status is "In Progress", must be one of [Open]`,
+		},
+		{name: "list_contains", constraint: "labels contains Change-Approved"},
+		{
+			name:       "list_does_not_contain",
+			constraint: "labels contains approved",
+			wantErr:    `issue field labels does not contain "approved"`,
+		},
+		{name: "text_contains", constraint: "summary contains database"},
+		{name: "equals_option", constraint: "customfield_10010 == yes"},
+		{
+			name:       "not_equals",
+			constraint: "customfield_10010 != Yes",
+			wantErr:    `issue field customfield_10010 must not be "Yes"`,
+		},
+		{name: "missing_not_equals", constraint: `resolution != "Won't Do"`},
+		{
+			name:       "missing_equals",
+			constraint: "resolution == Done",
+			wantErr:    `issue field resolution is "", must be "Done"`,
+		},
+		{name: "date_before", constraint: "duedate before 2026-10-17"},
+		{
+			name:       "date_not_before",
+			constraint: "duedate before 2026-10-16",
+			wantErr:    "issue field duedate is 2026-10-16T00:00:00Z, must be before 2026-10-16",
+		},
+		{name: "date_time_after", constraint: "updated after 2026-10-16T07:00:00Z"},
+		{
+			name:       "date_time_not_after",
+			constraint: "updated after 2026-10-16T08:00:00",
+			wantErr:    "issue field updated is 2026-10-16T07:30:00Z, must be after 2026-10-16T08:00:00",
+		},
+		{
+			name:       "missing_before",
+			constraint: "resolutiondate before now",
+			wantErr:    "issue field resolutiondate is empty, must be before now",
+		},
+		{
+			name:       "not_a_time",
+			constraint: "summary before now",
+			wantErr:    `issue field summary: invalid time "Rotate the production database credentials"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := parseFieldConstraint(tc.constraint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := testutil.DiffErrString(c.check(fields), tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestWithFieldConstraints_Fields(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = ABCD", "test@test.com", "token",
+		WithAnnotationFields([]string{"summary", "status"}, 0),
+		WithFieldConstraints([]string{"status in [Open]", "labels contains approved"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v.issueFieldsQuery, "fields=key%2Cid%2Csummary%2Cstatus%2Clabels"; got != want {
+		t.Errorf("got query %q, want %q", got, want)
+	}
+}
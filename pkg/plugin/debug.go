@@ -0,0 +1,147 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+const (
+	// debugAnnotationPrefix is the prefix of the diagnostic annotations, see
+	// [PluginConfig.DebugAnnotations].
+	debugAnnotationPrefix = "debug."
+
+	// debugValidationLatency is the key for the time the plugin spent on the
+	// validation in milliseconds.
+	debugValidationLatency = debugAnnotationPrefix + "validation_latency_ms"
+
+	// debugJiraAPICalls is the key for the number of requests made to Jira.
+	debugJiraAPICalls = debugAnnotationPrefix + "jira_api_calls"
+
+	// debugCacheHit is the key for whether the match was served from the
+	// decision cache.
+	debugCacheHit = debugAnnotationPrefix + "cache_hit"
+)
+
+// The stages of a validation timed by [validationStats], in the order they
+// usually run.
+const (
+	stageCacheLookup = "cache_lookup"
+	stageIssueFetch  = "issue_fetch"
+	stageMatch       = "match"
+	stagePolicy      = "policy"
+	stageAnnotation  = "annotation"
+)
+
+// validationStages are the stages logged by [validationStats.logTimings].
+var validationStages = []string{stageCacheLookup, stageIssueFetch, stageMatch, stagePolicy, stageAnnotation}
+
+// validationStats counts what a single validation did, for the diagnostic
+// annotations and the timing breakdown logged in debug mode.
+type validationStats struct {
+	apiCalls atomic.Int32
+	cacheHit atomic.Bool
+
+	mu     sync.Mutex
+	stages map[string]time.Duration
+}
+
+type validationStatsKey struct{}
+
+// withValidationStats returns a context whose Jira requests and cache hits
+// are counted by the returned stats.
+func withValidationStats(ctx context.Context) (context.Context, *validationStats) {
+	s := &validationStats{}
+	return context.WithValue(ctx, validationStatsKey{}, s), s
+}
+
+// validationStatsFromContext returns the stats of the context, or nil when
+// they are not collected.
+func validationStatsFromContext(ctx context.Context) *validationStats {
+	s, _ := ctx.Value(validationStatsKey{}).(*validationStats)
+	return s
+}
+
+// countAPICall counts a request to Jira made with ctx.
+func countAPICall(ctx context.Context) {
+	if s := validationStatsFromContext(ctx); s != nil {
+		s.apiCalls.Add(1)
+	}
+}
+
+// timeStage starts timing a stage of the validation of ctx and returns the
+// function that stops it. A stage timed several times, e.g. once per issue
+// key, adds up.
+func timeStage(ctx context.Context, stage string) func() {
+	s := validationStatsFromContext(ctx)
+	if s == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		d := time.Since(start)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.stages == nil {
+			s.stages = make(map[string]time.Duration, len(validationStages))
+		}
+		s.stages[stage] += d
+	}
+}
+
+// countCacheHit records that the match for ctx was served from the cache.
+func countCacheHit(ctx context.Context) {
+	if s := validationStatsFromContext(ctx); s != nil {
+		s.cacheHit.Store(true)
+	}
+}
+
+// annotate adds the diagnostic annotations to resp.
+func (s *validationStats) annotate(resp *jvspb.ValidateJustificationResponse, latency time.Duration) {
+	if resp.Annotation == nil {
+		resp.Annotation = make(map[string]string, 3)
+	}
+	resp.Annotation[debugValidationLatency] = strconv.FormatInt(latency.Milliseconds(), 10)
+	resp.Annotation[debugJiraAPICalls] = strconv.Itoa(int(s.apiCalls.Load()))
+	resp.Annotation[debugCacheHit] = strconv.FormatBool(s.cacheHit.Load())
+}
+
+// logTimings logs the time spent in each stage of the validation, in
+// milliseconds, as a single debug record. Stages that did not run are zero.
+func (s *validationStats) logTimings(ctx context.Context, logger *slog.Logger, latency time.Duration) {
+	s.mu.Lock()
+	attrs := make([]any, 0, 2*len(validationStages)+6)
+	for _, stage := range validationStages {
+		attrs = append(attrs, stage+"_ms", durationMillis(s.stages[stage]))
+	}
+	s.mu.Unlock()
+	attrs = append(attrs,
+		"total_ms", durationMillis(latency),
+		"jira_api_calls", s.apiCalls.Load(),
+		"cache_hit", s.cacheHit.Load())
+	logger.DebugContext(ctx, "validation timing", attrs...)
+}
+
+// durationMillis returns d in fractional milliseconds.
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
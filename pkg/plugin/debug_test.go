@@ -0,0 +1,161 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestPlugin_Validate_DebugAnnotations(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := &PluginConfig{
+		JIRAEndpoint:     srv.URL,
+		Jql:              "status NOT IN (Done)",
+		JIRAAccount:      "test@test.com",
+		IssueBaseURL:     "https://example.atlassian.net",
+		CachePath:        filepath.Join(t.TempDir(), "decisions.db"),
+		CacheTTL:         defaultCacheTTL,
+		DebugAnnotations: true,
+	}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	req := &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: jiraCategory, Value: "ABCD-1"},
+	}
+
+	// The first validation asks Jira, the second is served from the cache.
+	for _, want := range []map[string]string{
+		{debugJiraAPICalls: "2", debugCacheHit: "false"},
+		{debugJiraAPICalls: "0", debugCacheHit: "true"},
+	} {
+		resp, err := p.Validate(ctx, req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !resp.GetValid() {
+			t.Fatalf("expected a valid response, got errors %q", resp.GetError())
+		}
+
+		got := make(map[string]string)
+		for _, k := range []string{debugJiraAPICalls, debugCacheHit} {
+			got[k] = resp.GetAnnotation()[k]
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("debug annotations unexpected diff (-want,+got):\n%s", diff)
+		}
+		if _, err := strconv.Atoi(resp.GetAnnotation()[debugValidationLatency]); err != nil {
+			t.Errorf("invalid %s annotation: %v", debugValidationLatency, err)
+		}
+	}
+}
+
+func TestPlugin_Validate_TimingLog(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.CachePath = filepath.Join(t.TempDir(), "decisions.db")
+	cfg.CacheTTL = defaultCacheTTL
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := logging.WithLogger(context.Background(), logger)
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: jiraCategory, Value: "ABCD-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The timing is logged without the diagnostic annotations.
+	if _, ok := resp.GetAnnotation()[debugValidationLatency]; ok {
+		t.Errorf("unexpected %s annotation", debugValidationLatency)
+	}
+
+	var record map[string]any
+	for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+		var r map[string]any
+		if json.Unmarshal(line, &r) == nil && r["msg"] == "validation timing" {
+			record = r
+		}
+	}
+	if record == nil {
+		t.Fatalf("no validation timing record in %s", buf.String())
+	}
+	for _, stage := range validationStages {
+		if _, ok := record[stage+"_ms"].(float64); !ok {
+			t.Errorf("record %v has no %s_ms", record, stage)
+		}
+	}
+	if got, want := record["jira_api_calls"], float64(2); got != want {
+		t.Errorf("got %v jira_api_calls, want %v", got, want)
+	}
+}
+
+func TestTimeStage(t *testing.T) {
+	t.Parallel()
+
+	// Without stats timing is a no-op.
+	timeStage(context.Background(), stageMatch)()
+
+	ctx, stats := withValidationStats(context.Background())
+	for i := 0; i < 2; i++ {
+		stop := timeStage(ctx, stageMatch)
+		time.Sleep(time.Millisecond)
+		stop()
+	}
+	if got := stats.stages[stageMatch]; got < 2*time.Millisecond {
+		t.Errorf("got %s for the match stage, want at least 2ms", got)
+	}
+	if got := stats.stages[stagePolicy]; got != 0 {
+		t.Errorf("got %s for the policy stage, want 0", got)
+	}
+}
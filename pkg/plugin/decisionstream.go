@@ -0,0 +1,242 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// decisionStreamBuffer is the number of decisions buffered per subscriber.
+// Decisions a subscriber is too slow to receive are dropped, so a stalled
+// consumer never slows down validations.
+const decisionStreamBuffer = 256
+
+// DecisionStreamMethod is the full name of the server streaming RPC
+// emitting the decisions, see [RegisterDecisionStream]. The request is a
+// google.protobuf.Empty and every response a google.protobuf.Struct holding
+// one [Decision].
+const DecisionStreamMethod = "/jvs_plugin_jira.DecisionStream/Subscribe"
+
+// decisionStream fans the decisions out to the subscribers.
+type decisionStream struct {
+	mu     sync.Mutex
+	subs   map[chan *Decision]struct{}
+	closed bool
+
+	// dropped counts the decisions dropped for slow subscribers.
+	dropped atomic.Uint64
+}
+
+// newDecisionStream returns a decisionStream, or nil when disabled.
+func newDecisionStream(enabled bool) *decisionStream {
+	if !enabled {
+		return nil
+	}
+	return &decisionStream{subs: make(map[chan *Decision]struct{})}
+}
+
+// subscribe returns a channel receiving the decisions published from now
+// on, and a function to unsubscribe. The channel is closed on unsubscribe
+// and when the stream is closed.
+func (s *decisionStream) subscribe() (<-chan *Decision, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan *Decision, decisionStreamBuffer)
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	s.subs[ch] = struct{}{}
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subs[ch]; ok {
+			delete(s.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends the decision to every subscriber with room for it.
+func (s *decisionStream) publish(d *Decision) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subs {
+		select {
+		case ch <- d:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// close ends the subscriptions.
+func (s *decisionStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for ch := range s.subs {
+		delete(s.subs, ch)
+		close(ch)
+	}
+}
+
+// RegisterDecisionStream registers the service of [DecisionStreamMethod] on
+// the gRPC server of the plugin, e.g. the one go-plugin serves, when
+// [PluginConfig.DecisionStream] is set. It lets the JVS server collect the
+// decisions of every plugin centrally instead of scraping their logs.
+func (j *JiraPlugin) RegisterDecisionStream(s *grpc.Server) {
+	if j.decisions == nil {
+		return
+	}
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "jvs_plugin_jira.DecisionStream",
+		HandlerType: (*any)(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Subscribe",
+			Handler:       j.streamDecisions,
+			ServerStreams: true,
+		}},
+	}, j)
+}
+
+// streamDecisions sends the decisions to the stream until the client goes
+// away or the plugin is closed.
+func (j *JiraPlugin) streamDecisions(_ any, stream grpc.ServerStream) error {
+	if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+		return err //nolint:wrapcheck // Want the status error
+	}
+
+	ch, unsubscribe := j.decisions.subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case d, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			msg, err := decisionToStruct(d)
+			if err != nil {
+				return err
+			}
+			if err := stream.SendMsg(msg); err != nil {
+				return err //nolint:wrapcheck // Want the status error
+			}
+		}
+	}
+}
+
+// DecisionSubscription receives the decisions of a plugin, see
+// [SubscribeDecisions].
+type DecisionSubscription struct {
+	stream grpc.ClientStream
+}
+
+// SubscribeDecisions subscribes to the decisions of the plugin served on
+// cc, until ctx is done.
+func SubscribeDecisions(ctx context.Context, cc grpc.ClientConnInterface) (*DecisionSubscription, error) {
+	stream, err := cc.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, DecisionStreamMethod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to decisions: %w", err)
+	}
+	if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to decisions: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to decisions: %w", err)
+	}
+	return &DecisionSubscription{stream: stream}, nil
+}
+
+// Recv blocks until the next decision. It returns io.EOF when the plugin
+// ends the stream, e.g. on shutdown.
+func (s *DecisionSubscription) Recv() (*Decision, error) {
+	msg := &structpb.Struct{}
+	if err := s.stream.RecvMsg(msg); err != nil {
+		return nil, err //nolint:wrapcheck // Want io.EOF and status errors
+	}
+	return decisionFromStruct(msg), nil
+}
+
+// decisionToStruct encodes the decision for the stream.
+func decisionToStruct(d *Decision) (*structpb.Struct, error) {
+	errs := make([]any, 0, len(d.Errors))
+	for _, e := range d.Errors {
+		errs = append(errs, e)
+	}
+	annotation := make(map[string]any, len(d.Annotation))
+	for k, v := range d.Annotation {
+		annotation[k] = v
+	}
+
+	msg, err := structpb.NewStruct(map[string]any{
+		"time":               d.Time.UTC().Format(time.RFC3339Nano),
+		"category":           d.Category,
+		"value":              d.Value,
+		"valid":              d.Valid,
+		"requestor":          d.Requestor,
+		"bypassed":           d.Bypassed,
+		"bypass_token":       d.BypassToken,
+		"errors":             errs,
+		"annotation":         annotation,
+		"insecure_transport": d.InsecureTransport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode decision: %w", err)
+	}
+	return msg, nil
+}
+
+// decisionFromStruct decodes a decision of the stream, ignoring fields it
+// does not know.
+func decisionFromStruct(msg *structpb.Struct) *Decision {
+	f := msg.GetFields()
+	d := &Decision{
+		Category:          f["category"].GetStringValue(),
+		Value:             f["value"].GetStringValue(),
+		Valid:             f["valid"].GetBoolValue(),
+		Requestor:         f["requestor"].GetStringValue(),
+		Bypassed:          f["bypassed"].GetBoolValue(),
+		BypassToken:       f["bypass_token"].GetBoolValue(),
+		InsecureTransport: f["insecure_transport"].GetBoolValue(),
+	}
+	if t, err := time.Parse(time.RFC3339Nano, f["time"].GetStringValue()); err == nil {
+		d.Time = t
+	}
+	for _, v := range f["errors"].GetListValue().GetValues() {
+		d.Errors = append(d.Errors, v.GetStringValue())
+	}
+	if fields := f["annotation"].GetStructValue().GetFields(); len(fields) > 0 {
+		d.Annotation = make(map[string]string, len(fields))
+		for k, v := range fields {
+			d.Annotation[k] = v.GetStringValue()
+		}
+	}
+	return d
+}
@@ -0,0 +1,157 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestDecisionStruct(t *testing.T) {
+	t.Parallel()
+
+	want := &Decision{
+		Time:              time.Date(2024, 3, 1, 12, 0, 0, 5, time.UTC),
+		Category:          "jira",
+		Value:             "ABCD-1",
+		Valid:             false,
+		Requestor:         "user@example.com",
+		Errors:            []string{"no match", "closed"},
+		Annotation:        map[string]string{jiraErrorCode: "x"},
+		InsecureTransport: true,
+	}
+	msg, err := decisionToStruct(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, decisionFromStruct(msg)); diff != "" {
+		t.Errorf("round trip (-want,+got):\n%s", diff)
+	}
+}
+
+func TestDecisionStream_Publish(t *testing.T) {
+	t.Parallel()
+
+	s := newDecisionStream(true)
+	ch, unsubscribe := s.subscribe()
+
+	for i := 0; i < decisionStreamBuffer+1; i++ {
+		s.publish(&Decision{Value: "ABCD-1"})
+	}
+	if got := s.dropped.Load(); got != 1 {
+		t.Errorf("got %d dropped decisions, want 1", got)
+	}
+	if got := len(ch); got != decisionStreamBuffer {
+		t.Errorf("got %d buffered decisions, want %d", got, decisionStreamBuffer)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	s.publish(&Decision{})
+
+	s.close()
+	closedCh, _ := s.subscribe()
+	if _, ok := <-closedCh; ok {
+		t.Errorf("got a decision after close")
+	}
+}
+
+func TestSubscribeDecisions(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.DecisionStream = true
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	p.RegisterDecisionStream(srv)
+	go srv.Serve(lis) //nolint:errcheck // Stopped in cleanup
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	sub, err := SubscribeDecisions(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The subscription is registered once the request reached the server,
+	// validate until a decision comes through.
+	gotCh := make(chan *Decision, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		d, err := sub.Recv()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		gotCh <- d
+	}()
+	req := &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	}
+	var got *Decision
+	for got == nil {
+		if _, err := p.Validate(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case got = <-gotCh:
+		case err := <-errCh:
+			t.Fatal(err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if !got.Valid || got.Value != "ABCD-1" || got.Annotation[jiraIssueID] != "1234" {
+		t.Errorf("got decision %+v, want the valid decision for ABCD-1", got)
+	}
+
+	// Closing the plugin ends the stream.
+	if err := p.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, err := sub.Recv(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Errorf("got err %v, want %v", err, io.EOF)
+			}
+			break
+		}
+	}
+}
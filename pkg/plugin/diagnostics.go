@@ -0,0 +1,230 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abcxyz/jvs-plugin-jira/internal/version"
+)
+
+// maxGoroutineDumpBytes bounds the goroutine stacks of a diagnostic dump.
+const maxGoroutineDumpBytes = 64 << 20
+
+// Diagnostics is the state of a plugin, dumped to debug validations that are
+// stuck or failing, see [JiraPlugin.Diagnostics].
+type Diagnostics struct {
+	Time    time.Time `json:"time"`
+	Version string    `json:"version"`
+
+	// InFlight is the number of validations and cache operations in flight.
+	InFlight int64 `json:"in_flight"`
+
+	// Config is the current configuration, with the credentials of URLs
+	// redacted. Secrets are only referenced by their Secret Manager IDs.
+	Config *PluginConfig `json:"config"`
+
+	// Endpoints is the health of the Jira endpoints, it is empty without
+	// [PluginConfig.RegionalEndpoints].
+	Endpoints []EndpointHealth `json:"endpoints,omitempty"`
+
+	// RateLimit is the rate limit Jira last reported, if any.
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+
+	// APIStats are the requests made to Jira since the last reload.
+	APIStats map[string]EndpointStats `json:"api_stats,omitempty"`
+
+	// Cache describes the decision cache, CacheError is set instead when
+	// its statistics could not be read.
+	Cache      *CacheStats `json:"cache,omitempty"`
+	CacheError string      `json:"cache_error,omitempty"`
+
+	// NotFoundCache describes the cache of issues Jira reported missing.
+	NotFoundCache *NotFoundCacheStats `json:"not_found_cache,omitempty"`
+
+	// FeatureFlags are the feature flags with their overrides.
+	FeatureFlags []FeatureFlag `json:"feature_flags"`
+
+	// Decisions are the last decisions, oldest first, see
+	// [PluginConfig.DiagnosticDecisions]. They are encoded like the
+	// decisions of [DecisionStreamMethod].
+	Decisions []map[string]any `json:"decisions,omitempty"`
+
+	// Goroutines holds the stacks of all goroutines.
+	Goroutines string `json:"goroutines"`
+}
+
+// EndpointHealth is the state of a Jira endpoint, see [Diagnostics].
+type EndpointHealth struct {
+	URL string `json:"url"`
+
+	// Healthy is unset when the last probe or request failed.
+	Healthy bool `json:"healthy"`
+
+	// Selected is set for the endpoint requests are sent to.
+	Selected bool `json:"selected"`
+
+	// Latency is the moving average of the probe latency, zero until the
+	// first successful probe.
+	Latency time.Duration `json:"latency"`
+}
+
+// recentDecisions keeps the last decisions for [JiraPlugin.Diagnostics].
+type recentDecisions struct {
+	mu        sync.Mutex
+	decisions []*Decision
+	next      int
+	full      bool
+}
+
+// newRecentDecisions returns a buffer of size decisions, or nil when size is
+// not positive.
+func newRecentDecisions(size int) *recentDecisions {
+	if size <= 0 {
+		return nil
+	}
+	return &recentDecisions{decisions: make([]*Decision, size)}
+}
+
+func (r *recentDecisions) add(d *Decision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.decisions[r.next] = d
+	r.next = (r.next + 1) % len(r.decisions)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the decisions, oldest first.
+func (r *recentDecisions) list() []*Decision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]*Decision(nil), r.decisions[:r.next]...)
+	}
+	out := make([]*Decision, 0, len(r.decisions))
+	out = append(out, r.decisions[r.next:]...)
+	return append(out, r.decisions[:r.next]...)
+}
+
+// Diagnostics returns the state of the plugin. It works while the plugin is
+// closing, so that a shutdown waiting on stuck validations can be debugged.
+func (j *JiraPlugin) Diagnostics(ctx context.Context) *Diagnostics {
+	s := j.current.Load()
+	d := &Diagnostics{
+		Time:       time.Now().UTC(),
+		Version:    version.HumanVersion,
+		InFlight:   j.life.count.Load(),
+		Config:     s.config,
+		RateLimit:  j.RateLimit(),
+		APIStats:   j.APIStats(),
+		Goroutines: goroutineStacks(),
+	}
+	if s.jira != nil {
+		if v := s.jira.peek(); v != nil && v.regions != nil {
+			d.Endpoints = v.regions.health()
+		}
+	}
+	if j.cache != nil {
+		stats, err := j.CacheStats(ctx)
+		if err != nil {
+			d.CacheError = err.Error()
+		}
+		d.Cache = stats
+	}
+	if stats, ok := j.NotFoundCacheStats(); ok {
+		d.NotFoundCache = &stats
+	}
+	d.FeatureFlags = j.FeatureFlags()
+	if j.recent != nil {
+		for _, dec := range j.recent.list() {
+			msg, err := decisionToStruct(dec)
+			if err != nil {
+				continue
+			}
+			d.Decisions = append(d.Decisions, msg.AsMap())
+		}
+	}
+	return d
+}
+
+// DumpDiagnostics writes the [JiraPlugin.Diagnostics] to w as indented JSON.
+func (j *JiraPlugin) DumpDiagnostics(ctx context.Context, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(j.Diagnostics(ctx)); err != nil {
+		return fmt.Errorf("failed to write diagnostics: %w", err)
+	}
+	return nil
+}
+
+// goroutineStacks returns the stacks of all goroutines, truncated to
+// maxGoroutineDumpBytes.
+func goroutineStacks() string {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxGoroutineDumpBytes {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// redactedConfig returns a copy of cfg with the passwords of its URLs
+// redacted, for [Diagnostics].
+func redactedConfig(cfg *PluginConfig) *PluginConfig {
+	out := *cfg
+	out.JIRAEndpoint = redactURL(cfg.JIRAEndpoint)
+	out.IssueBaseURL = redactURL(cfg.IssueBaseURL)
+	out.RedisURL = redactURL(cfg.RedisURL)
+	out.RevocationDestination = redactURL(cfg.RevocationDestination)
+	if len(cfg.ProjectBaseURLs) > 0 {
+		out.ProjectBaseURLs = make([]string, 0, len(cfg.ProjectBaseURLs))
+		for _, rule := range cfg.ProjectBaseURLs {
+			project, baseURL, _ := strings.Cut(rule, "=")
+			out.ProjectBaseURLs = append(out.ProjectBaseURLs, project+"="+redactURL(baseURL))
+		}
+	}
+	out.RegionalEndpoints = make([]string, 0, len(cfg.RegionalEndpoints))
+	for _, endpoint := range cfg.RegionalEndpoints {
+		out.RegionalEndpoints = append(out.RegionalEndpoints, redactURL(endpoint))
+	}
+	return &out
+}
+
+// redactURL replaces the password of the URL with "xxxxx". A value that does
+// not parse is replaced as a whole, it might hold credentials.
+func redactURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return redactionMarker
+	}
+	return u.Redacted()
+}
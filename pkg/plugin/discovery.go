@@ -0,0 +1,114 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+// ServerInfo is the representation of the [server info] of a Jira site.
+//
+// [server info]: https://developer.atlassian.com/cloud/jira/platform/rest/v2/api-group-server-info/#api-rest-api-2-serverinfo-get
+type ServerInfo struct {
+	BaseURL        string `json:"baseUrl"`
+	Version        string `json:"version"`
+	DeploymentType string `json:"deploymentType"`
+}
+
+// isSiteURL reports whether the endpoint is a bare site URL, e.g.
+// https://your-domain.atlassian.net or https://jira.example.com/jira, rather
+// than a REST API endpoint.
+func isSiteURL(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	return !strings.Contains(u.Path+"/", "/rest/")
+}
+
+// DiscoverEndpoint returns the REST API endpoint of the Jira site at
+// siteURL, which may have a port and a context path. The site is asked for
+// its server info with the REST API version 2 every Jira serves, Jira Cloud
+// sites get the version 3 endpoint, Data Center and Server sites the
+// version 2 one.
+func DiscoverEndpoint(ctx context.Context, siteURL, account, apiToken string) (string, error) {
+	site, err := parseEndpoint(siteURL)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := NewValidator(site.JoinPath("rest", "api", "2").String(), "", account, apiToken)
+	if err != nil {
+		return "", err
+	}
+	info, err := v.ServerInfo(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to discover the rest api endpoint of %s: %w", siteURL, err)
+	}
+
+	version := "2"
+	if strings.EqualFold(info.DeploymentType, "Cloud") {
+		version = "3"
+	}
+	endpoint := site.JoinPath("rest", "api", version).String()
+	logging.FromContext(ctx).InfoContext(ctx, "discovered jira endpoint",
+		"site", siteURL,
+		"endpoint", endpoint,
+		"deployment_type", info.DeploymentType,
+		"version", info.Version)
+	return endpoint, nil
+}
+
+// ServerInfo returns the server info of the Jira site.
+func (v *Validator) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	// Construct [Get Server Info API].
+	//
+	// [Get Server Info API]: https://developer.atlassian.com/cloud/jira/platform/rest/v2/api-group-server-info/#api-rest-api-2-serverinfo-get
+	u := v.endpointURL("serverInfo")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct server info request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	var info ServerInfo
+	if err := v.makeRequest(req, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// newConfigValidator creates the validator for the config with the account
+// and API token, and the options on top of those of the config. The
+// endpoint is discovered first when endpoint discovery is enabled.
+func newConfigValidator(ctx context.Context, cfg *PluginConfig, account, apiToken string, opts ...ValidatorOption) (*Validator, error) {
+	endpoint := cfg.JIRAEndpoint
+	if cfg.EndpointDiscovery {
+		var err error
+		endpoint, err = DiscoverEndpoint(ctx, endpoint, account, apiToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewValidator(endpoint, cfg.Jql, account, apiToken, append(cfg.validatorOptions(), opts...)...)
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestDiscoverEndpoint(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name           string
+		contextPath    string
+		deploymentType string
+		status         int
+		wantPath       string
+		wantErr        string
+	}{
+		{
+			name:           "cloud",
+			deploymentType: "Cloud",
+			wantPath:       "/rest/api/3",
+		},
+		{
+			name:           "data_center_with_context_path",
+			contextPath:    "/jira",
+			deploymentType: "Server",
+			wantPath:       "/jira/rest/api/2",
+		},
+		{
+			name:    "not_jira",
+			status:  http.StatusNotFound,
+			wantErr: "failed to discover the rest api endpoint",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc(tc.contextPath+"/rest/api/2/serverInfo", func(w http.ResponseWriter, r *http.Request) {
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				fmt.Fprintf(w, `{"baseUrl":"https://example.com","version":"9.4.0","deploymentType":%q}`, tc.deploymentType)
+			})
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			got, err := DiscoverEndpoint(ctx, srv.URL+tc.contextPath, "test@test.com", "secrets")
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			if want := srv.URL + tc.wantPath; got != want {
+				t.Errorf("DiscoverEndpoint() got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPlugin_Validate_EndpointDiscovery(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jira/rest/api/2/serverInfo", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"version":"9.4.0","deploymentType":"Server"}`)
+	})
+	mux.HandleFunc("/jira/rest/api/2/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/jira/rest/api/2/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := &PluginConfig{
+		JIRAEndpoint:      srv.URL + "/jira",
+		EndpointDiscovery: true,
+		Jql:               "status NOT IN (Done)",
+		JIRAAccount:       "test@test.com",
+		IssueBaseURL:      srv.URL + "/jira",
+	}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	got, err := p.ValidateValue(ctx, "ABCD-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.GetValid() {
+		t.Errorf("expected a valid response, got errors %q", got.GetError())
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// maxClockSkew is the largest difference between the local clock and the
+	// jira server clock before the doctor reports a failure.
+	maxClockSkew = 30 * time.Second
+)
+
+// CheckStatus is the outcome of a single diagnostic check.
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	CheckFail CheckStatus = "fail"
+	CheckSkip CheckStatus = "skip"
+)
+
+// CheckResult reports the outcome of a single diagnostic check.
+type CheckResult struct {
+	Name     string        `json:"name"`
+	Status   CheckStatus   `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+
+	// err is the error of a failed check.
+	err error
+}
+
+// DoctorReport is the full list of diagnostic check results.
+type DoctorReport struct {
+	Checks []*CheckResult `json:"checks"`
+}
+
+// Healthy reports whether no check has failed.
+func (r *DoctorReport) Healthy() bool {
+	for _, c := range r.Checks {
+		if c.Status == CheckFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns the errors of the failed checks, or nil when no check has
+// failed. A failed config check wraps [ErrInvalidConfig], a failed
+// connectivity check [ErrJiraUnreachable], and Jira rejecting the
+// credentials [ErrJiraAuth].
+func (r *DoctorReport) Err() error {
+	var merr error
+	for _, c := range r.Checks {
+		if c.err != nil {
+			merr = errors.Join(merr, fmt.Errorf("%s: %w", c.Name, c.err))
+		}
+	}
+	return merr
+}
+
+// Doctor sequentially checks that the plugin can run with a given config.
+type Doctor struct {
+	cfg *PluginConfig
+
+	// issueKey is an optional sample issue to fetch and match against the JQL.
+	issueKey string
+
+	// accessSecret fetches the API token and the account secret, it is
+	// mockable for testing.
+	accessSecret func(context.Context, string) (string, error)
+
+	// secrets backs accessSecret, its client is released when Run returns.
+	secrets *secretManager
+
+	// httpClient is used for connectivity and clock checks.
+	httpClient *http.Client
+
+	// now returns the local time, it is mockable for testing.
+	now func() time.Time
+}
+
+// NewDoctor creates a new Doctor. The issueKey is optional, the sample issue
+// check is skipped when it is empty.
+func NewDoctor(cfg *PluginConfig, issueKey string) *Doctor {
+	secrets := newSecretManager(cfg)
+	return &Doctor{
+		cfg:          cfg,
+		issueKey:     issueKey,
+		accessSecret: secrets.access,
+		secrets:      secrets,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		now:          time.Now,
+	}
+}
+
+// Run executes all checks in order. A failing check causes the checks that
+// depend on it to be skipped.
+func (d *Doctor) Run(ctx context.Context) *DoctorReport {
+	r := &DoctorReport{}
+	if d.secrets != nil {
+		defer d.secrets.Close()
+	}
+
+	cfgOK := d.check(r, "config", func() (string, error) {
+		if err := d.cfg.Validate(); err != nil {
+			return "", fmt.Errorf("%w: %w", err, ErrInvalidConfig)
+		}
+		return "configuration is valid", nil
+	})
+
+	var account, apiToken, secondaryAPIToken string
+	secretOK := d.checkIf(r, cfgOK, "secret", func() (string, error) {
+		t, err := d.accessSecret(ctx, d.cfg.APITokenSecretID)
+		if err != nil {
+			return "", err
+		}
+		apiToken = t
+		detail := fmt.Sprintf("accessed %s", d.cfg.APITokenSecretID)
+
+		if d.cfg.SecondaryAPITokenSecretID != "" {
+			if secondaryAPIToken, err = d.accessSecret(ctx, d.cfg.SecondaryAPITokenSecretID); err != nil {
+				return "", err
+			}
+			detail += fmt.Sprintf(", %s", d.cfg.SecondaryAPITokenSecretID)
+		}
+
+		account, err = d.cfg.account(ctx, d.accessSecret)
+		if err != nil {
+			return "", err
+		}
+		if d.cfg.AccountSecretID != "" {
+			detail += fmt.Sprintf(" and %s", d.cfg.AccountSecretID)
+		}
+		return detail, nil
+	})
+
+	var serverDate time.Time
+	connOK := d.checkIf(r, cfgOK, "connectivity", func() (string, error) {
+		detail, date, err := d.checkConnectivity(ctx)
+		serverDate = date
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", err, ErrJiraUnreachable)
+		}
+		return detail, nil
+	})
+
+	var v *Validator
+	authOK := d.checkIf(r, secretOK && connOK, "auth", func() (string, error) {
+		var err error
+		opts := d.cfg.validatorOptions()
+		if secondaryAPIToken != "" {
+			opts = append(opts, WithSecondaryAPIToken(secondaryAPIToken))
+		}
+		v, err = NewValidator(d.cfg.JIRAEndpoint, d.cfg.Jql, account, apiToken, opts...)
+		if err != nil {
+			return "", err
+		}
+		u, err := v.Myself(ctx)
+		if err != nil {
+			return "", err
+		}
+		detail := fmt.Sprintf("authenticated as %q (%s)", u.DisplayName, u.AccountID)
+		if secondaryAPIToken != "" {
+			detail += fmt.Sprintf(" with the %s api token", v.APITokenInUse())
+		}
+		if rl := v.RateLimit(); rl != nil {
+			detail += fmt.Sprintf(", %s", rl)
+		}
+		return detail, nil
+	})
+
+	jqlOK := d.checkIf(r, authOK, "jql", func() (string, error) {
+		result, err := v.ParseJQL(ctx)
+		if err != nil {
+			return "", err
+		}
+		if err := result.err(); err != nil {
+			return "", err
+		}
+		return "JQL parsed successfully", nil
+	})
+
+	if d.issueKey == "" {
+		r.Checks = append(r.Checks, &CheckResult{Name: "issue", Status: CheckSkip, Detail: "no sample issue given"})
+	} else {
+		d.checkIf(r, jqlOK, "issue", func() (string, error) {
+			result, err := v.MatchIssue(ctx, d.issueKey)
+			if err != nil {
+				return "", err
+			}
+			if len(result.Matches) == 0 || len(result.Matches[0].MatchedIssues) == 0 {
+				return "", fmt.Errorf("issue %q does not match the JQL", d.issueKey)
+			}
+			return fmt.Sprintf("issue %q matches the JQL", d.issueKey), nil
+		})
+	}
+
+	d.checkIf(r, connOK, "clock", func() (string, error) {
+		if serverDate.IsZero() {
+			return "", fmt.Errorf("jira server did not return a Date header")
+		}
+		skew := d.now().Sub(serverDate)
+		if skew < 0 {
+			skew = -skew
+		}
+		// The time-based checks tolerate the configured skew.
+		if skew > max(maxClockSkew, d.cfg.ClockSkew) {
+			return "", fmt.Errorf("local clock is %s off the jira server clock", skew)
+		}
+		return fmt.Sprintf("clock skew is %s", skew), nil
+	})
+
+	return r
+}
+
+// checkConnectivity resolves the endpoint host, performs a TLS handshake for
+// https endpoints, and returns the server time from a plain request.
+func (d *Doctor) checkConnectivity(ctx context.Context) (string, time.Time, error) {
+	u, err := url.Parse(d.cfg.JIRAEndpoint)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse endpoint: %w", err)
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, u.Hostname())
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to resolve %q: %w", u.Hostname(), err)
+	}
+	details := []string{fmt.Sprintf("resolved %s to %s", u.Hostname(), strings.Join(addrs, ","))}
+
+	if u.Scheme == "https" {
+		port := u.Port()
+		if port == "" {
+			port = "443"
+		}
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}}
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed TLS handshake: %w", err)
+		}
+		state := conn.(*tls.Conn).ConnectionState() //nolint:forcetypeassert // tls.Dialer always returns *tls.Conn
+		conn.Close()
+		details = append(details, fmt.Sprintf("TLS %s", tls.VersionName(state.Version)))
+		if len(state.PeerCertificates) > 0 {
+			details = append(details, fmt.Sprintf("certificate expires %s",
+				state.PeerCertificates[0].NotAfter.Format(time.RFC3339)))
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to construct request: %w", err)
+	}
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to reach endpoint: %w", err)
+	}
+	resp.Body.Close()
+
+	date, _ := http.ParseTime(resp.Header.Get("Date"))
+	return strings.Join(details, ", "), date, nil
+}
+
+// checkIf runs fn as check name when ok is true, otherwise it records the
+// check as skipped. It returns whether the check passed.
+func (d *Doctor) checkIf(r *DoctorReport, ok bool, name string, fn func() (string, error)) bool {
+	if !ok {
+		r.Checks = append(r.Checks, &CheckResult{Name: name, Status: CheckSkip, Detail: "skipped due to earlier failure"})
+		return false
+	}
+	return d.check(r, name, fn)
+}
+
+// check runs fn as check name, records its result and returns whether it
+// passed.
+func (d *Doctor) check(r *DoctorReport, name string, fn func() (string, error)) bool {
+	start := d.now()
+	detail, err := fn()
+	res := &CheckResult{
+		Name:     name,
+		Status:   CheckPass,
+		Detail:   detail,
+		Duration: d.now().Sub(start),
+	}
+	if err != nil {
+		res.Status = CheckFail
+		res.Detail = err.Error()
+		res.err = err
+	}
+	r.Checks = append(r.Checks, res)
+	return err == nil
+}
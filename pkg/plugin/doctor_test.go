@@ -0,0 +1,251 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestDoctor_Run(t *testing.T) {
+	t.Parallel()
+
+	serverTime := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name      string
+		cfg       *PluginConfig
+		issueKey  string
+		secretErr error
+		now       time.Time
+		parseResp string
+
+		// myselfStatus is the status of the myself response, 200 when zero.
+		myselfStatus int
+
+		want      map[string]CheckStatus
+		wantOK    bool
+		wantErrIs error
+	}{
+		{
+			name:      "healthy",
+			issueKey:  "ABCD",
+			now:       serverTime,
+			parseResp: `{"queries":[{"query":"status NOT IN (Done)","errors":[]}]}`,
+			want: map[string]CheckStatus{
+				"config":       CheckPass,
+				"secret":       CheckPass,
+				"connectivity": CheckPass,
+				"auth":         CheckPass,
+				"jql":          CheckPass,
+				"issue":        CheckPass,
+				"clock":        CheckPass,
+			},
+			wantOK: true,
+		},
+		{
+			name:      "no_sample_issue",
+			now:       serverTime,
+			parseResp: `{"queries":[{"query":"status NOT IN (Done)","errors":[]}]}`,
+			want: map[string]CheckStatus{
+				"config":       CheckPass,
+				"secret":       CheckPass,
+				"connectivity": CheckPass,
+				"auth":         CheckPass,
+				"jql":          CheckPass,
+				"issue":        CheckSkip,
+				"clock":        CheckPass,
+			},
+			wantOK: true,
+		},
+		{
+			name:      "secret_error",
+			issueKey:  "ABCD",
+			secretErr: fmt.Errorf("permission denied"),
+			now:       serverTime,
+			want: map[string]CheckStatus{
+				"config":       CheckPass,
+				"secret":       CheckFail,
+				"connectivity": CheckPass,
+				"auth":         CheckSkip,
+				"jql":          CheckSkip,
+				"issue":        CheckSkip,
+				"clock":        CheckPass,
+			},
+		},
+		{
+			name:      "bad_jql",
+			issueKey:  "ABCD",
+			now:       serverTime,
+			parseResp: `{"queries":[{"query":"status NOT IN (Done","errors":["Error in the JQL Query"]}]}`,
+			want: map[string]CheckStatus{
+				"config":       CheckPass,
+				"secret":       CheckPass,
+				"connectivity": CheckPass,
+				"auth":         CheckPass,
+				"jql":          CheckFail,
+				"issue":        CheckSkip,
+				"clock":        CheckPass,
+			},
+		},
+		{
+			name:      "clock_skew",
+			now:       serverTime.Add(time.Hour),
+			parseResp: `{"queries":[{"query":"status NOT IN (Done)","errors":[]}]}`,
+			want: map[string]CheckStatus{
+				"config":       CheckPass,
+				"secret":       CheckPass,
+				"connectivity": CheckPass,
+				"auth":         CheckPass,
+				"jql":          CheckPass,
+				"issue":        CheckSkip,
+				"clock":        CheckFail,
+			},
+		},
+		{
+			name:         "auth_rejected",
+			now:          serverTime,
+			myselfStatus: http.StatusUnauthorized,
+			want: map[string]CheckStatus{
+				"config":       CheckPass,
+				"secret":       CheckPass,
+				"connectivity": CheckPass,
+				"auth":         CheckFail,
+				"jql":          CheckSkip,
+				"issue":        CheckSkip,
+				"clock":        CheckPass,
+			},
+			wantErrIs: ErrJiraAuth,
+		},
+		{
+			name: "invalid_config",
+			cfg:  &PluginConfig{},
+			now:  serverTime,
+			want: map[string]CheckStatus{
+				"config":       CheckFail,
+				"secret":       CheckSkip,
+				"connectivity": CheckSkip,
+				"auth":         CheckSkip,
+				"jql":          CheckSkip,
+				"issue":        CheckSkip,
+				"clock":        CheckSkip,
+			},
+			wantErrIs: ErrInvalidConfig,
+		},
+		{
+			name: "unreachable",
+			cfg: &PluginConfig{
+				JIRAEndpoint:     "http://127.0.0.1:1",
+				Jql:              "status NOT IN (Done)",
+				JIRAAccount:      "test@test.com",
+				APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+				Hint:             "Jira Issue Key under JVS project",
+				IssueBaseURL:     "http://127.0.0.1:1",
+				AllowHTTP:        true,
+			},
+			now: serverTime,
+			want: map[string]CheckStatus{
+				"config":       CheckPass,
+				"secret":       CheckPass,
+				"connectivity": CheckFail,
+				"auth":         CheckSkip,
+				"jql":          CheckSkip,
+				"issue":        CheckSkip,
+				"clock":        CheckSkip,
+			},
+			wantErrIs: ErrJiraUnreachable,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+			})
+			mux.HandleFunc("/myself", func(w http.ResponseWriter, r *http.Request) {
+				if tc.myselfStatus != 0 {
+					w.WriteHeader(tc.myselfStatus)
+					return
+				}
+				fmt.Fprint(w, `{"accountId":"5b10a2844c20165700ede21g","displayName":"Test","active":true}`)
+			})
+			mux.HandleFunc("/jql/parse", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tc.parseResp)
+			})
+			mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":"1234","key":"ABCD"}`)
+			})
+			mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+			})
+
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			cfg := tc.cfg
+			if cfg == nil {
+				cfg = &PluginConfig{
+					JIRAEndpoint:     srv.URL,
+					Jql:              "status NOT IN (Done)",
+					JIRAAccount:      "test@test.com",
+					APITokenSecretID: "projects/123456/secrets/api-token/versions/4",
+					Hint:             "Jira Issue Key under JVS project",
+					IssueBaseURL:     srv.URL,
+					AllowHTTP:        true,
+				}
+			}
+
+			d := NewDoctor(cfg, tc.issueKey)
+			d.accessSecret = func(context.Context, string) (string, error) {
+				return "secret", tc.secretErr
+			}
+			d.now = func() time.Time { return tc.now }
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			report := d.Run(ctx)
+
+			got := make(map[string]CheckStatus, len(report.Checks))
+			for _, c := range report.Checks {
+				got[c.Name] = c.Status
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Check statuses unexpected diff (-want,+got):\n%s", diff)
+			}
+			if got, want := report.Healthy(), tc.wantOK; got != want {
+				t.Errorf("Healthy() got %t, want %t", got, want)
+			}
+			if got, want := report.Err() == nil, tc.wantOK; got != want {
+				t.Errorf("Err() got %v, want nil %t", report.Err(), want)
+			}
+			if tc.wantErrIs != nil && !errors.Is(report.Err(), tc.wantErrIs) {
+				t.Errorf("Err() got %v, want %v", report.Err(), tc.wantErrIs)
+			}
+		})
+	}
+}
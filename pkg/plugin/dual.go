@@ -0,0 +1,71 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// JustificationFormatDual treats the justification value as a JSON object
+	// with an "incident" and a "change" issue key. The incident is validated
+	// against the JQL, the change against [PluginConfig.ChangeJql].
+	JustificationFormatDual = "dual"
+
+	// jiraChangeIssueID is the key for the Jira Issue ID of the change ticket
+	// of a dual justification in the annotation map of the justification.
+	jiraChangeIssueID = "jira_change_issue_id"
+
+	// jiraChangeIssueURL is the key for the Jira Issue URL of the change
+	// ticket of a dual justification in the annotation map of the
+	// justification.
+	jiraChangeIssueURL = "jira_change_issue_url"
+)
+
+// DualParser parses values like {"incident":"INC-123","change":"CHG-456"}.
+// The incident is the issue key, the change is the change issue key.
+type DualParser struct{}
+
+// dualJustification is the expected shape of a dual justification value.
+type dualJustification struct {
+	Incident string `json:"incident"`
+	Change   string `json:"change"`
+}
+
+// Parse decodes the value as JSON.
+func (p *DualParser) Parse(value string) (*ParsedJustification, error) {
+	var j dualJustification
+	if err := json.Unmarshal([]byte(value), &j); err != nil {
+		return nil, fmt.Errorf("failed to parse justification as JSON: %w", err)
+	}
+	if j.Incident == "" {
+		return nil, fmt.Errorf("missing \"incident\" in justification")
+	}
+	if j.Change == "" {
+		return nil, fmt.Errorf("missing \"change\" in justification")
+	}
+	return &ParsedJustification{IssueKey: j.Incident, ChangeIssueKey: j.Change}, nil
+}
+
+// changeConfig returns the configuration of the validator of change tickets,
+// whose JQL is the change JQL.
+func changeConfig(cfg *PluginConfig) *PluginConfig {
+	change := *cfg
+	change.Jql = cfg.ChangeJql
+	change.CandidateJql = ""
+	change.IssueTypeJql = ""
+	return &change
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestPlugin_ValidateDual(t *testing.T) {
+	t.Parallel()
+
+	matched := func(id int, warnings ...string) *mockValidator {
+		return &mockValidator{result: &MatchResult{Matches: []*Match{{MatchedIssues: []int{id}, Errors: warnings}}}}
+	}
+
+	cases := []struct {
+		name     string
+		incident *mockValidator
+		change   *mockValidator
+		value    string
+		want     *jvspb.ValidateJustificationResponse
+	}{
+		{
+			name:     "both_valid",
+			incident: matched(1, "incident warning"),
+			change:   matched(2, "change warning"),
+			value:    `{"incident":"INC-1","change":"CHG-2"}`,
+			want: &jvspb.ValidateJustificationResponse{
+				Valid:   true,
+				Warning: []string{"incident warning", "change warning"},
+				Annotation: map[string]string{
+					"jira_issue_id":         "1",
+					"jira_issue_url":        "https://example.atlassian.net/browse/INC-1",
+					"jira_change_issue_id":  "2",
+					"jira_change_issue_url": "https://example.atlassian.net/browse/CHG-2",
+				},
+			},
+		},
+		{
+			name:     "change_invalid",
+			incident: matched(1),
+			change:   &mockValidator{result: &MatchResult{Matches: []*Match{{MatchedIssues: []int{}}}}},
+			value:    `{"incident":"INC-1","change":"CHG-2"}`,
+			want: &jvspb.ValidateJustificationResponse{
+				Error: []string{`change ticket: no matched jira issue for justification "CHG-2": invalid justification`},
+			},
+		},
+		{
+			name:     "incident_invalid",
+			incident: &mockValidator{err: ErrInvalidJustification},
+			change:   matched(2),
+			value:    `{"incident":"INC-1","change":"CHG-2"}`,
+			want: &jvspb.ValidateJustificationResponse{
+				Error: []string{`failed to match jira issue with justification "INC-1": invalid justification`},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newTestPlugin(&snapshot{
+				validator:    tc.incident,
+				change:       tc.change,
+				issueBaseURL: "https://example.atlassian.net",
+				parser:       &DualParser{},
+			})
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+			got, err := p.ValidateValue(ctx, tc.value)
+			if err != nil {
+				t.Fatalf("ValidateValue() unexpected err: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(jvspb.ValidateJustificationResponse{})); diff != "" {
+				t.Errorf("response (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestChangeConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := &PluginConfig{
+		Jql:          "project = INC",
+		ChangeJql:    "project = CHG and status = Approved",
+		CandidateJql: "project = INC and priority = High",
+		IssueTypeJql: "Change=status = Approved",
+	}
+	got := changeConfig(cfg)
+	if got.Jql != cfg.ChangeJql || got.CandidateJql != "" || got.IssueTypeJql != "" {
+		t.Errorf("changeConfig() got jql %q, candidate %q and routes %q, want only the change jql",
+			got.Jql, got.CandidateJql, got.IssueTypeJql)
+	}
+	if bytes.Equal(cacheBucket(got), cacheBucket(cfg)) {
+		t.Errorf("change tickets share the cache bucket of incidents")
+	}
+}
@@ -15,6 +15,200 @@
 // Error is a concrete error implementation.
 package plugin
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
-var errInvalidJustification = fmt.Errorf("invalid justification")
+// The errors of the plugin, for callers to check with [errors.Is]. A Jira
+// request failing with an error status additionally carries the status, see
+// [JiraStatus], and some errors carry a machine readable reason, see
+// [Reason].
+var (
+	// ErrInvalidJustification is wrapped by errors caused by the
+	// justification, e.g. an issue not matching the JQL. They are reported
+	// as an invalid response rather than an error.
+	ErrInvalidJustification = fmt.Errorf("invalid justification")
+
+	// ErrClosed is returned when a validation is requested after
+	// [JiraPlugin.Close]. [JiraPlugin.Validate] reports it as an Unavailable
+	// status.
+	ErrClosed = fmt.Errorf("plugin is closed")
+
+	// ErrQuotaExceeded is returned when a validation is rejected because the
+	// category exceeded its rate or concurrency quota. [JiraPlugin.Validate]
+	// reports it as a ResourceExhausted status.
+	ErrQuotaExceeded = fmt.Errorf("quota exceeded")
+
+	// ErrInvalidSignature is returned by [VerifyAnnotations] when the
+	// annotations were not signed with the key or were changed after
+	// signing.
+	ErrInvalidSignature = fmt.Errorf("invalid annotation signature")
+)
+
+// ErrInvalidConfig is wrapped by errors caused by an invalid [PluginConfig],
+// as opposed to failures at runtime.
+var ErrInvalidConfig = fmt.Errorf("invalid configuration")
+
+// ErrJiraUnreachable is wrapped by errors caused by Jira not being reachable
+// or not being able to serve the request, i.e. network failures and 5xx
+// responses.
+var ErrJiraUnreachable = fmt.Errorf("jira unreachable")
+
+// ErrJiraMaintenance is wrapped, along with [ErrJiraUnreachable], by errors
+// caused by Jira answering with a maintenance page rather than the REST API,
+// e.g. an HTML 503 Service Unavailable page during a maintenance window.
+var ErrJiraMaintenance = fmt.Errorf("jira under maintenance, retry later")
+
+// ErrJiraAuth is wrapped by errors caused by Jira rejecting the credentials.
+var ErrJiraAuth = fmt.Errorf("jira authentication failed")
+
+// ErrWrongCategory is wrapped by the error of a request for a justification
+// category other than [Category], when [PluginConfig.WrongCategoryError] is
+// set. It points at a routing bug of the JVS server rather than at the user.
+var ErrWrongCategory = fmt.Errorf("wrong justification category")
+
+// statusError carries the status code of the Jira response that caused err.
+type statusError struct {
+	err  error
+	code int
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// WithJiraStatus wraps err with the status code of the Jira response that
+// caused it, see [JiraStatus].
+func WithJiraStatus(err error, code int) error {
+	if err == nil {
+		return nil
+	}
+	return &statusError{err: err, code: code}
+}
+
+// JiraStatus returns the status code of the Jira response that caused err,
+// and false when err was not caused by a Jira response.
+func JiraStatus(err error) (int, bool) {
+	var se *statusError
+	if !errors.As(err, &se) {
+		return 0, false
+	}
+	return se.code, true
+}
+
+// retryAfterError carries how long to wait before retrying the request
+// that failed with err.
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// WithRetryAfter wraps err with how long to wait before retrying, e.g. the
+// Retry-After of the Jira response, see [RetryAfter].
+func WithRetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, delay: delay}
+}
+
+// RetryAfter returns how long to wait before retrying the request that
+// failed with err, and false when err does not say.
+func RetryAfter(err error) (time.Duration, bool) {
+	var re *retryAfterError
+	if !errors.As(err, &re) {
+		return 0, false
+	}
+	return re.delay, true
+}
+
+// reasonError carries a machine readable reason for err.
+type reasonError struct {
+	err    error
+	reason string
+}
+
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reasonError) Unwrap() error {
+	return e.err
+}
+
+// WithReason wraps err with a machine readable reason, e.g.
+// [ErrorCodeWrongCategory], see [Reason].
+func WithReason(err error, reason string) error {
+	if err == nil {
+		return nil
+	}
+	return &reasonError{err: err, reason: reason}
+}
+
+// Reason returns the outermost reason err was wrapped with by [WithReason],
+// or "" when there is none.
+func Reason(err error) string {
+	var re *reasonError
+	if !errors.As(err, &re) {
+		return ""
+	}
+	return re.reason
+}
+
+// errNotModified is wrapped by errors of conditional requests when Jira
+// responds with 304 Not Modified.
+var errNotModified = fmt.Errorf("not modified")
+
+// policyError reports every policy an issue fails rather than only the
+// first, so that the issue can be fixed in one pass. Each failure wraps
+// ErrInvalidJustification.
+type policyError struct {
+	failures []error
+}
+
+// Error joins the failures.
+func (e *policyError) Error() string {
+	msgs := make([]string, 0, len(e.failures))
+	for _, f := range e.failures {
+		msgs = append(msgs, f.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the failures.
+func (e *policyError) Unwrap() []error {
+	return e.failures
+}
+
+// failureMessages returns a message per policy failure of the error, each
+// with the context the error adds to the failures.
+func failureMessages(err error) []string {
+	var pe *policyError
+	if !errors.As(err, &pe) || len(pe.failures) < 2 {
+		return []string{err.Error()}
+	}
+	prefix, ok := strings.CutSuffix(err.Error(), pe.Error())
+	if !ok {
+		return []string{err.Error()}
+	}
+	msgs := make([]string, 0, len(pe.failures))
+	for _, f := range pe.failures {
+		msgs = append(msgs, prefix+f.Error())
+	}
+	return msgs
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestWithJiraStatus(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("failed to match issue: %w",
+		WithJiraStatus(fmt.Errorf("got response code 404: %w", ErrInvalidJustification), http.StatusNotFound))
+
+	if got, want := err.Error(), "failed to match issue: got response code 404: invalid justification"; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+	if !errors.Is(err, ErrInvalidJustification) {
+		t.Errorf("got err %v, want it to wrap %v", err, ErrInvalidJustification)
+	}
+	if code, ok := JiraStatus(err); !ok || code != http.StatusNotFound {
+		t.Errorf("JiraStatus() got (%d, %t), want (%d, true)", code, ok, http.StatusNotFound)
+	}
+	if _, ok := JiraStatus(ErrJiraUnreachable); ok {
+		t.Errorf("JiraStatus() got a status for an error without one")
+	}
+	if WithJiraStatus(nil, http.StatusNotFound) != nil {
+		t.Errorf("WithJiraStatus(nil) got an error")
+	}
+}
+
+func TestWithReason(t *testing.T) {
+	t.Parallel()
+
+	inner := WithReason(ErrWrongCategory, "inner")
+	err := WithReason(fmt.Errorf("wrapped: %w", inner), ErrorCodeWrongCategory)
+
+	if got, want := Reason(err), ErrorCodeWrongCategory; got != want {
+		t.Errorf("Reason() got %q, want %q", got, want)
+	}
+	if got, want := Reason(inner), "inner"; got != want {
+		t.Errorf("Reason() got %q, want %q", got, want)
+	}
+	if got := Reason(ErrWrongCategory); got != "" {
+		t.Errorf("Reason() got %q for an error without a reason", got)
+	}
+	if !errors.Is(err, ErrWrongCategory) {
+		t.Errorf("got err %v, want it to wrap %v", err, ErrWrongCategory)
+	}
+}
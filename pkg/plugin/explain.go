@@ -0,0 +1,258 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"unicode"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+const (
+	// jiraExplainRequest is the key of the justification annotation asking
+	// for the decision tree of the validation in the response, see
+	// [PluginConfig.ExplainAnnotation].
+	jiraExplainRequest = "jira_explain"
+
+	// jiraExplanation is the key of the response annotation holding the
+	// decision tree of the validation as JSON, see [Explanation].
+	jiraExplanation = "jira_explanation"
+
+	// explainMaxInput is the maximum length in runes of the input of an
+	// explained check, longer inputs are truncated.
+	explainMaxInput = 128
+)
+
+// The outcomes of an explained check.
+const (
+	// ExplainPass means the justification passed the check.
+	ExplainPass = "pass"
+
+	// ExplainFail means the justification failed the check.
+	ExplainFail = "fail"
+
+	// ExplainError means the check could not be performed.
+	ExplainError = "error"
+
+	// ExplainSkipped means the check did not apply to the justification.
+	ExplainSkipped = "skipped"
+)
+
+// ExplainStep is a check run by a validation, with the checks it ran in turn,
+// e.g. the policies evaluated for an issue.
+type ExplainStep struct {
+	// Check names the check, e.g. "parse" or "issue".
+	Check string `json:"check"`
+
+	// Input is what the check was given, sanitized and truncated. It never
+	// holds issue field values.
+	Input string `json:"input,omitempty"`
+
+	// Outcome is one of [ExplainPass], [ExplainFail], [ExplainError] and
+	// [ExplainSkipped].
+	Outcome string `json:"outcome"`
+
+	// Detail says why the check had the outcome.
+	Detail string `json:"detail,omitempty"`
+
+	// Steps are the checks run as part of this one, in order.
+	Steps []*ExplainStep `json:"steps,omitempty"`
+}
+
+// Explanation is the decision tree of a validation: which checks ran, in
+// order, their inputs and their outcomes. A validation stops at the first
+// failed check that needs no network, so the checks after it are missing.
+type Explanation struct {
+	// Valid is whether the justification was accepted.
+	Valid bool `json:"valid"`
+
+	// Steps are the checks run by the validation, in order.
+	Steps []*ExplainStep `json:"steps"`
+
+	// mu guards the steps, the background refreshes of the decision cache
+	// never see the explanation.
+	mu sync.Mutex
+}
+
+// explainScope is the explanation of a context and the step whose sub-checks
+// are recorded, nil for the top level.
+type explainScope struct {
+	e      *Explanation
+	parent *ExplainStep
+}
+
+type explainKey struct{}
+
+// withExplanation returns a context whose checks are recorded in the
+// returned explanation.
+func withExplanation(ctx context.Context) (context.Context, *Explanation) {
+	e := &Explanation{Steps: []*ExplainStep{}}
+	return context.WithValue(ctx, explainKey{}, &explainScope{e: e}), e
+}
+
+// withoutExplanation returns a context whose checks are not recorded, for
+// work that outlives the validation.
+func withoutExplanation(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(explainKey{}).(*explainScope); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, explainKey{}, (*explainScope)(nil))
+}
+
+// explainCheck records a check of the validation of ctx and its outcome.
+func explainCheck(ctx context.Context, check, input, outcome, detail string) {
+	_, done := explainStart(ctx, check, input)
+	done(outcome, detail)
+}
+
+// explainStart records a check of the validation of ctx that runs other
+// checks. It returns the context recording them, and the function setting the
+// outcome of the check.
+func explainStart(ctx context.Context, check, input string) (context.Context, func(outcome, detail string)) {
+	scope, _ := ctx.Value(explainKey{}).(*explainScope)
+	if scope == nil {
+		return ctx, func(string, string) {}
+	}
+
+	step := &ExplainStep{Check: check, Input: sanitizeExplain(input, explainMaxInput)}
+	scope.e.mu.Lock()
+	if scope.parent == nil {
+		scope.e.Steps = append(scope.e.Steps, step)
+	} else {
+		scope.parent.Steps = append(scope.parent.Steps, step)
+	}
+	scope.e.mu.Unlock()
+
+	ctx = context.WithValue(ctx, explainKey{}, &explainScope{e: scope.e, parent: step})
+	return ctx, func(outcome, detail string) {
+		scope.e.mu.Lock()
+		defer scope.e.mu.Unlock()
+		step.Outcome, step.Detail = outcome, sanitizeExplain(detail, 0)
+	}
+}
+
+// explainErr returns the outcome and detail of a check that returned err:
+// a failure when the justification was rejected, an error otherwise.
+func explainErr(err error) (string, string) {
+	if err == nil {
+		return ExplainPass, ""
+	}
+	if errors.Is(err, ErrInvalidJustification) {
+		return ExplainFail, strings.Join(failureMessages(err), "; ")
+	}
+	return ExplainError, err.Error()
+}
+
+// explainMatched returns the outcome of a Jira match: a pass when exactly
+// one issue matched.
+func explainMatched(result *MatchResult) string {
+	if len(result.Matches) == 0 || len(result.Matches[0].MatchedIssues) != 1 {
+		return ExplainFail
+	}
+	return ExplainPass
+}
+
+// finish sets whether the justification was accepted from the response.
+func (e *Explanation) finish(resp *jvspb.ValidateJustificationResponse) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Valid = resp.GetValid()
+}
+
+// annotate adds the explanation to the annotations of resp as JSON.
+func (e *Explanation) annotate(resp *jvspb.ValidateJustificationResponse) error {
+	e.mu.Lock()
+	b, err := json.Marshal(e)
+	e.mu.Unlock()
+	if err != nil {
+		return err //nolint:wrapcheck // Want passthrough
+	}
+	if resp.Annotation == nil {
+		resp.Annotation = make(map[string]string, 1)
+	}
+	resp.Annotation[jiraExplanation] = string(b)
+	return nil
+}
+
+// explainRequestor returns the subject of the requestor, or "unknown".
+func explainRequestor(r *Requestor) string {
+	if r == nil || r.Subject == "" {
+		return "unknown"
+	}
+	return r.Subject
+}
+
+// explainParsed describes the issue keys of the parsed justification.
+func explainParsed(parsed *ParsedJustification) string {
+	msg := "issue " + parsed.IssueKey
+	if len(parsed.RelatedIssueKeys) > 0 {
+		msg += ", related issues " + strings.Join(parsed.RelatedIssueKeys, ", ")
+	}
+	if parsed.ChangeIssueKey != "" {
+		msg += ", change issue " + parsed.ChangeIssueKey
+	}
+	return msg
+}
+
+// sanitizeExplain replaces the control characters of s, so that user input
+// cannot forge lines in the explanation, and truncates it to max runes unless
+// max is 0.
+func sanitizeExplain(s string, max int) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		if n == max && max > 0 {
+			b.WriteString("…")
+			break
+		}
+		if unicode.IsControl(r) {
+			r = '�'
+		}
+		b.WriteRune(r)
+		n++
+	}
+	return b.String()
+}
+
+// Explain validates a justification value of the category of the plugin like
+// [JiraPlugin.ValidateValue] and returns the decision tree of the validation
+// along with the response. It does not log or audit the decision. The
+// explanation is returned with the error when the validation could not be
+// performed.
+func (j *JiraPlugin) Explain(ctx context.Context, value string) (*jvspb.ValidateJustificationResponse, *Explanation, error) {
+	if !j.life.acquire() {
+		return nil, nil, ErrClosed
+	}
+	defer j.life.release()
+
+	ctx, e := withExplanation(ctx)
+	s := j.current.Load()
+	resp, err := j.validate(ctx, s, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{
+			Category: s.justificationCategory(),
+			Value:    value,
+		},
+	})
+	if err != nil {
+		return nil, e, err
+	}
+	e.finish(resp)
+	return resp, e, nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// explainLines returns a line per step, indented by its depth.
+func explainLines(steps []*ExplainStep, depth int) []string {
+	var lines []string
+	for _, s := range steps {
+		line := fmt.Sprintf("%s%s %s %s", strings.Repeat("  ", depth), s.Outcome, s.Check, s.Input)
+		if s.Detail != "" {
+			line += ": " + s.Detail
+		}
+		lines = append(lines, line)
+		lines = append(lines, explainLines(s.Steps, depth+1)...)
+	}
+	return lines
+}
+
+func TestPlugin_Explain(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeJira(t)
+	jql := f.config().Jql
+
+	cases := []struct {
+		name      string
+		value     string
+		cfg       func(*PluginConfig)
+		wantValid bool
+		want      []string
+	}{
+		{
+			name:      "valid",
+			value:     "ABCD-1",
+			wantValid: true,
+			want: []string{
+				"pass category jira",
+				"pass parse ABCD-1: issue ABCD-1",
+				"pass issue ABCD-1",
+				"  pass fetch ABCD-1",
+				"  pass jql " + jql,
+			},
+		},
+		{
+			name:  "policy_failure",
+			value: "ABCD-1",
+			cfg: func(cfg *PluginConfig) {
+				cfg.MinPriority = "High"
+			},
+			want: []string{
+				"pass category jira",
+				"pass parse ABCD-1: issue ABCD-1",
+				`fail issue ABCD-1: failed to match jira issue with justification "ABCD-1": ` +
+					`jira issue "ABCD-1" rejected: issue has no priority, the minimum is High: invalid justification`,
+				"  pass fetch ABCD-1",
+				"  fail policy priority: issue has no priority, the minimum is High",
+				"  pass jql " + jql,
+			},
+		},
+		{
+			name:  "invalid_key",
+			value: "ABCD 1",
+			want: []string{
+				"pass category jira",
+				"pass parse ABCD 1: issue ABCD 1",
+				`fail issue_keys : "ABCD 1" is not a jira issue key`,
+			},
+		},
+		{
+			name:  "control_character",
+			value: "ABCD 1\n",
+			want: []string{
+				"pass category jira",
+				`fail value : justification value contains the character '\n' at byte 6, only printable characters are allowed`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			cfg := f.config()
+			if tc.cfg != nil {
+				tc.cfg(cfg)
+			}
+			p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+			if err != nil {
+				t.Fatalf("failed to create plugin: %v", err)
+			}
+			t.Cleanup(func() { p.Close(context.Background()) })
+
+			resp, e, err := p.Explain(ctx, tc.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := resp.GetValid(); got != tc.wantValid {
+				t.Errorf("got valid %t, want %t", got, tc.wantValid)
+			}
+			if got := e.Valid; got != tc.wantValid {
+				t.Errorf("got explanation valid %t, want %t", got, tc.wantValid)
+			}
+			if diff := cmp.Diff(tc.want, explainLines(e.Steps, 0)); diff != "" {
+				t.Errorf("explanation (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlugin_Validate_ExplainAnnotation(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.ExplainAnnotation = true
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	for _, tc := range []struct {
+		annotation map[string]string
+		want       bool
+	}{
+		{annotation: map[string]string{jiraExplainRequest: "true"}, want: true},
+		{annotation: nil, want: false},
+	} {
+		resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+			Justification: &jvspb.Justification{Category: jiraCategory, Value: "ABCD-1", Annotation: tc.annotation},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got, ok := resp.GetAnnotation()[jiraExplanation]
+		if ok != tc.want {
+			t.Fatalf("annotation %v got explanation %t, want %t", tc.annotation, ok, tc.want)
+		}
+		if !ok {
+			continue
+		}
+		var e Explanation
+		if err := json.Unmarshal([]byte(got), &e); err != nil {
+			t.Fatalf("failed to unmarshal explanation: %v", err)
+		}
+		if !e.Valid || len(e.Steps) != 3 {
+			t.Errorf("got explanation %s, want a valid one with 3 steps", got)
+		}
+	}
+}
+
+func TestSanitizeExplain(t *testing.T) {
+	t.Parallel()
+
+	if got, want := sanitizeExplain("ABCD-1\nfail forged", 0), "ABCD-1�fail forged"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := sanitizeExplain(strings.Repeat("a", explainMaxInput+1), explainMaxInput), strings.Repeat("a", explainMaxInput)+"…"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WithExpression makes the validator reject issues for which the [Jira
+// expression] does not evaluate to true. The expression is evaluated by
+// Jira with the issue in its context, so it can express checks JQL cannot,
+// e.g. comparing two fields:
+//
+//	issue.dueDate != null && issue.dueDate > issue.created
+//
+// It costs an extra Jira request per match. It only applies to
+// [Validator.MatchIssue], and cannot be used in search mode.
+//
+// [Jira expression]: https://developer.atlassian.com/cloud/jira/platform/jira-expressions/
+func WithExpression(expression string) ValidatorOption {
+	return func(v *Validator) error {
+		if v.searchJQLPrefix != "" {
+			return fmt.Errorf("jira expression cannot be used in search mode")
+		}
+		if strings.TrimSpace(expression) == "" {
+			return fmt.Errorf("empty jira expression")
+		}
+		v.expression = expression
+		return nil
+	}
+}
+
+// expressionData is the request body of the [evaluate request].
+//
+// [evaluate request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-jira-expressions/#api-rest-api-3-expression-eval-post
+type expressionData struct {
+	Expression string            `json:"expression"`
+	Context    expressionContext `json:"context"`
+}
+
+type expressionContext struct {
+	Issue expressionIssue `json:"issue"`
+}
+
+type expressionIssue struct {
+	Key string `json:"key"`
+}
+
+// expressionResult is the response of the evaluate request.
+type expressionResult struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// evalExpression evaluates the expression of [WithExpression] for the issue
+// and reports whether it is true.
+func (v *Validator) evalExpression(ctx context.Context, issueKey string) (bool, error) {
+	u := v.endpointURL("expression", "eval")
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(&expressionData{
+		Expression: v.expression,
+		Context:    expressionContext{Issue: expressionIssue{Key: issueKey}},
+	}); err != nil {
+		return false, fmt.Errorf("failed to construct request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), &body)
+	if err != nil {
+		return false, fmt.Errorf("failed to construct request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	var result expressionResult
+	if err := v.makeRequest(req, &result); err != nil {
+		return false, fmt.Errorf("failed to evaluate the jira expression: %w", err)
+	}
+
+	var ok bool
+	if err := json.Unmarshal(result.Value, &ok); err != nil {
+		return false, fmt.Errorf("the jira expression evaluated to %s, want a boolean: %w", result.Value, ErrInvalidConfig)
+	}
+	return ok, nil
+}
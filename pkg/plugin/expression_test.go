@@ -0,0 +1,135 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestWithExpression(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		value       string
+		status      int
+		matched     bool
+		wantErr     string
+		wantInvalid bool
+	}{
+		{
+			name:    "true",
+			value:   "true",
+			matched: true,
+		},
+		{
+			name:        "false",
+			value:       "false",
+			matched:     true,
+			wantErr:     `jira issue "ABCD-1" rejected: issue does not satisfy the jira expression: invalid justification`,
+			wantInvalid: true,
+		},
+		{
+			name:        "false_and_no_match",
+			value:       "false",
+			wantErr:     "issue does not satisfy the jira expression: invalid justification; no match for the JQL",
+			wantInvalid: true,
+		},
+		{
+			name:    "not_boolean",
+			value:   `"yes"`,
+			matched: true,
+			wantErr: `the jira expression evaluated to "yes", want a boolean`,
+		},
+		{
+			name:    "unavailable",
+			status:  http.StatusServiceUnavailable,
+			matched: true,
+			wantErr: "failed to evaluate the jira expression",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotReq expressionData
+			mux := http.NewServeMux()
+			mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+			})
+			mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+				if tc.matched {
+					fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+					return
+				}
+				fmt.Fprint(w, `{"matches":[{"matchedIssues":[],"errors":[]}]}`)
+			})
+			mux.HandleFunc("/expression/eval", func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&gotReq); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if tc.status != 0 {
+					w.WriteHeader(tc.status)
+					return
+				}
+				fmt.Fprintf(w, `{"value":%s}`, tc.value)
+			})
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			v, err := NewValidator(srv.URL, "project = JRA", "test@test.com", "token",
+				WithExpression("issue.dueDate > issue.created"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = v.MatchIssue(context.Background(), "ABCD-1")
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got := errors.Is(err, ErrInvalidJustification); got != tc.wantInvalid {
+				t.Errorf("got invalid justification %t, want %t: %v", got, tc.wantInvalid, err)
+			}
+			if got, want := gotReq.Context.Issue.Key, "ABCD-1"; got != want {
+				t.Errorf("evaluated the expression for issue %q, want %q", got, want)
+			}
+			if got, want := gotReq.Expression, "issue.dueDate > issue.created"; got != want {
+				t.Errorf("evaluated expression %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestWithExpression_SearchMode(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA", "test@test.com", "token",
+		WithSearchMode(), WithExpression("true"))
+	if diff := testutil.DiffErrString(err, "jira expression cannot be used in search mode"); diff != "" {
+		t.Errorf(diff)
+	}
+}
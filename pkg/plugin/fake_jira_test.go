@@ -0,0 +1,135 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeJiraHiddenProject is the project the account of the fakeJira cannot
+// browse.
+const fakeJiraHiddenProject = "HIDDEN"
+
+// fakeJiraMissingIssue is the only issue the fakeJira reports as not found.
+const fakeJiraMissingIssue = "MISSING-1"
+
+// fakeJira is an in-memory Jira REST API serving the endpoints the validator
+// uses. Every issue but fakeJiraMissingIssue exists and matches the JQL,
+// every JQL parses, and the account can browse every project but
+// fakeJiraHiddenProject.
+type fakeJira struct {
+	srv *httptest.Server
+
+	issueCalls      atomic.Int64
+	matchCalls      atomic.Int64
+	myselfCalls     atomic.Int64
+	parseCalls      atomic.Int64
+	permissionCalls atomic.Int64
+
+	// unmatched makes every issue not match the JQL, e.g. once closed.
+	unmatched atomic.Bool
+}
+
+// newFakeJira starts a fakeJira that is stopped when the test ends.
+func newFakeJira(tb testing.TB) *fakeJira {
+	tb.Helper()
+
+	f := &fakeJira{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		f.issueCalls.Add(1)
+		key := strings.TrimPrefix(r.URL.Path, "/issue/")
+		if key == fakeJiraMissingIssue {
+			http.Error(w, `{"errorMessages":["Issue does not exist or you do not have permission to see it."]}`, http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"1234","key":%q}`, key)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		f.matchCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if f.unmatched.Load() {
+			fmt.Fprint(w, `{"matches":[{"matchedIssues":[],"errors":[]}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+
+	mux.HandleFunc("/myself", func(w http.ResponseWriter, r *http.Request) {
+		f.myselfCalls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"accountId":"1","emailAddress":"test@test.com","displayName":"JVS","active":true,"groups":{"size":2,"items":[{"name":"jira-users"},{"name":"jvs-bots"}]}}`)
+	})
+	mux.HandleFunc("/jql/parse", func(w http.ResponseWriter, r *http.Request) {
+		f.parseCalls.Add(1)
+		var data parseData
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result := &ParseResult{}
+		for _, q := range data.Queries {
+			result.Queries = append(result.Queries, &ParsedJQL{Query: q})
+		}
+		b, err := json.Marshal(result)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+	mux.HandleFunc("/mypermissions", func(w http.ResponseWriter, r *http.Request) {
+		f.permissionCalls.Add(1)
+		have := r.URL.Query().Get("projectKey") != fakeJiraHiddenProject
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"permissions":{%q:{"havePermission":%t}}}`, r.URL.Query().Get("permissions"), have)
+	})
+
+	f.srv = httptest.NewServer(mux)
+	tb.Cleanup(f.srv.Close)
+	return f
+}
+
+// assertCalls checks that exactly n issue requests and n match requests were
+// made.
+func (f *fakeJira) assertCalls(tb testing.TB, n int) {
+	tb.Helper()
+
+	if got := f.issueCalls.Load(); got != int64(n) {
+		tb.Errorf("got %d issue requests, want %d", got, n)
+	}
+	if got := f.matchCalls.Load(); got != int64(n) {
+		tb.Errorf("got %d match requests, want %d", got, n)
+	}
+}
+
+// config returns a plugin config pointing at the fake.
+func (f *fakeJira) config() *PluginConfig {
+	return &PluginConfig{
+		JIRAEndpoint: f.srv.URL,
+		Jql:          "status NOT IN (Done)",
+		JIRAAccount:  "test@test.com",
+		IssueBaseURL: "https://example.atlassian.net",
+	}
+}
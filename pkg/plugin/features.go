@@ -0,0 +1,225 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+// The feature flags gating subsystems, see [PluginConfig.FeatureFlags].
+// Every flag is on unless the configuration or an override turns it off,
+// and a subsystem still needs its own configuration to run.
+const (
+	// FeatureCache gates the decision cache, lookups and writes alike.
+	FeatureCache = "cache"
+
+	// FeatureStaleWhileRevalidate gates serving expired cached decisions
+	// while they are refreshed, see [PluginConfig.CacheMaxStaleness].
+	FeatureStaleWhileRevalidate = "stale_while_revalidate"
+
+	// FeatureNotFoundCache gates the cache of issues Jira reported missing.
+	FeatureNotFoundCache = "not_found_cache"
+
+	// FeatureDecisionStream gates publishing decisions to the subscribers
+	// of [DecisionStreamMethod].
+	FeatureDecisionStream = "decision_stream"
+
+	// FeatureDecisionSampling gates capturing decisions for review, see
+	// [PluginConfig.SampleRate].
+	FeatureDecisionSampling = "decision_sampling"
+
+	// FeatureRevocation gates validating accepted decisions again and
+	// publishing their revocations, see [PluginConfig.RevocationDestination].
+	FeatureRevocation = "revocation"
+)
+
+// featureNames are the known feature flags, sorted.
+var featureNames = []string{
+	FeatureCache,
+	FeatureDecisionSampling,
+	FeatureDecisionStream,
+	FeatureNotFoundCache,
+	FeatureRevocation,
+	FeatureStaleWhileRevalidate,
+}
+
+// FeatureFlag is the state of a feature flag.
+type FeatureFlag struct {
+	Name string `json:"name"`
+
+	// Enabled is whether the gated subsystem runs, the override if any,
+	// otherwise the configured value.
+	Enabled bool `json:"enabled"`
+
+	// Configured is the value of the configuration.
+	Configured bool `json:"configured"`
+
+	// Overridden is set while an override at runtime applies, see
+	// [JiraPlugin.SetFeatureFlag].
+	Overridden bool `json:"overridden"`
+}
+
+// parseFeatureFlags parses settings of the form name=on or name=off into
+// the configured values. Flags that are not set are not in the map.
+func parseFeatureFlags(settings []string) (map[string]bool, error) {
+	values := make(map[string]bool, len(settings))
+	for _, setting := range settings {
+		name, value, ok := strings.Cut(setting, "=")
+		name = strings.TrimSpace(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid feature flag %q, must be name=on or name=off", setting)
+		}
+		if !knownFeature(name) {
+			return nil, fmt.Errorf("unknown feature flag %q, must be one of %s", name, strings.Join(featureNames, ", "))
+		}
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "on", "true":
+			values[name] = true
+		case "off", "false":
+			values[name] = false
+		default:
+			return nil, fmt.Errorf("invalid feature flag %q, must be name=on or name=off", setting)
+		}
+	}
+	return values, nil
+}
+
+// knownFeature reports whether name is one of [featureNames].
+func knownFeature(name string) bool {
+	i := sort.SearchStrings(featureNames, name)
+	return i < len(featureNames) && featureNames[i] == name
+}
+
+// featureFlags are the feature flags of a plugin. The configured values
+// are replaced on reload, the overrides are kept until reset or restart.
+// A nil *featureFlags has every flag on.
+type featureFlags struct {
+	mu         sync.RWMutex
+	configured map[string]bool
+	overrides  map[string]bool
+}
+
+// newFeatureFlags returns the feature flags with the configured settings,
+// which must parse.
+func newFeatureFlags(settings []string) *featureFlags {
+	f := &featureFlags{overrides: make(map[string]bool)}
+	f.configure(settings)
+	return f
+}
+
+// configure replaces the configured values with settings, which must parse.
+func (f *featureFlags) configure(settings []string) {
+	values, err := parseFeatureFlags(settings)
+	if err != nil {
+		// Reported by [PluginConfig.Validate].
+		values = map[string]bool{}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.configured = values
+}
+
+// enabled reports whether the flag is on.
+func (f *featureFlags) enabled(name string) bool {
+	if f == nil {
+		return true
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if v, ok := f.overrides[name]; ok {
+		return v
+	}
+	if v, ok := f.configured[name]; ok {
+		return v
+	}
+	return true
+}
+
+// override sets the flag regardless of the configuration.
+func (f *featureFlags) override(name string, enabled bool) error {
+	if !knownFeature(name) {
+		return fmt.Errorf("unknown feature flag %q: %w", name, errInvalidAdminRequest)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.overrides[name] = enabled
+	return nil
+}
+
+// reset removes the override of the flag.
+func (f *featureFlags) reset(name string) error {
+	if !knownFeature(name) {
+		return fmt.Errorf("unknown feature flag %q: %w", name, errInvalidAdminRequest)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.overrides, name)
+	return nil
+}
+
+// list returns the state of every flag, sorted by name.
+func (f *featureFlags) list() []FeatureFlag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	flags := make([]FeatureFlag, 0, len(featureNames))
+	for _, name := range featureNames {
+		configured, ok := f.configured[name]
+		if !ok {
+			configured = true
+		}
+		flag := FeatureFlag{Name: name, Enabled: configured, Configured: configured}
+		if v, ok := f.overrides[name]; ok {
+			flag.Enabled, flag.Overridden = v, true
+		}
+		flags = append(flags, flag)
+	}
+	return flags
+}
+
+// FeatureFlags returns the state of every feature flag, sorted by name.
+func (j *JiraPlugin) FeatureFlags() []FeatureFlag {
+	return j.features.list()
+}
+
+// SetFeatureFlag overrides the configured value of the feature flag until
+// [JiraPlugin.ResetFeatureFlag] or a restart, e.g. to turn a misbehaving
+// subsystem off at once. Validations starting afterwards see the new value.
+func (j *JiraPlugin) SetFeatureFlag(ctx context.Context, name string, enabled bool) error {
+	if err := j.features.override(name, enabled); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WarnContext(ctx, "feature flag overridden",
+		"feature", name,
+		"enabled", enabled)
+	return nil
+}
+
+// ResetFeatureFlag removes the override of the feature flag, so the
+// configured value applies again.
+func (j *JiraPlugin) ResetFeatureFlag(ctx context.Context, name string) error {
+	if err := j.features.reset(name); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "feature flag override removed",
+		"feature", name,
+		"enabled", j.features.enabled(name))
+	return nil
+}
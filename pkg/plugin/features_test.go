@@ -0,0 +1,186 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestParseFeatureFlags(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		settings []string
+		want     map[string]bool
+		wantErr  string
+	}{
+		{
+			name: "empty",
+			want: map[string]bool{},
+		},
+		{
+			name:     "on_and_off",
+			settings: []string{"cache=off", " decision_stream = on", "not_found_cache=FALSE"},
+			want:     map[string]bool{"cache": false, "decision_stream": true, "not_found_cache": false},
+		},
+		{
+			name:     "unknown",
+			settings: []string{"hedging=on"},
+			wantErr:  `unknown feature flag "hedging"`,
+		},
+		{
+			name:     "missing_value",
+			settings: []string{"cache"},
+			wantErr:  `invalid feature flag "cache", must be name=on or name=off`,
+		},
+		{
+			name:     "invalid_value",
+			settings: []string{"cache=maybe"},
+			wantErr:  `invalid feature flag "cache=maybe"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseFeatureFlags(tc.settings)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("parseFeatureFlags() (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFeatureFlags(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.CachePath = filepath.Join(t.TempDir(), "decisions.db")
+	cfg.FeatureFlags = []string{"decision_stream=off"}
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+	conn := newAdminConn(ctx, t, p)
+
+	validate := func(key string) {
+		t.Helper()
+		if resp, err := p.ValidateValue(ctx, key); err != nil || !resp.GetValid() {
+			t.Fatalf("ValidateValue(%q) got %v, %v, want a valid response", key, resp, err)
+		}
+	}
+
+	// The cache is on by default.
+	validate("ABCD-1")
+	validate("ABCD-1")
+	f.assertCalls(t, 1)
+
+	// Turned off, every validation asks Jira.
+	flags, err := SetFeatureFlag(ctx, conn, FeatureCache, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FeatureFlag{
+		{Name: FeatureCache, Enabled: false, Configured: true, Overridden: true},
+		{Name: FeatureDecisionSampling, Enabled: true, Configured: true},
+		{Name: FeatureDecisionStream, Enabled: false, Configured: false},
+		{Name: FeatureNotFoundCache, Enabled: true, Configured: true},
+		{Name: FeatureRevocation, Enabled: true, Configured: true},
+		{Name: FeatureStaleWhileRevalidate, Enabled: true, Configured: true},
+	}
+	if diff := cmp.Diff(want, flags); diff != "" {
+		t.Errorf("SetFeatureFlag() (-want, +got):\n%s", diff)
+	}
+	validate("ABCD-1")
+	validate("ABCD-1")
+	f.assertCalls(t, 3)
+
+	// The override survives a reload of the configuration.
+	cfg.FeatureFlags = nil
+	if err := p.Reload(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	validate("ABCD-1")
+	f.assertCalls(t, 4)
+
+	// Reset, the configured value applies again.
+	flags, err = ResetFeatureFlag(ctx, conn, FeatureCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := flags[0], (FeatureFlag{Name: FeatureCache, Enabled: true, Configured: true}); got != want {
+		t.Errorf("ResetFeatureFlag() got %+v, want %+v", got, want)
+	}
+	if got, want := flags[2], (FeatureFlag{Name: FeatureDecisionStream, Enabled: true, Configured: true}); got != want {
+		t.Errorf("flag after reload got %+v, want %+v", got, want)
+	}
+	validate("ABCD-1")
+	f.assertCalls(t, 4)
+
+	if _, err := SetFeatureFlag(ctx, conn, "hedging", true); status.Code(errors.Unwrap(err)) != codes.InvalidArgument {
+		t.Errorf("SetFeatureFlag() of an unknown flag got %v, want InvalidArgument", err)
+	}
+	got, err := GetFeatureFlags(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(p.FeatureFlags(), got); diff != "" {
+		t.Errorf("GetFeatureFlags() (-want, +got):\n%s", diff)
+	}
+}
+
+func TestFeatureFlags_NotFoundCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.NotFoundCacheTTL = defaultCacheTTL
+	cfg.FeatureFlags = []string{"not_found_cache=off"}
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	for i := 0; i < 2; i++ {
+		if resp, err := p.ValidateValue(ctx, fakeJiraMissingIssue); err != nil || resp.GetValid() {
+			t.Fatalf("ValidateValue() got %v, %v, want an invalid response", resp, err)
+		}
+	}
+	if got, want := f.issueCalls.Load(), int64(2); got != want {
+		t.Errorf("got %d issue requests, want %d", got, want)
+	}
+}
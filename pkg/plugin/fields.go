@@ -0,0 +1,185 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// annotationFieldPrefix prefixes the issue fields copied into the
+	// annotation map of the justification, e.g. "jira_field_summary".
+	annotationFieldPrefix = "jira_field_"
+
+	// defaultAnnotationFieldMaxBytes is the default limit of a single issue
+	// field value in the annotation.
+	defaultAnnotationFieldMaxBytes = 256
+
+	// maxAnnotationFieldsBytes limits the total size of all issue field
+	// values in the annotation. Fields are added in the configured order and
+	// the ones that no longer fit are left out.
+	maxAnnotationFieldsBytes = 4096
+
+	// truncationMarker is appended to truncated field values.
+	truncationMarker = "…"
+)
+
+// fieldNamePattern matches jira field ids such as "summary" or
+// "customfield_10010".
+var fieldNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// WithAnnotationFields makes the validator fetch the given issue fields and
+// return them in [MatchResult.IssueFields], rendered as text and truncated to
+// maxBytes each. Only these fields are requested from jira, so large fields
+// like the description are never transferred unless configured. A maxBytes
+// of zero uses the default of 256.
+func WithAnnotationFields(names []string, maxBytes int) ValidatorOption {
+	return func(v *Validator) error {
+		for _, name := range names {
+			if !fieldNamePattern.MatchString(name) {
+				return fmt.Errorf("invalid jira field name %q", name)
+			}
+		}
+		if maxBytes < 0 {
+			return fmt.Errorf("field size limit must be positive, got %d", maxBytes)
+		}
+		if maxBytes == 0 {
+			maxBytes = defaultAnnotationFieldMaxBytes
+		}
+
+		v.annotationFields = names
+		v.annotationFieldMaxBytes = maxBytes
+		v.issueFieldsQuery = v.fieldsQuery()
+		return nil
+	}
+}
+
+// fieldsQuery returns the pre-encoded query of the Get Issue API request.
+func (v *Validator) fieldsQuery() string {
+	return "fields=" + url.QueryEscape(strings.Join(v.requestedFields(), ","))
+}
+
+// requestedFields returns the fields to ask jira for.
+func (v *Validator) requestedFields() []string {
+	fields := make([]string, 0, 3+len(v.annotationFields)+len(v.issueChecks))
+	fields = append(fields, "key", "id")
+	fields = append(fields, v.annotationFields...)
+	add := func(name string) {
+		if !slices.Contains(fields, name) {
+			fields = append(fields, name)
+		}
+	}
+	if len(v.issueTypeJQL) > 0 {
+		add(issueTypeField)
+	}
+	if v.annotateArchived {
+		add(archivedDateField)
+	}
+	if v.snapshotHash {
+		for _, name := range issueSnapshotFields {
+			add(name)
+		}
+	}
+	for _, c := range v.issueChecks {
+		add(c.field())
+	}
+	return fields
+}
+
+// projectFields renders the configured annotation fields of the issue with the
+// key. Fields are redacted, see [WithFieldRedactions], truncated to the per
+// field limit, and left out once the total limit is reached.
+func (v *Validator) projectFields(issueKey string, fields map[string]json.RawMessage) map[string]string {
+	if len(v.annotationFields) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(v.annotationFields))
+	var total int
+	for _, name := range v.annotationFields {
+		s, ok := v.renderAnnotationField(name, fields[name])
+		if !ok {
+			continue
+		}
+		if r, ok := v.fieldRedactions[name]; ok {
+			s = r.redact(issueProject(issueKey), s)
+		}
+		s = truncateUTF8(s, v.annotationFieldMaxBytes)
+		if total+len(s) > maxAnnotationFieldsBytes {
+			break
+		}
+		total += len(s)
+		out[name] = s
+	}
+	return out
+}
+
+// renderField renders a field value as text. Objects are rendered by their
+// display name, name or value, which covers most built-in fields like status
+// or priority. Empty values are not rendered.
+func renderField(raw json.RawMessage) (string, bool) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return "", false
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, s != ""
+	}
+
+	var obj struct {
+		DisplayName string `json:"displayName"`
+		Name        string `json:"name"`
+		Value       string `json:"value"`
+	}
+	if raw[0] == '{' && json.Unmarshal(raw, &obj) == nil {
+		for _, s := range []string{obj.DisplayName, obj.Name, obj.Value} {
+			if s != "" {
+				return s, true
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a character,
+// marking it as truncated.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	marker := truncationMarker
+	if n <= len(marker) {
+		marker = ""
+	}
+	n -= len(marker)
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + marker
+}
@@ -0,0 +1,239 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestRenderField(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{name: "missing", raw: ""},
+		{name: "null", raw: "null"},
+		{name: "empty_string", raw: `""`},
+		{name: "string", raw: `"Roll back release"`, want: "Roll back release", wantOK: true},
+		{name: "number", raw: "3", want: "3", wantOK: true},
+		{name: "status", raw: `{"self":"x","name":"In Progress","id":"3"}`, want: "In Progress", wantOK: true},
+		{name: "user", raw: `{"accountId":"1","displayName":"Jane"}`, want: "Jane", wantOK: true},
+		{name: "option", raw: `{"value":"High","id":"1"}`, want: "High", wantOK: true},
+		{name: "array", raw: `[ "a", "b" ]`, want: `["a","b"]`, wantOK: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := renderField(json.RawMessage(tc.raw))
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("renderField(%s) got (%q, %t), want (%q, %t)", tc.raw, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		s    string
+		n    int
+		want string
+	}{
+		{name: "short", s: "abc", n: 3, want: "abc"},
+		{name: "ascii", s: "abcdefgh", n: 6, want: "abc…"},
+		{name: "multibyte", s: "äääää", n: 8, want: "ää…"},
+		{name: "tiny_limit", s: "abcdef", n: 2, want: "ab"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := truncateUTF8(tc.s, tc.n)
+			if got != tc.want {
+				t.Errorf("truncateUTF8(%q, %d) got %q, want %q", tc.s, tc.n, got, tc.want)
+			}
+			if len(got) > tc.n {
+				t.Errorf("truncateUTF8(%q, %d) got %d bytes", tc.s, tc.n, len(got))
+			}
+		})
+	}
+}
+
+func TestValidator_ProjectFields(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA", "test@test.com", "secrets",
+		WithAnnotationFields([]string{"summary", "description", "priority", "labels"}, 4085))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	got := v.projectFields("JRA-1", map[string]json.RawMessage{
+		"summary":     json.RawMessage(`"Roll back"`),
+		"description": json.RawMessage(fmt.Sprintf("%q", strings.Repeat("x", 10000))),
+		"priority":    json.RawMessage(`{"name":"High"}`),
+		"unused":      json.RawMessage(`"ignored"`),
+	})
+
+	// The description is truncated to 4085 bytes, after which the priority no
+	// longer fits into the total limit.
+	want := map[string]string{
+		"summary":     "Roll back",
+		"description": strings.Repeat("x", 4085-len(truncationMarker)) + truncationMarker,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("projectFields() unexpected diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWithAnnotationFields(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		fields    []string
+		maxBytes  int
+		wantQuery string
+		wantErr   string
+	}{
+		{
+			name:      "custom_field",
+			fields:    []string{"summary", "customfield_10010"},
+			wantQuery: "fields=key%2Cid%2Csummary%2Ccustomfield_10010",
+		},
+		{
+			name:    "injection",
+			fields:  []string{"summary&expand=renderedFields"},
+			wantErr: `invalid jira field name "summary&expand=renderedFields"`,
+		},
+		{
+			name:     "negative_limit",
+			fields:   []string{"summary"},
+			maxBytes: -1,
+			wantErr:  "field size limit must be positive",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA", "test@test.com", "secrets",
+				WithAnnotationFields(tc.fields, tc.maxBytes))
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if err == nil && v.issueFieldsQuery != tc.wantQuery {
+				t.Errorf("got query %q, want %q", v.issueFieldsQuery, tc.wantQuery)
+			}
+		})
+	}
+}
+
+func TestValidator_MatchIssue_AnnotationFields(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.URL.Query().Get("fields"), "key,id,summary"; got != want {
+			t.Errorf("got fields %q, want %q", got, want)
+		}
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1","fields":{"summary":"Roll back"}}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "secrets",
+		WithAnnotationFields([]string{"summary"}, 0))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	got, err := v.MatchIssue(ctx, "ABCD-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &MatchResult{
+		Matches:     []*Match{{MatchedIssues: []int{1234}, Errors: []string{}}},
+		IssueFields: map[string]string{"summary": "Roll back"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MatchIssue() unexpected diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestValidator_Issue(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1","fields":{"summary":"Roll back","priority":null}}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected match request")
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "secrets",
+		WithAnnotationFields([]string{"summary", "priority"}, 0))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	got, err := v.Issue(ctx, "ABCD-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &Issue{
+		Key:    "ABCD-1",
+		ID:     "1234",
+		Fields: map[string]string{"summary": "Roll back"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Issue() unexpected diff (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abcxyz/pkg/cli"
+)
+
+// ByteSize is a size in bytes. It is parsed from a number of bytes,
+// optionally followed by a unit: B, KB, MB, GB in powers of 1000 or KiB,
+// MiB, GiB in powers of 1024, e.g. "512", "16KiB" or "1MB".
+type ByteSize int64
+
+// byteSizeUnits are the units of [ByteSize], longest suffixes first.
+var byteSizeUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"KB", 1e3},
+	{"MB", 1e6},
+	{"GB", 1e9},
+	{"B", 1},
+}
+
+// ParseByteSize parses a [ByteSize].
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.TrimSpace(s)
+	num, unit := s, ByteSize(1)
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			num, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q, must be a number optionally followed by B, KB, MB, GB, KiB, MiB or GiB", s)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("invalid byte size %q, must not be negative", s)
+	}
+	if n > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("invalid byte size %q, too large", s)
+	}
+	return ByteSize(n) * unit, nil
+}
+
+// String returns the size in the largest binary unit dividing it, e.g.
+// "16KiB", or in bytes.
+func (b ByteSize) String() string {
+	for _, u := range []struct {
+		suffix string
+		size   ByteSize
+	}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if b != 0 && b%u.size == 0 {
+			return strconv.FormatInt(int64(b/u.size), 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(b), 10)
+}
+
+// typedFlags defines the typed flags of a config. [cli.Flag] ignores
+// environment variables that do not parse and keeps the default, so the
+// environment variables are checked again after parsing and reported by
+// name.
+type typedFlags struct {
+	set     *cli.FlagSet
+	section *cli.FlagSection
+	checks  []envCheck
+}
+
+// envCheck is the parser of an environment variable of a typed flag.
+type envCheck struct {
+	name  string
+	parse func(string) error
+}
+
+// newTypedFlags returns the typed flags of the section, their environment
+// variables are checked after set is parsed.
+func newTypedFlags(set *cli.FlagSet, section *cli.FlagSection) *typedFlags {
+	t := &typedFlags{set: set, section: section}
+	set.AfterParse(func(error) error {
+		return t.checkEnv()
+	})
+	return t
+}
+
+// checkEnv returns an error naming each environment variable of a typed
+// flag whose value does not parse.
+func (t *typedFlags) checkEnv() error {
+	var merr error
+	for _, c := range t.checks {
+		v, ok := t.set.LookupEnv(c.name)
+		if !ok || v == "" {
+			continue
+		}
+		if err := c.parse(v); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("invalid %s %q: %w", c.name, v, err))
+		}
+	}
+	return merr
+}
+
+// addCheck checks the environment variable with parse, if any.
+func addCheck[T any](t *typedFlags, name string, parse func(string) (T, error)) {
+	if name == "" {
+		return
+	}
+	t.checks = append(t.checks, envCheck{name: name, parse: func(s string) error {
+		_, err := parse(s)
+		return err
+	}})
+}
+
+// BoolVar defines a bool flag.
+func (t *typedFlags) BoolVar(i *cli.BoolVar) {
+	t.section.BoolVar(i)
+	addCheck(t, i.EnvVar, strconv.ParseBool)
+}
+
+// DurationVar defines a duration flag.
+func (t *typedFlags) DurationVar(i *cli.DurationVar) {
+	t.section.DurationVar(i)
+	addCheck(t, i.EnvVar, time.ParseDuration)
+}
+
+// IntVar defines an int flag.
+func (t *typedFlags) IntVar(i *cli.IntVar) {
+	t.section.IntVar(i)
+	addCheck(t, i.EnvVar, strconv.Atoi)
+}
+
+// Float64Var defines a float64 flag.
+func (t *typedFlags) Float64Var(i *cli.Float64Var) {
+	t.section.Float64Var(i)
+	addCheck(t, i.EnvVar, func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	})
+}
+
+// ByteSizeVar defines a [ByteSize] flag.
+func (t *typedFlags) ByteSizeVar(i *cli.Var[ByteSize]) {
+	i.Parser = ParseByteSize
+	i.Printer = func(b ByteSize) string {
+		if b == 0 {
+			return ""
+		}
+		return b.String()
+	}
+	cli.Flag(t.section, i)
+	addCheck(t, i.EnvVar, ParseByteSize)
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"math"
+	"testing"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestParseByteSize(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		in      string
+		want    ByteSize
+		wantStr string
+		wantErr string
+	}{
+		{
+			name:    "bytes",
+			in:      "512",
+			want:    512,
+			wantStr: "512",
+		},
+		{
+			name:    "bytes_unit",
+			in:      "300B",
+			want:    300,
+			wantStr: "300",
+		},
+		{
+			name:    "kibibytes",
+			in:      "16KiB",
+			want:    16 * 1024,
+			wantStr: "16KiB",
+		},
+		{
+			name:    "megabytes_with_space",
+			in:      "1 MB",
+			want:    1000 * 1000,
+			wantStr: "1000000",
+		},
+		{
+			name:    "gibibytes",
+			in:      "2GiB",
+			want:    2 << 30,
+			wantStr: "2GiB",
+		},
+		{
+			name:    "unknown_unit",
+			in:      "1TB",
+			wantErr: `invalid byte size "1TB"`,
+		},
+		{
+			name:    "empty",
+			in:      "",
+			wantErr: "invalid byte size",
+		},
+		{
+			name:    "negative",
+			in:      "-1MiB",
+			wantErr: `invalid byte size "-1MiB", must not be negative`,
+		},
+		{
+			name:    "overflow",
+			in:      "9999999999999GiB",
+			wantErr: `invalid byte size "9999999999999GiB", too large`,
+		},
+		{
+			name:    "max_bytes",
+			in:      "9223372036854775807",
+			want:    math.MaxInt64,
+			wantStr: "9223372036854775807",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseByteSize(tc.in)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			if got != tc.want {
+				t.Errorf("ParseByteSize(%q) got %d, want %d", tc.in, got, tc.want)
+			}
+			if got, want := got.String(), tc.wantStr; got != want {
+				t.Errorf("String() got %q, want %q", got, want)
+			}
+		})
+	}
+}
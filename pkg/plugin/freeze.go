@@ -0,0 +1,260 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxFreezeDuration bounds the duration of a freeze window, it keeps the
+// lookup of the window covering a time cheap.
+const maxFreezeDuration = 31 * 24 * time.Hour
+
+// jiraFreezeWindowEnd is the key for the end of the freeze window in the
+// annotation map of a justification accepted during a freeze.
+const jiraFreezeWindowEnd = "jira_freeze_window_end"
+
+var (
+	cronMonthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	cronDayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// cronField is the set of values a field of a cron expression matches.
+type cronField struct {
+	values map[int]bool
+
+	// any is set for "*", which matters for the day fields.
+	any bool
+}
+
+func (f *cronField) match(v int) bool {
+	return f.any || f.values[v]
+}
+
+// parseCronField parses a comma separated list of "*", values, ranges "a-b"
+// and steps "*/n" or "a-b/n" within [lo, hi].
+func parseCronField(s string, lo, hi int, names map[string]int) (*cronField, error) {
+	f := &cronField{values: make(map[int]bool), any: s == "*"}
+	for _, part := range strings.Split(s, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			rng, step = part[:i], n
+		}
+
+		start, end := lo, hi
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], names); err != nil {
+				return nil, err
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = parseCronValue(bounds[1], names); err != nil {
+					return nil, err
+				}
+			} else if step > 1 {
+				end = hi
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			f.values[v] = true
+		}
+	}
+	return f, nil
+}
+
+func parseCronValue(s string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// freezeWindow is a recurring freeze, starting at the times matching a cron
+// expression and lasting for a fixed duration.
+type freezeWindow struct {
+	minute, hour, dom, month, dow *cronField
+	duration                      time.Duration
+}
+
+// parseFreezeWindow parses a window like "0 18 * * FRI 62h": a cron
+// expression with the minute, hour, day of month, month and day of week of
+// the start, followed by the duration.
+func parseFreezeWindow(s string) (*freezeWindow, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("freeze window %q must be a cron expression with 5 fields followed by a duration", s)
+	}
+
+	d, err := time.ParseDuration(fields[5])
+	if err != nil {
+		return nil, fmt.Errorf("freeze window %q has an invalid duration: %w", s, err)
+	}
+	if d <= 0 || d > maxFreezeDuration {
+		return nil, fmt.Errorf("freeze window %q must last between 1m and %s", s, maxFreezeDuration)
+	}
+
+	w := &freezeWindow{duration: d}
+	for _, f := range []struct {
+		target **cronField
+		name   string
+		text   string
+		lo, hi int
+		names  map[string]int
+	}{
+		{&w.minute, "minute", fields[0], 0, 59, nil},
+		{&w.hour, "hour", fields[1], 0, 23, nil},
+		{&w.dom, "day of month", fields[2], 1, 31, nil},
+		{&w.month, "month", fields[3], 1, 12, cronMonthNames},
+		{&w.dow, "day of week", fields[4], 0, 7, cronDayNames},
+	} {
+		parsed, err := parseCronField(f.text, f.lo, f.hi, f.names)
+		if err != nil {
+			return nil, fmt.Errorf("freeze window %q has an invalid %s: %w", s, f.name, err)
+		}
+		*f.target = parsed
+	}
+	// Both 0 and 7 are Sunday.
+	if w.dow.values[7] {
+		w.dow.values[0] = true
+	}
+	return w, nil
+}
+
+// dayMatches reports whether a window starts on the day of t. Like cron, a
+// restricted day of month and day of week match when either does.
+func (w *freezeWindow) dayMatches(t time.Time) bool {
+	if !w.month.match(int(t.Month())) {
+		return false
+	}
+	dom, dow := w.dom.match(t.Day()), w.dow.match(int(t.Weekday()))
+	if !w.dom.any && !w.dow.any {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// end returns the end of the window covering t and true, or false when t is
+// outside the window.
+func (w *freezeWindow) end(t time.Time) (time.Time, bool) {
+	earliest := t.Add(-w.duration)
+	for s := t.Truncate(time.Minute); s.After(earliest); {
+		switch {
+		case !w.dayMatches(s):
+			s = time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, s.Location()).Add(-time.Minute)
+		case !w.hour.match(s.Hour()):
+			s = s.Truncate(time.Hour).Add(-time.Minute)
+		case !w.minute.match(s.Minute()):
+			s = s.Add(-time.Minute)
+		default:
+			return s.Add(w.duration), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// freezePolicy restricts validations during freeze windows to the issues
+// matching the emergency JQL.
+type freezePolicy struct {
+	windows  []*freezeWindow
+	location *time.Location
+	now      func() time.Time
+
+	// validator matches issues against the JQL and the emergency JQL, it is
+	// nil when no issue is accepted during a freeze.
+	validator IssueMatcher
+
+	// jira is the validator talking to Jira behind validator.
+	jira *lazyValidator
+}
+
+// newFreezePolicy parses the freeze windows of cfg, it returns nil when there
+// are none.
+func newFreezePolicy(cfg *PluginConfig) (*freezePolicy, error) {
+	if strings.TrimSpace(cfg.FreezeWindows) == "" {
+		return nil, nil
+	}
+
+	timezone := cfg.FreezeTimezone
+	if timezone == "" {
+		timezone = cfg.SiteTimezone
+	}
+	loc, err := loadTimezone(timezone)
+	if err != nil {
+		return nil, err
+	}
+	p := &freezePolicy{location: loc, now: time.Now}
+	for _, s := range strings.Split(cfg.FreezeWindows, ";") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		w, err := parseFreezeWindow(s)
+		if err != nil {
+			return nil, err
+		}
+		p.windows = append(p.windows, w)
+	}
+	return p, nil
+}
+
+// end returns the latest end of the freeze windows covering t and true, or
+// false when t is outside all of them.
+func (p *freezePolicy) end(t time.Time) (time.Time, bool) {
+	t = t.In(p.location)
+	var latest time.Time
+	for _, w := range p.windows {
+		if end, ok := w.end(t); ok && end.After(latest) {
+			latest = end
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// emergencyConfig returns the configuration of the validator used during a
+// freeze, whose JQLs, the issue type JQLs included, require both the JQL and
+// the emergency JQL to match.
+func emergencyConfig(cfg *PluginConfig) *PluginConfig {
+	emergency := *cfg
+	emergency.Jql = "(" + cfg.Jql + ") AND (" + cfg.FreezeJql + ")"
+	emergency.CandidateJql = ""
+	if cfg.IssueTypeJql != "" {
+		routes, _ := parseIssueTypeJQL(cfg.IssueTypeJql) //nolint:errcheck // Checked by Validate
+		for _, r := range routes {
+			r.JQL = "(" + r.JQL + ") AND (" + cfg.FreezeJql + ")"
+		}
+		emergency.IssueTypeJql = formatIssueTypeJQL(routes)
+	}
+	return &emergency
+}
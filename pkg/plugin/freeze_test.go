@@ -0,0 +1,285 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestParseFreezeWindow(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		window  string
+		wantErr string
+	}{
+		{
+			name:   "weekend",
+			window: "0 18 * * FRI 62h",
+		},
+		{
+			name:   "lists_ranges_and_steps",
+			window: "*/15 9-17 1,15 jan-mar mon-fri 10m",
+		},
+		{
+			name:    "missing_duration",
+			window:  "0 18 * * FRI",
+			wantErr: "must be a cron expression with 5 fields followed by a duration",
+		},
+		{
+			name:    "invalid_duration",
+			window:  "0 18 * * FRI 2d",
+			wantErr: "invalid duration",
+		},
+		{
+			name:    "too_long",
+			window:  "0 18 * * FRI 1000h",
+			wantErr: "must last between 1m and 744h0m0s",
+		},
+		{
+			name:    "minute_out_of_range",
+			window:  "60 18 * * FRI 1h",
+			wantErr: `invalid minute: "60" is out of range 0-59`,
+		},
+		{
+			name:    "invalid_day_name",
+			window:  "0 18 * * FRIDAY 1h",
+			wantErr: `invalid day of week: invalid value "FRIDAY"`,
+		},
+		{
+			name:    "invalid_step",
+			window:  "*/0 18 * * * 1h",
+			wantErr: `invalid minute: invalid step in "*/0"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseFreezeWindow(tc.window)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestFreezePolicy_End(t *testing.T) {
+	t.Parallel()
+
+	// 2023-09-01 is a Friday.
+	cases := []struct {
+		name         string
+		windows      string
+		timezone     string
+		siteTimezone string
+		time         time.Time
+		wantEnd      time.Time
+	}{
+		{
+			name:    "weekend_start",
+			windows: "0 18 * * FRI 62h",
+			time:    time.Date(2023, 9, 1, 18, 0, 0, 0, time.UTC),
+			wantEnd: time.Date(2023, 9, 4, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "weekend_last_minute",
+			windows: "0 18 * * FRI 62h",
+			time:    time.Date(2023, 9, 4, 7, 59, 59, 0, time.UTC),
+			wantEnd: time.Date(2023, 9, 4, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "weekend_over",
+			windows: "0 18 * * FRI 62h",
+			time:    time.Date(2023, 9, 4, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "before_weekend",
+			windows: "0 18 * * FRI 62h",
+			time:    time.Date(2023, 9, 1, 17, 59, 0, 0, time.UTC),
+		},
+		{
+			name:     "time_zone",
+			windows:  "0 18 * * FRI 62h",
+			timezone: "America/New_York",
+			time:     time.Date(2023, 9, 1, 22, 30, 0, 0, time.UTC),
+			wantEnd:  time.Date(2023, 9, 4, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "site_time_zone",
+			windows:      "0 18 * * FRI 62h",
+			siteTimezone: "America/New_York",
+			time:         time.Date(2023, 9, 1, 22, 30, 0, 0, time.UTC),
+			wantEnd:      time.Date(2023, 9, 4, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "freeze_time_zone_over_site_time_zone",
+			windows:      "0 18 * * FRI 62h",
+			timezone:     "UTC",
+			siteTimezone: "America/New_York",
+			time:         time.Date(2023, 9, 1, 18, 30, 0, 0, time.UTC),
+			wantEnd:      time.Date(2023, 9, 4, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "day_of_month_or_day_of_week",
+			windows: "0 0 15 * MON 24h",
+			time:    time.Date(2023, 9, 4, 12, 0, 0, 0, time.UTC),
+			wantEnd: time.Date(2023, 9, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "latest_end_of_overlapping_windows",
+			windows: "0 18 * * FRI 62h; 0 0 1 9 * 168h",
+			time:    time.Date(2023, 9, 2, 0, 0, 0, 0, time.UTC),
+			wantEnd: time.Date(2023, 9, 8, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := newFreezePolicy(&PluginConfig{FreezeWindows: tc.windows, FreezeTimezone: tc.timezone, SiteTimezone: tc.siteTimezone})
+			if err != nil {
+				t.Fatalf("failed to parse freeze windows: %v", err)
+			}
+			gotEnd, ok := p.end(tc.time)
+			if got, want := ok, !tc.wantEnd.IsZero(); got != want {
+				t.Fatalf("end(%s) got frozen %t, want %t", tc.time, got, want)
+			}
+			if !gotEnd.Equal(tc.wantEnd) {
+				t.Errorf("end(%s) got %s, want %s", tc.time, gotEnd, tc.wantEnd)
+			}
+		})
+	}
+}
+
+func TestPlugin_Validate_Freeze(t *testing.T) {
+	t.Parallel()
+
+	// The issue matches the JQL but not the emergency JQL.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		var data matchData
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			t.Errorf("failed to decode match request: %v", err)
+		}
+		if strings.Contains(data.Jqls[0], "emergency") {
+			fmt.Fprint(w, `{"matches":[{"matchedIssues":[],"errors":[]}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	frozen := time.Date(2023, 9, 2, 12, 0, 0, 0, time.UTC)
+	notFrozen := time.Date(2023, 9, 5, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name      string
+		freezeJQL string
+		now       time.Time
+		want      *jvspb.ValidateJustificationResponse
+	}{
+		{
+			name:      "not_frozen",
+			freezeJQL: "labels = emergency",
+			now:       notFrozen,
+			want: &jvspb.ValidateJustificationResponse{
+				Valid:   true,
+				Warning: []string{},
+				Annotation: map[string]string{
+					jiraIssueID:  "1234",
+					jiraIssueURL: "https://example.atlassian.net/browse/ABCD-1",
+				},
+			},
+		},
+		{
+			name:      "frozen_not_emergency",
+			freezeJQL: "labels = emergency",
+			now:       frozen,
+			want: &jvspb.ValidateJustificationResponse{
+				Valid: false,
+				Error: []string{"deploy freeze in effect until 2023-09-04T08:00:00Z, only issues matching the emergency JQL are accepted: " +
+					`no matched jira issue for justification "ABCD-1": invalid justification`},
+			},
+		},
+		{
+			name: "frozen_without_emergency_jql",
+			now:  frozen,
+			want: &jvspb.ValidateJustificationResponse{
+				Valid: false,
+				Error: []string{"deploy freeze in effect until 2023-09-04T08:00:00Z, no justification is accepted"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &PluginConfig{
+				JIRAEndpoint:  srv.URL,
+				Jql:           "status NOT IN (Done)",
+				JIRAAccount:   "test@test.com",
+				IssueBaseURL:  "https://example.atlassian.net",
+				FreezeWindows: "0 18 * * FRI 62h",
+				FreezeJql:     tc.freezeJQL,
+			}
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+			if err != nil {
+				t.Fatalf("failed to create plugin: %v", err)
+			}
+			t.Cleanup(func() { p.Close(context.Background()) })
+			p.current.Load().freeze.now = func() time.Time { return tc.now }
+
+			got, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{Category: jiraCategory, Value: "ABCD-1"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(jvspb.ValidateJustificationResponse{})); diff != "" {
+				t.Errorf("Validate() unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
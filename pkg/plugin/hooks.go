@@ -0,0 +1,121 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+// ValidationHook runs extra checks or side effects around the validation of
+// a justification, so that custom builds can extend the plugin without
+// forking it. See [WithValidationHooks] for the order hooks run in.
+type ValidationHook interface {
+	// PreValidate runs before the justification is validated. It returns the
+	// context the later hooks and the validation run with, nil to keep ctx.
+	// An error wrapping [ErrInvalidJustification] rejects the justification,
+	// use [WithReason] to give it a rejection code. Any other error fails the
+	// validation. Either way the justification is not validated and the
+	// later hooks do not run.
+	PreValidate(ctx context.Context, req *jvspb.ValidateJustificationRequest) (context.Context, error)
+
+	// PostValidate runs after the justification was validated, or rejected
+	// by the PreValidate of a later hook, with the response and the error of
+	// the validation. It returns the response and error passed on, e.g. the
+	// response with extra annotations, or an invalid response to reject a
+	// valid justification. Return resp and err unchanged to keep them.
+	PostValidate(ctx context.Context, req *jvspb.ValidateJustificationRequest, resp *jvspb.ValidateJustificationResponse, err error) (*jvspb.ValidateJustificationResponse, error)
+}
+
+// ValidationHookFuncs adapts functions to a [ValidationHook], a nil function
+// does nothing.
+type ValidationHookFuncs struct {
+	Pre  func(ctx context.Context, req *jvspb.ValidateJustificationRequest) (context.Context, error)
+	Post func(ctx context.Context, req *jvspb.ValidateJustificationRequest, resp *jvspb.ValidateJustificationResponse, err error) (*jvspb.ValidateJustificationResponse, error)
+}
+
+// PreValidate implements [ValidationHook].
+func (h ValidationHookFuncs) PreValidate(ctx context.Context, req *jvspb.ValidateJustificationRequest) (context.Context, error) {
+	if h.Pre == nil {
+		return ctx, nil
+	}
+	return h.Pre(ctx, req)
+}
+
+// PostValidate implements [ValidationHook].
+func (h ValidationHookFuncs) PostValidate(ctx context.Context, req *jvspb.ValidateJustificationRequest, resp *jvspb.ValidateJustificationResponse, err error) (*jvspb.ValidateJustificationResponse, error) {
+	if h.Post == nil {
+		return resp, err
+	}
+	return h.Post(ctx, req, resp, err)
+}
+
+// WithValidationHooks adds hooks running around every validation, after
+// the hooks added before. The PreValidate methods run in order, then the
+// validation, then the PostValidate methods in reverse order, like nested
+// middlewares: the first hook sees the request first and the response last.
+// When a PreValidate fails, only the PostValidate of the hooks before it
+// run. The PostValidate methods run with the context returned by the last
+// PreValidate.
+//
+// Hooks run for every request, including bypassed ones, before the decision
+// is signed, logged and audited. They must be safe for concurrent use, and
+// must not block longer than the deadline of the request.
+func WithValidationHooks(hooks ...ValidationHook) Option {
+	return func(s *snapshot) {
+		s.hooks = append(s.hooks[:len(s.hooks):len(s.hooks)], hooks...)
+	}
+}
+
+// validateWithHooks runs validate, the validation with s, within the
+// validation hooks of s.
+func (s *snapshot) validateWithHooks(ctx context.Context, req *jvspb.ValidateJustificationRequest,
+	validate func(context.Context) (*jvspb.ValidateJustificationResponse, error),
+) (*jvspb.ValidateJustificationResponse, error) {
+	var resp *jvspb.ValidateJustificationResponse
+	var err error
+	ran := 0
+	for i, h := range s.hooks {
+		hookCtx, hookErr := h.PreValidate(ctx, req)
+		if hookErr != nil {
+			name := fmt.Sprintf("hook %d", i)
+			if errors.Is(hookErr, ErrInvalidJustification) {
+				explainCheck(ctx, "pre_validate", name, ExplainFail, hookErr.Error())
+				resp, err = s.rejectErr(ctx, hookErr, req.GetJustification().GetValue())
+			} else {
+				explainCheck(ctx, "pre_validate", name, ExplainError, hookErr.Error())
+				err = fmt.Errorf("pre-validation hook %d failed: %w", i, hookErr)
+			}
+			break
+		}
+		if hookCtx != nil {
+			ctx = hookCtx
+		}
+		ran++
+	}
+	if ran == len(s.hooks) {
+		resp, err = validate(ctx)
+	}
+	for i := ran - 1; i >= 0; i-- {
+		resp, err = s.hooks[i].PostValidate(ctx, req, resp, err)
+	}
+	if resp == nil && err == nil {
+		return nil, fmt.Errorf("validation hooks returned neither a response nor an error")
+	}
+	return resp, err
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+type hookCtxKey struct{}
+
+// recordingHook records its calls in log and behaves as configured.
+type recordingHook struct {
+	name   string
+	log    *[]string
+	preErr error
+
+	// reject turns valid responses into invalid ones.
+	reject bool
+}
+
+func (h *recordingHook) PreValidate(ctx context.Context, req *jvspb.ValidateJustificationRequest) (context.Context, error) {
+	*h.log = append(*h.log, "pre "+h.name)
+	if h.preErr != nil {
+		return nil, h.preErr
+	}
+	return context.WithValue(ctx, hookCtxKey{}, h.name), nil
+}
+
+func (h *recordingHook) PostValidate(ctx context.Context, req *jvspb.ValidateJustificationRequest, resp *jvspb.ValidateJustificationResponse, err error) (*jvspb.ValidateJustificationResponse, error) {
+	*h.log = append(*h.log, fmt.Sprintf("post %s ctx=%v", h.name, ctx.Value(hookCtxKey{})))
+	if h.reject && resp.GetValid() {
+		return invalidErrResponse("rejected by " + h.name), nil
+	}
+	return resp, err
+}
+
+// ctxMatcher records the hook context value the issue is matched with.
+type ctxMatcher struct {
+	mockValidator
+	log *[]string
+}
+
+func (m *ctxMatcher) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
+	*m.log = append(*m.log, fmt.Sprintf("match ctx=%v", ctx.Value(hookCtxKey{})))
+	return m.mockValidator.MatchIssue(ctx, issueKey)
+}
+
+func TestValidationHooks(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		hooks     func(log *[]string) []ValidationHook
+		wantLog   []string
+		wantValid bool
+		wantError []string
+		wantCode  codes.Code
+	}{
+		{
+			name: "nested_order",
+			hooks: func(log *[]string) []ValidationHook {
+				return []ValidationHook{&recordingHook{name: "a", log: log}, &recordingHook{name: "b", log: log}}
+			},
+			wantLog:   []string{"pre a", "pre b", "match ctx=b", "post b ctx=b", "post a ctx=b"},
+			wantValid: true,
+		},
+		{
+			name: "pre_rejects",
+			hooks: func(log *[]string) []ValidationHook {
+				return []ValidationHook{
+					&recordingHook{name: "a", log: log},
+					&recordingHook{name: "b", log: log, preErr: fmt.Errorf("change window closed: %w", ErrInvalidJustification)},
+					&recordingHook{name: "c", log: log},
+				}
+			},
+			wantLog:   []string{"pre a", "pre b", "post a ctx=a"},
+			wantError: []string{"change window closed: invalid justification"},
+		},
+		{
+			name: "pre_fails",
+			hooks: func(log *[]string) []ValidationHook {
+				return []ValidationHook{&recordingHook{name: "a", log: log, preErr: errors.New("policy service down")}}
+			},
+			wantLog:  []string{"pre a"},
+			wantCode: codes.Internal,
+		},
+		{
+			name: "post_rejects",
+			hooks: func(log *[]string) []ValidationHook {
+				return []ValidationHook{&recordingHook{name: "a", log: log, reject: true}}
+			},
+			wantLog:   []string{"pre a", "match ctx=a", "post a ctx=a"},
+			wantError: []string{"rejected by a"},
+		},
+		{
+			name: "funcs",
+			hooks: func(log *[]string) []ValidationHook {
+				return []ValidationHook{ValidationHookFuncs{
+					Pre: func(ctx context.Context, req *jvspb.ValidateJustificationRequest) (context.Context, error) {
+						*log = append(*log, "pre "+req.GetJustification().GetValue())
+						return nil, nil
+					},
+				}}
+			},
+			wantLog:   []string{"pre ABCD-1", "match ctx=<nil>"},
+			wantValid: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var log []string
+			s := &snapshot{
+				validator:    &ctxMatcher{mockValidator: mockValidator{result: testMatch(1234)}, log: &log},
+				issueBaseURL: "https://example.atlassian.net",
+			}
+			WithValidationHooks(tc.hooks(&log)...)(s)
+			p := newTestPlugin(s)
+
+			resp, err := p.Validate(context.Background(), &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+			})
+			if got := status.Code(err); got != tc.wantCode {
+				t.Fatalf("got code %s, want %s: %v", got, tc.wantCode, err)
+			}
+			if diff := cmp.Diff(tc.wantLog, log); diff != "" {
+				t.Errorf("calls unexpected diff (-want,+got):\n%s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if got := resp.GetValid(); got != tc.wantValid {
+				t.Errorf("got valid %t, want %t", got, tc.wantValid)
+			}
+			if diff := cmp.Diff(tc.wantError, resp.GetError()); diff != "" {
+				t.Errorf("errors unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidationHooks_NoResponse(t *testing.T) {
+	t.Parallel()
+
+	s := &snapshot{validator: &mockValidator{result: testMatch(1234)}, issueBaseURL: "https://example.atlassian.net"}
+	WithValidationHooks(ValidationHookFuncs{
+		Post: func(ctx context.Context, req *jvspb.ValidateJustificationRequest, resp *jvspb.ValidateJustificationResponse, err error) (*jvspb.ValidateJustificationResponse, error) {
+			return nil, nil
+		},
+	})(s)
+	p := newTestPlugin(s)
+
+	if _, err := p.ValidateValue(context.Background(), "ABCD-1"); err == nil {
+		t.Error("got no error, want one")
+	}
+}
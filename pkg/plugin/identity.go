@@ -0,0 +1,191 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// IdentityMapper maps the subject of a JVS requestor, e.g. a Google identity,
+// to the identity of the Jira user, e.g. the email of the Jira account. The
+// error of a subject without a Jira identity should wrap
+// ErrInvalidJustification.
+type IdentityMapper interface {
+	JiraIdentity(ctx context.Context, subject string) (string, error)
+}
+
+var (
+	_ IdentityMapper = (*DirectIdentityMapper)(nil)
+	_ IdentityMapper = (*DomainRewriteMapper)(nil)
+	_ IdentityMapper = (*StaticIdentityMapper)(nil)
+)
+
+// WithIdentityMapper sets how requestors are mapped to Jira users by the
+// requestor-based policies, e.g. "assignee = {{.Requestor}}". Subjects are
+// used as is by default.
+func WithIdentityMapper(m IdentityMapper) ValidatorOption {
+	return func(v *Validator) error {
+		v.identities = m
+		return nil
+	}
+}
+
+// DirectIdentityMapper maps subjects to themselves, for JVS subjects that are
+// the emails of the Jira users.
+type DirectIdentityMapper struct{}
+
+// JiraIdentity returns the subject.
+func (m *DirectIdentityMapper) JiraIdentity(ctx context.Context, subject string) (string, error) {
+	return subject, nil
+}
+
+// DomainRewriteMapper maps email subjects by replacing their domain, e.g.
+// "jane@example.com" to "jane@corp.example.com". Subjects of other domains
+// are used as is.
+type DomainRewriteMapper struct {
+	domains map[string]string
+}
+
+// NewDomainRewriteMapper creates a mapper from rules like
+// "example.com=corp.example.com".
+func NewDomainRewriteMapper(rules []string) (*DomainRewriteMapper, error) {
+	m := &DomainRewriteMapper{domains: make(map[string]string, len(rules))}
+	for _, rule := range rules {
+		from, to, ok := strings.Cut(rule, "=")
+		from, to = strings.ToLower(strings.TrimSpace(from)), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" || strings.Contains(from, "@") || strings.Contains(to, "@") {
+			return nil, fmt.Errorf("invalid domain rewrite %q, must be <domain>=<domain>", rule)
+		}
+		if _, ok := m.domains[from]; ok {
+			return nil, fmt.Errorf("duplicate domain rewrite of %q", from)
+		}
+		m.domains[from] = to
+	}
+	return m, nil
+}
+
+// JiraIdentity returns the subject with its domain rewritten.
+func (m *DomainRewriteMapper) JiraIdentity(ctx context.Context, subject string) (string, error) {
+	at := strings.LastIndex(subject, "@")
+	if at < 0 {
+		return subject, nil
+	}
+	if to, ok := m.domains[strings.ToLower(subject[at+1:])]; ok {
+		return subject[:at+1] + to, nil
+	}
+	return subject, nil
+}
+
+// StaticIdentityMapper maps subjects with a fixed table, e.g. read from a
+// file or a secret. Subjects missing from the table are mapped by the next
+// mapper.
+type StaticIdentityMapper struct {
+	identities map[string]string
+	next       IdentityMapper
+}
+
+// NewStaticIdentityMapper creates a mapper from CSV records of a subject and
+// its Jira identity, e.g. "jane@example.com,jane.doe@corp.example.com".
+// Lines starting with # are comments. Subjects are case-insensitive. Subjects
+// missing from the table are mapped by next, used as is when next is nil.
+func NewStaticIdentityMapper(r io.Reader, next IdentityMapper) (*StaticIdentityMapper, error) {
+	if next == nil {
+		next = &DirectIdentityMapper{}
+	}
+	m := &StaticIdentityMapper{identities: make(map[string]string), next: next}
+
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity mapping: %w", err)
+		}
+		subject, identity := strings.ToLower(strings.TrimSpace(record[0])), strings.TrimSpace(record[1])
+		if subject == "" || identity == "" {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("invalid identity mapping on line %d, the subject and the jira identity must not be empty", line)
+		}
+		if _, ok := m.identities[subject]; ok {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("invalid identity mapping on line %d, duplicate subject %q", line, subject)
+		}
+		m.identities[subject] = identity
+	}
+	return m, nil
+}
+
+// JiraIdentity returns the Jira identity of the subject in the table, or the
+// one of the next mapper.
+func (m *StaticIdentityMapper) JiraIdentity(ctx context.Context, subject string) (string, error) {
+	if identity, ok := m.identities[strings.ToLower(subject)]; ok {
+		return identity, nil
+	}
+	return m.next.JiraIdentity(ctx, subject) //nolint:wrapcheck // Returned as is
+}
+
+// identityMapped reports whether the config maps requestors to Jira users.
+func (cfg *PluginConfig) identityMapped() bool {
+	return len(cfg.IdentityDomainRewrites) > 0 || cfg.IdentityMappingFile != "" || cfg.IdentityMappingSecretID != ""
+}
+
+// identityMapper returns the identity mapper of the config: the static
+// mapping, read from IdentityMappingFile unless given, falling back to the
+// domain rewrites.
+func (cfg *PluginConfig) identityMapper(mapping string) (IdentityMapper, error) {
+	var m IdentityMapper = &DirectIdentityMapper{}
+	if len(cfg.IdentityDomainRewrites) > 0 {
+		rewrites, err := NewDomainRewriteMapper(cfg.IdentityDomainRewrites)
+		if err != nil {
+			return nil, err
+		}
+		m = rewrites
+	}
+	if mapping == "" && cfg.IdentityMappingFile != "" {
+		b, err := os.ReadFile(cfg.IdentityMappingFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity mapping file: %w", err)
+		}
+		mapping = string(b)
+	}
+	if mapping == "" {
+		return m, nil
+	}
+	return NewStaticIdentityMapper(strings.NewReader(mapping), m)
+}
+
+// withConfigIdentityMapper sets the identity mapper of the config, see
+// [PluginConfig.identityMapper].
+func withConfigIdentityMapper(cfg *PluginConfig, mapping string) ValidatorOption {
+	return func(v *Validator) error {
+		m, err := cfg.identityMapper(mapping)
+		if err != nil {
+			return err
+		}
+		v.identities = m
+		return nil
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestDomainRewriteMapper(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewDomainRewriteMapper([]string{"example.com=corp.example.com", " Contractors.Example.com = vendors.example.com "})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		subject string
+		want    string
+	}{
+		{subject: "jane@example.com", want: "jane@corp.example.com"},
+		{subject: "jane@EXAMPLE.COM", want: "jane@corp.example.com"},
+		{subject: "joe@contractors.example.com", want: "joe@vendors.example.com"},
+		{subject: "joe@other.example.com", want: "joe@other.example.com"},
+		{subject: "5b10ac8d82e05b22cc7d4ef5", want: "5b10ac8d82e05b22cc7d4ef5"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.subject, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := m.JiraIdentity(context.Background(), tc.subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("JiraIdentity(%q) got %q, want %q", tc.subject, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewDomainRewriteMapper_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		rules   []string
+		wantErr string
+	}{
+		{name: "missing_target", rules: []string{"example.com"}, wantErr: `invalid domain rewrite "example.com", must be <domain>=<domain>`},
+		{name: "empty_target", rules: []string{"example.com="}, wantErr: "must be <domain>=<domain>"},
+		{name: "email", rules: []string{"jane@example.com=corp.example.com"}, wantErr: "must be <domain>=<domain>"},
+		{name: "duplicate", rules: []string{"example.com=a.example.com", "EXAMPLE.com=b.example.com"}, wantErr: `duplicate domain rewrite of "example.com"`},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewDomainRewriteMapper(tc.rules)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestStaticIdentityMapper(t *testing.T) {
+	t.Parallel()
+
+	rewrites, err := NewDomainRewriteMapper([]string{"example.com=corp.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		csv     string
+		next    IdentityMapper
+		subject string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "mapped",
+			csv:     "# subject,jira user\njane@gmail.com, jane.doe@corp.example.com\n",
+			subject: "Jane@Gmail.com",
+			want:    "jane.doe@corp.example.com",
+		},
+		{
+			name:    "unmapped_direct",
+			csv:     "jane@gmail.com,jane.doe@corp.example.com\n",
+			subject: "joe@gmail.com",
+			want:    "joe@gmail.com",
+		},
+		{
+			name:    "unmapped_rewrite",
+			csv:     "jane@gmail.com,jane.doe@corp.example.com\n",
+			next:    rewrites,
+			subject: "joe@example.com",
+			want:    "joe@corp.example.com",
+		},
+		{
+			name:    "wrong_field_count",
+			csv:     "jane@gmail.com,jane.doe@corp.example.com\njoe@gmail.com\n",
+			wantErr: "failed to read identity mapping: record on line 2: wrong number of fields",
+		},
+		{
+			name:    "empty_identity",
+			csv:     "jane@gmail.com, \n",
+			wantErr: "invalid identity mapping on line 1, the subject and the jira identity must not be empty",
+		},
+		{
+			name:    "duplicate",
+			csv:     "jane@gmail.com,a@corp.example.com\nJANE@gmail.com,b@corp.example.com\n",
+			wantErr: `invalid identity mapping on line 2, duplicate subject "jane@gmail.com"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := NewStaticIdentityMapper(strings.NewReader(tc.csv), tc.next)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			got, err := m.JiraIdentity(context.Background(), tc.subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("JiraIdentity(%q) got %q, want %q", tc.subject, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidator_MatchIssue_IdentityMapping(t *testing.T) {
+	t.Parallel()
+
+	mappingFile := filepath.Join(t.TempDir(), "identities.csv")
+	if err := os.WriteFile(mappingFile, []byte("jane@gmail.com,jane.doe@corp.example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		cfg     *PluginConfig
+		secret  string
+		subject string
+		wantJQL string
+		wantErr string
+	}{
+		{
+			name:    "direct",
+			cfg:     &PluginConfig{},
+			subject: "jane@gmail.com",
+			wantJQL: `assignee = "jane@gmail.com"`,
+		},
+		{
+			name:    "domain_rewrite",
+			cfg:     &PluginConfig{IdentityDomainRewrites: []string{"example.com=corp.example.com"}},
+			subject: "joe@example.com",
+			wantJQL: `assignee = "joe@corp.example.com"`,
+		},
+		{
+			name:    "file",
+			cfg:     &PluginConfig{IdentityMappingFile: mappingFile, IdentityDomainRewrites: []string{"example.com=corp.example.com"}},
+			subject: "jane@gmail.com",
+			wantJQL: `assignee = "jane.doe@corp.example.com"`,
+		},
+		{
+			name:    "secret",
+			cfg:     &PluginConfig{IdentityMappingSecretID: "projects/1/secrets/identities/versions/1"},
+			secret:  "joe@gmail.com,joe.bloggs@corp.example.com\n",
+			subject: "joe@gmail.com",
+			wantJQL: `assignee = "joe.bloggs@corp.example.com"`,
+		},
+		{
+			name:    "missing_file",
+			cfg:     &PluginConfig{IdentityMappingFile: filepath.Join(t.TempDir(), "missing.csv")},
+			wantErr: "failed to read identity mapping file",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotJQL string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id":"1","key":"ABCD-1"}`)
+			})
+			mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+				var req matchData
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					t.Error(err)
+				}
+				gotJQL = req.Jqls[0]
+				fmt.Fprint(w, `{"matches":[{"matchedIssues":[1],"errors":[]}]}`)
+			})
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			cfg := *tc.cfg
+			cfg.JIRAEndpoint = srv.URL
+			cfg.Jql = "assignee = {{.Requestor}}"
+			cfg.JIRAAccount = "test@test.com"
+			cfg.APITokenSecretID = "projects/1/secrets/token/versions/1"
+			accessSecret := func(ctx context.Context, name string) (string, error) {
+				if name == cfg.IdentityMappingSecretID {
+					return tc.secret, nil
+				}
+				return "token", nil
+			}
+
+			ctx := context.Background()
+			v, err := newValidatorFromSecrets(ctx, &cfg, accessSecret)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			ctx = WithRequestor(ctx, &Requestor{Subject: tc.subject})
+			if _, err := v.MatchIssue(ctx, "ABCD-1"); err != nil {
+				t.Fatal(err)
+			}
+			if gotJQL != tc.wantJQL {
+				t.Errorf("got JQL %q, want %q", gotJQL, tc.wantJQL)
+			}
+		})
+	}
+}
+
+func TestCacheBucket_IdentityMapping(t *testing.T) {
+	t.Parallel()
+
+	base := &PluginConfig{JIRAEndpoint: "https://example.atlassian.net", Jql: "assignee = {{.Requestor}}"}
+	buckets := map[string]string{}
+	for name, mutate := range map[string]func(cfg *PluginConfig){
+		"none":           func(cfg *PluginConfig) {},
+		"domain_rewrite": func(cfg *PluginConfig) { cfg.IdentityDomainRewrites = []string{"example.com=corp.example.com"} },
+		"other_rewrite":  func(cfg *PluginConfig) { cfg.IdentityDomainRewrites = []string{"example.com=vendors.example.com"} },
+		"file":           func(cfg *PluginConfig) { cfg.IdentityMappingFile = "/etc/jira/identities.csv" },
+		"secret":         func(cfg *PluginConfig) { cfg.IdentityMappingSecretID = "projects/p/secrets/identities/versions/1" },
+	} {
+		cfg := *base
+		mutate(&cfg)
+		bucket := string(cacheBucket(&cfg))
+		if other, ok := buckets[bucket]; ok {
+			t.Errorf("%s shares the cache bucket of %s", name, other)
+		}
+		buckets[bucket] = name
+	}
+}
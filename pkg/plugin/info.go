@@ -0,0 +1,105 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+// ProtocolVersions are the JVS plugin protocol versions the plugin serves,
+// i.e. the go-plugin app protocol versions of [jvspb.Handshake]. The host
+// refuses to load the plugin during the handshake when it speaks none of
+// them, instead of failing on the first validation.
+var ProtocolVersions = []int{int(jvspb.Handshake.ProtocolVersion)}
+
+// Info describes what a plugin with a given configuration supports, so hosts
+// and operators can check compatibility before sending validations.
+type Info struct {
+	// ProtocolVersions are the JVS plugin protocol versions served.
+	ProtocolVersions []int `json:"protocol_versions"`
+
+	// Categories are the justification categories validated.
+	Categories []string `json:"categories"`
+
+	// JustificationFormat is how justification values are parsed.
+	JustificationFormat string `json:"justification_format"`
+
+	// Annotations are the keys a valid justification may be annotated with.
+	Annotations []string `json:"annotations"`
+}
+
+// NewInfo returns the info of a plugin with the configuration.
+func NewInfo(cfg *PluginConfig) *Info {
+	format := cfg.JustificationFormat
+	if format == "" {
+		format = JustificationFormatKey
+	}
+
+	annotations := []string{jiraIssueID, jiraIssueURL}
+	switch format {
+	case JustificationFormatComposite:
+		annotations = append(annotations, jiraRelatedIssueKeys)
+	case JustificationFormatJSON:
+		annotations = append(annotations, jiraJustificationReason)
+	case JustificationFormatDual:
+		annotations = append(annotations, jiraChangeIssueID, jiraChangeIssueURL)
+	}
+	for _, name := range cfg.AnnotationFieldNames() {
+		annotations = append(annotations, annotationFieldPrefix+name)
+	}
+	if cfg.ArchivedIssues == ArchivedIssuesAllow {
+		annotations = append(annotations, jiraIssueArchived)
+	}
+	if cfg.IssueSnapshotHash {
+		annotations = append(annotations, jiraIssueSnapshotHash)
+	}
+	if cfg.FreezeWindows != "" && cfg.FreezeJql != "" {
+		annotations = append(annotations, jiraFreezeWindowEnd)
+	}
+	if len(cfg.BypassRequestors) > 0 {
+		annotations = append(annotations, jiraValidationBypassed, jiraBypassRequestor)
+	}
+	if cfg.BypassTokenSecretID != "" {
+		if len(cfg.BypassRequestors) == 0 {
+			annotations = append(annotations, jiraValidationBypassed, jiraBypassRequestor)
+		}
+		annotations = append(annotations, jiraBypassToken)
+	}
+	if cfg.AnnotationSigningKeySecretID != "" {
+		annotations = append(annotations, jiraSignedAt, jiraSignature)
+	}
+	if cfg.InsecureSkipVerify {
+		annotations = append(annotations, jiraInsecureTransport)
+	}
+	if cfg.cacheEnabled() && cfg.CacheMaxStaleness > 0 {
+		annotations = append(annotations, jiraCacheStale)
+	}
+	if cfg.DebugAnnotations {
+		annotations = append(annotations, debugValidationLatency, debugJiraAPICalls, debugCacheHit)
+	}
+	if cfg.MessageLocale != "" {
+		annotations = append(annotations, jiraErrorCode)
+	}
+	if cfg.ExplainAnnotation {
+		annotations = append(annotations, jiraExplanation)
+	}
+
+	return &Info{
+		ProtocolVersions:    ProtocolVersions,
+		Categories:          []string{cfg.category()},
+		JustificationFormat: format,
+		Annotations:         annotations,
+	}
+}
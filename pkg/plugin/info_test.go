@@ -0,0 +1,113 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewInfo(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		cfg  *PluginConfig
+		want *Info
+	}{
+		{
+			name: "default",
+			cfg:  &PluginConfig{},
+			want: &Info{
+				ProtocolVersions:    []int{1},
+				Categories:          []string{"jira"},
+				JustificationFormat: "key",
+				Annotations:         []string{"jira_issue_id", "jira_issue_url"},
+			},
+		},
+		{
+			name: "composite_with_fields",
+			cfg: &PluginConfig{
+				JustificationFormat: JustificationFormatComposite,
+				AnnotationFields:    []string{"summary"},
+			},
+			want: &Info{
+				ProtocolVersions:    []int{1},
+				Categories:          []string{"jira"},
+				JustificationFormat: "composite",
+				Annotations: []string{
+					"jira_issue_id",
+					"jira_issue_url",
+					"jira_related_issue_keys",
+					"jira_field_summary",
+				},
+			},
+		},
+		{
+			name: "json",
+			cfg:  &PluginConfig{JustificationFormat: JustificationFormatJSON},
+			want: &Info{
+				ProtocolVersions:    []int{1},
+				Categories:          []string{"jira"},
+				JustificationFormat: "json",
+				Annotations:         []string{"jira_issue_id", "jira_issue_url", "jira_justification_reason"},
+			},
+		},
+		{
+			name: "freeze",
+			cfg:  &PluginConfig{FreezeWindows: "0 18 * * FRI 62h", FreezeJql: "labels = emergency"},
+			want: &Info{
+				ProtocolVersions:    []int{1},
+				Categories:          []string{"jira"},
+				JustificationFormat: "key",
+				Annotations:         []string{"jira_issue_id", "jira_issue_url", "jira_freeze_window_end"},
+			},
+		},
+		{
+			name: "bypass",
+			cfg:  &PluginConfig{BypassRequestors: []string{"breakglass"}},
+			want: &Info{
+				ProtocolVersions:    []int{1},
+				Categories:          []string{"jira"},
+				JustificationFormat: "key",
+				Annotations:         []string{"jira_issue_id", "jira_issue_url", "jira_validation_bypassed", "jira_bypass_requestor"},
+			},
+		},
+		{
+			name: "stale_while_revalidate",
+			cfg:  &PluginConfig{CachePath: "/var/cache/decisions.db", CacheMaxStaleness: time.Minute},
+			want: &Info{
+				ProtocolVersions:    []int{1},
+				Categories:          []string{"jira"},
+				JustificationFormat: "key",
+				Annotations:         []string{"jira_issue_id", "jira_issue_url", "jira_cache_stale"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tc.want, NewInfo(tc.cfg)); diff != "" {
+				t.Errorf("NewInfo() unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
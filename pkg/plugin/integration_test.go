@@ -0,0 +1,230 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// integrationEnv holds the JIRA_TEST_* environment variables.
+type integrationEnv struct {
+	endpoint     string
+	account      string
+	apiToken     string
+	projectKey   string
+	otherProject string
+	issueType    string
+	issueBaseURL string
+}
+
+func loadIntegrationEnv(t *testing.T) *integrationEnv {
+	t.Helper()
+
+	env := &integrationEnv{
+		endpoint:     os.Getenv("JIRA_TEST_ENDPOINT"),
+		account:      os.Getenv("JIRA_TEST_ACCOUNT"),
+		apiToken:     os.Getenv("JIRA_TEST_API_TOKEN"),
+		projectKey:   os.Getenv("JIRA_TEST_PROJECT_KEY"),
+		otherProject: os.Getenv("JIRA_TEST_OTHER_PROJECT_KEY"),
+		issueType:    os.Getenv("JIRA_TEST_ISSUE_TYPE"),
+		issueBaseURL: os.Getenv("JIRA_TEST_ISSUE_BASE_URL"),
+	}
+	if env.endpoint == "" || env.account == "" || env.apiToken == "" || env.projectKey == "" {
+		t.Skip("JIRA_TEST_ENDPOINT, JIRA_TEST_ACCOUNT, JIRA_TEST_API_TOKEN and JIRA_TEST_PROJECT_KEY must be set")
+	}
+	if env.issueType == "" {
+		env.issueType = "Task"
+	}
+	if env.issueBaseURL == "" {
+		env.issueBaseURL = strings.SplitN(env.endpoint, "/rest/", 2)[0]
+	}
+	return env
+}
+
+// jiraCall sends an authenticated request to the Jira REST API and decodes
+// the response into respVal when it is not nil.
+func (e *integrationEnv) jiraCall(ctx context.Context, t *testing.T, method, pth string, body, respVal any) {
+	t.Helper()
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(e.endpoint, "/")+pth, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth(e.account, e.apiToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, pth, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("%s %s: got response code %d: %s", method, pth, resp.StatusCode, b)
+	}
+	if respVal != nil {
+		if err := json.NewDecoder(resp.Body).Decode(respVal); err != nil {
+			t.Fatalf("%s %s: failed to decode response: %v", method, pth, err)
+		}
+	}
+}
+
+// createIssue creates a temporary issue that is deleted when the test ends.
+func (e *integrationEnv) createIssue(ctx context.Context, t *testing.T) string {
+	t.Helper()
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	e.jiraCall(ctx, t, http.MethodPost, "/issue", map[string]any{
+		"fields": map[string]any{
+			"project":   map[string]string{"key": e.projectKey},
+			"summary":   fmt.Sprintf("jvs-plugin-jira integration test %s", time.Now().UTC().Format(time.RFC3339)),
+			"issuetype": map[string]string{"name": e.issueType},
+		},
+	}, &created)
+
+	t.Cleanup(func() {
+		e.jiraCall(context.Background(), t, http.MethodDelete, "/issue/"+created.Key, nil, nil)
+	})
+	return created.Key
+}
+
+// closeIssue transitions the issue into a status of the "done" category.
+func (e *integrationEnv) closeIssue(ctx context.Context, t *testing.T, key string) {
+	t.Helper()
+
+	var transitions struct {
+		Transitions []struct {
+			ID string `json:"id"`
+			To struct {
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	e.jiraCall(ctx, t, http.MethodGet, "/issue/"+key+"/transitions", nil, &transitions)
+
+	for _, tr := range transitions.Transitions {
+		if tr.To.StatusCategory.Key == "done" {
+			e.jiraCall(ctx, t, http.MethodPost, "/issue/"+key+"/transitions", map[string]any{
+				"transition": map[string]string{"id": tr.ID},
+			}, nil)
+			return
+		}
+	}
+	t.Skipf("no transition to a done status available for %s", key)
+}
+
+func (e *integrationEnv) validate(ctx context.Context, t *testing.T, jql, key string) *jvspb.ValidateJustificationResponse {
+	t.Helper()
+
+	p, err := NewJiraPluginWithToken(ctx, &PluginConfig{
+		JIRAEndpoint: e.endpoint,
+		Jql:          jql,
+		JIRAAccount:  e.account,
+		IssueBaseURL: e.issueBaseURL,
+	}, e.apiToken)
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: Category, Value: key},
+	})
+	if err != nil {
+		t.Fatalf("failed to validate %s: %v", key, err)
+	}
+	return resp
+}
+
+func TestIntegration_Validate(t *testing.T) {
+	t.Parallel()
+
+	env := loadIntegrationEnv(t)
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	openJQL := fmt.Sprintf("project = %q AND statusCategory != Done", env.projectKey)
+
+	t.Run("open_issue", func(t *testing.T) {
+		t.Parallel()
+
+		key := env.createIssue(ctx, t)
+		resp := env.validate(ctx, t, openJQL, key)
+		if !resp.GetValid() {
+			t.Errorf("expected %s to be valid, got errors %v", key, resp.GetError())
+		}
+		if got := resp.GetAnnotation()[jiraIssueURL]; !strings.HasSuffix(got, "/browse/"+key) {
+			t.Errorf("unexpected issue url %q", got)
+		}
+	})
+
+	t.Run("closed_issue", func(t *testing.T) {
+		t.Parallel()
+
+		key := env.createIssue(ctx, t)
+		env.closeIssue(ctx, t, key)
+		if resp := env.validate(ctx, t, openJQL, key); resp.GetValid() {
+			t.Errorf("expected closed issue %s to be invalid", key)
+		}
+	})
+
+	t.Run("wrong_project", func(t *testing.T) {
+		t.Parallel()
+
+		if env.otherProject == "" {
+			t.Skip("JIRA_TEST_OTHER_PROJECT_KEY is not set")
+		}
+		key := env.createIssue(ctx, t)
+		jql := fmt.Sprintf("project = %q", env.otherProject)
+		if resp := env.validate(ctx, t, jql, key); resp.GetValid() {
+			t.Errorf("expected %s to be invalid for project %s", key, env.otherProject)
+		}
+	})
+
+	t.Run("missing_issue", func(t *testing.T) {
+		t.Parallel()
+
+		key := env.projectKey + "-999999999"
+		if resp := env.validate(ctx, t, openJQL, key); resp.GetValid() {
+			t.Errorf("expected missing issue %s to be invalid", key)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// createdField is the Jira field holding the creation time of the issue.
+	createdField = "created"
+
+	// resolutionDateField is the Jira field holding the resolution time of
+	// the issue, empty while it is unresolved.
+	resolutionDateField = "resolutiondate"
+)
+
+// WithMaxIssueAge makes the validator reject issues created more than
+// created ago, or resolved more than resolved ago, so that ancient issues
+// cannot be recycled as justifications. A zero duration disables the
+// respective limit, unresolved issues always pass the resolution limit. The
+// times are compared with the site clock, see [WithSiteClock]. It only
+// applies to [Validator.MatchIssue], and cannot be used in search mode,
+// which does not fetch the issue.
+func WithMaxIssueAge(created, resolved time.Duration) ValidatorOption {
+	return func(v *Validator) error {
+		if v.searchJQLPrefix != "" {
+			return fmt.Errorf("maximum issue age cannot be used in search mode")
+		}
+		if created < 0 || resolved < 0 {
+			return fmt.Errorf("maximum issue age must be positive, got %s and %s", created, resolved)
+		}
+		if created > 0 {
+			v.issueChecks = append(v.issueChecks, &issueAgeCheck{name: createdField, maxAge: created, clock: v.clock})
+		}
+		if resolved > 0 {
+			v.issueChecks = append(v.issueChecks, &issueAgeCheck{name: resolutionDateField, maxAge: resolved, clock: v.clock})
+		}
+		v.issueFieldsQuery = v.fieldsQuery()
+		return nil
+	}
+}
+
+// issueAgeCheck rejects issues whose created or resolution time is too far
+// in the past.
+type issueAgeCheck struct {
+	name   string
+	maxAge time.Duration
+	clock  *jiraClock
+}
+
+func (c *issueAgeCheck) field() string {
+	return c.name
+}
+
+func (c *issueAgeCheck) reason() string {
+	return ErrorCodeIssueTooOld
+}
+
+func (c *issueAgeCheck) check(fields map[string]json.RawMessage) error {
+	clock := c.clock
+	if clock == nil {
+		clock = defaultJiraClock
+	}
+	value, ok := renderField(fields[c.name])
+	if !ok {
+		if c.name == resolutionDateField {
+			return nil
+		}
+		return fmt.Errorf("issue has no %s time, the maximum age is %s", c.name, formatAge(c.maxAge))
+	}
+	t, err := clock.parse(value)
+	if err != nil {
+		return fmt.Errorf("issue field %s: %w", c.name, err)
+	}
+	if !clock.after(t, clock.now().Add(-c.maxAge)) {
+		verb := "created"
+		if c.name == resolutionDateField {
+			verb = "resolved"
+		}
+		return fmt.Errorf("issue was %s at %s, more than %s ago", verb, t.UTC().Format(time.RFC3339), formatAge(c.maxAge))
+	}
+	return nil
+}
+
+// hasIssueAgeCheck reports whether the validator has a maximum issue age,
+// see [WithMaxIssueAge].
+func (v *Validator) hasIssueAgeCheck() bool {
+	for _, c := range v.issueChecks {
+		if _, ok := c.(*issueAgeCheck); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// formatAge formats a duration in days when it is a whole number of days,
+// e.g. "90d", and like [time.Duration.String] otherwise.
+func formatAge(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
@@ -0,0 +1,155 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestIssueAgeCheck(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	clock := &jiraClock{location: time.UTC, skew: time.Minute, now: func() time.Time { return now }}
+
+	cases := []struct {
+		name    string
+		field   string
+		maxAge  time.Duration
+		fields  map[string]json.RawMessage
+		wantErr string
+	}{
+		{
+			name:   "created_recently",
+			field:  createdField,
+			maxAge: 90 * 24 * time.Hour,
+			fields: map[string]json.RawMessage{"created": json.RawMessage(`"2026-09-01T10:00:00.000+0200"`)},
+		},
+		{
+			name:   "created_within_skew",
+			field:  createdField,
+			maxAge: 24 * time.Hour,
+			fields: map[string]json.RawMessage{"created": json.RawMessage(`"2026-10-15T11:59:30.000+0000"`)},
+		},
+		{
+			name:    "created_too_long_ago",
+			field:   createdField,
+			maxAge:  90 * 24 * time.Hour,
+			fields:  map[string]json.RawMessage{"created": json.RawMessage(`"2025-01-01T10:00:00.000+0200"`)},
+			wantErr: "issue was created at 2025-01-01T08:00:00Z, more than 90d ago",
+		},
+		{
+			name:    "created_missing",
+			field:   createdField,
+			maxAge:  36 * time.Hour,
+			fields:  map[string]json.RawMessage{},
+			wantErr: "issue has no created time, the maximum age is 36h0m0s",
+		},
+		{
+			name:    "created_invalid",
+			field:   createdField,
+			maxAge:  24 * time.Hour,
+			fields:  map[string]json.RawMessage{"created": json.RawMessage(`"yesterday"`)},
+			wantErr: `issue field created: invalid time "yesterday"`,
+		},
+		{
+			name:   "unresolved",
+			field:  resolutionDateField,
+			maxAge: 48 * time.Hour,
+			fields: map[string]json.RawMessage{"resolutiondate": json.RawMessage(`null`)},
+		},
+		{
+			name:   "resolved_recently",
+			field:  resolutionDateField,
+			maxAge: 48 * time.Hour,
+			fields: map[string]json.RawMessage{"resolutiondate": json.RawMessage(`"2026-10-15T09:00:00.000+0000"`)},
+		},
+		{
+			name:    "resolved_too_long_ago",
+			field:   resolutionDateField,
+			maxAge:  48 * time.Hour,
+			fields:  map[string]json.RawMessage{"resolutiondate": json.RawMessage(`"2026-10-14T11:00:00.000+0000"`)},
+			wantErr: "issue was resolved at 2026-10-14T11:00:00Z, more than 2d ago",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &issueAgeCheck{name: tc.field, maxAge: tc.maxAge, clock: clock}
+			if diff := testutil.DiffErrString(c.check(tc.fields), tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestWithMaxIssueAge(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name       string
+		opts       []ValidatorOption
+		wantFields string
+		wantErr    string
+	}{
+		{
+			name:       "created_and_resolved",
+			opts:       []ValidatorOption{WithMaxIssueAge(90*24*time.Hour, 48*time.Hour)},
+			wantFields: "fields=key%2Cid%2Ccreated%2Cresolutiondate",
+		},
+		{
+			name:       "created_only",
+			opts:       []ValidatorOption{WithMaxIssueAge(90*24*time.Hour, 0)},
+			wantFields: "fields=key%2Cid%2Ccreated",
+		},
+		{
+			name:    "negative",
+			opts:    []ValidatorOption{WithMaxIssueAge(-time.Hour, 0)},
+			wantErr: "maximum issue age must be positive, got -1h0m0s and 0s",
+		},
+		{
+			name:    "search_mode",
+			opts:    []ValidatorOption{WithSearchMode(), WithMaxIssueAge(time.Hour, 0)},
+			wantErr: "maximum issue age cannot be used in search mode",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = ABCD", "test@test.com", "token", tc.opts...)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			if got := v.issueFieldsQuery; got != tc.wantFields {
+				t.Errorf("got query %q, want %q", got, tc.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// issueTypeField is the Jira field holding the issue type.
+const issueTypeField = "issuetype"
+
+// issueTypeRoute is the JQL issues of a type are validated with, see
+// [PluginConfig.IssueTypeJql].
+type issueTypeRoute struct {
+	IssueType string
+	JQL       string
+}
+
+// parseIssueTypeJQL parses routes separated by semicolons, each an issue type
+// name, an equals sign and a JQL, e.g. "Change=status = Approved".
+func parseIssueTypeJQL(s string) ([]*issueTypeRoute, error) {
+	var routes []*issueTypeRoute
+	seen := make(map[string]struct{})
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		issueType, jql, ok := strings.Cut(part, "=")
+		issueType, jql = strings.TrimSpace(issueType), strings.TrimSpace(jql)
+		if !ok || issueType == "" || jql == "" {
+			return nil, fmt.Errorf("invalid issue type route %q, must be <issue type>=<jql>", part)
+		}
+		key := strings.ToLower(issueType)
+		if _, ok := seen[key]; ok {
+			return nil, fmt.Errorf("duplicate issue type %q", issueType)
+		}
+		seen[key] = struct{}{}
+		routes = append(routes, &issueTypeRoute{IssueType: issueType, JQL: jql})
+	}
+	return routes, nil
+}
+
+// formatIssueTypeJQL is the inverse of [parseIssueTypeJQL].
+func formatIssueTypeJQL(routes []*issueTypeRoute) string {
+	parts := make([]string, 0, len(routes))
+	for _, r := range routes {
+		parts = append(parts, r.IssueType+"="+r.JQL)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// WithIssueTypeJQL makes the validator match issues of the given types, by
+// name, against their own JQL instead of the configured one. The issue type
+// is fetched with the issue, names are compared case-insensitively, and
+// issues of other types are matched against the configured JQL. It only
+// applies to [Validator.MatchIssue], and cannot be used in search mode,
+// which does not fetch the issue.
+func WithIssueTypeJQL(jqls map[string]string) ValidatorOption {
+	return func(v *Validator) error {
+		if v.searchJQLPrefix != "" {
+			return fmt.Errorf("issue type routing cannot be used in search mode")
+		}
+		routed := make(map[string]string, len(jqls))
+		for issueType, jql := range jqls {
+			if issueType == "" || jql == "" {
+				return fmt.Errorf("invalid issue type route %q=%q", issueType, jql)
+			}
+			routed[strings.ToLower(issueType)] = jql
+		}
+		v.issueTypeJQL = routed
+		v.issueFieldsQuery = v.fieldsQuery()
+		return nil
+	}
+}
+
+// jqlFor returns the JQL the issue is matched against.
+func (v *Validator) jqlFor(issue *jiraIssue) string {
+	if len(v.issueTypeJQL) == 0 {
+		return v.jql
+	}
+	var issueType struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(issue.Fields[issueTypeField], &issueType); err != nil {
+		return v.jql
+	}
+	if jql, ok := v.issueTypeJQL[strings.ToLower(issueType.Name)]; ok {
+		return jql
+	}
+	return v.jql
+}
+
+// routedJQLs returns the issue type JQLs sorted by issue type.
+func (v *Validator) routedJQLs() []string {
+	types := make([]string, 0, len(v.issueTypeJQL))
+	for t := range v.issueTypeJQL {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	jqls := make([]string, 0, len(types))
+	for _, t := range types {
+		jqls = append(jqls, v.issueTypeJQL[t])
+	}
+	return jqls
+}
+
+// issueTypeJQLs returns the routes of the config as a map for
+// [WithIssueTypeJQL]. The config must be valid.
+func (cfg *PluginConfig) issueTypeJQLs() map[string]string {
+	routes, _ := parseIssueTypeJQL(cfg.IssueTypeJql) //nolint:errcheck // Checked by Validate
+	jqls := make(map[string]string, len(routes))
+	for _, r := range routes {
+		jqls[r.IssueType] = r.JQL
+	}
+	return jqls
+}
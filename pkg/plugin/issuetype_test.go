@@ -0,0 +1,176 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestParseIssueTypeJQL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		in      string
+		want    []*issueTypeRoute
+		wantErr string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "routes",
+			in:   "Change=status = Approved; Incident = statusCategory != Done;",
+			want: []*issueTypeRoute{
+				{IssueType: "Change", JQL: "status = Approved"},
+				{IssueType: "Incident", JQL: "statusCategory != Done"},
+			},
+		},
+		{
+			name:    "missing_jql",
+			in:      "Change=",
+			wantErr: `invalid issue type route "Change="`,
+		},
+		{
+			name:    "missing_equals",
+			in:      "Change",
+			wantErr: `invalid issue type route "Change"`,
+		},
+		{
+			name:    "duplicate",
+			in:      "Change=status = Approved; change=status = Done",
+			wantErr: `duplicate issue type "change"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseIssueTypeJQL(tc.in)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("routes (-want, +got):\n%s", diff)
+			}
+			if err == nil && len(got) > 0 {
+				if again, _ := parseIssueTypeJQL(formatIssueTypeJQL(got)); !cmp.Equal(got, again) {
+					t.Errorf("format does not round trip, got %v", again)
+				}
+			}
+		})
+	}
+}
+
+func TestValidation_IssueTypeJQL(t *testing.T) {
+	t.Parallel()
+
+	issueTypes := map[string]string{
+		"CHG-1": "Change",
+		"INC-1": "incident",
+		"BUG-1": "Bug",
+	}
+
+	cases := []struct {
+		name    string
+		key     string
+		wantJQL string
+	}{
+		{
+			name:    "routed",
+			key:     "CHG-1",
+			wantJQL: "status = Approved",
+		},
+		{
+			name:    "case_insensitive",
+			key:     "INC-1",
+			wantJQL: "statusCategory != Done",
+		},
+		{
+			name:    "default",
+			key:     "BUG-1",
+			wantJQL: "project = ABCD",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotFields string
+			var gotJQLs []string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+				gotFields = r.URL.Query().Get("fields")
+				key := strings.TrimPrefix(r.URL.Path, "/issue/")
+				fmt.Fprintf(w, `{"id":"1","key":%q,"fields":{"issuetype":{"name":%q}}}`, key, issueTypes[key])
+			})
+			mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+				var data matchData
+				if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+					t.Errorf("failed to decode match request: %v", err)
+				}
+				gotJQLs = data.Jqls
+				fmt.Fprint(w, `{"matches":[{"matchedIssues":[1],"errors":[]}]}`)
+			})
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			v, err := NewValidator(srv.URL, "project = ABCD", "test@test.com", "token",
+				WithIssueTypeJQL(map[string]string{
+					"Change":   "status = Approved",
+					"Incident": "statusCategory != Done",
+				}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := v.MatchIssue(context.Background(), tc.key); err != nil {
+				t.Fatalf("MatchIssue() unexpected err: %v", err)
+			}
+			if got, want := gotFields, "key,id,issuetype"; got != want {
+				t.Errorf("got fields %q, want %q", got, want)
+			}
+			if diff := cmp.Diff([]string{tc.wantJQL}, gotJQLs); diff != "" {
+				t.Errorf("match jqls (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithIssueTypeJQL_SearchMode(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = ABCD", "test@test.com", "token",
+		WithSearchMode(), WithIssueTypeJQL(map[string]string{"Change": "status = Approved"}))
+	if diff := testutil.DiffErrString(err, "issue type routing cannot be used in search mode"); diff != "" {
+		t.Errorf(diff)
+	}
+}
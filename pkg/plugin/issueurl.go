@@ -0,0 +1,127 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// issueURLData is the data of the issue URL template, see
+// [PluginConfig.IssueURLTemplate].
+type issueURLData struct {
+	// BaseURL is the issue base URL without trailing slash.
+	BaseURL string
+
+	// Key is the issue key, e.g. ABCD-123.
+	Key string
+
+	// ID is the issue id, e.g. 10001.
+	ID string
+}
+
+// parseIssueURLTemplate parses the issue URL template, it returns nil when
+// the template is empty. The template is executed once with sample data, so
+// references to unknown fields are reported early.
+func parseIssueURLTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("issue-url").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse issue url template: %w", err)
+	}
+	if _, err := executeIssueURL(tmpl, "https://example.atlassian.net", "ABCD-1", "1"); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// buildIssueURL returns the URL of the issue for the jira_issue_url
+// annotation. It is "<baseURL>/browse/<key>" when tmpl is nil.
+func buildIssueURL(tmpl *template.Template, baseURL, key, id string) (string, error) {
+	if tmpl == nil {
+		issueURL, err := url.JoinPath(baseURL, "browse", key)
+		if err != nil {
+			return "", fmt.Errorf("failed to build issue url: %w", err)
+		}
+		return issueURL, nil
+	}
+	return executeIssueURL(tmpl, baseURL, key, id)
+}
+
+// executeIssueURL executes the issue URL template.
+func executeIssueURL(tmpl *template.Template, baseURL, key, id string) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, &issueURLData{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Key:     key,
+		ID:      id,
+	}); err != nil {
+		return "", fmt.Errorf("failed to execute issue url template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// parseProjectBaseURLs parses the base URLs of the issues of projects hosted
+// on other Jira sites, each "<project key>=<base URL>", see
+// [PluginConfig.ProjectBaseURLs]. The map is keyed by the upper case project
+// key, it is nil when there are none.
+func parseProjectBaseURLs(rules []string) (map[string]string, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	projects := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		project, baseURL, ok := strings.Cut(rule, "=")
+		project, baseURL = strings.ToUpper(strings.TrimSpace(project)), strings.TrimSpace(baseURL)
+		if !ok || !projectKeyPattern.MatchString(project) || baseURL == "" {
+			return nil, fmt.Errorf("invalid project base url %q, must be <project key>=<base url>", rule)
+		}
+		if u, err := url.Parse(baseURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid base url %q of project %s, must be an http or https url", baseURL, project)
+		}
+		if _, ok := projects[project]; ok {
+			return nil, fmt.Errorf("duplicate base url of project %s", project)
+		}
+		projects[project] = baseURL
+	}
+	return projects, nil
+}
+
+// issueBaseURL returns the base URL of the issue: the one of its project in
+// projects if any, baseURL otherwise.
+func issueBaseURL(projects map[string]string, baseURL, key string) string {
+	if u, ok := projects[strings.ToUpper(issueProject(key))]; ok {
+		return u
+	}
+	return baseURL
+}
+
+// IssueURL returns the URL of the issue as in the jira_issue_url
+// annotation.
+func (cfg *PluginConfig) IssueURL(key, id string) (string, error) {
+	tmpl, err := parseIssueURLTemplate(cfg.IssueURLTemplate)
+	if err != nil {
+		return "", err
+	}
+	projects, err := parseProjectBaseURLs(cfg.ProjectBaseURLs)
+	if err != nil {
+		return "", err
+	}
+	return buildIssueURL(tmpl, issueBaseURL(projects, cfg.IssueBaseURL, key), key, id)
+}
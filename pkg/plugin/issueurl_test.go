@@ -0,0 +1,147 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestPluginConfig_IssueURL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		baseURL  string
+		template string
+		projects []string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:    "default",
+			baseURL: "https://example.atlassian.net",
+			want:    "https://example.atlassian.net/browse/ABCD-1",
+		},
+		{
+			name:     "query",
+			baseURL:  "https://example.atlassian.net/",
+			template: "{{.BaseURL}}/browse/{{.Key}}?src=jvs",
+			want:     "https://example.atlassian.net/browse/ABCD-1?src=jvs",
+		},
+		{
+			name:     "portal_link_by_id",
+			baseURL:  "https://example.atlassian.net",
+			template: "{{.BaseURL}}/servicedesk/customer/portal/2/{{.Key}}?id={{.ID}}",
+			want:     "https://example.atlassian.net/servicedesk/customer/portal/2/ABCD-1?id=1234",
+		},
+		{
+			name:     "unknown_field",
+			baseURL:  "https://example.atlassian.net",
+			template: "{{.BaseURL}}/browse/{{.Summary}}",
+			wantErr:  "failed to execute issue url template",
+		},
+		{
+			name:     "malformed",
+			baseURL:  "https://example.atlassian.net",
+			template: "{{.BaseURL",
+			wantErr:  "failed to parse issue url template",
+		},
+		{
+			name:     "project_base_url",
+			baseURL:  "https://example.atlassian.net",
+			projects: []string{"OPS=https://ops.atlassian.net", "abcd = https://abcd.example.com/jira/"},
+			want:     "https://abcd.example.com/jira/browse/ABCD-1",
+		},
+		{
+			name:     "project_base_url_template",
+			baseURL:  "https://example.atlassian.net",
+			template: "{{.BaseURL}}/browse/{{.Key}}?src=jvs",
+			projects: []string{"ABCD=https://abcd.example.com/jira/"},
+			want:     "https://abcd.example.com/jira/browse/ABCD-1?src=jvs",
+		},
+		{
+			name:     "other_project_base_url",
+			baseURL:  "https://example.atlassian.net",
+			projects: []string{"OPS=https://ops.atlassian.net"},
+			want:     "https://example.atlassian.net/browse/ABCD-1",
+		},
+		{
+			name:     "invalid_project_base_url",
+			baseURL:  "https://example.atlassian.net",
+			projects: []string{"ABCD=ftp://abcd.example.com"},
+			wantErr:  `invalid base url "ftp://abcd.example.com" of project ABCD, must be an http or https url`,
+		},
+		{
+			name:     "missing_project",
+			baseURL:  "https://example.atlassian.net",
+			projects: []string{"https://abcd.example.com"},
+			wantErr:  `invalid project base url "https://abcd.example.com", must be <project key>=<base url>`,
+		},
+		{
+			name:     "duplicate_project",
+			baseURL:  "https://example.atlassian.net",
+			projects: []string{"ABCD=https://abcd.example.com", "abcd=https://example.com"},
+			wantErr:  "duplicate base url of project ABCD",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &PluginConfig{IssueBaseURL: tc.baseURL, IssueURLTemplate: tc.template, ProjectBaseURLs: tc.projects}
+			got, err := cfg.IssueURL("ABCD-1", "1234")
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if got != tc.want {
+				t.Errorf("IssueURL() got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProjectBaseURLs(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.ProjectBaseURLs = []string{"OPS=https://ops.atlassian.net"}
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	for key, want := range map[string]string{
+		"OPS-1":  "https://ops.atlassian.net/browse/OPS-1",
+		"ABCD-1": "https://example.atlassian.net/browse/ABCD-1",
+	} {
+		resp, err := p.ValidateValue(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.GetAnnotation()[jiraIssueURL]; got != want {
+			t.Errorf("issue url of %s got %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxClockSkewTolerance bounds [PluginConfig.ClockSkew], a larger skew
+	// points at a broken clock rather than at drift.
+	maxClockSkewTolerance = time.Hour
+
+	// relativeTimeNow is the reference time of relative times, see
+	// [parseTimeReference].
+	relativeTimeNow = "now"
+)
+
+// jiraTimeLayouts are the layouts of the time values found in Jira fields,
+// in order. Layouts without a zone are in the site time zone.
+var jiraTimeLayouts = []struct {
+	layout string
+	zoned  bool
+}{
+	{jiraDateTimeLayout, true},
+	{time.RFC3339Nano, true},
+	{"2006-01-02T15:04:05-0700", true},
+	{"2006-01-02T15:04:05.000", false},
+	{"2006-01-02T15:04:05", false},
+	{"2006-01-02 15:04", false},
+	{jiraDateLayout, false},
+}
+
+// jiraClock reads the time values of Jira fields and compares them with the
+// plugin clock. Values without a zone, e.g. date fields, are in the time zone
+// of the Jira site. Comparisons with the plugin clock tolerate skew between
+// the clocks of Jira and the plugin, in favor of the issue.
+type jiraClock struct {
+	location *time.Location
+	skew     time.Duration
+	now      func() time.Time
+}
+
+// defaultJiraClock is a clock in UTC without skew tolerance.
+var defaultJiraClock = &jiraClock{location: time.UTC, now: time.Now}
+
+// newJiraClock creates a clock for the IANA time zone of the site, UTC when
+// empty, tolerating skew.
+func newJiraClock(timezone string, skew time.Duration) (*jiraClock, error) {
+	loc, err := loadTimezone(timezone)
+	if err != nil {
+		return nil, err
+	}
+	if skew < 0 || skew > maxClockSkewTolerance {
+		return nil, fmt.Errorf("invalid clock skew %s, must be between 0 and %s", skew, maxClockSkewTolerance)
+	}
+	return &jiraClock{location: loc, skew: skew, now: time.Now}, nil
+}
+
+// WithSiteClock sets the IANA time zone of the Jira site, UTC when empty,
+// and the clock skew tolerated by the time-based checks, e.g. the before and
+// after operators of [WithFieldConstraints]. Time values without a zone, like
+// date fields, are read in the site time zone. A check comparing a field with
+// the current time accepts the issue when it passes with the plugin clock
+// off by up to skew in either direction.
+func WithSiteClock(timezone string, skew time.Duration) ValidatorOption {
+	return func(v *Validator) error {
+		c, err := newJiraClock(timezone, skew)
+		if err != nil {
+			return err
+		}
+		// The checks already parsed hold the clock.
+		*v.clock = *c
+		return nil
+	}
+}
+
+// loadTimezone loads the IANA time zone, UTC when empty.
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load time zone: %w", err)
+	}
+	return loc, nil
+}
+
+// parseJiraTime parses a time value of a Jira field. Values without a zone
+// are in loc, a date is midnight in loc.
+func parseJiraTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, l := range jiraTimeLayouts {
+		var t time.Time
+		var err error
+		if l.zoned {
+			t, err = time.Parse(l.layout, s)
+		} else {
+			t, err = time.ParseInLocation(l.layout, s, loc)
+		}
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, must be a jira date or date-time", s)
+}
+
+// parse parses a time value of a Jira field in the site time zone.
+func (c *jiraClock) parse(s string) (time.Time, error) {
+	return parseJiraTime(s, c.location)
+}
+
+// before reports whether t is before ref, or within the skew after it.
+func (c *jiraClock) before(t, ref time.Time) bool {
+	return t.Before(ref.Add(c.skew))
+}
+
+// after reports whether t is after ref, or within the skew before it.
+func (c *jiraClock) after(t, ref time.Time) bool {
+	return t.After(ref.Add(-c.skew))
+}
+
+// timeReference is a point in time a field is compared with: a fixed time,
+// or an offset from the time of the comparison.
+type timeReference struct {
+	// fixed is the fixed time, it is read in the site time zone when it has
+	// none.
+	fixed    string
+	relative bool
+	offset   time.Duration
+}
+
+// parseTimeReference parses "now", "now-7d", "now+2h" or a time value like
+// [parseJiraTime].
+func parseTimeReference(s string) (*timeReference, error) {
+	s = strings.TrimSpace(s)
+	rest, ok := strings.CutPrefix(strings.ToLower(s), relativeTimeNow)
+	if !ok {
+		if _, err := parseJiraTime(s, time.UTC); err != nil {
+			return nil, err
+		}
+		return &timeReference{fixed: s}, nil
+	}
+	if rest == "" {
+		return &timeReference{relative: true}, nil
+	}
+	sign := time.Duration(1)
+	switch rest[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		return nil, fmt.Errorf("invalid time %q, must be now, now+<duration> or now-<duration>", s)
+	}
+	d, err := parseDays(rest[1:])
+	if err != nil || d <= 0 {
+		return nil, fmt.Errorf("invalid time %q, must be now, now+<duration> or now-<duration>", s)
+	}
+	return &timeReference{relative: true, offset: sign * d}, nil
+}
+
+// parseDays parses a duration like [time.ParseDuration], or a number of
+// days like "7d".
+func parseDays(s string) (time.Duration, error) {
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// at returns the time of the reference for a comparison now with the clock.
+func (r *timeReference) at(c *jiraClock) time.Time {
+	if r.relative {
+		return c.now().Add(r.offset)
+	}
+	t, _ := c.parse(r.fixed) //nolint:errcheck // Checked by parseTimeReference
+	return t
+}
@@ -0,0 +1,269 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestParseJiraTime(t *testing.T) {
+	t.Parallel()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string
+	}{
+		{name: "jira_date_time", in: "2026-10-16T09:30:00.000+0200", want: "2026-10-16T07:30:00Z"},
+		{name: "rfc3339", in: "2026-10-16T09:30:00Z", want: "2026-10-16T09:30:00Z"},
+		{name: "rfc3339_nano", in: "2026-10-16T09:30:00.123456789-04:00", want: "2026-10-16T13:30:00.123456789Z"},
+		{name: "numeric_zone", in: "2026-10-16T09:30:00+0530", want: "2026-10-16T04:00:00Z"},
+		{name: "local_millis", in: "2026-10-16T09:30:00.000", want: "2026-10-16T07:30:00Z"},
+		{name: "local", in: "2026-10-16T09:30:00", want: "2026-10-16T07:30:00Z"},
+		{name: "local_minutes", in: "2026-10-16 09:30", want: "2026-10-16T07:30:00Z"},
+		{name: "date_summer", in: "2026-10-16", want: "2026-10-15T22:00:00Z"},
+		{name: "date_winter", in: "2026-12-16", want: "2026-12-15T23:00:00Z"},
+		{name: "date_dst_change", in: "2026-10-25T12:00:00", want: "2026-10-25T11:00:00Z"},
+		{name: "spaces", in: " 2026-10-16 ", want: "2026-10-15T22:00:00Z"},
+		{
+			name:    "empty",
+			in:      "",
+			wantErr: `invalid time "", must be a jira date or date-time`,
+		},
+		{
+			name:    "not_a_time",
+			in:      "next week",
+			wantErr: `invalid time "next week"`,
+		},
+		{
+			name:    "invalid_date",
+			in:      "2026-02-30",
+			wantErr: `invalid time "2026-02-30"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseJiraTime(tc.in, berlin)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if err != nil {
+				return
+			}
+			if got := got.UTC().Format(time.RFC3339Nano); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewJiraClock(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		timezone string
+		skew     time.Duration
+		wantErr  string
+	}{
+		{name: "default"},
+		{name: "site", timezone: "America/New_York", skew: 5 * time.Minute},
+		{name: "max_skew", skew: time.Hour},
+		{
+			name:     "invalid_timezone",
+			timezone: "Mars/Olympus",
+			wantErr:  "failed to load time zone",
+		},
+		{
+			name:    "negative_skew",
+			skew:    -time.Second,
+			wantErr: "invalid clock skew -1s, must be between 0 and 1h0m0s",
+		},
+		{
+			name:    "skew_too_large",
+			skew:    2 * time.Hour,
+			wantErr: "invalid clock skew 2h0m0s",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newJiraClock(tc.timezone, tc.skew)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestJiraClock_Compare(t *testing.T) {
+	t.Parallel()
+
+	ref := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	c := &jiraClock{location: time.UTC, skew: time.Minute}
+
+	cases := []struct {
+		name       string
+		t          time.Time
+		wantBefore bool
+		wantAfter  bool
+	}{
+		{name: "well_before", t: ref.Add(-time.Hour), wantBefore: true},
+		{name: "before_within_skew", t: ref.Add(-time.Minute + time.Second), wantBefore: true, wantAfter: true},
+		{name: "skew_before", t: ref.Add(-time.Minute), wantBefore: true},
+		{name: "equal", t: ref, wantBefore: true, wantAfter: true},
+		{name: "after_within_skew", t: ref.Add(time.Minute - time.Second), wantBefore: true, wantAfter: true},
+		{name: "skew_after", t: ref.Add(time.Minute), wantAfter: true},
+		{name: "well_after", t: ref.Add(time.Hour), wantAfter: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := c.before(tc.t, ref); got != tc.wantBefore {
+				t.Errorf("before got %t, want %t", got, tc.wantBefore)
+			}
+			if got := c.after(tc.t, ref); got != tc.wantAfter {
+				t.Errorf("after got %t, want %t", got, tc.wantAfter)
+			}
+		})
+	}
+}
+
+func TestParseTimeReference(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &jiraClock{location: tokyo, now: func() time.Time { return now }}
+
+	cases := []struct {
+		name    string
+		in      string
+		want    time.Time
+		wantErr string
+	}{
+		{name: "now", in: "now", want: now},
+		{name: "now_upper", in: "NOW", want: now},
+		{name: "now_minus_days", in: "now-7d", want: now.Add(-7 * 24 * time.Hour)},
+		{name: "now_plus_hours", in: "now+2h", want: now.Add(2 * time.Hour)},
+		{name: "now_minus_minutes", in: "now-90m", want: now.Add(-90 * time.Minute)},
+		{name: "date_in_site_zone", in: "2026-10-16", want: time.Date(2026, 10, 15, 15, 0, 0, 0, time.UTC)},
+		{name: "zoned", in: "2026-10-16T12:00:00Z", want: now},
+		{
+			name:    "missing_sign",
+			in:      "now7d",
+			wantErr: `invalid time "now7d", must be now, now+<duration> or now-<duration>`,
+		},
+		{
+			name:    "missing_duration",
+			in:      "now-",
+			wantErr: "must be now, now+<duration> or now-<duration>",
+		},
+		{
+			name:    "zero_duration",
+			in:      "now+0s",
+			wantErr: "must be now, now+<duration> or now-<duration>",
+		},
+		{
+			name:    "invalid_days",
+			in:      "now-xd",
+			wantErr: "must be now, now+<duration> or now-<duration>",
+		},
+		{
+			name:    "not_a_time",
+			in:      "yesterday",
+			wantErr: `invalid time "yesterday"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := parseTimeReference(tc.in)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if err != nil {
+				return
+			}
+			if got := r.at(c); !got.Equal(tc.want) {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWithSiteClock(t *testing.T) {
+	t.Parallel()
+
+	// The site clock applies to the constraints whatever the order of the
+	// options.
+	v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = ABCD", "test@test.com", "token",
+		WithFieldConstraints([]string{"duedate after 2026-10-16T08:00:00"}),
+		WithSiteClock("America/Los_Angeles", time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := v.issueChecks[0].(*fieldConstraint)
+	if !ok {
+		t.Fatalf("got check %T, want *fieldConstraint", v.issueChecks[0])
+	}
+
+	// 2026-10-16T08:00:00 in Los Angeles is 15:00 UTC, the skew accepts a
+	// field up to a minute earlier.
+	fields := map[string]json.RawMessage{"duedate": json.RawMessage(`"2026-10-16T14:59:30Z"`)}
+	if err := c.check(fields); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	fields["duedate"] = json.RawMessage(`"2026-10-16T14:58:00Z"`)
+	wantErr := "issue field duedate is 2026-10-16T14:58:00Z, must be after 2026-10-16T08:00:00"
+	if diff := testutil.DiffErrString(c.check(fields), wantErr); diff != "" {
+		t.Errorf(diff)
+	}
+
+	if _, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = ABCD", "test@test.com", "token",
+		WithSiteClock("", 2*time.Hour)); err == nil {
+		t.Error("expected error for a skew above the maximum")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// lifecycle tracks the validations in flight, so closing the plugin waits
+// for them before releasing the clients they use.
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+
+	// count is the number of operations in flight, for diagnostics.
+	count atomic.Int64
+}
+
+// acquire registers a validation, it returns false once the plugin is
+// closing. Every successful acquire must be followed by a release.
+func (l *lifecycle) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return false
+	}
+	l.inflight.Add(1)
+	l.count.Add(1)
+	return true
+}
+
+// release unregisters a validation.
+func (l *lifecycle) release() {
+	l.count.Add(-1)
+	l.inflight.Done()
+}
+
+// shutdown stops accepting validations and waits for those in flight until
+// ctx is done. It returns false when the plugin was already closing.
+func (l *lifecycle) shutdown(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	first := !l.closed
+	l.closed = true
+	l.mu.Unlock()
+
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		l.inflight.Wait()
+	}()
+
+	select {
+	case <-doneCh:
+		return first, nil
+	case <-ctx.Done():
+		return first, fmt.Errorf("gave up waiting for validations in flight: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// blockingValidator matches once release is closed.
+type blockingValidator struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingValidator) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
+	close(b.started)
+	<-b.release
+	return &MatchResult{Matches: []*Match{{MatchedIssues: []int{1}, Errors: []string{}}}}, nil
+}
+
+func TestPlugin_Close(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	v := &blockingValidator{started: make(chan struct{}), release: make(chan struct{})}
+	p := newTestPlugin(&snapshot{validator: v, issueBaseURL: "https://example.atlassian.net"})
+
+	respCh := make(chan *jvspb.ValidateJustificationResponse, 1)
+	go func() {
+		resp, err := p.ValidateValue(ctx, "ABCD-1")
+		if err != nil {
+			t.Errorf("in flight validation failed: %v", err)
+		}
+		respCh <- resp
+	}()
+	<-v.started
+
+	// The validation in flight outlives the first deadline.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := p.Close(timeoutCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() got err %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// New validations are rejected once closing.
+	if _, err := p.ValidateValue(ctx, "ABCD-1"); !errors.Is(err, ErrClosed) {
+		t.Errorf("ValidateValue() got err %v, want %v", err, ErrClosed)
+	}
+	_, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	})
+	if got, want := status.Code(err), codes.Unavailable; got != want {
+		t.Errorf("Validate() got code %s, want %s", got, want)
+	}
+
+	close(v.release)
+	if resp := <-respCh; !resp.GetValid() {
+		t.Errorf("in flight validation got %v, want valid", resp)
+	}
+
+	// Closing again is a no-op.
+	if err := p.Close(ctx); err != nil {
+		t.Errorf("Close() unexpected err: %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// issueLinksField is the Jira field holding the links of an issue.
+const issueLinksField = "issuelinks"
+
+// Link rule actions, see [WithLinkRules].
+const (
+	linkRuleReject  = "reject"
+	linkRuleRequire = "require"
+)
+
+// Link rule conditions on the linked issue, see [WithLinkRules].
+const (
+	linkConditionResolved   = "resolved"
+	linkConditionUnresolved = "unresolved"
+	linkConditionIssueType  = "issuetype"
+	linkConditionStatus     = "status"
+)
+
+// doneStatusCategory is the key of the status category of resolved issues.
+const doneStatusCategory = "done"
+
+// issueLink is an element of the issuelinks field. Exactly one of
+// InwardIssue and OutwardIssue is set.
+type issueLink struct {
+	Type struct {
+		Name    string `json:"name"`
+		Inward  string `json:"inward"`
+		Outward string `json:"outward"`
+	} `json:"type"`
+	InwardIssue  *linkedIssue `json:"inwardIssue"`
+	OutwardIssue *linkedIssue `json:"outwardIssue"`
+}
+
+// linkedIssue is the summary of a linked issue jira includes in the
+// issuelinks field.
+type linkedIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Status *struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+		IssueType *struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
+	} `json:"fields"`
+}
+
+// describe returns how the issue relates to the linked issue, e.g. "is
+// blocked by", and the linked issue.
+func (l *issueLink) describe() (string, *linkedIssue) {
+	if l.InwardIssue != nil {
+		return l.Type.Inward, l.InwardIssue
+	}
+	return l.Type.Outward, l.OutwardIssue
+}
+
+// linkRule is a check of the links of an issue, e.g. reject "is blocked by"
+// unresolved.
+type linkRule struct {
+	rule   string
+	action string
+	link   string
+
+	// state is linkConditionResolved, linkConditionUnresolved or empty.
+	state     string
+	issueType string
+	status    string
+}
+
+// WithLinkRules makes the validator check the links of the issue, e.g. to
+// reject issues blocked by unresolved issues or to require an approved
+// security review. A rule is an action, a link and conditions on the linked
+// issue:
+//
+//	reject "is blocked by" unresolved
+//	require "is reviewed by" issuetype "Security Review" status Approved
+//
+// reject fails the issue when any link satisfies the rule, require when
+// none does. The link is the description of the link as Jira shows it on the
+// issue, e.g. "blocks" or "is blocked by", or the name of the link type, e.g.
+// "Blocks", which matches both directions. The conditions are resolved or
+// unresolved, by the status category of the linked issue, and issuetype and
+// status followed by a name. Words with spaces are double quoted, and all
+// comparisons ignore case. It only applies to [Validator.MatchIssue], and
+// cannot be used in search mode, which does not fetch the issue.
+//
+// A change of a linked issue alone does not change the cited issue, so
+// cached matches are not revalidated because of it.
+func WithLinkRules(rules []string) ValidatorOption {
+	return func(v *Validator) error {
+		if v.searchJQLPrefix != "" {
+			return fmt.Errorf("link rules cannot be used in search mode")
+		}
+		for _, s := range rules {
+			r, err := parseLinkRule(s)
+			if err != nil {
+				return err
+			}
+			v.issueChecks = append(v.issueChecks, r)
+		}
+		v.issueFieldsQuery = v.fieldsQuery()
+		return nil
+	}
+}
+
+// parseLinkRule parses a rule, see [WithLinkRules].
+func parseLinkRule(s string) (*linkRule, error) {
+	words, err := splitQuotedWords(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid link rule %q: %w", s, err)
+	}
+	if len(words) < 2 {
+		return nil, fmt.Errorf("invalid link rule %q, must be <action> <link> [<condition>...]", s)
+	}
+
+	r := &linkRule{rule: strings.TrimSpace(s), action: strings.ToLower(words[0]), link: words[1]}
+	if r.action != linkRuleReject && r.action != linkRuleRequire {
+		return nil, fmt.Errorf("invalid link rule %q, unknown action %q, must be one of reject, require", s, words[0])
+	}
+	for rest := words[2:]; len(rest) > 0; {
+		cond := strings.ToLower(rest[0])
+		switch cond {
+		case linkConditionResolved, linkConditionUnresolved:
+			if r.state != "" {
+				return nil, fmt.Errorf("invalid link rule %q, more than one of resolved, unresolved", s)
+			}
+			r.state = cond
+			rest = rest[1:]
+		case linkConditionIssueType, linkConditionStatus:
+			if len(rest) < 2 {
+				return nil, fmt.Errorf("invalid link rule %q, %s needs a name", s, cond)
+			}
+			if cond == linkConditionIssueType {
+				r.issueType = rest[1]
+			} else {
+				r.status = rest[1]
+			}
+			rest = rest[2:]
+		default:
+			return nil, fmt.Errorf("invalid link rule %q, unknown condition %q, must be one of resolved, unresolved, issuetype, status", s, rest[0])
+		}
+	}
+	return r, nil
+}
+
+// splitQuotedWords splits s at spaces, except within double quotes, and
+// removes the quotes.
+func splitQuotedWords(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, quoted := false, false
+	for _, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			inWord = true
+		case c == ' ' && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func (r *linkRule) field() string {
+	return issueLinksField
+}
+
+func (r *linkRule) reason() string {
+	return ErrorCodeLinkRule
+}
+
+func (r *linkRule) check(fields map[string]json.RawMessage) error {
+	var links []*issueLink
+	if raw := fields[issueLinksField]; len(raw) > 0 {
+		if err := json.Unmarshal(raw, &links); err != nil {
+			return fmt.Errorf("invalid issue links: %w", err)
+		}
+	}
+
+	var matched []string
+	for _, l := range links {
+		if desc, issue := l.describe(); issue != nil && r.matches(l.Type.Name, desc, issue) {
+			matched = append(matched, issue.Key)
+		}
+	}
+
+	switch r.action {
+	case linkRuleReject:
+		if len(matched) > 0 {
+			return fmt.Errorf("issue has links to %s rejected by link rule %q", strings.Join(matched, ", "), r.rule)
+		}
+	case linkRuleRequire:
+		if len(matched) == 0 {
+			return fmt.Errorf("issue has no link required by link rule %q", r.rule)
+		}
+	}
+	return nil
+}
+
+// matches reports whether a link of the type and description to the issue
+// satisfies the rule.
+func (r *linkRule) matches(typeName, desc string, issue *linkedIssue) bool {
+	if !strings.EqualFold(desc, r.link) && !strings.EqualFold(typeName, r.link) {
+		return false
+	}
+	status := issue.Fields.Status
+	switch r.state {
+	case linkConditionResolved:
+		if status == nil || status.StatusCategory.Key != doneStatusCategory {
+			return false
+		}
+	case linkConditionUnresolved:
+		if status != nil && status.StatusCategory.Key == doneStatusCategory {
+			return false
+		}
+	}
+	if r.status != "" && (status == nil || !strings.EqualFold(status.Name, r.status)) {
+		return false
+	}
+	if r.issueType != "" {
+		if t := issue.Fields.IssueType; t == nil || !strings.EqualFold(t.Name, r.issueType) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestParseLinkRule(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		in      string
+		want    *linkRule
+		wantErr string
+	}{
+		{
+			name: "reject_unresolved",
+			in:   `reject "is blocked by" unresolved`,
+			want: &linkRule{rule: `reject "is blocked by" unresolved`, action: linkRuleReject, link: "is blocked by", state: linkConditionUnresolved},
+		},
+		{
+			name: "require_type_and_status",
+			in:   `Require relates issuetype "Security Review" status Approved`,
+			want: &linkRule{
+				rule:      `Require relates issuetype "Security Review" status Approved`,
+				action:    linkRuleRequire,
+				link:      "relates",
+				issueType: "Security Review",
+				status:    "Approved",
+			},
+		},
+		{
+			name:    "missing_link",
+			in:      "reject",
+			wantErr: "must be <action> <link> [<condition>...]",
+		},
+		{
+			name:    "unknown_action",
+			in:      "forbid blocks",
+			wantErr: `unknown action "forbid"`,
+		},
+		{
+			name:    "unknown_condition",
+			in:      "reject blocks open",
+			wantErr: `unknown condition "open"`,
+		},
+		{
+			name:    "missing_name",
+			in:      "require relates status",
+			wantErr: "status needs a name",
+		},
+		{
+			name:    "both_states",
+			in:      "reject blocks resolved unresolved",
+			wantErr: "more than one of resolved, unresolved",
+		},
+		{
+			name:    "unterminated_quote",
+			in:      `reject "is blocked by`,
+			wantErr: "unterminated quote",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseLinkRule(tc.in)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(linkRule{})); diff != "" {
+				t.Errorf("parseLinkRule(%q) (-want,+got):\n%s", tc.in, diff)
+			}
+		})
+	}
+}
+
+func TestLinkRule_Check(t *testing.T) {
+	t.Parallel()
+
+	fields := map[string]json.RawMessage{
+		issueLinksField: json.RawMessage(`[
+			{"type":{"name":"Blocks","inward":"is blocked by","outward":"blocks"},
+			 "inwardIssue":{"key":"ABCD-2","fields":{"status":{"name":"In Progress","statusCategory":{"key":"indeterminate"}},"issuetype":{"name":"Task"}}}},
+			{"type":{"name":"Blocks","inward":"is blocked by","outward":"blocks"},
+			 "inwardIssue":{"key":"ABCD-3","fields":{"status":{"name":"Done","statusCategory":{"key":"done"}},"issuetype":{"name":"Task"}}}},
+			{"type":{"name":"Relates","inward":"relates to","outward":"relates to"},
+			 "outwardIssue":{"key":"SEC-7","fields":{"status":{"name":"Approved","statusCategory":{"key":"done"}},"issuetype":{"name":"Security Review"}}}}
+		]`),
+	}
+
+	cases := []struct {
+		name    string
+		rule    string
+		fields  map[string]json.RawMessage
+		wantErr string
+	}{
+		{
+			name:    "reject_unresolved_blocker",
+			rule:    `reject "is blocked by" unresolved`,
+			fields:  fields,
+			wantErr: `issue has links to ABCD-2 rejected by link rule "reject \"is blocked by\" unresolved"`,
+		},
+		{
+			name:    "reject_by_type_name",
+			rule:    "reject blocks",
+			fields:  fields,
+			wantErr: "issue has links to ABCD-2, ABCD-3 rejected",
+		},
+		{
+			name:   "reject_no_outward_blocks",
+			rule:   `reject "blocks"`,
+			fields: map[string]json.RawMessage{issueLinksField: json.RawMessage(`[]`)},
+		},
+		{
+			name:   "require_security_review",
+			rule:   `require "relates to" issuetype "security review" status approved`,
+			fields: fields,
+		},
+		{
+			name:    "require_unmet",
+			rule:    `require "relates to" issuetype "Security Review" unresolved`,
+			fields:  fields,
+			wantErr: "issue has no link required by link rule",
+		},
+		{
+			name:    "require_without_links",
+			rule:    "require relates",
+			fields:  map[string]json.RawMessage{},
+			wantErr: "issue has no link required by link rule",
+		},
+		{
+			name:    "invalid_links",
+			rule:    "reject blocks",
+			fields:  map[string]json.RawMessage{issueLinksField: json.RawMessage(`{}`)},
+			wantErr: "invalid issue links",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := parseLinkRule(tc.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := testutil.DiffErrString(r.check(tc.fields), tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestWithLinkRules_SearchMode(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = ABCD", "test@test.com", "token",
+		WithSearchMode(), WithLinkRules([]string{"reject blocks"}))
+	if diff := testutil.DiffErrString(err, "link rules cannot be used in search mode"); diff != "" {
+		t.Errorf(diff)
+	}
+}
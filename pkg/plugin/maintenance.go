@@ -0,0 +1,77 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultMaintenanceRetryHint is the retry delay suggested for a validation
+// failing because Jira is under maintenance, when Jira did not send a
+// Retry-After. Maintenance windows last minutes rather than seconds.
+const defaultMaintenanceRetryHint = time.Minute
+
+// isHTMLResponse reports whether the response is an HTML page rather than
+// the JSON of the REST API, e.g. the maintenance page Atlassian or a proxy
+// in front of Jira serves during a maintenance window.
+func isHTMLResponse(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// isMaintenanceResponse reports whether the response is a maintenance page,
+// an HTML page answering with a 2xx or 5xx status code. An HTML 4xx is
+// still a client error, e.g. the login or not found page of a proxy.
+func isMaintenanceResponse(resp *http.Response) bool {
+	return (resp.StatusCode < http.StatusMultipleChoices || resp.StatusCode >= http.StatusInternalServerError) && isHTMLResponse(resp)
+}
+
+// maintenanceError returns the error of a request answered with a
+// maintenance page, wrapping [ErrJiraMaintenance] and [ErrJiraUnreachable]
+// with the Retry-After of the response if any. The page itself is not
+// decoded or reported.
+func maintenanceError(req *http.Request, resp *http.Response) error {
+	err := WithJiraStatus(fmt.Errorf(
+		"failed to make request to %s, got response code %d with a %s page: %w: %w",
+		req.URL.String(), resp.StatusCode, contentTypeName(resp), ErrJiraMaintenance, ErrJiraUnreachable), resp.StatusCode)
+	if delay, ok := parseRetryAfter(resp.Header, time.Now()); ok {
+		err = WithRetryAfter(err, delay)
+	}
+	return err
+}
+
+// contentTypeName returns the media type of the response for an error
+// message.
+func contentTypeName(resp *http.Response) string {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType == "" {
+		return "untyped"
+	}
+	return mediaType
+}
+
+// isJSONResponse reports whether the response is declared as JSON. Jira
+// declares every REST API response as application/json.
+func isJSONResponse(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
@@ -0,0 +1,166 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestMaintenancePages(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name            string
+		code            int
+		contentType     string
+		body            string
+		retryAfter      string
+		wantMaintenance bool
+		wantUnreachable bool
+		wantAuth        bool
+		wantInvalid     bool
+		wantCached      bool
+		wantRetryAfter  time.Duration
+	}{
+		{
+			name:            "html_503",
+			code:            http.StatusServiceUnavailable,
+			contentType:     "text/html; charset=utf-8",
+			body:            "<html><body>Down for maintenance</body></html>",
+			retryAfter:      "600",
+			wantMaintenance: true,
+			wantUnreachable: true,
+			wantRetryAfter:  10 * time.Minute,
+		},
+		{
+			name:            "html_200",
+			code:            http.StatusOK,
+			contentType:     "text/html",
+			body:            "<!DOCTYPE html><html><body>Maintenance</body></html>",
+			wantMaintenance: true,
+			wantUnreachable: true,
+		},
+		{
+			name:        "html_401",
+			code:        http.StatusUnauthorized,
+			contentType: "text/html",
+			body:        "<html><body>Log in</body></html>",
+			wantAuth:    true,
+			wantInvalid: true,
+		},
+		{
+			name:        "html_403",
+			code:        http.StatusForbidden,
+			contentType: "text/html",
+			body:        "<html><body>Forbidden</body></html>",
+			wantAuth:    true,
+			wantInvalid: true,
+		},
+		{
+			name:        "html_404",
+			code:        http.StatusNotFound,
+			contentType: "text/html",
+			body:        "<html><body>Not found</body></html>",
+			wantInvalid: true,
+			wantCached:  true,
+		},
+		{
+			name:            "json_503",
+			code:            http.StatusServiceUnavailable,
+			contentType:     "application/json",
+			body:            `{"errorMessages":["unavailable"]}`,
+			wantUnreachable: true,
+		},
+		{
+			name:            "plain_200",
+			code:            http.StatusOK,
+			contentType:     "text/plain",
+			body:            "service unavailable",
+			wantUnreachable: true,
+		},
+		{
+			name:        "invalid_json_200",
+			code:        http.StatusOK,
+			contentType: "application/json;charset=UTF-8",
+			body:        "{",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.Header().Set("Content-Type", tc.contentType)
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.code)
+				fmt.Fprint(w, tc.body)
+			}))
+			t.Cleanup(srv.Close)
+
+			v, err := NewValidator(srv.URL, "project = ABCD", "test@test.com", "secrets")
+			if err != nil {
+				t.Fatalf("failed to create validator: %v", err)
+			}
+			m := &notFoundMatcher{next: &lazyValidator{v: v}, cache: newNotFoundCache(time.Minute, 10)}
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			_, err = m.MatchIssue(ctx, "ABCD-1")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got, want := errors.Is(err, ErrJiraMaintenance), tc.wantMaintenance; got != want {
+				t.Errorf("got maintenance %t, want %t: %v", got, want, err)
+			}
+			if got, want := errors.Is(err, ErrJiraUnreachable), tc.wantUnreachable; got != want {
+				t.Errorf("got unreachable %t, want %t: %v", got, want, err)
+			}
+			if got, want := errors.Is(err, ErrJiraAuth), tc.wantAuth; got != want {
+				t.Errorf("got auth %t, want %t: %v", got, want, err)
+			}
+			if got, want := errors.Is(err, ErrInvalidJustification), tc.wantInvalid; got != want {
+				t.Errorf("got invalid %t, want %t: %v", got, want, err)
+			}
+			if got, _ := RetryAfter(err); got != tc.wantRetryAfter {
+				t.Errorf("got retry after %s, want %s", got, tc.wantRetryAfter)
+			}
+
+			// Only missing issues are remembered, the other failures go to
+			// Jira again.
+			_, _ = m.MatchIssue(ctx, "ABCD-1")
+			wantCalls := int32(2)
+			if tc.wantCached {
+				wantCalls = 1
+			}
+			if got := calls.Load(); got != wantCalls {
+				t.Errorf("got %d requests, want %d", got, wantCalls)
+			}
+		})
+	}
+}
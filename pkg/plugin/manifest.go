@@ -0,0 +1,154 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"strings"
+)
+
+// Manifest is a machine-readable description of a plugin with a given
+// configuration, so deployment tooling can check that the JVS server and
+// plugin configurations agree before rolling them out.
+type Manifest struct {
+	// Category is the justification category served.
+	Category string `json:"category"`
+
+	// ProtocolVersions are the JVS plugin protocol versions served.
+	ProtocolVersions []int `json:"protocol_versions"`
+
+	// RequiredConfig are the environment variables the configuration
+	// requires.
+	RequiredConfig []string `json:"required_config"`
+
+	// Features are the optional features the configuration enables.
+	Features []string `json:"features"`
+
+	// UIData is what the plugin returns to the JVS UI.
+	UIData *ManifestUIData `json:"ui_data"`
+
+	// Annotations are the keys a valid justification may be annotated with.
+	Annotations []string `json:"annotations"`
+
+	// Valid reports whether the configuration is valid, Errors lists the
+	// problems otherwise.
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ManifestUIData is the UI data of a [Manifest].
+type ManifestUIData struct {
+	DisplayName string `json:"display_name"`
+	Hint        string `json:"hint"`
+}
+
+// NewManifest returns the manifest of a plugin with the configuration. An
+// invalid configuration is reported in the manifest.
+func NewManifest(cfg *PluginConfig) *Manifest {
+	m := &Manifest{
+		Category:         cfg.category(),
+		ProtocolVersions: ProtocolVersions,
+		RequiredConfig:   requiredConfig(cfg),
+		Features:         enabledFeatures(cfg),
+		UIData: &ManifestUIData{
+			DisplayName: cfg.DisplayName,
+			Hint:        cfg.Hint,
+		},
+		Annotations: NewInfo(cfg).Annotations,
+		Valid:       true,
+	}
+	if err := cfg.Validate(); err != nil {
+		m.Valid = false
+		m.Errors = strings.Split(err.Error(), "\n")
+	}
+	return m
+}
+
+// requiredConfig returns the environment variables cfg requires, the account
+// is required either as a value or as a secret.
+func requiredConfig(cfg *PluginConfig) []string {
+	account := "JIRA_PLUGIN_ACCOUNT"
+	if cfg.AccountSecretID != "" {
+		account = "JIRA_PLUGIN_ACCOUNT_SECRET_ID"
+	}
+	return []string{
+		"JIRA_PLUGIN_ENDPOINT",
+		"JIRA_PLUGIN_JQL",
+		account,
+		"JIRA_PLUGIN_API_TOKEN_SECRET_ID",
+		"JIRA_PLUGIN_HINT",
+		"JIRA_PLUGIN_ISSUE_BASE_URL",
+	}
+}
+
+// enabledFeatures returns the names of the optional features cfg enables.
+func enabledFeatures(cfg *PluginConfig) []string {
+	features := []string{}
+	for _, f := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"endpoint_discovery", cfg.EndpointDiscovery},
+		{"candidate_jql", cfg.CandidateJql != ""},
+		{"issue_type_jql", cfg.IssueTypeJql != ""},
+		{"min_priority", cfg.MinPriority != ""},
+		{"max_issue_age", cfg.MaxIssueAge > 0 || cfg.MaxResolvedAge > 0},
+		{"archived_issues", cfg.ArchivedIssues != ""},
+		{"issue_snapshot_hash", cfg.IssueSnapshotHash},
+		{"field_constraints", cfg.FieldConstraints != ""},
+		{"link_rules", cfg.LinkRules != ""},
+		{"expression", cfg.Expression != ""},
+		{"personalized_jql", cfg.personalized()},
+		{"account_ids", cfg.AccountIDs},
+		{"identity_mapping", cfg.identityMapped()},
+		{"secondary_api_token", cfg.SecondaryAPITokenSecretID != ""},
+		{"annotation_signing", cfg.AnnotationSigningKeySecretID != ""},
+		{"issue_url_template", cfg.IssueURLTemplate != ""},
+		{"project_base_urls", len(cfg.ProjectBaseURLs) > 0},
+		{"audit", cfg.AuditSyslogAddress != ""},
+		{"cache", cfg.cacheEnabled()},
+		{"cache_encryption", cfg.cacheEnabled() && len(cfg.CacheEncryptionKeySecretIDs) > 0},
+		{"cache_stale_while_revalidate", cfg.cacheEnabled() && cfg.CacheMaxStaleness > 0},
+		{"redis_state", cfg.StateBackend == StateBackendRedis},
+		{"not_found_cache", cfg.NotFoundCacheTTL > 0},
+		{"search_mode", cfg.MatchMode == MatchModeSearch},
+		{"annotation_fields", len(cfg.AnnotationFields) > 0},
+		{"field_redactions", cfg.FieldRedactions != ""},
+		{"quota", cfg.QuotaRate > 0 || cfg.QuotaMaxConcurrent > 0},
+		{"requestor_quota", cfg.RequestorQuota > 0},
+		{"freeze_windows", cfg.FreezeWindows != ""},
+		{"bypass", len(cfg.BypassRequestors) > 0},
+		{"bypass_token", cfg.BypassTokenSecretID != ""},
+		{"debug_annotations", cfg.DebugAnnotations},
+		{"explain_annotation", cfg.ExplainAnnotation},
+		{"wrong_category_error", cfg.WrongCategoryError},
+		{"localized_messages", cfg.MessageLocale != ""},
+		{"replay", cfg.ReplayBufferSize > 0},
+		{"diagnostic_decisions", cfg.DiagnosticDecisions > 0},
+		{"http_retries", cfg.HTTPRetries > 0},
+		{"regional_endpoints", len(cfg.RegionalEndpoints) > 0},
+		{"feature_flags", len(cfg.FeatureFlags) > 0},
+		{"allowed_hosts", len(cfg.AllowedHosts) > 0},
+		{"fault_injection", cfg.FaultInjectionRate > 0},
+		{"insecure_skip_verify", cfg.InsecureSkipVerify},
+		{"decision_stream", cfg.DecisionStream},
+		{"decision_sampling", cfg.SampleRate > 0},
+		{"revocation", cfg.RevocationDestination != ""},
+	} {
+		if f.enabled {
+			features = append(features, f.name)
+		}
+	}
+	return features
+}
@@ -0,0 +1,325 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+// RequestorLocaleMetadataKey is the gRPC metadata key of the preferred locale
+// of the requestor, e.g. "de-CH" or an Accept-Language header value. The
+// first locale is used.
+const RequestorLocaleMetadataKey = "jvs-requestor-locale"
+
+// The reasons a justification is rejected for, besides
+// [ErrorCodeWrongCategory]. They are the [AnnotationErrorCode] of invalid
+// responses and the keys of the message catalog when
+// [PluginConfig.MessageLocale] is set.
+const (
+	// ErrorCodeEmptyJustification is the code of an empty justification.
+	ErrorCodeEmptyJustification = "empty_justification"
+
+	// ErrorCodeValueTooLong is the code of a justification value longer than
+	// [PluginConfig.MaxValueLength].
+	ErrorCodeValueTooLong = "value_too_long"
+
+	// ErrorCodeInvalidCharacters is the code of a justification value with
+	// characters outside of [PluginConfig.ValueCharset].
+	ErrorCodeInvalidCharacters = "invalid_characters"
+
+	// ErrorCodeInvalidFormat is the code of a justification the parser
+	// rejects.
+	ErrorCodeInvalidFormat = "invalid_format"
+
+	// ErrorCodeInvalidIssueKey is the code of a value that is not an issue
+	// key.
+	ErrorCodeInvalidIssueKey = "invalid_issue_key"
+
+	// ErrorCodeDeployFreeze is the code of a justification rejected during a
+	// deploy freeze.
+	ErrorCodeDeployFreeze = "deploy_freeze"
+
+	// ErrorCodeChangeUnsupported is the code of a change ticket the
+	// justification format does not support.
+	ErrorCodeChangeUnsupported = "change_unsupported"
+
+	// ErrorCodeIssueNotFound is the code of an issue Jira does not know or
+	// does not show to the plugin.
+	ErrorCodeIssueNotFound = "issue_not_found"
+
+	// ErrorCodeJiraRejected is the code of any other request Jira rejected
+	// with a 4xx response.
+	ErrorCodeJiraRejected = "jira_rejected"
+
+	// ErrorCodeNoMatch is the code of an issue not matching the JQL.
+	ErrorCodeNoMatch = "no_match"
+
+	// ErrorCodeAmbiguousIssue is the code of a value matching several issues.
+	ErrorCodeAmbiguousIssue = "ambiguous_issue"
+
+	// ErrorCodePriority is the code of an issue whose priority is too low.
+	ErrorCodePriority = "priority_too_low"
+
+	// ErrorCodeIssueTooOld is the code of an issue created or resolved too
+	// long ago.
+	ErrorCodeIssueTooOld = "issue_too_old"
+
+	// ErrorCodeIssueArchived is the code of an archived issue, see
+	// [ArchivedIssuesReject].
+	ErrorCodeIssueArchived = "issue_archived"
+
+	// ErrorCodeFieldConstraint is the code of an issue failing a field
+	// constraint.
+	ErrorCodeFieldConstraint = "field_constraint"
+
+	// ErrorCodeLinkRule is the code of an issue failing a link rule.
+	ErrorCodeLinkRule = "link_rule"
+
+	// ErrorCodeExpression is the code of an issue not satisfying the Jira
+	// expression.
+	ErrorCodeExpression = "expression"
+
+	// ErrorCodeRequestorUnknown is the code of a justification whose JQL
+	// depends on a requestor the JVS server did not send.
+	ErrorCodeRequestorUnknown = "requestor_unknown"
+
+	// ErrorCodeRequestorNotInJira is the code of a justification whose JQL
+	// depends on a requestor no Jira user can be resolved to.
+	ErrorCodeRequestorNotInJira = "requestor_not_in_jira"
+
+	// ErrorCodeBypassRejected is the code of an emergency bypass with a wrong
+	// token, or while no bypass token is configured.
+	ErrorCodeBypassRejected = "bypass_rejected"
+
+	// ErrorCodeInvalidJustification is the code of any other rejection.
+	ErrorCodeInvalidJustification = "invalid_justification"
+)
+
+// defaultMessages are the built-in user-facing messages by code. {issue} is
+// replaced with the issue key, {until} with the end of the deploy freeze and
+// {max} with the maximum length of a justification value.
+var defaultMessages = map[string]string{
+	ErrorCodeWrongCategory:        "This justification category is not handled by the Jira plugin.",
+	ErrorCodeEmptyJustification:   "The justification is empty, enter a Jira issue key.",
+	ErrorCodeValueTooLong:         "The justification is longer than {max} characters.",
+	ErrorCodeInvalidCharacters:    "The justification contains characters that are not allowed.",
+	ErrorCodeInvalidFormat:        "The justification is not in the expected format.",
+	ErrorCodeInvalidIssueKey:      "{issue} is not a Jira issue key.",
+	ErrorCodeDeployFreeze:         "A deploy freeze is in effect until {until}, the justification is not accepted.",
+	ErrorCodeChangeUnsupported:    "Change tickets are not supported.",
+	ErrorCodeIssueNotFound:        "Jira issue {issue} does not exist or is not visible.",
+	ErrorCodeJiraRejected:         "Jira rejected the lookup of issue {issue}.",
+	ErrorCodeNoMatch:              "Jira issue {issue} does not meet the criteria for justifications.",
+	ErrorCodeAmbiguousIssue:       "{issue} matches several Jira issues.",
+	ErrorCodePriority:             "The priority of Jira issue {issue} is too low.",
+	ErrorCodeIssueTooOld:          "Jira issue {issue} is too old to justify the request.",
+	ErrorCodeIssueArchived:        "Jira issue {issue} is archived.",
+	ErrorCodeFieldConstraint:      "A field of Jira issue {issue} does not have an accepted value.",
+	ErrorCodeLinkRule:             "The links of Jira issue {issue} are not accepted.",
+	ErrorCodeExpression:           "Jira issue {issue} does not meet the criteria for justifications.",
+	ErrorCodeRequestorUnknown:     "Your identity is required to validate Jira issue {issue}.",
+	ErrorCodeRequestorNotInJira:   "No single Jira user matches your identity, which is required to validate Jira issue {issue}.",
+	ErrorCodeBypassRejected:       "The emergency bypass is not accepted.",
+	ErrorCodeInvalidJustification: "Jira issue {issue} is not a valid justification.",
+}
+
+// defaultMessageLocale is the locale of [defaultMessages].
+const defaultMessageLocale = "en"
+
+// localePattern matches a BCP 47 like locale, e.g. "de" or "pt-BR", or a
+// POSIX one like "pt_BR".
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})*$`)
+
+// messageCatalog holds the user-facing rejection messages by locale and code.
+// The built-in English messages are the fallback of every locale.
+type messageCatalog struct {
+	defaultLocale string
+	messages      map[string]map[string]string
+}
+
+// newMessageCatalog creates a catalog whose messages are used for requests
+// without a known locale in defaultLocale. An override looks like
+// "de:no_match=Das Ticket {issue} ist nicht freigegeben".
+func newMessageCatalog(defaultLocale string, overrides []string) (*messageCatalog, error) {
+	if !localePattern.MatchString(defaultLocale) {
+		return nil, fmt.Errorf("invalid locale %q", defaultLocale)
+	}
+	c := &messageCatalog{
+		defaultLocale: normalizeLocale(defaultLocale),
+		messages:      map[string]map[string]string{defaultMessageLocale: copyMessages(defaultMessages)},
+	}
+	for _, o := range overrides {
+		locale, code, msg, err := parseMessageOverride(o)
+		if err != nil {
+			return nil, err
+		}
+		if c.messages[locale] == nil {
+			c.messages[locale] = make(map[string]string)
+		}
+		c.messages[locale][code] = msg
+	}
+	return c, nil
+}
+
+// parseMessageOverride parses an override of [newMessageCatalog].
+func parseMessageOverride(s string) (string, string, string, error) {
+	key, msg, ok := strings.Cut(s, "=")
+	locale, code, ok2 := strings.Cut(key, ":")
+	locale, code, msg = strings.TrimSpace(locale), strings.TrimSpace(code), strings.TrimSpace(msg)
+	if !ok || !ok2 || msg == "" {
+		return "", "", "", fmt.Errorf("invalid message %q, must be <locale>:<code>=<message>", s)
+	}
+	if !localePattern.MatchString(locale) {
+		return "", "", "", fmt.Errorf("invalid message %q, invalid locale %q", s, locale)
+	}
+	if _, ok := defaultMessages[code]; !ok {
+		return "", "", "", fmt.Errorf("invalid message %q, unknown code %q, must be one of %s",
+			s, code, strings.Join(messageCodes(), ", "))
+	}
+	return normalizeLocale(locale), code, msg, nil
+}
+
+// messageCodes returns the sorted codes of the catalog.
+func messageCodes() []string {
+	codes := make([]string, 0, len(defaultMessages))
+	for code := range defaultMessages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func copyMessages(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// normalizeLocale lowercases the locale and uses dashes, e.g. "pt_BR"
+// becomes "pt-br".
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
+
+// message returns the message for the code in the locale, falling back to
+// its language, the default locale and English in turn. An unknown code
+// gets the message of [ErrorCodeInvalidJustification].
+func (c *messageCatalog) message(locale, code string, params map[string]string) string {
+	if _, ok := defaultMessages[code]; !ok {
+		code = ErrorCodeInvalidJustification
+	}
+	var msg string
+	for _, l := range c.fallbacks(locale) {
+		if m, ok := c.messages[l][code]; ok {
+			msg = m
+			break
+		}
+	}
+	for k, v := range params {
+		msg = strings.ReplaceAll(msg, "{"+k+"}", v)
+	}
+	return msg
+}
+
+// fallbacks returns the locales to look a message up in, in order.
+func (c *messageCatalog) fallbacks(locale string) []string {
+	var out []string
+	for _, l := range []string{normalizeLocale(locale), c.defaultLocale} {
+		if l == "" {
+			continue
+		}
+		out = append(out, l)
+		if lang, _, ok := strings.Cut(l, "-"); ok {
+			out = append(out, lang)
+		}
+	}
+	return append(out, defaultMessageLocale)
+}
+
+// localeFromContext returns the first locale the JVS server sent in the gRPC
+// metadata of the request, or "".
+func localeFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	vals := md.Get(RequestorLocaleMetadataKey)
+	if len(vals) == 0 {
+		return ""
+	}
+	locale, _, _ := strings.Cut(vals[0], ",")
+	locale, _, _ = strings.Cut(locale, ";")
+	locale = strings.TrimSpace(locale)
+	if !localePattern.MatchString(locale) {
+		return ""
+	}
+	return locale
+}
+
+// reject returns an invalid response for a rejection with the code. Without
+// a message catalog the response has the internal detail as error, as
+// before. Otherwise it has the localized message and the code annotation,
+// and the detail is logged.
+func (s *snapshot) reject(ctx context.Context, code, detail string, params map[string]string) *jvspb.ValidateJustificationResponse {
+	if s.messages == nil {
+		return invalidErrResponse(detail)
+	}
+	logging.FromContext(ctx).InfoContext(ctx, "justification rejected", "code", code, "detail", detail)
+	resp := invalidErrResponse(s.messages.message(localeFromContext(ctx), code, params))
+	resp.Annotation = map[string]string{jiraErrorCode: code}
+	return resp
+}
+
+// rejectErr converts an error from matching the issue into an invalid
+// response like [matchErrResponse], with a localized message per failure
+// when there is a message catalog.
+func (s *snapshot) rejectErr(ctx context.Context, err error, issueKey string) (*jvspb.ValidateJustificationResponse, error) {
+	if s.messages == nil || !errors.Is(err, ErrInvalidJustification) {
+		return matchErrResponse(err)
+	}
+
+	failures := []error{err}
+	var pe *policyError
+	if errors.As(err, &pe) {
+		failures = pe.failures
+	}
+	logger := logging.FromContext(ctx)
+	locale := localeFromContext(ctx)
+	resp := &jvspb.ValidateJustificationResponse{Valid: false}
+	for _, f := range failures {
+		code := Reason(f)
+		if code == "" {
+			code = ErrorCodeInvalidJustification
+		}
+		logger.InfoContext(ctx, "justification rejected", "code", code, "issue_key", issueKey, "detail", f.Error())
+		resp.Error = append(resp.Error, s.messages.message(locale, code, map[string]string{"issue": issueKey}))
+		if resp.Annotation == nil {
+			resp.Annotation = map[string]string{jiraErrorCode: code}
+		}
+	}
+	return resp, nil
+}
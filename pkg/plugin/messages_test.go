@@ -0,0 +1,232 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/grpc/metadata"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestMessageCatalog_Message(t *testing.T) {
+	t.Parallel()
+
+	c, err := newMessageCatalog("de", []string{
+		"de:no_match=Das Ticket {issue} ist nicht freigegeben",
+		"de_CH:no_match=Das Ticket {issue} ist nöd freigegeben",
+		"en:ambiguous_issue={issue} is ambiguous",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		locale string
+		code   string
+		want   string
+	}{
+		{
+			name:   "exact",
+			locale: "de-CH",
+			code:   ErrorCodeNoMatch,
+			want:   "Das Ticket ABCD-1 ist nöd freigegeben",
+		},
+		{
+			name:   "language",
+			locale: "de-AT",
+			code:   ErrorCodeNoMatch,
+			want:   "Das Ticket ABCD-1 ist nicht freigegeben",
+		},
+		{
+			name:   "default_locale",
+			locale: "fr",
+			code:   ErrorCodeNoMatch,
+			want:   "Das Ticket ABCD-1 ist nicht freigegeben",
+		},
+		{
+			name:   "english_override",
+			locale: "de",
+			code:   ErrorCodeAmbiguousIssue,
+			want:   "ABCD-1 is ambiguous",
+		},
+		{
+			name:   "built_in",
+			locale: "de",
+			code:   ErrorCodeIssueNotFound,
+			want:   "Jira issue ABCD-1 does not exist or is not visible.",
+		},
+		{
+			name:   "unknown_code",
+			locale: "",
+			code:   "unknown",
+			want:   "Jira issue ABCD-1 is not a valid justification.",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := c.message(tc.locale, tc.code, map[string]string{"issue": "ABCD-1"}); got != tc.want {
+				t.Errorf("message(%q, %q) got %q, want %q", tc.locale, tc.code, got, tc.want)
+			}
+		})
+	}
+
+	// Overrides do not leak into other catalogs.
+	if got, want := defaultMessages[ErrorCodeAmbiguousIssue], "{issue} matches several Jira issues."; got != want {
+		t.Errorf("built-in message got %q, want %q", got, want)
+	}
+}
+
+func TestNewMessageCatalog(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		locale    string
+		overrides []string
+		wantErr   string
+	}{
+		{
+			name:    "invalid_locale",
+			locale:  "german",
+			wantErr: `invalid locale "german"`,
+		},
+		{
+			name:      "missing_message",
+			locale:    "en",
+			overrides: []string{"de:no_match"},
+			wantErr:   `invalid message "de:no_match", must be <locale>:<code>=<message>`,
+		},
+		{
+			name:      "invalid_override_locale",
+			locale:    "en",
+			overrides: []string{"d:no_match=Nein"},
+			wantErr:   `invalid locale "d"`,
+		},
+		{
+			name:      "unknown_code",
+			locale:    "en",
+			overrides: []string{"de:nomatch=Nein"},
+			wantErr:   `unknown code "nomatch", must be one of ambiguous_issue,`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := newMessageCatalog(tc.locale, tc.overrides)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestPlugin_Validate_LocalizedMessages(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.MinPriority = "High"
+	cfg.MessageLocale = "en"
+	cfg.Messages = "de:priority_too_low=Die Priorität von {issue} ist zu niedrig; de:no_match=Das Ticket {issue} ist nicht freigegeben"
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	cases := []struct {
+		name   string
+		locale string
+		value  string
+		want   *jvspb.ValidateJustificationResponse
+	}{
+		{
+			name:   "policy",
+			locale: "de-DE,de;q=0.9,en;q=0.8",
+			value:  "ABCD-1",
+			want: &jvspb.ValidateJustificationResponse{
+				Error:      []string{"Die Priorität von ABCD-1 ist zu niedrig"},
+				Annotation: map[string]string{jiraErrorCode: ErrorCodePriority},
+			},
+		},
+		{
+			name:  "not_found",
+			value: fakeJiraMissingIssue,
+			want: &jvspb.ValidateJustificationResponse{
+				Error:      []string{"Jira issue MISSING-1 does not exist or is not visible."},
+				Annotation: map[string]string{jiraErrorCode: ErrorCodeIssueNotFound},
+			},
+		},
+		{
+			name:   "invalid_key",
+			locale: "de",
+			value:  "ABCD 1",
+			want: &jvspb.ValidateJustificationResponse{
+				Error:      []string{"ABCD 1 is not a Jira issue key."},
+				Annotation: map[string]string{jiraErrorCode: ErrorCodeInvalidIssueKey},
+			},
+		},
+		{
+			name:  "value_too_long",
+			value: strings.Repeat("A", defaultMaxValueLength+1),
+			want: &jvspb.ValidateJustificationResponse{
+				Error:      []string{"The justification is longer than 512 characters."},
+				Annotation: map[string]string{jiraErrorCode: ErrorCodeValueTooLong},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := ctx
+			if tc.locale != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(RequestorLocaleMetadataKey, tc.locale))
+			}
+			got, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{Category: jiraCategory, Value: tc.value},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(jvspb.ValidateJustificationResponse{})); diff != "" {
+				t.Errorf("response (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
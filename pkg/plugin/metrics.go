@@ -0,0 +1,99 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/abcxyz/jvs-plugin-jira/internal/version"
+)
+
+// MetricsContentType is the content type of [JiraPlugin.WriteMetrics], the
+// Prometheus text exposition format.
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// configHashBits is the number of bits of the SHA-256 of the configuration
+// kept by [configHash], so that the value is exact as a float64 sample.
+const configHashBits = 48
+
+// configHash returns a hash of cfg, which is the redacted configuration of
+// a snapshot, for the jira_plugin_config_hash metric. It changes whenever a
+// setting does, but not when only a password of a URL does.
+func configHash(cfg *PluginConfig) uint64 {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		// PluginConfig only has plain fields, this does not happen.
+		return 0
+	}
+	sum := sha256.Sum256(b)
+	return binary.BigEndian.Uint64(sum[:8]) >> (64 - configHashBits)
+}
+
+// ConfigHash returns the hash of the configuration validations currently
+// run with, as exported by [JiraPlugin.WriteMetrics].
+func (j *JiraPlugin) ConfigHash() uint64 {
+	return j.current.Load().configHash
+}
+
+// WriteMetrics writes the build info and configuration metrics of the plugin
+// to w in the Prometheus text exposition format, see [MetricsContentType]:
+//
+//	jira_plugin_build_info{version="0.4.0",commit="1a2b3c4",goversion="go1.22.1"} 1
+//	jira_plugin_config_hash{category="jira"} 1.4283919265641e+14
+//
+// The config hash changes on a restart or reload with a different
+// configuration, so dashboards can correlate a change of behavior with it.
+func (j *JiraPlugin) WriteMetrics(w io.Writer) error {
+	return writeMetrics(w, j)
+}
+
+// writeMetrics writes the metrics of the plugins, with one config hash
+// sample per plugin.
+func writeMetrics(w io.Writer, plugins ...*JiraPlugin) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# HELP jira_plugin_build_info A metric with a constant '1' value labeled by the version and commit the plugin was built from.")
+	fmt.Fprintln(bw, "# TYPE jira_plugin_build_info gauge")
+	fmt.Fprintf(bw, "jira_plugin_build_info{version=%s,commit=%s,goversion=%s} 1\n",
+		metricLabel(version.Version), metricLabel(version.Commit), metricLabel(runtime.Version()))
+
+	fmt.Fprintln(bw, "# HELP jira_plugin_config_hash Hash of the effective configuration, it changes when the configuration is reloaded or restarted with different settings.")
+	fmt.Fprintln(bw, "# TYPE jira_plugin_config_hash gauge")
+	for _, p := range plugins {
+		s := p.current.Load()
+		fmt.Fprintf(bw, "jira_plugin_config_hash{category=%s} %g\n",
+			metricLabel(s.justificationCategory()), float64(s.configHash))
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return nil
+}
+
+// metricLabel returns s quoted as a label value of the Prometheus text
+// format, which only escapes backslashes, double quotes and line feeds.
+func metricLabel(s string) string {
+	return `"` + metricLabelEscaper.Replace(s) + `"`
+}
+
+var metricLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
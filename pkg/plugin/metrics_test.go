@@ -0,0 +1,118 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	p, err := NewJiraPluginWithToken(ctx, f.config(), "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	metrics := func() string {
+		t.Helper()
+		var b strings.Builder
+		if err := p.WriteMetrics(&b); err != nil {
+			t.Fatalf("WriteMetrics() got unexpected error: %v", err)
+		}
+		return b.String()
+	}
+
+	got := metrics()
+	for _, want := range []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^# TYPE jira_plugin_build_info gauge$`),
+		regexp.MustCompile(`(?m)^jira_plugin_build_info\{version="[^"]*",commit="[^"]*",goversion="go[^"]*"\} 1$`),
+		regexp.MustCompile(`(?m)^# TYPE jira_plugin_config_hash gauge$`),
+		regexp.MustCompile(fmt.Sprintf(`(?m)^jira_plugin_config_hash\{category="jira"\} %s$`,
+			regexp.QuoteMeta(fmt.Sprintf("%g", float64(p.ConfigHash()))))),
+	} {
+		if !want.MatchString(got) {
+			t.Errorf("WriteMetrics() got:\n%s\nwant a match of %s", got, want)
+		}
+	}
+
+	hash := p.ConfigHash()
+	if hash == 0 || hash >= 1<<configHashBits {
+		t.Errorf("ConfigHash() got %d, want a non-zero %d bit hash", hash, configHashBits)
+	}
+
+	// Reloading the same configuration keeps the hash.
+	if err := p.Reload(ctx, f.config()); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.ConfigHash(); got != hash {
+		t.Errorf("ConfigHash() after identical reload got %d, want %d", got, hash)
+	}
+
+	// Any other setting changes it.
+	cfg := f.config()
+	cfg.Jql = "project = OPS"
+	if err := p.Reload(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.ConfigHash(); got == hash {
+		t.Errorf("ConfigHash() after reload with another JQL got unchanged %d", got)
+	}
+	if strings.Contains(metrics(), fmt.Sprintf(" %g\n", float64(hash))) {
+		t.Errorf("WriteMetrics() still reports the previous config hash")
+	}
+}
+
+func TestMetricLabel(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain",
+			in:   "v1.2.3",
+			want: `"v1.2.3"`,
+		},
+		{
+			name: "escaped",
+			in:   "a\\b\"c\nd",
+			want: `"a\\b\"c\nd"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := metricLabel(tc.in); got != tc.want {
+				t.Errorf("metricLabel(%q) got %s, want %s", tc.in, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultiPlugin serves several plugins behind one [jvspb.Validator], each
+// validating the justifications of its own category, see
+// [PluginConfig.Category]. It lets one process serve several Jira
+// configurations, e.g. one per Jira site.
+type MultiPlugin struct {
+	// plugins are the plugins by category.
+	plugins map[string]*JiraPlugin
+
+	// categories are the categories of the plugins, in order.
+	categories []string
+}
+
+// NewMultiPlugin returns a MultiPlugin routing the validations to the
+// plugins by justification category. The categories must be unique. The
+// plugins are owned by the MultiPlugin, see [MultiPlugin.Close].
+func NewMultiPlugin(plugins ...*JiraPlugin) (*MultiPlugin, error) {
+	if len(plugins) == 0 {
+		return nil, fmt.Errorf("no plugins to serve")
+	}
+	m := &MultiPlugin{plugins: make(map[string]*JiraPlugin, len(plugins))}
+	for _, p := range plugins {
+		category := p.Category()
+		if _, ok := m.plugins[category]; ok {
+			return nil, fmt.Errorf("duplicate justification category %q", category)
+		}
+		m.plugins[category] = p
+		m.categories = append(m.categories, category)
+	}
+	return m, nil
+}
+
+// Category returns the justification category the plugin validates, see
+// [PluginConfig.Category].
+func (j *JiraPlugin) Category() string {
+	return j.current.Load().justificationCategory()
+}
+
+// Categories returns the categories served, in the order of the plugins.
+func (m *MultiPlugin) Categories() []string {
+	return append([]string(nil), m.categories...)
+}
+
+// Plugin returns the plugin validating the category, or nil when there is
+// none.
+func (m *MultiPlugin) Plugin(category string) *JiraPlugin {
+	return m.plugins[category]
+}
+
+// Validate validates the justification with the plugin of its category. A
+// category no plugin validates fails with InvalidArgument, like a wrong
+// category with [PluginConfig.WrongCategoryError].
+func (m *MultiPlugin) Validate(ctx context.Context, req *jvspb.ValidateJustificationRequest) (*jvspb.ValidateJustificationResponse, error) {
+	category := req.GetJustification().GetCategory()
+	p, ok := m.plugins[category]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "%s %q, must be one of %s",
+			ErrWrongCategory, category, strings.Join(m.categories, ", "))
+	}
+	return p.Validate(ctx, req)
+}
+
+// GetUIData returns the UI data of the first plugin. The request does not
+// say which category the UI data are for.
+func (m *MultiPlugin) GetUIData(ctx context.Context, req *jvspb.GetUIDataRequest) (*jvspb.UIData, error) {
+	return m.plugins[m.categories[0]].GetUIData(ctx, req)
+}
+
+// WriteMetrics writes the metrics of the plugins like
+// [JiraPlugin.WriteMetrics], with a config hash per category.
+func (m *MultiPlugin) WriteMetrics(w io.Writer) error {
+	plugins := make([]*JiraPlugin, 0, len(m.categories))
+	for _, category := range m.categories {
+		plugins = append(plugins, m.plugins[category])
+	}
+	return writeMetrics(w, plugins...)
+}
+
+// Warmup warms up the plugins concurrently, see [JiraPlugin.Warmup]. It
+// returns the errors of all plugins that failed.
+func (m *MultiPlugin) Warmup(ctx context.Context) error {
+	errs := make([]error, len(m.categories))
+	var wg sync.WaitGroup
+	for i, category := range m.categories {
+		wg.Add(1)
+		go func(i int, category string) {
+			defer wg.Done()
+			if err := m.plugins[category].Warmup(ctx); err != nil {
+				errs[i] = fmt.Errorf("category %s: %w", category, err)
+			}
+		}(i, category)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Close shuts every plugin down, see [JiraPlugin.Close]. The validations in
+// flight are waited for until ctx is done.
+func (m *MultiPlugin) Close(ctx context.Context) error {
+	var merr error
+	for _, category := range m.categories {
+		if err := m.plugins[category].Close(ctx); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("category %s: %w", category, err))
+		}
+	}
+	return merr
+}
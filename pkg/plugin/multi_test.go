@@ -0,0 +1,126 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestMultiPlugin(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	newPlugin := func(f *fakeJira, category, hint string) *JiraPlugin {
+		t.Helper()
+		cfg := f.config()
+		cfg.Category = category
+		cfg.Hint = hint
+		p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+		if err != nil {
+			t.Fatalf("failed to create plugin: %v", err)
+		}
+		return p
+	}
+
+	jira, ops := newFakeJira(t), newFakeJira(t)
+	m, err := NewMultiPlugin(newPlugin(jira, "", "Jira Issue Key"), newPlugin(ops, "ops", "Ops Issue Key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := m.Close(ctx); err != nil {
+			t.Errorf("Close() got unexpected error: %v", err)
+		}
+	})
+
+	if diff := cmp.Diff([]string{"jira", "ops"}, m.Categories()); diff != "" {
+		t.Errorf("Categories() (-want, +got):\n%s", diff)
+	}
+
+	validate := func(category, value string) (*jvspb.ValidateJustificationResponse, error) {
+		t.Helper()
+		return m.Validate(ctx, &jvspb.ValidateJustificationRequest{
+			Justification: &jvspb.Justification{Category: category, Value: value},
+		})
+	}
+
+	// Each category is validated by its own plugin.
+	if resp, err := validate("ops", "OPS-1"); err != nil || !resp.GetValid() {
+		t.Fatalf("Validate(ops) got %v, %v, want a valid response", resp, err)
+	}
+	ops.assertCalls(t, 1)
+	jira.assertCalls(t, 0)
+
+	if resp, err := validate("jira", "ABCD-1"); err != nil || !resp.GetValid() {
+		t.Fatalf("Validate(jira) got %v, %v, want a valid response", resp, err)
+	}
+	jira.assertCalls(t, 1)
+	ops.assertCalls(t, 1)
+
+	// A category no plugin validates.
+	_, err = validate("github", "ABCD-1")
+	if diff := testutil.DiffErrString(err, `"github", must be one of jira, ops`); diff != "" {
+		t.Errorf(diff)
+	}
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("Validate(github) got code %s, want %s", got, want)
+	}
+
+	// The UI data are those of the first plugin.
+	ui, err := m.GetUIData(ctx, &jvspb.GetUIDataRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ui.GetHint(), "Jira Issue Key"; got != want {
+		t.Errorf("GetUIData() got hint %q, want %q", got, want)
+	}
+}
+
+func TestNewMultiPlugin_Errors(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	if _, err := NewMultiPlugin(); err == nil {
+		t.Errorf("NewMultiPlugin() got no error, want one")
+	}
+
+	f := newFakeJira(t)
+	plugins := make([]*JiraPlugin, 0, 2)
+	for _, category := range []string{"jira", ""} {
+		cfg := f.config()
+		cfg.Category = category
+		p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+		if err != nil {
+			t.Fatalf("failed to create plugin: %v", err)
+		}
+		t.Cleanup(func() { p.Close(ctx) })
+		plugins = append(plugins, p)
+	}
+	_, err := NewMultiPlugin(plugins...)
+	if diff := testutil.DiffErrString(err, `duplicate justification category "jira"`); diff != "" {
+		t.Errorf(diff)
+	}
+}
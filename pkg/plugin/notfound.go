@@ -0,0 +1,216 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultNotFoundCacheSize is the number of missing issue keys remembered
+// when no size is configured.
+const defaultNotFoundCacheSize = 1000
+
+// NotFoundCacheStats are the counters of the cache of missing issues, see
+// [JiraPlugin.NotFoundCacheStats].
+type NotFoundCacheStats struct {
+	// Hits is the number of lookups answered from the cache.
+	Hits uint64 `json:"hits"`
+
+	// Misses is the number of lookups that asked Jira.
+	Misses uint64 `json:"misses"`
+
+	// Evictions is the number of unexpired keys removed to make room for
+	// others.
+	Evictions uint64 `json:"evictions"`
+
+	// Entries is the number of keys currently cached.
+	Entries int `json:"entries"`
+}
+
+// notFoundCache remembers the issue keys Jira answered with 404 Not Found for
+// a short time, so typos and scanners do not cause a Jira request each time.
+// It holds at most size keys, the least recently used key is evicted first.
+type notFoundCache struct {
+	ttl  time.Duration
+	size int
+	now  func() time.Time
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+	stats NotFoundCacheStats
+}
+
+// notFoundEntry is a cached 404 response.
+type notFoundEntry struct {
+	key       string
+	err       error
+	expiresAt time.Time
+}
+
+// newNotFoundCache returns a cache keeping up to size keys for ttl, a zero
+// size keeps 1000 keys. It returns nil when ttl is zero, i.e. every lookup
+// asks Jira.
+func newNotFoundCache(ttl time.Duration, size int) *notFoundCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if size <= 0 {
+		size = defaultNotFoundCacheSize
+	}
+	return &notFoundCache{
+		ttl:   ttl,
+		size:  size,
+		now:   time.Now,
+		lru:   list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+// get returns the error Jira returned for the issue key, or nil when the key
+// is not cached or expired.
+func (c *notFoundCache) get(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return nil
+	}
+	entry := el.Value.(*notFoundEntry) //nolint:forcetypeassert // lru only holds *notFoundEntry
+	if !c.now().Before(entry.expiresAt) {
+		c.lru.Remove(el)
+		delete(c.items, key)
+		c.stats.Misses++
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	c.stats.Hits++
+	return entry.err
+}
+
+// put caches err for the issue key, evicting the least recently used key
+// when the cache is full.
+func (c *notFoundCache) put(key string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*notFoundEntry) //nolint:forcetypeassert // lru only holds *notFoundEntry
+		entry.err, entry.expiresAt = err, expiresAt
+		c.lru.MoveToFront(el)
+		return
+	}
+
+	for c.lru.Len() >= c.size {
+		oldest := c.lru.Back()
+		entry := oldest.Value.(*notFoundEntry) //nolint:forcetypeassert // lru only holds *notFoundEntry
+		c.lru.Remove(oldest)
+		delete(c.items, entry.key)
+		if c.now().Before(entry.expiresAt) {
+			c.stats.Evictions++
+		}
+	}
+	c.items[key] = c.lru.PushFront(&notFoundEntry{key: key, err: err, expiresAt: expiresAt})
+}
+
+// forget removes the issue key, the next lookup asks Jira.
+func (c *notFoundCache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.lru.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// purge removes every key.
+func (c *notFoundCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	clear(c.items)
+}
+
+// counters returns the current counters.
+func (c *notFoundCache) counters() NotFoundCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// notFoundMatcher answers lookups of issues recently reported missing from a
+// [notFoundCache] and falls back to the wrapped matcher.
+type notFoundMatcher struct {
+	next  IssueMatcher
+	cache *notFoundCache
+
+	// features turn the cache off at runtime, see [FeatureNotFoundCache].
+	features *featureFlags
+}
+
+// MatchIssue returns the cached error when Jira recently reported the issue
+// missing, or matches it with the wrapped matcher and caches a 404 Not Found
+// error.
+func (m *notFoundMatcher) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
+	if !m.features.enabled(FeatureNotFoundCache) {
+		return m.next.MatchIssue(ctx, issueKey) //nolint:wrapcheck // Want passthrough
+	}
+
+	stop := timeStage(ctx, stageCacheLookup)
+	err := m.cache.get(issueKey)
+	stop()
+	if err != nil {
+		countCacheHit(ctx)
+		explainCheck(ctx, "not_found_cache", issueKey, ExplainFail, "jira recently reported the issue missing")
+		return nil, err
+	}
+
+	result, err := m.next.MatchIssue(ctx, issueKey)
+	if code, ok := JiraStatus(err); ok && code == http.StatusNotFound {
+		m.cache.put(issueKey, err)
+	}
+	return result, err //nolint:wrapcheck // Want passthrough
+}
+
+// withNotFoundCache puts the cache of missing issues of s in front of m.
+func (s *snapshot) withNotFoundCache(m IssueMatcher) IssueMatcher {
+	if s.notFound == nil {
+		return m
+	}
+	return &notFoundMatcher{next: m, cache: s.notFound, features: s.features}
+}
+
+// NotFoundCacheStats returns the counters of the cache of issues Jira
+// reported missing since the last reload, and false when the cache is
+// disabled.
+func (j *JiraPlugin) NotFoundCacheStats() (NotFoundCacheStats, bool) {
+	s := j.current.Load()
+	if s == nil || s.notFound == nil {
+		return NotFoundCacheStats{}, false
+	}
+	return s.notFound.counters(), true
+}
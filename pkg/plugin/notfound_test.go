@@ -0,0 +1,135 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestNotFoundCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	c := newNotFoundCache(30*time.Second, 2)
+	c.now = func() time.Time { return now }
+
+	errMissing := errors.New("missing")
+	if err := c.get("ABCD-1"); err != nil {
+		t.Fatalf("got cached error %v for an unknown key", err)
+	}
+	c.put("ABCD-1", errMissing)
+	c.put("ABCD-2", errMissing)
+	if err := c.get("ABCD-1"); !errors.Is(err, errMissing) {
+		t.Errorf("got cached error %v, want %v", err, errMissing)
+	}
+
+	// ABCD-2 is the least recently used key.
+	c.put("ABCD-3", errMissing)
+	if err := c.get("ABCD-2"); err != nil {
+		t.Errorf("got cached error %v for an evicted key", err)
+	}
+	if err := c.get("ABCD-3"); err == nil {
+		t.Errorf("got no cached error for ABCD-3")
+	}
+
+	now = now.Add(30 * time.Second)
+	if err := c.get("ABCD-1"); err != nil {
+		t.Errorf("got cached error %v for an expired key", err)
+	}
+
+	want := NotFoundCacheStats{Hits: 2, Misses: 3, Evictions: 1, Entries: 1}
+	if got := c.counters(); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+}
+
+func TestNewNotFoundCache_Disabled(t *testing.T) {
+	t.Parallel()
+
+	if c := newNotFoundCache(0, 10); c != nil {
+		t.Errorf("got cache %v for a zero ttl, want nil", c)
+	}
+	if c := newNotFoundCache(time.Second, 0); c.size != defaultNotFoundCacheSize {
+		t.Errorf("got size %d, want %d", c.size, defaultNotFoundCacheSize)
+	}
+}
+
+func TestPlugin_NotFoundCache(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.NotFoundCacheTTL = time.Minute
+	newJira := func(cfg *PluginConfig) (*lazyValidator, error) {
+		v, err := NewValidator(cfg.JIRAEndpoint, cfg.Jql, cfg.JIRAAccount, "secrets")
+		return &lazyValidator{v: v}, err
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := newJiraPlugin(ctx, cfg, nil, newJira)
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	for i := 0; i < 3; i++ {
+		resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+			Justification: &jvspb.Justification{Category: "jira", Value: fakeJiraMissingIssue},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.GetValid() {
+			t.Fatalf("got valid response for a missing issue")
+		}
+	}
+	if got := f.issueCalls.Load(); got != 1 {
+		t.Errorf("got %d issue requests, want 1", got)
+	}
+
+	// Existing issues are never cached by it.
+	for i := 0; i < 2; i++ {
+		resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+			Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+		})
+		if err != nil || !resp.GetValid() {
+			t.Fatalf("unexpected result %v: %v", resp, err)
+		}
+	}
+	if got := f.issueCalls.Load(); got != 3 {
+		t.Errorf("got %d issue requests, want 3", got)
+	}
+
+	stats, ok := p.NotFoundCacheStats()
+	if !ok {
+		t.Fatal("got no stats from an enabled cache")
+	}
+	if want := (NotFoundCacheStats{Hits: 2, Misses: 3, Entries: 1}); stats != want {
+		t.Errorf("got stats %+v, want %+v", stats, want)
+	}
+
+	if err := p.Reload(ctx, cfg); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if stats, _ := p.NotFoundCacheStats(); stats.Entries != 0 {
+		t.Errorf("got %d entries after a reload, want none", stats.Entries)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// JustificationFormatKey treats the whole justification value as the issue
+	// key.
+	JustificationFormatKey = "key"
+
+	// JustificationFormatComposite treats the justification value as a list of
+	// issue keys separated by "/", e.g. "INC-123/CHG-456". Every key is
+	// validated.
+	JustificationFormatComposite = "composite"
+
+	// JustificationFormatJSON treats the justification value as a JSON object
+	// with an "issue" key and an optional "reason".
+	JustificationFormatJSON = "json"
+
+	// jiraRelatedIssueKeys is the key for the additional issue keys of a
+	// composite justification in the annotation map of the justification.
+	jiraRelatedIssueKeys = "jira_related_issue_keys"
+
+	// jiraJustificationReason is the key for the free text reason of a JSON
+	// justification in the annotation map of the justification.
+	jiraJustificationReason = "jira_justification_reason"
+
+	// compositeSeparator separates the issue keys of a composite justification.
+	compositeSeparator = "/"
+
+	// maxCompositeIssueKeys bounds the number of issue keys, and therefore Jira
+	// requests, of a composite justification.
+	maxCompositeIssueKeys = 5
+
+	// maxJustificationReasonLength is the maximum number of characters in the
+	// reason of a JSON justification.
+	maxJustificationReasonLength = 256
+)
+
+// ParsedJustification is the result of parsing a justification value.
+type ParsedJustification struct {
+	// IssueKey is the jira issue key to validate.
+	IssueKey string
+
+	// RelatedIssueKeys are additional jira issue keys. Each one is validated
+	// like IssueKey and recorded in the annotation map of the justification
+	// once all of them are valid.
+	RelatedIssueKeys []string
+
+	// ChangeIssueKey is the change ticket of a dual justification, it is
+	// validated against the change JQL instead of the JQL and recorded in
+	// the annotation map of the justification.
+	ChangeIssueKey string
+
+	// Annotation holds additional entries for the annotation map of the
+	// justification. It ends up in the signed token, so parsers must only put
+	// bounded, validated input here.
+	Annotation map[string]string
+}
+
+// JustificationParser extracts the jira issue key from a justification value.
+// An error returned by Parse is reported to the user as an invalid
+// justification.
+type JustificationParser interface {
+	Parse(value string) (*ParsedJustification, error)
+}
+
+// NewJustificationParser returns the parser for the given format. An empty
+// format returns the [IssueKeyParser].
+func NewJustificationParser(format string) (JustificationParser, error) {
+	switch format {
+	case "", JustificationFormatKey:
+		return &IssueKeyParser{}, nil
+	case JustificationFormatComposite:
+		return &CompositeParser{}, nil
+	case JustificationFormatJSON:
+		return &JSONParser{}, nil
+	case JustificationFormatDual:
+		return &DualParser{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported justification format %q", format)
+	}
+}
+
+// IssueKeyParser is the default parser, the value is the issue key.
+type IssueKeyParser struct{}
+
+// Parse returns the value as the issue key.
+func (p *IssueKeyParser) Parse(value string) (*ParsedJustification, error) {
+	return &ParsedJustification{IssueKey: value}, nil
+}
+
+// CompositeParser parses values like "INC-123/CHG-456". The first key is the
+// issue key, the remaining keys are related issue keys.
+type CompositeParser struct{}
+
+// Parse splits the value into issue keys.
+func (p *CompositeParser) Parse(value string) (*ParsedJustification, error) {
+	parts := strings.Split(value, compositeSeparator)
+	keys := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if k := strings.TrimSpace(part); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no issue key found in justification %q", value)
+	}
+	if len(keys) > maxCompositeIssueKeys {
+		return nil, fmt.Errorf("justification has %d issue keys, at most %d are allowed", len(keys), maxCompositeIssueKeys)
+	}
+
+	parsed := &ParsedJustification{IssueKey: keys[0]}
+	if len(keys) > 1 {
+		parsed.RelatedIssueKeys = keys[1:]
+	}
+	return parsed, nil
+}
+
+// JSONParser parses values like {"issue":"ABCD-123","reason":"rollback"}.
+type JSONParser struct{}
+
+// jsonJustification is the expected shape of a JSON justification value.
+type jsonJustification struct {
+	Issue  string `json:"issue"`
+	Reason string `json:"reason"`
+}
+
+// Parse decodes the value as JSON.
+func (p *JSONParser) Parse(value string) (*ParsedJustification, error) {
+	var j jsonJustification
+	if err := json.Unmarshal([]byte(value), &j); err != nil {
+		return nil, fmt.Errorf("failed to parse justification as JSON: %w", err)
+	}
+	if j.Issue == "" {
+		return nil, fmt.Errorf("missing \"issue\" in justification")
+	}
+
+	if n := utf8.RuneCountInString(j.Reason); n > maxJustificationReasonLength {
+		return nil, fmt.Errorf("reason has %d characters, at most %d are allowed", n, maxJustificationReasonLength)
+	}
+	if strings.IndexFunc(j.Reason, unicode.IsControl) >= 0 {
+		return nil, fmt.Errorf("reason must not contain control characters")
+	}
+
+	parsed := &ParsedJustification{IssueKey: j.Issue}
+	if j.Reason != "" {
+		parsed.Annotation = map[string]string{
+			jiraJustificationReason: j.Reason,
+		}
+	}
+	return parsed, nil
+}
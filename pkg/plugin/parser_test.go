@@ -0,0 +1,154 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestJustificationParsers(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		format  string
+		value   string
+		want    *ParsedJustification
+		wantErr string
+	}{
+		{
+			name:   "default_key",
+			format: "",
+			value:  "ABCD-123",
+			want:   &ParsedJustification{IssueKey: "ABCD-123"},
+		},
+		{
+			name:   "composite_single",
+			format: JustificationFormatComposite,
+			value:  "INC-123",
+			want:   &ParsedJustification{IssueKey: "INC-123"},
+		},
+		{
+			name:   "composite_multiple",
+			format: JustificationFormatComposite,
+			value:  "INC-123 / CHG-456/OPS-7",
+			want: &ParsedJustification{
+				IssueKey:         "INC-123",
+				RelatedIssueKeys: []string{"CHG-456", "OPS-7"},
+			},
+		},
+		{
+			name:    "composite_too_many",
+			format:  JustificationFormatComposite,
+			value:   "A-1/A-2/A-3/A-4/A-5/A-6",
+			wantErr: "justification has 6 issue keys, at most 5 are allowed",
+		},
+		{
+			name:    "composite_empty",
+			format:  JustificationFormatComposite,
+			value:   " / ",
+			wantErr: "no issue key found",
+		},
+		{
+			name:   "json_with_reason",
+			format: JustificationFormatJSON,
+			value:  `{"issue":"ABCD-123","reason":"rollback"}`,
+			want: &ParsedJustification{
+				IssueKey:   "ABCD-123",
+				Annotation: map[string]string{"jira_justification_reason": "rollback"},
+			},
+		},
+		{
+			name:    "json_reason_too_long",
+			format:  JustificationFormatJSON,
+			value:   `{"issue":"ABCD-123","reason":"` + strings.Repeat("ä", 257) + `"}`,
+			wantErr: "reason has 257 characters, at most 256 are allowed",
+		},
+		{
+			name:    "json_reason_control_characters",
+			format:  JustificationFormatJSON,
+			value:   `{"issue":"ABCD-123","reason":"rollback\nvalid=true"}`,
+			wantErr: "reason must not contain control characters",
+		},
+		{
+			name:    "json_missing_issue",
+			format:  JustificationFormatJSON,
+			value:   `{"reason":"rollback"}`,
+			wantErr: `missing "issue"`,
+		},
+		{
+			name:    "json_malformed",
+			format:  JustificationFormatJSON,
+			value:   `{`,
+			wantErr: "failed to parse justification as JSON",
+		},
+		{
+			name:   "dual",
+			format: JustificationFormatDual,
+			value:  `{"incident":"INC-123","change":"CHG-456"}`,
+			want: &ParsedJustification{
+				IssueKey:       "INC-123",
+				ChangeIssueKey: "CHG-456",
+			},
+		},
+		{
+			name:    "dual_missing_change",
+			format:  JustificationFormatDual,
+			value:   `{"incident":"INC-123"}`,
+			wantErr: `missing "change"`,
+		},
+		{
+			name:    "dual_missing_incident",
+			format:  JustificationFormatDual,
+			value:   `{"change":"CHG-456"}`,
+			wantErr: `missing "incident"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := NewJustificationParser(tc.format)
+			if err != nil {
+				t.Fatalf("failed to create parser: %v", err)
+			}
+
+			got, err := p.Parse(tc.value)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Parse() unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewJustificationParser_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewJustificationParser("xml")
+	if diff := testutil.DiffErrString(err, `unsupported justification format "xml"`); diff != "" {
+		t.Errorf(diff)
+	}
+}
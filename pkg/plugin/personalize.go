@@ -0,0 +1,79 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// requestorPlaceholder is replaced in a JQL by the identity of the
+// requestor as a quoted JQL string, e.g. "assignee = {{.Requestor}}". It
+// makes the decision depend on who asks, see [DecisionCache].
+const requestorPlaceholder = "{{.Requestor}}"
+
+// personalized reports whether the JQL depends on the requestor.
+func personalized(jql string) bool {
+	return strings.Contains(jql, requestorPlaceholder)
+}
+
+// personalizeJQL replaces the requestor placeholder in the JQL with the
+// requestor of the context, see [Validator.requestorUser]. The error wraps
+// ErrInvalidJustification when the JQL depends on the requestor and the
+// requestor is unknown.
+func (v *Validator) personalizeJQL(ctx context.Context, jql string) (string, error) {
+	if !personalized(jql) {
+		return jql, nil
+	}
+	r := requestorFromContext(ctx)
+	if r == nil {
+		return "", WithReason(fmt.Errorf("the JQL depends on the requestor, which is unknown: %w", ErrInvalidJustification), ErrorCodeRequestorUnknown)
+	}
+	user, err := v.requestorUser(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(jql, requestorPlaceholder, quoteJQL(user)), nil
+}
+
+// requestorUser returns the Jira user of the requestor as used in JQL: the
+// subject mapped with [WithIdentityMapper], then resolved to the account id
+// with [WithAccountIDs].
+func (v *Validator) requestorUser(ctx context.Context, r *Requestor) (string, error) {
+	user := r.Subject
+	if v.identities != nil {
+		var err error
+		if user, err = v.identities.JiraIdentity(ctx, r.Subject); err != nil {
+			return "", fmt.Errorf("failed to map the requestor to a jira user: %w", err)
+		}
+	}
+	if v.accounts == nil {
+		return user, nil
+	}
+	return v.accountID(ctx, user)
+}
+
+// parsableJQL replaces the requestor placeholder in the JQL with an empty
+// string, so that Jira can parse it without a requestor.
+func parsableJQL(jql string) string {
+	return strings.ReplaceAll(jql, requestorPlaceholder, quoteJQL(""))
+}
+
+// personalized reports whether any JQL of the config depends on the
+// requestor.
+func (cfg *PluginConfig) personalized() bool {
+	return personalized(cfg.Jql) || personalized(cfg.IssueTypeJql)
+}
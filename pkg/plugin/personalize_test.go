@@ -0,0 +1,142 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestPersonalizeJQL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		jql       string
+		requestor *Requestor
+		want      string
+		wantErr   string
+	}{
+		{
+			name: "not_personalized",
+			jql:  "project = JRA",
+			want: "project = JRA",
+		},
+		{
+			name:      "requestor",
+			jql:       "project = JRA AND (assignee = {{.Requestor}} OR reporter = {{.Requestor}})",
+			requestor: &Requestor{Subject: "jane@example.com"},
+			want:      `project = JRA AND (assignee = "jane@example.com" OR reporter = "jane@example.com")`,
+		},
+		{
+			name:      "quoted",
+			jql:       "assignee = {{.Requestor}}",
+			requestor: &Requestor{Subject: `jane" OR project = X OR "`},
+			want:      `assignee = "jane\" OR project = X OR \""`,
+		},
+		{
+			name:    "unknown_requestor",
+			jql:     "assignee = {{.Requestor}}",
+			wantErr: "the JQL depends on the requestor, which is unknown",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			if tc.requestor != nil {
+				ctx = WithRequestor(ctx, tc.requestor)
+			}
+			got, err := (&Validator{}).personalizeJQL(ctx, tc.jql)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got != tc.want {
+				t.Errorf("personalizeJQL(%q) got %q, want %q", tc.jql, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidator_MatchIssue_Personalized(t *testing.T) {
+	t.Parallel()
+
+	var gotJQL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		var req matchData
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		gotJQL = req.Jqls[0]
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL, "project = ABCD AND assignee = {{.Requestor}}", "test@test.com", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRequestor(context.Background(), &Requestor{Subject: "jane@example.com"})
+	if _, err := v.MatchIssue(ctx, "ABCD-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gotJQL, `project = ABCD AND assignee = "jane@example.com"`; got != want {
+		t.Errorf("got JQL %q, want %q", got, want)
+	}
+}
+
+func TestCachingMatcher_Personalized(t *testing.T) {
+	t.Parallel()
+
+	cfg := *testCacheConfig
+	cfg.Jql = "assignee = {{.Requestor}}"
+	c := openTestCache(t, filepath.Join(t.TempDir(), "decisions.db"), &cfg)
+	t.Cleanup(func() { c.Close() })
+
+	next := &countingMatcher{mockValidator: mockValidator{result: testMatch(1234)}}
+	m := &cachingMatcher{next: next, cache: c}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	jane := WithRequestor(ctx, &Requestor{Subject: "jane@example.com"})
+	joe := WithRequestor(ctx, &Requestor{Subject: "joe@example.com"})
+	for _, ctx := range []context.Context{jane, joe, jane, joe, ctx, ctx} {
+		if _, err := m.MatchIssue(ctx, "ABCD-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One match per requestor, validations without a requestor are never
+	// cached.
+	if got, want := next.calls, 4; got != want {
+		t.Errorf("got %d calls to Jira, want %d", got, want)
+	}
+}
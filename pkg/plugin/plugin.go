@@ -19,19 +19,25 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/url"
+	"log/slog"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
-	secretmanager "cloud.google.com/go/secretmanager/apiv1"
-	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
 )
 
 const (
-	// jiraCategory is the justification category this plugin will be validating.
+	// jiraCategory is the justification category this plugin validates unless
+	// configured otherwise, see [PluginConfig.Category].
 	jiraCategory = "jira"
 
 	// JiraIssueID is the key for the Jira Issue ID in the annotation map of the justification.
@@ -39,121 +45,1142 @@ const (
 
 	// jiraIssueURL is the key for the Jira Issue URL in the annotation map of the justification.
 	jiraIssueURL = "jira_issue_url"
+
+	// jiraInsecureTransport is the key marking the decisions made while the
+	// TLS certificate of Jira is not verified.
+	jiraInsecureTransport = "jira_insecure_transport"
+
+	// jiraErrorCode is the key for the machine readable reason of an invalid
+	// response in the annotation map of the justification.
+	jiraErrorCode = "jira_error_code"
+
+	// jiraCacheStale is the key marking the decisions made with an expired
+	// cached match while it is refreshed.
+	jiraCacheStale = "jira_cache_stale"
+)
+
+const (
+	// Category is the justification category this plugin validates.
+	Category = jiraCategory
+
+	// AnnotationIssueID is the annotation key for the Jira Issue ID.
+	AnnotationIssueID = jiraIssueID
+
+	// AnnotationIssueURL is the annotation key for the Jira Issue URL.
+	AnnotationIssueURL = jiraIssueURL
+
+	// AnnotationErrorCode is the annotation key for the machine readable
+	// reason of an invalid response, see [ErrorCodeWrongCategory].
+	AnnotationErrorCode = jiraErrorCode
+
+	// ErrorCodeWrongCategory is the [AnnotationErrorCode] of a request for a
+	// justification category other than [Category].
+	ErrorCodeWrongCategory = "wrong_category"
+
+	// AnnotationCacheStale is the annotation key set to "true" when the
+	// decision was made with an expired cached match, see
+	// [PluginConfig.CacheMaxStaleness].
+	AnnotationCacheStale = jiraCacheStale
 )
 
-// issueMatcher is the mockable interface for the convenience of testing.
-type issueMatcher interface {
+// IssueMatcher matches a Jira issue, by key, against the JQL and policies.
+// [Validator] implements it against Jira, the plugintest package has a test
+// double, see [WithIssueMatcher].
+type IssueMatcher interface {
 	MatchIssue(context.Context, string) (*MatchResult, error)
 }
 
 // JiraPlugin is the implementation of jvspb.Validator interface.
 type JiraPlugin struct {
-	validator    issueMatcher
+	// current is the configuration validations run with. It is loaded once
+	// per request, so a request sees a consistent configuration even when
+	// [JiraPlugin.Reload] replaces it concurrently.
+	current atomic.Pointer[snapshot]
+
+	// newJira creates the validator talking to Jira for a configuration.
+	newJira func(*PluginConfig) (*lazyValidator, error)
+
+	// opts are applied to every snapshot.
+	opts []Option
+
+	// auditSink receives every decision, it is nil when auditing is disabled.
+	auditSink AuditSink
+
+	// decisions streams every decision to the subscribers of
+	// [DecisionStreamMethod], it is nil when the stream is disabled.
+	decisions *decisionStream
+
+	// sampler captures a fraction of the decisions for review, it is nil
+	// when sampling is disabled.
+	sampler *decisionSampler
+
+	// revoker validates the accepted decisions again and publishes their
+	// revocations, it is nil when revocation is disabled.
+	revoker *revoker
+
+	// cache stores issue matches on disk or in Redis, it is nil when caching
+	// is disabled.
+	cache *DecisionCache
+
+	// redis holds the state shared with the other plugin replicas, it is nil
+	// unless the state backend is Redis.
+	redis *redisClient
+
+	// quota limits the validations of the category, it is nil when
+	// unlimited.
+	quota *quota
+
+	// requestorQuota limits the successful validations of each requestor,
+	// it is nil when unlimited.
+	requestorQuota *requestorQuota
+
+	// replay keeps the last failed validations with their Jira requests, it
+	// is nil when the replay log is disabled.
+	replay *replayRecorder
+
+	// recent keeps the last decisions for [JiraPlugin.Diagnostics], it is
+	// nil when disabled.
+	recent *recentDecisions
+
+	// candidate counts the evaluations of the candidate JQL across reloads.
+	candidate candidateCounter
+
+	// signingKey is the annotation signing key fetched from Secret Manager,
+	// it is empty when signing is disabled.
+	signingKey []byte
+
+	// bypassToken is the emergency bypass token fetched from Secret Manager,
+	// it is empty when disabled.
+	bypassToken string
+
+	// secrets is the Secret Manager client shared by the secret lookups of
+	// the plugin.
+	secrets *secretManager
+
+	// features are the feature flags gating subsystems, see
+	// [JiraPlugin.SetFeatureFlag].
+	features *featureFlags
+
+	// life tracks the validations in flight for [JiraPlugin.Close].
+	life lifecycle
+}
+
+// snapshot is an immutable view of the configuration a validation runs with.
+type snapshot struct {
+	// validator matches issues, it is jira or a cache in front of it.
+	validator IssueMatcher
+
+	// config is the configuration of the snapshot with the credentials of
+	// its URLs redacted, see [JiraPlugin.Diagnostics].
+	config *PluginConfig
+
+	// jira is the validator talking to Jira, it is nil in tests using a
+	// mock validator.
+	jira *lazyValidator
+
+	// change matches the change tickets of dual justifications, it is
+	// changeJira or a cache in front of it. Both are nil unless the
+	// justification format is dual.
+	change     IssueMatcher
+	changeJira *lazyValidator
+
 	uiData       *jvspb.UIData
 	issueBaseURL string
+
+	// category is the justification category validated, see
+	// [PluginConfig.Category]. It is jiraCategory when empty.
+	category string
+
+	// configHash is the [configHash] of config, see [JiraPlugin.WriteMetrics].
+	configHash uint64
+
+	// projectBaseURLs are the base URLs of the issue URL annotations of
+	// projects on other Jira sites, by upper case project key. Other
+	// projects use issueBaseURL.
+	projectBaseURLs map[string]string
+
+	// issueURLTemplate builds the issue URL annotation, the URL is
+	// "<issueBaseURL>/browse/<key>" when it is nil.
+	issueURLTemplate *template.Template
+
+	// parser extracts the issue key from the justification value, the
+	// [IssueKeyParser] is used when it is nil.
+	parser JustificationParser
+
+	// valueLimits bound the justification values, they are checked before
+	// the values are parsed.
+	valueLimits valueLimits
+
+	// candidate counts the evaluations of the candidate JQL.
+	candidate *candidateCounter
+
+	// freeze restricts validations during freeze windows, it is nil when
+	// there are none.
+	freeze *freezePolicy
+
+	// bypass lists the requestors whose justifications skip Jira, it is nil
+	// when empty.
+	bypass *bypassList
+
+	// bypassToken is the emergency bypass token, justifications
+	// "bypass:<token>" are rejected when it is empty.
+	bypassToken string
+
+	// notFound remembers the issues Jira recently reported missing, it is
+	// nil when disabled.
+	notFound *notFoundCache
+
+	// features are the feature flags of the plugin, shared by every
+	// snapshot.
+	features *featureFlags
+
+	// decisionCaches are the views of the decision cache for the JQL, the
+	// change JQL and the emergency JQL, in that order. It is empty when the
+	// cache is disabled, see [JiraPlugin.PurgeCache].
+	decisionCaches []*DecisionCache
+
+	// debugAnnotations adds the diagnostic annotations to the responses.
+	debugAnnotations bool
+
+	// messages are the user-facing rejection messages, nil to report the
+	// internal details.
+	messages *messageCatalog
+
+	// explainAnnotation adds the decision tree to the responses of the
+	// requests asking for it.
+	explainAnnotation bool
+
+	// insecureTransport annotates every response with
+	// jiraInsecureTransport, the TLS certificate of Jira is not verified.
+	insecureTransport bool
+
+	// wrongCategoryError fails requests for another category with
+	// [ErrWrongCategory] rather than an invalid response.
+	wrongCategoryError bool
+
+	// signer signs the annotations of valid responses, it is nil when
+	// signing is disabled.
+	signer *annotationSigner
+
+	// warmupProjects are the projects [JiraPlugin.Warmup] checks.
+	warmupProjects []string
+
+	// hooks run around every validation, see [WithValidationHooks].
+	hooks []ValidationHook
+}
+
+// Option customizes a [JiraPlugin].
+type Option func(*snapshot)
+
+// WithAnnotationSigningKey sets the key signing the annotations of valid
+// responses, it takes precedence over
+// [PluginConfig.AnnotationSigningKeySecretID].
+func WithAnnotationSigningKey(key []byte) Option {
+	return func(s *snapshot) {
+		s.signer = newAnnotationSigner(key)
+	}
 }
 
-// NewJiraPlugin creates a new JiraPlugin.
-func NewJiraPlugin(ctx context.Context, cfg *PluginConfig) (*JiraPlugin, error) {
-	apiToken, err := secretVersion(ctx, cfg.APITokenSecretID)
+// WithBypassToken sets the emergency bypass token, it takes precedence over
+// [PluginConfig.BypassTokenSecretID]. It must be at least 16 characters
+// long, a shorter token disables the bypass.
+func WithBypassToken(token string) Option {
+	return func(s *snapshot) {
+		s.bypassToken, _ = checkBypassToken(token) //nolint:errcheck // A short token disables the bypass
+	}
+}
+
+// WithIssueMatcher makes the plugin match issue keys with m instead of Jira,
+// e.g. a test double of the plugintest package in integration tests of the
+// JVS. The decision cache and the not found cache are not used, the change
+// and emergency JQLs still ask Jira.
+func WithIssueMatcher(m IssueMatcher) Option {
+	return func(s *snapshot) {
+		s.validator = m
+		s.jira = nil
+	}
+}
+
+// WithJustificationParser sets the parser for justification values, it takes
+// precedence over [PluginConfig.JustificationFormat].
+func WithJustificationParser(p JustificationParser) Option {
+	return func(s *snapshot) {
+		s.parser = p
+	}
+}
+
+// NewJiraPlugin creates a new JiraPlugin. The API token is fetched from
+// Secret Manager on first use, call [JiraPlugin.Warmup] to fetch it and
+// connect to Jira before the first validation.
+func NewJiraPlugin(ctx context.Context, cfg *PluginConfig, opts ...Option) (*JiraPlugin, error) {
+	secrets := newSecretManager(cfg)
+	newJira := func(cfg *PluginConfig) (*lazyValidator, error) {
+		return &lazyValidator{
+			newValidator: func(ctx context.Context) (*Validator, error) {
+				return newValidatorFromSecrets(ctx, cfg, secrets.access)
+			},
+		}, nil
+	}
+	return newJiraPlugin(ctx, cfg, secrets, newJira, opts...)
+}
+
+// NewJiraPluginWithToken creates a new JiraPlugin with the given API token
+// instead of fetching it from Secret Manager. cfg.APITokenSecretID and
+// cfg.AccountSecretID are ignored, the account is cfg.JIRAAccount.
+// With endpoint discovery enabled, the endpoint is discovered on first use.
+func NewJiraPluginWithToken(ctx context.Context, cfg *PluginConfig, apiToken string, opts ...Option) (*JiraPlugin, error) {
+	newJira := func(cfg *PluginConfig) (*lazyValidator, error) {
+		if cfg.EndpointDiscovery {
+			return &lazyValidator{
+				newValidator: func(ctx context.Context) (*Validator, error) {
+					return newConfigValidator(ctx, cfg, cfg.JIRAAccount, apiToken)
+				},
+			}, nil
+		}
+		v, err := NewValidator(cfg.JIRAEndpoint, cfg.Jql, cfg.JIRAAccount, apiToken, cfg.validatorOptions()...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to instantiate validator: %w", err)
+		}
+		return &lazyValidator{v: v}, nil
+	}
+	return newJiraPlugin(ctx, cfg, newSecretManager(cfg), newJira, opts...)
+}
+
+func newJiraPlugin(ctx context.Context, cfg *PluginConfig, secrets *secretManager, newJira func(*PluginConfig) (*lazyValidator, error), opts ...Option) (*JiraPlugin, error) {
+	j := &JiraPlugin{
+		newJira: newJira,
+		opts:    opts,
+		quota:   newQuota(cfg.category(), cfg.QuotaRate, cfg.QuotaBurst, cfg.QuotaMaxConcurrent),
+		replay:  newReplayRecorder(cfg.ReplayBufferSize),
+		recent:  newRecentDecisions(cfg.DiagnosticDecisions),
+		secrets: secrets,
+
+		features:       newFeatureFlags(cfg.FeatureFlags),
+		decisions:      newDecisionStream(cfg.DecisionStream),
+		requestorQuota: newRequestorQuota(cfg.RequestorQuota, cfg.RequestorQuotaWindow, cfg.RequestorQuotaMode),
+	}
+
+	if cfg.AnnotationSigningKeySecretID != "" {
+		key, err := secrets.access(ctx, cfg.AnnotationSigningKeySecretID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch annotation signing key: %w", err)
+		}
+		j.signingKey = []byte(key)
+	}
+	if cfg.BypassTokenSecretID != "" {
+		token, err := secrets.access(ctx, cfg.BypassTokenSecretID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch bypass token: %w", err)
+		}
+		if j.bypassToken, err = checkBypassToken(token); err != nil {
+			return nil, fmt.Errorf("invalid bypass token: %w: %w", err, ErrInvalidConfig)
+		}
+	}
+
+	s, err := j.newSnapshot(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch API token: %w", err)
+		return nil, err
+	}
+
+	if cfg.AuditSyslogAddress != "" {
+		j.auditSink, err = NewSyslogSink(ctx, cfg.AuditSyslogNetwork, cfg.AuditSyslogAddress, cfg.AuditFormat, cfg.AuditSyslogSDID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to instantiate audit sink: %w: %w", err, ErrInvalidConfig)
+		}
+	}
+
+	if j.sampler, err = newDecisionSampler(ctx, cfg.SampleRate, cfg.SampleDestination); err != nil {
+		j.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate decision sampler: %w", err)
+	}
+	j.revoker = newRevoker(ctx, cfg)
+
+	if cfg.StateBackend == StateBackendRedis {
+		var password string
+		if cfg.RedisPasswordSecretID != "" {
+			if password, err = secrets.access(ctx, cfg.RedisPasswordSecretID); err != nil {
+				j.Close(ctx)
+				return nil, fmt.Errorf("failed to fetch redis password: %w", err)
+			}
+		}
+		if j.redis, err = newRedisClient(cfg.RedisURL, password); err != nil {
+			j.Close(ctx)
+			return nil, fmt.Errorf("%w: %w", err, ErrInvalidConfig)
+		}
+		if j.requestorQuota != nil {
+			j.requestorQuota.counter = &redisRequestorCounter{client: j.redis, window: j.requestorQuota.window}
+		}
+	}
+
+	if cfg.cacheEnabled() {
+		var cacheOpts []CacheOption
+		if len(cfg.CacheEncryptionKeySecretIDs) > 0 {
+			keys := make([][]byte, 0, len(cfg.CacheEncryptionKeySecretIDs))
+			for _, id := range cfg.CacheEncryptionKeySecretIDs {
+				key, err := secrets.access(ctx, id)
+				if err != nil {
+					j.Close(ctx)
+					return nil, fmt.Errorf("failed to fetch cache encryption key: %w", err)
+				}
+				keys = append(keys, []byte(key))
+			}
+			cacheOpts = append(cacheOpts, WithCacheEncryptionKeys(keys...))
+		}
+		if j.redis != nil {
+			j.cache, err = newDecisionCache(cfg.CacheTTL, cfg, cacheOpts...)
+			if err == nil {
+				j.cache.store = &redisCacheStore{client: j.redis}
+			}
+		} else {
+			j.cache, err = OpenDecisionCache(cfg.CachePath, cfg.CacheTTL, cfg, cacheOpts...)
+		}
+		if err != nil {
+			j.Close(ctx)
+			return nil, fmt.Errorf("failed to open decision cache: %w", err)
+		}
+		j.useCache(s, cfg)
+	}
+
+	if cfg.FaultInjectionRate > 0 {
+		logging.FromContext(ctx).WarnContext(ctx, "fault injection enabled, jira requests fail on purpose",
+			"rate", cfg.FaultInjectionRate)
+	}
+	if cfg.InsecureSkipVerify {
+		logging.FromContext(ctx).WarnContext(ctx, "INSECURE: the tls certificate of jira is not verified, "+
+			"a man in the middle can forge any decision. Only use this with a test instance.",
+			"endpoint", cfg.JIRAEndpoint)
 	}
 
-	v, err := NewValidator(cfg.JIRAEndpoint, cfg.Jql, cfg.JIRAAccount, apiToken)
+	j.current.Store(s)
+	if j.revoker != nil {
+		j.revoker.start(ctx, func(ctx context.Context) {
+			if _, err := j.RevalidateDecisions(ctx); err != nil {
+				logging.FromContext(ctx).WarnContext(ctx, "failed to validate accepted decisions again", "error", err)
+			}
+		})
+	}
+	return j, nil
+}
+
+// newSnapshot creates the snapshot for cfg, without the decision cache.
+func (j *JiraPlugin) newSnapshot(cfg *PluginConfig) (*snapshot, error) {
+	jira, err := j.newJira(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to instantiate validator: %w", err)
+		return nil, fmt.Errorf("%w: %w", err, ErrInvalidConfig)
 	}
 
-	d := &jvspb.UIData{
-		DisplayName: cfg.DisplayName,
-		Hint:        cfg.Hint,
+	parser, err := NewJustificationParser(cfg.JustificationFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate justification parser: %w: %w", err, ErrInvalidConfig)
 	}
 
-	return &JiraPlugin{
-		validator:    v,
-		uiData:       d,
+	s := &snapshot{
+		jira:   jira,
+		config: redactedConfig(cfg),
+		uiData: &jvspb.UIData{
+			DisplayName: cfg.DisplayName,
+			Hint:        cfg.Hint,
+		},
 		issueBaseURL: cfg.IssueBaseURL,
-	}, nil
+		category:     cfg.category(),
+		parser:       parser,
+		valueLimits:  valueLimits{maxLength: cfg.MaxValueLength, charset: cfg.ValueCharset},
+		candidate:    &j.candidate,
+		bypass:       newBypassList(cfg.BypassRequestors),
+		bypassToken:  j.bypassToken,
+		notFound:     newNotFoundCache(cfg.NotFoundCacheTTL, cfg.NotFoundCacheSize),
+		features:     j.features,
+
+		debugAnnotations:   cfg.DebugAnnotations,
+		explainAnnotation:  cfg.ExplainAnnotation,
+		wrongCategoryError: cfg.WrongCategoryError,
+		insecureTransport:  cfg.InsecureSkipVerify,
+		signer:             newAnnotationSigner(j.signingKey),
+		warmupProjects:     cfg.WarmupProjects,
+	}
+	s.configHash = configHash(s.config)
+	s.validator = s.withNotFoundCache(jira)
+	s.issueURLTemplate, err = parseIssueURLTemplate(cfg.IssueURLTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", err, ErrInvalidConfig)
+	}
+	s.projectBaseURLs, err = parseProjectBaseURLs(cfg.ProjectBaseURLs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", err, ErrInvalidConfig)
+	}
+	if cfg.MessageLocale != "" {
+		s.messages, err = newMessageCatalog(cfg.MessageLocale, splitFieldConstraints(cfg.Messages))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse messages: %w: %w", err, ErrInvalidConfig)
+		}
+	}
+	s.freeze, err = newFreezePolicy(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse freeze windows: %w: %w", err, ErrInvalidConfig)
+	}
+	if cfg.JustificationFormat == JustificationFormatDual {
+		s.changeJira, err = j.newJira(changeConfig(cfg))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", err, ErrInvalidConfig)
+		}
+		s.change = s.withNotFoundCache(s.changeJira)
+	}
+	if s.freeze != nil && cfg.FreezeJql != "" {
+		emergency, err := j.newJira(emergencyConfig(cfg))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", err, ErrInvalidConfig)
+		}
+		s.freeze.validator = s.withNotFoundCache(emergency)
+		s.freeze.jira = emergency
+	}
+
+	for _, opt := range j.opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// useCache puts the decision cache in front of the validators of s.
+func (j *JiraPlugin) useCache(s *snapshot, cfg *PluginConfig) {
+	cached := func(next IssueMatcher, scope *PluginConfig) IssueMatcher {
+		c := j.cache.forConfig(scope)
+		s.decisionCaches = append(s.decisionCaches, c)
+		return s.withNotFoundCache(&cachingMatcher{
+			next:     next,
+			cache:    c,
+			maxStale: cfg.CacheMaxStaleness,
+			life:     &j.life,
+			features: j.features,
+		})
+	}
+	if s.jira != nil {
+		s.validator = cached(s.jira, cfg)
+	}
+	if s.changeJira != nil {
+		s.change = cached(s.changeJira, changeConfig(cfg))
+	}
+	if s.freeze != nil && s.freeze.jira != nil {
+		s.freeze.validator = cached(s.freeze.jira, emergencyConfig(cfg))
+	}
+}
+
+// Reload replaces the validation configuration, i.e. the Jira endpoint,
+// account, JQL, parsing, annotation and UI settings, and the configured
+// feature flags. Validations in flight finish with the configuration they
+// started with. The audit, cache, quota, replay and signing key settings of
+// cfg are ignored, they only take effect on restart. Feature flag overrides
+// are kept.
+func (j *JiraPlugin) Reload(ctx context.Context, cfg *PluginConfig) error {
+	s, err := j.newSnapshot(cfg)
+	if err != nil {
+		return err
+	}
+	if j.cache != nil {
+		j.useCache(s, cfg)
+	}
+	j.features.configure(cfg.FeatureFlags)
+
+	j.current.Store(s)
+	logging.FromContext(ctx).InfoContext(ctx, "reloaded configuration",
+		"config_hash", fmt.Sprintf("%012x", s.configHash))
+	return nil
+}
+
+// Warmup fetches the API token and connects to Jira, so the first validation
+// does not pay for it. Then it checks concurrently that Jira accepts the
+// credentials, that the JQL parses, and that the account can browse the
+// [PluginConfig.WarmupProjects]. It returns the errors of all failed checks.
+// A deadline of ctx bounds all of them together.
+func (j *JiraPlugin) Warmup(ctx context.Context) error {
+	s := j.current.Load()
+	if s.jira == nil {
+		return nil
+	}
+	v, err := s.jira.get(ctx)
+	if err != nil {
+		return err
+	}
+	// The connections are kept alive for the following validations.
+	if err := runChecks(ctx, warmupChecks(v, s.warmupProjects)); err != nil {
+		return fmt.Errorf("failed to connect to jira: %w", err)
+	}
+	return nil
+}
+
+// Close shuts the plugin down. New validations are rejected with [ErrClosed]
+// and the ones in flight are waited for until ctx is done. Then it releases
+// the resources held by the plugin: it closes the connections to Jira, the
+// Secret Manager client and the decision cache, and flushes and closes the
+// audit sink. Closing a closed plugin is a no-op.
+func (j *JiraPlugin) Close(ctx context.Context) error {
+	// A round of revalidation in progress would hold up the shutdown.
+	if j.revoker != nil {
+		j.revoker.close()
+	}
+	first, merr := j.life.shutdown(ctx)
+	if !first {
+		return nil
+	}
+
+	if s := j.current.Load(); s != nil {
+		if stats := j.APIStats(); len(stats) > 0 {
+			logging.FromContext(ctx).InfoContext(ctx, "jira api usage since the last reload", "endpoints", stats)
+		}
+		if stats, ok := j.NotFoundCacheStats(); ok {
+			logging.FromContext(ctx).InfoContext(ctx, "missing issue cache usage since the last reload",
+				"hits", stats.Hits, "misses", stats.Misses, "evictions", stats.Evictions)
+		}
+		for _, lv := range s.validators() {
+			if err := lv.close(ctx); err != nil {
+				merr = errors.Join(merr, err)
+			}
+		}
+	}
+	if j.secrets != nil {
+		if err := j.secrets.Close(); err != nil {
+			merr = errors.Join(merr, err)
+		}
+	}
+	if j.decisions != nil {
+		j.decisions.close()
+	}
+	if j.auditSink != nil {
+		if err := j.auditSink.Close(); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("failed to close audit sink: %w", err))
+		}
+	}
+	if j.sampler != nil {
+		if err := j.sampler.close(); err != nil {
+			merr = errors.Join(merr, err)
+		}
+	}
+	if j.cache != nil {
+		if err := j.cache.Close(); err != nil {
+			merr = errors.Join(merr, err)
+		}
+	}
+	if j.redis != nil {
+		if err := j.redis.close(); err != nil {
+			merr = errors.Join(merr, fmt.Errorf("failed to close redis connections: %w", err))
+		}
+	}
+	return merr
 }
 
 // Validate returns the validation result.
 func (j *JiraPlugin) Validate(ctx context.Context, req *jvspb.ValidateJustificationRequest) (*jvspb.ValidateJustificationResponse, error) {
-	if got, want := req.GetJustification().GetCategory(), jiraCategory; got != want {
-		return invalidErrResponse(fmt.Sprintf("failed to perform validation, expected category %q to be %q", got, want)), nil
+	if !j.life.acquire() {
+		return nil, status.Errorf(codes.Unavailable, ErrClosed.Error())
+	}
+	defer j.life.release()
+
+	// The request runs with a single snapshot, from the validation to the
+	// annotations and the audit record, even if the plugin is reloaded
+	// meanwhile.
+	s := j.current.Load()
+	start := time.Now()
+	var collector *replayCollector
+	if j.replay != nil {
+		ctx, collector = withReplayCollector(ctx)
+	}
+	// The stats are collected for the diagnostic annotations and for the
+	// timing breakdown logged at debug level.
+	logger := logging.FromContext(ctx)
+	debugAnnotations := s.debugAnnotations
+	var stats *validationStats
+	if debugAnnotations || logger.Enabled(ctx, slog.LevelDebug) {
+		ctx, stats = withValidationStats(ctx)
+	}
+
+	// Sampled decisions are captured with their explanation, whether or not
+	// the request asked for it.
+	explain := s.explainAnnotation && req.GetJustification().GetAnnotation()[jiraExplainRequest] == "true"
+	sample := j.sampler != nil && j.features.enabled(FeatureDecisionSampling) && j.sampler.sample()
+	var explanation *Explanation
+	if explain || sample {
+		ctx, explanation = withExplanation(ctx)
+	}
+
+	resp, err := j.validate(ctx, s, req)
+	if explanation != nil && err == nil {
+		explanation.finish(resp)
+	}
+	if explain && err == nil {
+		if err := explanation.annotate(resp); err != nil {
+			logger.WarnContext(ctx, "failed to add explanation", "error", err)
+		}
+	}
+	if s.insecureTransport && err == nil {
+		if resp.Annotation == nil {
+			resp.Annotation = make(map[string]string)
+		}
+		resp.Annotation[jiraInsecureTransport] = "true"
+	}
+	if s.signer != nil && err == nil && resp.GetValid() {
+		s.signer.sign(resp)
 	}
+	if stats != nil {
+		latency := time.Since(start)
+		if debugAnnotations && err == nil {
+			stats.annotate(resp, latency)
+		}
+		stats.logTimings(ctx, logger, latency)
+	}
+	d := j.recordDecision(ctx, s, req, resp, err)
+	if sample {
+		j.sampler.add(newDecisionSample(d, explanation))
+	}
+	if j.revoker != nil {
+		j.revoker.track(d, requestorFromContext(ctx))
+	}
+	if collector != nil && (err != nil || !resp.GetValid()) {
+		j.recordReplay(s, req, resp, err, collector)
+	}
+	if errors.Is(err, ErrQuotaExceeded) {
+		return nil, status.Errorf(codes.ResourceExhausted, err.Error())
+	}
+	if errors.Is(err, ErrWrongCategory) {
+		return nil, status.Errorf(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, ErrJiraUnreachable) {
+		return nil, unavailableStatus(err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, err.Error())
+	}
+	return resp, nil
+}
+
+// ValidateValue validates a justification value of the category of the plugin
+// like [JiraPlugin.Validate], but does not log or audit the decision and returns
+// plain errors instead of gRPC status errors. It is meant for callers using
+// the plugin as a library.
+func (j *JiraPlugin) ValidateValue(ctx context.Context, value string) (*jvspb.ValidateJustificationResponse, error) {
+	if !j.life.acquire() {
+		return nil, ErrClosed
+	}
+	defer j.life.release()
+
+	s := j.current.Load()
+	return j.validate(ctx, s, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{
+			Category: s.justificationCategory(),
+			Value:    value,
+		},
+	})
+}
+
+// justificationCategory returns the justification category validated.
+func (s *snapshot) justificationCategory() string {
+	if s.category == "" {
+		return jiraCategory
+	}
+	return s.category
+}
+
+// validate performs the validation with the configuration of s within the
+// validation hooks, without recording the decision. An error is returned
+// when the validation could not be performed.
+func (j *JiraPlugin) validate(ctx context.Context, s *snapshot, req *jvspb.ValidateJustificationRequest) (*jvspb.ValidateJustificationResponse, error) {
+	if len(s.hooks) == 0 {
+		return j.validateSnapshot(ctx, s, req)
+	}
+	return s.validateWithHooks(ctx, req, func(ctx context.Context) (*jvspb.ValidateJustificationResponse, error) {
+		return j.validateSnapshot(ctx, s, req)
+	})
+}
+
+// validateSnapshot performs the validation with the configuration of s.
+//
+// The checks that need no network run first, in order: the category, the
+// empty value, the length and characters of the value, the bypass list,
+// parsing the value, and the format of every issue key. A request failing any
+// of them never reaches Jira. Only then the freeze windows and the quotas
+// apply, and the issues are matched through the decision cache and finally
+// Jira. Only successful validations count towards the requestor quota.
+func (j *JiraPlugin) validateSnapshot(ctx context.Context, s *snapshot, req *jvspb.ValidateJustificationRequest) (*jvspb.ValidateJustificationResponse, error) {
+	if got, want := req.GetJustification().GetCategory(), s.justificationCategory(); got != want {
+		msg := fmt.Sprintf("failed to perform validation, expected category %q to be %q", got, want)
+		explainCheck(ctx, "category", got, ExplainFail, msg)
+		err := WithReason(fmt.Errorf("%s: %w", msg, ErrWrongCategory), ErrorCodeWrongCategory)
+		if s.wrongCategoryError {
+			return nil, err
+		}
+		resp := s.reject(ctx, ErrorCodeWrongCategory, msg, nil)
+		resp.Annotation = map[string]string{jiraErrorCode: Reason(err)}
+		return resp, nil
+	}
+	explainCheck(ctx, "category", s.justificationCategory(), ExplainPass, "")
 
 	if req.GetJustification().GetValue() == "" {
-		return invalidErrResponse("empty justification value"), nil
+		explainCheck(ctx, "value", "", ExplainFail, "empty justification value")
+		return s.reject(ctx, ErrorCodeEmptyJustification, "empty justification value", nil), nil
+	}
+	if code, err := s.valueLimits.check(req.GetJustification().GetValue()); err != nil {
+		explainCheck(ctx, "value", "", ExplainFail, err.Error())
+		return s.reject(ctx, code, err.Error(), map[string]string{"max": strconv.Itoa(s.valueLimits.max())}), nil
 	}
 
-	result, err := j.validateWithJiraEndpoint(ctx, req.GetJustification().GetValue())
+	if value := req.GetJustification().GetValue(); bypassTokenValue(value) {
+		r := requestorFromContext(ctx)
+		if !matchBypassToken(value, s.bypassToken) {
+			logging.FromContext(ctx).WarnContext(ctx, "emergency bypass rejected, wrong bypass token or none configured",
+				"requestor", explainRequestor(r))
+			explainCheck(ctx, "bypass_token", "", ExplainFail, "wrong bypass token or none configured")
+			return s.reject(ctx, ErrorCodeBypassRejected, "emergency bypass rejected", nil), nil
+		}
+		explainCheck(ctx, "bypass_token", "", ExplainPass, "emergency bypass token accepted, jira is not asked")
+		return bypassTokenResponse(r), nil
+	}
+
+	if s.bypass != nil {
+		r := requestorFromContext(ctx)
+		if entry, ok := s.bypass.match(r); ok {
+			explainCheck(ctx, "bypass", r.Subject, ExplainPass, fmt.Sprintf("requestor matches bypass entry %q, jira is not asked", entry))
+			return bypassResponse(r, entry), nil
+		}
+		explainCheck(ctx, "bypass", explainRequestor(r), ExplainSkipped, "requestor is not on the bypass list")
+	}
+
+	parser := s.parser
+	if parser == nil {
+		parser = &IssueKeyParser{}
+	}
+	parsed, err := parser.Parse(req.GetJustification().GetValue())
 	if err != nil {
-		if errors.Is(err, errInvalidJustification) {
-			return invalidErrResponse(err.Error()),
-				nil
+		explainCheck(ctx, "parse", req.GetJustification().GetValue(), ExplainFail, err.Error())
+		return s.reject(ctx, ErrorCodeInvalidFormat, fmt.Sprintf("failed to parse justification: %s", err), nil), nil
+	}
+	explainCheck(ctx, "parse", req.GetJustification().GetValue(), ExplainPass, explainParsed(parsed))
+	if key, err := checkIssueKeys(parsed); err != nil {
+		explainCheck(ctx, "issue_keys", "", ExplainFail, err.Error())
+		return s.reject(ctx, ErrorCodeInvalidIssueKey, err.Error(), map[string]string{"issue": key}), nil
+	}
+
+	validator := s.validator
+	var freezeEnd time.Time
+	if s.freeze != nil {
+		if end, ok := s.freeze.end(s.freeze.now()); ok {
+			if s.freeze.validator == nil {
+				msg := fmt.Sprintf("deploy freeze in effect until %s, no justification is accepted", end.Format(time.RFC3339))
+				explainCheck(ctx, "freeze", "", ExplainFail, msg)
+				return s.reject(ctx, ErrorCodeDeployFreeze, msg, map[string]string{"until": end.Format(time.RFC3339)}), nil
+			}
+			validator, freezeEnd = s.freeze.validator, end
+			explainCheck(ctx, "freeze", "", ExplainPass, fmt.Sprintf("deploy freeze in effect until %s, issues are matched against the emergency JQL",
+				end.Format(time.RFC3339)))
 		} else {
-			return nil, status.Errorf(codes.Internal, err.Error())
+			explainCheck(ctx, "freeze", "", ExplainPass, "no deploy freeze in effect")
 		}
 	}
-	issueID := strconv.Itoa(result.MatchedIssues[0])
-	// The format for the Jira issue URL follows the pattern "https://your-domain.atlassian.net/browse/<issueKey>".
-	issueURL, err := url.JoinPath(j.issueBaseURL, "browse", req.GetJustification().GetValue())
+	matchErr := func(err error, issueKey string) (*jvspb.ValidateJustificationResponse, error) {
+		if !freezeEnd.IsZero() && errors.Is(err, ErrInvalidJustification) {
+			until := freezeEnd.Format(time.RFC3339)
+			err = fmt.Errorf("deploy freeze in effect until %s, only issues matching the emergency JQL are accepted: %w", until, err)
+			if s.messages != nil {
+				return s.reject(ctx, ErrorCodeDeployFreeze, err.Error(), map[string]string{"issue": issueKey, "until": until}), nil
+			}
+		}
+		return s.rejectErr(ctx, err, issueKey)
+	}
+
+	if j.quota != nil {
+		release, err := j.quota.acquire()
+		if err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "validation rejected by quota",
+				"category", j.quota.category,
+				"error", err,
+				"rejected_total", j.quota.exceeded.Load())
+			explainCheck(ctx, "quota", "", ExplainFail, err.Error())
+			return nil, err
+		}
+		defer release()
+		explainCheck(ctx, "quota", "", ExplainPass, "")
+	}
+	var quotaWarning string
+	if j.requestorQuota != nil {
+		logger := logging.FromContext(ctx)
+		warning, err := j.requestorQuota.check(ctx, requestorFromContext(ctx))
+		subject := explainRequestor(requestorFromContext(ctx))
+		if errors.Is(err, ErrQuotaExceeded) {
+			logger.WarnContext(ctx, "validation rejected by requestor quota", "error", err)
+			explainCheck(ctx, "requestor_quota", subject, ExplainFail, err.Error())
+			return nil, err
+		}
+		switch {
+		case err != nil:
+			// The quota fails open, it must not stop validations.
+			logger.WarnContext(ctx, "failed to check requestor quota", "error", err)
+			explainCheck(ctx, "requestor_quota", subject, ExplainError, err.Error())
+		case warning != "":
+			logger.WarnContext(ctx, "requestor over quota", "warning", warning)
+			quotaWarning = warning
+			explainCheck(ctx, "requestor_quota", subject, ExplainPass, warning)
+		default:
+			explainCheck(ctx, "requestor_quota", subject, ExplainPass, "")
+		}
+	}
+
+	// Every issue is validated even when one is rejected, so that all the
+	// failures are reported at once.
+	var failures []string
+	var failureCode string
+	reject := func(resp *jvspb.ValidateJustificationResponse, err error) error {
+		if err != nil {
+			return err
+		}
+		failures = append(failures, resp.GetError()...)
+		if failureCode == "" {
+			failureCode = resp.GetAnnotation()[jiraErrorCode]
+		}
+		return nil
+	}
+
+	result, err := s.explainMatch(ctx, "issue", validator, parsed.IssueKey)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, err.Error())
+		if err := reject(matchErr(err, parsed.IssueKey)); err != nil {
+			return nil, err
+		}
+	}
+	// Related issue keys are recorded in the annotation, so they must be
+	// valid too.
+	for _, key := range parsed.RelatedIssueKeys {
+		if _, err := s.explainMatch(ctx, "related_issue", validator, key); err != nil {
+			if err := reject(matchErr(err, key)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var change *MatchResult
+	if parsed.ChangeIssueKey != "" {
+		if s.change == nil {
+			explainCheck(ctx, "change_issue", parsed.ChangeIssueKey, ExplainFail, "change tickets are not supported by the justification format")
+			return s.reject(ctx, ErrorCodeChangeUnsupported, "change tickets are not supported by the justification format", nil), nil
+		}
+		if change, err = s.explainMatch(ctx, "change_issue", s.change, parsed.ChangeIssueKey); err != nil {
+			if err := reject(s.rejectErr(ctx, fmt.Errorf("change ticket: %w", err), parsed.ChangeIssueKey)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(failures) > 0 {
+		resp := &jvspb.ValidateJustificationResponse{
+			Valid: false,
+			Error: failures,
+		}
+		if failureCode != "" {
+			resp.Annotation = map[string]string{jiraErrorCode: failureCode}
+		}
+		return resp, nil
+	}
+	defer timeStage(ctx, stageAnnotation)()
+	match := result.Matches[0]
+	issueID := strconv.Itoa(match.MatchedIssues[0])
+	// The format for the Jira issue URL follows the pattern "https://your-domain.atlassian.net/browse/<issueKey>"
+	// on the site of the project of the issue, unless a template is configured.
+	issueURL, err := buildIssueURL(s.issueURLTemplate, issueBaseURL(s.projectBaseURLs, s.issueBaseURL, parsed.IssueKey), parsed.IssueKey, issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	annotation := make(map[string]string, len(parsed.Annotation)+3)
+	for k, v := range parsed.Annotation {
+		annotation[k] = v
+	}
+	annotation[jiraIssueID] = issueID
+	annotation[jiraIssueURL] = issueURL
+	for name, value := range result.IssueFields {
+		annotation[annotationFieldPrefix+name] = value
+	}
+	if len(parsed.RelatedIssueKeys) > 0 {
+		annotation[jiraRelatedIssueKeys] = strings.Join(parsed.RelatedIssueKeys, ",")
+	}
+	warnings := match.Errors
+	if change != nil {
+		changeID := strconv.Itoa(change.Matches[0].MatchedIssues[0])
+		changeURL, err := buildIssueURL(s.issueURLTemplate, issueBaseURL(s.projectBaseURLs, s.issueBaseURL, parsed.ChangeIssueKey), parsed.ChangeIssueKey, changeID)
+		if err != nil {
+			return nil, err
+		}
+		annotation[jiraChangeIssueID] = changeID
+		annotation[jiraChangeIssueURL] = changeURL
+		warnings = append(slices.Clip(warnings), change.Matches[0].Errors...)
+	}
+	if result.ArchivedAt != "" {
+		annotation[jiraIssueArchived] = result.ArchivedAt
+	}
+	if result.SnapshotHash != "" {
+		annotation[jiraIssueSnapshotHash] = result.SnapshotHash
+	}
+	if !freezeEnd.IsZero() {
+		annotation[jiraFreezeWindowEnd] = freezeEnd.UTC().Format(time.RFC3339)
+	}
+	if result.Stale || (change != nil && change.Stale) {
+		annotation[jiraCacheStale] = "true"
+	}
+	if j.requestorQuota != nil {
+		if err := j.requestorQuota.record(ctx, requestorFromContext(ctx)); err != nil {
+			logging.FromContext(ctx).WarnContext(ctx, "failed to record requestor quota", "error", err)
+		}
+		if quotaWarning != "" {
+			warnings = append(slices.Clip(warnings), quotaWarning)
+		}
 	}
 
 	return &jvspb.ValidateJustificationResponse{
-		Valid:   true,
-		Warning: result.Errors,
-		Annotation: map[string]string{
-			jiraIssueID:  issueID,
-			jiraIssueURL: issueURL,
-		},
+		Valid:      true,
+		Warning:    warnings,
+		Annotation: annotation,
 	}, nil
 }
 
+// checkIssueKeys checks that every issue key of the parsed justification
+// looks like an issue key or id, so that Jira is not asked for values that
+// cannot be one. It returns the first key that is not one with the error.
+func checkIssueKeys(parsed *ParsedJustification) (string, error) {
+	keys := append([]string{parsed.IssueKey}, parsed.RelatedIssueKeys...)
+	if parsed.ChangeIssueKey != "" {
+		keys = append(keys, parsed.ChangeIssueKey)
+	}
+	for _, key := range keys {
+		if !issueKeyOrIDPattern.MatchString(key) {
+			return key, fmt.Errorf("%q is not a jira issue key", key)
+		}
+	}
+	return "", nil
+}
+
+// matchErrResponse converts an error from matching an issue into an invalid
+// response with an error per policy failure, or returns it when the issue
+// could not be matched.
+func matchErrResponse(err error) (*jvspb.ValidateJustificationResponse, error) {
+	if errors.Is(err, ErrInvalidJustification) {
+		return &jvspb.ValidateJustificationResponse{
+			Valid: false,
+			Error: failureMessages(err),
+		}, nil
+	}
+	return nil, err
+}
+
+// explainMatch matches the issue like [snapshot.validateWithJiraEndpoint]
+// and records it as a check of the explanation of ctx.
+func (s *snapshot) explainMatch(ctx context.Context, check string, validator IssueMatcher, issueKey string) (*MatchResult, error) {
+	ctx, done := explainStart(ctx, check, issueKey)
+	result, err := s.validateWithJiraEndpoint(ctx, validator, issueKey)
+	done(explainErr(err))
+	return result, err
+}
+
 // Validates the justification with the jira endpoint.
-// TODO(#46): move this function to j.validator.MatchIssue.
-func (j *JiraPlugin) validateWithJiraEndpoint(ctx context.Context, justificationValue string) (*Match, error) {
-	result, err := j.validator.MatchIssue(ctx, justificationValue)
+// TODO(#46): move this function to s.validator.MatchIssue.
+func (s *snapshot) validateWithJiraEndpoint(ctx context.Context, validator IssueMatcher, justificationValue string) (*MatchResult, error) {
+	result, err := validator.MatchIssue(ctx, justificationValue)
 	if err != nil {
 		return nil, fmt.Errorf("failed to match jira issue with justification %q: %w", justificationValue, err)
 	}
+	if s.candidate != nil {
+		s.candidate.compare(ctx, justificationValue, result)
+	}
 
 	if len(result.Matches) == 0 || len(result.Matches[0].MatchedIssues) == 0 {
-		return nil, fmt.Errorf("no matched jira issue for justification %q: %w", justificationValue, errInvalidJustification)
+		return nil, WithReason(fmt.Errorf("no matched jira issue for justification %q: %w", justificationValue, ErrInvalidJustification), ErrorCodeNoMatch)
 	}
 
 	// There is only one JQL and one issueKey, only one matching result is expected.
 	if len(result.Matches[0].MatchedIssues) > 1 {
-		return nil, fmt.Errorf("ambiguous justification %q, multiple matching jira issues are found %v: %w", justificationValue, result.Matches[0].MatchedIssues, errInvalidJustification)
+		return nil, WithReason(fmt.Errorf("ambiguous justification %q, multiple matching jira issues are found %v: %w",
+			justificationValue, result.Matches[0].MatchedIssues, ErrInvalidJustification), ErrorCodeAmbiguousIssue)
 	}
 
-	return result.Matches[0], nil
+	return result, nil
 }
 
-func (j *JiraPlugin) GetUIData(ctx context.Context, req *jvspb.GetUIDataRequest) (*jvspb.UIData, error) {
-	return j.uiData, nil
-}
+// recordDecision logs the decision, forwards it to the audit sink and returns
+// it. Failing to audit does not fail the validation.
+func (j *JiraPlugin) recordDecision(ctx context.Context, s *snapshot, req *jvspb.ValidateJustificationRequest, resp *jvspb.ValidateJustificationResponse, err error) *Decision {
+	d := &Decision{
+		Time:     time.Now(),
+		Category: req.GetJustification().GetCategory(),
+		Value:    redactBypassToken(s.valueLimits.truncate(req.GetJustification().GetValue())),
 
-// secretVersion returns the secret data as a string.
-func secretVersion(ctx context.Context, secretVersionName string) (string, error) {
-	client, err := secretmanager.NewClient(ctx)
+		InsecureTransport: s.insecureTransport,
+	}
+	if r := requestorFromContext(ctx); r != nil {
+		d.Requestor = r.Subject
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to set up secret manager client: %w", err)
+		d.Errors = []string{err.Error()}
+	} else {
+		d.Valid = resp.GetValid()
+		d.Errors = resp.GetError()
+		d.Annotation = resp.GetAnnotation()
+		d.Bypassed = d.Annotation[jiraValidationBypassed] == "true"
+		d.BypassToken = d.Annotation[jiraBypassToken] == "true"
 	}
-	defer client.Close()
 
-	// Fetch secret version.
-	resp, err := client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
-		Name: secretVersionName,
-	})
+	logger := logging.FromContext(ctx)
+	logger.InfoContext(ctx, "validation decision",
+		"category", d.Category,
+		"value", d.Value,
+		"requestor", d.Requestor,
+		"valid", d.Valid,
+		"bypassed", d.Bypassed,
+		"insecure_transport", d.InsecureTransport,
+		"errors", d.Errors)
+	if d.BypassToken {
+		logger.ErrorContext(ctx, "EMERGENCY: jira validation bypassed with the bypass token",
+			"requestor", d.Requestor)
+	} else if d.Bypassed {
+		logger.WarnContext(ctx, "jira validation bypassed",
+			"requestor", d.Requestor,
+			"value", d.Value)
+	}
+
+	if j.recent != nil {
+		j.recent.add(d)
+	}
+	if j.decisions != nil && j.features.enabled(FeatureDecisionStream) {
+		j.decisions.publish(d)
+	}
+	if j.auditSink != nil {
+		if err := j.auditSink.Emit(ctx, d); err != nil {
+			logger.ErrorContext(ctx, "failed to emit audit event", "error", err)
+		}
+	}
+	return d
+}
+
+// recordReplay adds a failed validation to the replay log.
+func (j *JiraPlugin) recordReplay(s *snapshot, req *jvspb.ValidateJustificationRequest, resp *jvspb.ValidateJustificationResponse, err error, c *replayCollector) {
+	r := &ReplayRecord{
+		Time:      time.Now(),
+		Category:  req.GetJustification().GetCategory(),
+		Value:     redactBypassToken(s.valueLimits.truncate(req.GetJustification().GetValue())),
+		Errors:    resp.GetError(),
+		Exchanges: c.list(),
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to access API token from secret manager: %w", err)
+		r.Errors = []string{err.Error()}
 	}
+	j.replay.add(r)
+}
+
+// RateLimit returns the rate limit Jira reported with the last response that
+// had rate limit headers, or nil when there was none since the last reload.
+func (j *JiraPlugin) RateLimit() *RateLimit {
+	s := j.current.Load()
+	if s.jira == nil {
+		return nil
+	}
+	if v := s.jira.peek(); v != nil {
+		return v.RateLimit()
+	}
+	return nil
+}
 
-	return string(resp.GetPayload().GetData()), nil
+func (j *JiraPlugin) GetUIData(ctx context.Context, req *jvspb.GetUIDataRequest) (*jvspb.UIData, error) {
+	return j.current.Load().uiData, nil
 }
 
 func invalidErrResponse(errStr string) *jvspb.ValidateJustificationResponse {
@@ -162,3 +1189,77 @@ func invalidErrResponse(errStr string) *jvspb.ValidateJustificationResponse {
 		Error: []string{errStr},
 	}
 }
+
+// lazyValidator creates the [Validator] on first use. Creation is retried on
+// the next use when it fails, e.g. because Secret Manager is unavailable.
+type lazyValidator struct {
+	newValidator func(context.Context) (*Validator, error)
+
+	mu sync.Mutex
+	v  *Validator
+}
+
+// get returns the validator, creating it when needed.
+func (l *lazyValidator) get(ctx context.Context) (*Validator, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.v != nil {
+		return l.v, nil
+	}
+	v, err := l.newValidator(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate validator: %w", err)
+	}
+	l.v = v
+	return v, nil
+}
+
+// validators returns the Jira validators of the snapshot that are
+// configured.
+func (s *snapshot) validators() []*lazyValidator {
+	var out []*lazyValidator
+	for _, lv := range []*lazyValidator{s.jira, s.changeJira} {
+		if lv != nil {
+			out = append(out, lv)
+		}
+	}
+	if s.freeze != nil && s.freeze.jira != nil {
+		out = append(out, s.freeze.jira)
+	}
+	return out
+}
+
+// peek returns the validator, or nil when it was not created yet.
+func (l *lazyValidator) peek() *Validator {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.v
+}
+
+// close closes the validator when it was created.
+func (l *lazyValidator) close(ctx context.Context) error {
+	if v := l.peek(); v != nil {
+		return v.Close(ctx)
+	}
+	return nil
+}
+
+// MatchIssue matches the issue with the validator.
+func (l *lazyValidator) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
+	v, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.MatchIssue(ctx, issueKey)
+}
+
+// matchIssueSince matches the issue with the validator unless it did not
+// change since the version, see [Validator.matchIssueSince].
+func (l *lazyValidator) matchIssueSince(ctx context.Context, issueKey string, since *IssueVersion) (*MatchResult, error) {
+	v, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.matchIssueSince(ctx, issueKey, since)
+}
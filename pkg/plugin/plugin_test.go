@@ -17,7 +17,9 @@ package plugin
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -33,18 +35,32 @@ import (
 type mockValidator struct {
 	result *MatchResult
 	err    error
+
+	// keyErrs overrides err for individual issue keys.
+	keyErrs map[string]error
 }
 
 func (m *mockValidator) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
+	if err, ok := m.keyErrs[issueKey]; ok {
+		return nil, err
+	}
 	return m.result, m.err
 }
 
+// newTestPlugin returns a plugin serving the snapshot.
+func newTestPlugin(s *snapshot) *JiraPlugin {
+	p := &JiraPlugin{}
+	p.current.Store(s)
+	return p
+}
+
 func TestPlugin_Validate(t *testing.T) {
 	t.Parallel()
 
 	cases := []struct {
 		name      string
 		validator *mockValidator
+		parser    JustificationParser
 		req       *jvspb.ValidateJustificationRequest
 		want      *jvspb.ValidateJustificationResponse
 		wantErr   string
@@ -54,7 +70,7 @@ func TestPlugin_Validate(t *testing.T) {
 			req: &jvspb.ValidateJustificationRequest{
 				Justification: &jvspb.Justification{
 					Category: "jira",
-					Value:    "ABCD",
+					Value:    "ABCD-1",
 				},
 			},
 			validator: &mockValidator{
@@ -72,7 +88,65 @@ func TestPlugin_Validate(t *testing.T) {
 				Warning: []string{},
 				Annotation: map[string]string{
 					"jira_issue_id":  "1234",
-					"jira_issue_url": "https://example.atlassian.net/browse/ABCD",
+					"jira_issue_url": "https://example.atlassian.net/browse/ABCD-1",
+				},
+			},
+		},
+		{
+			name: "stale_cached_match",
+			req: &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{
+					Category: "jira",
+					Value:    "ABCD-1",
+				},
+			},
+			validator: &mockValidator{
+				result: &MatchResult{
+					Matches: []*Match{
+						{
+							MatchedIssues: []int{1234},
+							Errors:        []string{},
+						},
+					},
+					Stale: true,
+				},
+			},
+			want: &jvspb.ValidateJustificationResponse{
+				Valid:   true,
+				Warning: []string{},
+				Annotation: map[string]string{
+					"jira_issue_id":    "1234",
+					"jira_issue_url":   "https://example.atlassian.net/browse/ABCD-1",
+					"jira_cache_stale": "true",
+				},
+			},
+		},
+		{
+			name: "annotation_fields",
+			req: &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{
+					Category: "jira",
+					Value:    "ABCD-1",
+				},
+			},
+			validator: &mockValidator{
+				result: &MatchResult{
+					Matches: []*Match{
+						{
+							MatchedIssues: []int{1234},
+							Errors:        []string{},
+						},
+					},
+					IssueFields: map[string]string{"summary": "Roll back"},
+				},
+			},
+			want: &jvspb.ValidateJustificationResponse{
+				Valid:   true,
+				Warning: []string{},
+				Annotation: map[string]string{
+					"jira_issue_id":      "1234",
+					"jira_issue_url":     "https://example.atlassian.net/browse/ABCD-1",
+					"jira_field_summary": "Roll back",
 				},
 			},
 		},
@@ -81,7 +155,7 @@ func TestPlugin_Validate(t *testing.T) {
 			req: &jvspb.ValidateJustificationRequest{
 				Justification: &jvspb.Justification{
 					Category: "github",
-					Value:    "ABCD",
+					Value:    "ABCD-1",
 				},
 			},
 			validator: &mockValidator{
@@ -94,14 +168,18 @@ func TestPlugin_Validate(t *testing.T) {
 					},
 				},
 			},
-			want: invalidErrResponse("failed to perform validation, expected category \"github\" to be \"jira\""),
+			want: &jvspb.ValidateJustificationResponse{
+				Valid:      false,
+				Error:      []string{"failed to perform validation, expected category \"github\" to be \"jira\""},
+				Annotation: map[string]string{"jira_error_code": "wrong_category"},
+			},
 		},
 		{
 			name: "empty_matches",
 			req: &jvspb.ValidateJustificationRequest{
 				Justification: &jvspb.Justification{
 					Category: "jira",
-					Value:    "ABCD",
+					Value:    "ABCD-1",
 				},
 			},
 			validator: &mockValidator{
@@ -109,14 +187,14 @@ func TestPlugin_Validate(t *testing.T) {
 					Matches: []*Match{},
 				},
 			},
-			want: invalidErrResponse("no matched jira issue for justification \"ABCD\": invalid justification"),
+			want: invalidErrResponse("no matched jira issue for justification \"ABCD-1\": invalid justification"),
 		},
 		{
 			name: "empty_matchesIssue",
 			req: &jvspb.ValidateJustificationRequest{
 				Justification: &jvspb.Justification{
 					Category: "jira",
-					Value:    "ABCD",
+					Value:    "ABCD-1",
 				},
 			},
 			validator: &mockValidator{
@@ -129,7 +207,7 @@ func TestPlugin_Validate(t *testing.T) {
 					},
 				},
 			},
-			want: invalidErrResponse("no matched jira issue for justification \"ABCD\": invalid justification"),
+			want: invalidErrResponse("no matched jira issue for justification \"ABCD-1\": invalid justification"),
 		},
 		{
 			name: "empty_value",
@@ -155,34 +233,34 @@ func TestPlugin_Validate(t *testing.T) {
 			req: &jvspb.ValidateJustificationRequest{
 				Justification: &jvspb.Justification{
 					Category: "jira",
-					Value:    "ABCD",
+					Value:    "ABCD-1",
 				},
 			},
 			validator: &mockValidator{
-				err: fmt.Errorf("non match: %w", errInvalidJustification),
+				err: fmt.Errorf("non match: %w", ErrInvalidJustification),
 			},
-			want: invalidErrResponse("failed to match jira issue with justification \"ABCD\": non match: invalid justification"),
+			want: invalidErrResponse("failed to match jira issue with justification \"ABCD-1\": non match: invalid justification"),
 		},
 		{
 			name: "match_error",
 			req: &jvspb.ValidateJustificationRequest{
 				Justification: &jvspb.Justification{
 					Category: "jira",
-					Value:    "ABCD",
+					Value:    "ABCD-1",
 				},
 			},
 			validator: &mockValidator{
 				err: fmt.Errorf("unexpected error"),
 			},
 			want:    nil,
-			wantErr: status.Errorf(codes.Internal, "failed to match jira issue with justification \"ABCD\": unexpected error").Error(),
+			wantErr: status.Errorf(codes.Internal, "failed to match jira issue with justification \"ABCD-1\": unexpected error").Error(),
 		},
 		{
 			name: "multiple_matches",
 			req: &jvspb.ValidateJustificationRequest{
 				Justification: &jvspb.Justification{
 					Category: "jira",
-					Value:    "ABCD",
+					Value:    "ABCD-1",
 				},
 			},
 			validator: &mockValidator{
@@ -195,7 +273,99 @@ func TestPlugin_Validate(t *testing.T) {
 					},
 				},
 			},
-			want: invalidErrResponse("ambiguous justification \"ABCD\", multiple matching jira issues are found [1234 5678 6784]: invalid justification"),
+			want: invalidErrResponse("ambiguous justification \"ABCD-1\", multiple matching jira issues are found [1234 5678 6784]: invalid justification"),
+		},
+		{
+			name: "composite_justification",
+			req: &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{
+					Category: "jira",
+					Value:    "INC-1/CHG-2",
+				},
+			},
+			parser: &CompositeParser{},
+			validator: &mockValidator{
+				result: &MatchResult{
+					Matches: []*Match{
+						{
+							MatchedIssues: []int{1234},
+							Errors:        []string{},
+						},
+					},
+				},
+			},
+			want: &jvspb.ValidateJustificationResponse{
+				Valid:   true,
+				Warning: []string{},
+				Annotation: map[string]string{
+					"jira_issue_id":           "1234",
+					"jira_issue_url":          "https://example.atlassian.net/browse/INC-1",
+					"jira_related_issue_keys": "CHG-2",
+				},
+			},
+		},
+		{
+			name: "composite_invalid_related_issue",
+			req: &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{
+					Category: "jira",
+					Value:    "INC-1/CHG-2",
+				},
+			},
+			parser: &CompositeParser{},
+			validator: &mockValidator{
+				result: &MatchResult{
+					Matches: []*Match{
+						{
+							MatchedIssues: []int{1234},
+							Errors:        []string{},
+						},
+					},
+				},
+				keyErrs: map[string]error{
+					"CHG-2": fmt.Errorf("non match: %w", ErrInvalidJustification),
+				},
+			},
+			want: invalidErrResponse("failed to match jira issue with justification \"CHG-2\": non match: invalid justification"),
+		},
+		{
+			name: "composite_all_failures",
+			req: &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{
+					Category: "jira",
+					Value:    "INC-1/CHG-2",
+				},
+			},
+			parser: &CompositeParser{},
+			validator: &mockValidator{
+				keyErrs: map[string]error{
+					"INC-1": fmt.Errorf("jira issue \"INC-1\" rejected: %w", &policyError{failures: []error{
+						fmt.Errorf("issue priority Low is below the minimum High: %w", ErrInvalidJustification),
+						fmt.Errorf("no match for the JQL: %w", ErrInvalidJustification),
+					}}),
+					"CHG-2": fmt.Errorf("non match: %w", ErrInvalidJustification),
+				},
+			},
+			want: &jvspb.ValidateJustificationResponse{
+				Valid: false,
+				Error: []string{
+					"failed to match jira issue with justification \"INC-1\": jira issue \"INC-1\" rejected: issue priority Low is below the minimum High: invalid justification",
+					"failed to match jira issue with justification \"INC-1\": jira issue \"INC-1\" rejected: no match for the JQL: invalid justification",
+					"failed to match jira issue with justification \"CHG-2\": non match: invalid justification",
+				},
+			},
+		},
+		{
+			name: "unparsable_justification",
+			req: &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{
+					Category: "jira",
+					Value:    "not json",
+				},
+			},
+			parser:    &JSONParser{},
+			validator: &mockValidator{},
+			want:      invalidErrResponse("failed to parse justification: failed to parse justification as JSON: invalid character 'o' in literal null (expecting 'u')"),
 		},
 	}
 
@@ -205,10 +375,11 @@ func TestPlugin_Validate(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			p := &JiraPlugin{
+			p := newTestPlugin(&snapshot{
 				validator:    tc.validator,
 				issueBaseURL: "https://example.atlassian.net",
-			}
+				parser:       tc.parser,
+			})
 
 			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
 			got, err := p.Validate(ctx, tc.req)
@@ -252,9 +423,9 @@ func TestPlugin_GetUIData(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 
-			p := &JiraPlugin{
+			p := newTestPlugin(&snapshot{
 				uiData: tc.uiData,
-			}
+			})
 
 			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
 			got, err := p.GetUIData(ctx, tc.req)
@@ -267,3 +438,569 @@ func TestPlugin_GetUIData(t *testing.T) {
 		})
 	}
 }
+
+type fakeAuditSink struct {
+	decisions []*Decision
+}
+
+func (s *fakeAuditSink) Emit(ctx context.Context, d *Decision) error {
+	s.decisions = append(s.decisions, d)
+	return nil
+}
+
+func (s *fakeAuditSink) Close() error {
+	return nil
+}
+
+func TestPlugin_Validate_Audit(t *testing.T) {
+	t.Parallel()
+
+	sink := &fakeAuditSink{}
+	p := newTestPlugin(&snapshot{
+		validator: &mockValidator{
+			result: &MatchResult{
+				Matches: []*Match{{MatchedIssues: []int{1234}}},
+			},
+		},
+		issueBaseURL: "https://example.atlassian.net",
+	})
+	p.auditSink = sink
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	if _, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []*Decision{
+		{
+			Category: "jira",
+			Value:    "ABCD-1",
+			Valid:    true,
+			Annotation: map[string]string{
+				"jira_issue_id":  "1234",
+				"jira_issue_url": "https://example.atlassian.net/browse/ABCD-1",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, sink.decisions, cmpopts.IgnoreFields(Decision{}, "Time")); diff != "" {
+		t.Errorf("audited decisions (-want,+got):\n%s", diff)
+	}
+}
+
+func TestPlugin_Validate_QuotaExceeded(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPlugin(&snapshot{
+		validator: &mockValidator{
+			result: &MatchResult{Matches: []*Match{{MatchedIssues: []int{1}, Errors: []string{}}}},
+		},
+		issueBaseURL: "https://example.atlassian.net",
+	})
+	p.quota = newQuota(jiraCategory, 0.001, 1, 0)
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	req := &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	}
+	if _, err := p.Validate(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := p.Validate(ctx, req)
+	if got, want := status.Code(err), codes.ResourceExhausted; got != want {
+		t.Errorf("got code %s, want %s: %v", got, want, err)
+	}
+
+	// Invalid requests are rejected before the quota is consulted.
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira"},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if resp.GetValid() {
+		t.Errorf("expected empty justification to be invalid")
+	}
+}
+
+func TestPlugin_Validate_WrongCategoryError(t *testing.T) {
+	t.Parallel()
+
+	validator := &mockValidator{}
+	p := newTestPlugin(&snapshot{
+		validator:          validator,
+		issueBaseURL:       "https://example.atlassian.net",
+		wrongCategoryError: true,
+	})
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	_, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "github", Value: "ABCD-1"},
+	})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("got code %s, want %s: %v", got, want, err)
+	}
+}
+
+func TestPlugin_ValidateValue(t *testing.T) {
+	t.Parallel()
+
+	sink := &fakeAuditSink{}
+	p := newTestPlugin(&snapshot{
+		validator:    &mockValidator{err: fmt.Errorf("unexpected error")},
+		issueBaseURL: "https://example.atlassian.net",
+	})
+	p.auditSink = sink
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	_, err := p.ValidateValue(ctx, "ABCD-1")
+	if diff := testutil.DiffErrString(err, `failed to match jira issue with justification "ABCD-1": unexpected error`); diff != "" {
+		t.Errorf(diff)
+	}
+	if _, ok := status.FromError(err); ok {
+		t.Errorf("ValidateValue() got gRPC status error %v, want a plain error", err)
+	}
+	if len(sink.decisions) > 0 {
+		t.Errorf("ValidateValue() audited %d decisions, want none", len(sink.decisions))
+	}
+}
+
+func TestLazyValidator(t *testing.T) {
+	t.Parallel()
+
+	var calls int
+	l := &lazyValidator{
+		newValidator: func(ctx context.Context) (*Validator, error) {
+			calls++
+			if calls == 1 {
+				return nil, fmt.Errorf("secret manager unavailable")
+			}
+			return NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA", "test@test.com", "secrets")
+		},
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	_, err := l.get(ctx)
+	if diff := testutil.DiffErrString(err, "secret manager unavailable"); diff != "" {
+		t.Errorf(diff)
+	}
+
+	// A failure is retried, a success is memoized.
+	for i := 0; i < 2; i++ {
+		if _, err := l.get(ctx); err != nil {
+			t.Fatalf("failed to get validator: %v", err)
+		}
+	}
+	if got, want := calls, 2; got != want {
+		t.Errorf("got %d validators created, want %d", got, want)
+	}
+}
+
+func TestPlugin_Warmup(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeJira(t)
+	cfg := f.config()
+
+	var created int
+	jira := &lazyValidator{
+		newValidator: func(ctx context.Context) (*Validator, error) {
+			created++
+			return NewValidator(cfg.JIRAEndpoint, cfg.Jql, cfg.JIRAAccount, "secrets")
+		},
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := newJiraPlugin(ctx, cfg, nil, func(*PluginConfig) (*lazyValidator, error) { return jira, nil })
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	if created != 0 {
+		t.Errorf("validator created before first use")
+	}
+
+	if err := p.Warmup(ctx); err != nil {
+		t.Fatalf("failed to warm up: %v", err)
+	}
+	if got := f.myselfCalls.Load(); got != 1 {
+		t.Errorf("got %d myself requests, want 1", got)
+	}
+	if got := f.parseCalls.Load(); got != 1 {
+		t.Errorf("got %d parse requests, want 1", got)
+	}
+
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	})
+	if err != nil || !resp.GetValid() {
+		t.Fatalf("unexpected result %v: %v", resp, err)
+	}
+	if created != 1 {
+		t.Errorf("got %d validators created, want 1", created)
+	}
+}
+
+// TestPlugin_Validate_NoJiraForInvalidRequests guards the order of the
+// checks in validate: requests failing a check that needs no network must
+// never reach Jira.
+func TestPlugin_Validate_NoJiraForInvalidRequests(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.JustificationFormat = JustificationFormatComposite
+
+	p, err := NewJiraPluginWithToken(context.Background(), cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	cases := []struct {
+		name     string
+		category string
+		value    string
+		wantErr  string
+	}{
+		{
+			name:     "wrong_category",
+			category: "explanation",
+			value:    "ABCD-1",
+			wantErr:  `failed to perform validation, expected category "explanation" to be "jira"`,
+		},
+		{
+			name:    "empty_value",
+			wantErr: "empty justification value",
+		},
+		{
+			name:    "unparsable",
+			value:   "A-1/B-2/C-3/D-4/E-5/F-6",
+			wantErr: "failed to parse justification: justification has 6 issue keys, at most 5 are allowed",
+		},
+		{
+			name:    "not_a_key",
+			value:   "rollback of yesterday's release",
+			wantErr: `"rollback of yesterday's release" is not a jira issue key`,
+		},
+		{
+			name:    "related_not_a_key",
+			value:   "ABCD-1/see slack",
+			wantErr: `"see slack" is not a jira issue key`,
+		},
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	for _, tc := range cases {
+		category := tc.category
+		if category == "" {
+			category = jiraCategory
+		}
+		resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+			Justification: &jvspb.Justification{Category: category, Value: tc.value},
+		})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if diff := cmp.Diff([]string{tc.wantErr}, resp.GetError()); resp.GetValid() || diff != "" {
+			t.Errorf("%s: got valid %t, unexpected errors (-want,+got):\n%s", tc.name, resp.GetValid(), diff)
+		}
+	}
+
+	f.assertCalls(t, 0)
+	if got := f.myselfCalls.Load(); got != 0 {
+		t.Errorf("got %d myself requests, want 0", got)
+	}
+}
+
+func TestPlugin_Warmup_Projects(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeJira(t)
+	cfg := f.config()
+	cfg.WarmupProjects = []string{"INC", fakeJiraHiddenProject}
+
+	newJira := func(cfg *PluginConfig) (*lazyValidator, error) {
+		return &lazyValidator{
+			newValidator: func(ctx context.Context) (*Validator, error) {
+				return NewValidator(cfg.JIRAEndpoint, cfg.Jql, cfg.JIRAAccount, "secrets")
+			},
+		}, nil
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := newJiraPlugin(ctx, cfg, nil, newJira)
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+
+	err = p.Warmup(ctx)
+	if diff := testutil.DiffErrString(err, `project HIDDEN: account cannot browse project "HIDDEN"`); diff != "" {
+		t.Errorf(diff)
+	}
+	if got := f.permissionCalls.Load(); got != 2 {
+		t.Errorf("got %d permission requests, want 2", got)
+	}
+}
+
+func TestPlugin_ConsistentSnapshot(t *testing.T) {
+	t.Parallel()
+
+	snapshotFor := func(id int, baseURL string) *snapshot {
+		return &snapshot{
+			validator: &mockValidator{
+				result: &MatchResult{Matches: []*Match{{MatchedIssues: []int{id}, Errors: []string{}}}},
+			},
+			issueBaseURL: baseURL,
+		}
+	}
+	a := snapshotFor(1, "https://a.example.com")
+	b := snapshotFor(2, "https://b.example.com")
+	want := map[string]string{
+		"1": "https://a.example.com/browse/ABCD-1",
+		"2": "https://b.example.com/browse/ABCD-1",
+	}
+
+	p := newTestPlugin(a)
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	// Swap the snapshots while validating concurrently, every response must
+	// come from a single snapshot.
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		for i := 0; i < 1000; i++ {
+			if i%2 == 0 {
+				p.current.Store(b)
+			} else {
+				p.current.Store(a)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				resp, err := p.ValidateValue(ctx, "ABCD-1")
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				id := resp.GetAnnotation()[jiraIssueID]
+				if got := resp.GetAnnotation()[jiraIssueURL]; got != want[id] {
+					t.Errorf("issue %s got url %q, want %q", id, got, want[id])
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	<-doneCh
+}
+
+// reloadingMatcher matches every issue, and swaps the snapshot of the plugin
+// for next while doing so, like a reload in the middle of a validation.
+type reloadingMatcher struct {
+	p    *JiraPlugin
+	next *snapshot
+}
+
+func (m *reloadingMatcher) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
+	m.p.current.Store(m.next)
+	return &MatchResult{Matches: []*Match{{MatchedIssues: []int{1234}}}}, nil
+}
+
+// newReloadingPlugin returns a plugin validating with the snapshot, which is
+// replaced by next while the issue is matched.
+func newReloadingPlugin(s, next *snapshot) *JiraPlugin {
+	p := newTestPlugin(s)
+	s.validator = &reloadingMatcher{p: p, next: next}
+	next.validator = s.validator
+	return p
+}
+
+func TestPlugin_Validate_ReloadDuringRequest(t *testing.T) {
+	t.Parallel()
+
+	sink := &fakeAuditSink{}
+	p := newReloadingPlugin(
+		&snapshot{issueBaseURL: "https://example.atlassian.net", insecureTransport: true},
+		&snapshot{issueBaseURL: "https://example.atlassian.net"},
+	)
+	p.auditSink = sink
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The annotations and the audit record come from the snapshot the
+	// request was validated with.
+	if got := resp.GetAnnotation()[jiraInsecureTransport]; got != "true" {
+		t.Errorf("got %s annotation %q, want true", jiraInsecureTransport, got)
+	}
+	if len(sink.decisions) != 1 || !sink.decisions[0].InsecureTransport {
+		t.Errorf("got audited decisions %v, want one with an insecure transport", sink.decisions)
+	}
+}
+
+func TestPlugin_Validate_SignsWithValidatingSnapshot(t *testing.T) {
+	t.Parallel()
+
+	oldKey, newKey := []byte("old-signing-key"), []byte("new-signing-key")
+	p := newReloadingPlugin(
+		&snapshot{issueBaseURL: "https://example.atlassian.net", signer: newAnnotationSigner(oldKey)},
+		&snapshot{issueBaseURL: "https://example.atlassian.net", signer: newAnnotationSigner(newKey)},
+	)
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := VerifyAnnotations(oldKey, resp.GetAnnotation()); err != nil {
+		t.Errorf("VerifyAnnotations() with the key of the validating snapshot got error: %v", err)
+	}
+}
+
+func TestPlugin_Validate_AuditsWithValidatingLimits(t *testing.T) {
+	t.Parallel()
+
+	sink := &fakeAuditSink{}
+	p := newReloadingPlugin(
+		&snapshot{issueBaseURL: "https://example.atlassian.net"},
+		&snapshot{issueBaseURL: "https://example.atlassian.net", valueLimits: valueLimits{maxLength: 4}},
+	)
+	p.auditSink = sink
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	if _, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sink.decisions) != 1 {
+		t.Fatalf("got %d audited decisions, want 1", len(sink.decisions))
+	}
+	if got, want := sink.decisions[0].Value, "ABCD-1"; got != want {
+		t.Errorf("got audited value %q, want %q", got, want)
+	}
+}
+
+func TestPlugin_Validate_DebugAnnotationsOfValidatingSnapshot(t *testing.T) {
+	t.Parallel()
+
+	p := newReloadingPlugin(
+		&snapshot{issueBaseURL: "https://example.atlassian.net", debugAnnotations: true},
+		&snapshot{issueBaseURL: "https://example.atlassian.net"},
+	)
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := resp.GetAnnotation()[debugValidationLatency]; !ok {
+		t.Errorf("got annotations %v, want the debug annotations of the validating snapshot", resp.GetAnnotation())
+	}
+}
+
+func TestPlugin_Validate_ExplainOfValidatingSnapshot(t *testing.T) {
+	t.Parallel()
+
+	p := newReloadingPlugin(
+		&snapshot{issueBaseURL: "https://example.atlassian.net", explainAnnotation: true},
+		&snapshot{issueBaseURL: "https://example.atlassian.net"},
+	)
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{
+			Category:   "jira",
+			Value:      "ABCD-1",
+			Annotation: map[string]string{jiraExplainRequest: "true"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := resp.GetAnnotation()[jiraExplanation]; !ok {
+		t.Errorf("got annotations %v, want the explanation enabled by the validating snapshot", resp.GetAnnotation())
+	}
+}
+
+func TestPlugin_Reload(t *testing.T) {
+	t.Parallel()
+
+	f := newFakeJira(t)
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	p, err := NewJiraPluginWithToken(ctx, f.config(), "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+
+	cfg := f.config()
+	cfg.IssueBaseURL = "https://reloaded.example.com"
+	cfg.JustificationFormat = JustificationFormatJSON
+	if err := p.Reload(ctx, cfg); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+
+	resp, err := p.ValidateValue(ctx, `{"issue":"ABCD-1"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := resp.GetAnnotation()[jiraIssueURL], "https://reloaded.example.com/browse/ABCD-1"; got != want {
+		t.Errorf("got url %q, want %q", got, want)
+	}
+
+	// An invalid configuration keeps the current one.
+	bad := f.config()
+	bad.JustificationFormat = "xml"
+	if err := p.Reload(ctx, bad); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("Reload() got err %v, want %v", err, ErrInvalidConfig)
+	}
+	if got := p.current.Load().issueBaseURL; got != cfg.IssueBaseURL {
+		t.Errorf("got issue base url %q after failed reload, want %q", got, cfg.IssueBaseURL)
+	}
+}
+
+func TestNewJiraPluginWithToken_WithJustificationParser(t *testing.T) {
+	t.Parallel()
+
+	parser := &CompositeParser{}
+	p, err := NewJiraPluginWithToken(context.Background(), &PluginConfig{
+		JIRAEndpoint:        "https://example.atlassian.net/rest/api/3",
+		JustificationFormat: JustificationFormatJSON,
+	}, "secrets", WithJustificationParser(parser))
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	if got := p.current.Load().parser; got != parser {
+		t.Errorf("got parser %T, want the parser passed as option", got)
+	}
+}
+
+func TestNewJiraPluginWithToken_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewJiraPluginWithToken(context.Background(), &PluginConfig{
+		JIRAEndpoint:        "https://example.atlassian.net/rest/api/3",
+		JustificationFormat: "xml",
+	}, "secrets")
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("NewJiraPluginWithToken() got err %v, want %v", err, ErrInvalidConfig)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugintest provides test doubles of the Jira plugin, so that
+// integration tests of the JVS can stub the Jira dependency.
+package plugintest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+)
+
+var _ plugin.IssueMatcher = (*FakeMatcher)(nil)
+
+// Call is a recorded call of [FakeMatcher.MatchIssue].
+type Call struct {
+	// IssueKey is the issue key the call matched.
+	IssueKey string
+}
+
+// FakeMatcher is a [plugin.IssueMatcher] answering from configured results
+// and recording its calls, safe for concurrent use. Issues that were not
+// configured do not match, like an issue not matching the JQL. Use it with
+// [plugin.WithIssueMatcher]:
+//
+//	m := plugintest.NewFakeMatcher().Match("ABCD-1", 10001)
+//	p, err := plugin.NewJiraPluginWithToken(ctx, cfg, "token", plugin.WithIssueMatcher(m))
+type FakeMatcher struct {
+	mu      sync.Mutex
+	results map[string]*plugin.MatchResult
+	errs    map[string]error
+	calls   []Call
+}
+
+// NewFakeMatcher creates a matcher no issue matches.
+func NewFakeMatcher() *FakeMatcher {
+	return &FakeMatcher{
+		results: make(map[string]*plugin.MatchResult),
+		errs:    make(map[string]error),
+	}
+}
+
+// Match makes the issue key match, as the issue with the id. It returns m for
+// chaining.
+func (m *FakeMatcher) Match(issueKey string, issueID int) *FakeMatcher {
+	return m.SetResult(issueKey, &plugin.MatchResult{
+		Matches: []*plugin.Match{{MatchedIssues: []int{issueID}, Errors: []string{}}},
+	})
+}
+
+// NoMatch makes the issue key exist without matching the JQL, which is also
+// the default. It returns m for chaining.
+func (m *FakeMatcher) NoMatch(issueKey string) *FakeMatcher {
+	return m.SetResult(issueKey, &plugin.MatchResult{
+		Matches: []*plugin.Match{{MatchedIssues: []int{}, Errors: []string{}}},
+	})
+}
+
+// Fail makes matching the issue key fail with err, e.g. one wrapping
+// [plugin.ErrJiraUnreachable] to simulate a Jira outage, or
+// [plugin.ErrInvalidJustification] for a rejection. It returns m for
+// chaining.
+func (m *FakeMatcher) Fail(issueKey string, err error) *FakeMatcher {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.results, issueKey)
+	m.errs[issueKey] = err
+	return m
+}
+
+// SetResult answers the issue key with the result, for results Match and
+// NoMatch cannot express, e.g. with annotation fields. It returns m for
+// chaining.
+func (m *FakeMatcher) SetResult(issueKey string, result *plugin.MatchResult) *FakeMatcher {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.errs, issueKey)
+	m.results[issueKey] = result
+	return m
+}
+
+// MatchIssue records the call and returns the configured result of the issue
+// key.
+func (m *FakeMatcher) MatchIssue(ctx context.Context, issueKey string) (*plugin.MatchResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, Call{IssueKey: issueKey})
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to match jira issue %q: %w", issueKey, err)
+	}
+	if err, ok := m.errs[issueKey]; ok {
+		return nil, err
+	}
+	if result, ok := m.results[issueKey]; ok {
+		return result, nil
+	}
+	return &plugin.MatchResult{
+		Matches: []*plugin.Match{{MatchedIssues: []int{}, Errors: []string{}}},
+	}, nil
+}
+
+// Calls returns the calls so far, oldest first.
+func (m *FakeMatcher) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Call(nil), m.calls...)
+}
+
+// Reset forgets the recorded calls, the configured results are kept.
+func (m *FakeMatcher) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugintest_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin"
+	"github.com/abcxyz/jvs-plugin-jira/pkg/plugin/plugintest"
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFakeMatcher(t *testing.T) {
+	t.Parallel()
+
+	m := plugintest.NewFakeMatcher().
+		Match("ABCD-1", 10001).
+		NoMatch("ABCD-2").
+		Fail("ABCD-3", fmt.Errorf("jira is down: %w", plugin.ErrJiraUnreachable))
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := plugin.NewJiraPluginWithToken(ctx, &plugin.PluginConfig{
+		JIRAEndpoint: "https://example.atlassian.net/rest/api/3",
+		Jql:          "project = ABCD",
+		JIRAAccount:  "jvs@example.com",
+		IssueBaseURL: "https://example.atlassian.net",
+	}, "token", plugin.WithIssueMatcher(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	validate := func(value string) (*jvspb.ValidateJustificationResponse, error) {
+		return p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+			Justification: &jvspb.Justification{Category: "jira", Value: value},
+		})
+	}
+
+	resp, err := validate("ABCD-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.GetValid() || resp.GetAnnotation()["jira_issue_id"] != "10001" {
+		t.Errorf("ABCD-1 got %v, want a valid response for issue 10001", resp)
+	}
+
+	for _, key := range []string{"ABCD-2", "ABCD-4"} {
+		resp, err := validate(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.GetValid() {
+			t.Errorf("%s got a valid response, want invalid", key)
+		}
+	}
+
+	if _, err := validate("ABCD-3"); status.Code(err) != codes.Unavailable {
+		t.Errorf("ABCD-3 got error %v, want Unavailable", err)
+	}
+
+	want := []plugintest.Call{{IssueKey: "ABCD-1"}, {IssueKey: "ABCD-2"}, {IssueKey: "ABCD-4"}, {IssueKey: "ABCD-3"}}
+	if diff := cmp.Diff(want, m.Calls()); diff != "" {
+		t.Errorf("Calls() unexpected diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestFakeMatcher_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	m := plugintest.NewFakeMatcher()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("ABCD-%d", i)
+			m.Match(key, i)
+			if _, err := m.MatchIssue(context.Background(), key); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got, want := len(m.Calls()), 50; got != want {
+		t.Errorf("got %d calls, want %d", got, want)
+	}
+	m.Reset()
+	if got := len(m.Calls()); got != 0 {
+		t.Errorf("got %d calls after Reset, want 0", got)
+	}
+}
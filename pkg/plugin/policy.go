@@ -0,0 +1,145 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// priorityField is the Jira field holding the issue priority.
+const priorityField = "priority"
+
+// defaultPriorityOrder are the priorities of the default Jira priority
+// scheme, highest first.
+var defaultPriorityOrder = []string{"Highest", "High", "Medium", "Low", "Lowest"}
+
+// issueCheck is a policy evaluated against the fields of the fetched issue,
+// in addition to the JQL. It does not cost extra Jira requests, the field is
+// fetched with the issue.
+type issueCheck interface {
+	// field returns the issue field the check reads.
+	field() string
+
+	// check returns why the issue fails the check, or nil.
+	check(fields map[string]json.RawMessage) error
+
+	// reason returns the rejection code of the check, see [Reason].
+	reason() string
+}
+
+// WithMinPriority makes the validator reject issues whose priority is below
+// min. The order lists the priority names highest first, it defaults to the
+// default Jira priority scheme when empty. Names are compared
+// case-insensitively, and issues without a priority or with a priority not
+// in the order are rejected. It only applies to [Validator.MatchIssue], and
+// cannot be used in search mode, which does not fetch the issue.
+func WithMinPriority(min string, order []string) ValidatorOption {
+	return func(v *Validator) error {
+		if v.searchJQLPrefix != "" {
+			return fmt.Errorf("minimum priority cannot be used in search mode")
+		}
+		c, err := newMinPriorityCheck(min, order)
+		if err != nil {
+			return err
+		}
+		v.issueChecks = append(v.issueChecks, c)
+		v.issueFieldsQuery = v.fieldsQuery()
+		return nil
+	}
+}
+
+// minPriorityCheck rejects issues below a priority.
+type minPriorityCheck struct {
+	min   string
+	order []string
+
+	// ranks maps lower case priority names to their position in order.
+	ranks map[string]int
+}
+
+func newMinPriorityCheck(min string, order []string) (*minPriorityCheck, error) {
+	if len(order) == 0 {
+		order = defaultPriorityOrder
+	}
+	c := &minPriorityCheck{order: order, ranks: make(map[string]int, len(order))}
+	for i, name := range order {
+		c.ranks[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	rank, ok := c.ranks[strings.ToLower(min)]
+	if !ok {
+		return nil, fmt.Errorf("unknown minimum priority %q, must be one of %s", min, strings.Join(order, ", "))
+	}
+	c.min = order[rank]
+	return c, nil
+}
+
+func (c *minPriorityCheck) field() string {
+	return priorityField
+}
+
+func (c *minPriorityCheck) reason() string {
+	return ErrorCodePriority
+}
+
+func (c *minPriorityCheck) check(fields map[string]json.RawMessage) error {
+	name, ok := renderField(fields[priorityField])
+	if !ok {
+		return fmt.Errorf("issue has no priority, the minimum is %s", c.min)
+	}
+	rank, ok := c.ranks[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("issue priority %s is unknown, the minimum is %s", name, c.min)
+	}
+	if rank > c.ranks[strings.ToLower(c.min)] {
+		return fmt.Errorf("issue priority %s is below the minimum %s", name, c.min)
+	}
+	return nil
+}
+
+// checkIssue runs all the issue checks and the expression of
+// [WithExpression]. When the issue fails any, the error is a *policyError
+// listing every failed check. Any other error means the expression could not
+// be evaluated.
+func (v *Validator) checkIssue(ctx context.Context, issue *jiraIssue) error {
+	var failures []error
+	for _, c := range v.issueChecks {
+		if err := c.check(issue.Fields); err != nil {
+			explainCheck(ctx, "policy", c.field(), ExplainFail, err.Error())
+			failures = append(failures, WithReason(fmt.Errorf("%w: %w", err, ErrInvalidJustification), c.reason()))
+			continue
+		}
+		explainCheck(ctx, "policy", c.field(), ExplainPass, "")
+	}
+	if v.expression != "" {
+		ok, err := v.evalExpression(ctx, issue.Key)
+		if err != nil {
+			explainCheck(ctx, "expression", v.expression, ExplainError, err.Error())
+			return err
+		}
+		if !ok {
+			explainCheck(ctx, "expression", v.expression, ExplainFail, "issue does not satisfy the jira expression")
+			failures = append(failures, WithReason(fmt.Errorf("issue does not satisfy the jira expression: %w", ErrInvalidJustification), ErrorCodeExpression))
+		} else {
+			explainCheck(ctx, "expression", v.expression, ExplainPass, "")
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &policyError{failures: failures}
+}
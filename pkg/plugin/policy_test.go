@@ -0,0 +1,177 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestMinPriorityCheck(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		min      string
+		order    []string
+		priority string
+		wantErr  string
+	}{
+		{
+			name:     "above",
+			min:      "High",
+			priority: `{"name":"Highest"}`,
+		},
+		{
+			name:     "equal_case_insensitive",
+			min:      "high",
+			priority: `{"name":"High"}`,
+		},
+		{
+			name:     "below",
+			min:      "High",
+			priority: `{"name":"Medium"}`,
+			wantErr:  "issue priority Medium is below the minimum High",
+		},
+		{
+			name:     "custom_order",
+			min:      "Major",
+			order:    []string{"Blocker", "Critical", "Major", "Minor"},
+			priority: `{"name":"Minor"}`,
+			wantErr:  "issue priority Minor is below the minimum Major",
+		},
+		{
+			name:     "unknown",
+			min:      "High",
+			priority: `{"name":"Urgent"}`,
+			wantErr:  "issue priority Urgent is unknown, the minimum is High",
+		},
+		{
+			name:     "missing",
+			min:      "High",
+			priority: `null`,
+			wantErr:  "issue has no priority, the minimum is High",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := newMinPriorityCheck(tc.min, tc.order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.check(map[string]json.RawMessage{priorityField: json.RawMessage(tc.priority)})
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestValidation_AllPolicyFailures(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1","key":"ABCD-1","fields":{"priority":{"name":"Low"},"components":[{"name":"web"}]}}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL, "project = ABCD", "test@test.com", "token",
+		WithMinPriority("High", nil),
+		WithFieldConstraints([]string{"components contains db"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = v.MatchIssue(context.Background(), "ABCD-1")
+	var pe *policyError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got err %v, want a policy error", err)
+	}
+	got := failureMessages(err)
+	want := []string{
+		`jira issue "ABCD-1" rejected: issue priority Low is below the minimum High: invalid justification`,
+		`jira issue "ABCD-1" rejected: issue field components does not contain "db": invalid justification`,
+		`jira issue "ABCD-1" rejected: no match for the JQL: invalid justification`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("failureMessages() unexpected diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestNewMinPriorityCheck_Unknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := newMinPriorityCheck("Urgent", nil)
+	if diff := testutil.DiffErrString(err, `unknown minimum priority "Urgent", must be one of Highest, High, Medium, Low, Lowest`); diff != "" {
+		t.Errorf(diff)
+	}
+}
+
+func TestValidation_MinPriority(t *testing.T) {
+	t.Parallel()
+
+	var gotFields string
+	var matchCalls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+		gotFields = r.URL.Query().Get("fields")
+		fmt.Fprint(w, `{"id":"1","key":"ABCD-1","fields":{"priority":{"name":"Low"}}}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		matchCalls++
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL, "project = ABCD", "test@test.com", "token", WithMinPriority("High", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = v.MatchIssue(context.Background(), "ABCD-1")
+	if diff := testutil.DiffErrString(err, `jira issue "ABCD-1" rejected: issue priority Low is below the minimum High`); diff != "" {
+		t.Errorf(diff)
+	}
+	if !errors.Is(err, ErrInvalidJustification) {
+		t.Errorf("got err %v, want it to wrap %v", err, ErrInvalidJustification)
+	}
+	if got, want := gotFields, "key,id,priority"; got != want {
+		t.Errorf("got fields %q, want %q", got, want)
+	}
+	// The JQL is still evaluated to report all the failures.
+	if matchCalls != 1 {
+		t.Errorf("got %d match requests for a rejected issue, want 1", matchCalls)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+)
+
+// quota limits the validations of a justification category. A validation
+// over the quota is rejected right away instead of waiting, so a flood of
+// requests never queues up in front of the ones that fit.
+type quota struct {
+	category string
+
+	// limiter limits the rate of validations, it is nil when unlimited.
+	limiter *rate.Limiter
+
+	// slots limits the validations in flight, it is nil when unlimited.
+	slots chan struct{}
+
+	// exceeded counts the rejected validations.
+	exceeded atomic.Uint64
+}
+
+// newQuota creates the quota for the category, it returns nil when neither
+// the rate nor the concurrency is limited. A burst of zero defaults to the
+// rate rounded up.
+func newQuota(category string, r float64, burst, maxConcurrent int) *quota {
+	if r <= 0 && maxConcurrent <= 0 {
+		return nil
+	}
+
+	q := &quota{category: category}
+	if r > 0 {
+		if burst <= 0 {
+			burst = int(math.Ceil(r))
+		}
+		q.limiter = rate.NewLimiter(rate.Limit(r), burst)
+	}
+	if maxConcurrent > 0 {
+		q.slots = make(chan struct{}, maxConcurrent)
+	}
+	return q
+}
+
+// acquire reserves a validation, it returns an error wrapping
+// [ErrQuotaExceeded] when the quota is exhausted. The returned function must
+// be called when the validation is done.
+func (q *quota) acquire() (func(), error) {
+	if q.slots != nil {
+		select {
+		case q.slots <- struct{}{}:
+		default:
+			q.exceeded.Add(1)
+			return nil, fmt.Errorf("too many concurrent validations for category %q: %w", q.category, ErrQuotaExceeded)
+		}
+	}
+
+	release := func() {
+		if q.slots != nil {
+			<-q.slots
+		}
+	}
+
+	if q.limiter != nil && !q.limiter.Allow() {
+		release()
+		q.exceeded.Add(1)
+		return nil, fmt.Errorf("too many validations per second for category %q: %w", q.category, ErrQuotaExceeded)
+	}
+	return release, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewQuota_Unlimited(t *testing.T) {
+	t.Parallel()
+
+	if q := newQuota(jiraCategory, 0, 10, 0); q != nil {
+		t.Errorf("newQuota() got %v, want nil", q)
+	}
+}
+
+func TestQuota_Acquire(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name          string
+		rate          float64
+		burst         int
+		maxConcurrent int
+		wantAllowed   int
+	}{
+		{
+			name:        "rate",
+			rate:        0.001,
+			burst:       3,
+			wantAllowed: 3,
+		},
+		{
+			name:        "rate_default_burst",
+			rate:        1.5,
+			wantAllowed: 2,
+		},
+		{
+			name:          "concurrency",
+			maxConcurrent: 2,
+			wantAllowed:   2,
+		},
+		{
+			name:          "concurrency_within_rate",
+			rate:          0.001,
+			burst:         5,
+			maxConcurrent: 1,
+			wantAllowed:   1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			q := newQuota(jiraCategory, tc.rate, tc.burst, tc.maxConcurrent)
+
+			// Validations are never released, so both limits are exhausted
+			// after the allowed ones.
+			for i := 0; i < tc.wantAllowed; i++ {
+				if _, err := q.acquire(); err != nil {
+					t.Fatalf("acquire() %d got unexpected error: %v", i, err)
+				}
+			}
+			if _, err := q.acquire(); !errors.Is(err, ErrQuotaExceeded) {
+				t.Errorf("acquire() got err %v, want %v", err, ErrQuotaExceeded)
+			}
+			if got, want := q.exceeded.Load(), uint64(1); got != want {
+				t.Errorf("got %d rejected validations, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestQuota_Release(t *testing.T) {
+	t.Parallel()
+
+	q := newQuota(jiraCategory, 0, 0, 1)
+
+	release, err := q.acquire()
+	if err != nil {
+		t.Fatalf("acquire() got unexpected error: %v", err)
+	}
+	if _, err := q.acquire(); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("acquire() got err %v, want %v", err, ErrQuotaExceeded)
+	}
+
+	release()
+	if _, err := q.acquire(); err != nil {
+		t.Errorf("acquire() after release got unexpected error: %v", err)
+	}
+}
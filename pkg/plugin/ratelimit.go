@@ -0,0 +1,121 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+// rateLimitWarnRatio is the fraction of the rate limit left below which a
+// warning is logged.
+const rateLimitWarnRatio = 0.1
+
+// RateLimit is the [rate limit] state Jira reported with the last response
+// carrying rate limit headers.
+//
+// [rate limit]: https://developer.atlassian.com/cloud/jira/platform/rate-limiting/
+type RateLimit struct {
+	// Limit is the maximum number of requests in the window, it is zero when
+	// Jira did not report it.
+	Limit int `json:"limit,omitempty"`
+
+	// Remaining is the number of requests left in the window.
+	Remaining int `json:"remaining"`
+
+	// Reset is when the window resets, it is zero when Jira did not report
+	// it.
+	Reset time.Time `json:"reset,omitempty"`
+
+	// ObservedAt is when the response was received.
+	ObservedAt time.Time `json:"observed_at"`
+}
+
+// String returns a summary like "90 of 100 requests remaining".
+func (r *RateLimit) String() string {
+	s := fmt.Sprintf("%d requests remaining", r.Remaining)
+	if r.Limit > 0 {
+		s = fmt.Sprintf("%d of %d requests remaining", r.Remaining, r.Limit)
+	}
+	if !r.Reset.IsZero() {
+		s += fmt.Sprintf(" until %s", r.Reset.UTC().Format(time.RFC3339))
+	}
+	return s
+}
+
+// low reports whether less than rateLimitWarnRatio of the limit is left. It
+// is only known when Jira reported the limit.
+func (r *RateLimit) low() bool {
+	return r.Limit > 0 && float64(r.Remaining) < rateLimitWarnRatio*float64(r.Limit)
+}
+
+// parseRateLimit returns the rate limit of the response header, or nil when
+// it has no X-RateLimit-Remaining header.
+func parseRateLimit(h http.Header, now time.Time) *RateLimit {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return nil
+	}
+
+	r := &RateLimit{Remaining: remaining, ObservedAt: now}
+	if limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		r.Limit = limit
+	}
+	if reset, err := time.Parse(time.RFC3339, h.Get("X-RateLimit-Reset")); err == nil {
+		r.Reset = reset
+	}
+	return r
+}
+
+// rateLimitGauge holds the last [RateLimit] reported by Jira.
+type rateLimitGauge struct {
+	last atomic.Pointer[RateLimit]
+
+	// warned is set while the rate limit is low, so the warning is logged
+	// once each time it runs low rather than on every response.
+	warned atomic.Bool
+}
+
+// observe records the rate limit of the response header, if any, and logs a
+// warning when it runs low.
+func (g *rateLimitGauge) observe(ctx context.Context, h http.Header) {
+	r := parseRateLimit(h, time.Now())
+	if r == nil {
+		return
+	}
+	g.last.Store(r)
+
+	logger := logging.FromContext(ctx)
+	if !r.low() {
+		g.warned.Store(false)
+		logger.DebugContext(ctx, "jira rate limit", "rate_limit", r)
+		return
+	}
+	if g.warned.CompareAndSwap(false, true) {
+		logger.WarnContext(ctx, "jira rate limit nearly exhausted, consider a longer cache ttl",
+			"rate_limit", r)
+	}
+}
+
+// load returns the last rate limit, or nil when Jira never reported one.
+func (g *rateLimitGauge) load() *RateLimit {
+	return g.last.Load()
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name    string
+		header  map[string]string
+		want    *RateLimit
+		wantStr string
+		wantLow bool
+	}{
+		{
+			name: "all_headers",
+			header: map[string]string{
+				"X-RateLimit-Limit":     "100",
+				"X-RateLimit-Remaining": "90",
+				"X-RateLimit-Reset":     "2023-09-01T12:01:00Z",
+			},
+			want: &RateLimit{
+				Limit:      100,
+				Remaining:  90,
+				Reset:      time.Date(2023, 9, 1, 12, 1, 0, 0, time.UTC),
+				ObservedAt: now,
+			},
+			wantStr: "90 of 100 requests remaining until 2023-09-01T12:01:00Z",
+		},
+		{
+			name: "nearly_exhausted",
+			header: map[string]string{
+				"X-RateLimit-Limit":     "100",
+				"X-RateLimit-Remaining": "9",
+			},
+			want:    &RateLimit{Limit: 100, Remaining: 9, ObservedAt: now},
+			wantStr: "9 of 100 requests remaining",
+			wantLow: true,
+		},
+		{
+			name: "remaining_only",
+			header: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "soon",
+			},
+			want:    &RateLimit{Remaining: 0, ObservedAt: now},
+			wantStr: "0 requests remaining",
+		},
+		{
+			name:   "no_headers",
+			header: map[string]string{},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := http.Header{}
+			for k, v := range tc.header {
+				h.Set(k, v)
+			}
+
+			got := parseRateLimit(h, now)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("parseRateLimit() unexpected diff (-want,+got):\n%s", diff)
+			}
+			if got == nil {
+				return
+			}
+			if got, want := got.String(), tc.wantStr; got != want {
+				t.Errorf("String() got %q, want %q", got, want)
+			}
+			if got, want := got.low(), tc.wantLow; got != want {
+				t.Errorf("low() got %t, want %t", got, want)
+			}
+		})
+	}
+}
+
+func TestValidator_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	remaining := 50
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(remaining))
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "secrets")
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+	if got := v.RateLimit(); got != nil {
+		t.Errorf("RateLimit() before any request got %v, want nil", got)
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	for _, r := range []int{50, 5, 4} {
+		remaining = r
+		if _, err := v.Issue(ctx, "ABCD-1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got := v.RateLimit()
+		if got == nil {
+			t.Fatalf("RateLimit() got nil")
+		}
+		if got.Remaining != r {
+			t.Errorf("RateLimit().Remaining got %d, want %d", got.Remaining, r)
+		}
+	}
+	if !v.rateLimit.warned.Load() {
+		t.Errorf("expected a warning once the rate limit ran low")
+	}
+}
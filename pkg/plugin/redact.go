@@ -0,0 +1,146 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Field redaction rules, see [WithFieldRedactions].
+const (
+	redactMax      = "max"
+	redactScrub    = "scrub"
+	redactProjects = "projects"
+
+	// redactionMarker replaces scrubbed text, and the fields of issues in
+	// projects not allowed to emit them.
+	redactionMarker = "[redacted]"
+)
+
+// fieldRedaction are the redaction rules of an annotation field.
+type fieldRedaction struct {
+	// maxBytes is the size limit of the field, no limit beyond the
+	// annotation field limit when zero.
+	maxBytes int
+
+	// scrub are the patterns replaced with [redactionMarker].
+	scrub []*regexp.Regexp
+
+	// projects are the projects allowed to emit the field, any when nil.
+	projects []string
+}
+
+// WithFieldRedactions sets rules applied to annotation fields before they are
+// returned, so that sensitive data, e.g. in issue summaries, does not end up
+// in signed tokens and audit logs. Each rule is a field id, a rule and its
+// value:
+//
+//	summary max 80
+//	summary scrub \b\d{13,16}\b
+//	summary projects [OPS, INFRA]
+//
+// max truncates the field to a number of bytes. scrub replaces the matches
+// of a regular expression with "[redacted]". projects emits the field only
+// for issues of the listed projects, the field of other issues is replaced
+// with "[redacted]". A field may have several rules, the project allowlist
+// applies first, then the scrub patterns in order, then the size limit, so
+// that truncation cannot cut a match out of reach of its pattern.
+func WithFieldRedactions(rules []string) ValidatorOption {
+	return func(v *Validator) error {
+		redactions, err := parseFieldRedactions(rules)
+		if err != nil {
+			return err
+		}
+		v.fieldRedactions = redactions
+		return nil
+	}
+}
+
+// parseFieldRedactions parses redaction rules by field, see
+// [WithFieldRedactions].
+func parseFieldRedactions(rules []string) (map[string]*fieldRedaction, error) {
+	redactions := make(map[string]*fieldRedaction, len(rules))
+	for _, s := range rules {
+		name, rest, _ := strings.Cut(strings.TrimSpace(s), " ")
+		if !fieldNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid field redaction %q, invalid jira field name %q", s, name)
+		}
+		rule, value, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, fmt.Errorf("invalid field redaction %q, must be <field> <rule> <value>", s)
+		}
+
+		r, ok := redactions[name]
+		if !ok {
+			r = &fieldRedaction{}
+			redactions[name] = r
+		}
+		switch rule {
+		case redactMax:
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid field redaction %q, the value of max must be a positive number of bytes", s)
+			}
+			r.maxBytes = n
+		case redactScrub:
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field redaction %q: %w", s, err)
+			}
+			r.scrub = append(r.scrub, re)
+		case redactProjects:
+			if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+				return nil, fmt.Errorf("invalid field redaction %q, the value of projects must be a list like [a, b]", s)
+			}
+			for _, p := range strings.Split(value[1:len(value)-1], ",") {
+				if p = strings.TrimSpace(p); p != "" {
+					r.projects = append(r.projects, strings.ToUpper(p))
+				}
+			}
+			if len(r.projects) == 0 {
+				return nil, fmt.Errorf("invalid field redaction %q, empty list", s)
+			}
+		default:
+			return nil, fmt.Errorf("invalid field redaction %q, unknown rule %q, must be one of max, scrub, projects", s, rule)
+		}
+	}
+	return redactions, nil
+}
+
+// redact applies the rules to the rendered field of an issue of the project.
+func (r *fieldRedaction) redact(project, s string) string {
+	if r.projects != nil && !slices.Contains(r.projects, strings.ToUpper(project)) {
+		return redactionMarker
+	}
+	for _, re := range r.scrub {
+		s = re.ReplaceAllLiteralString(s, redactionMarker)
+	}
+	if r.maxBytes > 0 {
+		s = truncateUTF8(s, r.maxBytes)
+	}
+	return s
+}
+
+// issueProject returns the project key of an issue key, e.g. "ABCD" for
+// "ABCD-123".
+func issueProject(issueKey string) string {
+	project, _, _ := strings.Cut(issueKey, "-")
+	return project
+}
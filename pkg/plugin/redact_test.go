@@ -0,0 +1,201 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/abcxyz/pkg/testutil"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFieldRedactions(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		rules   []string
+		wantErr string
+	}{
+		{name: "max", rules: []string{"summary max 80"}},
+		{name: "scrub", rules: []string{`summary scrub \b\d{16}\b`}},
+		{name: "projects", rules: []string{"summary projects [OPS, infra]"}},
+		{name: "several", rules: []string{"summary max 80", "summary scrub secret", "description max 10"}},
+		{
+			name:    "invalid_field",
+			rules:   []string{"sum-mary max 80"},
+			wantErr: `invalid field redaction "sum-mary max 80", invalid jira field name "sum-mary"`,
+		},
+		{
+			name:    "missing_value",
+			rules:   []string{"summary max"},
+			wantErr: "must be <field> <rule> <value>",
+		},
+		{
+			name:    "unknown_rule",
+			rules:   []string{"summary hide all"},
+			wantErr: `unknown rule "hide", must be one of max, scrub, projects`,
+		},
+		{
+			name:    "invalid_max",
+			rules:   []string{"summary max -1"},
+			wantErr: "the value of max must be a positive number of bytes",
+		},
+		{
+			name:    "invalid_pattern",
+			rules:   []string{"summary scrub (secret"},
+			wantErr: "missing closing )",
+		},
+		{
+			name:    "projects_without_list",
+			rules:   []string{"summary projects OPS"},
+			wantErr: "the value of projects must be a list like [a, b]",
+		},
+		{
+			name:    "projects_empty_list",
+			rules:   []string{"summary projects [ ]"},
+			wantErr: "empty list",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseFieldRedactions(tc.rules)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestFieldRedaction_Redact(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		rules   []string
+		project string
+		in      string
+		want    string
+	}{
+		{
+			name:  "max",
+			rules: []string{"summary max 10"},
+			in:    "Rotate the production database credentials",
+			want:  "Rotate …",
+		},
+		{
+			name:  "scrub",
+			rules: []string{`summary scrub \b\d{4}-\d{4}\b`, "summary scrub (?i)password"},
+			in:    "Card 1234-5678 and PASSWORD leaked",
+			want:  "Card [redacted] and [redacted] leaked",
+		},
+		{
+			name:  "scrub_before_max",
+			rules: []string{"summary max 20", `summary scrub \d{12}`},
+			in:    "Account 123456789012",
+			want:  "Account [redacted]",
+		},
+		{
+			name:    "allowed_project",
+			rules:   []string{"summary projects [OPS, INFRA]"},
+			project: "ops",
+			in:      "Restart the ingress",
+			want:    "Restart the ingress",
+		},
+		{
+			name:    "other_project",
+			rules:   []string{"summary projects [OPS, INFRA]"},
+			project: "HR",
+			in:      "Salary review of Jane Doe",
+			want:    redactionMarker,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			redactions, err := parseFieldRedactions(tc.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := redactions["summary"].redact(tc.project, tc.in); got != tc.want {
+				t.Errorf("redact(%q, %q) got %q, want %q", tc.project, tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidator_ProjectFields_Redactions(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA", "test@test.com", "secrets",
+		WithAnnotationFields([]string{"summary", "description", "priority"}, 0),
+		WithFieldRedactions([]string{"summary projects [JRA]", "description scrub secret-\\w+"}))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+	fields := map[string]json.RawMessage{
+		"summary":     json.RawMessage(`"Roll back"`),
+		"description": json.RawMessage(`"Uses secret-abc123"`),
+		"priority":    json.RawMessage(`{"name":"High"}`),
+	}
+
+	cases := []struct {
+		name     string
+		issueKey string
+		want     map[string]string
+	}{
+		{
+			name:     "allowed_project",
+			issueKey: "JRA-1",
+			want: map[string]string{
+				"summary":     "Roll back",
+				"description": "Uses [redacted]",
+				"priority":    "High",
+			},
+		},
+		{
+			name:     "other_project",
+			issueKey: "OPS-1",
+			want: map[string]string{
+				"summary":     redactionMarker,
+				"description": "Uses [redacted]",
+				"priority":    "High",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := v.projectFields(tc.issueKey, fields)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("projectFields() unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+const (
+	// defaultMaxRedirects is how many redirects a request follows by
+	// default, see [WithMaxRedirects].
+	defaultMaxRedirects = 3
+
+	// maxMaxRedirects bounds [WithMaxRedirects], it is the limit of the
+	// default HTTP client.
+	maxMaxRedirects = 10
+)
+
+// WithMaxRedirects sets how many redirects a request follows, 3 by default.
+// Redirects are only followed on the host of the request, and never from
+// https to http, because the API token is sent again with every redirected
+// request.
+func WithMaxRedirects(n int) ValidatorOption {
+	return func(v *Validator) error {
+		if n < 1 || n > maxMaxRedirects {
+			return fmt.Errorf("max redirects must be between 1 and %d, got %d", maxMaxRedirects, n)
+		}
+		v.maxRedirects = n
+		return nil
+	}
+}
+
+// checkRedirect is the redirect policy of the HTTP client of the validator.
+// The authentication middleware sets the API token on every request,
+// including redirected ones, so a redirect is only followed when it keeps
+// the host of the original request, and does not downgrade https to http.
+// A redirect elsewhere fails with an error wrapping [ErrHostNotAllowed].
+func (v *Validator) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > v.maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", v.maxRedirects)
+	}
+	from := via[0].URL
+	if !strings.EqualFold(req.URL.Host, from.Host) {
+		return fmt.Errorf("redirect from %s to %s refused, redirects must stay on the same host: %w",
+			from.Host, req.URL.Host, ErrHostNotAllowed)
+	}
+	if from.Scheme == "https" && req.URL.Scheme != "https" {
+		return fmt.Errorf("redirect from %s to %s refused, redirects must not downgrade https: %w",
+			from.Redacted(), req.URL.Redacted(), ErrHostNotAllowed)
+	}
+
+	ctx := req.Context()
+	logging.FromContext(ctx).DebugContext(ctx, "following jira redirect",
+		"endpoint", v.endpointName(from),
+		"location", req.URL.Redacted(),
+		"hops", len(via))
+	return nil
+}
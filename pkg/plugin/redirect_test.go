@@ -0,0 +1,166 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestCheckRedirect(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		via     []string
+		to      string
+		wantErr string
+	}{
+		{
+			name: "same_host",
+			via:  []string{"https://jira.example.com/rest/api/2/issue/ABCD-1"},
+			to:   "https://jira.example.com/jira/rest/api/2/issue/ABCD-1",
+		},
+		{
+			name: "upgrade",
+			via:  []string{"http://jira.example.com/rest/api/2/issue/ABCD-1"},
+			to:   "https://jira.example.com/rest/api/2/issue/ABCD-1",
+		},
+		{
+			name:    "other_host",
+			via:     []string{"https://jira.example.com/rest/api/2/issue/ABCD-1"},
+			to:      "https://attacker.example.com/rest/api/2/issue/ABCD-1",
+			wantErr: "redirect from jira.example.com to attacker.example.com refused",
+		},
+		{
+			name:    "other_port",
+			via:     []string{"https://jira.example.com/rest/api/2/issue/ABCD-1"},
+			to:      "https://jira.example.com:8443/rest/api/2/issue/ABCD-1",
+			wantErr: "redirects must stay on the same host",
+		},
+		{
+			name:    "downgrade",
+			via:     []string{"https://jira.example.com/rest/api/2/issue/ABCD-1"},
+			to:      "http://jira.example.com/rest/api/2/issue/ABCD-1",
+			wantErr: "redirects must not downgrade https",
+		},
+		{
+			name: "too_many",
+			via: []string{
+				"https://jira.example.com/1",
+				"https://jira.example.com/2",
+				"https://jira.example.com/3",
+				"https://jira.example.com/4",
+			},
+			to:      "https://jira.example.com/5",
+			wantErr: "stopped after 3 redirects",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := NewValidator("https://jira.example.com/rest/api/2", "project = ABCD", "test@test.com", "secrets")
+			if err != nil {
+				t.Fatalf("failed to create validator: %v", err)
+			}
+			via := make([]*http.Request, 0, len(tc.via))
+			for _, u := range tc.via {
+				via = append(via, &http.Request{URL: mustParseURL(t, u)})
+			}
+			req := (&http.Request{URL: mustParseURL(t, tc.to)}).WithContext(context.Background())
+
+			err = v.checkRedirect(req, via)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	var authenticated atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/issue/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/moved"+r.URL.Path, http.StatusFound)
+	})
+	mux.HandleFunc("/moved/rest/api/2/issue/", func(w http.ResponseWriter, r *http.Request) {
+		if _, token, ok := r.BasicAuth(); ok && token == "secrets" {
+			authenticated.Add(1)
+		}
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL+"/rest/api/2", "project = ABCD", "test@test.com", "secrets", WithMaxRedirects(1))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	// A redirect on the same host is followed with the credentials.
+	issue, err := v.jiraIssue(ctx, "ABCD-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := issue.ID, "1234"; got != want {
+		t.Errorf("got issue id %q, want %q", got, want)
+	}
+	if got, want := authenticated.Load(), int32(1); got != want {
+		t.Errorf("got %d authenticated requests after the redirect, want %d", got, want)
+	}
+
+	// A redirect loop stops after the maximum.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/loop", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = v.makeRequest(req, nil)
+	if diff := testutil.DiffErrString(err, "stopped after 1 redirects"); diff != "" {
+		t.Errorf(diff)
+	}
+	if errors.Is(err, ErrHostNotAllowed) {
+		t.Errorf("got error %v, want no %v", err, ErrHostNotAllowed)
+	}
+}
+
+func mustParseURL(t *testing.T, s string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
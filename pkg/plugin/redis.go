@@ -0,0 +1,280 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// redisTimeout bounds a Redis command when the context has no earlier
+	// deadline, so a hung Redis does not hang validations.
+	redisTimeout = 2 * time.Second
+
+	// redisPoolSize is the number of idle connections kept open.
+	redisPoolSize = 8
+
+	// redisKeyPrefix prefixes the keys the plugin stores in Redis, so the
+	// instance can be shared with other applications.
+	redisKeyPrefix = "jvs-plugin-jira:"
+)
+
+// redisError is an error reply of Redis, e.g. "WRONGTYPE ...". The
+// connection stays usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// redisClient is a minimal client of the [Redis protocol], enough for the
+// shared state of [PluginConfig.StateBackend]. Commands are sent on pooled
+// connections, one at a time.
+//
+// [Redis protocol]: https://redis.io/docs/reference/protocol-spec/
+type redisClient struct {
+	address  string
+	tls      *tls.Config
+	username string
+	password string
+	db       int
+
+	pool chan *redisConn
+}
+
+// redisConn is a connection to Redis.
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// newRedisClient creates a client for the redis:// or rediss:// (TLS) URL,
+// e.g. redis://10.0.0.3:6379/2. A password given explicitly overrides the
+// one of the URL. No connection is made until the first command.
+func newRedisClient(rawURL, password string) (*redisClient, error) {
+	u, err := parseRedisURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &redisClient{
+		address: u.Host,
+		pool:    make(chan *redisConn, redisPoolSize),
+	}
+	if u.Port() == "" {
+		c.address = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.Scheme == "rediss" {
+		c.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if password != "" {
+		c.password = password
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil || c.db < 0 {
+			return nil, fmt.Errorf("invalid redis database %q in %s", db, u.Redacted())
+		}
+	}
+	return c, nil
+}
+
+// parseRedisURL parses and checks a redis:// or rediss:// URL.
+func parseRedisURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+	if (u.Scheme != "redis" && u.Scheme != "rediss") || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid redis url %s, must be redis://host:port or rediss://host:port", u.Redacted())
+	}
+	return u, nil
+}
+
+// do sends the command and returns its reply: a string for status replies,
+// an int64 for integers, a []byte or nil for bulk strings, and an []any or
+// nil for arrays. An error reply is returned as a redisError.
+func (c *redisClient) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(ctx, args...)
+	var rerr redisError
+	if err != nil && !errors.As(err, &rerr) {
+		// The connection is in an unknown state.
+		conn.Close()
+		return nil, fmt.Errorf("redis %s failed: %w", args[0], err)
+	}
+	select {
+	case c.pool <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+// conn returns an idle connection, or a new one.
+func (c *redisClient) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case conn := <-c.pool:
+		return conn, nil
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	var nc net.Conn
+	var err error
+	if c.tls != nil {
+		d := &tls.Dialer{Config: c.tls}
+		nc, err = d.DialContext(ctx, "tcp", c.address)
+	} else {
+		d := &net.Dialer{}
+		nc, err = d.DialContext(ctx, "tcp", c.address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis %s: %w", c.address, err)
+	}
+
+	conn := &redisConn{Conn: nc, r: bufio.NewReader(nc)}
+	if c.password != "" {
+		auth := []string{"AUTH", c.password}
+		if c.username != "" {
+			auth = []string{"AUTH", c.username, c.password}
+		}
+		if _, err := conn.do(ctx, auth...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to authenticate to redis %s: %w", c.address, err)
+		}
+	}
+	if c.db != 0 {
+		if _, err := conn.do(ctx, "SELECT", strconv.Itoa(c.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select redis database %d: %w", c.db, err)
+		}
+	}
+	return conn, nil
+}
+
+// close closes the idle connections.
+func (c *redisClient) close() error {
+	var merr error
+	for {
+		select {
+		case conn := <-c.pool:
+			merr = errors.Join(merr, conn.Close())
+		default:
+			return merr
+		}
+	}
+}
+
+// do sends the command on the connection and reads the reply.
+func (c *redisConn) do(ctx context.Context, args ...string) (any, error) {
+	deadline, ok := ctx.Deadline()
+	if limit := time.Now().Add(redisTimeout); !ok || deadline.After(limit) {
+		deadline = limit
+	}
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err //nolint:wrapcheck // Want passthrough
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		return nil, err //nolint:wrapcheck // Want passthrough
+	}
+	return readRedisReply(c.r)
+}
+
+// readRedisReply reads a RESP2 reply.
+func readRedisReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Want passthrough
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("invalid redis reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		n, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis integer %q", payload)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("invalid redis bulk length %q", payload)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err //nolint:wrapcheck // Want passthrough
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < -1 {
+			return nil, fmt.Errorf("invalid redis array length %q", payload)
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		elems := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			elem, err := readRedisReply(r)
+			var rerr redisError
+			if err != nil && !errors.As(err, &rerr) {
+				return nil, err
+			}
+			if err != nil {
+				elem = rerr
+			}
+			elems = append(elems, elem)
+		}
+		return elems, nil
+	default:
+		return nil, fmt.Errorf("invalid redis reply %q", line)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// StateBackendMemory keeps the decision cache and the quota counters in
+	// the plugin process, and the cache file if any.
+	StateBackendMemory = "memory"
+
+	// StateBackendRedis keeps the decision cache, its refresh locks and the
+	// requestor quota counters in Redis, shared by all the plugin replicas.
+	StateBackendRedis = "redis"
+)
+
+// redisUnlockScript deletes a lock only when it still holds the token of
+// its owner, so an expired lock taken over by another plugin is not
+// released.
+const redisUnlockScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+
+// redisCacheStore is a [cacheStore] in Redis, entries expire with the Redis
+// key. The connections belong to the plugin, close does not close them.
+type redisCacheStore struct {
+	client *redisClient
+}
+
+func (s *redisCacheStore) key(bucket, name []byte) string {
+	return redisKeyPrefix + string(bucket) + "/" + string(name)
+}
+
+func (s *redisCacheStore) get(bucket, name []byte) ([]byte, error) {
+	ctx := context.Background()
+	reply, err := s.client.do(ctx, "GET", s.key(bucket, name))
+	if err != nil {
+		return nil, err
+	}
+	v, _ := reply.([]byte)
+	return v, nil
+}
+
+func (s *redisCacheStore) put(bucket, name, v []byte, keep time.Duration) error {
+	ctx := context.Background()
+	_, err := s.client.do(ctx, "SET", s.key(bucket, name), string(v), "PX", strconv.FormatInt(keep.Milliseconds(), 10))
+	return err
+}
+
+func (s *redisCacheStore) delete(bucket, name []byte) error {
+	ctx := context.Background()
+	_, err := s.client.do(ctx, "DEL", s.key(bucket, name))
+	return err
+}
+
+func (s *redisCacheStore) count(bucket []byte) (int, int, error) {
+	var n, total int
+	prefix := s.key(bucket, nil)
+	if err := s.scan(context.Background(), func(keys []string) error {
+		for _, k := range keys {
+			if strings.HasPrefix(k, prefix) {
+				n++
+			}
+		}
+		total += len(keys)
+		return nil
+	}); err != nil {
+		return 0, 0, err
+	}
+	return n, total, nil
+}
+
+func (s *redisCacheStore) purge() (int, error) {
+	ctx := context.Background()
+	var n int
+	if err := s.scan(ctx, func(keys []string) error {
+		if len(keys) == 0 {
+			return nil
+		}
+		reply, err := s.client.do(ctx, append([]string{"DEL"}, keys...)...)
+		if err != nil {
+			return err
+		}
+		deleted, _ := reply.(int64)
+		n += int(deleted)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// scan calls fn with the keys of the cache entries of every bucket, a batch
+// at a time. Keys may be reported more than once, as Redis SCAN does.
+func (s *redisCacheStore) scan(ctx context.Context, fn func(keys []string) error) error {
+	pattern := redisKeyPrefix + cacheBucketPrefix + "*"
+	cursor := "0"
+	for {
+		reply, err := s.client.do(ctx, "SCAN", cursor, "MATCH", pattern, "COUNT", "1000")
+		if err != nil {
+			return err
+		}
+		elems, _ := reply.([]any)
+		if len(elems) != 2 {
+			return fmt.Errorf("invalid redis SCAN reply %v", reply)
+		}
+		next, _ := elems[0].([]byte)
+		batch, _ := elems[1].([]any)
+		keys := make([]string, 0, len(batch))
+		for _, k := range batch {
+			if b, ok := k.([]byte); ok {
+				keys = append(keys, string(b))
+			}
+		}
+		if err := fn(keys); err != nil {
+			return err
+		}
+		if cursor = string(next); cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+func (s *redisCacheStore) close() error {
+	return nil
+}
+
+func (s *redisCacheStore) tryLock(ctx context.Context, bucket, name []byte, ttl time.Duration) (func(), bool, error) {
+	key := redisKeyPrefix + "lock/" + string(bucket) + "/" + string(name)
+	token, err := randomToken()
+	if err != nil {
+		return nil, false, err
+	}
+	reply, err := s.client.do(ctx, "SET", key, token, "NX", "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	unlock := func() {
+		// An unlock that fails leaves the lock to expire.
+		_, _ = s.client.do(context.WithoutCancel(ctx), "EVAL", redisUnlockScript, "1", key, token)
+	}
+	return unlock, true, nil
+}
+
+// redisRequestorCounter is a [requestorCounter] in Redis, a sorted set of
+// validation times per requestor.
+type redisRequestorCounter struct {
+	client *redisClient
+	window time.Duration
+}
+
+func (c *redisRequestorCounter) key(subject string) string {
+	return redisKeyPrefix + "requestors/" + subject
+}
+
+func (c *redisRequestorCounter) count(ctx context.Context, subject string, now time.Time) (int, error) {
+	key := c.key(subject)
+	cutoff := strconv.FormatInt(now.Add(-c.window).UnixMilli(), 10)
+	if _, err := c.client.do(ctx, "ZREMRANGEBYSCORE", key, "-inf", cutoff); err != nil {
+		return 0, err
+	}
+	reply, err := c.client.do(ctx, "ZCARD", key)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected redis ZCARD reply %v", reply)
+	}
+	return int(n), nil
+}
+
+func (c *redisRequestorCounter) add(ctx context.Context, subject string, now time.Time) error {
+	key := c.key(subject)
+	// Members must be unique, validations in the same millisecond count
+	// twice.
+	token, err := randomToken()
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.do(ctx, "ZADD", key, strconv.FormatInt(now.UnixMilli(), 10), token); err != nil {
+		return err
+	}
+	_, err = c.client.do(ctx, "PEXPIRE", key, strconv.FormatInt(c.window.Milliseconds(), 10))
+	return err
+}
+
+// randomToken returns a random hex string.
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
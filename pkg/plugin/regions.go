@@ -0,0 +1,265 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+const (
+	// defaultRegionProbeInterval is how often the regional endpoints are
+	// probed when no interval is configured.
+	defaultRegionProbeInterval = 30 * time.Second
+
+	// regionProbeTimeout bounds a single probe.
+	regionProbeTimeout = 5 * time.Second
+
+	// regionLatencyWeight is the weight of a new probe in the moving average
+	// of the latency of an endpoint.
+	regionLatencyWeight = 0.3
+)
+
+// WithRegionalEndpoints adds regional endpoints of the same Jira Data Center
+// cluster as the endpoint of the validator. The endpoints are probed every
+// interval, a zero interval probes every 30s, and requests go to the healthy
+// endpoint with the lowest latency. An endpoint a request fails to reach or
+// answers with a 5xx response is avoided until the next probe.
+//
+// Probes are sent while the validator is used only, as an unauthenticated
+// request for the server info.
+func WithRegionalEndpoints(endpoints []string, interval time.Duration) ValidatorOption {
+	return func(v *Validator) error {
+		if interval < 0 {
+			return fmt.Errorf("region probe interval must be positive, got %s", interval)
+		}
+		if interval == 0 {
+			interval = defaultRegionProbeInterval
+		}
+		s := &regionSelector{
+			primary:  v.baseURL,
+			interval: interval,
+			now:      time.Now,
+			regions:  []*region{{base: v.baseURL, healthy: true}},
+		}
+		for _, endpoint := range endpoints {
+			u, err := parseEndpoint(endpoint)
+			if err != nil {
+				return err
+			}
+			s.regions = append(s.regions, &region{base: u, healthy: true})
+		}
+		v.regions = s
+		return nil
+	}
+}
+
+// region is an endpoint of a [regionSelector].
+type region struct {
+	base *url.URL
+
+	// latency is the moving average of the probe latency, zero until the
+	// first successful probe.
+	latency time.Duration
+
+	// healthy is unset when the last probe or request failed.
+	healthy bool
+}
+
+// regionSelector sends the requests to the fastest healthy of several
+// endpoints of the same Jira, see [WithRegionalEndpoints].
+type regionSelector struct {
+	// primary is the endpoint of the validator, requests are built for it.
+	primary  *url.URL
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	regions   []*region
+	selected  *region
+	lastProbe time.Time
+	probing   bool
+}
+
+// route sends requests built for the primary endpoint to the selected
+// endpoint, and starts a probe in the background when one is due.
+func (s *regionSelector) route(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		if s.probeDue() {
+			// The probe outlives the request, it only keeps the logger.
+			go s.probe(context.WithoutCancel(ctx), next)
+		}
+
+		r := s.pick(ctx)
+		if r.base != s.primary {
+			req = rebase(req, s.primary, r.base)
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+			s.markUnhealthy(r)
+		}
+		return resp, err //nolint:wrapcheck // Want passthrough
+	})
+}
+
+// rebase returns a copy of req sent to the endpoint to instead of from.
+func rebase(req *http.Request, from, to *url.URL) *http.Request {
+	out := req.Clone(req.Context())
+	out.URL.Scheme = to.Scheme
+	out.URL.Host = to.Host
+	out.URL.Path = strings.TrimSuffix(to.Path, "/") + strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(from.Path, "/"))
+	out.URL.RawPath = ""
+	out.Host = ""
+	return out
+}
+
+// probeDue reports whether a probe should start, and marks it started.
+func (s *regionSelector) probeDue() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.probing || s.now().Sub(s.lastProbe) < s.interval {
+		return false
+	}
+	s.probing = true
+	return true
+}
+
+// probe measures the latency of every endpoint with a server info request.
+func (s *regionSelector) probe(ctx context.Context, transport http.RoundTripper) {
+	type result struct {
+		latency time.Duration
+		healthy bool
+	}
+	s.mu.Lock()
+	regions := append([]*region(nil), s.regions...)
+	s.mu.Unlock()
+
+	results := make([]result, len(regions))
+	var wg sync.WaitGroup
+	for i, r := range regions {
+		wg.Add(1)
+		go func(i int, r *region) {
+			defer wg.Done()
+			latency, err := probeRegion(ctx, transport, r.base)
+			if err != nil {
+				logging.FromContext(ctx).DebugContext(ctx, "jira region probe failed",
+					"endpoint", r.base.String(), "error", err)
+			}
+			results[i] = result{latency: latency, healthy: err == nil}
+		}(i, r)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range regions {
+		r.healthy = results[i].healthy
+		if !r.healthy {
+			continue
+		}
+		if r.latency == 0 {
+			r.latency = results[i].latency
+		} else {
+			r.latency = time.Duration(regionLatencyWeight*float64(results[i].latency) + (1-regionLatencyWeight)*float64(r.latency))
+		}
+	}
+	s.lastProbe = s.now()
+	s.probing = false
+}
+
+// probeRegion returns the latency of a server info request to the endpoint.
+func probeRegion(ctx context.Context, transport http.RoundTripper, base *url.URL) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, regionProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base.JoinPath("serverInfo").String(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return 0, err //nolint:wrapcheck // Want passthrough
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return 0, fmt.Errorf("got response code %d", resp.StatusCode)
+	}
+	return time.Since(start), nil
+}
+
+// pick returns the healthy endpoint with the lowest latency, preferring
+// measured endpoints and the configured order. It returns the primary
+// endpoint when none is healthy.
+func (s *regionSelector) pick(ctx context.Context) *region {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var best *region
+	for _, r := range s.regions {
+		if !r.healthy {
+			continue
+		}
+		if best == nil || (r.latency > 0 && (best.latency == 0 || r.latency < best.latency)) {
+			best = r
+		}
+	}
+	if best == nil {
+		best = s.regions[0]
+	}
+	if best != s.selected {
+		if s.selected != nil {
+			logging.FromContext(ctx).InfoContext(ctx, "switched jira endpoint",
+				"from", s.selected.base.String(),
+				"to", best.base.String(),
+				"latency", best.latency)
+		}
+		s.selected = best
+	}
+	return best
+}
+
+// markUnhealthy avoids the endpoint until the next probe.
+func (s *regionSelector) markUnhealthy(r *region) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.healthy = false
+}
+
+// health returns the state of the endpoints, in the configured order.
+func (s *regionSelector) health() []EndpointHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]EndpointHealth, 0, len(s.regions))
+	for _, r := range s.regions {
+		out = append(out, EndpointHealth{
+			URL:      r.base.Redacted(),
+			Healthy:  r.healthy,
+			Selected: r == s.selected,
+			Latency:  r.latency,
+		})
+	}
+	return out
+}
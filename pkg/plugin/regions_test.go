@@ -0,0 +1,117 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+// newRegionServer starts a Jira region answering the server info after the
+// delay and the issue and match requests with the status.
+func newRegionServer(t *testing.T, delay time.Duration, status *atomic.Int32, issueCalls *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/2/serverInfo", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		fmt.Fprint(w, `{"deploymentType":"Server"}`)
+	})
+	mux.HandleFunc("/rest/api/2/issue/", func(w http.ResponseWriter, r *http.Request) {
+		issueCalls.Add(1)
+		if code := int(status.Load()); code != 0 {
+			w.WriteHeader(code)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/rest/api/2/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRegionalEndpoints(t *testing.T) {
+	t.Parallel()
+
+	var slowStatus, fastStatus, slowCalls, fastCalls atomic.Int32
+	slow := newRegionServer(t, 50*time.Millisecond, &slowStatus, &slowCalls)
+	fast := newRegionServer(t, 0, &fastStatus, &fastCalls)
+
+	v, err := NewValidator(slow.URL+"/rest/api/2", "status NOT IN (Done)", "test@test.com", "secrets",
+		WithRegionalEndpoints([]string{fast.URL + "/rest/api/2"}, time.Hour))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	// The probe is done here rather than by the first request.
+	if !v.regions.probeDue() {
+		t.Fatal("got no probe due before the first probe")
+	}
+	v.regions.probe(ctx, http.DefaultTransport)
+
+	if _, err := v.MatchIssue(ctx, "ABCD-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := fastCalls.Load(), int32(1); got != want {
+		t.Errorf("got %d requests to the fast region, want %d", got, want)
+	}
+
+	// A failing region is avoided until the next probe.
+	fastStatus.Store(http.StatusServiceUnavailable)
+	if _, err := v.MatchIssue(ctx, "ABCD-1"); err == nil {
+		t.Fatal("got no error from the failing region")
+	}
+	if _, err := v.MatchIssue(ctx, "ABCD-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := slowCalls.Load(), int32(1); got != want {
+		t.Errorf("got %d requests to the slow region, want %d", got, want)
+	}
+}
+
+func TestRegionSelector_Pick(t *testing.T) {
+	t.Parallel()
+
+	primary := &region{base: &url.URL{Host: "primary"}, healthy: true}
+	measured := &region{base: &url.URL{Host: "measured"}, healthy: true, latency: 20 * time.Millisecond}
+	faster := &region{base: &url.URL{Host: "faster"}, healthy: false, latency: 5 * time.Millisecond}
+
+	s := &regionSelector{primary: primary.base, regions: []*region{primary, measured, faster}}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+
+	if got := s.pick(ctx); got != measured {
+		t.Errorf("got %s, want the measured healthy region", got.base.Host)
+	}
+	faster.healthy = true
+	if got := s.pick(ctx); got != faster {
+		t.Errorf("got %s, want the fastest region", got.base.Host)
+	}
+	primary.healthy, measured.healthy, faster.healthy = false, false, false
+	if got := s.pick(ctx); got != primary {
+		t.Errorf("got %s without a healthy region, want the primary", got.base.Host)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Annotation field renderers, see [WithAnnotationFieldRenderers].
+const (
+	// fieldRenderText renders objects by their display name, name or value,
+	// it is the default.
+	fieldRenderText = "text"
+
+	// fieldRenderUser renders user pickers by email address, falling back to
+	// the account id when Jira hides the email.
+	fieldRenderUser = "user"
+
+	// fieldRenderOption renders select lists by the option value.
+	fieldRenderOption = "option"
+
+	// fieldRenderDate renders date and date-time pickers as RFC 3339 in UTC.
+	fieldRenderDate = "date"
+
+	// jiraDateTimeLayout is the layout of Jira date-time field values.
+	jiraDateTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+	// jiraDateLayout is the layout of Jira date field values.
+	jiraDateLayout = "2006-01-02"
+
+	// renderedListSeparator separates the rendered values of multi-value
+	// fields.
+	renderedListSeparator = ","
+)
+
+// fieldRenderer renders a field value, it returns false for empty values.
+type fieldRenderer func(json.RawMessage) (string, bool)
+
+// fieldRenderers are the renderers by name.
+var fieldRenderers = map[string]fieldRenderer{
+	fieldRenderText:   renderField,
+	fieldRenderUser:   renderList(renderUser),
+	fieldRenderOption: renderList(renderOption),
+	fieldRenderDate:   renderDate,
+}
+
+// WithAnnotationFieldRenderers sets how annotation fields are rendered, by
+// field name. A renderer is one of "text", the default, "user" for the email
+// of user pickers, "option" for the value of select lists, or "date" for
+// date pickers as RFC 3339. Multi-value fields are rendered as a comma
+// separated list. A value the renderer does not understand is rendered as
+// text.
+func WithAnnotationFieldRenderers(renderers map[string]string) ValidatorOption {
+	return func(v *Validator) error {
+		v.fieldRenderers = make(map[string]fieldRenderer, len(renderers))
+		for name, renderer := range renderers {
+			r, ok := fieldRenderers[renderer]
+			if !ok {
+				return fmt.Errorf("unknown renderer %q for field %q, must be one of text, user, option, date", renderer, name)
+			}
+			v.fieldRenderers[name] = r
+		}
+		return nil
+	}
+}
+
+// splitAnnotationField splits an annotation field setting such as
+// "customfield_10010:user" into the field name and the renderer, which is
+// empty when not given.
+func splitAnnotationField(s string) (string, string) {
+	name, renderer, _ := strings.Cut(s, ":")
+	return name, renderer
+}
+
+// renderAnnotationField renders the field with its renderer.
+func (v *Validator) renderAnnotationField(name string, raw json.RawMessage) (string, bool) {
+	if r, ok := v.fieldRenderers[name]; ok {
+		if s, ok := r(raw); ok {
+			return s, true
+		}
+	}
+	return renderField(raw)
+}
+
+// renderList applies render to every element of a list value, or to the value
+// itself otherwise.
+func renderList(render fieldRenderer) fieldRenderer {
+	return func(raw json.RawMessage) (string, bool) {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || raw[0] != '[' {
+			return render(raw)
+		}
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return "", false
+		}
+		out := make([]string, 0, len(elems))
+		for _, e := range elems {
+			s, ok := render(e)
+			if !ok {
+				return "", false
+			}
+			out = append(out, s)
+		}
+		return strings.Join(out, renderedListSeparator), len(out) > 0
+	}
+}
+
+// renderUser renders a user by email address, or account id.
+func renderUser(raw json.RawMessage) (string, bool) {
+	var user struct {
+		EmailAddress string `json:"emailAddress"`
+		AccountID    string `json:"accountId"`
+		Name         string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &user); err != nil {
+		return "", false
+	}
+	// Jira Data Center has no account ids, users are identified by name.
+	for _, s := range []string{user.EmailAddress, user.AccountID, user.Name} {
+		if s != "" {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// renderOption renders a select list option by its value.
+func renderOption(raw json.RawMessage) (string, bool) {
+	var option struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &option); err != nil || option.Value == "" {
+		return "", false
+	}
+	return option.Value, true
+}
+
+// renderDate renders a date or date-time as RFC 3339 in UTC, a date is
+// midnight UTC.
+func renderDate(raw json.RawMessage) (string, bool) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil || s == "" {
+		return "", false
+	}
+	t, err := parseJiraTime(s, time.UTC)
+	if err != nil {
+		return "", false
+	}
+	return t.UTC().Format(time.RFC3339), true
+}
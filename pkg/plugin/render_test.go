@@ -0,0 +1,184 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestFieldRenderers(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		renderer string
+		raw      string
+		want     string
+		wantOK   bool
+	}{
+		{
+			name:     "user_email",
+			renderer: fieldRenderUser,
+			raw:      `{"accountId":"5b10a","emailAddress":"jane@example.com","displayName":"Jane"}`,
+			want:     "jane@example.com",
+			wantOK:   true,
+		},
+		{
+			name:     "user_hidden_email",
+			renderer: fieldRenderUser,
+			raw:      `{"accountId":"5b10a","displayName":"Jane"}`,
+			want:     "5b10a",
+			wantOK:   true,
+		},
+		{
+			name:     "user_data_center",
+			renderer: fieldRenderUser,
+			raw:      `{"name":"jane","displayName":"Jane"}`,
+			want:     "jane",
+			wantOK:   true,
+		},
+		{
+			name:     "multi_user",
+			renderer: fieldRenderUser,
+			raw:      `[{"emailAddress":"jane@example.com"},{"emailAddress":"joe@example.com"}]`,
+			want:     "jane@example.com,joe@example.com",
+			wantOK:   true,
+		},
+		{
+			name:     "user_null",
+			renderer: fieldRenderUser,
+			raw:      "null",
+		},
+		{
+			name:     "option",
+			renderer: fieldRenderOption,
+			raw:      `{"self":"x","value":"Sev 1","id":"10000"}`,
+			want:     "Sev 1",
+			wantOK:   true,
+		},
+		{
+			name:     "multi_option",
+			renderer: fieldRenderOption,
+			raw:      `[{"value":"db"},{"value":"network"}]`,
+			want:     "db,network",
+			wantOK:   true,
+		},
+		{
+			name:     "empty_multi_option",
+			renderer: fieldRenderOption,
+			raw:      `[]`,
+		},
+		{
+			name:     "option_not_an_option",
+			renderer: fieldRenderOption,
+			raw:      `"Sev 1"`,
+		},
+		{
+			name:     "date",
+			renderer: fieldRenderDate,
+			raw:      `"2023-04-05"`,
+			want:     "2023-04-05T00:00:00Z",
+			wantOK:   true,
+		},
+		{
+			name:     "date_time",
+			renderer: fieldRenderDate,
+			raw:      `"2023-04-05T10:30:00.000+0200"`,
+			want:     "2023-04-05T08:30:00Z",
+			wantOK:   true,
+		},
+		{
+			name:     "date_invalid",
+			renderer: fieldRenderDate,
+			raw:      `"next week"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := fieldRenderers[tc.renderer](json.RawMessage(tc.raw))
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("%s renderer(%s) got (%q, %t), want (%q, %t)", tc.renderer, tc.raw, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidator_ProjectFields_Renderers(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA", "test@test.com", "secrets",
+		WithAnnotationFields([]string{"assignee", "customfield_10010", "duedate", "reporter"}, 0),
+		WithAnnotationFieldRenderers(map[string]string{
+			"assignee":          fieldRenderUser,
+			"customfield_10010": fieldRenderOption,
+			"duedate":           fieldRenderDate,
+		}))
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	got := v.projectFields("JRA-1", map[string]json.RawMessage{
+		"assignee":          json.RawMessage(`{"emailAddress":"jane@example.com","displayName":"Jane"}`),
+		"customfield_10010": json.RawMessage(`"free text"`),
+		"duedate":           json.RawMessage(`"2023-04-05"`),
+		"reporter":          json.RawMessage(`{"emailAddress":"joe@example.com","displayName":"Joe"}`),
+	})
+
+	// A value the renderer does not understand falls back to text, as do
+	// fields without a renderer.
+	want := map[string]string{
+		"assignee":          "jane@example.com",
+		"customfield_10010": "free text",
+		"duedate":           "2023-04-05T00:00:00Z",
+		"reporter":          "Joe",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("projectFields() unexpected diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWithAnnotationFieldRenderers(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA", "test@test.com", "secrets",
+		WithAnnotationFieldRenderers(map[string]string{"duedate": "time"}))
+	if diff := testutil.DiffErrString(err, `unknown renderer "time" for field "duedate"`); diff != "" {
+		t.Errorf(diff)
+	}
+}
+
+func TestPluginConfig_AnnotationFields(t *testing.T) {
+	t.Parallel()
+
+	cfg := &PluginConfig{AnnotationFields: []string{"summary", "customfield_10010:user", "duedate:date"}}
+
+	if diff := cmp.Diff([]string{"summary", "customfield_10010", "duedate"}, cfg.AnnotationFieldNames()); diff != "" {
+		t.Errorf("AnnotationFieldNames() unexpected diff (-want,+got):\n%s", diff)
+	}
+	wantRenderers := map[string]string{"customfield_10010": "user", "duedate": "date"}
+	if diff := cmp.Diff(wantRenderers, cfg.annotationFieldRenderers()); diff != "" {
+		t.Errorf("annotationFieldRenderers() unexpected diff (-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// replayBodyMaxBytes limits the size of each recorded request and response
+// body, longer bodies are truncated.
+const replayBodyMaxBytes = 16 * 1024
+
+// replayRedactedHeaders are the headers never recorded because they carry
+// credentials.
+var replayRedactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// ReplayExchange is a recorded request to Jira and its response.
+type ReplayExchange struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestHeader  http.Header `json:"request_header,omitempty"`
+	RequestBody    string      `json:"request_body,omitempty"`
+	Status         int         `json:"status,omitempty"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   string      `json:"response_body,omitempty"`
+
+	// Error is the transport error, if the request did not get a response.
+	Error string `json:"error,omitempty"`
+
+	Duration time.Duration `json:"duration"`
+}
+
+// ReplayRecord is a failed validation with the Jira requests it made.
+type ReplayRecord struct {
+	Time      time.Time         `json:"time"`
+	Category  string            `json:"category"`
+	Value     string            `json:"value"`
+	Errors    []string          `json:"errors,omitempty"`
+	Exchanges []*ReplayExchange `json:"exchanges"`
+}
+
+// replayRecorder keeps the last failed validations in a ring buffer, so a
+// complaint can be reproduced with the responses Jira actually returned.
+type replayRecorder struct {
+	mu      sync.Mutex
+	records []*ReplayRecord
+	next    int
+	full    bool
+}
+
+// newReplayRecorder creates a recorder keeping the last size records, it
+// returns nil when size is not positive.
+func newReplayRecorder(size int) *replayRecorder {
+	if size <= 0 {
+		return nil
+	}
+	return &replayRecorder{records: make([]*ReplayRecord, size)}
+}
+
+// add records rec, replacing the oldest record when the buffer is full.
+func (r *replayRecorder) add(rec *ReplayRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot returns the records, oldest first.
+func (r *replayRecorder) snapshot() []*ReplayRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]*ReplayRecord(nil), r.records[:r.next]...)
+	}
+	out := make([]*ReplayRecord, 0, len(r.records))
+	out = append(out, r.records[r.next:]...)
+	return append(out, r.records[:r.next]...)
+}
+
+// replayCollector collects the exchanges of a single validation.
+type replayCollector struct {
+	mu        sync.Mutex
+	exchanges []*ReplayExchange
+}
+
+type replayCollectorKey struct{}
+
+// withReplayCollector returns a context whose Jira requests are collected by
+// the returned collector.
+func withReplayCollector(ctx context.Context) (context.Context, *replayCollector) {
+	c := &replayCollector{}
+	return context.WithValue(ctx, replayCollectorKey{}, c), c
+}
+
+// add records an exchange.
+func (c *replayCollector) add(e *ReplayExchange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exchanges = append(c.exchanges, e)
+}
+
+// list returns the recorded exchanges.
+func (c *replayCollector) list() []*ReplayExchange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*ReplayExchange(nil), c.exchanges...)
+}
+
+// replayTransport records the requests made with a context from
+// [withReplayCollector], other requests are passed through untouched.
+type replayTransport struct {
+	next http.RoundTripper
+}
+
+// withReplay makes the validator record its requests for the replay log.
+func withReplay() ValidatorOption {
+	return WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+		return &replayTransport{next: next}
+	})
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c, ok := req.Context().Value(replayCollectorKey{}).(*replayCollector)
+	if !ok {
+		return t.next.RoundTrip(req) //nolint:wrapcheck // Want passthrough
+	}
+
+	e := &ReplayExchange{
+		Method:        req.Method,
+		URL:           req.URL.String(),
+		RequestHeader: redactHeader(req.Header),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			e.RequestBody = readReplayBody(body)
+			body.Close()
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	e.Duration = time.Since(start)
+	defer c.add(e)
+	if err != nil {
+		e.Error = err.Error()
+		return nil, err //nolint:wrapcheck // Want passthrough
+	}
+
+	e.Status = resp.StatusCode
+	e.ResponseHeader = redactHeader(resp.Header)
+
+	// Buffer what the validator reads at most, so it sees the same response.
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, jiraResponseSizeLimitBytes+1))
+	resp.Body.Close()
+	if err != nil {
+		e.Error = fmt.Sprintf("failed to read response: %s", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(buf))
+	e.ResponseBody = truncateReplayBody(buf)
+	return resp, nil
+}
+
+// redactHeader returns a copy of h without credentials.
+func redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, k := range replayRedactedHeaders {
+		out.Del(k)
+	}
+	return out
+}
+
+// readReplayBody reads r for recording.
+func readReplayBody(r io.Reader) string {
+	buf, _ := io.ReadAll(io.LimitReader(r, replayBodyMaxBytes+1))
+	return truncateReplayBody(buf)
+}
+
+// truncateReplayBody returns the body for recording, at most
+// replayBodyMaxBytes long.
+func truncateReplayBody(buf []byte) string {
+	if len(buf) > replayBodyMaxBytes {
+		return string(buf[:replayBodyMaxBytes]) + "...(truncated)"
+	}
+	return string(buf)
+}
+
+// DumpReplay writes the recorded failed validations to w as JSON lines,
+// oldest first, and returns the number of records written. It writes nothing
+// when the replay log is disabled, see [PluginConfig.ReplayBufferSize].
+func (j *JiraPlugin) DumpReplay(w io.Writer) (int, error) {
+	if j.replay == nil {
+		return 0, nil
+	}
+
+	records := j.replay.snapshot()
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return 0, fmt.Errorf("failed to write replay record: %w", err)
+		}
+	}
+	return len(records), nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestReplayRecorder(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name   string
+		size   int
+		values []string
+		want   []string
+	}{
+		{
+			name:   "not_full",
+			size:   3,
+			values: []string{"a", "b"},
+			want:   []string{"a", "b"},
+		},
+		{
+			name:   "wraps_around",
+			size:   3,
+			values: []string{"a", "b", "c", "d", "e"},
+			want:   []string{"c", "d", "e"},
+		},
+		{
+			name:   "exactly_full",
+			size:   2,
+			values: []string{"a", "b"},
+			want:   []string{"a", "b"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newReplayRecorder(tc.size)
+			for _, v := range tc.values {
+				r.add(&ReplayRecord{Value: v})
+			}
+
+			got := make([]string, 0, len(tc.want))
+			for _, rec := range r.snapshot() {
+				got = append(got, rec.Value)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("snapshot() unexpected diff (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlugin_DumpReplay(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/issue/ABCD-2", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errorMessages":["Issue does not exist or you do not have permission to see it."]}`)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := &PluginConfig{
+		JIRAEndpoint:     srv.URL,
+		Jql:              "status NOT IN (Done)",
+		JIRAAccount:      "test@test.com",
+		IssueBaseURL:     "https://example.atlassian.net",
+		ReplayBufferSize: 10,
+	}
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	for _, key := range []string{"ABCD-1", "ABCD-2"} {
+		if _, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+			Justification: &jvspb.Justification{Category: jiraCategory, Value: key},
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	n, err := p.DumpReplay(&buf)
+	if err != nil {
+		t.Fatalf("failed to dump replay log: %v", err)
+	}
+	if got, want := n, 1; got != want {
+		t.Fatalf("got %d records, want %d, only failed validations are recorded", got, want)
+	}
+
+	var got ReplayRecord
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("failed to decode replay record: %v", err)
+	}
+	if got, want := got.Value, "ABCD-2"; got != want {
+		t.Errorf("got value %q, want %q", got, want)
+	}
+	if got, want := len(got.Exchanges), 1; got != want {
+		t.Fatalf("got %d exchanges, want %d", got, want)
+	}
+	e := got.Exchanges[0]
+	if got, want := e.Status, http.StatusNotFound; got != want {
+		t.Errorf("got status %d, want %d", got, want)
+	}
+	if e.RequestHeader.Get("Authorization") != "" {
+		t.Errorf("Authorization header was recorded")
+	}
+	if e.ResponseHeader.Get("Set-Cookie") != "" {
+		t.Errorf("Set-Cookie header was recorded")
+	}
+	if got, want := e.ResponseBody, `{"errorMessages":["Issue does not exist or you do not have permission to see it."]}`; got != want {
+		t.Errorf("got response body %q, want %q", got, want)
+	}
+}
+
+func TestPlugin_DumpReplay_Disabled(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	p, err := NewJiraPluginWithToken(ctx, newFakeJira(t).config(), "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(context.Background()) })
+
+	var buf bytes.Buffer
+	if n, err := p.DumpReplay(&buf); err != nil || n != 0 || buf.Len() != 0 {
+		t.Errorf("DumpReplay() got (%d, %v) and %q, want nothing written", n, err, buf.String())
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// RequestorMetadataKey is the gRPC metadata key of the identity of the
+	// requestor, e.g. an email address.
+	RequestorMetadataKey = "jvs-requestor"
+
+	// RequestorGroupsMetadataKey is the gRPC metadata key of the groups of
+	// the requestor, it may be repeated or hold comma separated groups.
+	RequestorGroupsMetadataKey = "jvs-requestor-groups"
+)
+
+// Requestor identifies who asked for the justification being validated.
+type Requestor struct {
+	// Subject is the identity of the requestor, e.g. an email address.
+	Subject string
+
+	// Groups are the groups the requestor belongs to.
+	Groups []string
+}
+
+type requestorKey struct{}
+
+// WithRequestor returns a context carrying the requestor of the validation,
+// for callers using the plugin as a library.
+func WithRequestor(ctx context.Context, r *Requestor) context.Context {
+	return context.WithValue(ctx, requestorKey{}, r)
+}
+
+// requestorFromContext returns the requestor set with [WithRequestor], or
+// the one the JVS server sent in the gRPC metadata of the request, or nil
+// when it is unknown. The metadata can be trusted because only the host that
+// launched the plugin can connect to it.
+func requestorFromContext(ctx context.Context) *Requestor {
+	if r, ok := ctx.Value(requestorKey{}).(*Requestor); ok {
+		return r
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	subjects := md.Get(RequestorMetadataKey)
+	if len(subjects) == 0 || subjects[0] == "" {
+		return nil
+	}
+
+	r := &Requestor{Subject: subjects[0]}
+	for _, v := range md.Get(RequestorGroupsMetadataKey) {
+		for _, g := range strings.Split(v, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				r.Groups = append(r.Groups, g)
+			}
+		}
+	}
+	return r
+}
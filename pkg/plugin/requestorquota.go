@@ -0,0 +1,171 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// RequestorQuotaModeReject rejects validations of requestors over their
+	// quota.
+	RequestorQuotaModeReject = "reject"
+
+	// RequestorQuotaModeWarn accepts validations of requestors over their
+	// quota with a warning.
+	RequestorQuotaModeWarn = "warn"
+
+	// defaultRequestorQuotaWindow is the default rolling window of the
+	// requestor quota.
+	defaultRequestorQuotaWindow = 24 * time.Hour
+)
+
+// requestorCounter counts the successful validations of each requestor over
+// a rolling window.
+type requestorCounter interface {
+	// count returns the validations of the subject in the window ending at
+	// now.
+	count(ctx context.Context, subject string, now time.Time) (int, error)
+
+	// add records a validation of the subject at now.
+	add(ctx context.Context, subject string, now time.Time) error
+}
+
+// requestorQuota limits the successful validations of each requestor over a
+// rolling window, to catch abuse such as scripted access with a single
+// evergreen ticket. Validations of unknown requestors are not counted.
+type requestorQuota struct {
+	limit   int
+	window  time.Duration
+	warn    bool
+	counter requestorCounter
+
+	now func() time.Time
+}
+
+// newRequestorQuota creates the requestor quota, it returns nil when the
+// limit is zero. A zero window defaults to 24 hours.
+func newRequestorQuota(limit int, window time.Duration, mode string) *requestorQuota {
+	if limit <= 0 {
+		return nil
+	}
+	if window <= 0 {
+		window = defaultRequestorQuotaWindow
+	}
+	return &requestorQuota{
+		limit:   limit,
+		window:  window,
+		warn:    mode == RequestorQuotaModeWarn,
+		counter: newMemoryRequestorCounter(window, limit),
+		now:     time.Now,
+	}
+}
+
+// check returns an error wrapping [ErrQuotaExceeded] when the requestor
+// used up the quota and it rejects, or a warning when it only warns.
+func (q *requestorQuota) check(ctx context.Context, r *Requestor) (string, error) {
+	if r == nil || r.Subject == "" {
+		return "", nil
+	}
+	n, err := q.counter.count(ctx, r.Subject, q.now())
+	if err != nil {
+		return "", fmt.Errorf("failed to count validations of requestor %q: %w", r.Subject, err)
+	}
+	if n < q.limit {
+		return "", nil
+	}
+	msg := fmt.Sprintf("requestor %q reached the quota of %d validations per %s", r.Subject, q.limit, q.window)
+	if q.warn {
+		return msg, nil
+	}
+	return "", fmt.Errorf("%s: %w", msg, ErrQuotaExceeded)
+}
+
+// record counts a successful validation of the requestor.
+func (q *requestorQuota) record(ctx context.Context, r *Requestor) error {
+	if r == nil || r.Subject == "" {
+		return nil
+	}
+	if err := q.counter.add(ctx, r.Subject, q.now()); err != nil {
+		return fmt.Errorf("failed to count validation of requestor %q: %w", r.Subject, err)
+	}
+	return nil
+}
+
+// memoryRequestorCounter is a [requestorCounter] in memory. It keeps at most
+// max timestamps per requestor, counts saturate at max.
+type memoryRequestorCounter struct {
+	window time.Duration
+	max    int
+
+	mu        sync.Mutex
+	times     map[string][]time.Time
+	lastSweep time.Time
+}
+
+func newMemoryRequestorCounter(window time.Duration, max int) *memoryRequestorCounter {
+	return &memoryRequestorCounter{
+		window: window,
+		max:    max,
+		times:  make(map[string][]time.Time),
+	}
+}
+
+func (c *memoryRequestorCounter) count(_ context.Context, subject string, now time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.prune(subject, now)), nil
+}
+
+func (c *memoryRequestorCounter) add(_ context.Context, subject string, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	times := append(c.prune(subject, now), now)
+	if len(times) > c.max {
+		times = times[len(times)-c.max:]
+	}
+	c.times[subject] = times
+
+	// Requestors that stopped validating are forgotten once per window.
+	if now.Sub(c.lastSweep) >= c.window {
+		for s := range c.times {
+			c.prune(s, now)
+		}
+		c.lastSweep = now
+	}
+	return nil
+}
+
+// prune drops the timestamps of the subject outside the window ending at
+// now, and the subject when none is left. c.mu must be held.
+func (c *memoryRequestorCounter) prune(subject string, now time.Time) []time.Time {
+	times := c.times[subject]
+	i := 0
+	for i < len(times) && now.Sub(times[i]) >= c.window {
+		i++
+	}
+	times = times[i:]
+	if len(times) == 0 {
+		delete(c.times, subject)
+		return nil
+	}
+	c.times[subject] = times
+	return times
+}
@@ -0,0 +1,197 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestNewRequestorQuota_Unlimited(t *testing.T) {
+	t.Parallel()
+
+	if q := newRequestorQuota(0, time.Hour, RequestorQuotaModeReject); q != nil {
+		t.Errorf("got quota %#v, want nil", q)
+	}
+	if got, want := newRequestorQuota(1, 0, "").window, defaultRequestorQuotaWindow; got != want {
+		t.Errorf("got window %s, want %s", got, want)
+	}
+}
+
+func TestMemoryRequestorCounter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newMemoryRequestorCounter(time.Hour, 3)
+
+	for i := 0; i < 5; i++ {
+		if err := c.add(ctx, "a@xyz.com", now.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.add(ctx, "b@xyz.com", now); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		subject string
+		at      time.Time
+		want    int
+	}{
+		// Counts saturate at the limit.
+		{subject: "a@xyz.com", at: now.Add(5 * time.Minute), want: 3},
+		{subject: "a@xyz.com", at: now.Add(time.Hour + 3*time.Minute), want: 1},
+		{subject: "a@xyz.com", at: now.Add(2 * time.Hour), want: 0},
+		{subject: "c@xyz.com", at: now, want: 0},
+	}
+	for _, tc := range cases {
+		got, err := c.count(ctx, tc.subject, tc.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("count(%q, %s) got %d, want %d", tc.subject, tc.at, got, tc.want)
+		}
+	}
+
+	// Idle requestors are forgotten.
+	if err := c.add(ctx, "a@xyz.com", now.Add(3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.times["b@xyz.com"]; ok {
+		t.Errorf("idle requestor was not forgotten")
+	}
+}
+
+func TestPlugin_Validate_RequestorQuota(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		mode        string
+		wantCode    codes.Code
+		wantWarning string
+	}{
+		{
+			name:     "reject",
+			mode:     RequestorQuotaModeReject,
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name:        "warn",
+			mode:        RequestorQuotaModeWarn,
+			wantCode:    codes.OK,
+			wantWarning: `requestor "a@xyz.com" reached the quota of 2 validations per 1h0m0s`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := newTestPlugin(&snapshot{
+				validator: &mockValidator{
+					result: &MatchResult{Matches: []*Match{{MatchedIssues: []int{1}, Errors: []string{}}}},
+				},
+				issueBaseURL: "https://example.atlassian.net",
+			})
+			p.requestorQuota = newRequestorQuota(2, time.Hour, tc.mode)
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			req := &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+			}
+
+			// Invalid and anonymous validations do not count.
+			if resp, err := p.Validate(WithRequestor(ctx, &Requestor{Subject: "a@xyz.com"}), &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{Category: "jira", Value: "not a key"},
+			}); err != nil || resp.GetValid() {
+				t.Fatalf("got %v, %v, want an invalid response", resp, err)
+			}
+			for i := 0; i < 3; i++ {
+				if _, err := p.Validate(ctx, req); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			actx := WithRequestor(ctx, &Requestor{Subject: "a@xyz.com"})
+			for i := 0; i < 2; i++ {
+				if _, err := p.Validate(actx, req); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			resp, err := p.Validate(actx, req)
+			if got := status.Code(err); got != tc.wantCode {
+				t.Errorf("got code %s, want %s", got, tc.wantCode)
+			}
+			if tc.wantWarning != "" {
+				if got := resp.GetWarning(); len(got) != 1 || got[0] != tc.wantWarning {
+					t.Errorf("got warnings %q, want %q", got, tc.wantWarning)
+				}
+			}
+
+			// Other requestors have their own quota.
+			if _, err := p.Validate(WithRequestor(ctx, &Requestor{Subject: "b@xyz.com"}), req); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// failingCounter is a requestorCounter whose store is down.
+type failingCounter struct{}
+
+func (failingCounter) count(context.Context, string, time.Time) (int, error) {
+	return 0, errors.New("store unavailable")
+}
+
+func (failingCounter) add(context.Context, string, time.Time) error {
+	return errors.New("store unavailable")
+}
+
+func TestPlugin_Validate_RequestorQuotaFailsOpen(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPlugin(&snapshot{
+		validator: &mockValidator{
+			result: &MatchResult{Matches: []*Match{{MatchedIssues: []int{1}, Errors: []string{}}}},
+		},
+		issueBaseURL: "https://example.atlassian.net",
+	})
+	p.requestorQuota = newRequestorQuota(1, time.Hour, RequestorQuotaModeReject)
+	p.requestorQuota.counter = failingCounter{}
+
+	ctx := WithRequestor(logging.WithLogger(context.Background(), logging.TestLogger(t)), &Requestor{Subject: "a@xyz.com"})
+	resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+		Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.GetValid() {
+		t.Errorf("expected a valid response, got errors %q", resp.GetError())
+	}
+}
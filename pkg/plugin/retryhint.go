@@ -0,0 +1,67 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// defaultRetryHint is the retry delay suggested for a validation failing
+// because Jira is unavailable, when Jira did not send a Retry-After.
+const defaultRetryHint = time.Second
+
+// parseRetryAfter returns the delay of the Retry-After header, given in
+// seconds or as an HTTP date, and false when there is none.
+func parseRetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// unavailableStatus returns the Unavailable status of an error wrapping
+// [ErrJiraUnreachable], with a RetryInfo detail suggesting when to retry
+// the validation: the Retry-After of Jira, or [defaultRetryHint], or
+// [defaultMaintenanceRetryHint] when Jira is under maintenance.
+func unavailableStatus(err error) error {
+	delay, ok := RetryAfter(err)
+	if !ok {
+		delay = defaultRetryHint
+		if errors.Is(err, ErrJiraMaintenance) {
+			delay = defaultMaintenanceRetryHint
+		}
+	}
+
+	st := status.New(codes.Unavailable, err.Error())
+	if withDetails, derr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}); derr == nil {
+		st = withDetails
+	}
+	return st.Err() //nolint:wrapcheck // Want a status error
+}
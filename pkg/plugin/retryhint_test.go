@@ -0,0 +1,174 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			name: "missing",
+		},
+		{
+			name:   "seconds",
+			value:  "30",
+			want:   30 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "date",
+			value:  "Fri, 01 Mar 2024 12:01:00 GMT",
+			want:   time.Minute,
+			wantOK: true,
+		},
+		{
+			name:   "past_date",
+			value:  "Fri, 01 Mar 2024 11:00:00 GMT",
+			wantOK: true,
+		},
+		{
+			name:  "invalid",
+			value: "soon",
+		},
+		{
+			name:  "negative",
+			value: "-1",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := http.Header{}
+			if tc.value != "" {
+				h.Set("Retry-After", tc.value)
+			}
+			got, ok := parseRetryAfter(h, now)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("parseRetryAfter(%q) got (%s, %t), want (%s, %t)", tc.value, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestPlugin_Validate_RetryHint(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name        string
+		code        int
+		contentType string
+		retryAfter  string
+		wantDelay   time.Duration
+	}{
+		{
+			name:      "unavailable",
+			code:      http.StatusServiceUnavailable,
+			wantDelay: defaultRetryHint,
+		},
+		{
+			name:       "unavailable_retry_after",
+			code:       http.StatusServiceUnavailable,
+			retryAfter: "30",
+			wantDelay:  30 * time.Second,
+		},
+		{
+			name:        "maintenance",
+			code:        http.StatusServiceUnavailable,
+			contentType: "text/html",
+			wantDelay:   defaultMaintenanceRetryHint,
+		},
+		{
+			name:       "rate_limited",
+			code:       http.StatusTooManyRequests,
+			retryAfter: "5",
+			wantDelay:  5 * time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				if tc.contentType != "" {
+					w.Header().Set("Content-Type", tc.contentType)
+				}
+				w.WriteHeader(tc.code)
+			}))
+			t.Cleanup(srv.Close)
+
+			p, err := NewJiraPluginWithToken(context.Background(), &PluginConfig{
+				JIRAEndpoint: srv.URL,
+				Jql:          "status NOT IN (Done)",
+				JIRAAccount:  "test@test.com",
+				IssueBaseURL: "https://example.atlassian.net",
+			}, "secrets")
+			if err != nil {
+				t.Fatalf("failed to create plugin: %v", err)
+			}
+			t.Cleanup(func() { p.Close(context.Background()) })
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			_, err = p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+			})
+
+			st := status.Convert(err)
+			if got, want := st.Code(), codes.Unavailable; got != want {
+				t.Fatalf("got code %s, want %s: %v", got, want, err)
+			}
+			var gotDelay time.Duration
+			for _, d := range st.Details() {
+				if ri, ok := d.(*errdetails.RetryInfo); ok {
+					gotDelay = ri.GetRetryDelay().AsDuration()
+				}
+			}
+			if gotDelay != tc.wantDelay {
+				t.Errorf("got retry delay %s, want %s", gotDelay, tc.wantDelay)
+			}
+		})
+	}
+}
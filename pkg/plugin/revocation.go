@@ -0,0 +1,473 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+	"google.golang.org/api/idtoken"
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+const (
+	// defaultRevocationInterval is how often accepted decisions are
+	// validated again when no interval is configured.
+	defaultRevocationInterval = 5 * time.Minute
+
+	// defaultRevocationWindow is how long accepted decisions are validated
+	// again when no window is configured, the default maximum lifetime of a
+	// JVS token.
+	defaultRevocationWindow = 24 * time.Hour
+
+	// revocationPublishTimeout bounds how long publishing one revocation may
+	// take.
+	revocationPublishTimeout = 30 * time.Second
+
+	// revocationMaxTracked bounds the number of accepted decisions validated
+	// again. Decisions accepted while it is reached are not tracked.
+	revocationMaxTracked = 10000
+)
+
+// pubsubTopicPattern matches the Pub/Sub topic names of
+// [PluginConfig.RevocationDestination].
+var pubsubTopicPattern = regexp.MustCompile(`^projects/[^/]+/topics/[^/]+$`)
+
+// Revocation reports that a justification JVS accepted no longer qualifies,
+// e.g. because its issue was closed, so that the tokens minted with it can
+// be revoked, see [PluginConfig.RevocationDestination].
+type Revocation struct {
+	// Time is when the justification was found to no longer qualify.
+	Time time.Time `json:"time"`
+
+	// AcceptedAt is when the justification was accepted.
+	AcceptedAt time.Time `json:"accepted_at"`
+
+	Category  string `json:"category"`
+	Value     string `json:"value"`
+	Requestor string `json:"requestor,omitempty"`
+
+	// IssueID is the ID of the issue of the justification, ChangeIssueID
+	// the ID of its change ticket if any.
+	IssueID       string `json:"issue_id"`
+	ChangeIssueID string `json:"change_issue_id,omitempty"`
+
+	// Reason is why the justification no longer qualifies.
+	Reason string `json:"reason"`
+
+	// Annotation is the annotation returned to JVS when the justification
+	// was accepted, which JVS copies into the tokens it mints.
+	Annotation map[string]string `json:"annotation"`
+}
+
+// revocationPublisher delivers revocations to a destination.
+type revocationPublisher interface {
+	publish(ctx context.Context, r *Revocation) error
+	String() string
+}
+
+// newRevocationPublisher returns the publisher of dest, a Pub/Sub topic or
+// a URL. Requests to a URL carry a Google ID token for the audience, unless
+// it is empty.
+func newRevocationPublisher(dest, audience string) revocationPublisher {
+	if pubsubTopicPattern.MatchString(dest) {
+		return &pubsubRevocationPublisher{
+			topic: dest,
+			newService: func(ctx context.Context) (*pubsub.Service, error) {
+				return pubsub.NewService(ctx, option.WithScopes(pubsub.PubsubScope)) //nolint:wrapcheck // Want passthrough
+			},
+		}
+	}
+	p := &httpRevocationPublisher{url: dest}
+	if audience == "" {
+		p.newClient = func(context.Context) (*http.Client, error) {
+			return &http.Client{}, nil
+		}
+	} else {
+		p.newClient = func(ctx context.Context) (*http.Client, error) {
+			return idtoken.NewClient(ctx, audience) //nolint:wrapcheck // Want passthrough
+		}
+	}
+	return p
+}
+
+// httpRevocationPublisher posts the revocations as JSON to a URL, e.g. a JVS
+// revocation or denylist API. Any 2xx response is a success.
+type httpRevocationPublisher struct {
+	url string
+
+	// newClient creates the client, it is mockable for testing.
+	newClient func(context.Context) (*http.Client, error)
+
+	mu     sync.Mutex
+	client *http.Client
+}
+
+func (p *httpRevocationPublisher) publish(ctx context.Context, r *Revocation) error {
+	p.mu.Lock()
+	if p.client == nil {
+		client, err := p.newClient(ctx)
+		if err != nil {
+			p.mu.Unlock()
+			return fmt.Errorf("failed to set up revocation client: %w", err)
+		}
+		p.client = client
+	}
+	client := p.client
+	p.mu.Unlock()
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revocation: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create revocation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post revocation to %s: %w", p, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to post revocation to %s, got response code %d", p, resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *httpRevocationPublisher) String() string {
+	return redactURL(p.url)
+}
+
+// pubsubRevocationPublisher publishes the revocations as JSON messages to a
+// Pub/Sub topic, with the category and issue ID as attributes for
+// subscription filters.
+type pubsubRevocationPublisher struct {
+	topic string
+
+	// newService creates the client, it is mockable for testing.
+	newService func(context.Context) (*pubsub.Service, error)
+
+	mu      sync.Mutex
+	service *pubsub.Service
+}
+
+func (p *pubsubRevocationPublisher) publish(ctx context.Context, r *Revocation) error {
+	p.mu.Lock()
+	if p.service == nil {
+		service, err := p.newService(ctx)
+		if err != nil {
+			p.mu.Unlock()
+			return fmt.Errorf("failed to set up pub/sub client: %w", err)
+		}
+		p.service = service
+	}
+	service := p.service
+	p.mu.Unlock()
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revocation: %w", err)
+	}
+	msg := &pubsub.PubsubMessage{
+		Data: base64.StdEncoding.EncodeToString(data),
+		Attributes: map[string]string{
+			"category": r.Category,
+			"issue_id": r.IssueID,
+		},
+	}
+	if _, err := service.Projects.Topics.Publish(p.topic, &pubsub.PublishRequest{
+		Messages: []*pubsub.PubsubMessage{msg},
+	}).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to publish revocation to %s: %w", p.topic, err)
+	}
+	return nil
+}
+
+func (p *pubsubRevocationPublisher) String() string {
+	return p.topic
+}
+
+// revocationKey identifies the tracked decisions, a decision replaces the
+// one accepted before for the same issues and requestor.
+type revocationKey struct {
+	issueID       string
+	changeIssueID string
+	requestor     string
+}
+
+// trackedDecision is an accepted decision validated again until it expires.
+type trackedDecision struct {
+	key       revocationKey
+	decision  *Decision
+	requestor *Requestor
+	expiresAt time.Time
+}
+
+// revoker validates the accepted decisions again on an interval and
+// publishes a [Revocation] for those whose issues no longer qualify.
+type revoker struct {
+	publisher revocationPublisher
+	interval  time.Duration
+	window    time.Duration
+	logger    *slog.Logger
+
+	// now returns the current time, it is mockable for testing.
+	now func() time.Time
+
+	mu        sync.Mutex
+	decisions map[revocationKey]*trackedDecision
+	full      bool
+
+	cancel context.CancelFunc
+	doneCh chan struct{}
+}
+
+// newRevoker returns a revoker publishing to cfg.RevocationDestination, or
+// nil when it is empty. It runs once started, see [revoker.start].
+func newRevoker(ctx context.Context, cfg *PluginConfig) *revoker {
+	if cfg.RevocationDestination == "" {
+		return nil
+	}
+	r := &revoker{
+		publisher: newRevocationPublisher(cfg.RevocationDestination, cfg.RevocationAudience),
+		interval:  cfg.RevocationInterval,
+		window:    cfg.RevocationWindow,
+		logger:    logging.FromContext(ctx),
+		now:       time.Now,
+		decisions: make(map[revocationKey]*trackedDecision),
+	}
+	if r.interval == 0 {
+		r.interval = defaultRevocationInterval
+	}
+	if r.window == 0 {
+		r.window = defaultRevocationWindow
+	}
+	return r
+}
+
+// track starts validating the accepted decision again. Decisions without an
+// issue, e.g. bypassed ones, are ignored.
+func (r *revoker) track(d *Decision, requestor *Requestor) {
+	issueID := d.Annotation[jiraIssueID]
+	if !d.Valid || d.Bypassed || issueID == "" {
+		return
+	}
+	key := revocationKey{
+		issueID:       issueID,
+		changeIssueID: d.Annotation[jiraChangeIssueID],
+		requestor:     d.Requestor,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.decisions[key]; !ok && len(r.decisions) >= revocationMaxTracked {
+		if !r.full {
+			r.logger.Warn("too many accepted decisions to validate again, new ones are not tracked until some expire",
+				"max", revocationMaxTracked)
+			r.full = true
+		}
+		return
+	}
+	r.decisions[key] = &trackedDecision{
+		key:       key,
+		decision:  d,
+		requestor: requestor,
+		expiresAt: d.Time.Add(r.window),
+	}
+}
+
+// due removes the expired decisions and returns the others, oldest first.
+func (r *revoker) due() []*trackedDecision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	out := make([]*trackedDecision, 0, len(r.decisions))
+	for key, t := range r.decisions {
+		if !now.Before(t.expiresAt) {
+			delete(r.decisions, key)
+			continue
+		}
+		out = append(out, t)
+	}
+	if len(r.decisions) < revocationMaxTracked {
+		r.full = false
+	}
+	sort.Slice(out, func(i, k int) bool {
+		return out[i].decision.Time.Before(out[k].decision.Time)
+	})
+	return out
+}
+
+// remove stops tracking the decision, unless a newer decision replaced it.
+func (r *revoker) remove(t *trackedDecision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.decisions[t.key] == t {
+		delete(r.decisions, t.key)
+	}
+}
+
+// start calls revalidate every interval until the revoker is closed.
+func (r *revoker) start(ctx context.Context, revalidate func(context.Context)) {
+	ctx, r.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	r.doneCh = make(chan struct{})
+	go func() {
+		defer close(r.doneCh)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				revalidate(ctx)
+			}
+		}
+	}()
+}
+
+// close stops the revoker and waits for a validation round in progress.
+func (r *revoker) close() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.doneCh
+}
+
+// RevalidateDecisions validates the accepted decisions tracked for
+// [PluginConfig.RevocationDestination] again, and publishes a [Revocation]
+// for each whose issue or change ticket no longer matches. The server does
+// this every [PluginConfig.RevocationInterval]. It returns the number of
+// revocations published, and the errors of the issues that could not be
+// checked or revocations that could not be published, which are retried on
+// the next call.
+func (j *JiraPlugin) RevalidateDecisions(ctx context.Context) (int, error) {
+	if j.revoker == nil || !j.features.enabled(FeatureRevocation) {
+		return 0, nil
+	}
+	if !j.life.acquire() {
+		return 0, ErrClosed
+	}
+	defer j.life.release()
+
+	logger := logging.FromContext(ctx)
+	s := j.current.Load()
+	var merr error
+	revoked := 0
+	for _, t := range j.revoker.due() {
+		reason, err := s.revalidate(ctx, t)
+		if err != nil {
+			merr = errors.Join(merr, err)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+
+		d := t.decision
+		rev := &Revocation{
+			Time:          j.revoker.now().UTC(),
+			AcceptedAt:    d.Time.UTC(),
+			Category:      d.Category,
+			Value:         d.Value,
+			Requestor:     d.Requestor,
+			IssueID:       t.key.issueID,
+			ChangeIssueID: t.key.changeIssueID,
+			Reason:        reason,
+			Annotation:    d.Annotation,
+		}
+		publishCtx, cancel := context.WithTimeout(ctx, revocationPublishTimeout)
+		err = j.revoker.publisher.publish(publishCtx, rev)
+		cancel()
+		if err != nil {
+			merr = errors.Join(merr, err)
+			continue
+		}
+		j.revoker.remove(t)
+		revoked++
+		logger.InfoContext(ctx, "published revocation of accepted decision",
+			"issue_id", rev.IssueID,
+			"requestor", rev.Requestor,
+			"reason", rev.Reason,
+			"destination", j.revoker.publisher.String())
+	}
+	return revoked, merr
+}
+
+// revalidate matches the issue and change ticket of the tracked decision
+// again, without the decision cache. It returns why the decision no longer
+// qualifies, or an empty reason when it still does.
+func (s *snapshot) revalidate(ctx context.Context, t *trackedDecision) (string, error) {
+	if t.requestor != nil {
+		ctx = WithRequestor(ctx, t.requestor)
+	}
+
+	var issue, change IssueMatcher = s.validator, s.change
+	if s.jira != nil {
+		issue = s.jira
+	}
+	if s.changeJira != nil {
+		change = s.changeJira
+	}
+	reason, err := noLongerQualifies(ctx, issue, t.key.issueID)
+	if err != nil || reason != "" || t.key.changeIssueID == "" || change == nil {
+		return reason, err
+	}
+	reason, err = noLongerQualifies(ctx, change, t.key.changeIssueID)
+	if reason != "" {
+		reason = "change ticket: " + reason
+	}
+	return reason, err
+}
+
+// noLongerQualifies matches the issue and returns why it no longer
+// qualifies, or an empty reason when it still does. Jira rejecting the
+// credentials or the request is an error rather than a reason, so that an
+// outage or a broken configuration does not revoke every decision.
+func noLongerQualifies(ctx context.Context, m IssueMatcher, issueID string) (string, error) {
+	result, err := m.MatchIssue(ctx, issueID)
+	if err != nil {
+		code, ok := JiraStatus(err)
+		if errors.Is(err, ErrInvalidJustification) && !errors.Is(err, ErrJiraAuth) && (!ok || code == http.StatusNotFound) {
+			return err.Error(), nil
+		}
+		return "", fmt.Errorf("failed to validate issue %s again: %w", issueID, err)
+	}
+	if len(result.Matches) == 0 || len(result.Matches[0].MatchedIssues) != 1 {
+		return fmt.Sprintf("issue %s no longer matches the JQL", issueID), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,308 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+// fakeRevocationAPI records the revocations posted to it.
+type fakeRevocationAPI struct {
+	srv *httptest.Server
+
+	// fail makes the API respond with an internal error.
+	fail atomic.Bool
+
+	mu          sync.Mutex
+	revocations []*Revocation
+}
+
+func newFakeRevocationAPI(tb testing.TB) *fakeRevocationAPI {
+	tb.Helper()
+
+	a := &fakeRevocationAPI{}
+	a.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.fail.Load() {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+		var rev Revocation
+		if err := json.NewDecoder(r.Body).Decode(&rev); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.mu.Lock()
+		a.revocations = append(a.revocations, &rev)
+		a.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	tb.Cleanup(a.srv.Close)
+	return a
+}
+
+func (a *fakeRevocationAPI) received() []*Revocation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]*Revocation(nil), a.revocations...)
+}
+
+func TestRevalidateDecisions(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	api := newFakeRevocationAPI(t)
+	cfg := f.config()
+	cfg.RevocationDestination = api.srv.URL
+	cfg.RevocationInterval = time.Hour
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	validate := func(value, requestor string) *jvspb.ValidateJustificationResponse {
+		t.Helper()
+		resp, err := p.Validate(WithRequestor(ctx, &Requestor{Subject: requestor}), &jvspb.ValidateJustificationRequest{
+			Justification: &jvspb.Justification{Category: "jira", Value: value},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	revalidate := func(want int) {
+		t.Helper()
+		got, err := p.RevalidateDecisions(ctx)
+		if err != nil {
+			t.Fatalf("RevalidateDecisions() got unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("RevalidateDecisions() got %d revocations, want %d", got, want)
+		}
+	}
+
+	resp := validate("ABCD-1", "user@example.com")
+	validate("ABCD-1", "user@example.com")
+	validate(fakeJiraMissingIssue, "user@example.com")
+
+	// The issue still matches.
+	revalidate(0)
+	if got := api.received(); len(got) != 0 {
+		t.Errorf("got revocations %v while the issue matches", got)
+	}
+
+	// Once it no longer does, the decision is revoked once, with the
+	// annotations returned to JVS.
+	f.unmatched.Store(true)
+	api.fail.Store(true)
+	if _, err := p.RevalidateDecisions(ctx); err == nil {
+		t.Errorf("RevalidateDecisions() got no error for a failing revocation API")
+	}
+	api.fail.Store(false)
+	revalidate(1)
+	revalidate(0)
+
+	got := api.received()
+	if len(got) != 1 {
+		t.Fatalf("got %d revocations, want 1", len(got))
+	}
+	rev := got[0]
+	if !strings.Contains(rev.Reason, "no longer matches the JQL") {
+		t.Errorf("got reason %q, want the issue to no longer match", rev.Reason)
+	}
+	rev.Time, rev.AcceptedAt, rev.Reason = time.Time{}, time.Time{}, ""
+	want := &Revocation{
+		Category:   "jira",
+		Value:      "ABCD-1",
+		Requestor:  "user@example.com",
+		IssueID:    "1234",
+		Annotation: resp.GetAnnotation(),
+	}
+	if diff := cmp.Diff(want, rev); diff != "" {
+		t.Errorf("revocation (-want, +got):\n%s", diff)
+	}
+
+	// Turned off, nothing is validated again.
+	f.unmatched.Store(false)
+	validate("ABCD-2", "other@example.com")
+	f.unmatched.Store(true)
+	if err := p.SetFeatureFlag(ctx, FeatureRevocation, false); err != nil {
+		t.Fatal(err)
+	}
+	revalidate(0)
+	if err := p.ResetFeatureFlag(ctx, FeatureRevocation); err != nil {
+		t.Fatal(err)
+	}
+	revalidate(1)
+}
+
+func TestRevoker_Track(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	r := newRevoker(ctx, &PluginConfig{RevocationDestination: "projects/p/topics/t", RevocationWindow: time.Hour})
+	r.now = func() time.Time { return now }
+
+	decision := func(at time.Duration, requestor, issueID string) *Decision {
+		return &Decision{
+			Time:       now.Add(at),
+			Valid:      true,
+			Requestor:  requestor,
+			Annotation: map[string]string{jiraIssueID: issueID},
+		}
+	}
+	r.track(decision(-2*time.Hour, "a", "1"), nil)
+	r.track(decision(-30*time.Minute, "a", "2"), nil)
+	r.track(decision(-20*time.Minute, "b", "2"), nil)
+	// Replaces the decision of a for issue 2.
+	r.track(decision(-10*time.Minute, "a", "2"), nil)
+	// Not tracked: rejected, bypassed and without an issue.
+	r.track(&Decision{Time: now, Requestor: "a", Annotation: map[string]string{jiraIssueID: "3"}}, nil)
+	bypassed := decision(0, "a", "4")
+	bypassed.Bypassed = true
+	r.track(bypassed, nil)
+	r.track(decision(0, "a", ""), nil)
+
+	var got []string
+	for _, d := range r.due() {
+		got = append(got, fmt.Sprintf("%s/%s", d.key.requestor, d.key.issueID))
+	}
+	if diff := cmp.Diff([]string{"b/2", "a/2"}, got); diff != "" {
+		t.Errorf("due() (-want, +got):\n%s", diff)
+	}
+}
+
+func TestNoLongerQualifies(t *testing.T) {
+	t.Parallel()
+
+	matched := &MatchResult{Matches: []*Match{{MatchedIssues: []int{1234}}}}
+
+	cases := []struct {
+		name       string
+		matcher    *mockValidator
+		wantReason string
+		wantErr    string
+	}{
+		{
+			name:    "matches",
+			matcher: &mockValidator{result: matched},
+		},
+		{
+			name:       "no_match",
+			matcher:    &mockValidator{result: &MatchResult{Matches: []*Match{{}}}},
+			wantReason: "issue 1234 no longer matches the JQL",
+		},
+		{
+			name:       "policy_failure",
+			matcher:    &mockValidator{err: fmt.Errorf("issue type changed: %w", ErrInvalidJustification)},
+			wantReason: "issue type changed: invalid justification",
+		},
+		{
+			name:       "not_found",
+			matcher:    &mockValidator{err: WithJiraStatus(fmt.Errorf("gone: %w", ErrInvalidJustification), http.StatusNotFound)},
+			wantReason: "gone: invalid justification",
+		},
+		{
+			name:    "auth_failure",
+			matcher: &mockValidator{err: WithJiraStatus(fmt.Errorf("denied: %w: %w", ErrJiraAuth, ErrInvalidJustification), http.StatusUnauthorized)},
+			wantErr: "denied",
+		},
+		{
+			name:    "bad_request",
+			matcher: &mockValidator{err: WithJiraStatus(fmt.Errorf("bad jql: %w", ErrInvalidJustification), http.StatusBadRequest)},
+			wantErr: "bad jql",
+		},
+		{
+			name:    "unreachable",
+			matcher: &mockValidator{err: fmt.Errorf("timeout: %w", ErrJiraUnreachable)},
+			wantErr: "timeout",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			reason, err := noLongerQualifies(context.Background(), tc.matcher, "1234")
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got, want := reason, tc.wantReason; got != want {
+				t.Errorf("got reason %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPubsubRevocationPublisher(t *testing.T) {
+	t.Parallel()
+
+	var gotPath string
+	var gotReq pubsub.PublishRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if err := json.NewDecoder(r.Body).Decode(&gotReq); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"messageIds":["1"]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	p := newRevocationPublisher("projects/my-project/topics/revocations", "")
+	pp, ok := p.(*pubsubRevocationPublisher)
+	if !ok {
+		t.Fatalf("newRevocationPublisher() got %T, want a pub/sub publisher", p)
+	}
+	pp.newService = func(ctx context.Context) (*pubsub.Service, error) {
+		return pubsub.NewService(ctx, option.WithEndpoint(srv.URL), option.WithoutAuthentication()) //nolint:wrapcheck // Test
+	}
+
+	rev := &Revocation{Category: "jira", IssueID: "1234", Reason: "closed"}
+	if err := p.publish(context.Background(), rev); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gotPath, "/v1/projects/my-project/topics/revocations:publish"; got != want {
+		t.Errorf("got path %q, want %q", got, want)
+	}
+	if len(gotReq.Messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(gotReq.Messages))
+	}
+	if diff := cmp.Diff(map[string]string{"category": "jira", "issue_id": "1234"}, gotReq.Messages[0].Attributes); diff != "" {
+		t.Errorf("attributes (-want, +got):\n%s", diff)
+	}
+}
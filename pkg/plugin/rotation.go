@@ -0,0 +1,119 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+// Names of the API tokens, see [Validator.APITokenInUse].
+const (
+	apiTokenPrimary   = "primary"
+	apiTokenSecondary = "secondary"
+)
+
+// WithSecondaryAPIToken sets a second API token, used when Jira rejects the
+// one in use with 401 Unauthorized. The token Jira accepted is used for the
+// following requests, so rotating the token is a matter of adding the new
+// token as the secondary one, revoking the old one, and promoting the new
+// one later.
+func WithSecondaryAPIToken(token string) ValidatorOption {
+	return func(v *Validator) error {
+		v.secondaryAPIToken = token
+		return nil
+	}
+}
+
+// APITokenInUse returns which API token the requests are made with,
+// "primary" or "secondary".
+func (v *Validator) APITokenInUse() string {
+	if v.useSecondaryAPIToken.Load() {
+		return apiTokenSecondary
+	}
+	return apiTokenPrimary
+}
+
+// do makes the request through the middlewares of the validator.
+func (v *Validator) do(req *http.Request) (*http.Response, error) {
+	resp, err := v.httpClient.Do(req)
+	if errors.Is(err, ErrHostNotAllowed) {
+		// Jira was not asked, retrying does not help.
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w: %w", err, ErrJiraUnreachable)
+	}
+	return resp, nil
+}
+
+// authenticate sets the API token in use on the request and, when Jira
+// rejects it with 401 Unauthorized, retries with the other token if there is
+// one. The token is switched when Jira accepts the other one.
+func (v *Validator) authenticate(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		secondary := v.useSecondaryAPIToken.Load()
+		resp, err := next.RoundTrip(v.withAPIToken(req, secondary))
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || v.secondaryAPIToken == "" {
+			return resp, err //nolint:wrapcheck // Want passthrough
+		}
+		// The body must be sent again.
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return resp, nil
+		}
+
+		retry, err := rewind(req)
+		if err != nil {
+			return resp, nil //nolint:nilerr // Report the first response
+		}
+		drain(resp)
+
+		resp, err = next.RoundTrip(v.withAPIToken(retry, !secondary))
+		if err != nil || resp.StatusCode == http.StatusUnauthorized {
+			return resp, err //nolint:wrapcheck // Want passthrough
+		}
+		if v.useSecondaryAPIToken.CompareAndSwap(secondary, !secondary) {
+			v.logTokenSwitch(req.Context(), secondary)
+		}
+		return resp, nil
+	})
+}
+
+// withAPIToken returns a copy of the request authenticated with the primary
+// or secondary API token.
+func (v *Validator) withAPIToken(req *http.Request, secondary bool) *http.Request {
+	token := v.apiToken
+	if secondary {
+		token = v.secondaryAPIToken
+	}
+	r := req.Clone(req.Context())
+	r.SetBasicAuth(v.account, token)
+	return r
+}
+
+// logTokenSwitch logs that Jira accepted the other API token.
+func (v *Validator) logTokenSwitch(ctx context.Context, fromSecondary bool) {
+	from, to := apiTokenPrimary, apiTokenSecondary
+	if fromSecondary {
+		from, to = to, from
+	}
+	logging.FromContext(ctx).WarnContext(ctx, "jira rejected the api token, switched to the other token",
+		"rejected", from,
+		"in_use", to)
+}
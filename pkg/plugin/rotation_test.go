@@ -0,0 +1,109 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestValidator_SecondaryAPIToken(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		secondary    string
+		wantErr      error
+		wantInUse    string
+		wantRejected int32
+	}{
+		{
+			name:         "rotated",
+			secondary:    "new-token",
+			wantInUse:    apiTokenSecondary,
+			wantRejected: 1,
+		},
+		{
+			name:         "no_secondary",
+			wantErr:      ErrJiraAuth,
+			wantInUse:    apiTokenPrimary,
+			wantRejected: 2,
+		},
+		{
+			name:         "secondary_rejected_too",
+			secondary:    "other-token",
+			wantErr:      ErrJiraAuth,
+			wantInUse:    apiTokenPrimary,
+			wantRejected: 4,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Jira only accepts the new token.
+			var rejected atomic.Int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+				if _, token, _ := r.BasicAuth(); token != "new-token" {
+					rejected.Add(1)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				switch r.URL.Path {
+				case "/issue/ABCD-1":
+					fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+				case "/jql/match":
+					fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+				}
+			})
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			var opts []ValidatorOption
+			if tc.secondary != "" {
+				opts = append(opts, WithSecondaryAPIToken(tc.secondary))
+			}
+			v, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "old-token", opts...)
+			if err != nil {
+				t.Fatalf("failed to create validator: %v", err)
+			}
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			for i := 0; i < 2; i++ {
+				if _, err := v.MatchIssue(ctx, "ABCD-1"); !errors.Is(err, tc.wantErr) {
+					t.Fatalf("MatchIssue() got error %v, want %v", err, tc.wantErr)
+				}
+			}
+			if got, want := v.APITokenInUse(), tc.wantInUse; got != want {
+				t.Errorf("APITokenInUse() got %q, want %q", got, want)
+			}
+			// Once rotated, the old token is not sent again.
+			if got, want := rejected.Load(), tc.wantRejected; got != want {
+				t.Errorf("got %d rejected requests, want %d", got, want)
+			}
+		})
+	}
+}
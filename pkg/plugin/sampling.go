@@ -0,0 +1,320 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	mathrand "math/rand"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+const (
+	// gcsScheme prefixes sample destinations in a Cloud Storage bucket.
+	gcsScheme = "gs://"
+
+	// sampleWriteTimeout bounds how long writing one sample may take.
+	sampleWriteTimeout = 30 * time.Second
+
+	// sampleCloseTimeout bounds how long closing the sampler waits for queued
+	// samples to be written.
+	sampleCloseTimeout = 10 * time.Second
+
+	// sampleQueueSize is the number of samples buffered for the destination.
+	// Samples taken while the queue is full are dropped.
+	sampleQueueSize = 64
+)
+
+// DecisionSample is the full context of a validation decision, captured for
+// a human to review whether the criteria accept and reject the right
+// issues, see [PluginConfig.SampleRate]. It never holds secrets: the value
+// has the bypass token redacted, and the issue fields are the annotation
+// fields after [WithFieldRedactions].
+type DecisionSample struct {
+	Time      time.Time `json:"time"`
+	Category  string    `json:"category"`
+	Value     string    `json:"value"`
+	Requestor string    `json:"requestor,omitempty"`
+	Valid     bool      `json:"valid"`
+	Bypassed  bool      `json:"bypassed,omitempty"`
+	Errors    []string  `json:"errors,omitempty"`
+
+	// Issue is the snapshot of the issue returned to JVS: the annotations
+	// of the response, e.g. the issue ID, URL and annotation fields,
+	// without the signature.
+	Issue map[string]string `json:"issue,omitempty"`
+
+	// Explanation is the evaluation of the JQL and policies, see
+	// [Explanation]. It is nil when the validation could not be performed
+	// before any check ran.
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// newDecisionSample returns the sample of a decision and the explanation of
+// its validation.
+func newDecisionSample(d *Decision, e *Explanation) *DecisionSample {
+	sample := &DecisionSample{
+		Time:        d.Time,
+		Category:    d.Category,
+		Value:       d.Value,
+		Requestor:   d.Requestor,
+		Valid:       d.Valid,
+		Bypassed:    d.Bypassed,
+		Errors:      d.Errors,
+		Explanation: e,
+	}
+	for k, v := range d.Annotation {
+		switch k {
+		case jiraSignature, jiraSignedAt, jiraExplanation:
+			continue
+		}
+		if sample.Issue == nil {
+			sample.Issue = make(map[string]string, len(d.Annotation))
+		}
+		sample.Issue[k] = v
+	}
+	return sample
+}
+
+// sampleStore writes samples to their destination.
+type sampleStore interface {
+	put(ctx context.Context, name string, data []byte) error
+	String() string
+}
+
+// newSampleStore returns the store of a destination, a gs://bucket/prefix
+// URL or a local directory.
+func newSampleStore(dest string) (sampleStore, error) {
+	if rest, ok := strings.CutPrefix(dest, gcsScheme); ok {
+		bucket, prefix, _ := strings.Cut(rest, "/")
+		if bucket == "" {
+			return nil, fmt.Errorf("missing bucket in sample destination %q", dest)
+		}
+		return &gcsSampleStore{
+			bucket: bucket,
+			prefix: strings.Trim(prefix, "/"),
+			newService: func(ctx context.Context) (*storage.Service, error) {
+				return storage.NewService(ctx, option.WithScopes(storage.DevstorageReadWriteScope)) //nolint:wrapcheck // Wrapped by the caller.
+			},
+		}, nil
+	}
+	if dest == "" {
+		return nil, fmt.Errorf("missing sample destination")
+	}
+	if err := os.MkdirAll(dest, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create sample directory: %w", err)
+	}
+	return dirSampleStore(dest), nil
+}
+
+// dirSampleStore writes the samples to files in a local directory.
+type dirSampleStore string
+
+func (d dirSampleStore) put(_ context.Context, name string, data []byte) error {
+	p := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return fmt.Errorf("failed to create sample directory: %w", err)
+	}
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write sample: %w", err)
+	}
+	return nil
+}
+
+func (d dirSampleStore) String() string {
+	return string(d)
+}
+
+// gcsSampleStore writes the samples to objects in a Cloud Storage bucket,
+// with Application Default Credentials.
+type gcsSampleStore struct {
+	bucket string
+	prefix string
+
+	// newService creates the client, it is mockable for testing.
+	newService func(context.Context) (*storage.Service, error)
+
+	mu      sync.Mutex
+	service *storage.Service
+}
+
+func (s *gcsSampleStore) put(ctx context.Context, name string, data []byte) error {
+	service, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+	obj := &storage.Object{
+		Name:        path.Join(s.prefix, name),
+		ContentType: "application/json",
+	}
+	if _, err := service.Objects.Insert(s.bucket, obj).Media(bytes.NewReader(data)).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to upload sample to gs://%s/%s: %w", s.bucket, obj.Name, err)
+	}
+	return nil
+}
+
+// get returns the client, creating it on first use.
+func (s *gcsSampleStore) get(ctx context.Context) (*storage.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.service == nil {
+		service, err := s.newService(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up cloud storage client: %w", err)
+		}
+		s.service = service
+	}
+	return s.service, nil
+}
+
+func (s *gcsSampleStore) String() string {
+	return gcsScheme + path.Join(s.bucket, s.prefix)
+}
+
+// decisionSampler captures a fraction of the decisions to a [sampleStore].
+//
+// Samples are queued and written by a background goroutine, so a slow or
+// unreachable destination never delays a validation.
+type decisionSampler struct {
+	rate   float64
+	store  sampleStore
+	logger *slog.Logger
+
+	// random returns a number in [0, 1), it is mockable for testing.
+	random func() float64
+
+	// mu guards closed and sending on queue.
+	mu     sync.RWMutex
+	closed bool
+	queue  chan *DecisionSample
+	doneCh chan struct{}
+}
+
+// newDecisionSampler returns a sampler writing the rate fraction of the
+// decisions to dest, or nil when rate is zero. Write failures are logged to
+// the logger in ctx.
+func newDecisionSampler(ctx context.Context, rate float64, dest string) (*decisionSampler, error) {
+	if rate <= 0 {
+		return nil, nil //nolint:nilnil // Sampling is disabled.
+	}
+	store, err := newSampleStore(dest)
+	if err != nil {
+		return nil, err
+	}
+	s := &decisionSampler{
+		rate:   rate,
+		store:  store,
+		logger: logging.FromContext(ctx),
+		random: mathrand.Float64, //nolint:gosec // Not security sensitive
+		queue:  make(chan *DecisionSample, sampleQueueSize),
+		doneCh: make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// sample reports whether the next decision is captured.
+func (s *decisionSampler) sample() bool {
+	return s.random() < s.rate
+}
+
+// add queues the sample for the destination. It never blocks, the sample is
+// dropped when the queue is full.
+func (s *decisionSampler) add(sample *DecisionSample) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- sample:
+	default:
+		s.logger.Warn("decision sample queue is full, dropping sample", "destination", s.store.String())
+	}
+}
+
+// close stops accepting samples and waits a bounded time for the queued
+// ones to be written.
+func (s *decisionSampler) close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	select {
+	case <-s.doneCh:
+		return nil
+	case <-time.After(sampleCloseTimeout):
+		return fmt.Errorf("timed out writing queued decision samples to %s", s.store)
+	}
+}
+
+// run writes queued samples until the queue is closed.
+func (s *decisionSampler) run() {
+	defer close(s.doneCh)
+
+	for sample := range s.queue {
+		if err := s.write(sample); err != nil {
+			s.logger.Error("failed to write decision sample", "error", err)
+		}
+	}
+}
+
+// write stores a single sample under a name unique to it.
+func (s *decisionSampler) write(sample *DecisionSample) error {
+	data, err := json.MarshalIndent(sample, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal decision sample: %w", err)
+	}
+	name, err := sampleName(sample.Time)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sampleWriteTimeout)
+	defer cancel()
+	return s.store.put(ctx, name, data)
+}
+
+// sampleName returns a unique name for a sample taken at t, grouped by day
+// for the periodic reviews, e.g. "2024-03-01/093012.123456789-1a2b3c4d.json".
+func sampleName(t time.Time) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate sample name: %w", err)
+	}
+	t = t.UTC()
+	return t.Format("2006-01-02") + "/" + t.Format("150405.000000000") + "-" + hex.EncodeToString(b) + ".json", nil
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+func TestNewSampleStore(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "samples")
+
+	cases := []struct {
+		name    string
+		dest    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "gcs",
+			dest: "gs://my-bucket/decision-samples/",
+			want: "gs://my-bucket/decision-samples",
+		},
+		{
+			name: "gcs_bucket",
+			dest: "gs://my-bucket",
+			want: "gs://my-bucket",
+		},
+		{
+			name:    "gcs_missing_bucket",
+			dest:    "gs:///samples",
+			wantErr: `missing bucket in sample destination "gs:///samples"`,
+		},
+		{
+			name: "directory",
+			dest: dir,
+			want: dir,
+		},
+		{
+			name:    "empty",
+			wantErr: "missing sample destination",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := newSampleStore(tc.dest)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if err != nil {
+				return
+			}
+			if got := store.String(); got != tc.want {
+				t.Errorf("newSampleStore() got %q, want %q", got, tc.want)
+			}
+			if _, ok := store.(dirSampleStore); ok {
+				if info, err := os.Stat(tc.dest); err != nil || !info.IsDir() {
+					t.Errorf("sample directory was not created: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewDecisionSample(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	e := &Explanation{Valid: true, Steps: []*ExplainStep{{Check: "issue", Outcome: ExplainPass}}}
+	got := newDecisionSample(&Decision{
+		Time:     now,
+		Category: jiraCategory,
+		Value:    "ABCD-1",
+		Valid:    true,
+		Annotation: map[string]string{
+			jiraIssueID:     "1234",
+			jiraIssueURL:    "https://example.atlassian.net/browse/ABCD-1",
+			jiraSignedAt:    "2024-03-01T00:00:00Z",
+			jiraSignature:   "c2lnbmF0dXJl",
+			jiraExplanation: `{"valid":true}`,
+		},
+	}, e)
+
+	want := &DecisionSample{
+		Time:     now,
+		Category: jiraCategory,
+		Value:    "ABCD-1",
+		Valid:    true,
+		Issue: map[string]string{
+			jiraIssueID:  "1234",
+			jiraIssueURL: "https://example.atlassian.net/browse/ABCD-1",
+		},
+		Explanation: e,
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(Explanation{})); diff != "" {
+		t.Errorf("newDecisionSample() (-want, +got):\n%s", diff)
+	}
+}
+
+func TestDecisionSampling(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	dir := t.TempDir()
+	cfg := f.config()
+	cfg.SampleRate = 1
+	cfg.SampleDestination = dir
+	p, err := NewJiraPluginWithToken(ctx, cfg, "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+
+	validate := func(value string) {
+		t.Helper()
+		resp, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+			Justification: &jvspb.Justification{Category: jiraCategory, Value: value},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := resp.GetAnnotation()[jiraExplanation]; ok {
+			t.Errorf("sampled response has an explanation annotation, the request did not ask for it")
+		}
+	}
+	validate("ABCD-1")
+	if err := p.SetFeatureFlag(ctx, FeatureDecisionSampling, false); err != nil {
+		t.Fatal(err)
+	}
+	validate("ABCD-2")
+	// Closing the plugin writes the queued samples.
+	if err := p.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var samples []*DecisionSample
+	if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err //nolint:wrapcheck // Test helper
+		}
+		var s DecisionSample
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err //nolint:wrapcheck // Test helper
+		}
+		samples = append(samples, &s)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(samples), 1; got != want {
+		t.Fatalf("got %d samples, want %d", got, want)
+	}
+	s := samples[0]
+	if got, want := s.Value, "ABCD-1"; got != want || !s.Valid {
+		t.Errorf("sample got value %q valid %t, want %q valid", got, s.Valid, want)
+	}
+	if got, want := s.Issue[jiraIssueID], "1234"; got != want {
+		t.Errorf("sample issue ID got %q, want %q", got, want)
+	}
+	if s.Explanation == nil || !s.Explanation.Valid || len(s.Explanation.Steps) == 0 {
+		t.Errorf("sample explanation got %+v, want the checks of a valid decision", s.Explanation)
+	}
+}
+
+func TestGCSSampleStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var gotPath, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"bucket":"my-bucket","name":"samples/2024-03-01/a.json"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	store := &gcsSampleStore{
+		bucket: "my-bucket",
+		prefix: "samples",
+		newService: func(ctx context.Context) (*storage.Service, error) {
+			return storage.NewService(ctx, option.WithEndpoint(srv.URL+"/storage/v1/"), option.WithoutAuthentication()) //nolint:wrapcheck // Test helper
+		},
+	}
+	if err := store.put(ctx, "2024-03-01/a.json", []byte(`{"valid":true}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := gotPath, "/upload/storage/v1/b/my-bucket/o"; got != want {
+		t.Errorf("upload path got %q, want %q", got, want)
+	}
+	// The object metadata and the sample are the parts of a multipart body.
+	for _, want := range []string{`"name":"samples/2024-03-01/a.json"`, `{"valid":true}`} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("upload body %q does not contain %q", gotBody, want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MatchModeMatch validates an issue with two requests, fetching the issue
+	// and then matching it against the JQL.
+	MatchModeMatch = "match"
+
+	// MatchModeSearch validates an issue with a single search request for the
+	// JQL restricted to the issue key.
+	MatchModeSearch = "search"
+)
+
+// issueKeyPattern matches a jira issue key, a project key followed by the
+// issue number. Only keys matching it are put into a search JQL.
+var issueKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*-[0-9]+$`)
+
+// issueKeyOrIDPattern matches a jira issue key or a numeric issue id, which
+// Jira accepts in place of the key.
+var issueKeyOrIDPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*-[0-9]+|[0-9]+)$`)
+
+// ValidatorOption customizes a [Validator].
+type ValidatorOption func(*Validator) error
+
+// WithSearchMode makes the validator use a single [search request] per issue
+// instead of fetching the issue and matching it. The JQL must compose with an
+// issue key restriction, i.e. it must have balanced parentheses and quotes
+// and no ORDER BY clause.
+//
+// [search request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-search/#api-rest-api-3-search-jql-post
+func WithSearchMode() ValidatorOption {
+	return func(v *Validator) error {
+		if err := checkComposableJQL(v.jql); err != nil {
+			return fmt.Errorf("JQL cannot be used in search mode: %w", err)
+		}
+		v.searchJQLPrefix = searchJQLPrefix(v.jql)
+		return nil
+	}
+}
+
+// searchJQLPrefix returns the JQL of a search request for jql up to the
+// quoted issue key.
+func searchJQLPrefix(jql string) string {
+	return "(" + jql + ") AND issuekey = "
+}
+
+// searchJQL returns the JQL matching only the given issue key, or an error
+// wrapping ErrInvalidJustification when the key is not an issue key.
+func (v *Validator) searchJQL(issueKey string) (string, error) {
+	return composeSearchJQL(v.searchJQLPrefix, issueKey)
+}
+
+// composeSearchJQL returns the JQL of a search request up to the quoted issue
+// key followed by the quoted key.
+func composeSearchJQL(prefix, issueKey string) (string, error) {
+	if !issueKeyPattern.MatchString(issueKey) {
+		return "", WithReason(fmt.Errorf("%q is not a jira issue key: %w", issueKey, ErrInvalidJustification), ErrorCodeInvalidIssueKey)
+	}
+	// The pattern already rules out quotes and backslashes, the key is quoted
+	// anyway so it can never be read as JQL syntax.
+	return prefix + quoteJQL(issueKey), nil
+}
+
+// quoteJQL returns s as a double quoted JQL string literal.
+func quoteJQL(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// checkComposableJQL checks that jql can be wrapped in parentheses and
+// combined with another clause without changing its meaning.
+func checkComposableJQL(jql string) error {
+	var quote rune
+	var escaped bool
+	var depth int
+	var outside strings.Builder
+	for _, r := range jql {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unbalanced parentheses")
+			}
+		}
+		if quote == 0 {
+			outside.WriteRune(r)
+		}
+	}
+	if quote != 0 || escaped {
+		return fmt.Errorf("unterminated string literal")
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses")
+	}
+	if strings.Contains(strings.ToLower(strings.Join(strings.Fields(outside.String()), " ")), "order by") {
+		return fmt.Errorf("ORDER BY is not supported")
+	}
+	return nil
+}
+
+// searchData contains data needed in the request body of a [search request].
+//
+// [search request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-search/#api-rest-api-3-search-jql-post
+type searchData struct {
+	JQL        string   `json:"jql"`
+	Fields     []string `json:"fields"`
+	MaxResults int      `json:"maxResults"`
+}
+
+// searchResult is the response of a [search request].
+//
+// [search request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-search/#api-rest-api-3-search-jql-post
+type searchResult struct {
+	Issues []*jiraIssue `json:"issues"`
+}
+
+// searchIssue matches the issue against the JQL with a single search
+// request. The JQL is given as the prefix of the search JQL, see
+// [Validator.searchJQL].
+func (v *Validator) searchIssue(ctx context.Context, prefix, issueKey string) (*MatchResult, error) {
+	jql, err := composeSearchJQL(prefix, issueKey)
+	if err != nil {
+		return nil, err
+	}
+
+	u := v.endpointURL("search", "jql")
+
+	body, err := json.Marshal(&searchData{
+		JQL:    jql,
+		Fields: append([]string{"id"}, v.annotationFields...),
+		// One more than needed, so ambiguous results are reported.
+		MaxResults: 2,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	var result searchResult
+	if err := v.makeRequest(req, &result); err != nil {
+		return nil, err
+	}
+
+	match := &Match{
+		MatchedIssues: make([]int, 0, len(result.Issues)),
+		Errors:        []string{},
+	}
+	for _, issue := range result.Issues {
+		id, err := strconv.Atoi(issue.ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue id %q in search result: %w", issue.ID, err)
+		}
+		match.MatchedIssues = append(match.MatchedIssues, id)
+	}
+	matchResult := &MatchResult{Matches: []*Match{match}}
+	if len(result.Issues) == 1 {
+		matchResult.IssueFields = v.projectFields(result.Issues[0].Key, result.Issues[0].Fields)
+	}
+	return matchResult, nil
+}
@@ -0,0 +1,251 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestValidator_SearchJQL(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA OR labels = \"a)b\"",
+		"test@test.com", "secrets", WithSearchMode())
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "valid_key",
+			key:  "ABCD-123",
+			want: `(project = JRA OR labels = "a)b") AND issuekey = "ABCD-123"`,
+		},
+		{
+			name: "lowercase_key",
+			key:  "abcd_2-7",
+			want: `(project = JRA OR labels = "a)b") AND issuekey = "abcd_2-7"`,
+		},
+		{
+			name:    "quote_injection",
+			key:     `ABCD-1" OR project = SECRET OR issuekey = "ABCD-1`,
+			wantErr: "is not a jira issue key",
+		},
+		{
+			name:    "clause_injection",
+			key:     "ABCD-1 OR project IS NOT EMPTY",
+			wantErr: "is not a jira issue key",
+		},
+		{
+			name:    "parenthesis_injection",
+			key:     "ABCD-1) OR (project = SECRET",
+			wantErr: "is not a jira issue key",
+		},
+		{
+			name:    "backslash",
+			key:     `ABCD-1\`,
+			wantErr: "is not a jira issue key",
+		},
+		{
+			name:    "newline",
+			key:     "ABCD-1\n",
+			wantErr: "is not a jira issue key",
+		},
+		{
+			name:    "function",
+			key:     "currentUser()",
+			wantErr: "is not a jira issue key",
+		},
+		{
+			name:    "empty",
+			key:     "",
+			wantErr: "is not a jira issue key",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := v.searchJQL(tc.key)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidJustification) {
+				t.Errorf("searchJQL() got err %v, want it to wrap %v", err, ErrInvalidJustification)
+			}
+			if got != tc.want {
+				t.Errorf("searchJQL() got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuoteJQL(t *testing.T) {
+	t.Parallel()
+
+	if got, want := quoteJQL(`a"b\c`), `"a\"b\\c"`; got != want {
+		t.Errorf("quoteJQL() got %s, want %s", got, want)
+	}
+}
+
+func TestCheckComposableJQL(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		jql     string
+		wantErr string
+	}{
+		{
+			name: "simple",
+			jql:  "project = JRA AND status NOT IN (Done)",
+		},
+		{
+			name: "quoted_syntax",
+			jql:  `summary ~ "order by (" AND labels = 'it\'s'`,
+		},
+		{
+			name:    "order_by",
+			jql:     "project = JRA ORDER  BY created",
+			wantErr: "ORDER BY is not supported",
+		},
+		{
+			name:    "escapes_parentheses",
+			jql:     "project = JRA) OR (project = SECRET",
+			wantErr: "unbalanced parentheses",
+		},
+		{
+			name:    "unclosed_parenthesis",
+			jql:     "status IN (Done",
+			wantErr: "unbalanced parentheses",
+		},
+		{
+			name:    "unterminated_string",
+			jql:     `summary ~ "abc`,
+			wantErr: "unterminated string literal",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkComposableJQL(tc.jql)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestValidator_MatchIssue_Search(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		issues  string
+		want    *MatchResult
+		wantErr string
+	}{
+		{
+			name:   "match",
+			issues: `[{"id":"1234","key":"ABCD-1"}]`,
+			want:   &MatchResult{Matches: []*Match{{MatchedIssues: []int{1234}, Errors: []string{}}}},
+		},
+		{
+			name:   "no_match",
+			issues: `[]`,
+			want:   &MatchResult{Matches: []*Match{{MatchedIssues: []int{}, Errors: []string{}}}},
+		},
+		{
+			name:    "bad_id",
+			issues:  `[{"id":"x","key":"ABCD-1"}]`,
+			wantErr: `invalid issue id "x"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var gotBody searchData
+			mux := http.NewServeMux()
+			mux.HandleFunc("/search/jql", func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&gotBody); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				fmt.Fprintf(w, `{"issues":%s}`, tc.issues)
+			})
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+
+			v, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "secrets", WithSearchMode())
+			if err != nil {
+				t.Fatalf("failed to create validator: %v", err)
+			}
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			got, err := v.MatchIssue(ctx, "ABCD-1")
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("MatchIssue() unexpected diff (-want,+got):\n%s", diff)
+			}
+
+			wantBody := searchData{
+				JQL:        `(status NOT IN (Done)) AND issuekey = "ABCD-1"`,
+				Fields:     []string{"id"},
+				MaxResults: 2,
+			}
+			if diff := cmp.Diff(wantBody, gotBody); diff != "" {
+				t.Errorf("search request (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewValidator_SearchModeRejectsJQL(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA ORDER BY key",
+		"test@test.com", "secrets", WithSearchMode())
+	if diff := testutil.DiffErrString(err, "JQL cannot be used in search mode: ORDER BY is not supported"); diff != "" {
+		t.Errorf(diff)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+)
+
+// secretManagerScope is the OAuth scope of the Secret Manager API.
+const secretManagerScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// secretAccessor is the subset of the Secret Manager client the plugin uses,
+// it is mockable for testing.
+type secretAccessor interface {
+	AccessSecretVersion(context.Context, *secretmanagerpb.AccessSecretVersionRequest, ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error)
+	Close() error
+}
+
+// secretManager fetches secrets with a Secret Manager client shared by all
+// lookups. The client is created on first use and released by
+// [secretManager.Close], a later lookup creates a new one.
+type secretManager struct {
+	// newClient creates the client, it is mockable for testing.
+	newClient func(context.Context) (secretAccessor, error)
+
+	mu     sync.Mutex
+	client secretAccessor
+}
+
+// newSecretManager creates a secretManager accessing Secret Manager with the
+// credentials of cfg.
+func newSecretManager(cfg *PluginConfig) *secretManager {
+	return &secretManager{
+		newClient: func(ctx context.Context) (secretAccessor, error) {
+			opts, err := cfg.secretManagerOptions(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return secretmanager.NewClient(ctx, opts...) //nolint:wrapcheck // Wrapped by the caller.
+		},
+	}
+}
+
+// access returns the data of the secret version as a string.
+func (s *secretManager) access(ctx context.Context, name string) (string, error) {
+	client, err := s.get(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: name,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to access %s from secret manager: %w", name, err)
+	}
+	return string(resp.GetPayload().GetData()), nil
+}
+
+// get returns the shared client, creating it on first use.
+func (s *secretManager) get(ctx context.Context) (secretAccessor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		client, err := s.newClient(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up secret manager client: %w", err)
+		}
+		s.client = client
+	}
+	return s.client, nil
+}
+
+// Close releases the client, it is a no-op when no secret was fetched.
+func (s *secretManager) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Close()
+	s.client = nil
+	if err != nil {
+		return fmt.Errorf("failed to close secret manager client: %w", err)
+	}
+	return nil
+}
+
+// secretManagerOptions returns the client options for Secret Manager. The
+// client uses Application Default Credentials unless a credentials file,
+// e.g. a Workload Identity Federation configuration, or a service account
+// to impersonate is configured.
+func (cfg *PluginConfig) secretManagerOptions(ctx context.Context) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+	if cfg.SecretManagerCredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.SecretManagerCredentialsFile))
+	}
+	if sa := cfg.SecretManagerImpersonateServiceAccount; sa != "" {
+		// The credentials above, or ADC, are the source credentials.
+		ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+			TargetPrincipal: sa,
+			Scopes:          []string{secretManagerScope},
+		}, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to impersonate %s: %w", sa, err)
+		}
+		opts = []option.ClientOption{option.WithTokenSource(ts)}
+	}
+	return opts, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/abcxyz/pkg/testutil"
+	"github.com/googleapis/gax-go/v2"
+)
+
+type fakeSecretClient struct {
+	secrets map[string]string
+	closed  bool
+}
+
+func (c *fakeSecretClient) AccessSecretVersion(_ context.Context, req *secretmanagerpb.AccessSecretVersionRequest, _ ...gax.CallOption) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	if c.closed {
+		return nil, fmt.Errorf("client is closed")
+	}
+	data, ok := c.secrets[req.GetName()]
+	if !ok {
+		return nil, fmt.Errorf("secret %s not found", req.GetName())
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{
+		Payload: &secretmanagerpb.SecretPayload{Data: []byte(data)},
+	}, nil
+}
+
+func (c *fakeSecretClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestSecretManager(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var clients []*fakeSecretClient
+	s := &secretManager{
+		newClient: func(context.Context) (secretAccessor, error) {
+			c := &fakeSecretClient{secrets: map[string]string{
+				"projects/123/secrets/api-token/versions/1": "token",
+				"projects/123/secrets/account/versions/1":   "abc@xyz.com",
+			}}
+			clients = append(clients, c)
+			return c, nil
+		},
+	}
+
+	// Closing before the first lookup is a no-op.
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() unexpected err: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"projects/123/secrets/api-token/versions/1": "token",
+		"projects/123/secrets/account/versions/1":   "abc@xyz.com",
+	} {
+		got, err := s.access(ctx, name)
+		if err != nil {
+			t.Fatalf("access(%s) unexpected err: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("access(%s) got %q, want %q", name, got, want)
+		}
+	}
+	if got, want := len(clients), 1; got != want {
+		t.Errorf("got %d clients, want %d", got, want)
+	}
+
+	_, err := s.access(ctx, "projects/123/secrets/missing/versions/1")
+	if diff := testutil.DiffErrString(err, "failed to access projects/123/secrets/missing/versions/1"); diff != "" {
+		t.Errorf("access() unexpected err: %s", diff)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() unexpected err: %v", err)
+	}
+	if !clients[0].closed {
+		t.Errorf("client not closed")
+	}
+
+	// A lookup after Close creates a new client.
+	if _, err := s.access(ctx, "projects/123/secrets/api-token/versions/1"); err != nil {
+		t.Fatalf("access() after Close unexpected err: %v", err)
+	}
+	if got, want := len(clients), 2; got != want {
+		t.Errorf("got %d clients, want %d", got, want)
+	}
+}
+
+func TestSecretManager_NewClientError(t *testing.T) {
+	t.Parallel()
+
+	s := &secretManager{
+		newClient: func(context.Context) (secretAccessor, error) {
+			return nil, fmt.Errorf("no credentials")
+		},
+	}
+	_, err := s.access(context.Background(), "projects/123/secrets/api-token/versions/1")
+	if diff := testutil.DiffErrString(err, "failed to set up secret manager client: no credentials"); diff != "" {
+		t.Errorf("access() unexpected err: %s", diff)
+	}
+}
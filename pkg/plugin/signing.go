@@ -0,0 +1,107 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+const (
+	// jiraSignedAt is the key for the time the annotations were signed in
+	// the annotation map.
+	jiraSignedAt = "jira_signed_at"
+
+	// jiraSignature is the key for the signature of the annotations in the
+	// annotation map.
+	jiraSignature = "jira_signature"
+
+	// signatureVersion prefixes the signature, so the payload format can
+	// change without breaking verifiers.
+	signatureVersion = "v1"
+)
+
+// annotationSigner signs the annotations of valid responses with an HMAC,
+// so consumers of the issued token can detect tampering.
+type annotationSigner struct {
+	key []byte
+	now func() time.Time
+}
+
+// newAnnotationSigner creates the signer, it returns nil when the key is
+// empty.
+func newAnnotationSigner(key []byte) *annotationSigner {
+	if len(key) == 0 {
+		return nil
+	}
+	return &annotationSigner{key: key, now: time.Now}
+}
+
+// sign adds the signing time and the signature of the annotations to resp.
+func (s *annotationSigner) sign(resp *jvspb.ValidateJustificationResponse) {
+	if resp.Annotation == nil {
+		resp.Annotation = make(map[string]string, 2)
+	}
+	resp.Annotation[jiraSignedAt] = s.now().UTC().Format(time.RFC3339)
+	resp.Annotation[jiraSignature] = signAnnotations(s.key, resp.Annotation)
+}
+
+// signAnnotations returns the signature of the annotations, ignoring the
+// signature itself.
+func signAnnotations(key []byte, annotations map[string]string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(signaturePayload(annotations))
+	return signatureVersion + ":" + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signaturePayload returns the canonical form of the annotations that is
+// signed: the sorted "key=value" lines of every annotation but the
+// signature. Keys and values are quoted, so they cannot run into each other.
+func signaturePayload(annotations map[string]string) []byte {
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		if k != jiraSignature {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%q=%q\n", k, annotations[k])
+	}
+	return []byte(b.String())
+}
+
+// VerifyAnnotations checks the signature of annotations signed by the plugin
+// with key, see [PluginConfig.AnnotationSigningKeySecretID]. It returns
+// [ErrInvalidSignature] when the annotations are unsigned or were changed.
+func VerifyAnnotations(key []byte, annotations map[string]string) error {
+	got, ok := annotations[jiraSignature]
+	if !ok {
+		return fmt.Errorf("missing %s annotation: %w", jiraSignature, ErrInvalidSignature)
+	}
+	if want := signAnnotations(key, annotations); !hmac.Equal([]byte(got), []byte(want)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
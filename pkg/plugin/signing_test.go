@@ -0,0 +1,110 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+)
+
+func TestAnnotationSigner(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("signing-key")
+	s := newAnnotationSigner(key)
+	s.now = func() time.Time { return time.Date(2023, 9, 1, 12, 0, 0, 0, time.UTC) }
+
+	resp := &jvspb.ValidateJustificationResponse{
+		Valid: true,
+		Annotation: map[string]string{
+			jiraIssueID:                      "1234",
+			annotationFieldPrefix + "status": "In Progress",
+		},
+	}
+	s.sign(resp)
+	if got, want := resp.GetAnnotation()[jiraSignedAt], "2023-09-01T12:00:00Z"; got != want {
+		t.Errorf("%s got %q, want %q", jiraSignedAt, got, want)
+	}
+
+	cases := []struct {
+		name   string
+		key    []byte
+		modify func(map[string]string)
+		want   error
+	}{
+		{
+			name: "valid",
+			key:  key,
+		},
+		{
+			name: "wrong_key",
+			key:  []byte("other-key"),
+			want: ErrInvalidSignature,
+		},
+		{
+			name:   "changed_value",
+			key:    key,
+			modify: func(a map[string]string) { a[annotationFieldPrefix+"status"] = "Done" },
+			want:   ErrInvalidSignature,
+		},
+		{
+			name:   "added_annotation",
+			key:    key,
+			modify: func(a map[string]string) { a["extra"] = "x" },
+			want:   ErrInvalidSignature,
+		},
+		{
+			name:   "unsigned",
+			key:    key,
+			modify: func(a map[string]string) { delete(a, jiraSignature) },
+			want:   ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			annotations := make(map[string]string, len(resp.GetAnnotation()))
+			for k, v := range resp.GetAnnotation() {
+				annotations[k] = v
+			}
+			if tc.modify != nil {
+				tc.modify(annotations)
+			}
+			if err := VerifyAnnotations(tc.key, annotations); !errors.Is(err, tc.want) {
+				t.Errorf("VerifyAnnotations() got error %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestSignaturePayload(t *testing.T) {
+	t.Parallel()
+
+	// Quoting keeps "a=b" "c" apart from "a" "b=c".
+	got := string(signaturePayload(map[string]string{"a=b": "c", jiraSignature: "ignored"}))
+	if other := string(signaturePayload(map[string]string{"a": "b=c"})); got == other {
+		t.Errorf("signaturePayload() got the same payload %q for different annotations", got)
+	}
+	if got, want := got, "\"a=b\"=\"c\"\n"; got != want {
+		t.Errorf("signaturePayload() got %q, want %q", got, want)
+	}
+}
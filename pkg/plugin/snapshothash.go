@@ -0,0 +1,103 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// jiraIssueSnapshotHash is the key for the [IssueSnapshotHash] of the
+	// validated issue in the annotation map of the justification.
+	jiraIssueSnapshotHash = "jira_issue_snapshot_hash"
+
+	// issueSnapshotHashVersion prefixes the hashes, it changes whenever the
+	// hashed content does, so hashes of different versions never compare
+	// equal by accident.
+	issueSnapshotHashVersion = "v1"
+)
+
+// issueSnapshotFields are the fields hashed by [IssueSnapshotHash], in order.
+var issueSnapshotFields = []string{"status", "assignee", "resolution"}
+
+// WithIssueSnapshotHash makes the validator set [MatchResult.SnapshotHash],
+// a hash of the key, status, assignee and resolution of the issue. A
+// revocation system can compare it with the hash of the issue later to tell
+// cheaply whether the issue changed since the justification was accepted.
+// It only applies to [Validator.MatchIssue], and cannot be used in search
+// mode, which does not fetch the issue.
+func WithIssueSnapshotHash() ValidatorOption {
+	return func(v *Validator) error {
+		if v.searchJQLPrefix != "" {
+			return fmt.Errorf("issue snapshot hash cannot be used in search mode")
+		}
+		v.snapshotHash = true
+		v.issueFieldsQuery = v.fieldsQuery()
+		return nil
+	}
+}
+
+// IssueSnapshotHash returns the snapshot hash of the issue with the key and
+// fields of the Get Issue API response, e.g. "v1:9f86d0…". It only depends
+// on the key and on the ID, or account ID for users, of the status, assignee
+// and resolution, so renaming a status or changing an avatar does not change
+// it. Unset fields hash like null.
+//
+// The hash is "v1:" followed by the hex SHA-256 of the key, then for each of
+// status, assignee and resolution a NUL byte, the field name, "=" and the
+// identifier, empty for null.
+func IssueSnapshotHash(key string, fields map[string]json.RawMessage) string {
+	h := sha256.New()
+	h.Write([]byte(key))
+	for _, name := range issueSnapshotFields {
+		h.Write([]byte{0})
+		h.Write([]byte(name + "="))
+		h.Write([]byte(snapshotFieldID(fields[name])))
+	}
+	return issueSnapshotHashVersion + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// snapshotFieldID returns the stable identifier of a status, user or
+// resolution field: the account ID of Jira Cloud users, the key or name of
+// Jira Data Center users, or the ID of other objects. It returns the compact
+// JSON of values without any, and "" for null.
+func snapshotFieldID(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	var obj struct {
+		AccountID string `json:"accountId"`
+		Key       string `json:"key"`
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+	}
+	if raw[0] == '{' && json.Unmarshal(raw, &obj) == nil {
+		for _, s := range []string{obj.AccountID, obj.Key, obj.ID, obj.Name} {
+			if s != "" {
+				return s
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
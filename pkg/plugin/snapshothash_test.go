@@ -0,0 +1,165 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestIssueSnapshotHash(t *testing.T) {
+	t.Parallel()
+
+	fields := func(s string) map[string]json.RawMessage {
+		var out map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(s), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	base := fields(`{
+		"status": {"id": "3", "name": "In Progress", "self": "https://example.atlassian.net/rest/api/3/status/3"},
+		"assignee": {"accountId": "5b10a2844c20165700ede21g", "displayName": "Jane Doe", "avatarUrls": {"48x48": "https://a/1"}},
+		"resolution": null
+	}`)
+	want := IssueSnapshotHash("ABCD-1", base)
+
+	// The hash format is a contract with revocation systems, it must not
+	// change within a version.
+	if got, want := want, "v1:847edbdd5ce1fd41776012e905305c3d51806d02040988ac529707cf6e5d25ad"; got != want {
+		t.Errorf("IssueSnapshotHash() got %q, want %q", got, want)
+	}
+
+	cases := []struct {
+		name     string
+		key      string
+		fields   string
+		wantSame bool
+	}{
+		{
+			name: "renamed_status_and_new_avatar",
+			key:  "ABCD-1",
+			fields: `{
+				"status": {"id": "3", "name": "Doing"},
+				"assignee": {"accountId": "5b10a2844c20165700ede21g", "displayName": "Jane Roe", "avatarUrls": {"48x48": "https://a/2"}}
+			}`,
+			wantSame: true,
+		},
+		{
+			name:   "other_key",
+			key:    "ABCD-2",
+			fields: `{"status": {"id": "3"}, "assignee": {"accountId": "5b10a2844c20165700ede21g"}}`,
+		},
+		{
+			name:   "other_status",
+			key:    "ABCD-1",
+			fields: `{"status": {"id": "4"}, "assignee": {"accountId": "5b10a2844c20165700ede21g"}}`,
+		},
+		{
+			name:   "unassigned",
+			key:    "ABCD-1",
+			fields: `{"status": {"id": "3"}, "assignee": null}`,
+		},
+		{
+			name:   "resolved",
+			key:    "ABCD-1",
+			fields: `{"status": {"id": "3"}, "assignee": {"accountId": "5b10a2844c20165700ede21g"}, "resolution": {"id": "10000", "name": "Done"}}`,
+		},
+		{
+			name:   "data_center_user",
+			key:    "ABCD-1",
+			fields: `{"status": {"id": "3"}, "assignee": {"key": "JIRAUSER10100", "name": "jdoe"}}`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := IssueSnapshotHash(tc.key, fields(tc.fields))
+			if same := got == want; same != tc.wantSame {
+				t.Errorf("IssueSnapshotHash() got %q, want same as %q: %t", got, want, tc.wantSame)
+			}
+		})
+	}
+}
+
+func TestWithIssueSnapshotHash(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = ABCD", "test@test.com", "token", WithIssueSnapshotHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v.issueFieldsQuery, "fields=key%2Cid%2Cstatus%2Cassignee%2Cresolution"; got != want {
+		t.Errorf("got query %q, want %q", got, want)
+	}
+
+	_, err = NewValidator("https://example.atlassian.net/rest/api/3", "project = ABCD", "test@test.com", "token", WithSearchMode(), WithIssueSnapshotHash())
+	if diff := testutil.DiffErrString(err, "issue snapshot hash cannot be used in search mode"); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestPlugin_IssueSnapshotHash(t *testing.T) {
+	t.Parallel()
+
+	issue := `{"id":"1234","key":"ABCD-1","fields":{"status":{"id":"3"},"assignee":null,"resolution":null}}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/3/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, issue)
+	})
+	mux.HandleFunc("/rest/api/3/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	cfg := &PluginConfig{
+		JIRAEndpoint:      srv.URL + "/rest/api/3",
+		Jql:               "project = ABCD",
+		JIRAAccount:       "test@test.com",
+		IssueBaseURL:      srv.URL,
+		AllowHTTP:         true,
+		IssueSnapshotHash: true,
+	}
+	p, err := NewJiraPluginWithToken(ctx, cfg, "token")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	resp, err := p.ValidateValue(ctx, "ABCD-1")
+	if err != nil || !resp.GetValid() {
+		t.Fatalf("ValidateValue() got %v, %v, want a valid response", resp, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(`{"status":{"id":"3"},"assignee":null,"resolution":null}`), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resp.GetAnnotation()[jiraIssueSnapshotHash], IssueSnapshotHash("ABCD-1", fields); got != want {
+		t.Errorf("got annotation %s=%q, want %q", jiraIssueSnapshotHash, got, want)
+	}
+}
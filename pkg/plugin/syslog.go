@@ -0,0 +1,369 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abcxyz/jvs-plugin-jira/internal/version"
+	"github.com/abcxyz/pkg/logging"
+)
+
+const (
+	// AuditFormatCEF is the ArcSight Common Event Format.
+	AuditFormatCEF = "cef"
+
+	// AuditFormatRFC5424 is the syslog protocol format.
+	AuditFormatRFC5424 = "rfc5424"
+
+	// syslogFacilityAuthPriv is the security/authorization syslog facility.
+	syslogFacilityAuthPriv = 10
+
+	// syslogDialTimeout bounds how long connecting to the collector may take.
+	// After a failed attempt, messages are dropped for the same duration
+	// before connecting again.
+	syslogDialTimeout = 5 * time.Second
+
+	// syslogWriteTimeout bounds how long writing one message may take.
+	syslogWriteTimeout = 5 * time.Second
+
+	// syslogCloseTimeout bounds how long Close waits for queued messages to
+	// be written.
+	syslogCloseTimeout = 5 * time.Second
+
+	// syslogQueueSize is the number of messages buffered for the collector.
+	// Messages emitted while the queue is full are dropped.
+	syslogQueueSize = 1024
+)
+
+// sdIDPattern matches an enterprise-specific [SD-ID], i.e. name@<private
+// enterprise number>.
+//
+// [SD-ID]: https://datatracker.ietf.org/doc/html/rfc5424#section-6.3.2
+var sdIDPattern = regexp.MustCompile(`^[!#-<>?A-Z\[\\^-~]{1,32}@[0-9]+(\.[0-9]+)*$`)
+
+// errAuditQueueFull is returned when a decision is dropped because the
+// collector cannot keep up.
+var errAuditQueueFull = fmt.Errorf("audit queue is full, dropping decision")
+
+// SyslogSink is an [AuditSink] that sends decisions in CEF or RFC 5424 format
+// to a syslog collector over udp, tcp or tls.
+//
+// Decisions are queued and written by a background goroutine, so a slow or
+// unreachable collector never delays a validation.
+type SyslogSink struct {
+	network  string
+	address  string
+	format   string
+	hostname string
+	sdID     string
+	logger   *slog.Logger
+
+	// mu guards closed and sending on queue.
+	mu     sync.RWMutex
+	closed bool
+	queue  chan string
+	doneCh chan struct{}
+
+	// conn and retryAt are only accessed by the background goroutine.
+	conn    net.Conn
+	retryAt time.Time
+}
+
+// NewSyslogSink creates a new SyslogSink. The connection to the collector is
+// established on first use and re-established after a write failure. An empty
+// network defaults to udp and an empty format defaults to CEF. sdID is the
+// SD-ID of the structured data element holding the decision in RFC 5424
+// messages, e.g. "jvsDecision@12345" with the operator's private enterprise
+// number. When it is empty, the decision is written to the message text
+// instead. Write failures are logged to the logger in ctx.
+func NewSyslogSink(ctx context.Context, network, address, format, sdID string) (*SyslogSink, error) {
+	if network == "" {
+		network = "udp"
+	}
+	if format == "" {
+		format = AuditFormatCEF
+	}
+
+	switch network {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", network)
+	}
+	switch format {
+	case AuditFormatCEF, AuditFormatRFC5424:
+	default:
+		return nil, fmt.Errorf("unsupported audit format %q", format)
+	}
+	if sdID != "" && !sdIDPattern.MatchString(sdID) {
+		return nil, fmt.Errorf("invalid syslog SD-ID %q, must be name@<private enterprise number>", sdID)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	s := &SyslogSink{
+		network:  network,
+		address:  address,
+		format:   format,
+		hostname: hostname,
+		sdID:     sdID,
+		logger:   logging.FromContext(ctx),
+		queue:    make(chan string, syslogQueueSize),
+		doneCh:   make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Emit formats the decision and queues it for the collector. It never blocks,
+// an error is returned when the decision is dropped.
+func (s *SyslogSink) Emit(ctx context.Context, d *Decision) error {
+	var msg string
+	switch s.format {
+	case AuditFormatCEF:
+		msg = formatCEF(d)
+	default:
+		msg = formatRFC5424(d, s.hostname, s.sdID)
+	}
+
+	// Stream transports need a frame delimiter, see RFC 6587 section 3.4.2.
+	if s.network != "udp" {
+		msg += "\n"
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return fmt.Errorf("audit sink is closed")
+	}
+	select {
+	case s.queue <- msg:
+		return nil
+	default:
+		return errAuditQueueFull
+	}
+}
+
+// Close stops accepting decisions, waits a bounded time for the queued ones to
+// be written and closes the connection to the collector.
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	select {
+	case <-s.doneCh:
+		return nil
+	case <-time.After(syslogCloseTimeout):
+		return fmt.Errorf("timed out writing queued decisions to syslog collector %s", s.address)
+	}
+}
+
+// run writes queued messages until the queue is closed.
+func (s *SyslogSink) run() {
+	defer close(s.doneCh)
+	defer func() {
+		if s.conn != nil {
+			s.conn.Close()
+		}
+	}()
+
+	for msg := range s.queue {
+		if err := s.write(msg); err != nil {
+			s.logger.Error("failed to write audit event", "error", err)
+		}
+	}
+}
+
+// write sends a single message, connecting first when needed.
+func (s *SyslogSink) write(msg string) error {
+	if s.conn == nil {
+		if time.Now().Before(s.retryAt) {
+			return fmt.Errorf("dropping decision, syslog collector %s was unreachable", s.address)
+		}
+		conn, err := s.dial()
+		if err != nil {
+			s.retryAt = time.Now().Add(syslogDialTimeout)
+			return err
+		}
+		s.conn = conn
+	}
+
+	if err := s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+		return fmt.Errorf("failed to set write deadline: %w", err)
+	}
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("failed to write to syslog collector %s: %w", s.address, err)
+	}
+	return nil
+}
+
+func (s *SyslogSink) dial() (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), syslogDialTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	var err error
+	if s.network == "tls" {
+		d := &tls.Dialer{
+			Config: &tls.Config{MinVersion: tls.VersionTLS12},
+		}
+		conn, err = d.DialContext(ctx, "tcp", s.address)
+	} else {
+		d := &net.Dialer{}
+		conn, err = d.DialContext(ctx, s.network, s.address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog collector %s: %w", s.address, err)
+	}
+	return conn, nil
+}
+
+// formatCEF renders the decision as a [CEF] event.
+//
+// [CEF]: https://www.microfocus.com/documentation/arcsight/arcsight-smartconnectors-8.3/cef-implementation-standard/
+func formatCEF(d *Decision) string {
+	signatureID, name, severity, outcome := "justification-valid", "Justification accepted", 3, "allowed"
+	if !d.Valid {
+		signatureID, name, severity, outcome = "justification-invalid", "Justification rejected", 6, "denied"
+	} else if d.BypassToken {
+		signatureID, name, severity = "justification-bypass-token", "Justification accepted with the emergency bypass token", 10
+	} else if d.Bypassed {
+		signatureID, name, severity = "justification-bypassed", "Justification accepted without Jira validation", 8
+	}
+
+	ext := []string{
+		"rt=" + strconv.FormatInt(d.Time.UnixMilli(), 10),
+		"outcome=" + outcome,
+		"cs1Label=category",
+		"cs1=" + cefExtEscape(d.Category),
+		"cs2Label=justification",
+		"cs2=" + cefExtEscape(d.Value),
+	}
+	if d.Requestor != "" {
+		ext = append(ext, "suser="+cefExtEscape(d.Requestor))
+	}
+	if id := d.Annotation[jiraIssueID]; id != "" {
+		ext = append(ext, "cs3Label=jiraIssueId", "cs3="+cefExtEscape(id))
+	}
+	if d.InsecureTransport {
+		ext = append(ext, "cs4Label=insecureTransport", "cs4=true")
+	}
+	if len(d.Errors) > 0 {
+		ext = append(ext, "reason="+cefExtEscape(strings.Join(d.Errors, "; ")))
+	}
+
+	return fmt.Sprintf("CEF:0|abcxyz|%s|%s|%s|%s|%d|%s",
+		cefHeaderEscape(version.Name),
+		cefHeaderEscape(version.Version),
+		signatureID,
+		name,
+		severity,
+		strings.Join(ext, " "))
+}
+
+// formatRFC5424 renders the decision as an [RFC 5424] syslog message. The
+// decision is written as the structured data element sdID, or to the message
+// text when sdID is empty.
+//
+// [RFC 5424]: https://datatracker.ietf.org/doc/html/rfc5424
+func formatRFC5424(d *Decision, hostname, sdID string) string {
+	severity, msg := 6, "justification accepted" // informational
+	if !d.Valid {
+		severity, msg = 4, "justification rejected" // warning
+	} else if d.BypassToken {
+		severity, msg = 2, "justification accepted with the emergency bypass token" // critical
+	} else if d.Bypassed {
+		severity, msg = 4, "justification accepted without jira validation" // warning
+	}
+
+	params := []string{
+		"valid=\"" + strconv.FormatBool(d.Valid) + "\"",
+		"category=\"" + sdEscape(d.Category) + "\"",
+		"value=\"" + sdEscape(d.Value) + "\"",
+	}
+	if d.Requestor != "" {
+		params = append(params, "requestor=\""+sdEscape(d.Requestor)+"\"")
+	}
+	if d.Bypassed {
+		params = append(params, "bypassed=\"true\"")
+	}
+	if d.BypassToken {
+		params = append(params, "bypassToken=\"true\"")
+	}
+	if id := d.Annotation[jiraIssueID]; id != "" {
+		params = append(params, "issueID=\""+sdEscape(id)+"\"")
+	}
+	if d.InsecureTransport {
+		params = append(params, "insecureTransport=\"true\"")
+	}
+	if len(d.Errors) > 0 {
+		params = append(params, "reason=\""+sdEscape(strings.Join(d.Errors, "; "))+"\"")
+	}
+
+	// "origin" is an IANA registered SD-ID, see RFC 5424 section 7.2.
+	sd := fmt.Sprintf("[origin software=\"%s\" swVersion=\"%s\"]",
+		sdEscape(version.Name), sdEscape(version.Version))
+	if sdID != "" {
+		sd += "[" + sdID + " " + strings.Join(params, " ") + "]"
+	} else {
+		msg += " " + strings.Join(params, " ")
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d decision %s %s",
+		syslogFacilityAuthPriv*8+severity,
+		d.Time.UTC().Format(time.RFC3339Nano),
+		hostname,
+		version.Name,
+		os.Getpid(),
+		sd,
+		msg)
+}
+
+// cefHeaderEscape escapes a CEF header field.
+func cefHeaderEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`).Replace(s)
+}
+
+// cefExtEscape escapes a CEF extension value.
+func cefExtEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// sdEscape escapes an RFC 5424 structured data parameter value.
+func sdEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(s)
+}
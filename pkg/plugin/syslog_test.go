@@ -0,0 +1,341 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/jvs-plugin-jira/internal/version"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+var testDecisionTime = time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func TestFormatCEF(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		decision *Decision
+		want     string
+	}{
+		{
+			name: "valid",
+			decision: &Decision{
+				Time:       testDecisionTime,
+				Category:   "jira",
+				Value:      "ABCD-1",
+				Valid:      true,
+				Annotation: map[string]string{jiraIssueID: "1234"},
+			},
+			want: fmt.Sprintf("CEF:0|abcxyz|%s|%s|justification-valid|Justification accepted|3|"+
+				"rt=1696161600000 outcome=allowed cs1Label=category cs1=jira cs2Label=justification cs2=ABCD-1 "+
+				"cs3Label=jiraIssueId cs3=1234", version.Name, version.Version),
+		},
+		{
+			name: "insecure_transport",
+			decision: &Decision{
+				Time:              testDecisionTime,
+				Category:          "jira",
+				Value:             "ABCD-1",
+				Valid:             true,
+				Annotation:        map[string]string{jiraIssueID: "1234", jiraInsecureTransport: "true"},
+				InsecureTransport: true,
+			},
+			want: fmt.Sprintf("CEF:0|abcxyz|%s|%s|justification-valid|Justification accepted|3|"+
+				"rt=1696161600000 outcome=allowed cs1Label=category cs1=jira cs2Label=justification cs2=ABCD-1 "+
+				"cs3Label=jiraIssueId cs3=1234 cs4Label=insecureTransport cs4=true", version.Name, version.Version),
+		},
+		{
+			name: "bypassed",
+			decision: &Decision{
+				Time:       testDecisionTime,
+				Category:   "jira",
+				Value:      "ABCD-1",
+				Requestor:  "robot@example.com",
+				Valid:      true,
+				Bypassed:   true,
+				Annotation: map[string]string{jiraValidationBypassed: "true"},
+			},
+			want: fmt.Sprintf("CEF:0|abcxyz|%s|%s|justification-bypassed|Justification accepted without Jira validation|8|"+
+				"rt=1696161600000 outcome=allowed cs1Label=category cs1=jira cs2Label=justification cs2=ABCD-1 "+
+				"suser=robot@example.com", version.Name, version.Version),
+		},
+		{
+			name: "bypass_token",
+			decision: &Decision{
+				Time:        testDecisionTime,
+				Category:    "jira",
+				Value:       "bypass:[redacted]",
+				Requestor:   "robot@example.com",
+				Valid:       true,
+				Bypassed:    true,
+				BypassToken: true,
+				Annotation:  map[string]string{jiraValidationBypassed: "true", jiraBypassToken: "true"},
+			},
+			want: fmt.Sprintf("CEF:0|abcxyz|%s|%s|justification-bypass-token|Justification accepted with the emergency bypass token|10|"+
+				"rt=1696161600000 outcome=allowed cs1Label=category cs1=jira cs2Label=justification cs2=bypass:[redacted] "+
+				"suser=robot@example.com", version.Name, version.Version),
+		},
+		{
+			name: "invalid_with_escaping",
+			decision: &Decision{
+				Time:     testDecisionTime,
+				Category: "jira",
+				Value:    "a=b\\c",
+				Errors:   []string{"no matched jira issue"},
+			},
+			want: fmt.Sprintf("CEF:0|abcxyz|%s|%s|justification-invalid|Justification rejected|6|"+
+				`rt=1696161600000 outcome=denied cs1Label=category cs1=jira cs2Label=justification cs2=a\=b\\c `+
+				"reason=no matched jira issue", version.Name, version.Version),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, want := formatCEF(tc.decision), tc.want; got != want {
+				t.Errorf("formatCEF() got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestFormatRFC5424(t *testing.T) {
+	t.Parallel()
+
+	d := &Decision{
+		Time:     testDecisionTime,
+		Category: "jira",
+		Value:    `AB"CD]`,
+		Errors:   []string{"no matched jira issue"},
+	}
+
+	cases := []struct {
+		name string
+		sdID string
+		want string
+	}{
+		{
+			name: "sd_id",
+			sdID: "jvsDecision@12345",
+			want: fmt.Sprintf(`<84>1 2023-10-01T12:00:00Z host %s %d decision `+
+				`[origin software="%s" swVersion="%s"]`+
+				`[jvsDecision@12345 valid="false" category="jira" value="AB\"CD\]" reason="no matched jira issue"] justification rejected`,
+				version.Name, os.Getpid(), version.Name, version.Version),
+		},
+		{
+			name: "no_sd_id",
+			want: fmt.Sprintf(`<84>1 2023-10-01T12:00:00Z host %s %d decision `+
+				`[origin software="%s" swVersion="%s"] `+
+				`justification rejected valid="false" category="jira" value="AB\"CD\]" reason="no matched jira issue"`,
+				version.Name, os.Getpid(), version.Name, version.Version),
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, want := formatRFC5424(d, "host", tc.sdID), tc.want; got != want {
+				t.Errorf("formatRFC5424() got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSyslogSink_Emit(t *testing.T) {
+	t.Parallel()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	sink, err := NewSyslogSink(ctx, "udp", pc.LocalAddr().String(), AuditFormatCEF, "")
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	t.Cleanup(func() { sink.Close() })
+
+	d := &Decision{Time: testDecisionTime, Category: "jira", Value: "ABCD-1", Valid: true}
+	if err := sink.Emit(ctx, d); err != nil {
+		t.Fatalf("failed to emit: %v", err)
+	}
+
+	if err := pc.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2048)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read from collector: %v", err)
+	}
+	if got, want := string(buf[:n]), formatCEF(d); got != want {
+		t.Errorf("collector got %q, want %q", got, want)
+	}
+}
+
+func TestSyslogSink_EmitDoesNotBlock(t *testing.T) {
+	t.Parallel()
+
+	// The listener accepts connections but never reads from them, so writes
+	// eventually stall once the socket buffers are full.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	sink, err := NewSyslogSink(ctx, "tcp", ln.Addr().String(), AuditFormatCEF, "")
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+
+	d := &Decision{
+		Time:     testDecisionTime,
+		Category: "jira",
+		Value:    strings.Repeat("A", 4096),
+		Valid:    true,
+	}
+
+	start := time.Now()
+	var dropped bool
+	for i := 0; i < 4*syslogQueueSize; i++ {
+		if err := sink.Emit(ctx, d); err != nil {
+			if !errors.Is(err, errAuditQueueFull) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			dropped = true
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("emitting took %s, expected it to never block", elapsed)
+	}
+	if !dropped {
+		t.Errorf("expected decisions to be dropped once the queue is full")
+	}
+}
+
+func TestSyslogSink_EmitAfterClose(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	sink, err := NewSyslogSink(ctx, "udp", "127.0.0.1:514", AuditFormatCEF, "")
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close sink: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("second Close() got err %v", err)
+	}
+
+	err = sink.Emit(ctx, &Decision{Time: testDecisionTime, Category: "jira", Value: "ABCD-1"})
+	if diff := testutil.DiffErrString(err, "audit sink is closed"); diff != "" {
+		t.Errorf(diff)
+	}
+}
+
+func TestNewSyslogSink(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		network string
+		format  string
+		sdID    string
+		wantErr string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:    "tls_rfc5424",
+			network: "tls",
+			format:  AuditFormatRFC5424,
+			sdID:    "jvsDecision@12345.1",
+		},
+		{
+			name:    "bad_network",
+			network: "unix",
+			wantErr: `unsupported syslog network "unix"`,
+		},
+		{
+			name:    "bad_format",
+			format:  "leef",
+			wantErr: `unsupported audit format "leef"`,
+		},
+		{
+			name:    "iana_sd_id",
+			sdID:    "origin",
+			wantErr: `invalid syslog SD-ID "origin"`,
+		},
+		{
+			name:    "bad_sd_id",
+			sdID:    "jvs decision@12345",
+			wantErr: `invalid syslog SD-ID "jvs decision@12345"`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			sink, err := NewSyslogSink(ctx, tc.network, "127.0.0.1:514", tc.format, tc.sdID)
+			if err == nil {
+				t.Cleanup(func() { sink.Close() })
+			}
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
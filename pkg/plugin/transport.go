@@ -0,0 +1,313 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+const (
+	// retryBaseDelay is the delay before the first retry, it doubles with
+	// every further retry.
+	retryBaseDelay = 100 * time.Millisecond
+
+	// maxRetries bounds the retries of a request, see [WithRetries].
+	maxRetries = 5
+)
+
+// readOnlyPostEndpoints are the endpoints taking a POST request that do not
+// change anything in Jira, so they may be retried like GET requests.
+var readOnlyPostEndpoints = map[string]bool{
+	"jql/match":  true,
+	"jql/parse":  true,
+	"search/jql": true,
+
+	"expression/eval": true,
+}
+
+// Middleware wraps the transport of the requests a [Validator] makes to Jira,
+// to add a cross-cutting behavior such as a header or tracing without
+// changing the validator.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an [http.RoundTripper].
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithMiddleware adds middlewares to the requests of the validator, the
+// first one outermost. They run inside the built-in middlewares, which log,
+// retry, authenticate and count the requests, so they see every attempt
+// with its credentials.
+func WithMiddleware(mws ...Middleware) ValidatorOption {
+	return func(v *Validator) error {
+		v.middlewares = append(v.middlewares, mws...)
+		return nil
+	}
+}
+
+// WithRetries retries requests that failed in transit or got a 5xx response
+// up to n times, with exponential backoff starting at 100ms. Only GET
+// requests and read-only POST requests, like the match request, are retried.
+// The retries count towards the request timeout.
+func WithRetries(n int) ValidatorOption {
+	return func(v *Validator) error {
+		if n < 0 || n > maxRetries {
+			return fmt.Errorf("retries must be between 0 and %d, got %d", maxRetries, n)
+		}
+		v.retries = n
+		return nil
+	}
+}
+
+// WithFaultInjection fails the given fraction of requests with a synthetic
+// 503 Service Unavailable response before they reach Jira, to test how a
+// deployment copes with Jira failing. The rate is between 0 and 1.
+func WithFaultInjection(rate float64) ValidatorOption {
+	return func(v *Validator) error {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("fault injection rate must be between 0 and 1, got %g", rate)
+		}
+		v.faultRate = rate
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the TLS certificate of
+// Jira. It is meant for test instances with a self-signed certificate only.
+func WithInsecureSkipVerify() ValidatorOption {
+	return func(v *Validator) error {
+		t := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // The default transport is an *http.Transport
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{} //nolint:gosec // MinVersion is the default of crypto/tls
+		}
+		t.TLSClientConfig.InsecureSkipVerify = true //nolint:gosec // Explicitly requested, logged and annotated
+		v.httpClient.Transport = t
+		return nil
+	}
+}
+
+// chainTransport is the transport of a validator, the base transport wrapped
+// in the middlewares.
+type chainTransport struct {
+	http.RoundTripper
+	base http.RoundTripper
+}
+
+// CloseIdleConnections closes the idle connections of the base transport.
+func (t *chainTransport) CloseIdleConnections() {
+	if c, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// newChainTransport wraps base in the middlewares, the first one outermost.
+func newChainTransport(base http.RoundTripper, mws []Middleware) *chainTransport {
+	rt := base
+	for i := len(mws) - 1; i >= 0; i-- {
+		rt = mws[i](rt)
+	}
+	return &chainTransport{RoundTripper: rt, base: base}
+}
+
+// useMiddlewares sets the transport of the validator. The requests pass, in
+// order, the request log, the retries, the authentication with API token
+// rotation, the metrics and rate limit observation, the middlewares of
+// [WithMiddleware], the selection of a regional endpoint, the host
+// allowlist, and the fault injection closest to the network.
+func (v *Validator) useMiddlewares() {
+	base := v.httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	mws := []Middleware{v.logRequests}
+	if v.retries > 0 {
+		mws = append(mws, v.retryRequests)
+	}
+	mws = append(mws, v.authenticate, v.observe)
+	mws = append(mws, v.middlewares...)
+	if v.regions != nil {
+		mws = append(mws, v.regions.route)
+	}
+	mws = append(mws, v.restrictHosts)
+	if v.faultRate > 0 {
+		mws = append(mws, injectFaults(v.faultRate, rand.Float64)) //nolint:gosec // Not security sensitive
+	}
+	v.httpClient.Transport = newChainTransport(base, mws)
+}
+
+// logRequests logs every request at debug level.
+func (v *Validator) logRequests(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+
+		ctx := req.Context()
+		logger := logging.FromContext(ctx)
+		if err != nil {
+			logger.DebugContext(ctx, "jira request failed",
+				"method", req.Method,
+				"endpoint", v.endpointName(req.URL),
+				"duration", time.Since(start),
+				"error", err)
+			return nil, err //nolint:wrapcheck // Want passthrough
+		}
+		logger.DebugContext(ctx, "jira request",
+			"method", req.Method,
+			"endpoint", v.endpointName(req.URL),
+			"duration", time.Since(start),
+			"status", resp.StatusCode)
+		return resp, nil
+	})
+}
+
+// retryRequests retries requests that failed in transit or got a 5xx
+// response, see [WithRetries].
+func (v *Validator) retryRequests(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if !v.retryable(req) {
+			return next.RoundTrip(req) //nolint:wrapcheck // Want passthrough
+		}
+
+		delay := retryBaseDelay
+		for attempt := 0; ; attempt++ {
+			attemptReq, err := rewind(req)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next.RoundTrip(attemptReq)
+			if attempt == v.retries || errors.Is(err, ErrHostNotAllowed) || (err == nil && resp.StatusCode < http.StatusInternalServerError) {
+				return resp, err //nolint:wrapcheck // Want passthrough
+			}
+			if err == nil {
+				drain(resp)
+			}
+
+			logging.FromContext(req.Context()).DebugContext(req.Context(), "retrying jira request",
+				"endpoint", v.endpointName(req.URL),
+				"attempt", attempt+1,
+				"delay", delay)
+			if err := sleep(req.Context(), delay); err != nil {
+				return nil, err
+			}
+			delay *= 2
+		}
+	})
+}
+
+// retryable reports whether the request can be sent again.
+func (v *Validator) retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return readOnlyPostEndpoints[v.endpointName(req.URL)]
+	}
+	return false
+}
+
+// rewind returns a copy of the request with a fresh body, so it can be sent
+// again.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// drain reads and closes the response body, so the connection can be
+// reused.
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, jiraResponseSizeLimitBytes))
+	resp.Body.Close()
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // Want passthrough
+	case <-t.C:
+		return nil
+	}
+}
+
+// observe counts the request for the diagnostic annotations and the
+// [Validator.APIStats], and records the rate limit Jira reports.
+func (v *Validator) observe(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		countAPICall(req.Context())
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		v.apiStats.observe(v.endpointName(req.URL), time.Since(start),
+			err != nil || resp.StatusCode >= http.StatusBadRequest)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // Want passthrough
+		}
+		v.rateLimit.observe(req.Context(), resp.Header)
+		return resp, nil
+	})
+}
+
+// injectFaults fails the given fraction of requests with a synthetic 503
+// Service Unavailable response, see [WithFaultInjection].
+func injectFaults(rate float64, random func() float64) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if random() >= rate {
+				return next.RoundTrip(req) //nolint:wrapcheck // Want passthrough
+			}
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			body := "fault injected by jvs-plugin-jira"
+			return &http.Response{
+				Status:        "503 Service Unavailable",
+				StatusCode:    http.StatusServiceUnavailable,
+				Proto:         "HTTP/1.1",
+				ProtoMajor:    1,
+				ProtoMinor:    1,
+				Header:        http.Header{"Content-Type": {"text/plain"}},
+				Body:          io.NopCloser(bytes.NewBufferString(body)),
+				ContentLength: int64(len(body)),
+				Request:       req,
+			}, nil
+		})
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	jvspb "github.com/abcxyz/jvs/apis/v0"
+	"github.com/abcxyz/pkg/logging"
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestNewChainTransport_Order(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	record := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				got = append(got, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	base := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		got = append(got, "base")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+
+	rt := newChainTransport(base, []Middleware{record("first"), record("second")})
+	req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"first", "second", "base"}, got); diff != "" {
+		t.Errorf("unexpected order (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWithMiddleware(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"accountId":"1"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	var gotAuth, gotHeader string
+	mw := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			gotAuth = req.Header.Get("Authorization")
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Request-Source", "jvs")
+			gotHeader = req.Header.Get("X-Request-Source")
+			return next.RoundTrip(req)
+		})
+	}
+
+	v, err := NewValidator(srv.URL, "project = JRA", "test@test.com", "token", WithMiddleware(mw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Myself(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// Middlewares run inside the authentication.
+	if !strings.HasPrefix(gotAuth, "Basic ") {
+		t.Errorf("got Authorization %q, want basic auth", gotAuth)
+	}
+	if gotHeader != "jvs" {
+		t.Errorf("middleware did not run")
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		retries   int
+		failures  int32
+		call      func(context.Context, *Validator) error
+		wantCalls int32
+		wantErr   string
+	}{
+		{
+			name:     "get_recovers",
+			retries:  2,
+			failures: 2,
+			call: func(ctx context.Context, v *Validator) error {
+				_, err := v.Myself(ctx)
+				return err
+			},
+			wantCalls: 3,
+		},
+		{
+			name:     "match_recovers",
+			retries:  1,
+			failures: 1,
+			call: func(ctx context.Context, v *Validator) error {
+				_, err := v.matchJQL(ctx, "1")
+				return err
+			},
+			wantCalls: 2,
+		},
+		{
+			name:     "exhausted",
+			retries:  1,
+			failures: 5,
+			call: func(ctx context.Context, v *Validator) error {
+				_, err := v.Myself(ctx)
+				return err
+			},
+			wantCalls: 2,
+			wantErr:   "got response code 503",
+		},
+		{
+			name:     "disabled",
+			failures: 1,
+			call: func(ctx context.Context, v *Validator) error {
+				_, err := v.Myself(ctx)
+				return err
+			},
+			wantCalls: 1,
+			wantErr:   "got response code 503",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tc.failures {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				fmt.Fprint(w, `{"accountId":"1","matches":[{"matchedIssues":[1],"errors":[]}]}`)
+			}))
+			t.Cleanup(srv.Close)
+
+			v, err := NewValidator(srv.URL, "project = JRA", "test@test.com", "token", WithRetries(tc.retries))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tc.call(context.Background(), v)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if got := calls.Load(); got != tc.wantCalls {
+				t.Errorf("got %d calls, want %d", got, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestValidator_Retryable(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewValidator("https://example.atlassian.net/rest/api/3", "project = JRA", "test@test.com", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{method: http.MethodGet, path: "/rest/api/3/issue/ABCD-1", want: true},
+		{method: http.MethodPost, path: "/rest/api/3/jql/match", want: true},
+		{method: http.MethodPost, path: "/rest/api/3/issue/ABCD-1/comment", want: false},
+		{method: http.MethodPut, path: "/rest/api/3/issue/ABCD-1", want: false},
+	}
+
+	for _, tc := range cases {
+		req, err := http.NewRequest(tc.method, "https://example.atlassian.net"+tc.path, strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.retryable(req); got != tc.want {
+			t.Errorf("retryable(%s %s) got %t, want %t", tc.method, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestWithFaultInjection(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		fmt.Fprint(w, `{"accountId":"1"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	v, err := NewValidator(srv.URL, "project = JRA", "test@test.com", "token", WithFaultInjection(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = v.Myself(context.Background())
+	if !errors.Is(err, ErrJiraUnreachable) {
+		t.Errorf("got err %v, want it to wrap %v", err, ErrJiraUnreachable)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("got %d calls to Jira, want 0", got)
+	}
+	if got := v.APIStats()["myself"].Failures; got != 1 {
+		t.Errorf("got %d failures counted, want 1", got)
+	}
+
+	if _, err := NewValidator(srv.URL, "project = JRA", "test@test.com", "token", WithFaultInjection(1.5)); err == nil {
+		t.Errorf("got no error for a rate above 1")
+	}
+}
+
+func TestPlugin_InsecureSkipVerify(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/jql/match") {
+			fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	cases := []struct {
+		name     string
+		insecure bool
+		want     *jvspb.ValidateJustificationResponse
+		wantErr  string
+	}{
+		{
+			name:    "verified",
+			wantErr: "certificate",
+		},
+		{
+			name:     "insecure",
+			insecure: true,
+			want: &jvspb.ValidateJustificationResponse{
+				Valid:   true,
+				Warning: []string{},
+				Annotation: map[string]string{
+					"jira_issue_id":           "1234",
+					"jira_issue_url":          "https://example.atlassian.net/browse/ABCD-1",
+					"jira_insecure_transport": "true",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			p, err := NewJiraPluginWithToken(ctx, &PluginConfig{
+				JIRAEndpoint:       srv.URL,
+				Jql:                "status NOT IN (Done)",
+				JIRAAccount:        "test@test.com",
+				IssueBaseURL:       "https://example.atlassian.net",
+				InsecureSkipVerify: tc.insecure,
+			}, "secrets")
+			if err != nil {
+				t.Fatalf("failed to create plugin: %v", err)
+			}
+			t.Cleanup(func() { p.Close(context.Background()) })
+
+			got, err := p.Validate(ctx, &jvspb.ValidateJustificationRequest{
+				Justification: &jvspb.Justification{Category: "jira", Value: "ABCD-1"},
+			})
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Errorf(diff)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.IgnoreUnexported(jvspb.ValidateJustificationResponse{})); diff != "" {
+				t.Errorf("unexpected response (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
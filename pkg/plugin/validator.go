@@ -25,7 +25,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"path"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -33,8 +34,29 @@ const (
 	// jiraResponseSizeLimitBytes is the maximum bytes be read from JIRA REST
 	// API response.
 	jiraResponseSizeLimitBytes = 4_000_000 // 4mb
+
+	// maxPooledBufferBytes is the largest buffer returned to bufferPool, so an
+	// occasional huge response does not stay pinned in memory.
+	maxPooledBufferBytes = 64 * 1024
+
+	// defaultIssueFieldsQuery is the pre-encoded query of the [Get Issue API]
+	// request, it only asks for the fields the validator needs.
+	//
+	// [Get Issue API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-issueidorkey-get
+	defaultIssueFieldsQuery = "fields=key%2Cid"
+
+	// matchDataOverheadBytes is the size of the JSON encoded [matchData] without
+	// the issue id and the JQL.
+	matchDataOverheadBytes = len(`{"issueIds":[""],"jqls":[""]}` + "\n")
 )
 
+// bufferPool holds buffers used to read response bodies.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
 // Validator validates jira issue against validation criteria.
 type Validator struct {
 	// baseURL is the JIRA REST API url. Example:
@@ -54,18 +76,116 @@ type Validator struct {
 	// [JIRA Basic Auth]: https://developer.atlassian.com/cloud/jira/platform/basic-auth-for-rest-apis/
 	apiToken string
 
+	// secondaryAPIToken is tried when Jira rejects the token in use, see
+	// [WithSecondaryAPIToken]. useSecondaryAPIToken is set while the
+	// secondary token is the one in use.
+	secondaryAPIToken    string
+	useSecondaryAPIToken atomic.Bool
+
 	// jql is the [JQL] query specifying validation criteria.
 	//
 	// [JQL]: https://support.atlassian.com/jira-service-management-cloud/docs/use-advanced-search-with-jira-query-language-jql/
 	jql string
+
+	// searchJQLPrefix is the JQL of a search request up to the quoted issue
+	// key, it is set in search mode only. See [WithSearchMode].
+	searchJQLPrefix string
+
+	// candidateJQL is evaluated in shadow of jql, see [WithCandidateJQL].
+	candidateJQL string
+
+	// issueTypeJQL maps lower case issue type names to the JQL issues of the
+	// type are matched against instead of jql, see [WithIssueTypeJQL].
+	issueTypeJQL map[string]string
+
+	// issueChecks are evaluated against the fetched issue before it is
+	// matched, see [WithMinPriority], [WithFieldConstraints] and
+	// [WithLinkRules].
+	issueChecks []issueCheck
+
+	// clock reads the time values of fields for the time-based checks, see
+	// [WithSiteClock]. The checks share it, so it is updated in place.
+	clock *jiraClock
+
+	// expression is the Jira expression an issue must satisfy, see
+	// [WithExpression].
+	expression string
+
+	// annotateArchived sets [MatchResult.ArchivedAt] of archived issues, see
+	// [WithArchivedIssues].
+	annotateArchived bool
+
+	// snapshotHash sets [MatchResult.SnapshotHash], see
+	// [WithIssueSnapshotHash].
+	snapshotHash bool
+
+	// issueFieldsQuery is the pre-encoded query of the Get Issue API request.
+	issueFieldsQuery string
+
+	// bulkFetchUnsupported is set once jira rejected a bulk fetch request,
+	// issues are then fetched one by one, see [Validator.fetchIssues].
+	bulkFetchUnsupported atomic.Bool
+
+	// annotationFields are the issue fields returned in
+	// [MatchResult.IssueFields], each at most annotationFieldMaxBytes long.
+	// See [WithAnnotationFields].
+	annotationFields        []string
+	annotationFieldMaxBytes int
+
+	// fieldRenderers render annotation fields by name instead of
+	// renderField, see [WithAnnotationFieldRenderers].
+	fieldRenderers map[string]fieldRenderer
+
+	// fieldRedactions are the redaction rules of annotation fields by name,
+	// see [WithFieldRedactions].
+	fieldRedactions map[string]*fieldRedaction
+
+	// accounts caches the account ids of requestors, the requestor
+	// placeholder is replaced with the email when nil. See [WithAccountIDs].
+	accounts *accountCache
+
+	// identities maps requestors to Jira users, subjects are used as is when
+	// nil. See [WithIdentityMapper].
+	identities IdentityMapper
+
+	// rateLimit is the last rate limit reported by jira.
+	rateLimit rateLimitGauge
+
+	// apiStats counts the requests to jira by endpoint.
+	apiStats endpointCounters
+
+	// middlewares wrap the requests inside the built-in middlewares, see
+	// [WithMiddleware].
+	middlewares []Middleware
+
+	// retries is the number of retries of a failed request, see
+	// [WithRetries].
+	retries int
+
+	// faultRate is the fraction of requests failed on purpose, see
+	// [WithFaultInjection].
+	faultRate float64
+
+	// regions selects the endpoint requests are sent to, it is nil without
+	// regional endpoints, see [WithRegionalEndpoints].
+	regions *regionSelector
+
+	// allowedHosts are the only hosts requests are sent to, see
+	// [WithAllowedHosts].
+	allowedHosts []string
+
+	// maxRedirects is how many redirects a request follows, see
+	// [WithMaxRedirects].
+	maxRedirects int
 }
 
 // jiraIssue is the representation of a [jira issue].
 //
 // [jira issue]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-issueidorkey-get
 type jiraIssue struct {
-	Key string `json:"key"`
-	ID  string `json:"id"`
+	Key    string                     `json:"key"`
+	ID     string                     `json:"id"`
+	Fields map[string]json.RawMessage `json:"fields"`
 }
 
 // matchData contains data needed in the request body of a [match request].
@@ -89,84 +209,491 @@ type Match struct {
 // [match request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-search/#api-rest-api-3-jql-match-post
 type MatchResult struct {
 	Matches []*Match `json:"matches"`
+
+	// IssueFields holds the rendered annotation fields of the issue, it is not
+	// part of the jira response. See [WithAnnotationFields].
+	IssueFields map[string]string `json:"issueFields,omitempty"`
+
+	// Candidate is the match of the candidate JQL evaluated in shadow, it is
+	// not part of the jira response. See [WithCandidateJQL].
+	Candidate *Match `json:"candidate,omitempty"`
+
+	// IssueVersion holds the cache validators of the issue response, it is
+	// not part of the jira response. It is nil in search mode or when jira
+	// returned neither an ETag nor a Last-Modified header.
+	IssueVersion *IssueVersion `json:"issueVersion,omitempty"`
+
+	// ArchivedAt is the archival time of an accepted archived issue in RFC
+	// 3339 format, it is not part of the jira response. See
+	// [WithArchivedIssues].
+	ArchivedAt string `json:"archivedAt,omitempty"`
+
+	// SnapshotHash is the [IssueSnapshotHash] of the issue, it is not part of
+	// the jira response. See [WithIssueSnapshotHash].
+	SnapshotHash string `json:"snapshotHash,omitempty"`
+
+	// Stale is set when the result is an expired cached match served while
+	// it is refreshed, it is not part of the jira response and never cached.
+	// See [PluginConfig.CacheMaxStaleness].
+	Stale bool `json:"-"`
+}
+
+// IssueVersion identifies a version of an issue with the [cache validators]
+// of the Get Issue response, so the issue can be requested again
+// conditionally.
+//
+// [cache validators]: https://developer.mozilla.org/en-US/docs/Web/HTTP/Conditional_requests
+type IssueVersion struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// Issue is a jira issue with the fields the validator uses.
+type Issue struct {
+	Key string `json:"key"`
+	ID  string `json:"id"`
+
+	// Fields holds the annotation fields, rendered and truncated as they
+	// would be in the annotation. See [WithAnnotationFields].
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// JiraUser is the representation of the [current user].
+//
+// [current user]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-myself/#api-rest-api-3-myself-get
+type JiraUser struct {
+	AccountID    string `json:"accountId"`
+	EmailAddress string `json:"emailAddress"`
+	DisplayName  string `json:"displayName"`
+	Active       bool   `json:"active"`
+}
+
+// parseData contains data needed in the request body of a [parse request].
+//
+// [parse request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-jql/#api-rest-api-3-jql-parse-post
+type parseData struct {
+	Queries []string `json:"queries"`
+}
+
+// ParsedJQL reports the result of parsing a single JQL query with the
+// [parse request].
+//
+// [parse request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-jql/#api-rest-api-3-jql-parse-post
+type ParsedJQL struct {
+	Query  string   `json:"query"`
+	Errors []string `json:"errors"`
+}
+
+// ParseResult reports full list of result of the [parse request].
+//
+// [parse request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-jql/#api-rest-api-3-jql-parse-post
+type ParseResult struct {
+	Queries []*ParsedJQL `json:"queries"`
 }
 
-// NewValidator creates a new validator.
-func NewValidator(baseURL, jql, account, apiToken string) (*Validator, error) {
-	u, err := url.Parse(baseURL)
+// NewValidator creates a new validator. The baseURL is the REST API
+// endpoint, it may have a port and a context path, e.g.
+// https://jira.example.com:8443/jira/rest/api/2.
+func NewValidator(baseURL, jql, account, apiToken string, opts ...ValidatorOption) (*Validator, error) {
+	u, err := parseEndpoint(baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse baseURL %s: %w", baseURL, err)
+		return nil, err
 	}
-	return &Validator{
+	v := &Validator{
 		baseURL:    u,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
 		jql:        jql,
 		account:    account,
 		apiToken:   apiToken,
-	}, nil
+
+		issueFieldsQuery: defaultIssueFieldsQuery,
+		clock:            &jiraClock{location: time.UTC, now: time.Now},
+		maxRedirects:     defaultMaxRedirects,
+	}
+	v.httpClient.CheckRedirect = v.checkRedirect
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			return nil, err
+		}
+	}
+	if v.allowedHosts == nil {
+		v.allowedHosts = v.defaultAllowedHosts()
+	}
+	v.useMiddlewares()
+	return v, nil
+}
+
+// parseEndpoint parses the Jira REST API endpoint. It must be an absolute
+// http or https URL without query or fragment, it may have a port and a
+// context path.
+func parseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse endpoint %s: %w", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint %s, must be an http or https url", endpoint)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %s, missing host", endpoint)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid endpoint %s, must not have a query or fragment", endpoint)
+	}
+	return u, nil
+}
+
+// endpointURL returns the URL of the REST resource at the path elements
+// under the endpoint, keeping its port and context path. Every element is
+// escaped as a single path segment, so a slash or percent sign in it cannot
+// add segments to the path.
+func (v *Validator) endpointURL(elem ...string) *url.URL {
+	escaped := make([]string, len(elem))
+	for i, e := range elem {
+		escaped[i] = url.PathEscape(e)
+	}
+	return v.baseURL.JoinPath(escaped...)
+}
+
+// issuePathSegment returns the issue key or id as a path segment of a
+// request, or an error wrapping ErrInvalidJustification when it could
+// change the path of the request. It is stricter than the issue key pattern
+// and does not depend on it, only ASCII letters, digits, underscores and
+// hyphens are allowed.
+func issuePathSegment(issueIDOrKey string) (string, error) {
+	if issueIDOrKey == "" {
+		return "", WithReason(fmt.Errorf("empty jira issue key: %w", ErrInvalidJustification), ErrorCodeInvalidIssueKey)
+	}
+	for i := 0; i < len(issueIDOrKey); i++ {
+		c := issueIDOrKey[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '_' || c == '-' {
+			continue
+		}
+		return "", WithReason(fmt.Errorf("%q is not a jira issue key: %w", issueIDOrKey, ErrInvalidJustification), ErrorCodeInvalidIssueKey)
+	}
+	return issueIDOrKey, nil
+}
+
+// NewValidatorFromConfig creates a new validator for the config, fetching
+// the API tokens, and the account when it is a secret too, from Secret
+// Manager.
+func NewValidatorFromConfig(ctx context.Context, cfg *PluginConfig) (*Validator, error) {
+	secrets := newSecretManager(cfg)
+	defer secrets.Close()
+	return newValidatorFromSecrets(ctx, cfg, secrets.access)
+}
+
+// newValidatorFromSecrets is [NewValidatorFromConfig] fetching the secrets
+// with accessSecret.
+func newValidatorFromSecrets(ctx context.Context, cfg *PluginConfig, accessSecret func(context.Context, string) (string, error)) (*Validator, error) {
+	account, err := cfg.account(ctx, accessSecret)
+	if err != nil {
+		return nil, err
+	}
+	apiToken, err := accessSecret(ctx, cfg.APITokenSecretID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch API token: %w", err)
+	}
+	var opts []ValidatorOption
+	if cfg.SecondaryAPITokenSecretID != "" {
+		secondary, err := accessSecret(ctx, cfg.SecondaryAPITokenSecretID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch secondary API token: %w", err)
+		}
+		opts = append(opts, WithSecondaryAPIToken(secondary))
+	}
+	if cfg.IdentityMappingSecretID != "" {
+		mapping, err := accessSecret(ctx, cfg.IdentityMappingSecretID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch identity mapping: %w", err)
+		}
+		opts = append(opts, withConfigIdentityMapper(cfg, mapping))
+	}
+	return newConfigValidator(ctx, cfg, account, apiToken, opts...)
+}
+
+// Close closes the idle connections to Jira. Requests in flight are not
+// interrupted, and a later request opens a new connection.
+func (v *Validator) Close(ctx context.Context) error {
+	v.httpClient.CloseIdleConnections()
+	return nil
 }
 
 // MatchIssue checks the jira issue against the JQL criteria.
 func (v *Validator) MatchIssue(ctx context.Context, issueKey string) (*MatchResult, error) {
-	issue, err := v.jiraIssue(ctx, issueKey)
+	return v.matchIssueSince(ctx, issueKey, nil)
+}
+
+// matchIssueSince checks the jira issue against the JQL criteria like
+// [Validator.MatchIssue], but only when the issue changed since the given
+// version. It returns an error wrapping errNotModified when jira reports the
+// issue unchanged, a nil version always matches. The version is ignored in
+// search mode, which does not fetch the issue, and with [WithMaxIssueAge],
+// whose checks depend on the time as well as on the issue.
+func (v *Validator) matchIssueSince(ctx context.Context, issueKey string, since *IssueVersion) (*MatchResult, error) {
+	if v.searchJQLPrefix != "" {
+		prefix, err := v.personalizeJQL(ctx, v.searchJQLPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("jira issue %q rejected: %w", issueKey, err)
+		}
+		stop := timeStage(ctx, stageMatch)
+		result, err := v.searchIssue(ctx, prefix, issueKey)
+		stop()
+		if err != nil {
+			explainCheck(ctx, "search", prefix, ExplainError, err.Error())
+			return nil, fmt.Errorf("failed to search jira issue %q: %w", issueKey, err)
+		}
+		explainCheck(ctx, "search", prefix, explainMatched(result), "")
+		if v.candidateJQL != "" {
+			result.Candidate = v.searchCandidate(ctx, issueKey)
+		}
+		return result, nil
+	}
+
+	if v.hasIssueAgeCheck() {
+		// An unchanged issue can still have grown too old.
+		since = nil
+	}
+	stop := timeStage(ctx, stageIssueFetch)
+	issue, version, err := v.jiraIssueSince(ctx, issueKey, since)
+	stop()
 	if err != nil {
+		if !errors.Is(err, errNotModified) {
+			explainCheck(ctx, "fetch", issueKey, ExplainError, err.Error())
+		}
 		return nil, fmt.Errorf("failed to get jira issue %q: %w", issueKey, err)
 	}
+	explainCheck(ctx, "fetch", issueKey, ExplainPass, "")
+	jql, err := v.personalizeJQL(ctx, v.jqlFor(issue))
+	if err != nil {
+		return nil, fmt.Errorf("jira issue %q rejected: %w", issueKey, err)
+	}
+	stop = timeStage(ctx, stagePolicy)
+	checkErr := v.checkIssue(ctx, issue)
+	stop()
+	var pe *policyError
+	if checkErr != nil && !errors.As(checkErr, &pe) {
+		return nil, fmt.Errorf("failed to validate jira issue %q: %w", issueKey, checkErr)
+	}
 
-	result, err := v.matchJQL(ctx, issue)
+	stop = timeStage(ctx, stageMatch)
+	result, err := v.matchWithCandidate(ctx, jql, issue.ID)
+	stop()
+	if err != nil {
+		explainCheck(ctx, "jql", jql, ExplainError, err.Error())
+	} else {
+		explainCheck(ctx, "jql", jql, explainMatched(result), "")
+	}
+	if checkErr != nil {
+		// The issue is rejected either way, the JQL is still evaluated to
+		// report all the failures at once.
+		if err == nil && (len(result.Matches) == 0 || len(result.Matches[0].MatchedIssues) == 0) {
+			pe.failures = append(pe.failures, WithReason(fmt.Errorf("no match for the JQL: %w", ErrInvalidJustification), ErrorCodeNoMatch))
+		}
+		return nil, fmt.Errorf("jira issue %q rejected: %w", issueKey, checkErr)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to validate jira issue %q: %w", issueKey, err)
 	}
+	result.IssueFields = v.projectFields(issue.Key, issue.Fields)
+	result.ArchivedAt = v.archivedAt(issue.Fields)
+	if v.snapshotHash {
+		result.SnapshotHash = IssueSnapshotHash(issue.Key, issue.Fields)
+	}
+	result.IssueVersion = version
 	return result, nil
 }
 
+// Issue fetches the jira issue without matching it against the JQL.
+func (v *Validator) Issue(ctx context.Context, issueKey string) (*Issue, error) {
+	issue, err := v.jiraIssue(ctx, issueKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jira issue %q: %w", issueKey, err)
+	}
+	return &Issue{
+		Key:    issue.Key,
+		ID:     issue.ID,
+		Fields: v.projectFields(issue.Key, issue.Fields),
+	}, nil
+}
+
 // jiraIssue sends a request to jira endpoint and returns the jira issue.
 func (v *Validator) jiraIssue(ctx context.Context, issueIDOrKey string) (*jiraIssue, error) {
+	issue, _, err := v.jiraIssueSince(ctx, issueIDOrKey, nil)
+	return issue, err
+}
+
+// jiraIssueSince sends a request to jira endpoint and returns the jira issue
+// and its version. When since is not nil the request is conditional and an
+// error wrapping errNotModified is returned when the issue did not change.
+func (v *Validator) jiraIssueSince(ctx context.Context, issueIDOrKey string, since *IssueVersion) (*jiraIssue, *IssueVersion, error) {
 	// Construct [Get Issue API].
 	//
 	// [Get Issue API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issues/#api-rest-api-3-issue-issueidorkey-get
-	u := &url.URL{
-		Scheme: v.baseURL.Scheme,
-		Host:   v.baseURL.Host,
-		Path:   path.Join(v.baseURL.Path, "issue", issueIDOrKey),
+	segment, err := issuePathSegment(issueIDOrKey)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	q := u.Query()
-	q.Set("fields", "key,id")
-	u.RawQuery = q.Encode()
+	u := v.endpointURL("issue", segment)
+	u.RawQuery = v.issueFieldsQuery
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to construct jira issue request: %w", err)
+		return nil, nil, fmt.Errorf("failed to construct jira issue request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/json")
+	if since != nil {
+		if since.ETag != "" {
+			req.Header.Set("If-None-Match", since.ETag)
+		}
+		if since.LastModified != "" {
+			req.Header.Set("If-Modified-Since", since.LastModified)
+		}
+	}
 
 	var jiraIssue jiraIssue
-	if err := v.makeRequest(req, &jiraIssue); err != nil {
+	header, err := v.makeRequestHeader(req, &jiraIssue)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var version *IssueVersion
+	if etag, lastModified := header.Get("ETag"), header.Get("Last-Modified"); etag != "" || lastModified != "" {
+		version = &IssueVersion{ETag: etag, LastModified: lastModified}
+	}
+	return &jiraIssue, version, nil
+}
+
+// MatchIssues checks several jira issues against the JQL with as few
+// [match request]s as the API limits allow and returns the raw result, the
+// matched issues are reported by id. The issues are fetched in bulk, see
+// [Validator.fetchIssues]. Unlike [Validator.MatchIssue] it always uses the
+// match request and does not return annotation fields.
+//
+// [match request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-search/#api-rest-api-3-jql-match-post
+func (v *Validator) MatchIssues(ctx context.Context, issueKeys []string) (*MatchResult, error) {
+	issues, err := v.fetchIssues(ctx, issueKeys)
+	if err != nil {
 		return nil, err
 	}
+	ids := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		ids = append(ids, issue.ID)
+	}
+
+	var result *MatchResult
+	for _, chunk := range chunkStrings(ids, matchMaxIssueIDs) {
+		r, err := v.matchJQL(ctx, chunk...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match jira issues %q: %w", issueKeys, err)
+		}
+		result = mergeMatchResults(result, r)
+	}
+	return result, nil
+}
 
-	return &jiraIssue, nil
+// matchJQL checks the jira issues against the JQL.
+func (v *Validator) matchJQL(ctx context.Context, issueIDs ...string) (*MatchResult, error) {
+	jql, err := v.personalizeJQL(ctx, v.jql)
+	if err != nil {
+		return nil, err
+	}
+	return v.match(ctx, []string{jql}, issueIDs...)
 }
 
-// matchJQL checks the jira issue against the JQL.
-func (v *Validator) matchJQL(ctx context.Context, issue *jiraIssue) (*MatchResult, error) {
+// match checks the jira issues against each of the JQLs, the result has one
+// match per JQL.
+func (v *Validator) match(ctx context.Context, jqls []string, issueIDs ...string) (*MatchResult, error) {
 	// Construct [Match API].
 	//
 	// [Match API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-issue-search/#api-rest-api-3-jql-match-post
-	u := &url.URL{
-		Scheme: v.baseURL.Scheme,
-		Host:   v.baseURL.Host,
-		Path:   path.Join(v.baseURL.Path, "jql", "match"),
-	}
+	u := v.endpointURL("jql", "match")
 
-	// Create the request body.
+	// Create the request body. It is not taken from bufferPool because the
+	// transport may still read it after the response is returned. The JQL may
+	// grow when escaped, so the pre-sized buffer is a lower bound.
 	data := matchData{
-		IssueIDs: []string{issue.ID},
-		Jqls:     []string{v.jql},
+		IssueIDs: issueIDs,
+		Jqls:     jqls,
+	}
+	size := matchDataOverheadBytes
+	for i, jql := range jqls {
+		if i > 0 {
+			size += len(`,""`)
+		}
+		size += len(jql)
+	}
+	for i, id := range issueIDs {
+		if i > 0 {
+			size += len(`,""`)
+		}
+		size += len(id)
+	}
+	body := bytes.NewBuffer(make([]byte, 0, size))
+	if err := json.NewEncoder(body).Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to construct request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	var result MatchResult
+	if err := v.makeRequest(req, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Myself returns the jira user the validator is authenticated as.
+func (v *Validator) Myself(ctx context.Context) (*JiraUser, error) {
+	// Construct [Get Current User API].
+	//
+	// [Get Current User API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-myself/#api-rest-api-3-myself-get
+	u := v.endpointURL("myself")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct myself request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	var user JiraUser
+	if err := v.makeRequest(req, &user); err != nil {
+		return nil, err
 	}
-	body, err := json.Marshal(data)
+
+	return &user, nil
+}
+
+// ParseJQL asks jira to strictly parse the configured JQL, followed by the
+// candidate JQL if any and the issue type JQLs sorted by issue type. The
+// requestor placeholder is parsed as an empty string.
+func (v *Validator) ParseJQL(ctx context.Context) (*ParseResult, error) {
+	// Construct [Parse JQL API].
+	//
+	// [Parse JQL API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-jql/#api-rest-api-3-jql-parse-post
+	u := v.endpointURL("jql", "parse")
+
+	q := u.Query()
+	q.Set("validation", "strict")
+	u.RawQuery = q.Encode()
+
+	queries := []string{parsableJQL(v.jql)}
+	if v.candidateJQL != "" {
+		queries = append(queries, v.candidateJQL)
+	}
+	for _, jql := range v.routedJQLs() {
+		queries = append(queries, parsableJQL(jql))
+	}
+	body, err := json.Marshal(parseData{Queries: queries})
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct request body: %w", err)
 	}
@@ -179,7 +706,7 @@ func (v *Validator) matchJQL(ctx context.Context, issue *jiraIssue) (*MatchResul
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 
-	var result MatchResult
+	var result ParseResult
 	if err := v.makeRequest(req, &result); err != nil {
 		return nil, err
 	}
@@ -187,33 +714,93 @@ func (v *Validator) matchJQL(ctx context.Context, issue *jiraIssue) (*MatchResul
 	return &result, nil
 }
 
+// RateLimit returns the rate limit jira reported with the last response that
+// had rate limit headers, or nil when there was none.
+func (v *Validator) RateLimit() *RateLimit {
+	return v.rateLimit.load()
+}
+
 // makeRequest sends an HTTP request, decodes the response and stores the data
 // in the value pointed by respVal.
 func (v *Validator) makeRequest(req *http.Request, respVal any) error {
-	req.SetBasicAuth(v.account, v.apiToken)
+	_, err := v.makeRequestHeader(req, respVal)
+	return err
+}
 
-	resp, err := v.httpClient.Do(req)
+// makeRequestHeader is like [Validator.makeRequest] and also returns the
+// response header. A 304 Not Modified response returns an error wrapping
+// errNotModified.
+func (v *Validator) makeRequestHeader(req *http.Request, respVal any) (http.Header, error) {
+	resp, err := v.do(req)
 	if err != nil {
-		return fmt.Errorf("failed to make request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= http.StatusInternalServerError {
-		// Return ErrInternal if jira api returns http status code 5xx.
-		return fmt.Errorf(
+	defer func() {
+		// Drain the body so the connection can be reused.
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, jiraResponseSizeLimitBytes))
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return resp.Header, fmt.Errorf("%s: %w", req.URL.String(), errNotModified)
+	} else if isMaintenanceResponse(resp) {
+		return nil, maintenanceError(req, resp)
+	} else if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		// Return ErrJiraUnreachable if jira api returns http status code 5xx
+		// or rate limits the request, with the Retry-After if any.
+		err := WithJiraStatus(fmt.Errorf(
 			"failed to make request to %s, got response code %d: %w",
-			req.URL.String(), resp.StatusCode, err)
+			req.URL.String(), resp.StatusCode, ErrJiraUnreachable), resp.StatusCode)
+		if delay, ok := parseRetryAfter(resp.Header, time.Now()); ok {
+			err = WithRetryAfter(err, delay)
+		}
+		return nil, err
+	} else if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		// The credentials are rejected, which also invalidates the
+		// justification like any other 4xx.
+		return nil, WithJiraStatus(WithReason(fmt.Errorf(
+			"failed to make request to %s, got response code %d: %w: %w",
+			req.URL.String(), resp.StatusCode, ErrJiraAuth, ErrInvalidJustification), ErrorCodeJiraRejected), resp.StatusCode)
 	} else if resp.StatusCode >= http.StatusBadRequest {
-		// Return errInvalidJustification if jira api returns http status code 4xx.
-		return fmt.Errorf(
+		// Return ErrInvalidJustification if jira api returns http status code 4xx.
+		code := ErrorCodeJiraRejected
+		if resp.StatusCode == http.StatusNotFound {
+			code = ErrorCodeIssueNotFound
+		}
+		return nil, WithJiraStatus(WithReason(fmt.Errorf(
 			"failed to make request to %s, got response code %d: %w",
-			req.URL.String(), resp.StatusCode, errors.Join(errInvalidJustification, err))
+			req.URL.String(), resp.StatusCode, ErrInvalidJustification), code), resp.StatusCode)
 	}
 
-	r := io.LimitReader(resp.Body, jiraResponseSizeLimitBytes)
-	if err := json.NewDecoder(r).Decode(&respVal); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+	buf := bufferPool.Get().(*bytes.Buffer) //nolint:forcetypeassert // bufferPool only holds *bytes.Buffer
+	defer putBuffer(buf)
+
+	// Read one byte past the limit to tell a truncated response from one that
+	// is exactly at the limit.
+	if _, err := buf.ReadFrom(io.LimitReader(resp.Body, jiraResponseSizeLimitBytes+1)); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if buf.Len() > jiraResponseSizeLimitBytes {
+		return nil, fmt.Errorf("response from %s exceeds %d bytes", req.URL.String(), jiraResponseSizeLimitBytes)
+	}
+	if err := json.Unmarshal(buf.Bytes(), respVal); err != nil {
+		if !isJSONResponse(resp) {
+			// Not the REST API answering, e.g. a proxy in front of Jira.
+			return nil, fmt.Errorf("failed to decode %s response from %s: %w: %w",
+				contentTypeName(resp), req.URL.String(), err, ErrJiraUnreachable)
+		}
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return nil
+	return resp.Header, nil
+}
+
+// putBuffer returns buf to bufferPool unless it grew beyond
+// maxPooledBufferBytes.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferBytes {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
 }
@@ -15,10 +15,14 @@
 package plugin
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -150,3 +154,334 @@ func TestValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestValidation_LargeResponse(t *testing.T) {
+	t.Parallel()
+
+	// The padding pushes the issue response past maxPooledBufferBytes.
+	padding := strings.Repeat("x", 2*maxPooledBufferBytes)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":"1234","key":"ABCD","padding":%q}`, padding)
+	})
+	mux.HandleFunc("/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	validator, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "secrets")
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	got, err := validator.MatchIssue(ctx, "ABCD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &MatchResult{Matches: []*Match{{MatchedIssues: []int{1234}, Errors: []string{}}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Failed validation (-want,+got):\n%s", diff)
+	}
+}
+
+func TestValidation_ContextPath(t *testing.T) {
+	t.Parallel()
+
+	// A Data Center style endpoint, with a context path and the non-standard
+	// port of the test server.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jira/rest/api/2/issue/ABCD-1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"1234","key":"ABCD-1"}`)
+	})
+	mux.HandleFunc("/jira/rest/api/2/jql/match", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"matches":[{"matchedIssues":[1234],"errors":[]}]}`)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	for _, endpoint := range []string{srv.URL + "/jira/rest/api/2", srv.URL + "/jira/rest/api/2/"} {
+		validator, err := NewValidator(endpoint, "status NOT IN (Done)", "test@test.com", "secrets")
+		if err != nil {
+			t.Fatalf("failed to create validator: %v", err)
+		}
+
+		ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+		got, err := validator.MatchIssue(ctx, "ABCD-1")
+		if err != nil {
+			t.Fatalf("endpoint %s: unexpected error: %v", endpoint, err)
+		}
+		want := &MatchResult{Matches: []*Match{{MatchedIssues: []int{1234}, Errors: []string{}}}}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("endpoint %s: failed validation (-want,+got):\n%s", endpoint, diff)
+		}
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		endpoint string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "cloud",
+			endpoint: "https://example.atlassian.net/rest/api/3",
+			want:     "https://example.atlassian.net/rest/api/3",
+		},
+		{
+			name:     "context_path_and_port",
+			endpoint: "https://jira.corp.example:8443/jira/rest/api/2",
+			want:     "https://jira.corp.example:8443/jira/rest/api/2",
+		},
+		{
+			name:     "missing_scheme",
+			endpoint: "jira.corp.example/rest/api/2",
+			wantErr:  "must be an http or https url",
+		},
+		{
+			name:     "missing_host",
+			endpoint: "https:///rest/api/2",
+			wantErr:  "missing host",
+		},
+		{
+			name:     "query",
+			endpoint: "https://jira.corp.example/rest/api/2?x=y",
+			wantErr:  "must not have a query or fragment",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseEndpoint(tc.endpoint)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				return
+			}
+			if got, want := got.String(), tc.want; got != want {
+				t.Errorf("parseEndpoint() got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestIssuePathSegment(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		key     string
+		wantErr string
+	}{
+		{name: "key", key: "ABCD-1"},
+		{name: "lower_case_key", key: "abcd_x-12"},
+		{name: "id", key: "10001"},
+		{name: "empty", key: "", wantErr: "empty jira issue key"},
+		{name: "traversal", key: "ABCD/../../admin", wantErr: "is not a jira issue key"},
+		{name: "dot_dot", key: "..", wantErr: "is not a jira issue key"},
+		{name: "encoded_slash", key: "ABCD%2F..%2Fadmin", wantErr: "is not a jira issue key"},
+		{name: "backslash", key: `ABCD\..\admin`, wantErr: "is not a jira issue key"},
+		{name: "query", key: "ABCD-1?expand=all", wantErr: "is not a jira issue key"},
+		{name: "fragment", key: "ABCD-1#x", wantErr: "is not a jira issue key"},
+		{name: "whitespace", key: "ABCD-1 ", wantErr: "is not a jira issue key"},
+		{name: "non_ascii", key: "ÄBCD-1", wantErr: "is not a jira issue key"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := issuePathSegment(tc.key)
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Fatal(diff)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidJustification) {
+					t.Errorf("issuePathSegment() got error %v, want ErrInvalidJustification", err)
+				}
+				if got, want := Reason(err), ErrorCodeInvalidIssueKey; got != want {
+					t.Errorf("issuePathSegment() got reason %q, want %q", got, want)
+				}
+				return
+			}
+			if got != tc.key {
+				t.Errorf("issuePathSegment() got %q, want %q", got, tc.key)
+			}
+		})
+	}
+}
+
+func TestEndpointURL_EscapesSegments(t *testing.T) {
+	t.Parallel()
+
+	validator, err := NewValidator("https://jira.corp.example:8443/jira/rest/api/2", "project = ABCD", "test@test.com", "secrets")
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	cases := []struct {
+		elem []string
+		want string
+	}{
+		{elem: []string{"issue", "ABCD-1"}, want: "https://jira.corp.example:8443/jira/rest/api/2/issue/ABCD-1"},
+		{elem: []string{"issue", "ABCD/admin"}, want: "https://jira.corp.example:8443/jira/rest/api/2/issue/ABCD%2Fadmin"},
+		{elem: []string{"issue", "ABCD%2F..%2Fadmin"}, want: "https://jira.corp.example:8443/jira/rest/api/2/issue/ABCD%252F..%252Fadmin"},
+		{elem: []string{"issue", "ABCD-1?expand=all"}, want: "https://jira.corp.example:8443/jira/rest/api/2/issue/ABCD-1%3Fexpand=all"},
+	}
+	for _, tc := range cases {
+		if got := validator.endpointURL(tc.elem...).String(); got != tc.want {
+			t.Errorf("endpointURL(%q) got %q, want %q", tc.elem, got, tc.want)
+		}
+	}
+}
+
+func TestValidation_PathAlteringKey(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	validator, err := NewValidator(srv.URL+"/rest/api/3", "project = ABCD", "test@test.com", "secrets")
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	for _, key := range []string{"ABCD/../../admin", "../myself", "ABCD-1/../../../serverInfo", "ABCD%2F..%2Fadmin"} {
+		if _, err := validator.MatchIssue(ctx, key); !errors.Is(err, ErrInvalidJustification) {
+			t.Errorf("MatchIssue(%q) got error %v, want ErrInvalidJustification", key, err)
+		}
+		if _, err := validator.Issue(ctx, key); !errors.Is(err, ErrInvalidJustification) {
+			t.Errorf("Issue(%q) got error %v, want ErrInvalidJustification", key, err)
+		}
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("got %d requests to jira, want none", got)
+	}
+}
+
+func TestValidation_ResponseTooLarge(t *testing.T) {
+	t.Parallel()
+
+	padding := strings.Repeat("x", jiraResponseSizeLimitBytes)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":"1234","key":"ABCD","padding":%q}`, padding)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	validator, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "secrets")
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	_, err = validator.MatchIssue(ctx, "ABCD")
+	if diff := testutil.DiffErrString(err, fmt.Sprintf("exceeds %d bytes", jiraResponseSizeLimitBytes)); diff != "" {
+		t.Errorf(diff)
+	}
+}
+
+func TestValidation_ErrorClasses(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		status    int
+		closed    bool
+		wantErrIs []error
+	}{
+		{
+			name:      "unauthorized",
+			status:    http.StatusUnauthorized,
+			wantErrIs: []error{ErrJiraAuth, ErrInvalidJustification},
+		},
+		{
+			name:      "forbidden",
+			status:    http.StatusForbidden,
+			wantErrIs: []error{ErrJiraAuth, ErrInvalidJustification},
+		},
+		{
+			name:      "unavailable",
+			status:    http.StatusServiceUnavailable,
+			wantErrIs: []error{ErrJiraUnreachable},
+		},
+		{
+			name:      "connection_refused",
+			closed:    true,
+			wantErrIs: []error{ErrJiraUnreachable},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			}))
+			t.Cleanup(srv.Close)
+			if tc.closed {
+				srv.Close()
+			}
+
+			v, err := NewValidator(srv.URL, "status NOT IN (Done)", "test@test.com", "secrets")
+			if err != nil {
+				t.Fatalf("failed to create validator: %v", err)
+			}
+
+			ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+			_, err = v.Myself(ctx)
+			for _, want := range tc.wantErrIs {
+				if !errors.Is(err, want) {
+					t.Errorf("Myself() got err %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPutBuffer(t *testing.T) {
+	t.Parallel()
+
+	large := bytes.NewBuffer(make([]byte, 0, 2*maxPooledBufferBytes))
+	putBuffer(large)
+
+	// A pooled buffer may be dropped at any time, but the large one must
+	// never come back out of the pool.
+	for i := 0; i < 10; i++ {
+		buf := bufferPool.Get().(*bytes.Buffer) //nolint:forcetypeassert // bufferPool only holds *bytes.Buffer
+		if buf == large || buf.Cap() > maxPooledBufferBytes {
+			t.Fatalf("got buffer with capacity %d from the pool, want at most %d", buf.Cap(), maxPooledBufferBytes)
+		}
+	}
+
+	small := bytes.NewBufferString("leftover")
+	putBuffer(small)
+	if got := small.Len(); got != 0 {
+		t.Errorf("pooled buffer has length %d, want it reset to 0", got)
+	}
+}
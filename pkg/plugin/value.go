@@ -0,0 +1,113 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// defaultMaxValueLength is the maximum number of characters of a
+	// justification value when [PluginConfig.MaxValueLength] is not set.
+	defaultMaxValueLength = 512
+
+	// ValueCharsetPrintable accepts justification values of valid UTF-8
+	// without control characters.
+	ValueCharsetPrintable = "printable"
+
+	// ValueCharsetASCII only accepts justification values of printable ASCII
+	// characters, i.e. from space to tilde.
+	ValueCharsetASCII = "ascii"
+)
+
+// valueLimits bound the justification values accepted, see
+// [PluginConfig.MaxValueLength] and [PluginConfig.ValueCharset]. The zero
+// value has the default limits.
+type valueLimits struct {
+	maxLength int
+	charset   string
+}
+
+// max returns the maximum number of characters of a value.
+func (l valueLimits) max() int {
+	if l.maxLength <= 0 {
+		return defaultMaxValueLength
+	}
+	return l.maxLength
+}
+
+// check returns the error code and the reason the value is rejected for, or
+// "" when it is within the limits. It runs before the value is parsed or
+// logged, so it looks at each character at most once.
+func (l valueLimits) check(value string) (string, error) {
+	maxLength, n := l.max(), 0
+	for i, r := range value {
+		n++
+		if n > maxLength {
+			return ErrorCodeValueTooLong, fmt.Errorf("justification value is longer than %d characters", maxLength)
+		}
+		if r == utf8.RuneError && !validRuneAt(value, i) {
+			return ErrorCodeInvalidCharacters, fmt.Errorf("justification value is not valid UTF-8 at byte %d", i)
+		}
+		if !l.allowed(r) {
+			return ErrorCodeInvalidCharacters, fmt.Errorf("justification value contains the character %s at byte %d, "+
+				"only %s characters are allowed", strconv.QuoteRuneToASCII(r), i, l.charsetName())
+		}
+	}
+	return "", nil
+}
+
+// charsetName returns the name of the charset for messages.
+func (l valueLimits) charsetName() string {
+	if l.charset == "" {
+		return ValueCharsetPrintable
+	}
+	return l.charset
+}
+
+// allowed reports whether the charset holds r.
+func (l valueLimits) allowed(r rune) bool {
+	if l.charset == ValueCharsetASCII {
+		return r >= ' ' && r <= '~'
+	}
+	return !unicode.IsControl(r)
+}
+
+// truncate shortens a value over the maximum length, so that it can be
+// logged and audited without flooding the logs.
+func (l valueLimits) truncate(value string) string {
+	maxLength := l.max()
+	if len(value) <= maxLength {
+		return value
+	}
+	n := 0
+	for i := range value {
+		if n == maxLength {
+			return value[:i] + "...(truncated)"
+		}
+		n++
+	}
+	return value
+}
+
+// validRuneAt reports whether the rune at byte i of s is encoded properly,
+// i.e. it is the replacement character itself rather than an invalid byte.
+func validRuneAt(s string, i int) bool {
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return size > 1
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestValueLimits_Check(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name     string
+		limits   valueLimits
+		value    string
+		wantCode string
+		wantErr  string
+	}{
+		{
+			name:  "default",
+			value: strings.Repeat("A", defaultMaxValueLength),
+		},
+		{
+			name:     "default_too_long",
+			value:    strings.Repeat("A", defaultMaxValueLength+1),
+			wantCode: ErrorCodeValueTooLong,
+			wantErr:  "justification value is longer than 512 characters",
+		},
+		{
+			name:   "counts_characters",
+			limits: valueLimits{maxLength: 3},
+			value:  "äöü",
+		},
+		{
+			name:     "too_long",
+			limits:   valueLimits{maxLength: 3},
+			value:    "ABCD-1",
+			wantCode: ErrorCodeValueTooLong,
+			wantErr:  "justification value is longer than 3 characters",
+		},
+		{
+			name:  "unicode",
+			value: `{"issue": "ABCD-1", "reason": "Störung ☎"}`,
+		},
+		{
+			name:     "control_character",
+			value:    "ABCD-1\x1b[2J",
+			wantCode: ErrorCodeInvalidCharacters,
+			wantErr:  `contains the character '\x1b' at byte 6, only printable characters are allowed`,
+		},
+		{
+			name:     "c1_control_character",
+			value:    "ABCD-1\u0085",
+			wantCode: ErrorCodeInvalidCharacters,
+			wantErr:  `contains the character '\u0085' at byte 6`,
+		},
+		{
+			name:     "invalid_utf8",
+			value:    "ABCD-\xff",
+			wantCode: ErrorCodeInvalidCharacters,
+			wantErr:  "justification value is not valid UTF-8 at byte 5",
+		},
+		{
+			name:  "replacement_character",
+			value: "ABCD-1 �",
+		},
+		{
+			name:   "ascii",
+			limits: valueLimits{charset: ValueCharsetASCII},
+			value:  "ABCD-1, ABCD-2 ~",
+		},
+		{
+			name:     "ascii_unicode",
+			limits:   valueLimits{charset: ValueCharsetASCII},
+			value:    "ABCD-1 ☎",
+			wantCode: ErrorCodeInvalidCharacters,
+			wantErr:  `contains the character '\u260e' at byte 7, only ascii characters are allowed`,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			code, err := tc.limits.check(tc.value)
+			if got, want := code, tc.wantCode; got != want {
+				t.Errorf("check() got code %q, want %q", got, want)
+			}
+			if diff := testutil.DiffErrString(err, tc.wantErr); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestValueLimits_Truncate(t *testing.T) {
+	t.Parallel()
+
+	l := valueLimits{maxLength: 3}
+	for value, want := range map[string]string{
+		"ABC":    "ABC",
+		"ABCD-1": "ABC...(truncated)",
+		"äöüß":   "äöü...(truncated)",
+	} {
+		if got := l.truncate(value); got != want {
+			t.Errorf("truncate(%q) got %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// browseProjectsPermission is the Jira permission to see a project and its
+// issues.
+const browseProjectsPermission = "BROWSE_PROJECTS"
+
+// projectKeyPattern matches a jira project key.
+var projectKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// permissionsData is the response of the [my permissions request].
+//
+// [my permissions request]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-permissions/#api-rest-api-3-mypermissions-get
+type permissionsData struct {
+	Permissions map[string]struct {
+		HavePermission bool `json:"havePermission"`
+	} `json:"permissions"`
+}
+
+// HasProjectPermission reports whether the account has the permission, e.g.
+// "BROWSE_PROJECTS", in the project.
+func (v *Validator) HasProjectPermission(ctx context.Context, projectKey, permission string) (bool, error) {
+	// Construct [Get My Permissions API].
+	//
+	// [Get My Permissions API]: https://developer.atlassian.com/cloud/jira/platform/rest/v3/api-group-permissions/#api-rest-api-3-mypermissions-get
+	u := v.endpointURL("mypermissions")
+	q := u.Query()
+	q.Set("projectKey", projectKey)
+	q.Set("permissions", permission)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to construct permissions request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	var data permissionsData
+	if err := v.makeRequest(req, &data); err != nil {
+		return false, err
+	}
+	return data.Permissions[permission].HavePermission, nil
+}
+
+// err returns the parse errors of the queries, or nil when all of them
+// parsed.
+func (r *ParseResult) err() error {
+	var merr error
+	for _, q := range r.Queries {
+		if len(q.Errors) > 0 {
+			merr = errors.Join(merr, fmt.Errorf("failed to parse JQL %q: %s", q.Query, strings.Join(q.Errors, "; ")))
+		}
+	}
+	return merr
+}
+
+// startupCheck is a named check run by [JiraPlugin.Warmup].
+type startupCheck struct {
+	name string
+	run  func(context.Context) error
+}
+
+// warmupChecks returns the startup checks of [JiraPlugin.Warmup]: the
+// credentials, the JQL, and the browse permission in each warmup project.
+func warmupChecks(v *Validator, projects []string) []*startupCheck {
+	checks := []*startupCheck{
+		{
+			name: "credentials",
+			run: func(ctx context.Context) error {
+				_, err := v.Myself(ctx)
+				return err
+			},
+		},
+		{
+			name: "jql",
+			run: func(ctx context.Context) error {
+				result, err := v.ParseJQL(ctx)
+				if err != nil {
+					return err
+				}
+				if err := result.err(); err != nil {
+					return fmt.Errorf("%w: %w", err, ErrInvalidConfig)
+				}
+				return nil
+			},
+		},
+	}
+	for _, project := range projects {
+		project := project
+		checks = append(checks, &startupCheck{
+			name: "project " + project,
+			run: func(ctx context.Context) error {
+				ok, err := v.HasProjectPermission(ctx, project, browseProjectsPermission)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return fmt.Errorf("account cannot browse project %q", project)
+				}
+				return nil
+			},
+		})
+	}
+	return checks
+}
+
+// runChecks runs the checks concurrently and returns the errors of the
+// failed ones in the order of the checks, each prefixed with the name of the
+// check.
+func runChecks(ctx context.Context, checks []*startupCheck) error {
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		i, c := i, c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.run(ctx); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", c.name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,74 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abcxyz/pkg/testutil"
+)
+
+func TestRunChecks(t *testing.T) {
+	t.Parallel()
+
+	// Every check waits for all of them to start, so they only finish when
+	// they run concurrently.
+	var started sync.WaitGroup
+	started.Add(3)
+	check := func(err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			started.Done()
+			started.Wait()
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runChecks(ctx, []*startupCheck{
+			{name: "first", run: check(fmt.Errorf("unreachable"))},
+			{name: "second", run: check(nil)},
+			{name: "third", run: check(fmt.Errorf("denied"))},
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if diff := testutil.DiffErrString(err, "first: unreachable\nthird: denied"); diff != "" {
+			t.Errorf(diff)
+		}
+	case <-ctx.Done():
+		t.Fatal("checks did not run concurrently")
+	}
+}
+
+func TestParseResult_Err(t *testing.T) {
+	t.Parallel()
+
+	r := &ParseResult{Queries: []*ParsedJQL{
+		{Query: "project = JRA"},
+		{Query: "project = = JRA", Errors: []string{"Error in the JQL Query", "Expecting a value"}},
+	}}
+	if diff := testutil.DiffErrString(r.err(), `failed to parse JQL "project = = JRA": Error in the JQL Query; Expecting a value`); diff != "" {
+		t.Errorf(diff)
+	}
+}
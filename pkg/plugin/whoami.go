@@ -0,0 +1,99 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// JiraIdentity is the Jira account a validator authenticates as, with the
+// details Jira admins need to grant it permissions.
+type JiraIdentity struct {
+	// Endpoint is the REST API endpoint the account was asked for.
+	Endpoint string `json:"endpoint"`
+
+	AccountID    string `json:"account_id"`
+	EmailAddress string `json:"email_address,omitempty"`
+	DisplayName  string `json:"display_name,omitempty"`
+	Active       bool   `json:"active"`
+
+	// Groups are the names of the groups of the account, sorted by Jira.
+	Groups []string `json:"groups"`
+}
+
+// myselfWithGroups is the current user expanded with the groups.
+type myselfWithGroups struct {
+	JiraUser
+	Groups struct {
+		Items []struct {
+			Name string `json:"name"`
+		} `json:"items"`
+	} `json:"groups"`
+}
+
+// WhoAmI returns the identity of the account the validator authenticates
+// as, including its groups. Email addresses hidden by the privacy settings
+// of the account are empty.
+func (v *Validator) WhoAmI(ctx context.Context) (*JiraIdentity, error) {
+	u := v.endpointURL("myself")
+	q := u.Query()
+	q.Set("expand", "groups")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct myself request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	var user myselfWithGroups
+	if err := v.makeRequest(req, &user); err != nil {
+		return nil, err
+	}
+
+	id := &JiraIdentity{
+		Endpoint:     v.baseURL.String(),
+		AccountID:    user.AccountID,
+		EmailAddress: user.EmailAddress,
+		DisplayName:  user.DisplayName,
+		Active:       user.Active,
+		Groups:       make([]string, 0, len(user.Groups.Items)),
+	}
+	for _, g := range user.Groups.Items {
+		id.Groups = append(id.Groups, g.Name)
+	}
+	return id, nil
+}
+
+// WhoAmI returns the identity of the account the plugin authenticates as
+// with its current configuration.
+func (j *JiraPlugin) WhoAmI(ctx context.Context) (*JiraIdentity, error) {
+	if !j.life.acquire() {
+		return nil, ErrClosed
+	}
+	defer j.life.release()
+
+	s := j.current.Load()
+	if s.jira == nil {
+		return nil, fmt.Errorf("no jira validator configured")
+	}
+	v, err := s.jira.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return v.WhoAmI(ctx)
+}
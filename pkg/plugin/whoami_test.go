@@ -0,0 +1,88 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/abcxyz/pkg/logging"
+)
+
+func TestWhoAmI(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	p, err := NewJiraPluginWithToken(ctx, f.config(), "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	t.Cleanup(func() { p.Close(ctx) })
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	p.RegisterAdmin(srv)
+	go srv.Serve(lis) //nolint:errcheck // Stopped in cleanup
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	got, err := WhoAmI(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &JiraIdentity{
+		Endpoint:     f.config().JIRAEndpoint,
+		AccountID:    "1",
+		EmailAddress: "test@test.com",
+		DisplayName:  "JVS",
+		Active:       true,
+		Groups:       []string{"jira-users", "jvs-bots"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("WhoAmI (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWhoAmI_Closed(t *testing.T) {
+	t.Parallel()
+
+	ctx := logging.WithLogger(context.Background(), logging.TestLogger(t))
+	f := newFakeJira(t)
+	p, err := NewJiraPluginWithToken(ctx, f.config(), "secrets")
+	if err != nil {
+		t.Fatalf("failed to create plugin: %v", err)
+	}
+	if err := p.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.WhoAmI(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("WhoAmI() got err %v, want %v", err, ErrClosed)
+	}
+}